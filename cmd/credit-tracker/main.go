@@ -10,16 +10,20 @@ import (
 	"os/signal"
 	"runtime/debug"
 	"strconv"
+	"time"
 
 	// import docs for swagger generation.
 	_ "github.com/DIMO-Network/credit-tracker/docs"
 	"github.com/DIMO-Network/credit-tracker/internal/app"
 	"github.com/DIMO-Network/credit-tracker/internal/config"
+	"github.com/DIMO-Network/credit-tracker/internal/tracing"
 	"github.com/DIMO-Network/credit-tracker/pkg/migrations"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 )
@@ -52,22 +56,37 @@ func main() {
 			return
 		}
 	}
+	shutdownTracing, err := setupTracing(ctx, settings, &logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to set up tracing.")
+	}
+	defer shutdownTracing()
 	monApp := CreateMonitoringServer(strconv.Itoa(settings.MonPort), &logger)
 	group, gCtx := errgroup.WithContext(ctx)
 
-	webServer, rpcServer, err := app.CreateServers(ctx, settings)
+	webServer, rpcServer, workers, closeServers, err := app.CreateServers(ctx, settings)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to create servers.")
 	}
 
 	logger.Info().Str("port", strconv.Itoa(settings.GRPCPort)).Msgf("Starting gRPC server")
-	runGRPC(gCtx, rpcServer, ":"+strconv.Itoa(settings.GRPCPort), group)
+	runGRPC(gCtx, rpcServer, ":"+strconv.Itoa(settings.GRPCPort), settings.ShutdownTimeout, group)
 	logger.Info().Str("port", strconv.Itoa(settings.MonPort)).Msgf("Starting monitoring server")
 	runFiber(gCtx, monApp, ":"+strconv.Itoa(settings.MonPort), group)
 	logger.Info().Str("port", strconv.Itoa(settings.Port)).Msgf("Starting web server")
 	runFiber(gCtx, webServer, ":"+strconv.Itoa(settings.Port), group)
+	for _, worker := range workers {
+		group.Go(func() error {
+			return worker(gCtx)
+		})
+	}
 
-	if err := group.Wait(); err != nil {
+	err = group.Wait()
+	// the repository is drained and the database closed only once the servers and workers stopped using them
+	if closeErr := closeServers(context.WithoutCancel(ctx)); closeErr != nil {
+		logger.Error().Err(closeErr).Msg("Failed to close servers.")
+	}
+	if err != nil {
 		logger.Fatal().Err(err).Msg("Server failed.")
 	}
 	logger.Info().Msg("Server stopped.")
@@ -89,7 +108,9 @@ func runFiber(ctx context.Context, fiberApp *fiber.App, addr string, group *errg
 	})
 }
 
-func runGRPC(ctx context.Context, grpcServer *grpc.Server, addr string, group *errgroup.Group) {
+// runGRPC serves gRPC until the context is done, then waits up to the shutdown timeout for the running RPCs
+// before cancelling the ones left, so their operations roll back.
+func runGRPC(ctx context.Context, grpcServer *grpc.Server, addr string, shutdownTimeout time.Duration, group *errgroup.Group) {
 	group.Go(func() error {
 		lis, err := net.Listen("tcp", addr)
 		if err != nil {
@@ -102,11 +123,42 @@ func runGRPC(ctx context.Context, grpcServer *grpc.Server, addr string, group *e
 	})
 	group.Go(func() error {
 		<-ctx.Done()
-		grpcServer.GracefulStop()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(shutdownTimeout):
+			grpcServer.Stop()
+			<-stopped
+		}
 		return nil
 	})
 }
 
+// setupTracing propagates the W3C trace context of incoming requests and, when tracing is enabled,
+// exports spans to the OTLP collector. The returned function flushes the spans that are not exported yet.
+func setupTracing(ctx context.Context, settings *config.Settings, logger *zerolog.Logger) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !settings.TracingEnabled {
+		return func() {}, nil
+	}
+	provider, err := tracing.NewTracerProvider(ctx, "credit-tracker")
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(provider)
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(shutdownCtx); err != nil {
+			logger.Error().Err(err).Msg("Failed to flush traces.")
+		}
+	}, nil
+}
+
 func CreateMonitoringServer(port string, logger *zerolog.Logger) *fiber.App {
 	monApp := fiber.New(fiber.Config{DisableStartupMessage: true})
 
@@ -39,14 +39,140 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/credits/{licenseId}/assets/{assetId}/usage": {
+        "/v1/admin/credits/{licenseId}/assets/{assetId}/reconciliation": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Admin only. Compute the balance of a license and asset from the remaining amounts of its grants and by replaying the operation ledger,\nand report the discrepancy between the two. Both cover every grant of the asset regardless of status or expiration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reconcile License Asset Balance",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Asset DID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers_httphandlers.BalanceReconciliation"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/credits/{licenseId}/pending-grants": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Admin only. Get the number, credits, and oldest creation time of the pending grants of a license, across every asset unless assetId is set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get Pending Grant Stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Asset DID",
+                        "name": "assetId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.PendingGrantStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/admin/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Admin only. List the open database transactions that have been running for at least minDuration, including the backends blocking them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get Long Running Transactions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Minimum transaction duration (e.g. 5s), defaults to 1s",
+                        "name": "minDuration",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.TransactionDiagnostic"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/assets/{assetId}/debt": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get detailed usage report for a specific license and asset",
+                "description": "Get the outstanding debt of a license and asset, the credits owed from failed grants that must be settled before credits can be spent again",
                 "consumes": [
                     "application/json"
                 ],
@@ -56,7 +182,7 @@ const docTemplate = `{
                 "tags": [
                     "Credits"
                 ],
-                "summary": "Get License Asset Usage Report",
+                "summary": "Get License Asset Debt",
                 "parameters": [
                     {
                         "type": "string",
@@ -68,21 +194,76 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "description": "Asset DID",
-                        "name": "assetDID",
+                        "name": "assetId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controllers_httphandlers.Debt"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/assets/{assetId}/grants": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the grants backing the balance of a license and asset, in the order deductions use them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "List License Asset Grants",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "From Date",
-                        "name": "fromDate",
-                        "in": "query",
+                        "description": "Asset DID",
+                        "name": "assetId",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "To Date",
-                        "name": "toDate",
+                        "description": "Only return grants with this status (pending, confirmed, failed, expired)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include expired grants",
+                        "name": "includeExpired",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of grants to return, defaults to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of grants to skip",
+                        "name": "offset",
                         "in": "query"
                     }
                 ],
@@ -90,30 +271,34 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseAssetUsageReport"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/internal_controllers_httphandlers.Grant"
+                            }
                         }
                     }
                 }
             }
         },
-        "/v1/credits/{licenseId}/usage": {
+        "/v1/credits/{licenseId}/assets/{assetId}/usage": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get usage report for a license across all assets",
+                "description": "Get detailed usage report for a specific license and asset. As CSV the report is a single row without the confirmed grants.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
                     "Credits"
                 ],
-                "summary": "Get License Usage Report",
+                "summary": "Get License Asset Usage Report",
                 "parameters": [
                     {
                         "type": "string",
@@ -124,71 +309,733 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "From Date",
-                        "name": "fromDate",
-                        "in": "query",
+                        "description": "Asset DID",
+                        "name": "assetDID",
+                        "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "From Date, required without a window",
+                        "name": "fromDate",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "To Date",
                         "name": "toDate",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Report the window ending now instead of fromDate and toDate, such as 30d, 720h, or P30D",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the tx hashes of grants confirmed during the period",
+                        "name": "includeGrantTxHashes",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format, json or csv, overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseUsageReport"
+                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseAssetUsageReport"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseAssetUsageReport": {
-            "type": "object",
-            "properties": {
-                "assetDid": {
-                    "description": "Asset DID",
-                    "type": "string"
-                },
-                "currentCreditsRemaining": {
-                    "description": "Number of credits remaining at the current time, this is not affected by the time period",
-                    "type": "integer"
-                },
-                "fromDate": {
-                    "description": "From date",
-                    "type": "string"
-                },
-                "licenseId": {
-                    "description": "License ID",
-                    "type": "string"
-                },
-                "numOfCreditsGrantsPurchased": {
-                    "description": "Number of credit grants purchased during the time period",
-                    "type": "integer"
-                },
-                "numOfCreditsUsed": {
-                    "description": "Number of credits used during the time period",
-                    "type": "integer"
-                },
-                "toDate": {
-                    "description": "To date",
-                    "type": "string"
+        },
+        "/v1/credits/{licenseId}/balances": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the cached balance and debt of every asset for a license",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Get License Balances",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.BalanceSummary"
+                            }
+                        }
+                    }
                 }
             }
         },
-        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseUsageReport": {
-            "type": "object",
-            "properties": {
-                "fromDate": {
-                    "description": "From date",
-                    "type": "string"
-                },
-                "licenseId": {
+        "/v1/credits/{licenseId}/balances/refresh": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Recompute the cached balance and debt of every asset for a license from its grants",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Refresh License Balances",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.BalanceSummary"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/debts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get every asset of a license with outstanding debt, ordered by asset DID. Deductions of these assets are refused until their debt is settled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Get License Debts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.AssetDebt"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/operations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the credit operations of a license, newest first, with the grants each operation touched.\nRefunds share the reference ID of the deduction they refund.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Get License Operation History",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only return operations of this asset",
+                        "name": "assetDid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From Date",
+                        "name": "fromDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To Date",
+                        "name": "toDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated operation types to return, such as deduction,refund",
+                        "name": "operationTypes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of operations to return, defaults to 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of operations to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "nextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.OperationHistoryPage"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/operations/{referenceId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the operations of a license with the reference ID, oldest first, with the grants each operation touched,\nsuch as a deduction and its refunds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Get License Operation By Reference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reference ID",
+                        "name": "referenceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.OperationRecord"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/recent-operations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the most recent credit operations of a license across all assets, newest first, without the grants each operation touched.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Get License Recent Operations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of operations to return, defaults to 10 and is capped at 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.RecentOperation"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/snapshot": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the balance, debt, pending grants, soonest expiration, and recent operations of a license, in total and per asset.\nEverything is read at a single point in time, so the totals are the sums of the assets.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Get License Account Snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.AccountSnapshot"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/summary": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the total balance and debt of a license across all of its assets, and how many assets have credits or debt.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Get License Summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseSummary"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/credits/{licenseId}/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get usage report for a license across all assets. As CSV the report is a single summary row with the refunds of every reason totaled,\nor one row per asset when the per-asset breakdown is included.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Credits"
+                ],
+                "summary": "Get License Usage Report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "License ID",
+                        "name": "licenseId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From Date, required without a window",
+                        "name": "fromDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To Date",
+                        "name": "toDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Report the window ending now instead of fromDate and toDate, such as 30d, 720h, or P30D",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the usage of each asset accessed during the period",
+                        "name": "includePerAsset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format, json or csv, overrides the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseUsageReport"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.AccountSnapshot": {
+            "type": "object",
+            "properties": {
+                "assets": {
+                    "description": "State of every asset with grants, ordered by asset DID",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.AssetSnapshot"
+                    }
+                },
+                "balance": {
+                    "description": "Spendable credits of every asset",
+                    "type": "integer"
+                },
+                "debt": {
+                    "description": "Outstanding debt of every asset",
+                    "type": "integer"
+                },
+                "licenseId": {
+                    "description": "License ID",
+                    "type": "string"
+                },
+                "nextExpiration": {
+                    "description": "Soonest expiration of a grant with spendable credits, nil when no such grant expires",
+                    "type": "string"
+                },
+                "pendingGrants": {
+                    "description": "Pending grants of every asset",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.PendingGrantStats"
+                        }
+                    ]
+                },
+                "recentOperations": {
+                    "description": "Most recent operations across all assets, newest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.RecentOperation"
+                    }
+                },
+                "takenAt": {
+                    "description": "When the snapshot was taken",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.AssetDebt": {
+            "type": "object",
+            "properties": {
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "debt": {
+                    "description": "Credits owed from failed grants, deductions of the asset are refused until they are settled",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.AssetSnapshot": {
+            "type": "object",
+            "properties": {
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "balance": {
+                    "description": "Spendable credits from active grants",
+                    "type": "integer"
+                },
+                "debt": {
+                    "description": "Outstanding debt from failed grants",
+                    "type": "integer"
+                },
+                "nextExpiration": {
+                    "description": "Soonest expiration of a grant with spendable credits, nil when no such grant expires",
+                    "type": "string"
+                },
+                "pendingGrants": {
+                    "description": "Pending grants of the asset",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.PendingGrantStats"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.AssetUsage": {
+            "type": "object",
+            "properties": {
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "numOfCreditsGrantsPurchased": {
+                    "description": "Number of credit grants purchased during the time period",
+                    "type": "integer"
+                },
+                "numOfCreditsUsed": {
+                    "description": "Number of credits used during the time period",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.BalanceSummary": {
+            "type": "object",
+            "properties": {
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "balance": {
+                    "description": "Spendable credits from active grants",
+                    "type": "integer"
+                },
+                "debt": {
+                    "description": "Outstanding debt from failed grants",
+                    "type": "integer"
+                },
+                "licenseId": {
+                    "description": "License ID",
+                    "type": "string"
+                },
+                "refreshedAt": {
+                    "description": "When the summary was last recomputed",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.ConfirmedGrant": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Number of credits granted",
+                    "type": "integer"
+                },
+                "confirmedAt": {
+                    "description": "When the grant was confirmed",
+                    "type": "string"
+                },
+                "txHash": {
+                    "description": "Transaction hash of the burn that created the grant",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseAssetUsageReport": {
+            "type": "object",
+            "properties": {
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "confirmedGrants": {
+                    "description": "Grants confirmed during the time period, only included when requested",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.ConfirmedGrant"
+                    }
+                },
+                "currentCreditsRemaining": {
+                    "description": "Number of credits remaining at the current time, this is not affected by the time period",
+                    "type": "integer"
+                },
+                "fromDate": {
+                    "description": "From date",
+                    "type": "string"
+                },
+                "licenseId": {
+                    "description": "License ID",
+                    "type": "string"
+                },
+                "numOfCreditsGranted": {
+                    "description": "Number of credits granted by grants confirmed during the time period",
+                    "type": "integer"
+                },
+                "numOfCreditsGrantsPurchased": {
+                    "description": "Number of credit grants purchased during the time period",
+                    "type": "integer"
+                },
+                "numOfCreditsUsed": {
+                    "description": "Number of credits used during the time period",
+                    "type": "integer"
+                },
+                "projectedExhaustion": {
+                    "description": "When the remaining credits run out at the usage rate of the time period, null if there was no usage",
+                    "type": "string"
+                },
+                "toDate": {
+                    "description": "To date",
+                    "type": "string"
+                },
+                "utilizationRate": {
+                    "description": "Fraction of the credits granted during the time period that were used, null if nothing was granted",
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseSummary": {
+            "type": "object",
+            "properties": {
+                "activeAssets": {
+                    "description": "Assets with spendable credits",
+                    "type": "integer"
+                },
+                "assets": {
+                    "description": "Assets with grants of any status",
+                    "type": "integer"
+                },
+                "assetsInDebt": {
+                    "description": "Assets with outstanding debt, an asset in debt can still have spendable credits",
+                    "type": "integer"
+                },
+                "balance": {
+                    "description": "Spendable credits of every asset",
+                    "type": "integer"
+                },
+                "debt": {
+                    "description": "Outstanding debt of every asset",
+                    "type": "integer"
+                },
+                "licenseId": {
+                    "description": "License ID",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.LicenseUsageReport": {
+            "type": "object",
+            "properties": {
+                "fromDate": {
+                    "description": "From date",
+                    "type": "string"
+                },
+                "licenseId": {
                     "description": "License ID",
                     "type": "string"
                 },
@@ -204,11 +1051,268 @@ const docTemplate = `{
                     "description": "Number of credits used during the time period",
                     "type": "integer"
                 },
+                "perAsset": {
+                    "description": "Usage of each asset accessed during the time period, only included when requested",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.AssetUsage"
+                    }
+                },
+                "refundsByReason": {
+                    "description": "Refunds during the time period grouped by reason code",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.RefundReasonTotal"
+                    }
+                },
                 "toDate": {
                     "description": "To date",
                     "type": "string"
                 }
             }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.OperationGrantRecord": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Number of credits applied to the grant",
+                    "type": "integer"
+                },
+                "grantId": {
+                    "description": "Grant ID",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.OperationHistoryPage": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "description": "Cursor for the next page, empty when there are no more operations",
+                    "type": "string"
+                },
+                "operations": {
+                    "description": "Operations newest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.OperationRecord"
+                    }
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.OperationRecord": {
+            "type": "object",
+            "properties": {
+                "appName": {
+                    "description": "Name of the app that made the operation",
+                    "type": "string"
+                },
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "description": "When the operation was made",
+                    "type": "string"
+                },
+                "grants": {
+                    "description": "Credits taken from or returned to each grant",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_DIMO-Network_credit-tracker_internal_creditrepo.OperationGrantRecord"
+                    }
+                },
+                "operationType": {
+                    "description": "Type of the operation, one of deduction, refund, grant_purchase, grant_confirm, debt_settlement or expiration",
+                    "type": "string"
+                },
+                "referenceId": {
+                    "description": "Reference ID of the operation, refunds share the reference ID of the deduction they refund",
+                    "type": "string"
+                },
+                "totalAmount": {
+                    "description": "Total number of credits of the operation",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.PendingGrantStats": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Number of pending grants",
+                    "type": "integer"
+                },
+                "oldestCreatedAt": {
+                    "description": "Creation time of the oldest pending grant, nil without pending grants",
+                    "type": "string"
+                },
+                "totalAmount": {
+                    "description": "Credits of the pending grants",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.RecentOperation": {
+            "type": "object",
+            "properties": {
+                "appName": {
+                    "description": "Name of the app that made the operation",
+                    "type": "string"
+                },
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "description": "When the operation was made",
+                    "type": "string"
+                },
+                "operationType": {
+                    "description": "Type of the operation",
+                    "type": "string"
+                },
+                "referenceId": {
+                    "description": "Reference ID of the operation",
+                    "type": "string"
+                },
+                "totalAmount": {
+                    "description": "Total number of credits of the operation",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.RefundReasonTotal": {
+            "type": "object",
+            "properties": {
+                "numOfCreditsRefunded": {
+                    "description": "Number of credits refunded",
+                    "type": "integer"
+                },
+                "numOfRefunds": {
+                    "description": "Number of refunds",
+                    "type": "integer"
+                },
+                "reasonCode": {
+                    "description": "Reason code of the refunds, empty for refunds without a reason",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_DIMO-Network_credit-tracker_internal_creditrepo.TransactionDiagnostic": {
+            "type": "object",
+            "properties": {
+                "blockedBy": {
+                    "description": "Backends holding locks this transaction is waiting for",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "durationSeconds": {
+                    "description": "How long the transaction has been open in seconds",
+                    "type": "number"
+                },
+                "pid": {
+                    "description": "Backend process ID",
+                    "type": "integer"
+                },
+                "query": {
+                    "description": "Most recent query of the backend",
+                    "type": "string"
+                },
+                "startedAt": {
+                    "description": "When the transaction started",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "Backend state (active, idle in transaction, ...)",
+                    "type": "string"
+                },
+                "waitEvent": {
+                    "description": "Event the backend is waiting for, if any",
+                    "type": "string"
+                },
+                "waitEventType": {
+                    "description": "Type of event the backend is waiting for, if any",
+                    "type": "string"
+                },
+                "waitingLocks": {
+                    "description": "Number of locks this transaction is waiting for",
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controllers_httphandlers.BalanceReconciliation": {
+            "type": "object",
+            "properties": {
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "discrepancy": {
+                    "description": "Grant balance minus ledger balance, zero when the grants match the ledger",
+                    "type": "integer"
+                },
+                "grantBalance": {
+                    "description": "Sum of the remaining amounts of the grants",
+                    "type": "integer"
+                },
+                "ledgerBalance": {
+                    "description": "Initial amounts of the grants with every recorded grant allocation applied",
+                    "type": "integer"
+                },
+                "licenseId": {
+                    "description": "License ID",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers_httphandlers.Debt": {
+            "type": "object",
+            "properties": {
+                "assetDid": {
+                    "description": "Asset DID",
+                    "type": "string"
+                },
+                "debt": {
+                    "description": "Credits owed from failed grants, deductions are refused until they are settled",
+                    "type": "integer"
+                },
+                "licenseId": {
+                    "description": "License ID",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controllers_httphandlers.Grant": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "description": "When the grant expires, null for perpetual grants that never expire",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Grant ID",
+                    "type": "string"
+                },
+                "initialAmount": {
+                    "description": "Number of credits granted",
+                    "type": "integer"
+                },
+                "remainingAmount": {
+                    "description": "Number of credits not yet used",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Grant status: pending, confirmed, or failed",
+                    "type": "string"
+                },
+                "txHash": {
+                    "description": "Transaction hash of the burn that created the grant",
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
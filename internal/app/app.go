@@ -2,8 +2,12 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/DIMO-Network/credit-tracker/internal/auth"
 	"github.com/DIMO-Network/credit-tracker/internal/config"
@@ -12,36 +16,67 @@ import (
 	"github.com/DIMO-Network/credit-tracker/internal/controllers/rpc"
 	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
 	"github.com/DIMO-Network/credit-tracker/internal/events"
+	"github.com/DIMO-Network/credit-tracker/internal/tracing"
 	ctgrpc "github.com/DIMO-Network/credit-tracker/pkg/grpc"
 	"github.com/DIMO-Network/shared/pkg/db"
 	"github.com/DIMO-Network/shared/pkg/middleware/metrics"
+	"github.com/IBM/sarama"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/swagger"
+	"github.com/golang-jwt/jwt/v5"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
-// CreateServers creates a new fiber app and grpc server with the given settings.
-func CreateServers(ctx context.Context, settings *config.Settings) (*fiber.App, *grpc.Server, error) {
-	ctrl, rpcCtrl, err := createControllers(ctx, settings)
+// defaultPendingGrantCheckInterval is how often stale pending grants are failed when no interval is configured.
+const defaultPendingGrantCheckInterval = time.Minute
+
+// defaultExpiredGrantCleanupInterval is how often consumed expired grants are deleted when no interval is configured.
+const defaultExpiredGrantCleanupInterval = time.Hour
+
+// Worker is a background job that runs until its context is done.
+type Worker func(ctx context.Context) error
+
+// Closer releases the resources of the servers, it is called once the servers and the workers stopped.
+type Closer func(ctx context.Context) error
+
+// CreateServers creates a new fiber app, grpc server, the background workers to run alongside them,
+// and the closer to call once they all stopped with the given settings.
+func CreateServers(ctx context.Context, settings *config.Settings) (*fiber.App, *grpc.Server, []Worker, Closer, error) {
+	ctrl, rpcCtrl, workers, closer, err := createControllers(ctx, settings)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, nil, err
 	}
-	app := setupHttpServer(ctx, settings, ctrl)
-	rpc := setupRPCServer(settings, rpcCtrl)
-	return app, rpc, nil
+	app, err := setupHttpServer(ctx, settings, ctrl)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	keyFunc, err := auth.NewJWKSKeyfunc(ctx, settings.JWKKeySetURL, settings.JWKSRefreshInterval)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to create rpc auth: %w", err)
+	}
+	rpc, healthServer := setupRPCServer(settings, rpcCtrl, keyFunc)
+	workers = append(workers, healthShutdownWorker(healthServer), watchShutdownWorker(rpcCtrl))
+	return app, rpc, workers, closer, nil
 }
 
-func setupHttpServer(ctx context.Context, settings *config.Settings, ctrl *httphandlers.HTTPController) *fiber.App {
+func setupHttpServer(ctx context.Context, settings *config.Settings, ctrl *httphandlers.HTTPController) (*fiber.App, error) {
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			return ErrorHandler(c, err)
-		},
+		ErrorHandler:          ctrlerrors.ErrorHandler,
 		DisableStartupMessage: true,
 	})
 	logger := zerolog.Ctx(ctx)
@@ -51,6 +86,9 @@ func setupHttpServer(ctx context.Context, settings *config.Settings, ctrl *httph
 		c.SetUserContext(userCtx)
 		return c.Next()
 	})
+	if settings.HTTPRequestLogging {
+		app.Use(requestLogger(*logger, settings.HTTPRequestLogQuery))
+	}
 	app.Use(recover.New(recover.Config{
 		Next:              nil,
 		EnableStackTrace:  true,
@@ -58,60 +96,139 @@ func setupHttpServer(ctx context.Context, settings *config.Settings, ctrl *httph
 	}))
 
 	app.Get("/swagger/*", swagger.HandlerDefault)
-	jwtAuth := auth.Middleware(settings)
-	app.Get("/v1/credits/:licenseId/usage", jwtAuth, ctrl.GetLicenseUsageReport)
-	app.Get("/v1/credits/:licenseId/assets/:assetId/usage", jwtAuth, ctrl.GetLicenseAssetUsageReport)
+	jwtAuth, err := auth.Middleware(ctx, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth middleware: %w", err)
+	}
+	reportLimit := reportRateLimiter(settings)
+	app.Get("/v1/credits/:licenseId/usage", jwtAuth, reportLimit, ctrl.GetLicenseUsageReport)
+	app.Get("/v1/credits/:licenseId/assets/:assetId/usage", jwtAuth, reportLimit, ctrl.GetLicenseAssetUsageReport)
+	app.Get("/v1/credits/:licenseId/assets/:assetId/grants", jwtAuth, ctrl.ListLicenseAssetGrants)
+	app.Get("/v1/credits/:licenseId/assets/:assetId/debt", jwtAuth, ctrl.GetLicenseAssetDebt)
+	app.Get("/v1/credits/:licenseId/debts", jwtAuth, ctrl.GetLicenseDebts)
+	app.Get("/v1/credits/:licenseId/operations", jwtAuth, reportLimit, ctrl.GetLicenseOperationHistory)
+	app.Get("/v1/credits/:licenseId/recent-operations", jwtAuth, ctrl.GetLicenseRecentOperations)
+	app.Get("/v1/credits/:licenseId/operations/:referenceId", jwtAuth, ctrl.GetLicenseOperationByReference)
+	app.Get("/v1/credits/:licenseId/balances", jwtAuth, reportLimit, ctrl.GetLicenseBalances)
+	app.Get("/v1/credits/:licenseId/snapshot", jwtAuth, ctrl.GetLicenseAccountSnapshot)
+	app.Get("/v1/credits/:licenseId/summary", jwtAuth, ctrl.GetLicenseSummary)
+	app.Post("/v1/credits/:licenseId/balances/refresh", jwtAuth, reportLimit, ctrl.RefreshLicenseBalances)
+
+	adminAuth := auth.AdminMiddleware(settings)
+	app.Get("/v1/admin/transactions", jwtAuth, adminAuth, ctrl.GetLongRunningTransactions)
+	app.Get("/v1/admin/credits/:licenseId/pending-grants", jwtAuth, adminAuth, ctrl.GetPendingGrantStats)
+	app.Get("/v1/admin/credits/:licenseId/assets/:assetId/reconciliation", jwtAuth, adminAuth, ctrl.ReconcileLicenseAssetBalance)
+
+	return app, nil
+}
+
+// reportRateLimiter limits report requests per license, a limit of zero disables the limiter.
+func reportRateLimiter(settings *config.Settings) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Next: func(*fiber.Ctx) bool {
+			return settings.ReportRateLimit <= 0
+		},
+		Max:        settings.ReportRateLimit,
+		Expiration: settings.ReportRateLimitWindow,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.Params("licenseId")
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			// the limiter sets the seconds until the window resets before calling this handler
+			retryAfter, _ := strconv.Atoi(c.GetRespHeader(fiber.HeaderRetryAfter))
+			return ctrlerrors.RateLimitError{RetryAfter: time.Duration(retryAfter) * time.Second}
+		},
+	})
+}
+
+// requestLogger logs the method, path, status, latency, and response size of every request, and its query params when logQuery is set.
+// Errors are handled by the app's error handler first so that the logged status is the one sent. Headers are never logged,
+// so bearer tokens stay out of the logs, and query params that look like tokens are redacted.
+func requestLogger(logger zerolog.Logger, logQuery bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		event := logger.Info().
+			Str("httpMethod", c.Method()).
+			Str("httpPath", strings.TrimPrefix(c.Path(), "/")).
+			Int("status", c.Response().StatusCode()).
+			Dur("latency", time.Since(start)).
+			Int("responseBytes", len(c.Response().Body()))
+		if logQuery {
+			query := zerolog.Dict()
+			for key, value := range c.Queries() {
+				if isSecretQueryParam(key) {
+					value = "[REDACTED]"
+				}
+				query.Str(key, value)
+			}
+			event = event.Dict("query", query)
+		}
+		event.Msg("HTTP request")
+		return nil
+	}
+}
 
-	return app
+// isSecretQueryParam returns whether a query param may carry a credential and must not be logged.
+func isSecretQueryParam(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "token") || key == "authorization" || key == "key"
 }
 
-func setupRPCServer(settings *config.Settings, rpcCtrl *rpc.CreditTrackerServer) *grpc.Server {
+// setupRPCServer creates the grpc server with the credit tracker, reflection, and health services.
+// The controllers are created after the database is ready, so the health server starts out serving.
+// Admin methods require the bearer token of an admin address, validated with the key function.
+func setupRPCServer(settings *config.Settings, rpcCtrl *rpc.CreditTrackerServer, keyFunc jwt.Keyfunc) (*grpc.Server, *health.Server) {
 	grpcPanic := metrics.GRPCPanicker{}
+	interceptors := []grpc.UnaryServerInterceptor{
+		// metrics.GRPCMetricsAndLogMiddleware(logger),
+		grpc_ctxtags.UnaryServerInterceptor(),
+		tracing.UnaryServerInterceptor(),
+		grpc_prometheus.UnaryServerInterceptor,
+		auth.AdminUnaryServerInterceptor(keyFunc, settings.AdminAddresses,
+			ctgrpc.CreditTracker_AdminAddCredits_FullMethodName, ctgrpc.CreditTracker_SelfTest_FullMethodName),
+	}
+	if settings.RPCRateLimit > 0 {
+		interceptors = append(interceptors, rpc.NewRateLimiter(settings.RPCRateLimit, settings.RPCRateLimitBurst).UnaryServerInterceptor())
+	}
+	interceptors = append(interceptors, recovery.UnaryServerInterceptor(recovery.WithRecoveryHandler(grpcPanic.GRPCPanicRecoveryHandler)))
 	server := grpc.NewServer(
-		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
-			// metrics.GRPCMetricsAndLogMiddleware(logger),
-			grpc_ctxtags.UnaryServerInterceptor(),
-			grpc_prometheus.UnaryServerInterceptor,
-			recovery.UnaryServerInterceptor(recovery.WithRecoveryHandler(grpcPanic.GRPCPanicRecoveryHandler)),
-		)),
+		// the stats handler starts a span per request, continuing the W3C trace context of the caller
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(interceptors...)),
 		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
 	)
 	ctgrpc.RegisterCreditTrackerServer(server, rpcCtrl)
-	return server
-}
-
-// ErrorHandler custom handler to log recovered errors using our logger and return json instead of string
-func ErrorHandler(ctx *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError // Default 500 statuscode
-	message := "Internal error."
-
-	var fiberErr *fiber.Error
-	var ctrlErr ctrlerrors.Error
-	if errors.As(err, &fiberErr) {
-		code = fiberErr.Code
-		message = fiberErr.Message
-	} else if errors.As(err, &ctrlErr) {
-		message = ctrlErr.ExternalMsg
-		if ctrlErr.Code != 0 {
-			code = ctrlErr.Code
-		}
-	}
+	reflection.Register(server)
 
-	// log all errors except 404
-	if code != fiber.StatusNotFound {
-		logger := zerolog.Ctx(ctx.UserContext())
-		logger.Err(err).Int("httpStatusCode", code).
-			Str("httpPath", strings.TrimPrefix(ctx.Path(), "/")).
-			Str("httpMethod", ctx.Method()).
-			Msg("caught an error from http request")
-	}
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(ctgrpc.CreditTracker_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	return server, healthServer
+}
 
-	return ctx.Status(code).JSON(codeResp{Code: code, Message: message})
+// healthShutdownWorker reports every service as not serving once its context is done,
+// so health probes stop routing to the server while it drains.
+func healthShutdownWorker(healthServer *health.Server) Worker {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		healthServer.Shutdown()
+		return nil
+	}
 }
 
-type codeResp struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+// watchShutdownWorker ends the open operation watches once its context is done, since a graceful stop waits for every stream.
+func watchShutdownWorker(rpcCtrl *rpc.CreditTrackerServer) Worker {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		rpcCtrl.StopWatches()
+		return nil
+	}
 }
 
 // HealthCheck godoc
@@ -130,16 +247,258 @@ func HealthCheck(ctx *fiber.Ctx) error {
 	return ctx.JSON(res)
 }
 
-// createControllers creates a new controllers with the given settings.
-func createControllers(ctx context.Context, settings *config.Settings) (*httphandlers.HTTPController, *rpc.CreditTrackerServer, error) {
+// createControllers creates a new controllers, background workers, and the closer of the database with the given settings.
+func createControllers(ctx context.Context, settings *config.Settings) (*httphandlers.HTTPController, *rpc.CreditTrackerServer, []Worker, Closer, error) {
 	pdb := db.NewDbConnectionFromSettings(ctx, &settings.DB, true)
 	logger := zerolog.Ctx(ctx)
 	pdb.WaitForDB(*logger)
+	configureDBPool(pdb.DBS().GetWriterConn(), settings)
 
-	repo := creditrepo.New(pdb.DBS().GetWriterConn())
-	contractProcessor := events.NewContractProcessor(repo)
-	server := rpc.NewServer(repo, contractProcessor)
+	usageOpts, err := creditrepo.NewUsageOptions(settings.UsageOperationTypes, settings.UsageReturnOperationTypes)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to create usage options: %w", err)
+	}
+	discountTiers, err := creditrepo.ParseDiscountTiers(settings.DiscountTiers)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse discount tiers: %w", err)
+	}
+	isolationLevel, err := creditrepo.ParseIsolationLevel(settings.IsolationLevel)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse isolation level: %w", err)
+	}
+	var producer sarama.SyncProducer
+	if settings.LowBalanceThreshold > 0 || settings.GrantConfirmedEvents || settings.ContractEventsDLQTopic != "" {
+		producer, err = createKafkaProducer(ctx, settings)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	repoOpts := []creditrepo.Option{
+		creditrepo.WithProjectionOptions(creditrepo.ProjectionOptions{
+			ExhaustionRounding:   settings.ExhaustionRounding,
+			UtilizationPrecision: settings.UtilizationPrecision,
+		}),
+		creditrepo.WithUsageOptions(usageOpts),
+		creditrepo.WithDepletedGrantMarking(settings.MarkDepletedGrants),
+		creditrepo.WithSummaryOnlyApps(settings.SummaryOnlyAppNames...),
+		creditrepo.WithRefundOverflowPolicy(creditrepo.RefundOverflowPolicy(settings.RefundOverflowPolicy)),
+		creditrepo.WithPendingGrantMismatchConfirmation(settings.AllowPendingGrantMismatch),
+		creditrepo.WithPendingGrantSpending(!settings.SpendConfirmedGrantsOnly),
+		creditrepo.WithDiscountTiers(discountTiers),
+		creditrepo.WithBalanceSnapshots(settings.RecordBalanceAfter),
+		creditrepo.WithLazyExpiration(settings.LazyExpiration),
+		creditrepo.WithOpTimeout(settings.OpTimeout),
+		creditrepo.WithDeadlockMaxAttempts(settings.DeadlockMaxAttempts),
+		creditrepo.WithMaxConcurrentDeductions(settings.MaxConcurrentDeductions),
+		creditrepo.WithIsolationLevel(isolationLevel),
+	}
+	if settings.GrantConfirmedEvents {
+		repoOpts = append(repoOpts, creditrepo.WithGrantConfirmedNotifier(events.NewBalancePublisher(producer, settings.GrantConfirmedTopic)))
+	}
+	repo := creditrepo.New(pdb.DBS().GetWriterConn(), repoOpts...)
+	if err := registerCollector(prometheus.DefaultRegisterer, creditrepo.NewPendingGrantCollector(repo)); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to register pending grant metrics: %w", err)
+	}
+	burner, err := createCreditBurner(ctx, settings)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	processorOpts := []events.ProcessorOption{events.WithDCXBurnedEventID(settings.DCXBurnedEventID)}
+	if settings.ContractEventsDLQTopic != "" {
+		processorOpts = append(processorOpts, events.WithDeadLetterQueue(producer, settings.ContractEventsDLQTopic))
+	}
+	contractProcessor := events.NewContractProcessor(repo, burner, processorOpts...)
+	didValidator, err := rpc.NewDIDValidator(settings.AssetDIDMethods)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to create asset DID validator: %w", err)
+	}
+	serverOpts := []rpc.ServerOption{
+		rpc.WithBurnCreditAmount(settings.BurnCreditAmount),
+		rpc.WithMaxBurnAttempts(settings.MaxBurnAttempts),
+	}
+	if settings.LowBalanceThreshold > 0 {
+		publisher := events.NewBalancePublisher(producer, settings.LowBalanceTopic)
+		serverOpts = append(serverOpts, rpc.WithLowBalanceNotifier(publisher, settings.LowBalanceThreshold))
+	}
+	server := rpc.NewServer(repo, contractProcessor, didValidator, serverOpts...)
 	ctrl := httphandlers.NewHTTPController(repo, settings)
 
-	return ctrl, server, nil
+	var workers []Worker
+	if settings.PendingGrantTimeout > 0 {
+		workers = append(workers, pendingGrantWorker(repo, settings.PendingGrantTimeout, settings.PendingGrantCheckInterval))
+	}
+	if settings.ExpiredGrantRetention > 0 {
+		workers = append(workers, expiredGrantCleanupWorker(repo, settings.ExpiredGrantRetention, settings.ExpiredGrantCleanupInterval))
+	}
+	if settings.ContractEventsTopic != "" {
+		consumerGroup, err := createKafkaConsumerGroup(settings)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		workers = append(workers, contractEventWorker(contractProcessor, consumerGroup, settings.ContractEventsTopic))
+	}
+
+	return ctrl, server, workers, drainCloser(repo, pdb.DBS().GetWriterConn(), settings.ShutdownTimeout), nil
+}
+
+// registerCollector registers a collector of an app's repository. A collector of an earlier app built in the same process,
+// as in tests, is replaced, since its repository is no longer in use.
+func registerCollector(registerer prometheus.Registerer, collector prometheus.Collector) error {
+	err := registerer.Register(collector)
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if !errors.As(err, &alreadyRegistered) {
+		return err
+	}
+	registerer.Unregister(alreadyRegistered.ExistingCollector)
+	return registerer.Register(collector)
+}
+
+// configureDBPool applies the connection pool limits of the settings to the database.
+func configureDBPool(sqlDB *sql.DB, settings *config.Settings) {
+	sqlDB.SetMaxOpenConns(settings.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(settings.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(settings.ConnMaxLifetime)
+}
+
+// drainCloser drains the repository and then closes the database, once nothing is left to use them.
+// Operations still running after the timeout are cancelled and roll back, so none is cut off by the closed database.
+func drainCloser(repo *creditrepo.Repository, sqlDB *sql.DB, timeout time.Duration) Closer {
+	return func(ctx context.Context) error {
+		drainCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := repo.Drain(drainCtx); err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Dur("timeout", timeout).Msg("Repository operations did not finish before the shutdown timeout")
+		}
+		if err := sqlDB.Close(); err != nil {
+			return fmt.Errorf("failed to close database: %w", err)
+		}
+		return nil
+	}
+}
+
+// pendingGrantWorker fails the pending grants older than the timeout every interval, a non-positive interval uses the default.
+func pendingGrantWorker(repo *creditrepo.Repository, timeout, interval time.Duration) Worker {
+	if interval <= 0 {
+		interval = defaultPendingGrantCheckInterval
+	}
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				failed, err := repo.ExpireStalePendingGrants(ctx, timeout)
+				if err != nil {
+					zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to fail stale pending grants")
+				}
+				if failed > 0 {
+					zerolog.Ctx(ctx).Warn().Int("failed", failed).Dur("timeout", timeout).Msg("Failed stale pending grants")
+				}
+			}
+		}
+	}
+}
+
+// expiredGrantCleanupWorker deletes the consumed grants expired for longer than the retention every interval, a non-positive interval uses the default.
+func expiredGrantCleanupWorker(repo *creditrepo.Repository, retention, interval time.Duration) Worker {
+	if interval <= 0 {
+		interval = defaultExpiredGrantCleanupInterval
+	}
+	return func(ctx context.Context) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				deleted, err := repo.CleanupExpiredGrants(ctx, retention)
+				if err != nil {
+					zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to clean up expired grants")
+				}
+				if deleted > 0 {
+					zerolog.Ctx(ctx).Info().Int64("deleted", deleted).Dur("retention", retention).Msg("Deleted consumed expired grants")
+				}
+			}
+		}
+	}
+}
+
+// createCreditBurner creates the burner sending credit burns to the DCX burn contract.
+// Without an Ethereum RPC URL no burner is created and credits are not burned when a license runs out.
+func createCreditBurner(ctx context.Context, settings *config.Settings) (events.CreditBurner, error) {
+	if settings.EthereumRPCURL == "" {
+		zerolog.Ctx(ctx).Warn().Msg("ETHEREUM_RPC_URL is not set, credits will not be burned")
+		return nil, nil
+	}
+	if settings.DCXBurnContractAddress == (common.Address{}) || settings.BurnerPrivateKey == "" {
+		return nil, errors.New("DCX_BURN_CONTRACT_ADDRESS and BURNER_PRIVATE_KEY are required when ETHEREUM_RPC_URL is set")
+	}
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(settings.BurnerPrivateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse burner private key: %w", err)
+	}
+	client, err := ethclient.DialContext(ctx, settings.EthereumRPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ethereum rpc: %w", err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		client.Close()
+	}()
+	burner, err := events.NewChainBurner(client, settings.DCXBurnContractAddress, privateKey, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credit burner: %w", err)
+	}
+	return burner, nil
+}
+
+// createKafkaProducer creates the producer of the balance event publishers and the dead-letter queue, it is closed when the context is done.
+func createKafkaProducer(ctx context.Context, settings *config.Settings) (sarama.SyncProducer, error) {
+	if len(settings.KafkaBrokers) == 0 {
+		return nil, errors.New("KAFKA_BROKERS is required when LOW_BALANCE_THRESHOLD, GRANT_CONFIRMED_EVENTS, or CONTRACT_EVENTS_DLQ_TOPIC is set")
+	}
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true
+	producer, err := sarama.NewSyncProducer(settings.KafkaBrokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka producer: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		if err := producer.Close(); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to close kafka producer")
+		}
+	}()
+	return producer, nil
+}
+
+// createKafkaConsumerGroup creates the consumer group of the contract events, starting from the oldest event when the group has no committed offset
+// so that no burn sent before the first deployment is missed.
+func createKafkaConsumerGroup(settings *config.Settings) (sarama.ConsumerGroup, error) {
+	if len(settings.KafkaBrokers) == 0 {
+		return nil, errors.New("KAFKA_BROKERS is required when CONTRACT_EVENTS_TOPIC is set")
+	}
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	saramaConfig.Consumer.Return.Errors = true
+	group, err := sarama.NewConsumerGroup(settings.KafkaBrokers, settings.ContractEventsGroupID, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka consumer group: %w", err)
+	}
+	return group, nil
+}
+
+// contractEventWorker confirms grants from the contract events of the topic until its context is done.
+func contractEventWorker(processor *events.ContractProcessor, group sarama.ConsumerGroup, topic string) Worker {
+	return func(ctx context.Context) error {
+		return processor.Consume(ctx, group, topic)
+	}
 }
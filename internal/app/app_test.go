@@ -0,0 +1,282 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/auth"
+	"github.com/DIMO-Network/credit-tracker/internal/config"
+	"github.com/DIMO-Network/credit-tracker/internal/controllers/ctrlerrors"
+	"github.com/DIMO-Network/credit-tracker/internal/controllers/httphandlers"
+	"github.com/DIMO-Network/credit-tracker/internal/controllers/rpc"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo/memstore"
+	"github.com/DIMO-Network/credit-tracker/internal/events"
+	"github.com/DIMO-Network/credit-tracker/internal/tracing"
+	"github.com/DIMO-Network/credit-tracker/models"
+	ctgrpc "github.com/DIMO-Network/credit-tracker/pkg/grpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serveRPC serves the grpc server on an in-memory listener and returns a client connection to it.
+func serveRPC(t *testing.T, server *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestRPCServerHealth(t *testing.T) {
+	store := memstore.New()
+	didValidator, err := rpc.NewDIDValidator(nil)
+	require.NoError(t, err)
+	server, healthServer := setupRPCServer(&config.Settings{}, rpc.NewServer(store, events.NewContractProcessor(store, nil), didValidator), nil)
+
+	conn := serveRPC(t, server)
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	// Test: The server and the credit tracker service report serving
+	for _, service := range []string{"", ctgrpc.CreditTracker_ServiceDesc.ServiceName} {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus(), "service %q", service)
+	}
+
+	// Test: The services report not serving once the shutdown worker's context is done
+	workerCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.NoError(t, healthShutdownWorker(healthServer)(workerCtx))
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestRPCServerTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	const licenseID = "0x0000000000000000000000000000000000000001"
+	const assetDID = "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:123"
+	store := memstore.New()
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDID,
+		InitialAmount:   100,
+		RemainingAmount: 100,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	didValidator, err := rpc.NewDIDValidator(nil)
+	require.NoError(t, err)
+	server, _ := setupRPCServer(&config.Settings{}, rpc.NewServer(store, events.NewContractProcessor(store, nil), didValidator), nil)
+
+	conn := serveRPC(t, server)
+	client := ctgrpc.NewCreditTrackerClient(conn)
+
+	// Test: Deduct with the trace context of a caller
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.AppendToOutgoingContext(context.Background(), tracing.TraceParentMetadataKey, "00-"+traceID+"-00f067aa0ba902b7-01")
+	var header metadata.MD
+	_, err = client.DeductCredits(ctx, &ctgrpc.CreditDeductRequest{
+		DeveloperLicense: licenseID,
+		AssetDid:         assetDID,
+		Amount:           25,
+		AppName:          "test-app",
+		ReferenceId:      "trace-1",
+	}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	// Verify: The request span continues the caller's trace and carries the deduction attributes
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, ctgrpc.CreditTracker_DeductCredits_FullMethodName[1:], span.Name)
+	assert.Equal(t, traceID, span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Subset(t, span.Attributes, tracing.OperationAttributes(licenseID, assetDID, 25, creditrepo.OperationTypeDeduction))
+	assert.Equal(t, []string{traceID}, header.Get(tracing.TraceIDMetadataKey))
+}
+
+func TestRPCServerAdminMethods(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyFunc := func(*jwt.Token) (any, error) {
+		return &key.PublicKey, nil
+	}
+	adminAddress := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	signToken := func(t *testing.T, address common.Address) string {
+		t.Helper()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, &auth.Token{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			CustomDexClaims:  auth.CustomDexClaims{EthereumAddress: address.Hex()},
+		})
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	store := memstore.New()
+	store.SelfTestSteps = []creditrepo.SelfTestStep{{Name: "grant"}}
+	didValidator, err := rpc.NewDIDValidator(nil)
+	require.NoError(t, err)
+	settings := &config.Settings{AdminAddresses: []common.Address{adminAddress}}
+	server, _ := setupRPCServer(settings, rpc.NewServer(store, events.NewContractProcessor(store, nil), didValidator), keyFunc)
+	client := ctgrpc.NewCreditTrackerClient(serveRPC(t, server))
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	// Test: SelfTest writes to the database, so only admins can run it
+	_, err = client.SelfTest(context.Background(), &ctgrpc.SelfTestRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.SelfTest(withToken(signToken(t, common.HexToAddress("0x0000000000000000000000000000000000000002"))), &ctgrpc.SelfTestRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resp, err := client.SelfTest(withToken(signToken(t, adminAddress)), &ctgrpc.SelfTestRequest{})
+	require.NoError(t, err)
+	assert.Len(t, resp.GetSteps(), 1)
+}
+
+func TestRegisterCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first := creditrepo.NewPendingGrantCollector(creditrepo.New(nil))
+	require.NoError(t, registerCollector(registry, first))
+
+	// Test: The collector of a second app in the same process replaces the first one
+	second := creditrepo.NewPendingGrantCollector(creditrepo.New(nil))
+	require.NoError(t, registerCollector(registry, second))
+
+	// Verify: The second collector is the one registered
+	err := registry.Register(creditrepo.NewPendingGrantCollector(creditrepo.New(nil)))
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	require.ErrorAs(t, err, &alreadyRegistered)
+	assert.Same(t, second, alreadyRegistered.ExistingCollector)
+}
+
+func TestConfigureDBPool(t *testing.T) {
+	settings, err := config.LoadSettings(filepath.Join(t.TempDir(), "settings.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, 25, settings.MaxOpenConns)
+	assert.Equal(t, 10, settings.MaxIdleConns)
+	assert.Equal(t, 5*time.Minute, settings.ConnMaxLifetime)
+
+	// sql.Open does not connect, so the pool can be inspected without a database
+	sqlDB, err := sql.Open("postgres", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	settings.MaxOpenConns = 7
+	configureDBPool(sqlDB, settings)
+	assert.Equal(t, 7, sqlDB.Stats().MaxOpenConnections)
+}
+
+func TestHTTPRequestLogger(t *testing.T) {
+	const licenseID = "0x0000000000000000000000000000000000000001"
+	newApp := func(logs *bytes.Buffer, logQuery bool) *fiber.App {
+		ctrl := httphandlers.NewHTTPController(memstore.New(), &config.Settings{})
+		app := fiber.New(fiber.Config{ErrorHandler: ctrlerrors.ErrorHandler})
+		app.Use(requestLogger(zerolog.New(logs), logQuery))
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals(auth.ContextKey, &jwt.Token{Claims: &auth.Token{
+				CustomDexClaims: auth.CustomDexClaims{EthereumAddress: licenseID},
+			}})
+			return c.Next()
+		})
+		app.Get("/v1/credits/:licenseId/usage", ctrl.GetLicenseUsageReport)
+		return app
+	}
+	get := func(t *testing.T, app *fiber.App, target string) {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, target, nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer secret-jwt")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+	lastLine := func(t *testing.T, logs *bytes.Buffer) map[string]any {
+		t.Helper()
+		lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &line))
+		return line
+	}
+
+	t.Run("usage request is logged with its path and status", func(t *testing.T) {
+		var logs bytes.Buffer
+		app := newApp(&logs, false)
+		get(t, app, "/v1/credits/"+licenseID+"/usage?fromDate=2024-01-01T00:00:00Z")
+
+		line := lastLine(t, &logs)
+		assert.Equal(t, "GET", line["httpMethod"])
+		assert.Equal(t, "v1/credits/"+licenseID+"/usage", line["httpPath"])
+		assert.Equal(t, float64(fiber.StatusOK), line["status"])
+		assert.Contains(t, line, "latency")
+		assert.Positive(t, line["responseBytes"])
+		assert.NotContains(t, line, "query")
+		assert.NotContains(t, logs.String(), "secret-jwt")
+	})
+
+	t.Run("errors are logged with the status sent", func(t *testing.T) {
+		var logs bytes.Buffer
+		app := newApp(&logs, false)
+		get(t, app, "/v1/credits/"+licenseID+"/usage")
+		assert.Equal(t, float64(fiber.StatusBadRequest), lastLine(t, &logs)["status"])
+	})
+
+	t.Run("query params are logged when enabled, without tokens", func(t *testing.T) {
+		var logs bytes.Buffer
+		app := newApp(&logs, true)
+		get(t, app, "/v1/credits/"+licenseID+"/usage?fromDate=2024-01-01T00:00:00Z&access_token=secret-jwt")
+
+		line := lastLine(t, &logs)
+		assert.Equal(t, map[string]any{"fromDate": "2024-01-01T00:00:00Z", "access_token": "[REDACTED]"}, line["query"])
+		assert.NotContains(t, logs.String(), "secret-jwt")
+	})
+}
@@ -1,16 +1,32 @@
 package auth
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/DIMO-Network/credit-tracker/internal/config"
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/ethereum/go-ethereum/common"
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
 )
 
 const (
 	ContextKey = "user"
 )
 
+const (
+	// defaultJWKSRefreshInterval is how often the JWK set is refetched when no interval is configured.
+	defaultJWKSRefreshInterval = time.Hour
+	// jwksRefreshRateLimit is the least time between two fetches of the JWK set, e.g. for tokens with an unknown key ID.
+	jwksRefreshRateLimit = time.Minute
+	// jwksRefreshTimeout bounds a single fetch of the JWK set.
+	jwksRefreshTimeout = 10 * time.Second
+)
+
 // Token is the token for the user.
 type Token struct {
 	jwt.RegisteredClaims
@@ -26,12 +42,68 @@ type CustomDexClaims struct {
 }
 
 // Middleware is the middleware for Dex JWT authentication.
-func Middleware(settings *config.Settings) fiber.Handler {
+// The JWK set is cached and refetched in the background until the context is done, see NewJWKSKeyfunc.
+func Middleware(ctx context.Context, settings *config.Settings) (fiber.Handler, error) {
+	keyFunc, err := NewJWKSKeyfunc(ctx, settings.JWKKeySetURL, settings.JWKSRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
 	return jwtware.New(jwtware.Config{
-		JWKSetURLs: []string{settings.JWKKeySetURL},
+		KeyFunc:    keyFunc,
 		Claims:     &Token{},
 		ContextKey: ContextKey,
+	}), nil
+}
+
+// NewJWKSKeyfunc returns a key function for the JWK set at the given URL, refetched every refresh interval or the default when zero,
+// and whenever a token has an unknown key ID. A failed fetch keeps the last known keys, so tokens keep validating through
+// a JWKS outage. If the set cannot be fetched at startup it is fetched again when the first token is checked instead of failing.
+func NewJWKSKeyfunc(ctx context.Context, url string, refreshInterval time.Duration) (jwt.Keyfunc, error) {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultJWKSRefreshInterval
+	}
+	logger := zerolog.Ctx(ctx)
+	jwks, err := keyfunc.Get(url, keyfunc.Options{
+		Ctx: ctx,
+		RefreshErrorHandler: func(err error) {
+			logger.Warn().Err(err).Str("url", url).Msg("Failed to refresh JWK set, using the last known keys")
+		},
+		RefreshInterval:             refreshInterval,
+		RefreshRateLimit:            min(jwksRefreshRateLimit, refreshInterval),
+		RefreshTimeout:              jwksRefreshTimeout,
+		RefreshUnknownKID:           true,
+		TolerateInitialJWKHTTPError: true,
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWK set key function: %w", err)
+	}
+	return jwks.Keyfunc, nil
+}
+
+// AdminMiddleware only allows users whose ethereum address is one of the configured admin addresses.
+// It must run after Middleware.
+func AdminMiddleware(settings *config.Settings) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := GetDexJWT(c)
+		if !ok || !IsAdmin(token, settings.AdminAddresses) {
+			return fiber.NewError(fiber.StatusForbidden, "Admin access required")
+		}
+		return c.Next()
+	}
+}
+
+// IsAdmin returns whether the ethereum address of the token is one of the admin addresses.
+func IsAdmin(token *Token, adminAddresses []common.Address) bool {
+	if !common.IsHexAddress(token.EthereumAddress) {
+		return false
+	}
+	address := common.HexToAddress(token.EthereumAddress)
+	for _, admin := range adminAddresses {
+		if admin == address {
+			return true
+		}
+	}
+	return false
 }
 
 // GetDexJWT returns the dex jwt from the context.
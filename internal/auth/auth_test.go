@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKID = "test-key"
+
+// jwksServer serves the JWK set of a single RSA key until it is taken down.
+type jwksServer struct {
+	*httptest.Server
+	down     atomic.Bool
+	requests atomic.Int64
+}
+
+func newJWKSServer(t *testing.T, key *rsa.PrivateKey) *jwksServer {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": testKID,
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	server := &jwksServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		server.requests.Add(1)
+		if server.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newAuthApp creates an app with a single route behind the auth middleware.
+func newAuthApp(t *testing.T, url string, refreshInterval time.Duration) *fiber.App {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	middleware, err := Middleware(ctx, &config.Settings{JWKKeySetURL: url, JWKSRefreshInterval: refreshInterval})
+	require.NoError(t, err)
+	app := fiber.New()
+	app.Get("/", middleware, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, &Token{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		CustomDexClaims:  CustomDexClaims{EthereumAddress: "0x1234567890123456789012345678901234567890"},
+	})
+	token.Header["kid"] = testKID
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func doAuthRequest(t *testing.T, app *fiber.App, token string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestMiddlewareJWKSOutage(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	token := signToken(t, key)
+
+	t.Run("cached keys survive an outage", func(t *testing.T) {
+		server := newJWKSServer(t, key)
+		app := newAuthApp(t, server.URL, 20*time.Millisecond)
+		require.Equal(t, fiber.StatusOK, doAuthRequest(t, app, token))
+
+		server.down.Store(true)
+		fetched := server.requests.Load()
+		require.Eventually(t, func() bool {
+			return server.requests.Load() > fetched
+		}, 5*time.Second, 10*time.Millisecond, "the JWK set should be refetched during the outage")
+		assert.Equal(t, fiber.StatusOK, doAuthRequest(t, app, token))
+	})
+
+	t.Run("unavailable at startup", func(t *testing.T) {
+		server := newJWKSServer(t, key)
+		server.down.Store(true)
+		app := newAuthApp(t, server.URL, time.Hour)
+
+		// the keys are fetched again for the first token once the endpoint is back
+		server.down.Store(false)
+		assert.Equal(t, fiber.StatusOK, doAuthRequest(t, app, token))
+	})
+
+	t.Run("invalid token is rejected", func(t *testing.T) {
+		server := newJWKSServer(t, key)
+		app := newAuthApp(t, server.URL, time.Hour)
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, doAuthRequest(t, app, signToken(t, other)))
+	})
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authorizationMetadataKey is the gRPC metadata key of the bearer token.
+const authorizationMetadataKey = "authorization"
+
+// AdminUnaryServerInterceptor requires the Dex JWT of an admin, as a bearer token in the authorization metadata,
+// for calls to the given gRPC methods. Calls to other methods are not checked.
+// A missing or invalid token fails with UNAUTHENTICATED and the token of a non-admin with PERMISSION_DENIED.
+func AdminUnaryServerInterceptor(keyFunc jwt.Keyfunc, adminAddresses []common.Address, fullMethods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !slices.Contains(fullMethods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+		token, err := tokenFromMetadata(ctx, keyFunc)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if !IsAdmin(token, adminAddresses) {
+			return nil, status.Error(codes.PermissionDenied, "Admin access required")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// tokenFromMetadata parses and validates the bearer token in the incoming metadata.
+func tokenFromMetadata(ctx context.Context, keyFunc jwt.Keyfunc) (*Token, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationMetadataKey)
+	if len(values) == 0 {
+		return nil, errors.New("missing bearer token")
+	}
+	raw, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || raw == "" {
+		return nil, errors.New("malformed bearer token")
+	}
+	token := &Token{}
+	if _, err := jwt.ParseWithClaims(raw, token, keyFunc); err != nil {
+		return nil, errors.New("invalid or expired JWT")
+	}
+	return token, nil
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminUnaryServerInterceptor(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyFunc := func(*jwt.Token) (any, error) {
+		return &key.PublicKey, nil
+	}
+	const adminMethod = "/grpc.CreditTracker/AdminAddCredits"
+	// signToken signs tokens for this address
+	tokenAddress := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	otherAddress := common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	call := func(interceptor grpc.UnaryServerInterceptor, method, token string) (bool, error) {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+		}
+		called := false
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) {
+			called = true
+			return nil, nil
+		})
+		return called, err
+	}
+
+	t.Run("admin is allowed", func(t *testing.T) {
+		interceptor := AdminUnaryServerInterceptor(keyFunc, []common.Address{otherAddress, tokenAddress}, adminMethod)
+		called, err := call(interceptor, adminMethod, signToken(t, key))
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("caller without admin scope is rejected", func(t *testing.T) {
+		interceptor := AdminUnaryServerInterceptor(keyFunc, []common.Address{otherAddress}, adminMethod)
+		called, err := call(interceptor, adminMethod, signToken(t, key))
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.False(t, called)
+	})
+
+	t.Run("missing or invalid token is rejected", func(t *testing.T) {
+		interceptor := AdminUnaryServerInterceptor(keyFunc, []common.Address{tokenAddress}, adminMethod)
+		called, err := call(interceptor, adminMethod, "")
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.False(t, called)
+
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		called, err = call(interceptor, adminMethod, signToken(t, other))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.False(t, called)
+	})
+
+	t.Run("other methods are not checked", func(t *testing.T) {
+		interceptor := AdminUnaryServerInterceptor(keyFunc, nil, adminMethod)
+		called, err := call(interceptor, "/grpc.CreditTracker/GetDebt", "")
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/DIMO-Network/shared/pkg/db"
 	"github.com/caarlos0/env/v11"
@@ -12,15 +13,63 @@ import (
 
 // Settings contains the application config.
 type Settings struct {
-	Environment               string         `env:"ENVIRONMENT"`
-	LogLevel                  string         `env:"LOG_LEVEL"`
-	Port                      int            `env:"PORT"`
-	MonPort                   int            `env:"MON_PORT"`
-	GRPCPort                  int            `env:"GRPC_PORT"`
-	JWKKeySetURL              string         `env:"JWT_KEY_SET_URL"`
-	DIMORegistryChainID       uint64         `env:"DIMO_REGISTRY_CHAIN_ID"`
-	VehicleNFTContractAddress common.Address `env:"VEHICLE_NFT_CONTRACT_ADDRESS"`
-	DB                        db.Settings    `envPrefix:"DB_"`
+	Environment                 string           `env:"ENVIRONMENT"`
+	LogLevel                    string           `env:"LOG_LEVEL"`
+	Port                        int              `env:"PORT"`
+	MonPort                     int              `env:"MON_PORT"`
+	GRPCPort                    int              `env:"GRPC_PORT"`
+	JWKKeySetURL                string           `env:"JWT_KEY_SET_URL"`
+	JWKSRefreshInterval         time.Duration    `env:"JWKS_REFRESH_INTERVAL" envDefault:"1h"`
+	DIMORegistryChainID         uint64           `env:"DIMO_REGISTRY_CHAIN_ID"`
+	VehicleNFTContractAddress   common.Address   `env:"VEHICLE_NFT_CONTRACT_ADDRESS"`
+	DB                          db.Settings      `envPrefix:"DB_"`
+	MaxOpenConns                int              `env:"DB_MAX_OPEN_CONNS" envDefault:"25"`
+	MaxIdleConns                int              `env:"DB_MAX_IDLE_CONNS" envDefault:"10"`
+	ConnMaxLifetime             time.Duration    `env:"DB_CONN_MAX_LIFETIME" envDefault:"5m"`
+	IsolationLevel              string           `env:"DB_ISOLATION_LEVEL" envDefault:"ReadCommitted"`
+	ShutdownTimeout             time.Duration    `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+	ExhaustionRounding          time.Duration    `env:"EXHAUSTION_ROUNDING" envDefault:"24h"`
+	UtilizationPrecision        int              `env:"UTILIZATION_PRECISION" envDefault:"4"`
+	MarkDepletedGrants          bool             `env:"MARK_DEPLETED_GRANTS"`
+	SummaryOnlyAppNames         []string         `env:"SUMMARY_ONLY_APP_NAMES" envSeparator:","`
+	ReportRateLimit             int              `env:"REPORT_RATE_LIMIT"`
+	ReportRateLimitWindow       time.Duration    `env:"REPORT_RATE_LIMIT_WINDOW" envDefault:"1m"`
+	AdminAddresses              []common.Address `env:"ADMIN_ADDRESSES" envSeparator:","`
+	AssetDIDMethods             []string         `env:"ASSET_DID_METHODS" envSeparator:"," envDefault:"erc721"`
+	RefundOverflowPolicy        string           `env:"REFUND_OVERFLOW_POLICY" envDefault:"error"`
+	AllowPendingGrantMismatch   bool             `env:"ALLOW_PENDING_GRANT_MISMATCH"`
+	RecordBalanceAfter          bool             `env:"RECORD_BALANCE_AFTER"`
+	LazyExpiration              bool             `env:"LAZY_EXPIRATION"`
+	KafkaBrokers                []string         `env:"KAFKA_BROKERS" envSeparator:","`
+	ContractEventsTopic         string           `env:"CONTRACT_EVENTS_TOPIC"`
+	ContractEventsGroupID       string           `env:"CONTRACT_EVENTS_GROUP_ID" envDefault:"credit-tracker"`
+	ContractEventsDLQTopic      string           `env:"CONTRACT_EVENTS_DLQ_TOPIC"`
+	DCXBurnedEventID            string           `env:"DCX_BURNED_EVENT_ID"`
+	LowBalanceThreshold         int64            `env:"LOW_BALANCE_THRESHOLD"`
+	LowBalanceTopic             string           `env:"LOW_BALANCE_TOPIC" envDefault:"topic.credit.balance"`
+	GrantConfirmedEvents        bool             `env:"GRANT_CONFIRMED_EVENTS"`
+	GrantConfirmedTopic         string           `env:"GRANT_CONFIRMED_TOPIC" envDefault:"topic.credit.grant"`
+	OpTimeout                   time.Duration    `env:"OP_TIMEOUT"`
+	DeadlockMaxAttempts         int              `env:"DEADLOCK_MAX_ATTEMPTS" envDefault:"10"`
+	MaxConcurrentDeductions     int              `env:"MAX_CONCURRENT_DEDUCTIONS"`
+	RPCRateLimit                float64          `env:"RPC_RATE_LIMIT"`
+	RPCRateLimitBurst           int              `env:"RPC_RATE_LIMIT_BURST"`
+	EthereumRPCURL              string           `env:"ETHEREUM_RPC_URL"`
+	DCXBurnContractAddress      common.Address   `env:"DCX_BURN_CONTRACT_ADDRESS"`
+	BurnerPrivateKey            string           `env:"BURNER_PRIVATE_KEY"`
+	BurnCreditAmount            uint64           `env:"BURN_CREDIT_AMOUNT" envDefault:"50000"`
+	MaxBurnAttempts             int              `env:"MAX_BURN_ATTEMPTS" envDefault:"1"`
+	PendingGrantTimeout         time.Duration    `env:"PENDING_GRANT_TIMEOUT"`
+	PendingGrantCheckInterval   time.Duration    `env:"PENDING_GRANT_CHECK_INTERVAL" envDefault:"1m"`
+	ExpiredGrantRetention       time.Duration    `env:"EXPIRED_GRANT_RETENTION"`
+	ExpiredGrantCleanupInterval time.Duration    `env:"EXPIRED_GRANT_CLEANUP_INTERVAL" envDefault:"1h"`
+	SpendConfirmedGrantsOnly    bool             `env:"SPEND_CONFIRMED_GRANTS_ONLY"`
+	UsageOperationTypes         []string         `env:"USAGE_OPERATION_TYPES" envSeparator:","`
+	UsageReturnOperationTypes   []string         `env:"USAGE_RETURN_OPERATION_TYPES" envSeparator:","`
+	DiscountTiers               string           `env:"DISCOUNT_TIERS"`
+	TracingEnabled              bool             `env:"TRACING_ENABLED"`
+	HTTPRequestLogging          bool             `env:"HTTP_REQUEST_LOGGING"`
+	HTTPRequestLogQuery         bool             `env:"HTTP_REQUEST_LOG_QUERY"`
 }
 
 func LoadSettings(filePath string) (*Settings, error) {
@@ -1,18 +1,110 @@
 package ctrlerrors
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/rs/zerolog"
+)
+
+// Error codes returned with the validation failures of the usage report endpoints.
+const (
+	CodeLicenseMismatch = "license_mismatch"
+	CodeInvalidFormat   = "invalid_format"
+	CodeFromDateMissing = "from_date_required"
+	CodeInvalidFromDate = "invalid_from_date"
+	CodeInvalidToDate   = "invalid_to_date"
+	CodeInvalidAssetDID = "invalid_asset_did"
+	CodeInvalidWindow   = "invalid_window"
+	CodeWindowConflict  = "window_conflict"
+)
 
 // Error represents an error that can be returned by a controller.
 type Error struct {
 	InternalError error
 	ExternalMsg   string
 	Code          int
+	// ErrorCode is a stable identifier of the error clients can branch on, derived from Code when empty.
+	ErrorCode string
+}
+
+// New returns an error with the given HTTP status, error code, and external message.
+func New(code int, errorCode, msg string) Error {
+	return Error{ExternalMsg: msg, Code: code, ErrorCode: errorCode}
 }
 
 func (e Error) Error() string {
+	if e.InternalError == nil {
+		return e.ExternalMsg
+	}
 	return fmt.Sprintf("%s: %s", e.ExternalMsg, e.InternalError)
 }
 
 func (e Error) Unwrap() error {
 	return e.InternalError
 }
+
+// ErrorCode returns the error code of err, or the snake cased status text of the HTTP status when err has none,
+// e.g. bad_request or internal_server_error.
+func ErrorCode(err error, status int) string {
+	var ctrlErr Error
+	if errors.As(err, &ctrlErr) && ctrlErr.ErrorCode != "" {
+		return ctrlErr.ErrorCode
+	}
+	return strings.ReplaceAll(strings.ToLower(utils.StatusMessage(status)), " ", "_")
+}
+
+// RateLimitError is returned when a client exceeded its rate limit and should retry later.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+// ErrorHandler custom handler to log recovered errors using our logger and return json instead of string
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError // Default 500 statuscode
+	message := "Internal error."
+
+	var fiberErr *fiber.Error
+	var ctrlErr Error
+	var rateLimitErr RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		code = fiber.StatusTooManyRequests
+		message = "Too many requests."
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+	} else if errors.As(err, &fiberErr) {
+		code = fiberErr.Code
+		message = fiberErr.Message
+	} else if errors.As(err, &ctrlErr) {
+		message = ctrlErr.ExternalMsg
+		if ctrlErr.Code != 0 {
+			code = ctrlErr.Code
+		}
+	}
+
+	// log all errors except 404
+	if code != fiber.StatusNotFound {
+		logger := zerolog.Ctx(ctx.UserContext())
+		logger.Err(err).Int("httpStatusCode", code).
+			Str("httpPath", strings.TrimPrefix(ctx.Path(), "/")).
+			Str("httpMethod", ctx.Method()).
+			Msg("caught an error from http request")
+	}
+
+	return ctx.Status(code).JSON(codeResp{Code: code, ErrorCode: ErrorCode(err, code), Message: message})
+}
+
+type codeResp struct {
+	Message   string `json:"message"`
+	Code      int    `json:"code"`
+	ErrorCode string `json:"errorCode"`
+}
@@ -0,0 +1,112 @@
+package httphandlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/controllers/ctrlerrors"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/gofiber/fiber/v2"
+)
+
+const mimeTextCSV = "text/csv"
+
+// reportFormat returns the format a usage report is serialized in, either JSON or CSV.
+// The format query parameter takes precedence over the Accept header, and JSON is the default.
+func reportFormat(fiberCtx *fiber.Ctx) (string, error) {
+	switch fiberCtx.Query("format") {
+	case "":
+	case "json":
+		return fiber.MIMEApplicationJSON, nil
+	case "csv":
+		return mimeTextCSV, nil
+	default:
+		return "", ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeInvalidFormat, "Invalid format, must be json or csv")
+	}
+	if fiberCtx.Accepts(fiber.MIMEApplicationJSON, mimeTextCSV) == mimeTextCSV {
+		return mimeTextCSV, nil
+	}
+	return fiber.MIMEApplicationJSON, nil
+}
+
+// licenseUsageReportCSV serializes a license usage report as a header row and a single summary row,
+// where the refunds of every reason are totaled. A report with the per-asset breakdown has a row per asset instead.
+func licenseUsageReportCSV(report *creditrepo.LicenseUsageReport) ([]byte, error) {
+	if report.PerAsset != nil {
+		records := [][]string{{"licenseId", "assetDid", "fromDate", "toDate", "numOfCreditsUsed", "numOfCreditsGrantsPurchased"}}
+		for _, asset := range report.PerAsset {
+			records = append(records, []string{
+				report.LicenseID,
+				asset.AssetDID,
+				formatCSVTime(report.FromDate),
+				formatCSVTime(report.ToDate),
+				strconv.FormatInt(asset.NumOfCreditsUsed, 10),
+				strconv.FormatInt(asset.NumOfCreditsGrantsPurchased, 10),
+			})
+		}
+		return writeCSV(records)
+	}
+	var numOfRefunds, numOfCreditsRefunded int64
+	for _, refunds := range report.RefundsByReason {
+		numOfRefunds += refunds.NumOfRefunds
+		numOfCreditsRefunded += refunds.NumOfCreditsRefunded
+	}
+	return writeCSV([][]string{
+		{"licenseId", "fromDate", "toDate", "numOfAssets", "numOfCreditsGrantsPurchased", "numOfCreditsUsed", "numOfRefunds", "numOfCreditsRefunded"},
+		{
+			report.LicenseID,
+			formatCSVTime(report.FromDate),
+			formatCSVTime(report.ToDate),
+			strconv.FormatInt(report.NumOfAssets, 10),
+			strconv.FormatInt(report.NumOfCreditsGrantsPurchased, 10),
+			strconv.FormatInt(report.NumOfCreditsUsed, 10),
+			strconv.FormatInt(numOfRefunds, 10),
+			strconv.FormatInt(numOfCreditsRefunded, 10),
+		},
+	})
+}
+
+// licenseAssetUsageReportCSV serializes an asset usage report as a header row and a single row.
+// Null values are left empty and the confirmed grants are not included.
+func licenseAssetUsageReportCSV(report *creditrepo.LicenseAssetUsageReport) ([]byte, error) {
+	var utilizationRate, projectedExhaustion string
+	if report.UtilizationRate != nil {
+		utilizationRate = strconv.FormatFloat(*report.UtilizationRate, 'f', -1, 64)
+	}
+	if report.ProjectedExhaustion != nil {
+		projectedExhaustion = formatCSVTime(*report.ProjectedExhaustion)
+	}
+	return writeCSV([][]string{
+		{"licenseId", "assetDid", "fromDate", "toDate", "numOfCreditsUsed", "numOfCreditsGrantsPurchased", "currentCreditsRemaining", "numOfCreditsGranted", "utilizationRate", "projectedExhaustion"},
+		{
+			report.LicenseID,
+			report.AssetDID,
+			formatCSVTime(report.FromDate),
+			formatCSVTime(report.ToDate),
+			strconv.FormatInt(report.NumOfCreditsUsed, 10),
+			strconv.FormatInt(report.NumOfCreditsGrantsPurchased, 10),
+			strconv.FormatInt(report.CurrentCreditsRemaining, 10),
+			strconv.FormatInt(report.NumOfCreditsGranted, 10),
+			utilizationRate,
+			projectedExhaustion,
+		},
+	})
+}
+
+// formatCSVTime formats a time as RFC 3339, leaving the zero time empty.
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func writeCSV(records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,12 +1,16 @@
 package httphandlers
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/DIMO-Network/cloudevent"
 	"github.com/DIMO-Network/credit-tracker/internal/auth"
 	"github.com/DIMO-Network/credit-tracker/internal/config"
+	"github.com/DIMO-Network/credit-tracker/internal/controllers/ctrlerrors"
 	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gofiber/fiber/v2"
@@ -15,13 +19,13 @@ import (
 
 // HTTPController handles VIN VC-related http requests.
 type HTTPController struct {
-	creditTrackerRepo   *creditrepo.Repository
+	creditTrackerRepo   creditrepo.CreditStore
 	ChainID             uint64
 	VehicleContractAddr common.Address
 }
 
 // NewHTTPController creates a new http VCController.
-func NewHTTPController(service *creditrepo.Repository, settings *config.Settings) *HTTPController {
+func NewHTTPController(service creditrepo.CreditStore, settings *config.Settings) *HTTPController {
 	return &HTTPController{
 		creditTrackerRepo:   service,
 		ChainID:             settings.DIMORegistryChainID,
@@ -30,13 +34,17 @@ func NewHTTPController(service *creditrepo.Repository, settings *config.Settings
 }
 
 // @Summary Get License Usage Report
-// @Description Get usage report for a license across all assets
+// @Description Get usage report for a license across all assets. As CSV the report is a single summary row with the refunds of every reason totaled,
+// @Description or one row per asset when the per-asset breakdown is included.
 // @Tags Credits
 // @Accept json
-// @Produce json
+// @Produce json,text/csv
 // @Param  licenseId path string true "License ID"
-// @Param  fromDate query string true "From Date"
+// @Param  fromDate query string false "From Date, required without a window"
 // @Param  toDate query string false "To Date"
+// @Param  window query string false "Report the window ending now instead of fromDate and toDate, such as 30d, 720h, or P30D"
+// @Param  includePerAsset query bool false "Include the usage of each asset accessed during the period"
+// @Param  format query string false "Response format, json or csv, overrides the Accept header" Enums(json, csv)
 // @Success 200 {object} creditrepo.LicenseUsageReport
 // @Security     BearerAuth
 // @Router /v1/credits/{licenseId}/usage [get]
@@ -44,46 +52,67 @@ func (v *HTTPController) GetLicenseUsageReport(fiberCtx *fiber.Ctx) error {
 	licenseID := fiberCtx.Params("licenseId")
 	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
 		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
-		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+		return ctrlerrors.New(fiber.StatusUnauthorized, ctrlerrors.CodeLicenseMismatch, "Unauthorized license does not match")
 	}
-	fromDateStr := fiberCtx.Query("fromDate")
-	toDateStr := fiberCtx.Query("toDate")
-	if fromDateStr == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "fromDate is required")
+	format, err := reportFormat(fiberCtx)
+	if err != nil {
+		return err
 	}
-	fromDate, err := time.Parse(time.RFC3339, fromDateStr)
+	fromDate, toDate, err := reportWindow(fiberCtx, time.Now())
 	if err != nil {
-		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid fromDate")
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid fromDate")
+		return err
 	}
-	var toDate time.Time
-	if toDateStr != "" {
-		toDate, err = time.Parse(time.RFC3339, toDateStr)
+	if fromDate.IsZero() {
+		fromDateStr := fiberCtx.Query("fromDate")
+		toDateStr := fiberCtx.Query("toDate")
+		if fromDateStr == "" {
+			return ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeFromDateMissing, "fromDate is required")
+		}
+		fromDate, err = time.Parse(time.RFC3339, fromDateStr)
 		if err != nil {
-			zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid toDate")
-			return fiber.NewError(fiber.StatusBadRequest, "Invalid toDate")
+			zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid fromDate")
+			return ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeInvalidFromDate, "Invalid fromDate")
+		}
+		if toDateStr != "" {
+			toDate, err = time.Parse(time.RFC3339, toDateStr)
+			if err != nil {
+				zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid toDate")
+				return ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeInvalidToDate, "Invalid toDate")
+			}
 		}
 	}
 
-	resp, err := v.creditTrackerRepo.GetLicenseUsageReport(fiberCtx.Context(), licenseID, fromDate, toDate)
+	resp, err := v.creditTrackerRepo.GetLicenseUsageReport(fiberCtx.Context(), licenseID, fromDate, toDate, fiberCtx.QueryBool("includePerAsset"))
 	if err != nil {
 		fmt.Println("Failed to get license usage report", err)
 		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get license usage report")
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get license usage report")
 	}
 
+	if format == mimeTextCSV {
+		body, err := licenseUsageReportCSV(resp)
+		if err != nil {
+			zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to write license usage report CSV")
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to get license usage report")
+		}
+		fiberCtx.Set(fiber.HeaderContentType, mimeTextCSV)
+		return fiberCtx.Send(body)
+	}
 	return fiberCtx.JSON(resp)
 }
 
 // @Summary Get License Asset Usage Report
-// @Description Get detailed usage report for a specific license and asset
+// @Description Get detailed usage report for a specific license and asset. As CSV the report is a single row without the confirmed grants.
 // @Tags Credits
 // @Accept json
-// @Produce json
+// @Produce json,text/csv
 // @Param  licenseId path string true "License ID"
 // @Param  assetDID path string true "Asset DID"
-// @Param  fromDate query string true "From Date"
+// @Param  fromDate query string false "From Date, required without a window"
 // @Param  toDate query string false "To Date"
+// @Param  window query string false "Report the window ending now instead of fromDate and toDate, such as 30d, 720h, or P30D"
+// @Param  includeGrantTxHashes query bool false "Include the tx hashes of grants confirmed during the period"
+// @Param  format query string false "Response format, json or csv, overrides the Accept header" Enums(json, csv)
 // @Success 200 {object} creditrepo.LicenseAssetUsageReport
 // @Security     BearerAuth
 // @Router /v1/credits/{licenseId}/assets/{assetId}/usage [get]
@@ -92,39 +121,546 @@ func (v *HTTPController) GetLicenseAssetUsageReport(fiberCtx *fiber.Ctx) error {
 	assetDID := fiberCtx.Params("assetID")
 	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
 		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
-		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+		return ctrlerrors.New(fiber.StatusUnauthorized, ctrlerrors.CodeLicenseMismatch, "Unauthorized license does not match")
 	}
 
-	fromDateStr := fiberCtx.Query("fromDate")
-	toDateStr := fiberCtx.Query("toDate")
-	fromDate, err := time.Parse(time.RFC3339, fromDateStr)
+	format, err := reportFormat(fiberCtx)
 	if err != nil {
-		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid fromDate")
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid fromDate")
+		return err
 	}
-	var toDate time.Time
-	if toDateStr != "" {
-		toDate, err = time.Parse(time.RFC3339, toDateStr)
+	fromDate, toDate, err := reportWindow(fiberCtx, time.Now())
+	if err != nil {
+		return err
+	}
+	if fromDate.IsZero() {
+		fromDateStr := fiberCtx.Query("fromDate")
+		toDateStr := fiberCtx.Query("toDate")
+		fromDate, err = time.Parse(time.RFC3339, fromDateStr)
 		if err != nil {
-			zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid toDate")
-			return fiber.NewError(fiber.StatusBadRequest, "Invalid toDate")
+			zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid fromDate")
+			return ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeInvalidFromDate, "Invalid fromDate")
+		}
+		if toDateStr != "" {
+			toDate, err = time.Parse(time.RFC3339, toDateStr)
+			if err != nil {
+				zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid toDate")
+				return ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeInvalidToDate, "Invalid toDate")
+			}
 		}
 	}
 	// unescape the assetDID
 	assetDID, err = url.QueryUnescape(assetDID)
 	if err != nil {
 		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid assetDID")
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid assetDID")
+		return ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeInvalidAssetDID, "Invalid assetDID")
+	}
+	if _, err := cloudevent.DecodeERC721DID(assetDID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid assetDID")
+		return ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeInvalidAssetDID, "Invalid assetDID, must be an ERC-721 DID such as did:erc721:<chainId>:<contract>:<tokenId>")
 	}
-	resp, err := v.creditTrackerRepo.GetLicenseAssetUsageReport(fiberCtx.Context(), licenseID, assetDID, fromDate, toDate)
+	includeGrantTxHashes := fiberCtx.QueryBool("includeGrantTxHashes")
+	resp, err := v.creditTrackerRepo.GetLicenseAssetUsageReport(fiberCtx.Context(), licenseID, assetDID, fromDate, toDate, includeGrantTxHashes)
 	if err != nil {
 		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get asset usage report")
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get asset usage report")
 	}
 
+	if format == mimeTextCSV {
+		body, err := licenseAssetUsageReportCSV(resp)
+		if err != nil {
+			zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to write asset usage report CSV")
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to get asset usage report")
+		}
+		fiberCtx.Set(fiber.HeaderContentType, mimeTextCSV)
+		return fiberCtx.Send(body)
+	}
+	return fiberCtx.JSON(resp)
+}
+
+// Grant is a credit grant backing the balance of a license and asset.
+type Grant struct {
+	// Grant ID
+	ID string `json:"id"`
+	// Grant status: pending, confirmed, or failed
+	Status string `json:"status"`
+	// Number of credits granted
+	InitialAmount int64 `json:"initialAmount"`
+	// Number of credits not yet used
+	RemainingAmount int64 `json:"remainingAmount"`
+	// When the grant expires, null for perpetual grants that never expire
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// Transaction hash of the burn that created the grant
+	TxHash string `json:"txHash"`
+}
+
+// @Summary List License Asset Grants
+// @Description List the grants backing the balance of a license and asset, in the order deductions use them
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Param  assetId path string true "Asset DID"
+// @Param  status query string false "Only return grants with this status (pending, confirmed, failed, expired)"
+// @Param  includeExpired query bool false "Include expired grants"
+// @Param  limit query int false "Maximum number of grants to return, defaults to 100"
+// @Param  offset query int false "Number of grants to skip"
+// @Success 200 {array} Grant
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/assets/{assetId}/grants [get]
+func (v *HTTPController) ListLicenseAssetGrants(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+	assetDID, err := url.QueryUnescape(fiberCtx.Params("assetId"))
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid assetDID")
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid assetDID")
+	}
+
+	opts := creditrepo.ListOptions{
+		Limit:          fiberCtx.QueryInt("limit"),
+		Offset:         fiberCtx.QueryInt("offset"),
+		Status:         fiberCtx.Query("status"),
+		IncludeExpired: fiberCtx.QueryBool("includeExpired"),
+	}
+	switch opts.Status {
+	case "", creditrepo.GrantStatusPending, creditrepo.GrantStatusConfirmed, creditrepo.GrantStatusFailed, creditrepo.GrantStatusExpired:
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid status")
+	}
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid limit or offset")
+	}
+
+	grants, err := v.creditTrackerRepo.ListGrants(fiberCtx.Context(), licenseID, assetDID, opts)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to list grants")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to list grants")
+	}
+
+	resp := make([]Grant, 0, len(grants))
+	for _, grant := range grants {
+		resp = append(resp, Grant{
+			ID:              grant.ID,
+			Status:          grant.Status,
+			InitialAmount:   grant.InitialAmount,
+			RemainingAmount: grant.RemainingAmount,
+			ExpiresAt:       grant.ExpiresAt.Ptr(),
+			TxHash:          grant.TXHash,
+		})
+	}
 	return fiberCtx.JSON(resp)
 }
 
+// Debt is the outstanding debt of a license and asset.
+type Debt struct {
+	// License ID
+	LicenseID string `json:"licenseId"`
+	// Asset DID
+	AssetDID string `json:"assetDid"`
+	// Credits owed from failed grants, deductions are refused until they are settled
+	Debt int64 `json:"debt"`
+}
+
+// @Summary Get License Asset Debt
+// @Description Get the outstanding debt of a license and asset, the credits owed from failed grants that must be settled before credits can be spent again
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Param  assetId path string true "Asset DID"
+// @Success 200 {object} Debt
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/assets/{assetId}/debt [get]
+func (v *HTTPController) GetLicenseAssetDebt(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+	assetDID, err := url.QueryUnescape(fiberCtx.Params("assetId"))
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid assetDID")
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid assetDID")
+	}
+
+	debt, err := v.creditTrackerRepo.GetDebt(fiberCtx.Context(), licenseID, assetDID)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get debt")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get debt")
+	}
+
+	return fiberCtx.JSON(Debt{LicenseID: licenseID, AssetDID: assetDID, Debt: debt})
+}
+
+// @Summary Get License Debts
+// @Description Get every asset of a license with outstanding debt, ordered by asset DID. Deductions of these assets are refused until their debt is settled.
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Success 200 {array} creditrepo.AssetDebt
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/debts [get]
+func (v *HTTPController) GetLicenseDebts(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+
+	debts, err := v.creditTrackerRepo.GetAssetsWithDebt(fiberCtx.Context(), licenseID)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get assets with debt")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get assets with debt")
+	}
+
+	return fiberCtx.JSON(debts)
+}
+
+// @Summary Get License Operation History
+// @Description Get the credit operations of a license, newest first, with the grants each operation touched.
+// @Description Refunds share the reference ID of the deduction they refund.
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Param  assetDid query string false "Only return operations of this asset"
+// @Param  fromDate query string false "From Date"
+// @Param  toDate query string false "To Date"
+// @Param  operationTypes query string false "Comma separated operation types to return, such as deduction,refund"
+// @Param  limit query int false "Maximum number of operations to return, defaults to 100"
+// @Param  offset query int false "Number of operations to skip"
+// @Param  cursor query string false "nextCursor of the previous page"
+// @Success 200 {object} creditrepo.OperationHistoryPage
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/operations [get]
+func (v *HTTPController) GetLicenseOperationHistory(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+
+	var fromDate, toDate time.Time
+	var err error
+	if fromDateStr := fiberCtx.Query("fromDate"); fromDateStr != "" {
+		fromDate, err = time.Parse(time.RFC3339, fromDateStr)
+		if err != nil {
+			zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid fromDate")
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid fromDate")
+		}
+	}
+	if toDateStr := fiberCtx.Query("toDate"); toDateStr != "" {
+		toDate, err = time.Parse(time.RFC3339, toDateStr)
+		if err != nil {
+			zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid toDate")
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid toDate")
+		}
+	}
+	if !fromDate.IsZero() && !toDate.IsZero() && fromDate.After(toDate) {
+		return fiber.NewError(fiber.StatusBadRequest, "fromDate must be before toDate")
+	}
+	limit := fiberCtx.QueryInt("limit")
+	offset := fiberCtx.QueryInt("offset")
+	if limit < 0 || offset < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid limit or offset")
+	}
+	var operationTypes []string
+	if operationTypesStr := fiberCtx.Query("operationTypes"); operationTypesStr != "" {
+		for _, operationType := range strings.Split(operationTypesStr, ",") {
+			operationType = strings.TrimSpace(operationType)
+			if !creditrepo.IsOperationType(operationType) {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid operation type "+operationType)
+			}
+			operationTypes = append(operationTypes, operationType)
+		}
+	}
+
+	resp, err := v.creditTrackerRepo.GetOperationHistory(fiberCtx.Context(), licenseID, creditrepo.OperationHistoryOptions{
+		AssetDID:       fiberCtx.Query("assetDid"),
+		FromDate:       fromDate,
+		ToDate:         toDate,
+		OperationTypes: operationTypes,
+		Limit:          limit,
+		Offset:         offset,
+		Cursor:         fiberCtx.Query("cursor"),
+	})
+	if errors.Is(err, creditrepo.InvalidCursorErr) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid cursor")
+	}
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get operation history")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get operation history")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// @Summary Get License Recent Operations
+// @Description Get the most recent credit operations of a license across all assets, newest first, without the grants each operation touched.
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Param  limit query int false "Maximum number of operations to return, defaults to 10 and is capped at 100"
+// @Success 200 {array} creditrepo.RecentOperation
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/recent-operations [get]
+func (v *HTTPController) GetLicenseRecentOperations(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+	limit := fiberCtx.QueryInt("limit")
+	if limit < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid limit")
+	}
+
+	resp, err := v.creditTrackerRepo.GetRecentOperations(fiberCtx.Context(), licenseID, limit)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get recent operations")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get recent operations")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// @Summary Get License Operation By Reference
+// @Description Get the operations of a license with the reference ID, oldest first, with the grants each operation touched,
+// @Description such as a deduction and its refunds.
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Param  referenceId path string true "Reference ID"
+// @Success 200 {array} creditrepo.OperationRecord
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/operations/{referenceId} [get]
+func (v *HTTPController) GetLicenseOperationByReference(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+
+	resp, err := v.creditTrackerRepo.GetOperationByReference(fiberCtx.Context(), licenseID, fiberCtx.Params("referenceId"))
+	if errors.Is(err, creditrepo.OperationNotFoundErr) {
+		return fiber.NewError(fiber.StatusNotFound, "Operation not found")
+	}
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get operation by reference")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get operation by reference")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// @Summary Get License Account Snapshot
+// @Description Get the balance, debt, pending grants, soonest expiration, and recent operations of a license, in total and per asset.
+// @Description Everything is read at a single point in time, so the totals are the sums of the assets.
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Success 200 {object} creditrepo.AccountSnapshot
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/snapshot [get]
+func (v *HTTPController) GetLicenseAccountSnapshot(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+
+	resp, err := v.creditTrackerRepo.GetAccountSnapshot(fiberCtx.Context(), licenseID)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get account snapshot")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get account snapshot")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// @Summary Get License Summary
+// @Description Get the total balance and debt of a license across all of its assets, and how many assets have credits or debt.
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Success 200 {object} creditrepo.LicenseSummary
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/summary [get]
+func (v *HTTPController) GetLicenseSummary(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+
+	resp, err := v.creditTrackerRepo.GetLicenseSummary(fiberCtx.Context(), licenseID)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get license summary")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get license summary")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// @Summary Get License Balances
+// @Description Get the cached balance and debt of every asset for a license
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Success 200 {array} creditrepo.BalanceSummary
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/balances [get]
+func (v *HTTPController) GetLicenseBalances(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+
+	resp, err := v.creditTrackerRepo.GetBalanceSummaries(fiberCtx.Context(), licenseID)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get license balances")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get license balances")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// @Summary Refresh License Balances
+// @Description Recompute the cached balance and debt of every asset for a license from its grants
+// @Tags Credits
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Success 200 {array} creditrepo.BalanceSummary
+// @Security     BearerAuth
+// @Router /v1/credits/{licenseId}/balances/refresh [post]
+func (v *HTTPController) RefreshLicenseBalances(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	if err := isExpectedUser(fiberCtx, licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Unauthorized license does not match")
+		return fiber.NewError(fiber.StatusUnauthorized, "Unauthorized license does not match")
+	}
+
+	if _, err := v.creditTrackerRepo.RefreshLicenseBalanceSummaries(fiberCtx.Context(), licenseID); err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to refresh license balances")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to refresh license balances")
+	}
+	resp, err := v.creditTrackerRepo.GetBalanceSummaries(fiberCtx.Context(), licenseID)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get license balances")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get license balances")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// @Summary Get Long Running Transactions
+// @Description Admin only. List the open database transactions that have been running for at least minDuration, including the backends blocking them
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param  minDuration query string false "Minimum transaction duration (e.g. 5s), defaults to 1s"
+// @Success 200 {array} creditrepo.TransactionDiagnostic
+// @Security     BearerAuth
+// @Router /v1/admin/transactions [get]
+func (v *HTTPController) GetLongRunningTransactions(fiberCtx *fiber.Ctx) error {
+	minDuration := time.Second
+	if minDurationStr := fiberCtx.Query("minDuration"); minDurationStr != "" {
+		var err error
+		minDuration, err = time.ParseDuration(minDurationStr)
+		if err != nil || minDuration < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid minDuration")
+		}
+	}
+
+	resp, err := v.creditTrackerRepo.GetLongRunningTransactions(fiberCtx.Context(), minDuration)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get long running transactions")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get long running transactions")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// @Summary Get Pending Grant Stats
+// @Description Admin only. Get the number, credits, and oldest creation time of the pending grants of a license, across every asset unless assetId is set
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Param  assetId query string false "Asset DID"
+// @Success 200 {object} creditrepo.PendingGrantStats
+// @Security     BearerAuth
+// @Router /v1/admin/credits/{licenseId}/pending-grants [get]
+func (v *HTTPController) GetPendingGrantStats(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	resp, err := v.creditTrackerRepo.GetPendingGrantStats(fiberCtx.Context(), licenseID, fiberCtx.Query("assetId"))
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to get pending grant stats")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to get pending grant stats")
+	}
+
+	return fiberCtx.JSON(resp)
+}
+
+// BalanceReconciliation is the balance of a license and asset computed from its grants and from the operation ledger.
+type BalanceReconciliation struct {
+	// License ID
+	LicenseID string `json:"licenseId"`
+	// Asset DID
+	AssetDID string `json:"assetDid"`
+	// Initial amounts of the grants with every recorded grant allocation applied
+	LedgerBalance int64 `json:"ledgerBalance"`
+	// Sum of the remaining amounts of the grants
+	GrantBalance int64 `json:"grantBalance"`
+	// Grant balance minus ledger balance, zero when the grants match the ledger
+	Discrepancy int64 `json:"discrepancy"`
+}
+
+// @Summary Reconcile License Asset Balance
+// @Description Admin only. Compute the balance of a license and asset from the remaining amounts of its grants and by replaying the operation ledger,
+// @Description and report the discrepancy between the two. Both cover every grant of the asset regardless of status or expiration.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param  licenseId path string true "License ID"
+// @Param  assetId path string true "Asset DID"
+// @Success 200 {object} BalanceReconciliation
+// @Security     BearerAuth
+// @Router /v1/admin/credits/{licenseId}/assets/{assetId}/reconciliation [get]
+func (v *HTTPController) ReconcileLicenseAssetBalance(fiberCtx *fiber.Ctx) error {
+	licenseID := fiberCtx.Params("licenseId")
+	assetDID, err := url.QueryUnescape(fiberCtx.Params("assetId"))
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Invalid assetDID")
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid assetDID")
+	}
+
+	ledgerBalance, grantBalance, err := v.creditTrackerRepo.ReconcileBalance(fiberCtx.Context(), licenseID, assetDID)
+	if err != nil {
+		zerolog.Ctx(fiberCtx.UserContext()).Error().Err(err).Msg("Failed to reconcile balance")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to reconcile balance")
+	}
+
+	return fiberCtx.JSON(BalanceReconciliation{
+		LicenseID:     licenseID,
+		AssetDID:      assetDID,
+		LedgerBalance: ledgerBalance,
+		GrantBalance:  grantBalance,
+		Discrepancy:   grantBalance - ledgerBalance,
+	})
+}
+
 func isExpectedUser(fiberCtx *fiber.Ctx, licenseID string) error {
 	dexUser, ok := auth.GetDexJWT(fiberCtx)
 	if !ok {
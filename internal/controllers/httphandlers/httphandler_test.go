@@ -0,0 +1,605 @@
+package httphandlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/auth"
+	"github.com/DIMO-Network/credit-tracker/internal/config"
+	"github.com/DIMO-Network/credit-tracker/internal/controllers/ctrlerrors"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo/memstore"
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+const (
+	testLicenseID = "0x1234567890123456789012345678901234567890"
+	testAssetDID  = "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:123"
+)
+
+// newTestApp creates an app with the controller routes where every request is authenticated as testLicenseID.
+func newTestApp(store creditrepo.CreditStore) *fiber.App {
+	ctrl := NewHTTPController(store, &config.Settings{})
+	app := fiber.New(fiber.Config{ErrorHandler: ctrlerrors.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(auth.ContextKey, &jwt.Token{Claims: &auth.Token{
+			CustomDexClaims: auth.CustomDexClaims{EthereumAddress: testLicenseID},
+		}})
+		return c.Next()
+	})
+	app.Get("/v1/credits/:licenseId/usage", ctrl.GetLicenseUsageReport)
+	app.Get("/v1/credits/:licenseId/assets/:assetId/usage", ctrl.GetLicenseAssetUsageReport)
+	app.Get("/v1/credits/:licenseId/assets/:assetId/grants", ctrl.ListLicenseAssetGrants)
+	app.Get("/v1/credits/:licenseId/assets/:assetId/debt", ctrl.GetLicenseAssetDebt)
+	app.Get("/v1/credits/:licenseId/debts", ctrl.GetLicenseDebts)
+	app.Get("/v1/credits/:licenseId/operations", ctrl.GetLicenseOperationHistory)
+	app.Get("/v1/credits/:licenseId/recent-operations", ctrl.GetLicenseRecentOperations)
+	app.Get("/v1/credits/:licenseId/operations/:referenceId", ctrl.GetLicenseOperationByReference)
+	app.Get("/v1/credits/:licenseId/balances", ctrl.GetLicenseBalances)
+	app.Get("/v1/credits/:licenseId/snapshot", ctrl.GetLicenseAccountSnapshot)
+	app.Get("/v1/credits/:licenseId/summary", ctrl.GetLicenseSummary)
+	app.Get("/v1/admin/credits/:licenseId/assets/:assetId/reconciliation", ctrl.ReconcileLicenseAssetBalance)
+	return app
+}
+
+func doGet(t *testing.T, app *fiber.App, target string, out any) int {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if out != nil && resp.StatusCode == fiber.StatusOK {
+		require.NoError(t, json.Unmarshal(body, out), string(body))
+	}
+	return resp.StatusCode
+}
+
+func TestHTTPControllerUsageReport(t *testing.T) {
+	store := memstore.New()
+	_, err := store.ConfirmGrant(t.Context(), testLicenseID, testAssetDID, "0x1", 1, 100, time.Now())
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 40, "app", "ref-1")
+	require.NoError(t, err)
+	app := newTestApp(store)
+	fromDate := url.QueryEscape(time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+	var report creditrepo.LicenseUsageReport
+	code := doGet(t, app, "/v1/credits/"+testLicenseID+"/usage?fromDate="+fromDate, &report)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, int64(40), report.NumOfCreditsUsed)
+	assert.Equal(t, int64(1), report.NumOfAssets)
+
+	code = doGet(t, app, "/v1/credits/"+testLicenseID+"/usage", nil)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+
+	code = doGet(t, app, "/v1/credits/0x0000000000000000000000000000000000000001/usage?fromDate="+fromDate, nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+}
+
+func TestHTTPControllerUsageReportWindow(t *testing.T) {
+	store := memstore.New()
+	_, err := store.ConfirmGrant(t.Context(), testLicenseID, testAssetDID, "0x1", 1, 100, time.Now().Add(-8*24*time.Hour))
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 40, "app", "ref-1")
+	require.NoError(t, err)
+	app := newTestApp(store)
+	usagePath := "/v1/credits/" + testLicenseID + "/usage"
+	assetUsagePath := "/v1/credits/" + testLicenseID + "/assets/" + url.PathEscape(testAssetDID) + "/usage"
+
+	// Test: A window of 7 days, written in each supported format, reports the 7 days ending now
+	for _, window := range []string{"7d", "168h", "P7D", "P1W"} {
+		t.Run(window, func(t *testing.T) {
+			before := time.Now()
+			var report creditrepo.LicenseUsageReport
+			code := doGet(t, app, usagePath+"?window="+window, &report)
+			require.Equal(t, fiber.StatusOK, code)
+			assert.Equal(t, 7*24*time.Hour, report.ToDate.Sub(report.FromDate))
+			assert.WithinRange(t, report.ToDate, before.Add(-time.Second), time.Now())
+			assert.Equal(t, int64(40), report.NumOfCreditsUsed)
+
+			var assetReport creditrepo.LicenseAssetUsageReport
+			code = doGet(t, app, assetUsagePath+"?window="+window, &assetReport)
+			require.Equal(t, fiber.StatusOK, code)
+			assert.Equal(t, 7*24*time.Hour, assetReport.ToDate.Sub(assetReport.FromDate))
+			assert.WithinRange(t, assetReport.ToDate, before.Add(-time.Second), time.Now())
+		})
+	}
+}
+
+func TestHTTPControllerReportErrorCodes(t *testing.T) {
+	app := newTestApp(memstore.New())
+	fromDate := url.QueryEscape(time.Now().Add(-time.Hour).Format(time.RFC3339))
+	usagePath := "/v1/credits/" + testLicenseID + "/usage"
+	assetUsagePath := "/v1/credits/" + testLicenseID + "/assets/" + url.PathEscape(testAssetDID) + "/usage"
+
+	tests := []struct {
+		name       string
+		target     string
+		expectCode int
+		expectErr  string
+	}{
+		{name: "usage license mismatch", target: "/v1/credits/0x0000000000000000000000000000000000000001/usage?fromDate=" + fromDate, expectCode: fiber.StatusUnauthorized, expectErr: ctrlerrors.CodeLicenseMismatch},
+		{name: "usage invalid format", target: usagePath + "?format=xml&fromDate=" + fromDate, expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidFormat},
+		{name: "usage missing fromDate", target: usagePath, expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeFromDateMissing},
+		{name: "usage invalid fromDate", target: usagePath + "?fromDate=yesterday", expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidFromDate},
+		{name: "usage invalid toDate", target: usagePath + "?fromDate=" + fromDate + "&toDate=today", expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidToDate},
+		{name: "asset usage license mismatch", target: "/v1/credits/0x0000000000000000000000000000000000000001/assets/" + url.PathEscape(testAssetDID) + "/usage?fromDate=" + fromDate, expectCode: fiber.StatusUnauthorized, expectErr: ctrlerrors.CodeLicenseMismatch},
+		{name: "asset usage invalid format", target: assetUsagePath + "?format=xml&fromDate=" + fromDate, expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidFormat},
+		{name: "asset usage invalid fromDate", target: assetUsagePath + "?fromDate=yesterday", expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidFromDate},
+		{name: "asset usage invalid toDate", target: assetUsagePath + "?fromDate=" + fromDate + "&toDate=today", expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidToDate},
+		{name: "asset usage malformed assetDID", target: "/v1/credits/" + testLicenseID + "/assets/not-a-did/usage?fromDate=" + fromDate, expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidAssetDID},
+		{name: "asset usage invalid assetDID", target: "/v1/credits/" + testLicenseID + "/assets/%zz/usage?fromDate=" + fromDate, expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidAssetDID},
+		{name: "usage window with fromDate", target: usagePath + "?window=7d&fromDate=" + fromDate, expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeWindowConflict},
+		{name: "usage window with toDate", target: usagePath + "?window=7d&toDate=" + fromDate, expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeWindowConflict},
+		{name: "usage invalid window", target: usagePath + "?window=7y", expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidWindow},
+		{name: "usage negative window", target: usagePath + "?window=-7d", expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidWindow},
+		{name: "usage window of months", target: usagePath + "?window=P1M", expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidWindow},
+		{name: "asset usage window with fromDate", target: assetUsagePath + "?window=7d&fromDate=" + fromDate, expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeWindowConflict},
+		{name: "asset usage invalid window", target: assetUsagePath + "?window=P", expectCode: fiber.StatusBadRequest, expectErr: ctrlerrors.CodeInvalidWindow},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			// set the raw request URI so malformed escapes reach the handler
+			req.RequestURI = tc.target
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			var body struct {
+				Code      int    `json:"code"`
+				ErrorCode string `json:"errorCode"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tc.expectCode, resp.StatusCode)
+			assert.Equal(t, tc.expectCode, body.Code)
+			assert.Equal(t, tc.expectErr, body.ErrorCode)
+		})
+	}
+
+	t.Run("errors without a code use the status", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/v1/credits/"+testLicenseID+"/recent-operations?limit=-1", nil))
+		require.NoError(t, err)
+		var body struct {
+			ErrorCode string `json:"errorCode"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "bad_request", body.ErrorCode)
+	})
+}
+
+func TestHTTPControllerUsageReportCSV(t *testing.T) {
+	store := memstore.New()
+	_, err := store.ConfirmGrant(t.Context(), testLicenseID, testAssetDID, "0x1", 1, 100, time.Now())
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 40, "app", "ref-1")
+	require.NoError(t, err)
+	_, err = store.RefundCredits(t.Context(), "app", "ref-1", creditrepo.WithRefundReason("timeout"))
+	require.NoError(t, err)
+	app := newTestApp(store)
+	fromDate := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	toDate := fromDate.Add(2 * time.Hour)
+	query := "?fromDate=" + url.QueryEscape(fromDate.Format(time.RFC3339)) + "&toDate=" + url.QueryEscape(toDate.Format(time.RFC3339))
+	licenseTarget := "/v1/credits/" + testLicenseID + "/usage" + query
+	assetTarget := "/v1/credits/" + testLicenseID + "/assets/" + url.PathEscape(testAssetDID) + "/usage" + query
+
+	doCSV := func(target, accept string) (int, string, string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", target, nil)
+		if accept != "" {
+			req.Header.Set(fiber.HeaderAccept, accept)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), string(body)
+	}
+
+	licenseCSV := "licenseId,fromDate,toDate,numOfAssets,numOfCreditsGrantsPurchased,numOfCreditsUsed,numOfRefunds,numOfCreditsRefunded\n" +
+		testLicenseID + "," + fromDate.Format(time.RFC3339) + "," + toDate.Format(time.RFC3339) + ",1,1,0,1,40\n"
+	code, contentType, body := doCSV(licenseTarget, "text/csv")
+	require.Equal(t, fiber.StatusOK, code, body)
+	assert.Equal(t, "text/csv", contentType)
+	assert.Equal(t, licenseCSV, body)
+
+	code, contentType, body = doCSV(licenseTarget+"&format=csv", "")
+	require.Equal(t, fiber.StatusOK, code, body)
+	assert.Equal(t, "text/csv", contentType)
+	assert.Equal(t, licenseCSV, body)
+
+	assetCSV := "licenseId,assetDid,fromDate,toDate,numOfCreditsUsed,numOfCreditsGrantsPurchased,currentCreditsRemaining,numOfCreditsGranted,utilizationRate,projectedExhaustion\n" +
+		testLicenseID + "," + testAssetDID + "," + fromDate.Format(time.RFC3339) + "," + toDate.Format(time.RFC3339) + ",0,1,100,100,,\n"
+	code, contentType, body = doCSV(assetTarget, "text/csv")
+	require.Equal(t, fiber.StatusOK, code, body)
+	assert.Equal(t, "text/csv", contentType)
+	assert.Equal(t, assetCSV, body)
+
+	code, contentType, body = doCSV(assetTarget+"&format=csv", "")
+	require.Equal(t, fiber.StatusOK, code, body)
+	assert.Equal(t, "text/csv", contentType)
+	assert.Equal(t, assetCSV, body)
+
+	perAssetCSV := "licenseId,assetDid,fromDate,toDate,numOfCreditsUsed,numOfCreditsGrantsPurchased\n" +
+		testLicenseID + "," + testAssetDID + "," + fromDate.Format(time.RFC3339) + "," + toDate.Format(time.RFC3339) + ",0,1\n"
+	code, contentType, body = doCSV(licenseTarget+"&includePerAsset=true&format=csv", "")
+	require.Equal(t, fiber.StatusOK, code, body)
+	assert.Equal(t, "text/csv", contentType)
+	assert.Equal(t, perAssetCSV, body)
+
+	// JSON stays the default and the format parameter overrides the Accept header
+	code, contentType, _ = doCSV(licenseTarget, "")
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Contains(t, contentType, fiber.MIMEApplicationJSON)
+	code, contentType, _ = doCSV(assetTarget+"&format=json", "text/csv")
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Contains(t, contentType, fiber.MIMEApplicationJSON)
+
+	code, _, _ = doCSV(licenseTarget+"&format=xml", "")
+	assert.Equal(t, fiber.StatusBadRequest, code)
+}
+
+func TestHTTPControllerListGrants(t *testing.T) {
+	store := memstore.New()
+	later := store.AddGrant(&models.CreditGrant{
+		LicenseID:       testLicenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 100,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(48 * time.Hour)),
+		TXHash:          "0x2",
+	})
+	earlier := store.AddGrant(&models.CreditGrant{
+		LicenseID:       testLicenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 60,
+		Status:          creditrepo.GrantStatusPending,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		TXHash:          "0x1",
+	})
+	app := newTestApp(store)
+	target := "/v1/credits/" + testLicenseID + "/assets/" + url.PathEscape(testAssetDID) + "/grants"
+
+	var grants []Grant
+	code := doGet(t, app, target, &grants)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, grants, 2)
+	assert.Equal(t, earlier.ID, grants[0].ID)
+	assert.Equal(t, int64(60), grants[0].RemainingAmount)
+	assert.Equal(t, "0x1", grants[0].TxHash)
+	assert.Equal(t, later.ID, grants[1].ID)
+
+	code = doGet(t, app, target+"?status=confirmed", &grants)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, grants, 1)
+	assert.Equal(t, later.ID, grants[0].ID)
+
+	code = doGet(t, app, target+"?status=unknown", nil)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+}
+
+func TestHTTPControllerBalances(t *testing.T) {
+	store := memstore.New()
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       testLicenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 80,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	app := newTestApp(store)
+
+	var summaries []creditrepo.BalanceSummary
+	code := doGet(t, app, "/v1/credits/"+testLicenseID+"/balances", &summaries)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, testAssetDID, summaries[0].AssetDID)
+	assert.Equal(t, int64(80), summaries[0].Balance)
+}
+
+func TestHTTPControllerDebt(t *testing.T) {
+	store := memstore.New()
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       testLicenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 80,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	app := newTestApp(store)
+	target := "/v1/credits/" + testLicenseID + "/assets/" + url.PathEscape(testAssetDID) + "/debt"
+
+	// credits to spend and no failed grants
+	var debt Debt
+	code := doGet(t, app, target, &debt)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, Debt{LicenseID: testLicenseID, AssetDID: testAssetDID}, debt)
+
+	// a failed grant owes the credits spent from it
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       testLicenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 40,
+		Status:          creditrepo.GrantStatusFailed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	code = doGet(t, app, target, &debt)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, int64(60), debt.Debt)
+
+	code = doGet(t, app, "/v1/credits/0xother/assets/"+url.PathEscape(testAssetDID)+"/debt", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+}
+
+func TestHTTPControllerDebts(t *testing.T) {
+	store := memstore.New()
+	otherAssetDID := "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:2"
+	lastAssetDID := "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:3"
+	for _, grant := range []*models.CreditGrant{
+		{AssetDid: testAssetDID, InitialAmount: 100, RemainingAmount: 40, Status: creditrepo.GrantStatusFailed},
+		{AssetDid: otherAssetDID, InitialAmount: 100, RemainingAmount: 80, Status: creditrepo.GrantStatusConfirmed},
+		{AssetDid: lastAssetDID, InitialAmount: 100, RemainingAmount: 90, Status: creditrepo.GrantStatusFailed},
+	} {
+		grant.LicenseID = testLicenseID
+		grant.ExpiresAt = null.TimeFrom(time.Now().Add(time.Hour))
+		store.AddGrant(grant)
+	}
+	app := newTestApp(store)
+
+	var debts []creditrepo.AssetDebt
+	code := doGet(t, app, "/v1/credits/"+testLicenseID+"/debts", &debts)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, []creditrepo.AssetDebt{
+		{AssetDID: testAssetDID, Debt: 60},
+		{AssetDID: lastAssetDID, Debt: 10},
+	}, debts)
+
+	code = doGet(t, app, "/v1/credits/0xother/debts", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+}
+
+func TestHTTPControllerAccountSnapshot(t *testing.T) {
+	store := memstore.New()
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       testLicenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 80,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	app := newTestApp(store)
+
+	var snapshot creditrepo.AccountSnapshot
+	code := doGet(t, app, "/v1/credits/"+testLicenseID+"/snapshot", &snapshot)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, testLicenseID, snapshot.LicenseID)
+	require.Len(t, snapshot.Assets, 1)
+	assert.Equal(t, testAssetDID, snapshot.Assets[0].AssetDID)
+	assert.Equal(t, int64(80), snapshot.Assets[0].Balance)
+	assert.Equal(t, snapshot.Assets[0].Balance, snapshot.Balance)
+	assert.Empty(t, snapshot.RecentOperations)
+
+	code = doGet(t, app, "/v1/credits/0xother/snapshot", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+}
+
+func TestHTTPControllerLicenseSummary(t *testing.T) {
+	store := memstore.New()
+	later := null.TimeFrom(time.Now().Add(time.Hour))
+	for _, grant := range []*models.CreditGrant{
+		{AssetDid: "did:erc721:1:0xabc:1", InitialAmount: 100, RemainingAmount: 80, Status: creditrepo.GrantStatusConfirmed},
+		{AssetDid: "did:erc721:1:0xabc:2", InitialAmount: 100, RemainingAmount: 25, Status: creditrepo.GrantStatusConfirmed},
+		{AssetDid: "did:erc721:1:0xabc:3", InitialAmount: 100, RemainingAmount: 60, Status: creditrepo.GrantStatusFailed},
+		{AssetDid: "did:erc721:1:0xabc:4", InitialAmount: 100, RemainingAmount: 0, Status: creditrepo.GrantStatusConfirmed},
+	} {
+		grant.LicenseID = testLicenseID
+		grant.ExpiresAt = later
+		store.AddGrant(grant)
+	}
+	app := newTestApp(store)
+
+	var summary creditrepo.LicenseSummary
+	code := doGet(t, app, "/v1/credits/"+testLicenseID+"/summary", &summary)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, creditrepo.LicenseSummary{
+		LicenseID:    testLicenseID,
+		Balance:      105,
+		Debt:         40,
+		Assets:       4,
+		ActiveAssets: 2,
+		AssetsInDebt: 1,
+	}, summary)
+
+	code = doGet(t, app, "/v1/credits/0xother/summary", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+}
+
+func TestHTTPControllerOperationHistory(t *testing.T) {
+	store := memstore.New()
+	_, err := store.ConfirmGrant(t.Context(), testLicenseID, testAssetDID, "0x1", 1, 100, time.Now())
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 40, "app", "ref-1")
+	require.NoError(t, err)
+	_, err = store.RefundCredits(t.Context(), "app", "ref-1")
+	require.NoError(t, err)
+	app := newTestApp(store)
+	target := "/v1/credits/" + testLicenseID + "/operations"
+
+	var page creditrepo.OperationHistoryPage
+	code := doGet(t, app, target, &page)
+	require.Equal(t, fiber.StatusOK, code)
+	records := page.Operations
+	require.Len(t, records, 3)
+	assert.Equal(t, creditrepo.OperationTypeRefund, records[0].OperationType)
+	assert.Equal(t, creditrepo.OperationTypeDeduction, records[1].OperationType)
+	assert.Equal(t, creditrepo.OperationTypeGrantConfirm, records[2].OperationType)
+	assert.Equal(t, records[0].ReferenceID, records[1].ReferenceID)
+	require.Len(t, records[0].Grants, 1)
+	assert.Equal(t, int64(40), records[0].Grants[0].Amount)
+	assert.Equal(t, records[2].Grants[0].GrantID, records[1].Grants[0].GrantID)
+	assert.Empty(t, page.NextCursor)
+
+	page = creditrepo.OperationHistoryPage{}
+	code = doGet(t, app, target+"?limit=1&offset=1", &page)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, page.Operations, 1)
+	assert.Equal(t, creditrepo.OperationTypeDeduction, page.Operations[0].OperationType)
+
+	page = creditrepo.OperationHistoryPage{}
+	code = doGet(t, app, target+"?limit=2", &page)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, page.Operations, 2)
+	require.NotEmpty(t, page.NextCursor)
+	cursor := page.NextCursor
+	page = creditrepo.OperationHistoryPage{}
+	code = doGet(t, app, target+"?limit=2&cursor="+cursor, &page)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, page.Operations, 1)
+	assert.Equal(t, creditrepo.OperationTypeGrantConfirm, page.Operations[0].OperationType)
+	assert.Empty(t, page.NextCursor)
+
+	page = creditrepo.OperationHistoryPage{}
+	code = doGet(t, app, target+"?operationTypes=refund,grant_confirm", &page)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, page.Operations, 2)
+	assert.Equal(t, creditrepo.OperationTypeRefund, page.Operations[0].OperationType)
+	assert.Equal(t, creditrepo.OperationTypeGrantConfirm, page.Operations[1].OperationType)
+
+	page = creditrepo.OperationHistoryPage{}
+	code = doGet(t, app, target+"?assetDid="+url.QueryEscape("did:erc721:1:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:1"), &page)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Empty(t, page.Operations)
+
+	code = doGet(t, app, target+"?fromDate=yesterday", nil)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+
+	code = doGet(t, app, target+"?operationTypes=withdrawal", nil)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+
+	code = doGet(t, app, target+"?cursor=not-a-cursor", nil)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+}
+
+func TestHTTPControllerOperationByReference(t *testing.T) {
+	store := memstore.New()
+	_, err := store.ConfirmGrant(t.Context(), testLicenseID, testAssetDID, "0x1", 1, 100, time.Now())
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 40, "app", "ref-1")
+	require.NoError(t, err)
+	_, err = store.RefundCredits(t.Context(), "app", "ref-1")
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 10, "app", "ref-2")
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 5, "app", "recent")
+	require.NoError(t, err)
+	app := newTestApp(store)
+	target := "/v1/credits/" + testLicenseID + "/operations/"
+
+	var records []creditrepo.OperationRecord
+	code := doGet(t, app, target+"ref-1", &records)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, records, 2)
+	assert.Equal(t, creditrepo.OperationTypeDeduction, records[0].OperationType)
+	assert.Equal(t, creditrepo.OperationTypeRefund, records[1].OperationType)
+	for _, record := range records {
+		assert.Equal(t, "ref-1", record.ReferenceID)
+		assert.Equal(t, int64(40), record.TotalAmount)
+		require.Len(t, record.Grants, 1)
+	}
+	assert.Equal(t, int64(-40), records[0].Grants[0].Amount)
+	assert.Equal(t, int64(40), records[1].Grants[0].Amount)
+
+	// a reference ID that reads like a route name is still looked up by reference
+	code = doGet(t, app, target+"recent", &records)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, records, 1)
+	assert.Equal(t, "recent", records[0].ReferenceID)
+	assert.Equal(t, int64(5), records[0].TotalAmount)
+
+	code = doGet(t, app, target+"ref-missing", nil)
+	assert.Equal(t, fiber.StatusNotFound, code)
+
+	code = doGet(t, app, "/v1/credits/0xother/operations/ref-1", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+}
+
+func TestHTTPControllerRecentOperations(t *testing.T) {
+	store := memstore.New()
+	_, err := store.ConfirmGrant(t.Context(), testLicenseID, testAssetDID, "0x1", 1, 100, time.Now())
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 40, "app", "ref-1")
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 10, "app", "ref-2")
+	require.NoError(t, err)
+	app := newTestApp(store)
+	target := "/v1/credits/" + testLicenseID + "/recent-operations"
+
+	var recent []creditrepo.RecentOperation
+	code := doGet(t, app, target, &recent)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, recent, 3)
+	assert.Equal(t, "ref-2", recent[0].ReferenceID)
+	assert.Equal(t, "ref-1", recent[1].ReferenceID)
+	assert.Equal(t, creditrepo.OperationTypeGrantConfirm, recent[2].OperationType)
+
+	code = doGet(t, app, target+"?limit=2", &recent)
+	require.Equal(t, fiber.StatusOK, code)
+	require.Len(t, recent, 2)
+	assert.Equal(t, "ref-2", recent[0].ReferenceID)
+	assert.Equal(t, int64(10), recent[0].TotalAmount)
+	assert.Equal(t, "ref-1", recent[1].ReferenceID)
+
+	code = doGet(t, app, target+"?limit=-1", nil)
+	assert.Equal(t, fiber.StatusBadRequest, code)
+
+	code = doGet(t, app, "/v1/credits/0x0000000000000000000000000000000000000001/recent-operations", nil)
+	assert.Equal(t, fiber.StatusUnauthorized, code)
+}
+
+func TestHTTPControllerReconcileBalance(t *testing.T) {
+	store := memstore.New()
+	_, err := store.ConfirmGrant(t.Context(), testLicenseID, testAssetDID, "0x1", 1, 100, time.Now())
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 40, "app", "ref-1")
+	require.NoError(t, err)
+	_, err = store.DeductCredits(t.Context(), testLicenseID, testAssetDID, 10, "app", "ref-2")
+	require.NoError(t, err)
+	_, err = store.RefundCredits(t.Context(), "app", "ref-2")
+	require.NoError(t, err)
+	app := newTestApp(store)
+	target := "/v1/admin/credits/" + testLicenseID + "/assets/" + url.PathEscape(testAssetDID) + "/reconciliation"
+
+	// Test: A consistent account reports the same balance both ways
+	var reconciliation BalanceReconciliation
+	code := doGet(t, app, target, &reconciliation)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, int64(60), reconciliation.LedgerBalance)
+	assert.Equal(t, int64(60), reconciliation.GrantBalance)
+	assert.Zero(t, reconciliation.Discrepancy)
+
+	// Test: A grant whose remaining amount drifted from the ledger reports the discrepancy
+	grants, err := store.ListGrants(t.Context(), testLicenseID, testAssetDID, creditrepo.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	grants[0].RemainingAmount += 5
+
+	code = doGet(t, app, target, &reconciliation)
+	require.Equal(t, fiber.StatusOK, code)
+	assert.Equal(t, int64(60), reconciliation.LedgerBalance)
+	assert.Equal(t, int64(65), reconciliation.GrantBalance)
+	assert.Equal(t, int64(5), reconciliation.Discrepancy)
+}
@@ -0,0 +1,93 @@
+package httphandlers
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/controllers/ctrlerrors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// isoDurationPattern matches the ISO-8601 durations of a fixed length: weeks, days, hours, minutes, and seconds.
+// Years and months have no fixed length and are not supported.
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// reportWindow returns the period of a usage report requested as a window ending now, such as ?window=30d.
+// It returns zero times when there is no window, and fails when the window is combined with fromDate or toDate.
+func reportWindow(fiberCtx *fiber.Ctx, now time.Time) (time.Time, time.Time, error) {
+	window := fiberCtx.Query("window")
+	if window == "" {
+		return time.Time{}, time.Time{}, nil
+	}
+	if fiberCtx.Query("fromDate") != "" || fiberCtx.Query("toDate") != "" {
+		return time.Time{}, time.Time{}, ctrlerrors.New(fiber.StatusBadRequest, ctrlerrors.CodeWindowConflict, "window can not be combined with fromDate or toDate")
+	}
+	duration, err := parseWindow(window)
+	if err != nil {
+		return time.Time{}, time.Time{}, ctrlerrors.Error{
+			InternalError: err,
+			ExternalMsg:   "Invalid window, must be a duration such as 30d, 720h, or P30D",
+			Code:          fiber.StatusBadRequest,
+			ErrorCode:     ctrlerrors.CodeInvalidWindow,
+		}
+	}
+	return now.Add(-duration), now, nil
+}
+
+// parseWindow parses a positive duration written as a number of days (30d), a Go duration (720h), or an ISO-8601 duration (P30D, PT12H).
+func parseWindow(window string) (time.Duration, error) {
+	var duration time.Duration
+	switch {
+	case strings.HasPrefix(window, "P"):
+		match := isoDurationPattern.FindStringSubmatch(window)
+		if match == nil || window == "P" || strings.HasSuffix(window, "T") {
+			return 0, fmt.Errorf("invalid ISO-8601 duration %q", window)
+		}
+		units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+		for i, unit := range units {
+			if match[i+1] == "" {
+				continue
+			}
+			part, err := scaleDuration(match[i+1], unit)
+			if err != nil {
+				return 0, err
+			}
+			if duration > math.MaxInt64-part {
+				return 0, fmt.Errorf("duration %q is too long", window)
+			}
+			duration += part
+		}
+	case strings.HasSuffix(window, "d"):
+		days, err := scaleDuration(strings.TrimSuffix(window, "d"), 24*time.Hour)
+		if err != nil {
+			return 0, err
+		}
+		duration = days
+	default:
+		parsed, err := time.ParseDuration(window)
+		if err != nil {
+			return 0, err
+		}
+		duration = parsed
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", window)
+	}
+	return duration, nil
+}
+
+// scaleDuration multiplies the unit by the decimal count, failing when the result overflows a duration.
+func scaleDuration(count string, unit time.Duration) (time.Duration, error) {
+	n, err := strconv.ParseInt(count, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid count %q", count)
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("duration of %s %s is too long", count, unit)
+	}
+	return time.Duration(n) * unit, nil
+}
@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DIMO-Network/cloudevent"
+	"github.com/DIMO-Network/credit-tracker/pkg/grpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ERC1155DIDMethod is the method for a ERC1155 token DID, e.g. did:erc1155:1:0xbA5738a18d83D41847dfFbDC6101d37C69c9B0cF:1
+	ERC1155DIDMethod = "erc1155"
+	// LegacyNFTDIDMethod is the method for a legacy NFT DID, e.g. did:nft:1:0xbA5738a18d83D41847dfFbDC6101d37C69c9B0cF_1
+	LegacyNFTDIDMethod = "nft"
+)
+
+// didDecoders are the supported asset DID decoders by DID method.
+var didDecoders = map[string]func(did string) error{
+	cloudevent.ERC721DIDMethod: func(did string) error {
+		_, err := cloudevent.DecodeERC721DID(did)
+		return err
+	},
+	ERC1155DIDMethod: decodeERC1155DID,
+	LegacyNFTDIDMethod: func(did string) error {
+		_, err := cloudevent.DecodeLegacyNFTDID(did)
+		return err
+	},
+	cloudevent.EthrDIDMethod: func(did string) error {
+		_, err := cloudevent.DecodeEthrDID(did)
+		return err
+	},
+	cloudevent.ERC20DIDMethod: func(did string) error {
+		_, err := cloudevent.DecodeERC20DID(did)
+		return err
+	},
+}
+
+// DIDValidator validates asset DIDs against a configured set of DID methods.
+type DIDValidator struct {
+	decoders map[string]func(did string) error
+}
+
+// NewDIDValidator creates a validator accepting the given DID methods.
+// Only ERC721 DIDs are accepted when no methods are given.
+func NewDIDValidator(methods []string) (*DIDValidator, error) {
+	if len(methods) == 0 {
+		methods = []string{cloudevent.ERC721DIDMethod}
+	}
+	decoders := make(map[string]func(did string) error, len(methods))
+	for _, method := range methods {
+		decoder, ok := didDecoders[method]
+		if !ok {
+			return nil, fmt.Errorf("unsupported asset DID method %q", method)
+		}
+		decoders[method] = decoder
+	}
+	return &DIDValidator{decoders: decoders}, nil
+}
+
+// Validate returns an InvalidArgument status if the DID is malformed or its method is not accepted.
+func (v *DIDValidator) Validate(assetDid string) error {
+	parts := strings.SplitN(assetDid, ":", 3)
+	if len(parts) == 3 && parts[0] == "did" {
+		if decoder, ok := v.decoders[parts[1]]; ok && decoder(assetDid) == nil {
+			return nil
+		}
+	}
+	grpcStatus := status.New(codes.InvalidArgument, "Invalid asset DID")
+	errorInfo := &errdetails.ErrorInfo{
+		Reason: grpc.ErrorReason_ERROR_REASON_INVALID_ASSET_DID.String(),
+		Domain: grpc.ErrorDomain_ERROR_DOMAIN_CREDIT_TRACKER.String(),
+		Metadata: map[string]string{
+			grpc.MetadataKey_METADATA_KEY_ASSET_DID.String(): assetDid,
+		},
+	}
+	grpcStatus, err := grpcStatus.WithDetails(errorInfo)
+	if err != nil {
+		return status.Error(codes.Internal, "Failed to create error details")
+	}
+	return grpcStatus.Err()
+}
+
+// decodeERC1155DID validates a ERC1155 DID, which has the same shape as a ERC721 DID.
+func decodeERC1155DID(did string) error {
+	parts := strings.Split(did, ":")
+	if len(parts) != 5 || parts[1] != ERC1155DIDMethod {
+		return fmt.Errorf("invalid DID, incorrect DID method %s", did)
+	}
+	parts[1] = cloudevent.ERC721DIDMethod
+	_, err := cloudevent.DecodeERC721DID(strings.Join(parts, ":"))
+	return err
+}
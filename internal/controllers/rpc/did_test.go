@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/DIMO-Network/credit-tracker/pkg/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDIDValidator(t *testing.T) {
+	allMethods := []string{"erc721", "erc1155", "nft", "ethr", "erc20"}
+	validator, err := NewDIDValidator(allMethods)
+	require.NoError(t, err)
+
+	validDIDs := map[string]string{
+		"erc721":  "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:123",
+		"erc1155": "did:erc1155:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:123",
+		"nft":     "did:nft:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8_123",
+		"ethr":    "did:ethr:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8",
+		"erc20":   "did:erc20:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8",
+	}
+	for method, did := range validDIDs {
+		t.Run("accepts "+method, func(t *testing.T) {
+			require.NoError(t, validator.Validate(did))
+		})
+	}
+
+	invalidDIDs := map[string]string{
+		"malformed erc721":  "did:erc721:80002:not-an-address:123",
+		"malformed erc1155": "did:erc1155:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8",
+		"unsupported":       "did:web:example.com",
+		"not a did":         "not-a-did",
+		"empty":             "",
+	}
+	for name, did := range invalidDIDs {
+		t.Run("rejects "+name, func(t *testing.T) {
+			assertInvalidAssetDID(t, validator.Validate(did), did)
+		})
+	}
+
+	t.Run("defaults to erc721 only", func(t *testing.T) {
+		defaultValidator, err := NewDIDValidator(nil)
+		require.NoError(t, err)
+		require.NoError(t, defaultValidator.Validate(validDIDs["erc721"]))
+		assertInvalidAssetDID(t, defaultValidator.Validate(validDIDs["erc1155"]), validDIDs["erc1155"])
+	})
+
+	t.Run("unknown configured method", func(t *testing.T) {
+		_, err := NewDIDValidator([]string{"erc721", "web"})
+		require.Error(t, err)
+	})
+}
+
+func assertInvalidAssetDID(t *testing.T, err error, did string) {
+	t.Helper()
+	grpcStatus, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, grpcStatus.Code())
+	require.Len(t, grpcStatus.Details(), 1)
+	errorInfo, ok := grpcStatus.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, grpc.ErrorReason_ERROR_REASON_INVALID_ASSET_DID.String(), errorInfo.Reason)
+	assert.Equal(t, did, errorInfo.Metadata[grpc.MetadataKey_METADATA_KEY_ASSET_DID.String()])
+}
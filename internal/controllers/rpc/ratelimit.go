@@ -0,0 +1,137 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	ctgrpc "github.com/DIMO-Network/credit-tracker/pkg/grpc"
+	"golang.org/x/time/rate"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// rateLimitedMethods are the RPCs that take grant row locks and are limited per caller.
+var rateLimitedMethods = map[string]bool{
+	ctgrpc.CreditTracker_DeductCredits_FullMethodName:      true,
+	ctgrpc.CreditTracker_BatchDeductCredits_FullMethodName: true,
+	ctgrpc.CreditTracker_RefundCredits_FullMethodName:      true,
+	ctgrpc.CreditTracker_SettleDebt_FullMethodName:         true,
+}
+
+// limiterIdleTimeout is how long the limiter of a caller is kept without requests, at least until its bucket is full again.
+const limiterIdleTimeout = 10 * time.Minute
+
+// RateLimiter is a token bucket limiter of the deduct, refund, and settle debt RPCs per developer license.
+// Refund requests carry no developer license and are limited per app name instead.
+// The limiters of idle callers are evicted, so the limiter does not grow with every caller ever seen.
+type RateLimiter struct {
+	limit       rate.Limit
+	burst       int
+	idleTimeout time.Duration
+	now         func() time.Time
+	mu          sync.Mutex
+	limiters    map[string]*callerLimiter
+	lastSweep   time.Time
+}
+
+// callerLimiter is the limiter of a caller with the time of its last request.
+type callerLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerSecond requests per caller with bursts of up to burst requests,
+// a non-positive burst allows bursts of one second of requests.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = max(1, int(requestsPerSecond))
+	}
+	// a limiter is only evicted once its bucket has refilled, so evicting it never resets a caller's limit
+	idleTimeout := limiterIdleTimeout
+	if requestsPerSecond > 0 {
+		idleTimeout = max(idleTimeout, time.Duration(float64(burst)/requestsPerSecond*float64(time.Second)))
+	}
+	return &RateLimiter{
+		limit:       rate.Limit(requestsPerSecond),
+		burst:       burst,
+		idleTimeout: idleTimeout,
+		now:         time.Now,
+		limiters:    map[string]*callerLimiter{},
+	}
+}
+
+// UnaryServerInterceptor rejects the deduct, refund, and settle debt requests of a caller past its limit with a ResourceExhausted status
+// whose RetryInfo is the wait until the next request is allowed.
+func (l *RateLimiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !rateLimitedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		key := rateLimitKey(req)
+		if key == "" {
+			return handler(ctx, req)
+		}
+		reservation := l.limiter(key).Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			return nil, rateLimitedStatus(delay)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// limiter returns the limiter of the key, creating it on first use, and evicts the idle limiters at most once per idle timeout.
+func (l *RateLimiter) limiter(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.lastSweep) >= l.idleTimeout {
+		l.evictIdle(now)
+	}
+	entry, ok := l.limiters[key]
+	if !ok {
+		entry = &callerLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter
+}
+
+// evictIdle removes the limiters without requests for the idle timeout, the caller must hold the lock.
+func (l *RateLimiter) evictIdle(now time.Time) {
+	for key, entry := range l.limiters {
+		if now.Sub(entry.lastSeen) >= l.idleTimeout {
+			delete(l.limiters, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// rateLimitKey returns the caller a request is limited by, empty when the request does not identify one.
+func rateLimitKey(req any) string {
+	switch req := req.(type) {
+	case interface{ GetDeveloperLicense() string }:
+		if license := req.GetDeveloperLicense(); license != "" {
+			return "license:" + license
+		}
+	case interface{ GetAppName() string }:
+		if appName := req.GetAppName(); appName != "" {
+			return "app:" + appName
+		}
+	}
+	return ""
+}
+
+// rateLimitedStatus creates a ResourceExhausted status with the delay before retrying in the error details.
+func rateLimitedStatus(delay time.Duration) error {
+	grpcStatus, err := status.New(codes.ResourceExhausted, "Rate limit exceeded, retry later").
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)})
+	if err != nil {
+		return status.Error(codes.Internal, "Failed to create error details")
+	}
+	return grpcStatus.Err()
+}
@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ctgrpc "github.com/DIMO-Network/credit-tracker/pkg/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	handler := func(context.Context, any) (any, error) {
+		return &ctgrpc.CreditDeductResponse{}, nil
+	}
+	call := func(interceptor grpc.UnaryServerInterceptor, method string, req any) error {
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	deduct := func(license string) *ctgrpc.CreditDeductRequest {
+		return &ctgrpc.CreditDeductRequest{DeveloperLicense: license, AssetDid: testAssetDID, Amount: 1}
+	}
+
+	t.Run("burst past the limit", func(t *testing.T) {
+		interceptor := NewRateLimiter(0.001, 3).UnaryServerInterceptor()
+		for range 3 {
+			require.NoError(t, call(interceptor, ctgrpc.CreditTracker_DeductCredits_FullMethodName, deduct("license-1")))
+		}
+
+		// Test: The request past the burst is rejected with the wait before retrying
+		err := call(interceptor, ctgrpc.CreditTracker_DeductCredits_FullMethodName, deduct("license-1"))
+		require.Error(t, err)
+		grpcStatus, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.ResourceExhausted, grpcStatus.Code())
+		require.Len(t, grpcStatus.Details(), 1)
+		retryInfo, ok := grpcStatus.Details()[0].(*errdetails.RetryInfo)
+		require.True(t, ok)
+		assert.Positive(t, retryInfo.GetRetryDelay().AsDuration())
+
+		// Verify: Other licenses and unlimited methods are not affected
+		require.NoError(t, call(interceptor, ctgrpc.CreditTracker_DeductCredits_FullMethodName, deduct("license-2")))
+		require.NoError(t, call(interceptor, ctgrpc.CreditTracker_GetDebt_FullMethodName, &ctgrpc.GetDebtRequest{DeveloperLicense: "license-1"}))
+	})
+
+	t.Run("refunds are limited per app name", func(t *testing.T) {
+		interceptor := NewRateLimiter(0.001, 1).UnaryServerInterceptor()
+		refund := &ctgrpc.RefundCreditsRequest{AppName: "app-1", ReferenceId: "ref-1"}
+		require.NoError(t, call(interceptor, ctgrpc.CreditTracker_RefundCredits_FullMethodName, refund))
+
+		err := call(interceptor, ctgrpc.CreditTracker_RefundCredits_FullMethodName, refund)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.NoError(t, call(interceptor, ctgrpc.CreditTracker_RefundCredits_FullMethodName, &ctgrpc.RefundCreditsRequest{AppName: "app-2"}))
+	})
+	t.Run("idle limiters are evicted", func(t *testing.T) {
+		limiter := NewRateLimiter(1, 1)
+		now := time.Now()
+		limiter.now = func() time.Time { return now }
+		interceptor := limiter.UnaryServerInterceptor()
+		require.NoError(t, call(interceptor, ctgrpc.CreditTracker_DeductCredits_FullMethodName, deduct("license-idle")))
+		now = now.Add(limiterIdleTimeout / 2)
+		require.NoError(t, call(interceptor, ctgrpc.CreditTracker_DeductCredits_FullMethodName, deduct("license-active")))
+		assert.Len(t, limiter.limiters, 2)
+
+		// Test: A request after the idle timeout evicts the limiters idle for that long
+		now = now.Add(limiterIdleTimeout / 2)
+		require.NoError(t, call(interceptor, ctgrpc.CreditTracker_DeductCredits_FullMethodName, deduct("license-new")))
+
+		// Verify: Only the idle limiter is evicted
+		assert.NotContains(t, limiter.limiters, "license:license-idle")
+		assert.Contains(t, limiter.limiters, "license:license-active")
+		assert.Contains(t, limiter.limiters, "license:license-new")
+	})
+}
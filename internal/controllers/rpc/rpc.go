@@ -4,41 +4,118 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
-	"github.com/DIMO-Network/cloudevent"
 	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/internal/tracing"
 	"github.com/DIMO-Network/credit-tracker/models"
 	"github.com/DIMO-Network/credit-tracker/pkg/grpc"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-const creditsFromBurn = 50_000
+const (
+	// defaultBurnCreditAmount is the number of credits a burn grants when no amount is configured.
+	defaultBurnCreditAmount = 50_000
+	// defaultMaxBurnAttempts is how many times a deduction burns credits before failing with insufficient credits.
+	defaultMaxBurnAttempts = 1
+	// maxBatchDeductItems is the maximum number of items of a BatchDeductCredits request.
+	maxBatchDeductItems = 1000
+	// maxBalancesAssets is the maximum number of assets of a GetBalances request.
+	maxBalancesAssets = 1000
+)
 
 type Repository interface {
-	DeductCredits(ctx context.Context, licenseID string, assetDID string, amount uint64, appName string, referenceID string) (*models.CreditOperation, error)
-	RefundCredits(ctx context.Context, appName string, referenceID string) (*models.CreditOperation, error)
-	GetBalance(ctx context.Context, licenseID, assetDID string) (int64, error)
+	DeductCredits(ctx context.Context, licenseID string, assetDID string, amount uint64, appName string, referenceID string, opts ...creditrepo.PoolOption) (*models.CreditOperation, error)
+	DeductCreditsBatch(ctx context.Context, licenseID, appName string, deductions []creditrepo.DeductInput) ([]creditrepo.DeductOutcome, error)
+	RefundCredits(ctx context.Context, appName string, referenceID string, opts ...creditrepo.RefundOption) (*models.CreditOperation, error)
+	GetBalance(ctx context.Context, licenseID, assetDID string, opts ...creditrepo.PoolOption) (*creditrepo.Balance, error)
+	GetBalances(ctx context.Context, licenseID string, assetDIDs []string) (map[string]*creditrepo.Balance, error)
+	GetDebt(ctx context.Context, licenseID, assetDID string) (int64, error)
+	SettleOutstandingDebt(ctx context.Context, licenseID, assetDID string) (int64, error)
+	CanDeduct(ctx context.Context, licenseID, assetDID string, amount uint64) (bool, int64, error)
+	GetAccountSnapshot(ctx context.Context, licenseID string) (*creditrepo.AccountSnapshot, error)
+	GetRecentOperations(ctx context.Context, licenseID string, limit int) ([]creditrepo.RecentOperation, error)
+	SubscribeOperations(licenseID string) (<-chan creditrepo.RecentOperation, func())
+	GetGrantByTxHash(ctx context.Context, txHash string) (*models.CreditGrant, error)
+	CreateManualGrant(ctx context.Context, licenseID, assetDID string, amount uint64, reason string) (*models.CreditGrant, error)
+	SelfTest(ctx context.Context) []creditrepo.SelfTestStep
+	GetLicenseUsageReport(ctx context.Context, licenseID string, fromDate time.Time, toDate time.Time, includePerAsset bool) (*creditrepo.LicenseUsageReport, error)
+	GetLicenseAssetUsageReport(ctx context.Context, licenseID string, assetDID string, fromDate time.Time, toDate time.Time, includeGrantTxHashes bool) (*creditrepo.LicenseAssetUsageReport, error)
 }
 
 type ContractProcessor interface {
 	CreateGrant(ctx context.Context, licenseID string, assetDID string, amount uint64) (*types.Transaction, error)
 }
 
+// LowBalanceNotifier publishes an event when the balance of a license and asset drops below the low balance threshold.
+type LowBalanceNotifier interface {
+	PublishLowBalance(ctx context.Context, licenseID, assetDID string, balance, threshold int64) error
+}
+
 // CreditTrackerServer represents the gRPC server
 type CreditTrackerServer struct {
 	grpc.UnimplementedCreditTrackerServer
-	repository        Repository
-	contractProcessor ContractProcessor
+	repository          Repository
+	contractProcessor   ContractProcessor
+	didValidator        *DIDValidator
+	lowBalanceNotifier  LowBalanceNotifier
+	lowBalanceThreshold int64
+	burnCreditAmount    uint64
+	maxBurnAttempts     int
+	watchesDone         chan struct{}
+	stopWatches         sync.Once
+}
+
+// ServerOption configures optional behavior of the gRPC server.
+type ServerOption func(*CreditTrackerServer)
+
+// WithLowBalanceNotifier notifies when a deduction drops the balance from at or above the threshold to below it.
+func WithLowBalanceNotifier(notifier LowBalanceNotifier, threshold int64) ServerOption {
+	return func(s *CreditTrackerServer) {
+		s.lowBalanceNotifier = notifier
+		s.lowBalanceThreshold = threshold
+	}
+}
+
+// WithBurnCreditAmount sets the number of credits burned for a license and asset that ran out, zero keeps the default of 50,000.
+func WithBurnCreditAmount(amount uint64) ServerOption {
+	return func(s *CreditTrackerServer) {
+		if amount > 0 {
+			s.burnCreditAmount = amount
+		}
+	}
+}
+
+// WithMaxBurnAttempts sets how many times a deduction burns credits and retries before failing with insufficient credits,
+// zero keeps the default of a single burn.
+func WithMaxBurnAttempts(attempts int) ServerOption {
+	return func(s *CreditTrackerServer) {
+		if attempts > 0 {
+			s.maxBurnAttempts = attempts
+		}
+	}
 }
 
 // NewServer creates a new instance of the gRPC server
-func NewServer(repo Repository, contractProcessor ContractProcessor) *CreditTrackerServer {
+func NewServer(repo Repository, contractProcessor ContractProcessor, didValidator *DIDValidator, opts ...ServerOption) *CreditTrackerServer {
 	server := &CreditTrackerServer{
 		repository:        repo,
 		contractProcessor: contractProcessor,
+		didValidator:      didValidator,
+		burnCreditAmount:  defaultBurnCreditAmount,
+		maxBurnAttempts:   defaultMaxBurnAttempts,
+		watchesDone:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(server)
 	}
 
 	return server
@@ -46,39 +123,198 @@ func NewServer(repo Repository, contractProcessor ContractProcessor) *CreditTrac
 
 // DeductCredits implements the gRPC service method
 func (s *CreditTrackerServer) DeductCredits(ctx context.Context, req *grpc.CreditDeductRequest) (*grpc.CreditDeductResponse, error) {
-	if _, err := decodeAssetDID(req.AssetDid); err != nil {
+	trace.SpanFromContext(ctx).SetAttributes(tracing.OperationAttributes(req.DeveloperLicense, req.AssetDid, int64(req.Amount), creditrepo.OperationTypeDeduction)...)
+	if err := s.didValidator.Validate(req.AssetDid); err != nil {
 		return nil, err
 	}
 
 	// First attempt to deduct credits
-	_, err := s.repository.DeductCredits(ctx, req.DeveloperLicense, req.AssetDid, req.Amount, req.AppName, req.ReferenceId)
-	for errors.Is(err, creditrepo.InsufficientCreditsErr) {
-		err = s.addBurnCredits(ctx, req.DeveloperLicense, req.AssetDid)
-		if err != nil {
-			return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to add credits after burn: %v", err))
+	operation, err := s.repository.DeductCredits(ctx, req.DeveloperLicense, req.AssetDid, req.Amount, req.AppName, req.ReferenceId)
+	var insufficientErr *creditrepo.InsufficientCreditsError
+	for attempt := 0; attempt < s.maxBurnAttempts && errors.As(err, &insufficientErr); attempt++ {
+		if burnErr := s.addBurnCredits(ctx, req.DeveloperLicense, req.AssetDid); burnErr != nil {
+			// the caller can not act on a failed burn, so the insufficient credits are reported instead
+			zerolog.Ctx(ctx).Error().Err(burnErr).Str("licenseId", req.DeveloperLicense).Str("assetDid", req.AssetDid).Msg("failed to add credits after burn")
+			return nil, insufficientCreditsStatus(req.DeveloperLicense, req.AssetDid, insufficientErr)
 		}
 		// Try again now that the developer should have credits
-		_, err = s.repository.DeductCredits(ctx, req.DeveloperLicense, req.AssetDid, req.Amount, req.AppName, req.ReferenceId)
-		if err != nil {
-			return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to deduct credits after burn: %v", err))
-		}
+		operation, err = s.repository.DeductCredits(ctx, req.DeveloperLicense, req.AssetDid, req.Amount, req.AppName, req.ReferenceId)
+	}
+	if errors.As(err, &insufficientErr) {
+		// every burn attempt is used, the burns did not cover the amount
+		return nil, insufficientCreditsStatus(req.DeveloperLicense, req.AssetDid, insufficientErr)
+	}
+	if errors.Is(err, creditrepo.DuplicateOperationErr) {
+		return nil, duplicateOperationStatus(req.AppName, req.ReferenceId)
 	}
 	if err != nil {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to deduct credits: %v", err))
+		return nil, deductionErrorStatus("Failed to deduct credits", err)
 	}
 
 	// Record metrics
 	CreditOperations.WithLabelValues("deduct", req.DeveloperLicense, getAmountBucket(int64(req.Amount))).Inc()
 
-	return &grpc.CreditDeductResponse{}, nil
+	resp := &grpc.CreditDeductResponse{OperationId: operation.ReferenceID}
+	balance, err := s.balanceAfter(ctx, operation)
+	if err != nil {
+		// the deduction has already been committed, failing would make the caller deduct again
+		zerolog.Ctx(ctx).Error().Err(err).Str("licenseId", operation.LicenseID).Str("assetDid", operation.AssetDid).Msg("failed to get balance after deduction")
+		return resp, nil
+	}
+	resp.RemainingBalance = balance
+	s.notifyLowBalance(ctx, operation, balance)
+
+	return resp, nil
+}
+
+// balanceAfter returns the balance after the operation, the balance recorded on the operation when snapshots are enabled
+// and the current balance otherwise.
+func (s *CreditTrackerServer) balanceAfter(ctx context.Context, operation *models.CreditOperation) (int64, error) {
+	if operation.BalanceAfter.Valid {
+		return operation.BalanceAfter.Int64, nil
+	}
+	current, err := s.repository.GetBalance(ctx, operation.LicenseID, operation.AssetDid, creditrepo.WithCreditType(operation.CreditType))
+	if err != nil {
+		return 0, err
+	}
+	return current.Balance, nil
+}
+
+// notifyLowBalance publishes a low balance event if the deduction crossed the low balance threshold with the balance after it.
+// Failures are logged, the deduction has already been committed.
+func (s *CreditTrackerServer) notifyLowBalance(ctx context.Context, operation *models.CreditOperation, balance int64) {
+	if s.lowBalanceNotifier == nil || s.lowBalanceThreshold <= 0 {
+		return
+	}
+	logger := zerolog.Ctx(ctx).With().Str("licenseId", operation.LicenseID).Str("assetDid", operation.AssetDid).Logger()
+	// only the deduction that crosses the threshold notifies, later deductions below it do not
+	if balance >= s.lowBalanceThreshold || balance+operation.TotalAmount < s.lowBalanceThreshold {
+		return
+	}
+	if err := s.lowBalanceNotifier.PublishLowBalance(ctx, operation.LicenseID, operation.AssetDid, balance, s.lowBalanceThreshold); err != nil {
+		logger.Error().Err(err).Msg("failed to publish low balance event")
+	}
+}
+
+// notifyLowBalanceAfter reads the balance after the deduction and publishes a low balance event if the deduction crossed the low balance threshold.
+// The balance is only read when low balance events are enabled.
+func (s *CreditTrackerServer) notifyLowBalanceAfter(ctx context.Context, operation *models.CreditOperation) {
+	if s.lowBalanceNotifier == nil || s.lowBalanceThreshold <= 0 {
+		return
+	}
+	balance, err := s.balanceAfter(ctx, operation)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("licenseId", operation.LicenseID).Str("assetDid", operation.AssetDid).Msg("failed to get balance for low balance check")
+		return
+	}
+	s.notifyLowBalance(ctx, operation, balance)
+}
+
+// BatchDeductCredits implements the gRPC service method
+// Items are deducted in a single transaction where each item succeeds or fails on its own.
+// Assets without enough credits get one credit burn, after which their items are retried once.
+func (s *CreditTrackerServer) BatchDeductCredits(ctx context.Context, req *grpc.BatchDeductCreditsRequest) (*grpc.BatchDeductCreditsResponse, error) {
+	trace.SpanFromContext(ctx).SetAttributes(tracing.OperationAttributes(req.DeveloperLicense, "", 0, creditrepo.OperationTypeDeduction)...)
+	if len(req.Items) > maxBatchDeductItems {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("A batch can have at most %d items", maxBatchDeductItems))
+	}
+
+	results := make([]*grpc.BatchDeductResult, len(req.Items))
+	var inputs []creditrepo.DeductInput
+	var indexes []int
+	for i, item := range req.Items {
+		results[i] = &grpc.BatchDeductResult{AssetDid: item.AssetDid, ReferenceId: item.ReferenceId}
+		if err := s.didValidator.Validate(item.AssetDid); err != nil {
+			results[i].ErrorReason = grpc.ErrorReason_ERROR_REASON_INVALID_ASSET_DID
+			results[i].Error = "Invalid asset DID"
+			continue
+		}
+		inputs = append(inputs, creditrepo.DeductInput{AssetDID: item.AssetDid, Amount: item.Amount, ReferenceID: item.ReferenceId})
+		indexes = append(indexes, i)
+	}
+	if len(inputs) == 0 {
+		return &grpc.BatchDeductCreditsResponse{Results: results}, nil
+	}
+
+	outcomes, err := s.repository.DeductCreditsBatch(ctx, req.DeveloperLicense, req.AppName, inputs)
+	if err != nil {
+		return nil, deductionErrorStatus("Failed to deduct credits", err)
+	}
+
+	// burn credits once for every asset that ran out and retry its items
+	var retryInputs []creditrepo.DeductInput
+	var retryIndexes []int
+	burned := map[string]error{}
+	for i, outcome := range outcomes {
+		if !errors.Is(outcome.Err, creditrepo.InsufficientCreditsErr) {
+			continue
+		}
+		assetDID := outcome.Input.AssetDID
+		burnErr, ok := burned[assetDID]
+		if !ok {
+			burnErr = s.addBurnCredits(ctx, req.DeveloperLicense, assetDID)
+			burned[assetDID] = burnErr
+		}
+		if burnErr != nil {
+			outcomes[i].Err = fmt.Errorf("failed to add credits after burn: %w", burnErr)
+			continue
+		}
+		retryInputs = append(retryInputs, outcome.Input)
+		retryIndexes = append(retryIndexes, i)
+	}
+	if len(retryInputs) > 0 {
+		retryOutcomes, err := s.repository.DeductCreditsBatch(ctx, req.DeveloperLicense, req.AppName, retryInputs)
+		if err != nil {
+			return nil, deductionErrorStatus("Failed to deduct credits after burn", err)
+		}
+		for i, outcome := range retryOutcomes {
+			outcomes[retryIndexes[i]] = outcome
+		}
+	}
+
+	for i, outcome := range outcomes {
+		setBatchDeductResult(results[indexes[i]], outcome)
+		if outcome.Err == nil {
+			CreditOperations.WithLabelValues("deduct", req.DeveloperLicense, getAmountBucket(outcome.Operation.TotalAmount)).Inc()
+			s.notifyLowBalanceAfter(ctx, outcome.Operation)
+		}
+	}
+
+	return &grpc.BatchDeductCreditsResponse{Results: results}, nil
+}
+
+// setBatchDeductResult sets the success or failure of a batch item from its deduction outcome.
+func setBatchDeductResult(result *grpc.BatchDeductResult, outcome creditrepo.DeductOutcome) {
+	if outcome.Err == nil {
+		result.Success = true
+		return
+	}
+	result.Error = outcome.Err.Error()
+	switch {
+	case errors.Is(outcome.Err, creditrepo.InsufficientCreditsErr):
+		result.ErrorReason = grpc.ErrorReason_ERROR_REASON_INSUFFICIENT_CREDITS
+	case errors.Is(outcome.Err, creditrepo.DuplicateOperationErr), creditrepo.IsDuplicateKeyError(outcome.Err):
+		result.ErrorReason = grpc.ErrorReason_ERROR_REASON_DUPLICATE_OPERATION
+	}
 }
 
 // RefundCredits implements the gRPC service method
 func (s *CreditTrackerServer) RefundCredits(ctx context.Context, req *grpc.RefundCreditsRequest) (*grpc.RefundCreditsResponse, error) {
-	operation, err := s.repository.RefundCredits(ctx, req.AppName, req.ReferenceId)
+	if len(req.ReasonCode) > creditrepo.MaxReasonCodeLength {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("reason code must be at most %d characters", creditrepo.MaxReasonCodeLength))
+	}
+	operation, err := s.repository.RefundCredits(ctx, req.AppName, req.ReferenceId, creditrepo.WithRefundReason(req.ReasonCode))
+	if errors.Is(err, creditrepo.DuplicateOperationErr) {
+		return nil, duplicateOperationStatus(req.AppName, req.ReferenceId)
+	}
+	if errors.Is(err, creditrepo.RefundUnavailableErr) {
+		return nil, status.Error(codes.FailedPrecondition, "The grants of the deduction were cleaned up, it can no longer be refunded")
+	}
 	if err != nil {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to refund credits: %v", err))
+		return nil, deductionErrorStatus("Failed to refund credits", err)
 	}
+	// the license and asset of a refund are only known from the refunded deduction
+	trace.SpanFromContext(ctx).SetAttributes(tracing.OperationAttributes(operation.LicenseID, operation.AssetDid, operation.TotalAmount, operation.OperationType)...)
 
 	// Record metrics
 	CreditOperations.WithLabelValues("refund", operation.LicenseID, getAmountBucket(operation.TotalAmount)).Inc()
@@ -87,24 +323,371 @@ func (s *CreditTrackerServer) RefundCredits(ctx context.Context, req *grpc.Refun
 	return &grpc.RefundCreditsResponse{}, nil
 }
 
-func decodeAssetDID(assetDid string) (cloudevent.ERC721DID, error) {
-	did, err := cloudevent.DecodeERC721DID(assetDid)
-	if err == nil {
-		return did, nil
+// SelfTest implements the gRPC service method
+func (s *CreditTrackerServer) SelfTest(ctx context.Context, req *grpc.SelfTestRequest) (*grpc.SelfTestResponse, error) {
+	steps := s.repository.SelfTest(ctx)
+	resp := &grpc.SelfTestResponse{
+		Passed: true,
+		Steps:  make([]*grpc.SelfTestStep, 0, len(steps)),
+	}
+	for _, step := range steps {
+		resp.Passed = resp.Passed && step.Passed
+		resp.Steps = append(resp.Steps, &grpc.SelfTestStep{
+			Name:   step.Name,
+			Passed: step.Passed,
+			Error:  step.Error,
+		})
 	}
-	grpcStatus := status.New(codes.InvalidArgument, "Invalid asset DID")
+	return resp, nil
+}
+
+// GetBalances implements the gRPC service method
+func (s *CreditTrackerServer) GetBalances(ctx context.Context, req *grpc.GetBalancesRequest) (*grpc.GetBalancesResponse, error) {
+	if req.DeveloperLicense == "" {
+		return nil, invalidArgumentStatus("Developer license is required", grpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE, nil)
+	}
+	if len(req.AssetDids) > maxBalancesAssets {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("At most %d assets can be requested at once", maxBalancesAssets))
+	}
+	for _, assetDID := range req.AssetDids {
+		if err := s.didValidator.Validate(assetDID); err != nil {
+			return nil, err
+		}
+	}
+
+	balances, err := s.repository.GetBalances(ctx, req.DeveloperLicense, req.AssetDids)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to get balances: %v", err))
+	}
+	resp := &grpc.GetBalancesResponse{Balances: make(map[string]*grpc.AssetBalance, len(balances))}
+	for assetDID, balance := range balances {
+		resp.Balances[assetDID] = &grpc.AssetBalance{Balance: balance.Balance, Debt: balance.Debt}
+	}
+	return resp, nil
+}
+
+// CheckCredits implements the gRPC service method
+func (s *CreditTrackerServer) CheckCredits(ctx context.Context, req *grpc.CheckCreditsRequest) (*grpc.CheckCreditsResponse, error) {
+	if req.DeveloperLicense == "" {
+		return nil, invalidArgumentStatus("Developer license is required", grpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE, nil)
+	}
+	if err := s.didValidator.Validate(req.AssetDid); err != nil {
+		return nil, err
+	}
+
+	canDeduct, balance, err := s.repository.CanDeduct(ctx, req.DeveloperLicense, req.AssetDid, req.Amount)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to check credits: %v", err))
+	}
+	return &grpc.CheckCreditsResponse{CanDeduct: canDeduct, Balance: balance}, nil
+}
+
+// GetDebt implements the gRPC service method
+func (s *CreditTrackerServer) GetDebt(ctx context.Context, req *grpc.GetDebtRequest) (*grpc.GetDebtResponse, error) {
+	if req.DeveloperLicense == "" {
+		return nil, invalidArgumentStatus("Developer license is required", grpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE, nil)
+	}
+	if err := s.didValidator.Validate(req.AssetDid); err != nil {
+		return nil, err
+	}
+
+	debt, err := s.repository.GetDebt(ctx, req.DeveloperLicense, req.AssetDid)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to get debt: %v", err))
+	}
+	return &grpc.GetDebtResponse{Debt: debt}, nil
+}
+
+// SettleDebt implements the gRPC service method
+func (s *CreditTrackerServer) SettleDebt(ctx context.Context, req *grpc.SettleDebtRequest) (*grpc.SettleDebtResponse, error) {
+	if req.DeveloperLicense == "" {
+		return nil, invalidArgumentStatus("Developer license is required", grpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE, nil)
+	}
+	if err := s.didValidator.Validate(req.AssetDid); err != nil {
+		return nil, err
+	}
+
+	settled, err := s.repository.SettleOutstandingDebt(ctx, req.DeveloperLicense, req.AssetDid)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to settle debt: %v", err))
+	}
+	return &grpc.SettleDebtResponse{SettledAmount: settled}, nil
+}
+
+// GetAccountSnapshot implements the gRPC service method
+func (s *CreditTrackerServer) GetAccountSnapshot(ctx context.Context, req *grpc.GetAccountSnapshotRequest) (*grpc.GetAccountSnapshotResponse, error) {
+	if req.DeveloperLicense == "" {
+		return nil, invalidArgumentStatus("Developer license is required", grpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE, nil)
+	}
+
+	snapshot, err := s.repository.GetAccountSnapshot(ctx, req.DeveloperLicense)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to get account snapshot: %v", err))
+	}
+	resp := &grpc.GetAccountSnapshotResponse{
+		DeveloperLicense: snapshot.LicenseID,
+		TakenAt:          timestamppb.New(snapshot.TakenAt),
+		Balance:          snapshot.Balance,
+		Debt:             snapshot.Debt,
+		PendingGrants:    pendingGrantStatsProto(snapshot.PendingGrants),
+		NextExpiration:   timestampPtr(snapshot.NextExpiration),
+	}
+	for _, operation := range snapshot.RecentOperations {
+		resp.RecentOperations = append(resp.RecentOperations, recentOperationProto(operation))
+	}
+	for _, asset := range snapshot.Assets {
+		resp.Assets = append(resp.Assets, &grpc.AssetSnapshot{
+			AssetDid:       asset.AssetDID,
+			Balance:        asset.Balance,
+			Debt:           asset.Debt,
+			PendingGrants:  pendingGrantStatsProto(asset.PendingGrants),
+			NextExpiration: timestampPtr(asset.NextExpiration),
+		})
+	}
+	return resp, nil
+}
+
+// GetGrant implements the gRPC service method
+func (s *CreditTrackerServer) GetGrant(ctx context.Context, req *grpc.GetGrantRequest) (*grpc.GetGrantResponse, error) {
+	if req.TxHash == "" {
+		return nil, status.Error(codes.InvalidArgument, "Tx hash is required")
+	}
+
+	grant, err := s.repository.GetGrantByTxHash(ctx, req.TxHash)
+	if err != nil {
+		if errors.Is(err, creditrepo.GrantNotFoundErr) {
+			return nil, status.Error(codes.NotFound, "No grant has the tx hash")
+		}
+		if errors.Is(err, creditrepo.AmbiguousGrantErr) {
+			return nil, status.Error(codes.FailedPrecondition, "Several confirmed grants have the tx hash")
+		}
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to get grant: %v", err))
+	}
+	resp := &grpc.GetGrantResponse{
+		GrantId:          grant.ID,
+		DeveloperLicense: grant.LicenseID,
+		AssetDid:         grant.AssetDid,
+		Status:           grant.Status,
+		InitialAmount:    grant.InitialAmount,
+		RemainingAmount:  grant.RemainingAmount,
+		ExpiresAt:        timestampPtr(grant.ExpiresAt.Ptr()),
+		TxHash:           grant.TXHash,
+		CreatedAt:        timestampPtr(grant.CreatedAt.Ptr()),
+	}
+	if grant.LogIndex.Valid {
+		logIndex := int64(grant.LogIndex.Int)
+		resp.LogIndex = &logIndex
+	}
+	return resp, nil
+}
+
+// AdminAddCredits implements the gRPC service method, the caller is checked to be an admin by the auth interceptor
+func (s *CreditTrackerServer) AdminAddCredits(ctx context.Context, req *grpc.AdminAddCreditsRequest) (*grpc.AdminAddCreditsResponse, error) {
+	if req.DeveloperLicense == "" {
+		return nil, invalidArgumentStatus("Developer license is required", grpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE, nil)
+	}
+	if err := s.didValidator.Validate(req.AssetDid); err != nil {
+		return nil, err
+	}
+	if req.Amount == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Amount must be positive")
+	}
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "Reason is required")
+	}
+	if len(req.Reason) > creditrepo.MaxReasonCodeLength {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("reason must be at most %d characters", creditrepo.MaxReasonCodeLength))
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(tracing.OperationAttributes(req.DeveloperLicense, req.AssetDid, int64(req.Amount), creditrepo.OperationTypeGrantConfirm)...)
+	grant, err := s.repository.CreateManualGrant(ctx, req.DeveloperLicense, req.AssetDid, req.Amount, req.Reason)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to add credits: %v", err))
+	}
+	CreditOperations.WithLabelValues("manual_grant", grant.LicenseID, getAmountBucket(grant.InitialAmount)).Inc()
+
+	return &grpc.AdminAddCreditsResponse{GrantId: grant.ID, TxHash: grant.TXHash}, nil
+}
+
+// pendingGrantStatsProto converts pending grant stats to their gRPC message.
+func pendingGrantStatsProto(stats creditrepo.PendingGrantStats) *grpc.PendingGrantStats {
+	return &grpc.PendingGrantStats{
+		Count:           stats.Count,
+		TotalAmount:     stats.TotalAmount,
+		OldestCreatedAt: timestampPtr(stats.OldestCreatedAt),
+	}
+}
+
+// timestampPtr converts an optional time to a timestamp, nil when unset.
+func timestampPtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// GetUsageReport implements the gRPC service method
+func (s *CreditTrackerServer) GetUsageReport(ctx context.Context, req *grpc.GetUsageReportRequest) (*grpc.GetUsageReportResponse, error) {
+	if req.DeveloperLicense == "" {
+		return nil, invalidArgumentStatus("Developer license is required", grpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE, nil)
+	}
+	if req.FromDate == nil {
+		return nil, invalidArgumentStatus("fromDate is required", grpc.ErrorReason_ERROR_REASON_INVALID_DATE_RANGE, map[string]string{
+			grpc.MetadataKey_METADATA_KEY_DEVELOPER_LICENSE.String(): req.DeveloperLicense,
+		})
+	}
+	fromDate := req.FromDate.AsTime()
+	var toDate time.Time
+	if req.ToDate != nil {
+		toDate = req.ToDate.AsTime()
+	}
+	if !toDate.IsZero() && fromDate.After(toDate) {
+		return nil, invalidArgumentStatus("fromDate must be before toDate", grpc.ErrorReason_ERROR_REASON_INVALID_DATE_RANGE, map[string]string{
+			grpc.MetadataKey_METADATA_KEY_DEVELOPER_LICENSE.String(): req.DeveloperLicense,
+		})
+	}
+	if fromDate.After(time.Now()) {
+		return nil, invalidArgumentStatus("fromDate cannot be in the future", grpc.ErrorReason_ERROR_REASON_INVALID_DATE_RANGE, map[string]string{
+			grpc.MetadataKey_METADATA_KEY_DEVELOPER_LICENSE.String(): req.DeveloperLicense,
+		})
+	}
+
+	if req.AssetDid == "" {
+		report, err := s.repository.GetLicenseUsageReport(ctx, req.DeveloperLicense, fromDate, toDate, req.IncludePerAsset)
+		if err != nil {
+			return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to get usage report: %v", err))
+		}
+		resp := &grpc.GetUsageReportResponse{
+			LicenseId:                   report.LicenseID,
+			FromDate:                    timestamppb.New(report.FromDate),
+			ToDate:                      optionalTimestamp(report.ToDate),
+			NumOfAssets:                 report.NumOfAssets,
+			NumOfCreditsGrantsPurchased: report.NumOfCreditsGrantsPurchased,
+			NumOfCreditsUsed:            report.NumOfCreditsUsed,
+		}
+		for _, refunds := range report.RefundsByReason {
+			resp.RefundsByReason = append(resp.RefundsByReason, &grpc.RefundReasonTotal{
+				ReasonCode:           refunds.ReasonCode,
+				NumOfRefunds:         refunds.NumOfRefunds,
+				NumOfCreditsRefunded: refunds.NumOfCreditsRefunded,
+			})
+		}
+		for _, asset := range report.PerAsset {
+			resp.PerAsset = append(resp.PerAsset, &grpc.AssetUsage{
+				AssetDid:                    asset.AssetDID,
+				NumOfCreditsUsed:            asset.NumOfCreditsUsed,
+				NumOfCreditsGrantsPurchased: asset.NumOfCreditsGrantsPurchased,
+			})
+		}
+		return resp, nil
+	}
+
+	if err := s.didValidator.Validate(req.AssetDid); err != nil {
+		return nil, err
+	}
+	report, err := s.repository.GetLicenseAssetUsageReport(ctx, req.DeveloperLicense, req.AssetDid, fromDate, toDate, req.IncludeConfirmedGrants)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("Failed to get asset usage report: %v", err))
+	}
+	resp := &grpc.GetUsageReportResponse{
+		LicenseId:                   report.LicenseID,
+		AssetDid:                    report.AssetDID,
+		FromDate:                    timestamppb.New(report.FromDate),
+		ToDate:                      optionalTimestamp(report.ToDate),
+		NumOfCreditsGrantsPurchased: report.NumOfCreditsGrantsPurchased,
+		NumOfCreditsUsed:            report.NumOfCreditsUsed,
+		CurrentCreditsRemaining:     report.CurrentCreditsRemaining,
+		NumOfCreditsGranted:         report.NumOfCreditsGranted,
+		UtilizationRate:             report.UtilizationRate,
+		ConfirmedGrants:             make([]*grpc.ConfirmedGrant, 0, len(report.ConfirmedGrants)),
+	}
+	if report.ProjectedExhaustion != nil {
+		resp.ProjectedExhaustion = timestamppb.New(*report.ProjectedExhaustion)
+	}
+	for _, grant := range report.ConfirmedGrants {
+		resp.ConfirmedGrants = append(resp.ConfirmedGrants, &grpc.ConfirmedGrant{
+			TxHash:      grant.TxHash,
+			Amount:      grant.Amount,
+			ConfirmedAt: timestamppb.New(grant.ConfirmedAt),
+		})
+	}
+	return resp, nil
+}
+
+// optionalTimestamp converts a time to a timestamp, leaving it unset for the zero time.
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// invalidArgumentStatus creates an InvalidArgument status with the reason and metadata in the error details.
+func invalidArgumentStatus(msg string, reason grpc.ErrorReason, metadata map[string]string) error {
+	grpcStatus := status.New(codes.InvalidArgument, msg)
+	errorInfo := &errdetails.ErrorInfo{
+		Reason:   reason.String(),
+		Domain:   grpc.ErrorDomain_ERROR_DOMAIN_CREDIT_TRACKER.String(),
+		Metadata: metadata,
+	}
+	grpcStatus, err := grpcStatus.WithDetails(errorInfo)
+	if err != nil {
+		return status.Error(codes.Internal, "Failed to create error details")
+	}
+	return grpcStatus.Err()
+}
+
+// duplicateOperationStatus creates an AlreadyExists status for a deduction or refund whose reference ID was already used by the app,
+// with the app name and reference ID in the error details.
+func duplicateOperationStatus(appName, referenceID string) error {
+	grpcStatus := status.New(codes.AlreadyExists, "Operation already exists")
+	errorInfo := &errdetails.ErrorInfo{
+		Reason: grpc.ErrorReason_ERROR_REASON_DUPLICATE_OPERATION.String(),
+		Domain: grpc.ErrorDomain_ERROR_DOMAIN_CREDIT_TRACKER.String(),
+		Metadata: map[string]string{
+			grpc.MetadataKey_METADATA_KEY_APP_NAME.String():     appName,
+			grpc.MetadataKey_METADATA_KEY_REFERENCE_ID.String(): referenceID,
+		},
+	}
+	grpcStatus, err := grpcStatus.WithDetails(errorInfo)
+	if err != nil {
+		return status.Error(codes.Internal, "Failed to create error details")
+	}
+	return grpcStatus.Err()
+}
+
+// deductionErrorStatus creates a ResourceExhausted status for a deduction or refund shed by the concurrency limit,
+// so the caller can retry later, an Unavailable status for one started while the server shuts down,
+// and an Internal status with the message for any other error.
+func deductionErrorStatus(msg string, err error) error {
+	if errors.Is(err, creditrepo.ConcurrencyLimitErr) {
+		return status.Error(codes.ResourceExhausted, "Too many concurrent deductions, retry later")
+	}
+	if errors.Is(err, creditrepo.ShuttingDownErr) {
+		return status.Error(codes.Unavailable, "Server is shutting down, retry on another instance")
+	}
+	return status.Error(codes.Internal, fmt.Sprintf("%s: %v", msg, err))
+}
+
+// insufficientCreditsStatus creates a FailedPrecondition status with the available, required and shortfall credits in the error details.
+func insufficientCreditsStatus(developerLicense, assetDid string, insufficientErr *creditrepo.InsufficientCreditsError) error {
+	grpcStatus := status.New(codes.FailedPrecondition, "Insufficient credits")
 	errorInfo := &errdetails.ErrorInfo{
-		Reason: grpc.ErrorReason_ERROR_REASON_INVALID_ASSET_DID.String(),
+		Reason: grpc.ErrorReason_ERROR_REASON_INSUFFICIENT_CREDITS.String(),
 		Domain: grpc.ErrorDomain_ERROR_DOMAIN_CREDIT_TRACKER.String(),
 		Metadata: map[string]string{
-			grpc.MetadataKey_METADATA_KEY_ASSET_DID.String(): assetDid,
+			grpc.MetadataKey_METADATA_KEY_ASSET_DID.String():         assetDid,
+			grpc.MetadataKey_METADATA_KEY_DEVELOPER_LICENSE.String(): developerLicense,
+			grpc.MetadataKey_METADATA_KEY_AVAILABLE_CREDITS.String(): strconv.FormatInt(insufficientErr.Available, 10),
+			grpc.MetadataKey_METADATA_KEY_REQUIRED_CREDITS.String():  strconv.FormatInt(insufficientErr.Required, 10),
+			grpc.MetadataKey_METADATA_KEY_CREDIT_SHORTFALL.String():  strconv.FormatInt(insufficientErr.Shortfall, 10),
 		},
 	}
-	grpcStatus, err = grpcStatus.WithDetails(errorInfo)
+	grpcStatus, err := grpcStatus.WithDetails(errorInfo)
 	if err != nil {
-		return cloudevent.ERC721DID{}, status.Error(codes.Internal, "Failed to create error details")
+		return status.Error(codes.Internal, "Failed to create error details")
 	}
-	return cloudevent.ERC721DID{}, grpcStatus.Err()
+	return grpcStatus.Err()
 }
 
 // HandleInsufficientCredits handles the case where the user has insufficient credits
@@ -148,7 +731,7 @@ func HandleInsufficientCredits(ctx context.Context, assetDid string, hasCredits
 // addBurnCredits adds burn credits to the user's balance then tries to deduct the amount requested.
 func (s *CreditTrackerServer) addBurnCredits(ctx context.Context, developerLicense, assetDid string) error {
 	// Add burn credits
-	_, err := s.contractProcessor.CreateGrant(ctx, developerLicense, assetDid, creditsFromBurn)
+	_, err := s.contractProcessor.CreateGrant(ctx, developerLicense, assetDid, s.burnCreditAmount)
 	if err != nil {
 		if errors.Is(err, creditrepo.GrantAlreadyExistsErr) {
 			// Someone else has already created a grant for this license and asset, so we can just return
@@ -157,7 +740,7 @@ func (s *CreditTrackerServer) addBurnCredits(ctx context.Context, developerLicen
 		return fmt.Errorf("failed to create grant transaction: %w", err)
 	}
 	// Record burn credit metric
-	CreditOperations.WithLabelValues("burn", developerLicense, getAmountBucket(creditsFromBurn)).Inc()
+	CreditOperations.WithLabelValues("burn", developerLicense, getAmountBucket(int64(s.burnCreditAmount))).Inc()
 
 	return nil
 }
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/pkg/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestInsufficientCreditsStatus(t *testing.T) {
+	testCases := []struct {
+		name              string
+		available         int64
+		required          int64
+		expectedShortfall string
+	}{
+		{name: "empty balance", available: 0, required: 10, expectedShortfall: "10"},
+		{name: "partial balance", available: 40, required: 100, expectedShortfall: "60"},
+		{name: "one short", available: 99, required: 100, expectedShortfall: "1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := insufficientCreditsStatus("license", "did:erc721:1:0x1:1", creditrepo.NewInsufficientCreditsError(tc.available, tc.required))
+			grpcStatus, ok := status.FromError(err)
+			require.True(t, ok)
+			assert.Equal(t, codes.FailedPrecondition, grpcStatus.Code())
+			require.Len(t, grpcStatus.Details(), 1)
+			errorInfo, ok := grpcStatus.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			assert.Equal(t, grpc.ErrorReason_ERROR_REASON_INSUFFICIENT_CREDITS.String(), errorInfo.Reason)
+			assert.Equal(t, tc.expectedShortfall, errorInfo.Metadata[grpc.MetadataKey_METADATA_KEY_CREDIT_SHORTFALL.String()])
+		})
+	}
+}
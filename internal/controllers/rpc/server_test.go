@@ -0,0 +1,1029 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo/memstore"
+	"github.com/DIMO-Network/credit-tracker/internal/events"
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/pkg/grpc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const testAssetDID = "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:123"
+
+func newTestServer(t *testing.T) (*CreditTrackerServer, *memstore.Store) {
+	t.Helper()
+	store := memstore.New()
+	didValidator, err := NewDIDValidator(nil)
+	require.NoError(t, err)
+	return NewServer(store, events.NewContractProcessor(store, &fakeBurner{}), didValidator), store
+}
+
+func TestServerDeductCredits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("deducts from existing credits", func(t *testing.T) {
+		server, store := newTestServer(t)
+		licenseID := "license-deduct"
+		store.AddGrant(&models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetDID,
+			InitialAmount:   100,
+			RemainingAmount: 100,
+			Status:          creditrepo.GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		})
+
+		resp, err := server.DeductCredits(ctx, &grpc.CreditDeductRequest{
+			DeveloperLicense: licenseID,
+			AssetDid:         testAssetDID,
+			Amount:           30,
+			ReferenceId:      "ref-1",
+			AppName:          "app",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), resp.RemainingBalance)
+		assert.Equal(t, "ref-1", resp.OperationId)
+
+		balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), balance.Balance)
+	})
+
+	t.Run("burns credits when there are none", func(t *testing.T) {
+		server, store := newTestServer(t)
+		licenseID := "license-burn"
+
+		_, err := server.DeductCredits(ctx, &grpc.CreditDeductRequest{
+			DeveloperLicense: licenseID,
+			AssetDid:         testAssetDID,
+			Amount:           30,
+			ReferenceId:      "ref-1",
+			AppName:          "app",
+		})
+		require.NoError(t, err)
+
+		balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(defaultBurnCreditAmount-30), balance.Balance)
+	})
+
+	t.Run("insufficient credits after burn", func(t *testing.T) {
+		server, _ := newTestServer(t)
+
+		_, err := server.DeductCredits(ctx, &grpc.CreditDeductRequest{
+			DeveloperLicense: "license-insufficient",
+			AssetDid:         testAssetDID,
+			Amount:           defaultBurnCreditAmount + 1,
+			ReferenceId:      "ref-1",
+			AppName:          "app",
+		})
+		grpcStatus, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.FailedPrecondition, grpcStatus.Code())
+	})
+
+	t.Run("insufficient credits when burn fails", func(t *testing.T) {
+		store := memstore.New()
+		didValidator, err := NewDIDValidator(nil)
+		require.NoError(t, err)
+		server := NewServer(store, failingContractProcessor{}, didValidator)
+
+		_, err = server.DeductCredits(ctx, &grpc.CreditDeductRequest{
+			DeveloperLicense: "license-burn-fails",
+			AssetDid:         testAssetDID,
+			Amount:           25,
+			ReferenceId:      "ref-1",
+			AppName:          "app",
+		})
+		grpcStatus, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.FailedPrecondition, grpcStatus.Code())
+		require.Len(t, grpcStatus.Details(), 1)
+		errorInfo, ok := grpcStatus.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, grpc.ErrorReason_ERROR_REASON_INSUFFICIENT_CREDITS.String(), errorInfo.Reason)
+		assert.Equal(t, "license-burn-fails", errorInfo.Metadata[grpc.MetadataKey_METADATA_KEY_DEVELOPER_LICENSE.String()])
+		assert.Equal(t, "25", errorInfo.Metadata[grpc.MetadataKey_METADATA_KEY_REQUIRED_CREDITS.String()])
+		assert.Equal(t, "0", errorInfo.Metadata[grpc.MetadataKey_METADATA_KEY_AVAILABLE_CREDITS.String()])
+	})
+
+	t.Run("repeated reference ID", func(t *testing.T) {
+		server, store := newTestServer(t)
+		licenseID := "license-duplicate"
+		store.AddGrant(&models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetDID,
+			InitialAmount:   100,
+			RemainingAmount: 100,
+			Status:          creditrepo.GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		})
+		req := &grpc.CreditDeductRequest{
+			DeveloperLicense: licenseID,
+			AssetDid:         testAssetDID,
+			Amount:           30,
+			ReferenceId:      "ref-1",
+			AppName:          "app",
+		}
+		_, err := server.DeductCredits(ctx, req)
+		require.NoError(t, err)
+
+		_, err = server.DeductCredits(ctx, req)
+		grpcStatus, ok := status.FromError(err)
+		require.True(t, ok)
+		assert.Equal(t, codes.AlreadyExists, grpcStatus.Code())
+		require.Len(t, grpcStatus.Details(), 1)
+		errorInfo, ok := grpcStatus.Details()[0].(*errdetails.ErrorInfo)
+		require.True(t, ok)
+		assert.Equal(t, grpc.ErrorReason_ERROR_REASON_DUPLICATE_OPERATION.String(), errorInfo.Reason)
+		assert.Equal(t, "ref-1", errorInfo.Metadata[grpc.MetadataKey_METADATA_KEY_REFERENCE_ID.String()])
+		assert.Equal(t, "app", errorInfo.Metadata[grpc.MetadataKey_METADATA_KEY_APP_NAME.String()])
+
+		// Verify: Only the first deduction was charged
+		balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), balance.Balance)
+	})
+
+	t.Run("invalid asset DID", func(t *testing.T) {
+		server, _ := newTestServer(t)
+
+		_, err := server.DeductCredits(ctx, &grpc.CreditDeductRequest{
+			DeveloperLicense: "license-invalid-did",
+			AssetDid:         "did:unknown:1",
+			Amount:           1,
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// fakeBurner returns an unsent transaction with a new nonce for every burn.
+type fakeBurner struct {
+	nonce atomic.Uint64
+}
+
+func (f *fakeBurner) BurnCredits(context.Context, string, string, uint64) (*types.Transaction, error) {
+	return types.NewTx(&types.LegacyTx{Nonce: f.nonce.Add(1)}), nil
+}
+
+// failingContractProcessor fails every credit burn.
+type failingContractProcessor struct{}
+
+func (failingContractProcessor) CreateGrant(context.Context, string, string, uint64) (*types.Transaction, error) {
+	return nil, errors.New("burn failed")
+}
+
+// recordingContractProcessor records the amount of every credit burn and fails it with err when set.
+type recordingContractProcessor struct {
+	amounts []uint64
+	err     error
+}
+
+func (r *recordingContractProcessor) CreateGrant(_ context.Context, _, _ string, amount uint64) (*types.Transaction, error) {
+	r.amounts = append(r.amounts, amount)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return types.NewTx(&types.LegacyTx{Nonce: uint64(len(r.amounts))}), nil
+}
+
+// unfundedRepository reports insufficient credits for every deduction before the fundedAt-th one,
+// as if burns did not add credits. Zero never funds the deductions.
+type unfundedRepository struct {
+	Repository
+	fundedAt   int
+	deductions int
+}
+
+func (u *unfundedRepository) DeductCredits(_ context.Context, licenseID, assetDID string, amount uint64, appName, referenceID string, _ ...creditrepo.PoolOption) (*models.CreditOperation, error) {
+	u.deductions++
+	if u.fundedAt == 0 || u.deductions < u.fundedAt {
+		return nil, creditrepo.NewInsufficientCreditsError(0, int64(amount))
+	}
+	return &models.CreditOperation{LicenseID: licenseID, AssetDid: assetDID, TotalAmount: int64(amount), AppName: appName, ReferenceID: referenceID, BalanceAfter: null.Int64From(0)}, nil
+}
+
+func TestServerBurnCreditAmount(t *testing.T) {
+	ctx := context.Background()
+	didValidator, err := NewDIDValidator(nil)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		amount   uint64
+		expected uint64
+	}{
+		"configured amount": {amount: 1234, expected: 1234},
+		"unset amount":      {amount: 0, expected: defaultBurnCreditAmount},
+	} {
+		t.Run(name, func(t *testing.T) {
+			processor := &recordingContractProcessor{err: errors.New("burn not sent")}
+			server := NewServer(memstore.New(), processor, didValidator, WithBurnCreditAmount(tc.amount))
+
+			_, err := server.DeductCredits(ctx, &grpc.CreditDeductRequest{
+				DeveloperLicense: "license-burn-amount",
+				AssetDid:         testAssetDID,
+				Amount:           10,
+				ReferenceId:      "ref-1",
+				AppName:          "app",
+			})
+			require.Error(t, err)
+			assert.Equal(t, []uint64{tc.expected}, processor.amounts)
+		})
+	}
+}
+
+func TestServerMaxBurnAttempts(t *testing.T) {
+	ctx := context.Background()
+	didValidator, err := NewDIDValidator(nil)
+	require.NoError(t, err)
+	req := &grpc.CreditDeductRequest{
+		DeveloperLicense: "license-burn-attempts",
+		AssetDid:         testAssetDID,
+		Amount:           10,
+		ReferenceId:      "ref-1",
+		AppName:          "app",
+	}
+
+	t.Run("stops after the configured attempts", func(t *testing.T) {
+		for _, attempts := range []int{0, 1, 3} {
+			repo := &unfundedRepository{}
+			processor := &recordingContractProcessor{}
+			server := NewServer(repo, processor, didValidator, WithMaxBurnAttempts(attempts))
+
+			_, err := server.DeductCredits(ctx, req)
+			assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+			expected := max(attempts, defaultMaxBurnAttempts)
+			assert.Len(t, processor.amounts, expected)
+			assert.Equal(t, expected+1, repo.deductions)
+		}
+	})
+
+	t.Run("succeeds once a burn covers the amount", func(t *testing.T) {
+		repo := &unfundedRepository{fundedAt: 3}
+		processor := &recordingContractProcessor{}
+		server := NewServer(repo, processor, didValidator, WithMaxBurnAttempts(3))
+
+		_, err := server.DeductCredits(ctx, req)
+		require.NoError(t, err)
+		assert.Len(t, processor.amounts, 2)
+		assert.Equal(t, 3, repo.deductions)
+	})
+}
+
+// sheddingRepository sheds every deduction and refund as if the concurrency limit was reached.
+type sheddingRepository struct {
+	Repository
+}
+
+func (sheddingRepository) DeductCredits(context.Context, string, string, uint64, string, string, ...creditrepo.PoolOption) (*models.CreditOperation, error) {
+	return nil, creditrepo.ConcurrencyLimitErr
+}
+
+func (sheddingRepository) RefundCredits(context.Context, string, string, ...creditrepo.RefundOption) (*models.CreditOperation, error) {
+	return nil, creditrepo.ConcurrencyLimitErr
+}
+
+func TestServerConcurrencyLimit(t *testing.T) {
+	ctx := context.Background()
+	didValidator, err := NewDIDValidator(nil)
+	require.NoError(t, err)
+	server := NewServer(sheddingRepository{}, &recordingContractProcessor{}, didValidator)
+
+	_, err = server.DeductCredits(ctx, &grpc.CreditDeductRequest{
+		DeveloperLicense: "license-shed",
+		AssetDid:         testAssetDID,
+		Amount:           10,
+		ReferenceId:      "ref-1",
+		AppName:          "app",
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = server.RefundCredits(ctx, &grpc.RefundCreditsRequest{AppName: "app", ReferenceId: "ref-1"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// drainingRepository rejects every deduction as if the repository was draining for shutdown.
+type drainingRepository struct {
+	Repository
+}
+
+func (drainingRepository) DeductCredits(context.Context, string, string, uint64, string, string, ...creditrepo.PoolOption) (*models.CreditOperation, error) {
+	return nil, creditrepo.ShuttingDownErr
+}
+
+func TestServerShuttingDown(t *testing.T) {
+	didValidator, err := NewDIDValidator(nil)
+	require.NoError(t, err)
+	server := NewServer(drainingRepository{}, &recordingContractProcessor{}, didValidator)
+
+	_, err = server.DeductCredits(context.Background(), &grpc.CreditDeductRequest{
+		DeveloperLicense: "license-draining",
+		AssetDid:         testAssetDID,
+		Amount:           10,
+		ReferenceId:      "ref-1",
+		AppName:          "app",
+	})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestServerRefundCredits(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-refund"
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 100,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	_, err := store.DeductCredits(ctx, licenseID, testAssetDID, 40, "app", "ref-1")
+	require.NoError(t, err)
+
+	_, err = server.RefundCredits(ctx, &grpc.RefundCreditsRequest{AppName: "app", ReferenceId: "ref-1"})
+	require.NoError(t, err)
+
+	balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), balance.Balance)
+
+	// refunding twice fails
+	_, err = server.RefundCredits(ctx, &grpc.RefundCreditsRequest{AppName: "app", ReferenceId: "ref-1"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// the reason code is recorded and broken down in the usage report
+	_, err = store.DeductCredits(ctx, licenseID, testAssetDID, 10, "app", "ref-2")
+	require.NoError(t, err)
+	_, err = server.RefundCredits(ctx, &grpc.RefundCreditsRequest{AppName: "app", ReferenceId: "ref-2", ReasonCode: "upstream_failure"})
+	require.NoError(t, err)
+	report, err := store.GetLicenseUsageReport(ctx, licenseID, time.Now().Add(-time.Hour), time.Time{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []creditrepo.RefundReasonTotal{
+		{ReasonCode: "", NumOfRefunds: 1, NumOfCreditsRefunded: 40},
+		{ReasonCode: "upstream_failure", NumOfRefunds: 1, NumOfCreditsRefunded: 10},
+	}, report.RefundsByReason)
+
+	_, err = server.RefundCredits(ctx, &grpc.RefundCreditsRequest{AppName: "app", ReferenceId: "ref-2", ReasonCode: strings.Repeat("x", creditrepo.MaxReasonCodeLength+1)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerGetUsageReport(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-report"
+	_, err := store.ConfirmGrant(ctx, licenseID, testAssetDID, "0x1", 1, 100, time.Now())
+	require.NoError(t, err)
+	_, err = store.DeductCredits(ctx, licenseID, testAssetDID, 25, "app", "ref-1")
+	require.NoError(t, err)
+	fromDate := timestamppb.New(time.Now().Add(-time.Hour))
+
+	t.Run("license report", func(t *testing.T) {
+		resp, err := server.GetUsageReport(ctx, &grpc.GetUsageReportRequest{DeveloperLicense: licenseID, FromDate: fromDate})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.NumOfAssets)
+		assert.Equal(t, int64(1), resp.NumOfCreditsGrantsPurchased)
+		assert.Equal(t, int64(25), resp.NumOfCreditsUsed)
+		assert.Empty(t, resp.PerAsset)
+	})
+
+	t.Run("license report per asset", func(t *testing.T) {
+		resp, err := server.GetUsageReport(ctx, &grpc.GetUsageReportRequest{DeveloperLicense: licenseID, FromDate: fromDate, IncludePerAsset: true})
+		require.NoError(t, err)
+		require.Len(t, resp.PerAsset, 1)
+		assert.Equal(t, testAssetDID, resp.PerAsset[0].AssetDid)
+		assert.Equal(t, int64(25), resp.PerAsset[0].NumOfCreditsUsed)
+		assert.Equal(t, int64(1), resp.PerAsset[0].NumOfCreditsGrantsPurchased)
+	})
+
+	t.Run("asset report", func(t *testing.T) {
+		resp, err := server.GetUsageReport(ctx, &grpc.GetUsageReportRequest{
+			DeveloperLicense:       licenseID,
+			AssetDid:               testAssetDID,
+			FromDate:               fromDate,
+			IncludeConfirmedGrants: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(25), resp.NumOfCreditsUsed)
+		assert.Equal(t, int64(75), resp.CurrentCreditsRemaining)
+		assert.Equal(t, int64(100), resp.NumOfCreditsGranted)
+		require.Len(t, resp.ConfirmedGrants, 1)
+		assert.Equal(t, "0x1", resp.ConfirmedGrants[0].TxHash)
+	})
+
+	t.Run("missing fromDate", func(t *testing.T) {
+		_, err := server.GetUsageReport(ctx, &grpc.GetUsageReportRequest{DeveloperLicense: licenseID})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+type lowBalanceEvent struct {
+	licenseID string
+	assetDID  string
+	balance   int64
+}
+
+// fakeNotifier records the low balance events it was asked to publish.
+type fakeNotifier struct {
+	events []lowBalanceEvent
+	err    error
+}
+
+func (n *fakeNotifier) PublishLowBalance(_ context.Context, licenseID, assetDID string, balance, _ int64) error {
+	n.events = append(n.events, lowBalanceEvent{licenseID: licenseID, assetDID: assetDID, balance: balance})
+	return n.err
+}
+
+func TestServerLowBalanceNotification(t *testing.T) {
+	ctx := context.Background()
+	newServer := func(t *testing.T, notifier *fakeNotifier) (*CreditTrackerServer, string) {
+		t.Helper()
+		store := memstore.New()
+		licenseID := "license-low-balance"
+		store.AddGrant(&models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetDID,
+			InitialAmount:   100,
+			RemainingAmount: 100,
+			Status:          creditrepo.GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		})
+		didValidator, err := NewDIDValidator(nil)
+		require.NoError(t, err)
+		return NewServer(store, events.NewContractProcessor(store, &fakeBurner{}), didValidator, WithLowBalanceNotifier(notifier, 50)), licenseID
+	}
+	deduct := func(t *testing.T, server *CreditTrackerServer, licenseID string, amount uint64, referenceID string) {
+		t.Helper()
+		_, err := server.DeductCredits(ctx, &grpc.CreditDeductRequest{
+			DeveloperLicense: licenseID,
+			AssetDid:         testAssetDID,
+			Amount:           amount,
+			ReferenceId:      referenceID,
+			AppName:          "app",
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("no event above the threshold", func(t *testing.T) {
+		notifier := &fakeNotifier{}
+		server, licenseID := newServer(t, notifier)
+		deduct(t, server, licenseID, 30, "ref-1")
+		deduct(t, server, licenseID, 20, "ref-2")
+		assert.Empty(t, notifier.events)
+	})
+
+	t.Run("one event when crossing the threshold", func(t *testing.T) {
+		notifier := &fakeNotifier{}
+		server, licenseID := newServer(t, notifier)
+		deduct(t, server, licenseID, 30, "ref-1")
+		deduct(t, server, licenseID, 30, "ref-2")
+		deduct(t, server, licenseID, 10, "ref-3")
+		require.Len(t, notifier.events, 1)
+		assert.Equal(t, lowBalanceEvent{licenseID: licenseID, assetDID: testAssetDID, balance: 40}, notifier.events[0])
+	})
+
+	t.Run("publish failure does not fail the deduction", func(t *testing.T) {
+		notifier := &fakeNotifier{err: errors.New("broker down")}
+		server, licenseID := newServer(t, notifier)
+		deduct(t, server, licenseID, 60, "ref-1")
+		assert.Len(t, notifier.events, 1)
+	})
+}
+
+func TestServerBatchDeductCredits(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-batch"
+	fundedAsset := "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:1"
+	unfundedAsset := "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:2"
+	burnedAsset := "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:3"
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        fundedAsset,
+		InitialAmount:   100,
+		RemainingAmount: 100,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+
+	resp, err := server.BatchDeductCredits(ctx, &grpc.BatchDeductCreditsRequest{
+		DeveloperLicense: licenseID,
+		AppName:          "app",
+		Items: []*grpc.BatchDeductItem{
+			{AssetDid: fundedAsset, Amount: 40, ReferenceId: "batch-1"},
+			{AssetDid: unfundedAsset, Amount: defaultBurnCreditAmount + 1, ReferenceId: "batch-2"},
+			{AssetDid: "not-a-did", Amount: 1, ReferenceId: "batch-3"},
+			{AssetDid: fundedAsset, Amount: 100, ReferenceId: "batch-4"},
+			{AssetDid: burnedAsset, Amount: 10, ReferenceId: "batch-5"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 5)
+
+	assert.True(t, resp.Results[0].Success)
+	assert.Equal(t, "batch-1", resp.Results[0].ReferenceId)
+	assert.False(t, resp.Results[1].Success)
+	assert.Equal(t, grpc.ErrorReason_ERROR_REASON_INSUFFICIENT_CREDITS, resp.Results[1].ErrorReason)
+	assert.False(t, resp.Results[2].Success)
+	assert.Equal(t, grpc.ErrorReason_ERROR_REASON_INVALID_ASSET_DID, resp.Results[2].ErrorReason)
+	// the funded asset still has an active grant, so no credits are burned for it
+	assert.False(t, resp.Results[3].Success)
+	assert.Equal(t, grpc.ErrorReason_ERROR_REASON_INSUFFICIENT_CREDITS, resp.Results[3].ErrorReason)
+	// the asset without credits is deducted after a burn
+	assert.True(t, resp.Results[4].Success)
+
+	balance, err := store.GetBalance(ctx, licenseID, fundedAsset)
+	require.NoError(t, err)
+	assert.Equal(t, int64(60), balance.Balance)
+	balance, err = store.GetBalance(ctx, licenseID, burnedAsset)
+	require.NoError(t, err)
+	assert.Equal(t, int64(defaultBurnCreditAmount-10), balance.Balance)
+
+	_, err = server.BatchDeductCredits(ctx, &grpc.BatchDeductCreditsRequest{
+		DeveloperLicense: licenseID,
+		Items:            make([]*grpc.BatchDeductItem, maxBatchDeductItems+1),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerGetBalances(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-balances"
+	assetDIDs := make([]string, 50)
+	for i := range assetDIDs {
+		assetDIDs[i] = fmt.Sprintf("did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:%d", i)
+		if i%2 == 0 {
+			continue
+		}
+		store.AddGrant(&models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        assetDIDs[i],
+			InitialAmount:   100,
+			RemainingAmount: int64(i),
+			Status:          creditrepo.GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		})
+	}
+
+	resp, err := server.GetBalances(ctx, &grpc.GetBalancesRequest{DeveloperLicense: licenseID, AssetDids: assetDIDs})
+	require.NoError(t, err)
+	require.Len(t, resp.Balances, 50)
+	for i, assetDID := range assetDIDs {
+		expected := int64(i)
+		if i%2 == 0 {
+			// assets without credits are still in the result
+			expected = 0
+		}
+		require.Contains(t, resp.Balances, assetDID)
+		assert.Equal(t, expected, resp.Balances[assetDID].Balance)
+	}
+
+	// an asset whose only grant failed has nothing to spend but reports its debt
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDIDs[0],
+		InitialAmount:   100,
+		RemainingAmount: 30,
+		Status:          creditrepo.GrantStatusFailed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	resp, err = server.GetBalances(ctx, &grpc.GetBalancesRequest{DeveloperLicense: licenseID, AssetDids: assetDIDs[:1]})
+	require.NoError(t, err)
+	require.Contains(t, resp.Balances, assetDIDs[0])
+	assert.Equal(t, int64(0), resp.Balances[assetDIDs[0]].Balance)
+	assert.Equal(t, int64(70), resp.Balances[assetDIDs[0]].Debt)
+
+	_, err = server.GetBalances(ctx, &grpc.GetBalancesRequest{DeveloperLicense: licenseID, AssetDids: []string{"did:unknown:1"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.GetBalances(ctx, &grpc.GetBalancesRequest{AssetDids: assetDIDs})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerGetDebt(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-debt"
+	assetDID := "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:1"
+
+	// an asset with credits and no failed grants owes nothing
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDID,
+		InitialAmount:   100,
+		RemainingAmount: 100,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	resp, err := server.GetDebt(ctx, &grpc.GetDebtRequest{DeveloperLicense: licenseID, AssetDid: assetDID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), resp.Debt)
+
+	// a failed grant owes the credits spent from it
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDID,
+		InitialAmount:   100,
+		RemainingAmount: 25,
+		Status:          creditrepo.GrantStatusFailed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	resp, err = server.GetDebt(ctx, &grpc.GetDebtRequest{DeveloperLicense: licenseID, AssetDid: assetDID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(75), resp.Debt)
+
+	_, err = server.GetDebt(ctx, &grpc.GetDebtRequest{AssetDid: assetDID})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.GetDebt(ctx, &grpc.GetDebtRequest{DeveloperLicense: licenseID, AssetDid: "did:unknown:1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerSettleDebt(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-settle"
+
+	// without debt nothing is settled
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   50,
+		RemainingAmount: 50,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	resp, err := server.SettleDebt(ctx, &grpc.SettleDebtRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), resp.SettledAmount)
+
+	// the debt is settled as far as the balance covers it
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 25,
+		Status:          creditrepo.GrantStatusFailed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+	resp, err = server.SettleDebt(ctx, &grpc.SettleDebtRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), resp.SettledAmount)
+	debt, err := server.GetDebt(ctx, &grpc.GetDebtRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), debt.Debt)
+
+	// with the balance used up nothing more is settled
+	resp, err = server.SettleDebt(ctx, &grpc.SettleDebtRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), resp.SettledAmount)
+
+	_, err = server.SettleDebt(ctx, &grpc.SettleDebtRequest{AssetDid: testAssetDID})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.SettleDebt(ctx, &grpc.SettleDebtRequest{DeveloperLicense: licenseID, AssetDid: "did:unknown:1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerCheckCredits(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-check"
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        testAssetDID,
+		InitialAmount:   100,
+		RemainingAmount: 100,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	})
+
+	t.Run("sufficient balance", func(t *testing.T) {
+		resp, err := server.CheckCredits(ctx, &grpc.CheckCreditsRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID, Amount: 100})
+		require.NoError(t, err)
+		assert.True(t, resp.CanDeduct)
+		assert.Equal(t, int64(100), resp.Balance)
+
+		// Verify: Nothing was deducted
+		balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance.Balance)
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		resp, err := server.CheckCredits(ctx, &grpc.CheckCreditsRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID, Amount: 101})
+		require.NoError(t, err)
+		assert.False(t, resp.CanDeduct)
+		assert.Equal(t, int64(100), resp.Balance)
+	})
+
+	t.Run("debt present", func(t *testing.T) {
+		store.AddGrant(&models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetDID,
+			InitialAmount:   100,
+			RemainingAmount: 90,
+			Status:          creditrepo.GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		})
+		resp, err := server.CheckCredits(ctx, &grpc.CheckCreditsRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID, Amount: 10})
+		require.NoError(t, err)
+		assert.False(t, resp.CanDeduct)
+		assert.Equal(t, int64(100), resp.Balance)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := server.CheckCredits(ctx, &grpc.CheckCreditsRequest{AssetDid: testAssetDID, Amount: 10})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = server.CheckCredits(ctx, &grpc.CheckCreditsRequest{DeveloperLicense: licenseID, AssetDid: "did:unknown:1", Amount: 10})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestServerGetAccountSnapshot(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-snapshot"
+	assetDIDs := []string{
+		"did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:1",
+		"did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:2",
+	}
+	expiresAt := time.Now().Add(time.Hour)
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDIDs[0],
+		InitialAmount:   100,
+		RemainingAmount: 100,
+		Status:          creditrepo.GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(expiresAt),
+	})
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDIDs[1],
+		InitialAmount:   50,
+		RemainingAmount: 50,
+		Status:          creditrepo.GrantStatusPending,
+		ExpiresAt:       null.TimeFrom(expiresAt.Add(time.Hour)),
+	})
+	store.AddGrant(&models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDIDs[1],
+		InitialAmount:   100,
+		RemainingAmount: 80,
+		Status:          creditrepo.GrantStatusFailed,
+		ExpiresAt:       null.TimeFrom(expiresAt),
+	})
+	_, err := store.DeductCredits(ctx, licenseID, assetDIDs[0], 30, "test-app", "ref-snapshot")
+	require.NoError(t, err)
+
+	resp, err := server.GetAccountSnapshot(ctx, &grpc.GetAccountSnapshotRequest{DeveloperLicense: licenseID})
+	require.NoError(t, err)
+	require.Len(t, resp.Assets, 2)
+	assert.Equal(t, assetDIDs[0], resp.Assets[0].AssetDid)
+	assert.Equal(t, int64(70), resp.Assets[0].Balance)
+	assert.Equal(t, int64(50), resp.Assets[1].Balance)
+	assert.Equal(t, int64(20), resp.Assets[1].Debt)
+	assert.Equal(t, int64(1), resp.Assets[1].PendingGrants.Count)
+	assert.Nil(t, resp.Assets[0].PendingGrants.OldestCreatedAt)
+
+	// the totals are the sums of the assets
+	assert.Equal(t, resp.Assets[0].Balance+resp.Assets[1].Balance, resp.Balance)
+	assert.Equal(t, resp.Assets[0].Debt+resp.Assets[1].Debt, resp.Debt)
+	assert.Equal(t, int64(1), resp.PendingGrants.Count)
+	assert.Equal(t, int64(50), resp.PendingGrants.TotalAmount)
+	require.NotNil(t, resp.NextExpiration)
+	assert.WithinDuration(t, expiresAt, resp.NextExpiration.AsTime(), time.Millisecond)
+	require.Len(t, resp.RecentOperations, 1)
+	assert.Equal(t, "ref-snapshot", resp.RecentOperations[0].ReferenceId)
+
+	_, err = server.GetAccountSnapshot(ctx, &grpc.GetAccountSnapshotRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerGetGrant(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-get-grant"
+	addGrant := func(txHash, status string, logIndex null.Int) *models.CreditGrant {
+		return store.AddGrant(&models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetDID,
+			InitialAmount:   100,
+			RemainingAmount: 60,
+			TXHash:          txHash,
+			Status:          status,
+			LogIndex:        logIndex,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		})
+	}
+
+	t.Run("found", func(t *testing.T) {
+		grant := addGrant("0xget-grant", creditrepo.GrantStatusConfirmed, null.IntFrom(3))
+
+		resp, err := server.GetGrant(ctx, &grpc.GetGrantRequest{TxHash: grant.TXHash})
+		require.NoError(t, err)
+		assert.Equal(t, grant.ID, resp.GrantId)
+		assert.Equal(t, licenseID, resp.DeveloperLicense)
+		assert.Equal(t, testAssetDID, resp.AssetDid)
+		assert.Equal(t, creditrepo.GrantStatusConfirmed, resp.Status)
+		assert.Equal(t, int64(100), resp.InitialAmount)
+		assert.Equal(t, int64(60), resp.RemainingAmount)
+		assert.Equal(t, grant.ExpiresAt.Time.Unix(), resp.ExpiresAt.AsTime().Unix())
+		require.NotNil(t, resp.LogIndex)
+		assert.Equal(t, int64(3), *resp.LogIndex)
+	})
+
+	t.Run("pending grant has no log index", func(t *testing.T) {
+		grant := addGrant("0xget-grant-pending", creditrepo.GrantStatusPending, null.Int{})
+
+		resp, err := server.GetGrant(ctx, &grpc.GetGrantRequest{TxHash: grant.TXHash})
+		require.NoError(t, err)
+		assert.Equal(t, creditrepo.GrantStatusPending, resp.Status)
+		assert.Nil(t, resp.LogIndex)
+	})
+
+	t.Run("confirmed grant preferred over a failed pending grant", func(t *testing.T) {
+		confirmed := addGrant("0xget-grant-shared", creditrepo.GrantStatusConfirmed, null.IntFrom(0))
+		addGrant("0xget-grant-shared", creditrepo.GrantStatusFailed, null.Int{})
+
+		resp, err := server.GetGrant(ctx, &grpc.GetGrantRequest{TxHash: "0xget-grant-shared"})
+		require.NoError(t, err)
+		assert.Equal(t, confirmed.ID, resp.GrantId)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := server.GetGrant(ctx, &grpc.GetGrantRequest{TxHash: "0xget-grant-missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("several confirmed grants", func(t *testing.T) {
+		addGrant("0xget-grant-ambiguous", creditrepo.GrantStatusConfirmed, null.IntFrom(0))
+		addGrant("0xget-grant-ambiguous", creditrepo.GrantStatusConfirmed, null.IntFrom(1))
+
+		_, err := server.GetGrant(ctx, &grpc.GetGrantRequest{TxHash: "0xget-grant-ambiguous"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("missing tx hash", func(t *testing.T) {
+		_, err := server.GetGrant(ctx, &grpc.GetGrantRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestServerAdminAddCredits(t *testing.T) {
+	ctx := context.Background()
+	server, store := newTestServer(t)
+	licenseID := "license-admin-add"
+
+	resp, err := server.AdminAddCredits(ctx, &grpc.AdminAddCreditsRequest{
+		DeveloperLicense: licenseID,
+		AssetDid:         testAssetDID,
+		Amount:           250,
+		Reason:           "goodwill",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.GrantId)
+	assert.NotEmpty(t, resp.TxHash)
+
+	balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(250), balance.Balance)
+
+	_, err = server.AdminAddCredits(ctx, &grpc.AdminAddCreditsRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID, Amount: 250})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.AdminAddCredits(ctx, &grpc.AdminAddCreditsRequest{DeveloperLicense: licenseID, AssetDid: testAssetDID, Reason: "goodwill"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+type fakeWatchStream struct {
+	grpc.CreditTracker_WatchOperationsServer
+	ctx  context.Context
+	sent chan *grpc.RecentOperation
+}
+
+func newFakeWatchStream(ctx context.Context) *fakeWatchStream {
+	return &fakeWatchStream{ctx: ctx, sent: make(chan *grpc.RecentOperation, 10)}
+}
+
+func (f *fakeWatchStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchStream) Send(operation *grpc.RecentOperation) error {
+	f.sent <- operation
+	return nil
+}
+
+func (f *fakeWatchStream) next(t *testing.T) *grpc.RecentOperation {
+	t.Helper()
+	select {
+	case operation := <-f.sent:
+		return operation
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no operation was sent")
+		return nil
+	}
+}
+
+func TestServerWatchOperations(t *testing.T) {
+	addGrant := func(store *memstore.Store, licenseID string) {
+		store.AddGrant(&models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetDID,
+			InitialAmount:   100,
+			RemainingAmount: 100,
+			Status:          creditrepo.GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		})
+	}
+	watch := func(server *CreditTrackerServer, req *grpc.WatchOperationsRequest, stream *fakeWatchStream) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- server.WatchOperations(req, stream)
+		}()
+		return done
+	}
+	waitDone := func(t *testing.T, done <-chan error) error {
+		t.Helper()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "the stream did not end")
+			return nil
+		}
+	}
+
+	t.Run("streams the backfill then new deductions", func(t *testing.T) {
+		server, store := newTestServer(t)
+		licenseID := "license-watch"
+		addGrant(store, licenseID)
+		_, err := server.DeductCredits(context.Background(), &grpc.CreditDeductRequest{
+			DeveloperLicense: licenseID, AssetDid: testAssetDID, Amount: 10, AppName: "app", ReferenceId: "watch-1",
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream := newFakeWatchStream(ctx)
+		done := watch(server, &grpc.WatchOperationsRequest{DeveloperLicense: licenseID}, stream)
+
+		// Verify: The operations committed before watching are sent first
+		assert.Equal(t, "watch-1", stream.next(t).ReferenceId)
+
+		// Test: Deduct while watching
+		_, err = server.DeductCredits(context.Background(), &grpc.CreditDeductRequest{
+			DeveloperLicense: licenseID, AssetDid: testAssetDID, Amount: 15, AppName: "app", ReferenceId: "watch-2",
+		})
+		require.NoError(t, err)
+
+		// Verify: The deduction is delivered on the stream
+		operation := stream.next(t)
+		assert.Equal(t, "watch-2", operation.ReferenceId)
+		assert.Equal(t, creditrepo.OperationTypeDeduction, operation.OperationType)
+		assert.Equal(t, int64(15), operation.TotalAmount)
+		assert.Equal(t, testAssetDID, operation.AssetDid)
+
+		// Verify: Operations of other licenses are not delivered
+		addGrant(store, "license-watch-other")
+		_, err = server.DeductCredits(context.Background(), &grpc.CreditDeductRequest{
+			DeveloperLicense: "license-watch-other", AssetDid: testAssetDID, Amount: 5, AppName: "app", ReferenceId: "watch-3",
+		})
+		require.NoError(t, err)
+
+		// Verify: Cancelling the stream ends it
+		cancel()
+		err = waitDone(t, done)
+		assert.Equal(t, codes.Canceled, status.Code(err))
+		assert.Empty(t, stream.sent)
+	})
+
+	t.Run("stopping the watches ends the stream", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		done := watch(server, &grpc.WatchOperationsRequest{DeveloperLicense: "license-watch-stop"}, newFakeWatchStream(context.Background()))
+
+		server.StopWatches()
+		err := waitDone(t, done)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("requires a license", func(t *testing.T) {
+		server, _ := newTestServer(t)
+		err := server.WatchOperations(&grpc.WatchOperationsRequest{}, newFakeWatchStream(context.Background()))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/pkg/grpc"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// operationKey identifies an operation, the operations table has one row per app name, reference ID, and operation type.
+type operationKey struct {
+	appName       string
+	referenceID   string
+	operationType string
+}
+
+// WatchOperations implements the gRPC service method
+// The stream ends when the caller cancels it, falls too far behind, or StopWatches is called.
+func (s *CreditTrackerServer) WatchOperations(req *grpc.WatchOperationsRequest, stream grpc.CreditTracker_WatchOperationsServer) error {
+	if req.DeveloperLicense == "" {
+		return invalidArgumentStatus("Developer license is required", grpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE, nil)
+	}
+	if req.BackfillLimit < 0 {
+		return status.Error(codes.InvalidArgument, "Backfill limit must not be negative")
+	}
+	ctx := stream.Context()
+
+	// subscribing before the backfill is read makes sure operations committed in between are not missed
+	operations, unsubscribe := s.repository.SubscribeOperations(req.DeveloperLicense)
+	defer unsubscribe()
+
+	recent, err := s.repository.GetRecentOperations(ctx, req.DeveloperLicense, int(req.BackfillLimit))
+	if err != nil {
+		return status.Error(codes.Internal, fmt.Sprintf("Failed to get recent operations: %v", err))
+	}
+	backfilled := make(map[operationKey]struct{}, len(recent))
+	for _, operation := range slices.Backward(recent) {
+		if err := stream.Send(recentOperationProto(operation)); err != nil {
+			return err
+		}
+		backfilled[operationKey{operation.AppName, operation.ReferenceID, operation.OperationType}] = struct{}{}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.watchesDone:
+			return status.Error(codes.Unavailable, "Server is shutting down, watch again to continue")
+		case operation, ok := <-operations:
+			if !ok {
+				zerolog.Ctx(ctx).Warn().Str("licenseId", req.DeveloperLicense).Msg("operation watcher fell behind")
+				return status.Error(codes.Unavailable, "Fell too far behind the operations, watch again to catch up")
+			}
+			key := operationKey{operation.AppName, operation.ReferenceID, operation.OperationType}
+			if _, ok := backfilled[key]; ok {
+				// committed after subscribing but already read by the backfill
+				delete(backfilled, key)
+				continue
+			}
+			if err := stream.Send(recentOperationProto(operation)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// StopWatches ends every open WatchOperations stream and the ones opened later, so a graceful stop of the server is not held up by them.
+func (s *CreditTrackerServer) StopWatches() {
+	s.stopWatches.Do(func() {
+		close(s.watchesDone)
+	})
+}
+
+// recentOperationProto converts a recent operation to its gRPC message.
+func recentOperationProto(operation creditrepo.RecentOperation) *grpc.RecentOperation {
+	return &grpc.RecentOperation{
+		AssetDid:      operation.AssetDID,
+		OperationType: operation.OperationType,
+		TotalAmount:   operation.TotalAmount,
+		AppName:       operation.AppName,
+		ReferenceId:   operation.ReferenceID,
+		CreatedAt:     timestamppb.New(operation.CreatedAt),
+	}
+}
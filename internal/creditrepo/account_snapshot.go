@@ -0,0 +1,161 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+)
+
+// accountSnapshotQuery computes the spendable balance of the grants with a status in the array $3, outstanding debt,
+// pending grants, and soonest expiring spendable grant at $2 of every asset of a license ($1), ordered by asset DID.
+var accountSnapshotQuery = fmt.Sprintf(`
+	SELECT %[1]s AS asset_did,
+		COALESCE(SUM(%[2]s) FILTER (WHERE %[3]s = ANY($3) AND (%[4]s IS NULL OR %[4]s > $2) AND %[2]s > 0), 0) AS balance,
+		COALESCE(SUM(%[5]s - %[2]s) FILTER (WHERE %[3]s = '%[6]s' AND %[2]s < %[5]s), 0) AS debt,
+		COUNT(*) FILTER (WHERE %[3]s = '%[7]s') AS pending_count,
+		COALESCE(SUM(%[5]s) FILTER (WHERE %[3]s = '%[7]s'), 0) AS pending_amount,
+		MIN(%[8]s) FILTER (WHERE %[3]s = '%[7]s') AS pending_oldest,
+		MIN(%[4]s) FILTER (WHERE %[3]s = ANY($3) AND %[4]s > $2 AND %[2]s > 0) AS next_expiration
+	FROM %[9]s
+	WHERE %[10]s = $1
+	GROUP BY %[1]s
+	ORDER BY %[1]s ASC
+`,
+	models.CreditGrantColumns.AssetDid,
+	models.CreditGrantColumns.RemainingAmount,
+	models.CreditGrantColumns.Status,
+	models.CreditGrantColumns.ExpiresAt,
+	models.CreditGrantColumns.InitialAmount,
+	GrantStatusFailed,
+	GrantStatusPending,
+	models.CreditGrantColumns.CreatedAt,
+	models.TableNames.CreditGrants,
+	models.CreditGrantColumns.LicenseID,
+)
+
+// AccountSnapshot is the state of a license across all of its assets, read at a single point in time.
+type AccountSnapshot struct {
+	// License ID
+	LicenseID string `json:"licenseId"`
+	// When the snapshot was taken
+	TakenAt time.Time `json:"takenAt"`
+	// Spendable credits of every asset
+	Balance int64 `json:"balance"`
+	// Outstanding debt of every asset
+	Debt int64 `json:"debt"`
+	// Pending grants of every asset
+	PendingGrants PendingGrantStats `json:"pendingGrants"`
+	// Soonest expiration of a grant with spendable credits, nil when no such grant expires
+	NextExpiration *time.Time `json:"nextExpiration"`
+	// Most recent operations across all assets, newest first
+	RecentOperations []RecentOperation `json:"recentOperations"`
+	// State of every asset with grants, ordered by asset DID
+	Assets []AssetSnapshot `json:"assets"`
+}
+
+// AssetSnapshot is the state of a single asset of an account snapshot.
+type AssetSnapshot struct {
+	// Asset DID
+	AssetDID string `json:"assetDid"`
+	// Spendable credits from active grants
+	Balance int64 `json:"balance"`
+	// Outstanding debt from failed grants
+	Debt int64 `json:"debt"`
+	// Pending grants of the asset
+	PendingGrants PendingGrantStats `json:"pendingGrants"`
+	// Soonest expiration of a grant with spendable credits, nil when no such grant expires
+	NextExpiration *time.Time `json:"nextExpiration"`
+}
+
+// accountSnapshotRow is a row of the account snapshot query.
+type accountSnapshotRow struct {
+	AssetDID       string    `boil:"asset_did"`
+	Balance        int64     `boil:"balance"`
+	Debt           int64     `boil:"debt"`
+	PendingCount   int64     `boil:"pending_count"`
+	PendingAmount  int64     `boil:"pending_amount"`
+	PendingOldest  null.Time `boil:"pending_oldest"`
+	NextExpiration null.Time `boil:"next_expiration"`
+}
+
+// GetAccountSnapshot returns the balance, debt, pending grants, soonest expiration, and recent operations of a license,
+// in total and per asset. Everything is read in one repeatable read transaction, so the parts are consistent with each other
+// and the totals are the sums of the assets.
+// Like GetBalances it does not expire grants when lazy expiration is enabled; expired grants are never counted in the balance either way.
+func (r *Repository) GetAccountSnapshot(ctx context.Context, licenseID string) (*AccountSnapshot, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	return retryTx(ctx, r, "GetAccountSnapshot", func(ctx context.Context) (*AccountSnapshot, error) {
+		return r.getAccountSnapshotInternal(ctx, licenseID)
+	})
+}
+
+// getAccountSnapshotInternal is the internal implementation of GetAccountSnapshot
+func (r *Repository) getAccountSnapshotInternal(ctx context.Context, licenseID string) (*AccountSnapshot, error) {
+	// repeatable read makes every query of the transaction see the same snapshot of the database
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("GetAccountSnapshot")()
+	defer rollbackTx(ctx, tx)
+
+	snapshot := &AccountSnapshot{LicenseID: licenseID, TakenAt: time.Now()}
+	var rows []*accountSnapshotRow
+	err = queries.Raw(accountSnapshotQuery, licenseID, snapshot.TakenAt, pq.StringArray(r.spendableStatuses())).Bind(ctx, tx, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get asset states: %w", err)
+	}
+	snapshot.RecentOperations, err = recentOperations(ctx, tx, licenseID, defaultRecentOperationsLimit)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	snapshot.Assets = make([]AssetSnapshot, 0, len(rows))
+	for _, row := range rows {
+		snapshot.AddAsset(AssetSnapshot{
+			AssetDID: row.AssetDID,
+			Balance:  row.Balance,
+			Debt:     row.Debt,
+			PendingGrants: PendingGrantStats{
+				Count:           row.PendingCount,
+				TotalAmount:     row.PendingAmount,
+				OldestCreatedAt: row.PendingOldest.Ptr(),
+			},
+			NextExpiration: row.NextExpiration.Ptr(),
+		})
+	}
+	return snapshot, nil
+}
+
+// AddAsset appends the state of an asset to the snapshot and adds it to the totals.
+func (s *AccountSnapshot) AddAsset(asset AssetSnapshot) {
+	s.Assets = append(s.Assets, asset)
+	s.Balance += asset.Balance
+	s.Debt += asset.Debt
+	s.PendingGrants.Count += asset.PendingGrants.Count
+	s.PendingGrants.TotalAmount += asset.PendingGrants.TotalAmount
+	s.PendingGrants.OldestCreatedAt = earliest(s.PendingGrants.OldestCreatedAt, asset.PendingGrants.OldestCreatedAt)
+	s.NextExpiration = earliest(s.NextExpiration, asset.NextExpiration)
+}
+
+// earliest returns the earlier of two optional times.
+func earliest(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.Before(*a)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,121 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGetAccountSnapshot(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	insertGrant := func(t *testing.T, licenseID, assetDID, status string, remaining int64, expiresAt null.Time) *models.CreditGrant {
+		t.Helper()
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        assetDID,
+			InitialAmount:   100,
+			RemainingAmount: remaining,
+			Status:          status,
+			ExpiresAt:       expiresAt,
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		return grant
+	}
+
+	t.Run("totals match the assets", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-snapshot"
+		assetA, assetB, assetC := "test-asset-snapshot-a", "test-asset-snapshot-b", "test-asset-snapshot-c"
+		soonest := time.Now().Add(2 * time.Hour).Truncate(time.Microsecond)
+
+		// Setup: asset A has two dated grants and a perpetual one, asset B a pending grant and a failed grant with debt,
+		// and asset C only an expired grant
+		insertGrant(t, licenseID, assetA, GrantStatusConfirmed, 100, null.TimeFrom(soonest))
+		insertGrant(t, licenseID, assetA, GrantStatusConfirmed, 100, null.TimeFrom(time.Now().Add(48*time.Hour)))
+		insertGrant(t, licenseID, assetA, GrantStatusConfirmed, 100, null.Time{})
+		insertGrant(t, licenseID, assetB, GrantStatusPending, 100, null.TimeFrom(time.Now().Add(24*time.Hour)))
+		insertGrant(t, licenseID, assetB, GrantStatusFailed, 40, null.TimeFrom(time.Now().Add(24*time.Hour)))
+		insertGrant(t, licenseID, assetC, GrantStatusConfirmed, 100, null.TimeFrom(time.Now().Add(-time.Hour)))
+		for range 12 {
+			_, err := repo.DeductCredits(ctx, licenseID, assetA, 5, testAPIEndpoint, uuid.NewString())
+			require.NoError(t, err)
+		}
+
+		// Test: Get the snapshot
+		snapshot, err := repo.GetAccountSnapshot(ctx, licenseID)
+		require.NoError(t, err)
+
+		// Verify: Every asset with grants is included, ordered by asset DID, with the same state as its own queries
+		require.Len(t, snapshot.Assets, 3)
+		var balance, debt, pendingCount, pendingAmount int64
+		for i, assetDID := range []string{assetA, assetB, assetC} {
+			asset := snapshot.Assets[i]
+			assert.Equal(t, assetDID, asset.AssetDID)
+			expected, err := repo.GetBalance(ctx, licenseID, assetDID)
+			require.NoError(t, err)
+			assert.Equal(t, expected.Balance, asset.Balance, assetDID)
+			assert.Equal(t, expected.Debt, asset.Debt, assetDID)
+			pending, err := repo.GetPendingGrantStats(ctx, licenseID, assetDID)
+			require.NoError(t, err)
+			assert.Equal(t, pending.Count, asset.PendingGrants.Count, assetDID)
+			assert.Equal(t, pending.TotalAmount, asset.PendingGrants.TotalAmount, assetDID)
+			balance += asset.Balance
+			debt += asset.Debt
+			pendingCount += asset.PendingGrants.Count
+			pendingAmount += asset.PendingGrants.TotalAmount
+		}
+		assert.Equal(t, int64(300-60), snapshot.Assets[0].Balance)
+		assert.Equal(t, int64(100), snapshot.Assets[1].Balance) // the pending grant is spendable
+		assert.Equal(t, int64(60), snapshot.Assets[1].Debt)
+		assert.Equal(t, int64(0), snapshot.Assets[2].Balance)
+
+		// Verify: The totals are the sums of the assets
+		assert.Equal(t, balance, snapshot.Balance)
+		assert.Equal(t, debt, snapshot.Debt)
+		assert.Equal(t, pendingCount, snapshot.PendingGrants.Count)
+		assert.Equal(t, pendingAmount, snapshot.PendingGrants.TotalAmount)
+		require.NotNil(t, snapshot.PendingGrants.OldestCreatedAt)
+
+		// Verify: The first grant of asset A still has credits and expires soonest, the expired grant is ignored
+		require.NotNil(t, snapshot.NextExpiration)
+		assert.WithinDuration(t, soonest, *snapshot.NextExpiration, time.Millisecond)
+		assert.Nil(t, snapshot.Assets[2].NextExpiration)
+
+		// Verify: The default number of recent operations, newest first
+		require.Len(t, snapshot.RecentOperations, defaultRecentOperationsLimit)
+		for i := 1; i < len(snapshot.RecentOperations); i++ {
+			assert.False(t, snapshot.RecentOperations[i].CreatedAt.After(snapshot.RecentOperations[i-1].CreatedAt))
+		}
+	})
+
+	t.Run("license without grants", func(t *testing.T) {
+		t.Parallel()
+		snapshot, err := repo.GetAccountSnapshot(ctx, "test-license-snapshot-empty")
+		require.NoError(t, err)
+		assert.Empty(t, snapshot.Assets)
+		assert.Empty(t, snapshot.RecentOperations)
+		assert.Equal(t, int64(0), snapshot.Balance)
+		assert.Nil(t, snapshot.NextExpiration)
+	})
+
+	t.Run("missing licenseID", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.GetAccountSnapshot(ctx, "")
+		require.Error(t, err)
+	})
+}
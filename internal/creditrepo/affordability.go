@@ -0,0 +1,86 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// Affordability is whether a license and asset can afford a total amount of upcoming deductions.
+type Affordability struct {
+	// Whether the total can be deducted right now
+	Affordable bool `json:"affordable"`
+	// Whether outstanding debt blocks deductions regardless of the balance
+	BlockedByDebt bool `json:"blockedByDebt"`
+	// Spendable credits from active grants
+	Balance int64 `json:"balance"`
+	// Outstanding debt from failed grants of the default credit type
+	Debt int64 `json:"debt"`
+	// Total amount of the upcoming deductions
+	Required int64 `json:"required"`
+	// Credits missing to cover the total, zero when the balance covers it
+	Shortfall int64 `json:"shortfall"`
+}
+
+// CanAfford returns whether the spendable balance covers the total amount and whether debt would block it.
+// Unlike a deduction nothing is allocated or locked, so the answer may change before the deductions are made.
+func (r *Repository) CanAfford(ctx context.Context, licenseID, assetDID string, totalAmount uint64) (*Affordability, error) {
+	if totalAmount > math.MaxInt64 {
+		return nil, fmt.Errorf("total amount is too large must be less than %d", math.MaxInt64)
+	}
+	return retryTx(ctx, r, "CanAfford", func(ctx context.Context) (*Affordability, error) {
+		return r.canAffordInternal(ctx, licenseID, assetDID, int64(totalAmount))
+	})
+}
+
+// CanDeduct returns whether a deduction of the amount would succeed right now, and the spendable balance.
+// Like a deduction it fails when there is outstanding debt or the balance does not cover the amount,
+// but it only reads in a read-only transaction and records no operation.
+func (r *Repository) CanDeduct(ctx context.Context, licenseID, assetDID string, amount uint64) (bool, int64, error) {
+	result, err := r.CanAfford(ctx, licenseID, assetDID, amount)
+	if err != nil {
+		return false, 0, err
+	}
+	return result.Affordable, result.Balance, nil
+}
+
+func (r *Repository) canAffordInternal(ctx context.Context, licenseID, assetDID string, totalAmount int64) (*Affordability, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("CanAfford")()
+	defer rollbackTx(ctx, tx)
+
+	debt, err := outstandingDebt(ctx, tx, licenseID, assetDID, DefaultCreditType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outstanding debt: %w", err)
+	}
+	balance, err := r.calculateBalance(ctx, tx, licenseID, assetDID, DefaultCreditType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate balance: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return affordability(balance, debt, totalAmount), nil
+}
+
+// affordability decides whether the total can be deducted from the balance, mirroring the checks of DeductCredits.
+func affordability(balance, debt, totalAmount int64) *Affordability {
+	result := &Affordability{
+		BlockedByDebt: debt > 0,
+		Balance:       balance,
+		Debt:          debt,
+		Required:      totalAmount,
+		Shortfall:     max(totalAmount-balance, 0),
+	}
+	result.Affordable = !result.BlockedByDebt && result.Shortfall == 0
+	return result
+}
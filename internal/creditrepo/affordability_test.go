@@ -0,0 +1,142 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestAffordability(t *testing.T) {
+	testCases := []struct {
+		name              string
+		balance           int64
+		debt              int64
+		amount            int64
+		expectAffordable  bool
+		expectBlocked     bool
+		expectedShortfall int64
+	}{
+		{name: "sufficient", balance: 100, amount: 60, expectAffordable: true},
+		{name: "exact", balance: 100, amount: 100, expectAffordable: true},
+		{name: "insufficient", balance: 40, amount: 100, expectedShortfall: 60},
+		{name: "blocked by debt", balance: 100, debt: 10, amount: 60, expectBlocked: true},
+		{name: "insufficient and blocked by debt", balance: 40, debt: 10, amount: 100, expectBlocked: true, expectedShortfall: 60},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := affordability(tc.balance, tc.debt, tc.amount)
+			assert.Equal(t, tc.expectAffordable, result.Affordable)
+			assert.Equal(t, tc.expectBlocked, result.BlockedByDebt)
+			assert.Equal(t, tc.expectedShortfall, result.Shortfall)
+		})
+	}
+}
+
+func TestCanAfford(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	insertGrant := func(t *testing.T, licenseID, status string, initialAmount, remainingAmount int64) {
+		t.Helper()
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   initialAmount,
+			RemainingAmount: remainingAmount,
+			Status:          status,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+	}
+
+	t.Run("sufficient balance", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-can-afford"
+		insertGrant(t, licenseID, GrantStatusConfirmed, 1000, 600)
+		insertGrant(t, licenseID, GrantStatusPending, 1000, 400)
+
+		result, err := repo.CanAfford(ctx, licenseID, testAssetID, 1000)
+		require.NoError(t, err)
+		assert.True(t, result.Affordable)
+		assert.False(t, result.BlockedByDebt)
+		assert.Equal(t, int64(1000), result.Balance)
+		assert.Equal(t, int64(0), result.Shortfall)
+
+		// Verify: Nothing was allocated
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), balance.Balance)
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-can-afford-insufficient"
+		insertGrant(t, licenseID, GrantStatusConfirmed, 1000, 250)
+
+		result, err := repo.CanAfford(ctx, licenseID, testAssetID, 1000)
+		require.NoError(t, err)
+		assert.False(t, result.Affordable)
+		assert.False(t, result.BlockedByDebt)
+		assert.Equal(t, int64(250), result.Balance)
+		assert.Equal(t, int64(750), result.Shortfall)
+	})
+
+	t.Run("blocked by debt", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-can-afford-debt"
+		insertGrant(t, licenseID, GrantStatusConfirmed, 1000, 1000)
+		insertGrant(t, licenseID, GrantStatusFailed, 1000, 900)
+
+		result, err := repo.CanAfford(ctx, licenseID, testAssetID, 10)
+		require.NoError(t, err)
+		assert.False(t, result.Affordable)
+		assert.True(t, result.BlockedByDebt)
+		assert.Equal(t, int64(100), result.Debt)
+		assert.Equal(t, int64(0), result.Shortfall)
+	})
+
+	t.Run("can deduct", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-can-deduct"
+		insertGrant(t, licenseID, GrantStatusConfirmed, 1000, 300)
+
+		ok, balance, err := repo.CanDeduct(ctx, licenseID, testAssetID, 300)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, int64(300), balance)
+
+		ok, balance, err = repo.CanDeduct(ctx, licenseID, testAssetID, 301)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, int64(300), balance)
+
+		// Verify: No operation was recorded
+		count, err := models.CreditOperations(models.CreditOperationWhere.LicenseID.EQ(licenseID)).Count(ctx, db)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("can not deduct with debt", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-can-deduct-debt"
+		insertGrant(t, licenseID, GrantStatusConfirmed, 1000, 1000)
+		insertGrant(t, licenseID, GrantStatusFailed, 1000, 990)
+
+		ok, balance, err := repo.CanDeduct(ctx, licenseID, testAssetID, 10)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, int64(1000), balance)
+	})
+}
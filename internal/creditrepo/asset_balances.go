@@ -0,0 +1,177 @@
+package creditrepo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+)
+
+const (
+	defaultAssetBalancePageSize = 100
+	maxAssetBalancePageSize     = 1000
+	maxBalancesAssets           = 1000
+)
+
+// assetBalancesQuery computes the spendable balance of the grants with a status in the array $7 and outstanding debt
+// of every asset of a license ($1) at $2, ordered by balance then asset DID.
+// $3 enables the keyset cursor ($4 balance, $5 asset DID) and $6 is the page size.
+var assetBalancesQuery = fmt.Sprintf(`
+	SELECT asset_did, balance, debt FROM (
+		SELECT %[1]s AS asset_did,
+			COALESCE(SUM(%[2]s) FILTER (WHERE %[3]s = ANY($7) AND (%[4]s IS NULL OR %[4]s > $2) AND %[2]s > 0), 0) AS balance,
+			COALESCE(SUM(%[5]s - %[2]s) FILTER (WHERE %[3]s = '%[6]s' AND %[2]s < %[5]s), 0) AS debt
+		FROM %[7]s
+		WHERE %[8]s = $1
+		GROUP BY %[1]s
+	) AS asset_balances
+	WHERE NOT $3 OR (balance, asset_did) > ($4, $5)
+	ORDER BY balance ASC, asset_did ASC
+	LIMIT $6
+`,
+	models.CreditGrantColumns.AssetDid,
+	models.CreditGrantColumns.RemainingAmount,
+	models.CreditGrantColumns.Status,
+	models.CreditGrantColumns.ExpiresAt,
+	models.CreditGrantColumns.InitialAmount,
+	GrantStatusFailed,
+	models.TableNames.CreditGrants,
+	models.CreditGrantColumns.LicenseID,
+)
+
+// balancesQuery computes the spendable balance of the grants with a status in the array $4 and outstanding debt at $2
+// of the assets of a license ($1) in the asset DID array $3. Assets without grants have no row.
+var balancesQuery = fmt.Sprintf(`
+	SELECT %[1]s AS asset_did,
+		COALESCE(SUM(%[2]s) FILTER (WHERE %[3]s = ANY($4) AND (%[4]s IS NULL OR %[4]s > $2) AND %[2]s > 0), 0) AS balance,
+		COALESCE(SUM(%[5]s - %[2]s) FILTER (WHERE %[3]s = '%[6]s' AND %[2]s < %[5]s), 0) AS debt
+	FROM %[7]s
+	WHERE %[8]s = $1 AND %[1]s = ANY($3)
+	GROUP BY %[1]s
+`,
+	models.CreditGrantColumns.AssetDid,
+	models.CreditGrantColumns.RemainingAmount,
+	models.CreditGrantColumns.Status,
+	models.CreditGrantColumns.ExpiresAt,
+	models.CreditGrantColumns.InitialAmount,
+	GrantStatusFailed,
+	models.TableNames.CreditGrants,
+	models.CreditGrantColumns.LicenseID,
+)
+
+// AssetBalance is the spendable balance and outstanding debt of a single asset.
+type AssetBalance struct {
+	// Asset DID
+	AssetDID string `boil:"asset_did" json:"assetDid"`
+	// Spendable credits from active grants
+	Balance int64 `boil:"balance" json:"balance"`
+	// Outstanding debt from failed grants
+	Debt int64 `boil:"debt" json:"debt"`
+}
+
+// AssetBalancePage is a page of asset balances.
+type AssetBalancePage struct {
+	// Assets ordered by ascending balance
+	Assets []*AssetBalance `json:"assets"`
+	// Cursor for the next page, empty when there are no more assets
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// assetBalanceCursor is the position after the last asset of a page.
+type assetBalanceCursor struct {
+	Balance  int64  `json:"b"`
+	AssetDID string `json:"a"`
+}
+
+// ListAssetsByBalance returns the assets of a license ordered by ascending spendable balance, most depleted first.
+// The cursor is the NextCursor of the previous page, or empty for the first page. A limit of zero uses the default page size.
+func (r *Repository) ListAssetsByBalance(ctx context.Context, licenseID string, limit int, cursor string) (*AssetBalancePage, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	if limit <= 0 {
+		limit = defaultAssetBalancePageSize
+	}
+	limit = min(limit, maxAssetBalancePageSize)
+
+	var after assetBalanceCursor
+	if cursor != "" {
+		if err := decodeCursor(cursor, &after); err != nil {
+			return nil, err
+		}
+	}
+
+	var assets []*AssetBalance
+	// fetch one extra row to know if there is a next page
+	err := queries.Raw(assetBalancesQuery, licenseID, time.Now(), cursor != "", after.Balance, after.AssetDID, limit+1, pq.StringArray(r.spendableStatuses())).Bind(ctx, r.db, &assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list asset balances: %w", err)
+	}
+
+	page := &AssetBalancePage{Assets: assets}
+	if len(assets) > limit {
+		page.Assets = assets[:limit]
+		last := page.Assets[limit-1]
+		page.NextCursor, err = encodeCursor(assetBalanceCursor{Balance: last.Balance, AssetDID: last.AssetDID})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// GetBalances returns the balance of each of the given assets of a license, keyed by asset DID, in a single query.
+// Every requested asset is in the result, assets without grants have a zero balance and debt.
+// Unlike GetBalance, it does not expire grants when lazy expiration is enabled; expired grants are never counted in the balance either way.
+func (r *Repository) GetBalances(ctx context.Context, licenseID string, assetDIDs []string) (map[string]*Balance, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	if len(assetDIDs) > maxBalancesAssets {
+		return nil, fmt.Errorf("at most %d assets can be requested at once", maxBalancesAssets)
+	}
+
+	balances := make(map[string]*Balance, len(assetDIDs))
+	for _, assetDID := range assetDIDs {
+		balances[assetDID] = &Balance{}
+	}
+	if len(balances) == 0 {
+		return balances, nil
+	}
+
+	var assets []*AssetBalance
+	err := queries.Raw(balancesQuery, licenseID, time.Now(), pq.StringArray(assetDIDs), pq.StringArray(r.spendableStatuses())).Bind(ctx, r.db, &assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+	for _, asset := range assets {
+		balances[asset.AssetDID] = &Balance{Balance: asset.Balance, Debt: asset.Debt}
+	}
+	return balances, nil
+}
+
+// encodeCursor encodes a page position as an opaque cursor string.
+func encodeCursor(position any) (string, error) {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor decodes an opaque cursor string into a page position.
+func decodeCursor(cursor string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("%w: %w", InvalidCursorErr, err)
+	}
+	if err := json.Unmarshal(data, position); err != nil {
+		return fmt.Errorf("%w: %w", InvalidCursorErr, err)
+	}
+	return nil
+}
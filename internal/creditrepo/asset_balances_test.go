@@ -0,0 +1,183 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestListAssetsByBalance(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("ordered by balance and paged", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-assets-by-balance"
+		// asset-d and asset-e have the same balance so they are ordered by asset DID
+		balances := map[string]int64{
+			"asset-a": 500,
+			"asset-b": 0,
+			"asset-c": 1000,
+			"asset-d": 250,
+			"asset-e": 250,
+		}
+		for assetDID, balance := range balances {
+			grant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        assetDID,
+				InitialAmount:   defaultGrantAmount,
+				RemainingAmount: balance,
+				Status:          GrantStatusConfirmed,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			}
+			require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		}
+		// asset-b also has debt
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        "asset-b",
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount - 75,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+
+		var assets []*AssetBalance
+		var pages int
+		cursor := ""
+		for {
+			page, err := repo.ListAssetsByBalance(ctx, licenseID, 2, cursor)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(page.Assets), 2)
+			assets = append(assets, page.Assets...)
+			pages++
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		assert.Equal(t, 3, pages)
+
+		order := make([]string, 0, len(assets))
+		for _, asset := range assets {
+			order = append(order, fmt.Sprintf("%s=%d", asset.AssetDID, asset.Balance))
+		}
+		assert.Equal(t, []string{"asset-b=0", "asset-d=250", "asset-e=250", "asset-a=500", "asset-c=1000"}, order)
+		assert.Equal(t, int64(75), assets[0].Debt)
+		assert.Equal(t, int64(0), assets[1].Debt)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.ListAssetsByBalance(ctx, "test-license-assets-invalid-cursor", 2, "not a cursor")
+		require.Error(t, err)
+	})
+}
+
+func TestAssetBalanceCursor(t *testing.T) {
+	cursor, err := encodeCursor(assetBalanceCursor{Balance: 250, AssetDID: "did:erc721:1:0x1:1"})
+	require.NoError(t, err)
+
+	var position assetBalanceCursor
+	require.NoError(t, decodeCursor(cursor, &position))
+	assert.Equal(t, assetBalanceCursor{Balance: 250, AssetDID: "did:erc721:1:0x1:1"}, position)
+
+	require.Error(t, decodeCursor("%%%", &position))
+}
+
+func TestGetBalances(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("50 assets in one query", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-get-balances"
+		assetDIDs := make([]string, 50)
+		for i := range assetDIDs {
+			assetDIDs[i] = fmt.Sprintf("asset-%02d", i)
+			// every third asset has no grants at all
+			if i%3 == 0 {
+				continue
+			}
+			grant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        assetDIDs[i],
+				InitialAmount:   defaultGrantAmount,
+				RemainingAmount: int64(i * 10),
+				Status:          GrantStatusConfirmed,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			}
+			require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		}
+		// expired grants are not counted
+		expiredGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        assetDIDs[1],
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(-time.Hour)),
+		}
+		require.NoError(t, expiredGrant.Insert(ctx, db, boil.Infer()))
+		// debt is reported next to the balance
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        assetDIDs[2],
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount - 75,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+
+		balances, err := repo.GetBalances(ctx, licenseID, assetDIDs)
+		require.NoError(t, err)
+		require.Len(t, balances, 50)
+		for i, assetDID := range assetDIDs {
+			expected := int64(i * 10)
+			if i%3 == 0 {
+				expected = 0
+			}
+			require.Contains(t, balances, assetDID)
+			assert.Equal(t, expected, balances[assetDID].Balance, assetDID)
+		}
+		assert.Equal(t, int64(75), balances[assetDIDs[2]].Debt)
+		assert.Equal(t, int64(0), balances[assetDIDs[1]].Debt)
+	})
+
+	t.Run("other licenses are not counted", func(t *testing.T) {
+		t.Parallel()
+		grant := &models.CreditGrant{
+			LicenseID:       "test-license-get-balances-other",
+			AssetDid:        "asset-shared",
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+
+		balances, err := repo.GetBalances(ctx, "test-license-get-balances-empty", []string{"asset-shared"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]*Balance{"asset-shared": {}}, balances)
+	})
+}
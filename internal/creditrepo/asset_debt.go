@@ -0,0 +1,41 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// AssetDebt is the outstanding debt of a single asset.
+type AssetDebt struct {
+	// Asset DID
+	AssetDID string `boil:"asset_did" json:"assetDid"`
+	// Credits owed from failed grants, deductions of the asset are refused until they are settled
+	Debt int64 `boil:"debt" json:"debt"`
+}
+
+// GetAssetsWithDebt returns every asset of a license with outstanding debt from failed grants, ordered by asset DID.
+// Assets without debt are left out, so an empty result means the license can spend on every asset.
+func (r *Repository) GetAssetsWithDebt(ctx context.Context, licenseID string) ([]AssetDebt, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	return retryTx(ctx, r, "GetAssetsWithDebt", func(ctx context.Context) ([]AssetDebt, error) {
+		debts := []AssetDebt{}
+		err := models.CreditGrants(
+			qm.Select(models.CreditGrantColumns.AssetDid+" AS asset_did",
+				"SUM("+models.CreditGrantColumns.InitialAmount+" - "+models.CreditGrantColumns.RemainingAmount+") AS debt"),
+			models.CreditGrantWhere.LicenseID.EQ(licenseID),
+			models.CreditGrantWhere.Status.EQ(GrantStatusFailed),
+			qm.Where(models.CreditGrantColumns.RemainingAmount+" < "+models.CreditGrantColumns.InitialAmount),
+			qm.GroupBy(models.CreditGrantColumns.AssetDid),
+			qm.OrderBy(models.CreditGrantColumns.AssetDid+" ASC"),
+		).Bind(ctx, r.db, &debts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get assets with debt: %w", err)
+		}
+		return debts, nil
+	})
+}
@@ -0,0 +1,56 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGetAssetsWithDebt(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+	licenseID := "test-license-assets-with-debt"
+
+	// Setup: asset-a and asset-c have failed grants with debt, asset-b only has credits and a failed grant that was never spent from
+	grants := []*models.CreditGrant{
+		{AssetDid: "asset-a", InitialAmount: 100, RemainingAmount: 40, Status: GrantStatusFailed},
+		{AssetDid: "asset-a", InitialAmount: 50, RemainingAmount: 20, Status: GrantStatusFailed},
+		{AssetDid: "asset-b", InitialAmount: 100, RemainingAmount: 100, Status: GrantStatusFailed},
+		{AssetDid: "asset-b", InitialAmount: 100, RemainingAmount: 10, Status: GrantStatusConfirmed},
+		{AssetDid: "asset-c", InitialAmount: 100, RemainingAmount: 75, Status: GrantStatusFailed},
+		{AssetDid: "asset-c", InitialAmount: 100, RemainingAmount: 100, Status: GrantStatusConfirmed},
+	}
+	for _, grant := range grants {
+		grant.LicenseID = licenseID
+		grant.ExpiresAt = null.TimeFrom(time.Now().Add(time.Hour))
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+	}
+
+	// Test: Only the assets with debt are returned, with the debt of all their failed grants
+	debts, err := repo.GetAssetsWithDebt(ctx, licenseID)
+	require.NoError(t, err)
+	assert.Equal(t, []AssetDebt{
+		{AssetDID: "asset-a", Debt: 90},
+		{AssetDID: "asset-c", Debt: 25},
+	}, debts)
+
+	// Verify: A license without debt has no assets
+	debts, err = repo.GetAssetsWithDebt(ctx, "test-license-assets-with-debt-other")
+	require.NoError(t, err)
+	assert.Empty(t, debts)
+
+	_, err = repo.GetAssetsWithDebt(ctx, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// WithBalanceSnapshots sets whether operations record the spendable balance after the operation in balance_after.
+// Snapshots cost an extra balance query and update for operations other than deductions.
+func WithBalanceSnapshots(enabled bool) Option {
+	return func(r *Repository) {
+		r.recordBalanceAfter = enabled
+	}
+}
+
+// recordOperationBalance records the spendable balance at the end of the operation's transaction on the operation.
+// The balance is read within the transaction so it reflects the grants the transaction locked and updated.
+func (r *Repository) recordOperationBalance(ctx context.Context, tx *sql.Tx, operation *models.CreditOperation) error {
+	if !r.recordBalanceAfter {
+		return nil
+	}
+	balance, err := r.calculateBalance(ctx, tx, operation.LicenseID, operation.AssetDid, operation.CreditType)
+	if err != nil {
+		return err
+	}
+	operation.BalanceAfter = null.Int64From(balance)
+	if _, err := operation.Update(ctx, tx, boil.Whitelist(models.CreditOperationColumns.BalanceAfter)); err != nil {
+		return fmt.Errorf("failed to record balance after operation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestBalanceSnapshots(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db, WithBalanceSnapshots(true))
+	ctx := context.Background()
+
+	// requireBalanceAfter asserts the operation stored the balance it reports and that it matches the computed balance.
+	requireBalanceAfter := func(t *testing.T, operation *models.CreditOperation, licenseID string, expected int64) {
+		t.Helper()
+		require.True(t, operation.BalanceAfter.Valid)
+		assert.Equal(t, expected, operation.BalanceAfter.Int64)
+
+		stored, err := models.FindCreditOperation(ctx, db, operation.AppName, operation.ReferenceID, operation.OperationType)
+		require.NoError(t, err)
+		assert.Equal(t, operation.BalanceAfter, stored.BalanceAfter)
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, balance.Balance, stored.BalanceAfter.Int64)
+	}
+
+	t.Run("deductions and refunds", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-balance-snapshot"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   1000,
+			RemainingAmount: 1000,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+
+		firstReferenceID := uuid.NewString()
+		operation, err := repo.DeductCredits(ctx, licenseID, testAssetID, 100, testAPIEndpoint, firstReferenceID)
+		require.NoError(t, err)
+		requireBalanceAfter(t, operation, licenseID, 900)
+
+		operation, err = repo.DeductCredits(ctx, licenseID, testAssetID, 50, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		requireBalanceAfter(t, operation, licenseID, 850)
+
+		operation, err = repo.RefundCredits(ctx, testAPIEndpoint, firstReferenceID)
+		require.NoError(t, err)
+		requireBalanceAfter(t, operation, licenseID, 950)
+	})
+
+	t.Run("grant confirmation", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-balance-snapshot-confirm"
+		txHash := common.BytesToAddress([]byte(licenseID)).Hex()
+		operation, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, txHash, 1, uint64(defaultGrantAmount), time.Now())
+		require.NoError(t, err)
+		requireBalanceAfter(t, operation, licenseID, defaultGrantAmount)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-balance-snapshot-disabled"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   1000,
+			RemainingAmount: 1000,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+
+		operation, err := New(db).DeductCredits(ctx, licenseID, testAssetID, 100, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		assert.False(t, operation.BalanceAfter.Valid)
+	})
+}
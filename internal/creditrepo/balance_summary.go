@@ -0,0 +1,123 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+var (
+	// balanceSummaryUpsert recomputes the spendable balance, of the grants with a status in the array $2, and outstanding debt
+	// from credit_grants at $1 and upserts the result into credit_balance_summaries. The %s placeholder is an optional WHERE clause.
+	balanceSummaryUpsert = fmt.Sprintf(`
+		INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s, %[5]s, %[6]s)
+		SELECT %[7]s, %[8]s,
+			COALESCE(SUM(%[9]s) FILTER (WHERE %[10]s = ANY($2) AND (%[11]s IS NULL OR %[11]s > $1) AND %[9]s > 0), 0),
+			COALESCE(SUM(%[12]s - %[9]s) FILTER (WHERE %[10]s = '%[13]s' AND %[9]s < %[12]s), 0),
+			$1
+		FROM %[14]s
+		%%s
+		GROUP BY %[7]s, %[8]s
+		ON CONFLICT (%[2]s, %[3]s) DO UPDATE SET
+			%[4]s = EXCLUDED.%[4]s,
+			%[5]s = EXCLUDED.%[5]s,
+			%[6]s = EXCLUDED.%[6]s
+	`,
+		models.TableNames.CreditBalanceSummaries,
+		models.CreditBalanceSummaryColumns.LicenseID,
+		models.CreditBalanceSummaryColumns.AssetDid,
+		models.CreditBalanceSummaryColumns.Balance,
+		models.CreditBalanceSummaryColumns.Debt,
+		models.CreditBalanceSummaryColumns.RefreshedAt,
+		models.CreditGrantColumns.LicenseID,
+		models.CreditGrantColumns.AssetDid,
+		models.CreditGrantColumns.RemainingAmount,
+		models.CreditGrantColumns.Status,
+		models.CreditGrantColumns.ExpiresAt,
+		models.CreditGrantColumns.InitialAmount,
+		GrantStatusFailed,
+		models.TableNames.CreditGrants,
+	)
+)
+
+// BalanceSummary is the cached balance of a single asset for a license.
+type BalanceSummary struct {
+	// License ID
+	LicenseID string `json:"licenseId"`
+	// Asset DID
+	AssetDID string `json:"assetDid"`
+	// Spendable credits from active grants
+	Balance int64 `json:"balance"`
+	// Outstanding debt from failed grants
+	Debt int64 `json:"debt"`
+	// When the summary was last recomputed
+	RefreshedAt time.Time `json:"refreshedAt"`
+}
+
+// RefreshBalanceSummaries recomputes the cached balance summaries for every license and asset.
+func (r *Repository) RefreshBalanceSummaries(ctx context.Context) (int64, error) {
+	return retryTx(ctx, r, "RefreshBalanceSummaries", func(ctx context.Context) (int64, error) {
+		return r.refreshBalanceSummaries(ctx, r.db, "")
+	})
+}
+
+// RefreshLicenseBalanceSummaries recomputes the cached balance summaries for all assets of a license.
+func (r *Repository) RefreshLicenseBalanceSummaries(ctx context.Context, licenseID string) (int64, error) {
+	return retryTx(ctx, r, "RefreshLicenseBalanceSummaries", func(ctx context.Context) (int64, error) {
+		return r.refreshBalanceSummaries(ctx, r.db, "WHERE "+models.CreditGrantColumns.LicenseID+" = $3", licenseID)
+	})
+}
+
+// GetBalanceSummaries returns the cached balance summaries for all assets of a license.
+func (r *Repository) GetBalanceSummaries(ctx context.Context, licenseID string) ([]*BalanceSummary, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	summaries, err := models.CreditBalanceSummaries(
+		models.CreditBalanceSummaryWhere.LicenseID.EQ(licenseID),
+		qm.OrderBy(models.CreditBalanceSummaryColumns.AssetDid+" ASC"),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance summaries: %w", err)
+	}
+
+	result := make([]*BalanceSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, &BalanceSummary{
+			LicenseID:   summary.LicenseID,
+			AssetDID:    summary.AssetDid,
+			Balance:     summary.Balance,
+			Debt:        summary.Debt,
+			RefreshedAt: summary.RefreshedAt,
+		})
+	}
+	return result, nil
+}
+
+// updateBalanceSummary recomputes the cached balance summary for a single license and asset.
+// It is called at the end of every operation so the summary is committed together with the grant changes.
+func (r *Repository) updateBalanceSummary(ctx context.Context, tx *sql.Tx, licenseID, assetDID string) error {
+	_, err := r.refreshBalanceSummaries(ctx, tx, "WHERE "+models.CreditGrantColumns.LicenseID+" = $3 AND "+models.CreditGrantColumns.AssetDid+" = $4", licenseID, assetDID)
+	return err
+}
+
+// refreshBalanceSummaries runs the balance summary upsert with the given WHERE clause and returns the number of summaries refreshed.
+func (r *Repository) refreshBalanceSummaries(ctx context.Context, exec boil.ContextExecutor, where string, args ...any) (int64, error) {
+	args = append([]any{time.Now(), pq.StringArray(r.spendableStatuses())}, args...)
+	result, err := queries.Raw(fmt.Sprintf(balanceSummaryUpsert, where), args...).ExecContext(ctx, exec)
+	if err != nil {
+		return 0, fmt.Errorf("failed to refresh balance summaries: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get refreshed balance summary count: %w", err)
+	}
+	return rows, nil
+}
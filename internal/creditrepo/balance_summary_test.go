@@ -0,0 +1,134 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestBalanceSummaries(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("summary matches computed balance after refresh", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-summary-refresh"
+		assets := []string{testAssetID + "-1", testAssetID + "-2"}
+		for _, assetDID := range assets {
+			grant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        assetDID,
+				InitialAmount:   defaultGrantAmount,
+				RemainingAmount: defaultGrantAmount,
+				Status:          GrantStatusConfirmed,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			}
+			require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		}
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        assets[1],
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount - 500,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+
+		_, err := repo.DeductCredits(ctx, licenseID, assets[0], 100, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+
+		refreshed, err := repo.RefreshLicenseBalanceSummaries(ctx, licenseID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(assets)), refreshed)
+
+		summaries, err := repo.GetBalanceSummaries(ctx, licenseID)
+		require.NoError(t, err)
+		require.Len(t, summaries, len(assets))
+		for _, summary := range summaries {
+			balance, err := repo.calculateBalanceForTest(ctx, licenseID, summary.AssetDID)
+			require.NoError(t, err)
+			debt, err := repo.getOutstandingDebt(ctx, licenseID, summary.AssetDID)
+			require.NoError(t, err)
+			assert.Equal(t, balance, summary.Balance, summary.AssetDID)
+			assert.Equal(t, debt, summary.Debt, summary.AssetDID)
+		}
+	})
+
+	t.Run("summary is updated incrementally by operations", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-summary-incremental"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+
+		referenceID := uuid.NewString()
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 100, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		summaries, err := repo.GetBalanceSummaries(ctx, licenseID)
+		require.NoError(t, err)
+		require.Len(t, summaries, 1)
+		assert.Equal(t, defaultGrantAmount-100, summaries[0].Balance)
+		assert.Equal(t, int64(0), summaries[0].Debt)
+
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		summaries, err = repo.GetBalanceSummaries(ctx, licenseID)
+		require.NoError(t, err)
+		require.Len(t, summaries, 1)
+		assert.Equal(t, defaultGrantAmount, summaries[0].Balance)
+	})
+
+	t.Run("full refresh covers all licenses", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-summary-full-refresh"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: 42,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+
+		_, err := repo.RefreshBalanceSummaries(ctx)
+		require.NoError(t, err)
+
+		summaries, err := repo.GetBalanceSummaries(ctx, licenseID)
+		require.NoError(t, err)
+		require.Len(t, summaries, 1)
+		assert.Equal(t, int64(42), summaries[0].Balance)
+	})
+}
+
+// calculateBalanceForTest computes the spendable balance of a license and asset directly from its grants.
+func (r *Repository) calculateBalanceForTest(ctx context.Context, licenseID, assetDID string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer rollbackTx(ctx, tx)
+	return r.calculateBalance(ctx, tx, licenseID, assetDID, "")
+}
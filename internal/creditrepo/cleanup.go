@@ -0,0 +1,94 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// expiredGrantCleanupBatchSize is the number of consumed expired grants deleted per transaction.
+const expiredGrantCleanupBatchSize = 500
+
+// assetHasNoDebt matches the grants whose license and asset have no failed grant with unsettled debt.
+var assetHasNoDebt = fmt.Sprintf(`NOT EXISTS (
+	SELECT 1 FROM %[1]s AS debt
+	WHERE debt.%[2]s = %[1]s.%[2]s AND debt.%[3]s = %[1]s.%[3]s
+	AND debt.%[4]s = '%[5]s' AND debt.%[6]s < debt.%[7]s
+)`,
+	models.TableNames.CreditGrants,
+	models.CreditGrantColumns.LicenseID,
+	models.CreditGrantColumns.AssetDid,
+	models.CreditGrantColumns.Status,
+	GrantStatusFailed,
+	models.CreditGrantColumns.RemainingAmount,
+	models.CreditGrantColumns.InitialAmount,
+)
+
+// CleanupExpiredGrants deletes the confirmed or expired grants that expired more than olderThan ago with no credits left,
+// of assets without outstanding debt, so the grant table does not grow without bound. It returns the number of grants deleted.
+// The operation grant details of a deleted grant are deleted with it by the foreign key cascade, while the operations themselves
+// are kept as history. Refunds of deductions made from a deleted grant fail with RefundUnavailableErr, olderThan should outlast the refund window.
+// Pending, failed, and perpetual grants are never deleted.
+func (r *Repository) CleanupExpiredGrants(ctx context.Context, olderThan time.Duration) (int64, error) {
+	if olderThan < 0 {
+		return 0, fmt.Errorf("olderThan must not be negative")
+	}
+	var total int64
+	for {
+		deleted, err := retryTx(ctx, r, "CleanupExpiredGrants", func(ctx context.Context) (int64, error) {
+			return r.cleanupExpiredGrantsBatch(ctx, time.Now().Add(-olderThan))
+		})
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < expiredGrantCleanupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// cleanupExpiredGrantsBatch deletes a batch of the consumed grants that expired before the cutoff in one transaction.
+// Grants locked by a concurrent operation are skipped and left for the next run.
+func (r *Repository) cleanupExpiredGrantsBatch(ctx context.Context, cutoff time.Time) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("CleanupExpiredGrants")()
+	defer rollbackTx(ctx, tx)
+
+	grants, err := models.CreditGrants(
+		qm.Select(models.CreditGrantColumns.ID),
+		models.CreditGrantWhere.Status.IN([]string{GrantStatusConfirmed, GrantStatusExpired}),
+		models.CreditGrantWhere.RemainingAmount.EQ(0),
+		models.CreditGrantWhere.ExpiresAt.LT(null.TimeFrom(cutoff)),
+		qm.Where(assetHasNoDebt),
+		qm.OrderBy(models.CreditGrantColumns.ExpiresAt+" ASC, "+models.CreditGrantColumns.ID+" ASC"),
+		qm.Limit(expiredGrantCleanupBatchSize),
+		qm.For("UPDATE SKIP LOCKED"),
+	).All(ctx, tx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get consumed expired grants: %w", err)
+	}
+	if len(grants) == 0 {
+		return 0, nil
+	}
+
+	deleted, err := grants.DeleteAll(ctx, tx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete consumed expired grants: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return deleted, nil
+}
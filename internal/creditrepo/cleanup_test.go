@@ -0,0 +1,109 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestCleanupExpiredGrants(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	// grants expired long before the fixtures of the other tests, so the cleanup only touches these
+	longExpired := time.Now().AddDate(-10, 0, 0)
+	retention := 5 * 365 * 24 * time.Hour
+
+	insertGrant := func(t *testing.T, licenseID, status string, remaining int64, expiresAt null.Time) *models.CreditGrant {
+		t.Helper()
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   1000,
+			RemainingAmount: remaining,
+			Status:          status,
+			ExpiresAt:       expiresAt,
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		return grant
+	}
+	exists := func(t *testing.T, grant *models.CreditGrant) bool {
+		t.Helper()
+		found, err := models.CreditGrantExists(ctx, db, grant.ID)
+		require.NoError(t, err)
+		return found
+	}
+
+	licenseID := "test-license-cleanup-expired"
+	consumed := insertGrant(t, licenseID, GrantStatusConfirmed, 0, null.TimeFrom(longExpired))
+	consumedExpired := insertGrant(t, licenseID, GrantStatusExpired, 0, null.TimeFrom(longExpired))
+	withRemaining := insertGrant(t, licenseID, GrantStatusExpired, 100, null.TimeFrom(longExpired))
+	recent := insertGrant(t, licenseID, GrantStatusConfirmed, 0, null.TimeFrom(time.Now().Add(-time.Hour)))
+	perpetual := insertGrant(t, licenseID, GrantStatusConfirmed, 0, null.Time{})
+	operation := &models.CreditOperation{
+		LicenseID:     licenseID,
+		AssetDid:      testAssetID,
+		OperationType: OperationTypeDeduction,
+		TotalAmount:   1000,
+		AppName:       testAPIEndpoint,
+		ReferenceID:   "test-cleanup-deduction",
+	}
+	require.NoError(t, operation.Insert(ctx, db, boil.Infer()))
+	require.NoError(t, (&models.CreditOperationGrant{
+		AppName:       operation.AppName,
+		ReferenceID:   operation.ReferenceID,
+		OperationType: operation.OperationType,
+		GrantID:       consumed.ID,
+		AmountUsed:    -1000,
+	}).Insert(ctx, db, boil.Infer()))
+
+	debtLicenseID := "test-license-cleanup-debt"
+	withDebt := insertGrant(t, debtLicenseID, GrantStatusConfirmed, 0, null.TimeFrom(longExpired))
+	insertGrant(t, debtLicenseID, GrantStatusFailed, 400, null.TimeFrom(time.Now().Add(24*time.Hour)))
+
+	deleted, err := repo.CleanupExpiredGrants(ctx, retention)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	// Verify: Only the consumed grants expired past the retention are removed
+	assert.False(t, exists(t, consumed))
+	assert.False(t, exists(t, consumedExpired))
+	assert.True(t, exists(t, withRemaining))
+	assert.True(t, exists(t, recent))
+	assert.True(t, exists(t, perpetual))
+	assert.True(t, exists(t, withDebt))
+
+	// Verify: The operation is kept while its grant details are cascade deleted
+	operationExists, err := models.CreditOperationExists(ctx, db, operation.AppName, operation.ReferenceID, operation.OperationType)
+	require.NoError(t, err)
+	assert.True(t, operationExists)
+	details, err := models.CreditOperationGrants(models.CreditOperationGrantWhere.GrantID.EQ(consumed.ID)).Count(ctx, db)
+	require.NoError(t, err)
+	assert.Zero(t, details)
+
+	// Test: Refunding the deduction fails instead of recording a refund that credits nothing back
+	_, err = repo.RefundCredits(ctx, operation.AppName, operation.ReferenceID)
+	require.ErrorIs(t, err, RefundUnavailableErr)
+	_, err = repo.RefundPartialCredits(ctx, operation.AppName, operation.ReferenceID, 100)
+	require.ErrorIs(t, err, RefundUnavailableErr)
+	refunded, err := models.CreditOperationExists(ctx, db, operation.AppName, operation.ReferenceID, OperationTypeRefund)
+	require.NoError(t, err)
+	assert.False(t, refunded)
+
+	// Verify: A second run has nothing left to delete
+	deleted, err = repo.CleanupExpiredGrants(ctx, retention)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
@@ -0,0 +1,82 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// UsagePeriodComparison compares the usage of a license during two time periods.
+type UsagePeriodComparison struct {
+	// License ID
+	LicenseID string `json:"licenseId"`
+	// Usage during the baseline period
+	PeriodA *LicenseUsageReport `json:"periodA"`
+	// Usage during the compared period
+	PeriodB *LicenseUsageReport `json:"periodB"`
+	// Change in credits used from period A to period B
+	CreditsUsed UsageDelta `json:"creditsUsed"`
+	// Change in credit grants purchased from period A to period B
+	GrantsPurchased UsageDelta `json:"grantsPurchased"`
+	// Change in assets accessed from period A to period B
+	Assets UsageDelta `json:"assets"`
+}
+
+// UsageDelta is the change of a usage figure between two periods.
+type UsageDelta struct {
+	// Period B less period A
+	Absolute int64 `json:"absolute"`
+	// Change relative to period A in percent, null when period A is zero and period B is not
+	Percentage *float64 `json:"percentage"`
+}
+
+// CompareUsagePeriods returns the usage reports of a license for two periods, each given as [fromDate, toDate],
+// and the change of each figure from period A to period B. A zero toDate runs the period until now, like GetLicenseUsageReport.
+func (r *Repository) CompareUsagePeriods(ctx context.Context, licenseID string, periodA, periodB [2]time.Time) (*UsagePeriodComparison, error) {
+	var reportA, reportB *LicenseUsageReport
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		reportA, err = r.GetLicenseUsageReport(gctx, licenseID, periodA[0], periodA[1], false)
+		if err != nil {
+			return fmt.Errorf("failed to get usage of period A: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		reportB, err = r.GetLicenseUsageReport(gctx, licenseID, periodB[0], periodB[1], false)
+		if err != nil {
+			return fmt.Errorf("failed to get usage of period B: %w", err)
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &UsagePeriodComparison{
+		LicenseID:       licenseID,
+		PeriodA:         reportA,
+		PeriodB:         reportB,
+		CreditsUsed:     usageDelta(reportA.NumOfCreditsUsed, reportB.NumOfCreditsUsed),
+		GrantsPurchased: usageDelta(reportA.NumOfCreditsGrantsPurchased, reportB.NumOfCreditsGrantsPurchased),
+		Assets:          usageDelta(reportA.NumOfAssets, reportB.NumOfAssets),
+	}, nil
+}
+
+// usageDelta returns the change from a to b. The percentage has no baseline when a is zero, so it is nil unless b is zero as well.
+func usageDelta(a, b int64) UsageDelta {
+	delta := UsageDelta{Absolute: b - a}
+	switch {
+	case a != 0:
+		percentage := float64(b-a) / float64(a) * 100
+		delta.Percentage = &percentage
+	case b == 0:
+		percentage := 0.0
+		delta.Percentage = &percentage
+	}
+	return delta
+}
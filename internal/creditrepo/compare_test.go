@@ -0,0 +1,126 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestUsageDelta(t *testing.T) {
+	percent := func(v float64) *float64 { return &v }
+	cases := []struct {
+		name       string
+		a, b       int64
+		absolute   int64
+		percentage *float64
+	}{
+		{name: "growth", a: 200, b: 300, absolute: 100, percentage: percent(50.0)},
+		{name: "decline", a: 400, b: 100, absolute: -300, percentage: percent(-75.0)},
+		{name: "zero to nonzero", a: 0, b: 10, absolute: 10, percentage: nil},
+		{name: "nonzero to zero", a: 10, b: 0, absolute: -10, percentage: percent(-100.0)},
+		{name: "zero to zero", a: 0, b: 0, absolute: 0, percentage: percent(0.0)},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := usageDelta(tt.a, tt.b)
+			assert.Equal(t, tt.absolute, delta.Absolute)
+			if tt.percentage == nil {
+				assert.Nil(t, delta.Percentage)
+				return
+			}
+			require.NotNil(t, delta.Percentage)
+			assert.InDelta(t, *tt.percentage, *delta.Percentage, 1e-9)
+		})
+	}
+}
+
+func TestCompareUsagePeriods(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+	day := 24 * time.Hour
+	now := time.Now().Truncate(time.Second)
+	lastMonth := [2]time.Time{now.Add(-60 * day), now.Add(-30*day - time.Second)}
+	thisMonth := [2]time.Time{now.Add(-30 * day), now}
+
+	insertOperations := func(t *testing.T, licenseID string, operations []*models.CreditOperation) {
+		t.Helper()
+		for _, operation := range operations {
+			operation.LicenseID = licenseID
+			operation.AppName = testAPIEndpoint
+			require.NoError(t, operation.Insert(ctx, db, boil.Infer()))
+		}
+	}
+
+	t.Run("growth", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-compare-growth"
+		insertOperations(t, licenseID, []*models.CreditOperation{
+			{AssetDid: "asset-a", OperationType: OperationTypeDeduction, TotalAmount: 100, ReferenceID: "a-1", CreatedAt: null.TimeFrom(now.Add(-45 * day))},
+			{AssetDid: "asset-a", OperationType: OperationTypeGrantPurchase, TotalAmount: 1000, ReferenceID: "g-1", CreatedAt: null.TimeFrom(now.Add(-45 * day))},
+			{AssetDid: "asset-a", OperationType: OperationTypeDeduction, TotalAmount: 150, ReferenceID: "b-1", CreatedAt: null.TimeFrom(now.Add(-10 * day))},
+			{AssetDid: "asset-b", OperationType: OperationTypeDeduction, TotalAmount: 50, ReferenceID: "b-2", CreatedAt: null.TimeFrom(now.Add(-5 * day))},
+		})
+
+		comparison, err := repo.CompareUsagePeriods(ctx, licenseID, lastMonth, thisMonth)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), comparison.PeriodA.NumOfCreditsUsed)
+		assert.Equal(t, int64(200), comparison.PeriodB.NumOfCreditsUsed)
+		assert.Equal(t, int64(100), comparison.CreditsUsed.Absolute)
+		require.NotNil(t, comparison.CreditsUsed.Percentage)
+		assert.InDelta(t, 100.0, *comparison.CreditsUsed.Percentage, 1e-9)
+		assert.Equal(t, int64(1), comparison.Assets.Absolute)
+		require.NotNil(t, comparison.Assets.Percentage)
+		assert.InDelta(t, 100.0, *comparison.Assets.Percentage, 1e-9)
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-compare-decline"
+		insertOperations(t, licenseID, []*models.CreditOperation{
+			{AssetDid: "asset-a", OperationType: OperationTypeDeduction, TotalAmount: 400, ReferenceID: "a-1", CreatedAt: null.TimeFrom(now.Add(-45 * day))},
+			{AssetDid: "asset-a", OperationType: OperationTypeDeduction, TotalAmount: 100, ReferenceID: "b-1", CreatedAt: null.TimeFrom(now.Add(-10 * day))},
+		})
+
+		comparison, err := repo.CompareUsagePeriods(ctx, licenseID, lastMonth, thisMonth)
+		require.NoError(t, err)
+		assert.Equal(t, int64(-300), comparison.CreditsUsed.Absolute)
+		require.NotNil(t, comparison.CreditsUsed.Percentage)
+		assert.InDelta(t, -75.0, *comparison.CreditsUsed.Percentage, 1e-9)
+		assert.Equal(t, int64(0), comparison.Assets.Absolute)
+	})
+
+	t.Run("zero to nonzero", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-compare-new"
+		insertOperations(t, licenseID, []*models.CreditOperation{
+			{AssetDid: "asset-a", OperationType: OperationTypeDeduction, TotalAmount: 10, ReferenceID: "b-1", CreatedAt: null.TimeFrom(now.Add(-10 * day))},
+		})
+
+		comparison, err := repo.CompareUsagePeriods(ctx, licenseID, lastMonth, thisMonth)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), comparison.CreditsUsed.Absolute)
+		assert.Nil(t, comparison.CreditsUsed.Percentage)
+		assert.Nil(t, comparison.Assets.Percentage)
+		// nothing was purchased in either period
+		require.NotNil(t, comparison.GrantsPurchased.Percentage)
+		assert.Zero(t, *comparison.GrantsPurchased.Percentage)
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.CompareUsagePeriods(ctx, "test-license-compare-invalid", [2]time.Time{now, now.Add(-day)}, thisMonth)
+		require.Error(t, err)
+	})
+}
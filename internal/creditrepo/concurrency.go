@@ -0,0 +1,70 @@
+package creditrepo
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// DeductionsInFlight tracks the deduction and refund transactions currently holding a concurrency slot
+	DeductionsInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "credit_tracker_deductions_in_flight",
+			Help: "Number of deduction and refund operations currently running on this instance",
+		},
+	)
+
+	// DeductionsRejected counts the deduction and refund operations shed because the concurrency limit was reached
+	DeductionsRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "credit_tracker_deductions_rejected_total",
+			Help: "Total number of deduction and refund operations rejected by the concurrency limit",
+		},
+		[]string{"operation"},
+	)
+)
+
+// WithMaxConcurrentDeductions limits how many deduction and refund operations run at once on this repository.
+// An operation started while the limit is reached fails right away with ConcurrencyLimitErr instead of waiting
+// for a connection or a row lock. Zero disables the limit.
+func WithMaxConcurrentDeductions(limit int) Option {
+	return func(r *Repository) {
+		if limit > 0 {
+			r.deductionSlots = make(chan struct{}, limit)
+		} else {
+			r.deductionSlots = nil
+		}
+	}
+}
+
+// acquireDeductionSlot takes a concurrency slot for the operation and returns the function releasing it.
+// It fails with ConcurrencyLimitErr when every slot is taken.
+func (r *Repository) acquireDeductionSlot(funcName string) (func(), error) {
+	if r.deductionSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case r.deductionSlots <- struct{}{}:
+		DeductionsInFlight.Inc()
+		return func() {
+			<-r.deductionSlots
+			DeductionsInFlight.Dec()
+		}, nil
+	default:
+		DeductionsRejected.WithLabelValues(funcName).Inc()
+		return nil, ConcurrencyLimitErr
+	}
+}
+
+// limitedTx runs the operation like retryTx while holding a deduction slot, the slot is kept across deadlock retries.
+func limitedTx[T any](ctx context.Context, r *Repository, funcName string, operation func(ctx context.Context) (T, error)) (T, error) {
+	release, err := r.acquireDeductionSlot(funcName)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer release()
+	return retryTx(ctx, r, funcName, operation)
+}
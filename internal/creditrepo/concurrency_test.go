@@ -0,0 +1,62 @@
+package creditrepo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxConcurrentDeductions(t *testing.T) {
+	ctx := context.Background()
+	repo := New(nil, WithMaxConcurrentDeductions(2))
+
+	// Setup: Two operations hold the slots until released
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := limitedTx(ctx, repo, "TestMaxConcurrentDeductions", func(context.Context) (int, error) {
+				started <- struct{}{}
+				<-release
+				return 0, nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	<-started
+	<-started
+	assert.Equal(t, float64(2), testutil.ToFloat64(DeductionsInFlight))
+
+	// Test: Excess deductions and refunds are shed before touching the database
+	rejected := testutil.ToFloat64(DeductionsRejected.WithLabelValues("DeductCredits"))
+	_, err := repo.DeductCredits(ctx, "license", "asset", 10, "app", "ref-1")
+	require.ErrorIs(t, err, ConcurrencyLimitErr)
+	_, err = repo.RefundCredits(ctx, "app", "ref-1")
+	require.ErrorIs(t, err, ConcurrencyLimitErr)
+	assert.Equal(t, rejected+1, testutil.ToFloat64(DeductionsRejected.WithLabelValues("DeductCredits")))
+
+	// Verify: Released slots can be taken again
+	close(release)
+	wg.Wait()
+	assert.Equal(t, float64(0), testutil.ToFloat64(DeductionsInFlight))
+	result, err := limitedTx(ctx, repo, "TestMaxConcurrentDeductions", func(context.Context) (int, error) {
+		return 1, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result)
+}
+
+func TestMaxConcurrentDeductionsDisabled(t *testing.T) {
+	repo := New(nil, WithMaxConcurrentDeductions(0))
+	for range 3 {
+		_, err := repo.acquireDeductionSlot("TestMaxConcurrentDeductionsDisabled")
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,172 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/null/v8"
+)
+
+const (
+	// confirmGrantsChunkSize is the maximum number of confirmations committed in a single transaction.
+	confirmGrantsChunkSize = 100
+	confirmGrantSavepoint  = "confirm_grant"
+)
+
+const (
+	// ConfirmStatusConfirmed means the grant was confirmed by this call.
+	ConfirmStatusConfirmed = "confirmed"
+	// ConfirmStatusAlreadyConfirmed means the chain event was already confirmed and was skipped.
+	ConfirmStatusAlreadyConfirmed = "already_confirmed"
+	// ConfirmStatusConflict means the chain event was already confirmed with a different license, asset, or amount,
+	// or it matches a pending grant of a different license, asset, or amount.
+	ConfirmStatusConflict = "conflict"
+	// ConfirmStatusFailed means the confirmation failed, see the outcome error.
+	ConfirmStatusFailed = "failed"
+)
+
+// ConfirmInput is a single chain event to confirm.
+type ConfirmInput struct {
+	LicenseID string
+	AssetDID  string
+	TxHash    string
+	LogIndex  int
+	Amount    uint64
+	MintTime  time.Time
+	// Block of the burn, nil when unknown, see WithGrantBlockNumber
+	BlockNumber *int64
+}
+
+// ConfirmOutcome is the result of confirming a single chain event.
+type ConfirmOutcome struct {
+	Input     ConfirmInput
+	Status    string
+	Operation *models.CreditOperation
+	Err       error
+}
+
+// ConfirmGrants confirms a batch of grants in chunked transactions.
+// Chain events that were already confirmed are skipped, and a failure of one confirmation does not affect the others.
+// The returned outcomes are in the same order as the confirmations.
+func (r *Repository) ConfirmGrants(ctx context.Context, confirmations []ConfirmInput) ([]ConfirmOutcome, error) {
+	outcomes := make([]ConfirmOutcome, 0, len(confirmations))
+	for start := 0; start < len(confirmations); start += confirmGrantsChunkSize {
+		chunk := confirmations[start:min(start+confirmGrantsChunkSize, len(confirmations))]
+		chunkOutcomes, err := retryTx(ctx, r, "ConfirmGrants", func(ctx context.Context) ([]ConfirmOutcome, error) {
+			return r.confirmGrantsChunk(ctx, chunk)
+		})
+		if err != nil {
+			return outcomes, err
+		}
+		outcomes = append(outcomes, chunkOutcomes...)
+	}
+	return outcomes, nil
+}
+
+// confirmGrantsChunk confirms a chunk of grants in a single transaction
+// each confirmation runs in its own savepoint so a failed confirmation is rolled back on its own.
+func (r *Repository) confirmGrantsChunk(ctx context.Context, confirmations []ConfirmInput) ([]ConfirmOutcome, error) {
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("ConfirmGrants")()
+	defer rollbackTx(ctx, tx)
+
+	outcomes := make([]ConfirmOutcome, 0, len(confirmations))
+	var confirmed []*grantConfirmation
+	for _, input := range confirmations {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT "+confirmGrantSavepoint); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		outcome := r.confirmGrantOutcome(ctx, tx, input)
+		if IsRetryableError(outcome.Err) {
+			// the whole chunk is retried on deadlock or serialization failure
+			return nil, outcome.Err
+		}
+		if outcome.Status == ConfirmStatusFailed {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+confirmGrantSavepoint); err != nil {
+				return nil, fmt.Errorf("failed to rollback to savepoint: %w", err)
+			}
+		} else if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+confirmGrantSavepoint); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		if outcome.Status == ConfirmStatusConfirmed {
+			confirmation, err := r.newGrantConfirmation(ctx, tx, outcome.Operation)
+			if err != nil {
+				return nil, err
+			}
+			confirmed = append(confirmed, confirmation)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, outcome := range outcomes {
+		if outcome.Status == ConfirmStatusConfirmed {
+			r.logOperation(ctx, outcome.Operation, "confirmed grant")
+			r.observeSettledDebt(ctx, outcome.Operation.LicenseID)
+		}
+	}
+	for _, confirmation := range confirmed {
+		r.notifyGrantConfirmed(ctx, confirmation)
+	}
+
+	return outcomes, nil
+}
+
+// confirmGrantOutcome confirms a single grant within the transaction unless the chain event was already confirmed.
+func (r *Repository) confirmGrantOutcome(ctx context.Context, tx *sql.Tx, input ConfirmInput) ConfirmOutcome {
+	outcome := ConfirmOutcome{Input: input}
+	if input.Amount == 0 {
+		outcome.Status = ConfirmStatusFailed
+		outcome.Err = fmt.Errorf("invalid amount: %d. Amount must be positive", input.Amount)
+		return outcome
+	}
+	if input.Amount > math.MaxInt64 {
+		outcome.Status = ConfirmStatusFailed
+		outcome.Err = fmt.Errorf("credit amount is too large must be less than %d", math.MaxInt64)
+		return outcome
+	}
+
+	existing, err := models.CreditGrants(
+		models.CreditGrantWhere.TXHash.EQ(input.TxHash),
+		models.CreditGrantWhere.LogIndex.EQ(null.IntFrom(input.LogIndex)),
+	).One(ctx, tx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		outcome.Status = ConfirmStatusFailed
+		outcome.Err = fmt.Errorf("failed to find confirmed grant: %w", err)
+		return outcome
+	}
+	if existing != nil {
+		if existing.LicenseID != input.LicenseID || existing.AssetDid != input.AssetDID || existing.InitialAmount != int64(input.Amount) {
+			outcome.Status = ConfirmStatusConflict
+			outcome.Err = fmt.Errorf("%w: grant %s", ConfirmConflictErr, existing.ID)
+			return outcome
+		}
+		outcome.Status = ConfirmStatusAlreadyConfirmed
+		return outcome
+	}
+
+	operation, err := r.confirmGrantTx(ctx, tx, input.LicenseID, input.AssetDID, input.TxHash, input.LogIndex, int64(input.Amount), input.MintTime, GrantOptions{BlockNumber: input.BlockNumber})
+	if errors.Is(err, PendingGrantMismatchErr) || errors.Is(err, GrantAmountMismatchErr) {
+		outcome.Status = ConfirmStatusConflict
+		outcome.Err = err
+		return outcome
+	}
+	if err != nil {
+		outcome.Status = ConfirmStatusFailed
+		outcome.Err = err
+		return outcome
+	}
+	outcome.Status = ConfirmStatusConfirmed
+	outcome.Operation = operation
+	return outcome
+}
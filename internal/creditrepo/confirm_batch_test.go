@@ -0,0 +1,73 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmGrants(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("batch with new, already confirmed, and conflicting events", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-confirm-batch"
+		newTxHash := common.BytesToAddress([]byte(licenseID + "-new")).Hex()
+		confirmedTxHash := common.BytesToAddress([]byte(licenseID + "-confirmed")).Hex()
+
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, confirmedTxHash, 1, uint64(defaultGrantAmount), time.Now())
+		require.NoError(t, err)
+
+		outcomes, err := repo.ConfirmGrants(ctx, []ConfirmInput{
+			{LicenseID: licenseID, AssetDID: testAssetID, TxHash: newTxHash, LogIndex: 1, Amount: uint64(defaultGrantAmount), MintTime: time.Now()},
+			{LicenseID: licenseID, AssetDID: testAssetID, TxHash: confirmedTxHash, LogIndex: 1, Amount: uint64(defaultGrantAmount), MintTime: time.Now()},
+			{LicenseID: licenseID, AssetDID: testAssetID, TxHash: confirmedTxHash, LogIndex: 1, Amount: uint64(defaultGrantAmount) + 1, MintTime: time.Now()},
+			{LicenseID: licenseID, AssetDID: testAssetID, TxHash: newTxHash, LogIndex: 2, Amount: 0, MintTime: time.Now()},
+		})
+		require.NoError(t, err)
+		require.Len(t, outcomes, 4)
+
+		assert.Equal(t, ConfirmStatusConfirmed, outcomes[0].Status)
+		require.NotNil(t, outcomes[0].Operation)
+		assert.NoError(t, outcomes[0].Err)
+
+		assert.Equal(t, ConfirmStatusAlreadyConfirmed, outcomes[1].Status)
+		assert.NoError(t, outcomes[1].Err)
+
+		assert.Equal(t, ConfirmStatusConflict, outcomes[2].Status)
+		assert.ErrorIs(t, outcomes[2].Err, ConfirmConflictErr)
+
+		assert.Equal(t, ConfirmStatusFailed, outcomes[3].Status)
+		assert.Error(t, outcomes[3].Err)
+
+		// Verify: only the two confirmed events produced grants
+		grants, err := models.CreditGrants(
+			models.CreditGrantWhere.LicenseID.EQ(licenseID),
+		).All(ctx, db)
+		require.NoError(t, err)
+		require.Len(t, grants, 2)
+		for _, grant := range grants {
+			assert.Equal(t, GrantStatusConfirmed, grant.Status)
+			assert.Equal(t, defaultGrantAmount, grant.InitialAmount)
+		}
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		t.Parallel()
+		outcomes, err := repo.ConfirmGrants(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, outcomes)
+	})
+}
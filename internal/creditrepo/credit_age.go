@@ -0,0 +1,77 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// CreditAgeStats is the amount-weighted age of the spendable credits of a license and asset.
+type CreditAgeStats struct {
+	// Spendable credits the averages are weighted over
+	SpendableCredits int64 `json:"spendableCredits"`
+	// Amount-weighted average time since the grants were created
+	AverageAge time.Duration `json:"averageAge"`
+	// Amount-weighted average time until the grants expire, perpetual grants are left out
+	AverageTimeToExpiry time.Duration `json:"averageTimeToExpiry"`
+}
+
+// GetCreditAgeStats returns the weighted-average age and time to expiry of the spendable credits,
+// where each active grant is weighted by its remaining amount.
+func (r *Repository) GetCreditAgeStats(ctx context.Context, licenseID, assetDID string) (*CreditAgeStats, error) {
+	return retryTx(ctx, r, "GetCreditAgeStats", func(ctx context.Context) (*CreditAgeStats, error) {
+		return r.getCreditAgeStatsInternal(ctx, licenseID, assetDID)
+	})
+}
+
+func (r *Repository) getCreditAgeStatsInternal(ctx context.Context, licenseID, assetDID string) (*CreditAgeStats, error) {
+	now := time.Now()
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.LicenseID.EQ(licenseID),
+		models.CreditGrantWhere.AssetDid.EQ(assetDID),
+		models.CreditGrantWhere.RemainingAmount.GT(0),
+		notExpired(now),
+		models.CreditGrantWhere.Status.IN(r.spendableStatuses()),
+		qm.Select(
+			models.CreditGrantColumns.RemainingAmount,
+			models.CreditGrantColumns.CreatedAt,
+			models.CreditGrantColumns.ExpiresAt,
+		),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active grants: %w", err)
+	}
+	return creditAgeStats(grants, now), nil
+}
+
+// creditAgeStats computes the amount-weighted averages of the given grants at the given time.
+// Grants without a creation time are treated as created now.
+func creditAgeStats(grants []*models.CreditGrant, now time.Time) *CreditAgeStats {
+	stats := &CreditAgeStats{}
+	// weighted sums are accumulated as floats since amount * duration easily overflows int64
+	var ageSum, expirySum, expiringCredits float64
+	for _, grant := range grants {
+		createdAt := now
+		if grant.CreatedAt.Valid {
+			createdAt = grant.CreatedAt.Time
+		}
+		weight := float64(grant.RemainingAmount)
+		ageSum += weight * float64(now.Sub(createdAt))
+		if grant.ExpiresAt.Valid {
+			expirySum += weight * float64(grant.ExpiresAt.Time.Sub(now))
+			expiringCredits += weight
+		}
+		stats.SpendableCredits += grant.RemainingAmount
+	}
+	if stats.SpendableCredits == 0 {
+		return stats
+	}
+	stats.AverageAge = time.Duration(ageSum / float64(stats.SpendableCredits))
+	if expiringCredits > 0 {
+		stats.AverageTimeToExpiry = time.Duration(expirySum / expiringCredits)
+	}
+	return stats
+}
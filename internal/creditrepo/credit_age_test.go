@@ -0,0 +1,90 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGetCreditAgeStats(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("weighted by remaining amount", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-credit-age"
+		now := time.Now()
+		// 300 credits aged 1 day expiring in 10 days and 100 credits aged 5 days expiring in 2 days
+		grants := []*models.CreditGrant{
+			{RemainingAmount: 300, CreatedAt: null.TimeFrom(now.Add(-24 * time.Hour)), ExpiresAt: null.TimeFrom(now.Add(10 * 24 * time.Hour))},
+			{RemainingAmount: 100, CreatedAt: null.TimeFrom(now.Add(-5 * 24 * time.Hour)), ExpiresAt: null.TimeFrom(now.Add(2 * 24 * time.Hour))},
+			// expired and failed grants are not spendable
+			{RemainingAmount: 1000, CreatedAt: null.TimeFrom(now.Add(-40 * 24 * time.Hour)), ExpiresAt: null.TimeFrom(now.Add(-time.Hour))},
+			{RemainingAmount: 1000, CreatedAt: null.TimeFrom(now.Add(-40 * 24 * time.Hour)), ExpiresAt: null.TimeFrom(now.Add(time.Hour)), Status: GrantStatusFailed},
+		}
+		for _, grant := range grants {
+			grant.LicenseID = licenseID
+			grant.AssetDid = testAssetID
+			grant.InitialAmount = grant.RemainingAmount
+			if grant.Status == "" {
+				grant.Status = GrantStatusConfirmed
+			}
+			require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		}
+
+		stats, err := repo.GetCreditAgeStats(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(400), stats.SpendableCredits)
+		// (300*1 + 100*5) / 400 = 2 days
+		assert.InDelta(t, float64(2*24*time.Hour), float64(stats.AverageAge), float64(time.Minute))
+		// (300*10 + 100*2) / 400 = 8 days
+		assert.InDelta(t, float64(8*24*time.Hour), float64(stats.AverageTimeToExpiry), float64(time.Minute))
+	})
+
+	t.Run("no spendable credits", func(t *testing.T) {
+		t.Parallel()
+		stats, err := repo.GetCreditAgeStats(ctx, "test-license-credit-age-empty", testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, CreditAgeStats{}, *stats)
+	})
+}
+
+func TestCreditAgeStats(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	t.Run("equal amounts average evenly", func(t *testing.T) {
+		stats := creditAgeStats([]*models.CreditGrant{
+			{RemainingAmount: 50, CreatedAt: null.TimeFrom(now.Add(-2 * day)), ExpiresAt: null.TimeFrom(now.Add(4 * day))},
+			{RemainingAmount: 50, CreatedAt: null.TimeFrom(now.Add(-4 * day)), ExpiresAt: null.TimeFrom(now.Add(2 * day))},
+		}, now)
+		assert.Equal(t, int64(100), stats.SpendableCredits)
+		assert.Equal(t, 3*day, stats.AverageAge)
+		assert.Equal(t, 3*day, stats.AverageTimeToExpiry)
+	})
+
+	t.Run("larger grants weigh more", func(t *testing.T) {
+		stats := creditAgeStats([]*models.CreditGrant{
+			{RemainingAmount: 900, CreatedAt: null.TimeFrom(now), ExpiresAt: null.TimeFrom(now.Add(30 * day))},
+			{RemainingAmount: 100, CreatedAt: null.TimeFrom(now.Add(-10 * day)), ExpiresAt: null.TimeFrom(now.Add(20 * day))},
+		}, now)
+		assert.Equal(t, day, stats.AverageAge)
+		assert.Equal(t, 29*day, stats.AverageTimeToExpiry)
+	})
+
+	t.Run("no grants", func(t *testing.T) {
+		assert.Equal(t, CreditAgeStats{}, *creditAgeStats(nil, now))
+	})
+}
@@ -0,0 +1,69 @@
+package creditrepo
+
+import (
+	"fmt"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// DefaultCreditType is the credit type of grants and operations that do not name one.
+const DefaultCreditType = "default"
+
+// maxCreditTypeLength is the length of the credit_type columns.
+const maxCreditTypeLength = 50
+
+// WithGrantCreditType makes the grant's credits spendable only by deductions of the credit type, such as "telemetry" or "attestation".
+// Grants created without a credit type are of DefaultCreditType, confirming a pending grant without one keeps the type of the pending grant.
+func WithGrantCreditType(creditType string) GrantOption {
+	return func(o *GrantOptions) {
+		o.CreditType = creditType
+	}
+}
+
+// PoolOption selects the pool of credits a deduction or balance is for.
+type PoolOption func(*PoolOptions)
+
+// PoolOptions are the options of a deduction or balance, set with PoolOption.
+type PoolOptions struct {
+	// Credit type of the grants to use, DefaultCreditType when empty
+	CreditType string
+}
+
+// WithCreditType draws a deduction from, or reports the balance of, the grants of the credit type only.
+func WithCreditType(creditType string) PoolOption {
+	return func(o *PoolOptions) {
+		o.CreditType = creditType
+	}
+}
+
+// NewPoolOptions applies the pool options and validates the result.
+func NewPoolOptions(opts ...PoolOption) (PoolOptions, error) {
+	options := PoolOptions{CreditType: DefaultCreditType}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.CreditType == "" {
+		options.CreditType = DefaultCreditType
+	}
+	if err := validateCreditType(options.CreditType); err != nil {
+		return options, err
+	}
+	return options, nil
+}
+
+// validateCreditType checks that the credit type fits the credit_type columns.
+func validateCreditType(creditType string) error {
+	if len(creditType) > maxCreditTypeLength {
+		return fmt.Errorf("credit type must be at most %d characters", maxCreditTypeLength)
+	}
+	return nil
+}
+
+// creditTypeMods adds the filter of the credit type to grant query mods, an empty credit type matches grants of every type.
+func creditTypeMods(mods []qm.QueryMod, creditType string) []qm.QueryMod {
+	if creditType == "" {
+		return mods
+	}
+	return append(mods, models.CreditGrantWhere.CreditType.EQ(creditType))
+}
@@ -0,0 +1,166 @@
+package creditrepo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestCreditTypes(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("deduction does not touch grants of another type", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-credit-type-isolated"
+
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xcredit-type-telemetry", 0, 1000, time.Now(), WithGrantCreditType("telemetry"))
+		require.NoError(t, err)
+		attestationOp, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xcredit-type-attestation", 0, 500, time.Now(), WithGrantCreditType("attestation"))
+		require.NoError(t, err)
+
+		operation, err := repo.DeductCredits(ctx, licenseID, testAssetID, 300, "test-app", "credit-type-deduct-1", WithCreditType("telemetry"))
+		require.NoError(t, err)
+		assert.Equal(t, "telemetry", operation.CreditType)
+
+		// Verify: Only the telemetry pool was drawn from
+		telemetry, err := repo.GetBalance(ctx, licenseID, testAssetID, WithCreditType("telemetry"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(700), telemetry.Balance)
+
+		attestation, err := repo.GetBalance(ctx, licenseID, testAssetID, WithCreditType("attestation"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(500), attestation.Balance)
+
+		attestationGrant, err := models.FindCreditGrant(ctx, db, attestationOp.ReferenceID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(500), attestationGrant.RemainingAmount)
+
+		// Verify: The other pool does not cover a deduction larger than the matching pool
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 600, "test-app", "credit-type-deduct-2", WithCreditType("attestation"))
+		var insufficient *InsufficientCreditsError
+		require.ErrorAs(t, err, &insufficient)
+		assert.Equal(t, int64(500), insufficient.Available)
+	})
+
+	t.Run("debt only blocks deductions of its type", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-credit-type-debt"
+		// Setup: A failed attestation grant with 100 debt and a telemetry grant
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   500,
+			RemainingAmount: 400,
+			Status:          GrantStatusFailed,
+			CreditType:      "attestation",
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xcredit-type-debt-telemetry", 0, 1000, time.Now(), WithGrantCreditType("telemetry"))
+		require.NoError(t, err)
+
+		// Test: The telemetry deduction is not blocked by the attestation debt
+		operation, err := repo.DeductCredits(ctx, licenseID, testAssetID, 300, "test-app", "credit-type-debt-deduct-1", WithCreditType("telemetry"))
+		require.NoError(t, err)
+		assert.Equal(t, "telemetry", operation.CreditType)
+
+		// Verify: An attestation deduction is still refused until the attestation debt is settled
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xcredit-type-debt-attestation", 0, 50, time.Now(), WithGrantCreditType("attestation"))
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 10, "test-app", "credit-type-debt-deduct-2", WithCreditType("attestation"))
+		require.ErrorIs(t, err, OutstandingDebtErr)
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xcredit-type-debt-attestation-2", 0, 100, time.Now(), WithGrantCreditType("attestation"))
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 10, "test-app", "credit-type-debt-deduct-3", WithCreditType("attestation"))
+		require.NoError(t, err)
+	})
+
+	t.Run("default credit type", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-credit-type-default"
+
+		grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, DefaultCreditType, grant.CreditType)
+
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 100, "test-app", "credit-type-default-1", WithCreditType("telemetry"))
+		var insufficient *InsufficientCreditsError
+		require.ErrorAs(t, err, &insufficient)
+
+		operation, err := repo.DeductCredits(ctx, licenseID, testAssetID, 100, "test-app", "credit-type-default-2")
+		require.NoError(t, err)
+		assert.Equal(t, DefaultCreditType, operation.CreditType)
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(900), balance.Balance)
+	})
+
+	t.Run("grants of different types can be pending at once", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-credit-type-create"
+
+		_, err := repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now(), WithGrantCreditType("telemetry"))
+		require.NoError(t, err)
+		_, err = repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now(), WithGrantCreditType("attestation"))
+		require.NoError(t, err)
+
+		_, err = repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now(), WithGrantCreditType("telemetry"))
+		require.ErrorIs(t, err, GrantAlreadyExistsErr)
+	})
+
+	t.Run("refund returns credits to the deducted type", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-credit-type-refund"
+
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xcredit-type-refund", 0, 1000, time.Now(), WithGrantCreditType("telemetry"))
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 400, "test-app", "credit-type-refund-1", WithCreditType("telemetry"))
+		require.NoError(t, err)
+
+		refund, err := repo.RefundCredits(ctx, "test-app", "credit-type-refund-1")
+		require.NoError(t, err)
+		assert.Equal(t, "telemetry", refund.CreditType)
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID, WithCreditType("telemetry"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), balance.Balance)
+	})
+}
+
+func TestNewPoolOptions(t *testing.T) {
+	t.Parallel()
+
+	options, err := NewPoolOptions()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCreditType, options.CreditType)
+
+	options, err = NewPoolOptions(WithCreditType(""))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCreditType, options.CreditType)
+
+	options, err = NewPoolOptions(WithCreditType("telemetry"))
+	require.NoError(t, err)
+	assert.Equal(t, "telemetry", options.CreditType)
+
+	_, err = NewPoolOptions(WithCreditType(strings.Repeat("a", maxCreditTypeLength+1)))
+	require.Error(t, err)
+
+	grantOptions, err := NewGrantOptions()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCreditType, grantOptions.creditType())
+}
@@ -1,19 +1,25 @@
 package creditrepo
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/DIMO-Network/credit-tracker/internal/tracing"
 	"github.com/DIMO-Network/credit-tracker/models"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -22,64 +28,191 @@ const (
 	OperationTypeGrantPurchase  = "grant_purchase"
 	OperationTypeGrantConfirm   = "grant_confirm"
 	OperationTypeDebtSettlement = "debt_settlement"
+	OperationTypeExpiration     = "expiration"
+	OperationTypeGrantFailed    = "grant_failed"
+	OperationTypeTransfer       = "transfer"
 )
 
 const (
 	GrantStatusPending   = "pending"
 	GrantStatusConfirmed = "confirmed"
 	GrantStatusFailed    = "failed"
+	GrantStatusExpired   = "expired"
 )
 
-func New(db *sql.DB) *Repository {
-	return &Repository{db: db}
+// Option configures optional behavior of the Repository.
+type Option func(*Repository)
+
+// WithProjectionOptions sets the rounding used for projected exhaustion and utilization computations.
+func WithProjectionOptions(opts ProjectionOptions) Option {
+	return func(r *Repository) {
+		r.projection = opts
+	}
+}
+
+// WithDepletedGrantMarking sets whether grants that are fully used are marked with a depleted_at timestamp.
+func WithDepletedGrantMarking(enabled bool) Option {
+	return func(r *Repository) {
+		r.markDepletedGrants = enabled
+	}
+}
+
+// WithPendingGrantMismatchConfirmation sets whether a confirmation whose tx hash matches a pending grant of a different license or asset
+// creates a new grant instead of failing with PendingGrantMismatchErr.
+func WithPendingGrantMismatchConfirmation(allowed bool) Option {
+	return func(r *Repository) {
+		r.allowPendingGrantMismatch = allowed
+	}
+}
+
+// WithPendingGrantSpending sets whether deductions can spend the credits of pending grants before their burn is confirmed,
+// which is the default. A pending grant that fails after being spent from leaves debt. When disabled only confirmed grants
+// are spendable and count toward the balance.
+func WithPendingGrantSpending(allowed bool) Option {
+	return func(r *Repository) {
+		r.allowSpendingPendingGrants = allowed
+	}
+}
+
+// WithOpTimeout bounds every attempt of a repository operation, including its transaction, by the given timeout.
+// An attempt that times out is not retried, so a stuck lock fails the operation instead of holding a connection. Zero disables the timeout.
+func WithOpTimeout(timeout time.Duration) Option {
+	return func(r *Repository) {
+		r.opTimeout = timeout
+	}
+}
+
+func New(db *sql.DB, opts ...Option) *Repository {
+	repo := &Repository{
+		db:                         db,
+		projection:                 DefaultProjectionOptions(),
+		usage:                      DefaultUsageOptions(),
+		allowSpendingPendingGrants: true,
+		deadlockRetry:              DefaultDeadlockRetryPolicy(),
+		isolationLevel:             sql.LevelReadCommitted,
+		tracer:                     otel.Tracer(tracing.TracerName),
+		operationHub:               NewOperationHub(),
+		drain:                      newDrainState(),
+		debtGauge:                  newDebtGauge(),
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
 }
 
 type Repository struct {
-	db *sql.DB
+	db                         *sql.DB
+	projection                 ProjectionOptions
+	usage                      UsageOptions
+	markDepletedGrants         bool
+	summaryOnlyApps            map[string]struct{}
+	refundOverflow             RefundOverflowPolicy
+	allowPendingGrantMismatch  bool
+	recordBalanceAfter         bool
+	lazyExpiration             bool
+	allowSpendingPendingGrants bool
+	discountTiers              []DiscountTier
+	grantConfirmedNotifier     GrantConfirmedNotifier
+	opTimeout                  time.Duration
+	deadlockRetry              DeadlockRetryPolicy
+	isolationLevel             sql.IsolationLevel
+	deductionSlots             chan struct{}
+	tracer                     trace.Tracer
+	operationHub               *OperationHub
+	drain                      *drainState
+	debtGauge                  *debtGauge
+}
+
+// spendableStatuses returns the statuses of the grants deductions can spend from.
+func (r *Repository) spendableStatuses() []string {
+	if r.allowSpendingPendingGrants {
+		return []string{GrantStatusConfirmed, GrantStatusPending}
+	}
+	return []string{GrantStatusConfirmed}
 }
 
 // DeductCredits deducts credits using FIFO logic with full ACID guarantees
-// 1. Check for outstanding debt from failed grants
+// 1. Check for outstanding debt from failed grants of the credit type
 // 2. Check if the current balance is sufficient
 // 3. Create a operation record
 // 4. Get active grants in FIFO order (with row-level locking)
 // 5. Deduct from grants using FIFO and record details
 // 6. Commit the operation
-func (r *Repository) DeductCredits(ctx context.Context, licenseID, assetDID string, deductionAmount uint64, appName, referenceID string) (*models.CreditOperation, error) {
-	return RetryWithDeadlockHandling(ctx, "DeductCredits", func() (*models.CreditOperation, error) {
-		return r.deductCreditsInternal(ctx, licenseID, assetDID, deductionAmount, appName, referenceID)
+// Only grants of the credit type of the deduction are used, DefaultCreditType unless set with WithCreditType.
+func (r *Repository) DeductCredits(ctx context.Context, licenseID, assetDID string, deductionAmount uint64, appName, referenceID string, opts ...PoolOption) (*models.CreditOperation, error) {
+	options, err := NewPoolOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	logger := operationLogger(ctx, licenseID, assetDID, appName, referenceID, deductionAmount).With().Str("creditType", options.CreditType).Logger()
+	logger.Debug().Msg("deducting credits")
+	operation, err := limitedTx(ctx, r, "DeductCredits", func(ctx context.Context) (*models.CreditOperation, error) {
+		return r.deductCreditsInternal(ctx, licenseID, assetDID, deductionAmount, appName, referenceID, options.CreditType)
 	})
+	if err != nil {
+		logger.Debug().Err(err).Msg("failed to deduct credits")
+		r.observeDebtRejection(ctx, licenseID, err)
+	}
+	return operation, err
 }
 
 // deductCreditsInternal is the internal implementation of DeductCredits
-func (r *Repository) deductCreditsInternal(ctx context.Context, licenseID, assetDID string, deductionAmount uint64, appName, referenceID string) (*models.CreditOperation, error) {
+func (r *Repository) deductCreditsInternal(ctx context.Context, licenseID, assetDID string, deductionAmount uint64, appName, referenceID, creditType string) (*models.CreditOperation, error) {
 	if deductionAmount > math.MaxInt64 {
 		return nil, fmt.Errorf("deduction amount is too large must be less than %d", math.MaxInt64)
 	}
-	amount := int64(deductionAmount)
 
-	// First check for outstanding debt from failed grants
-	debt, err := r.getOutstandingDebt(ctx, licenseID, assetDID)
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
 	if err != nil {
-		return nil, fmt.Errorf("failed to check outstanding debt: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer observeTransaction("DeductCredits")()
+	defer rollbackTx(ctx, tx)
 
-	if debt > 0 {
-		return nil, fmt.Errorf("cannot use credits, while there is outstanding debt: %d. Please add credits to clear debt first", debt)
+	expired, err := r.expireGrants(ctx, tx, licenseID, assetDID)
+	if err != nil {
+		return nil, err
+	}
+	operation, err := r.deductCreditsTx(ctx, tx, licenseID, assetDID, int64(deductionAmount), appName, referenceID, creditType)
+	if err != nil {
+		return nil, err
 	}
 
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelReadCommitted,
-	})
+	// Commit the transaction
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.logExpirations(ctx, expired)
+	r.logOperation(ctx, operation, "deducted credits")
+
+	return operation, nil
+}
+
+// deductCreditsTx deducts credits from the active grants of the credit type in FIFO order within the given transaction.
+func (r *Repository) deductCreditsTx(ctx context.Context, tx *sql.Tx, licenseID, assetDID string, amount int64, appName, referenceID, creditType string) (*models.CreditOperation, error) {
+	operation, _, err := r.debitGrantsTx(ctx, tx, licenseID, assetDID, amount, null.Int64{}, OperationTypeDeduction, appName, referenceID, creditType)
+	return operation, err
+}
+
+// debitGrantsTx takes credits from the active grants of the credit type in FIFO order within the given transaction and records them as an operation of the given type.
+// A valid nominal amount is recorded as the credits of the operation before its discount.
+// It returns the operation and the grants credits were taken from. The caller expires the passed grants first with expireGrants.
+func (r *Repository) debitGrantsTx(ctx context.Context, tx *sql.Tx, licenseID, assetDID string, amount int64, nominalAmount null.Int64, operationType, appName, referenceID, creditType string) (*models.CreditOperation, []*models.CreditGrant, error) {
+	// First check for outstanding debt from failed grants of the credit type, only credits of that type can settle it
+	debt, err := outstandingDebt(ctx, tx, licenseID, assetDID, creditType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, nil, fmt.Errorf("failed to check outstanding debt: %w", err)
+	}
+
+	if debt > 0 {
+		return nil, nil, fmt.Errorf("%w: %d. Please add credits to clear debt first", OutstandingDebtErr, debt)
 	}
-	defer rollbackTx(ctx, tx)
 
 	// Calculate current available balance from active grants only
-	grants, err := r.getActiveGrants(ctx, tx, licenseID, assetDID)
+	grants, err := r.getActiveGrants(ctx, tx, licenseID, assetDID, creditType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active grants: %w", err)
+		return nil, nil, fmt.Errorf("failed to get active grants: %w", err)
 	}
 	// Note: There is a chance that a grant is inserted here after we pull active grants and before we calculate the current balance.
 	// Which we are okay with because at the time of the original operation, the grant was not active.
@@ -90,29 +223,36 @@ func (r *Repository) deductCreditsInternal(ctx context.Context, licenseID, asset
 
 	// Check if sufficient balance
 	if currentBalance < amount {
-		return nil, fmt.Errorf("%w. Current: %d, Required: %d", InsufficientCreditsErr, currentBalance, amount)
+		return nil, nil, NewInsufficientCreditsError(currentBalance, amount)
 	}
 
 	operation := &models.CreditOperation{
 		LicenseID:     licenseID,
 		AssetDid:      assetDID,
-		OperationType: OperationTypeDeduction,
+		OperationType: operationType,
 		TotalAmount:   amount,
+		NominalAmount: nominalAmount,
 		AppName:       appName,
 		ReferenceID:   referenceID,
 		CreatedAt:     null.TimeFrom(time.Now()),
+		SummaryOnly:   r.isSummaryOnly(appName),
+		TraceID:       traceIDFrom(ctx),
+		CreditType:    creditType,
+	}
+	if r.recordBalanceAfter {
+		operation.BalanceAfter = null.Int64From(currentBalance - amount)
 	}
 
 	if err := operation.Insert(ctx, tx, boil.Infer()); err != nil {
 		if IsDuplicateKeyError(err) {
-			// TODO: Need to get this to the gRPC caller
-			return nil, fmt.Errorf("operation already exists: %w", err)
+			return nil, nil, fmt.Errorf("%w: %w", DuplicateOperationErr, err)
 		}
-		return nil, fmt.Errorf("failed to create operation record: %w", err)
+		return nil, nil, fmt.Errorf("failed to create operation record: %w", err)
 	}
 
 	// Deduct from grants using FIFO and record details
 	remainingToDeduct := amount
+	var debited []*models.CreditGrant
 
 	for _, grant := range grants {
 		if remainingToDeduct <= 0 {
@@ -120,13 +260,23 @@ func (r *Repository) deductCreditsInternal(ctx context.Context, licenseID, asset
 		}
 
 		deductionAmount := min(remainingToDeduct, grant.RemainingAmount)
+		if err := guardGrantDebit(ctx, grant, deductionAmount); err != nil {
+			return nil, nil, err
+		}
 		newGrantAmount := grant.RemainingAmount - deductionAmount
 
 		// Update grant
 		grant.RemainingAmount = newGrantAmount
 		grant.UpdatedAt = null.TimeFrom(time.Now())
-		if _, err := grant.Update(ctx, tx, boil.Whitelist(models.CreditGrantColumns.RemainingAmount, models.CreditGrantColumns.UpdatedAt)); err != nil {
-			return nil, fmt.Errorf("failed to update grant %s: %w", grant.TXHash, err)
+		if _, err := grant.Update(ctx, tx, boil.Whitelist(r.grantAmountColumns(grant)...)); err != nil {
+			return nil, nil, grantUpdateError(grant, err)
+		}
+
+		remainingToDeduct -= deductionAmount
+		debited = append(debited, grant)
+		if operation.SummaryOnly {
+			// summary-only operations skip the per-grant detail rows
+			continue
 		}
 
 		// Record which grant was used in this operation
@@ -141,18 +291,15 @@ func (r *Repository) deductCreditsInternal(ctx context.Context, licenseID, asset
 		}
 
 		if err := opGrant.Insert(ctx, tx, boil.Infer()); err != nil {
-			return nil, fmt.Errorf("failed to record operation grant: %w", err)
+			return nil, nil, fmt.Errorf("failed to record operation grant: %w", err)
 		}
-
-		remainingToDeduct -= deductionAmount
 	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	if err := r.updateBalanceSummary(ctx, tx, licenseID, assetDID); err != nil {
+		return nil, nil, err
 	}
 
-	return operation, nil
+	return operation, debited, nil
 }
 
 // RefundCredits refunds credits using FIFO logic with full ACID guarantees
@@ -160,21 +307,54 @@ func (r *Repository) deductCreditsInternal(ctx context.Context, licenseID, asset
 // 2. Add funds back to the grant
 // 3. Create a operation record for the refund
 // 4. Settle any debt if any
-func (r *Repository) RefundCredits(ctx context.Context, appName, referenceID string) (*models.CreditOperation, error) {
-	return RetryWithDeadlockHandling(ctx, "RefundCredits", func() (*models.CreditOperation, error) {
-		return r.refundCreditsInternal(ctx, appName, referenceID)
+func (r *Repository) RefundCredits(ctx context.Context, appName, referenceID string, opts ...RefundOption) (*models.CreditOperation, error) {
+	options, err := NewRefundOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return r.refundCredits(ctx, appName, referenceID, 0, options)
+}
+
+// RefundPartialCredits refunds at most amount credits of the referenced deduction.
+// The refund is returned to the grants in reverse of the order they were deducted from, so the most recently drained grant is refilled first.
+// Like RefundCredits a deduction can only be refunded once, so a partial refund can not be followed by another refund.
+func (r *Repository) RefundPartialCredits(ctx context.Context, appName, referenceID string, amount uint64, opts ...RefundOption) (*models.CreditOperation, error) {
+	if amount == 0 {
+		return nil, fmt.Errorf("invalid amount: %d. Amount must be positive", amount)
+	}
+	if amount > math.MaxInt64 {
+		return nil, fmt.Errorf("refund amount is too large must be less than %d", math.MaxInt64)
+	}
+	options, err := NewRefundOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return r.refundCredits(ctx, appName, referenceID, amount, options)
+}
+
+// refundCredits logs and runs a refund of RefundCredits or RefundPartialCredits,
+// an amount of zero refunds the full deduction.
+func (r *Repository) refundCredits(ctx context.Context, appName, referenceID string, amount uint64, options RefundOptions) (*models.CreditOperation, error) {
+	logger := operationLogger(ctx, "", "", appName, referenceID, amount)
+	logger.Debug().Msg("refunding credits")
+	operation, err := limitedTx(ctx, r, "RefundCredits", func(ctx context.Context) (*models.CreditOperation, error) {
+		return r.refundCreditsInternal(ctx, appName, referenceID, int64(amount), options)
 	})
+	if err != nil {
+		logger.Debug().Err(err).Msg("failed to refund credits")
+	}
+	return operation, err
 }
 
-// refundCreditsInternal is the internal implementation of RefundCredits
-func (r *Repository) refundCreditsInternal(ctx context.Context, appName, referenceID string) (*models.CreditOperation, error) {
+// refundCreditsInternal is the internal implementation of RefundCredits and RefundPartialCredits,
+// an amount of zero refunds the full deduction.
+func (r *Repository) refundCreditsInternal(ctx context.Context, appName, referenceID string, amount int64, options RefundOptions) (*models.CreditOperation, error) {
 	// Start a transaction with read committed isolation
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelReadCommitted,
-	})
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer observeTransaction("RefundCredits")()
 	defer rollbackTx(ctx, tx)
 
 	// Get grants used in the original operation.
@@ -183,6 +363,12 @@ func (r *Repository) refundCreditsInternal(ctx context.Context, appName, referen
 		return nil, fmt.Errorf("failed to get active grants: %w", err)
 	}
 	refundAmount := deductOp.TotalAmount
+	if amount != 0 {
+		if amount > deductOp.TotalAmount {
+			return nil, fmt.Errorf("%w: refund of %d, deduction of %d", RefundExceedsDeductionErr, amount, deductOp.TotalAmount)
+		}
+		refundAmount = amount
+	}
 
 	operation := &models.CreditOperation{
 		LicenseID:     deductOp.LicenseID,
@@ -192,22 +378,40 @@ func (r *Repository) refundCreditsInternal(ctx context.Context, appName, referen
 		AppName:       appName,
 		ReferenceID:   referenceID,
 		CreatedAt:     null.TimeFrom(time.Now()),
+		TraceID:       traceIDFrom(ctx),
+		ReasonCode:    options.reasonCode(),
+		CreditType:    deductOp.CreditType,
 	}
 
 	if err := operation.Insert(ctx, tx, boil.Infer()); err != nil {
 		if IsDuplicateKeyError(err) {
-			// TODO: Need to get this to the gRPC caller
-			return nil, fmt.Errorf("operation already exists: %w", err)
+			return nil, fmt.Errorf("%w: %w", DuplicateOperationErr, err)
 		}
 		return nil, fmt.Errorf("failed to create operation record: %w", err)
 	}
 
-	for _, opGrant := range grants {
+	if deductOp.SummaryOnly {
+		if err := r.refundSummaryOnly(ctx, tx, operation); err != nil {
+			return nil, fmt.Errorf("failed to redistribute refund: %w", err)
+		}
+	}
+	refundExcess := int64(0)
+	unrefunded := refundAmount
+	// refill the grants in reverse of the order they were deducted from
+	for _, opGrant := range slices.Backward(grants) {
+		if unrefunded == 0 {
+			break
+		}
 		grant := opGrant.GetGrant()
-		if grant == nil {
-			return nil, fmt.Errorf("grant not found for operation grant %s", opGrant.ID)
+		grantRefundAmount, excess, err := r.capRefund(grant, min(-opGrant.AmountUsed, unrefunded))
+		if err != nil {
+			return nil, err
+		}
+		unrefunded -= grantRefundAmount + excess
+		refundExcess += excess
+		if grantRefundAmount == 0 {
+			continue
 		}
-		grantRefundAmount := -opGrant.AmountUsed
 		// Update grant
 		newAmount := grant.RemainingAmount + grantRefundAmount
 		if newAmount < grant.RemainingAmount {
@@ -216,7 +420,7 @@ func (r *Repository) refundCreditsInternal(ctx context.Context, appName, referen
 		}
 		grant.RemainingAmount = newAmount
 		grant.UpdatedAt = null.TimeFrom(time.Now())
-		if _, err := grant.Update(ctx, tx, boil.Whitelist(models.CreditGrantColumns.RemainingAmount, models.CreditGrantColumns.UpdatedAt)); err != nil {
+		if _, err := grant.Update(ctx, tx, boil.Whitelist(r.grantAmountColumns(grant)...)); err != nil {
 			return nil, fmt.Errorf("failed to update grant %s: %w", grant.TXHash, err)
 		}
 
@@ -236,16 +440,35 @@ func (r *Repository) refundCreditsInternal(ctx context.Context, appName, referen
 		}
 
 	}
+	if unrefunded > 0 && !deductOp.SummaryOnly {
+		// never record a refund that returned fewer credits than it claims
+		return nil, fmt.Errorf("%w: %d of %d credits have no grant to return to", RefundUnavailableErr, unrefunded, refundAmount)
+	}
+	if refundExcess > 0 {
+		if err := r.redirectRefundExcess(ctx, tx, operation, refundExcess); err != nil {
+			return nil, err
+		}
+	}
 
-	err = r.settleDebt(ctx, tx, deductOp.LicenseID, deductOp.AssetDid, appName, referenceID)
+	_, err = r.settleDebt(ctx, tx, deductOp.LicenseID, deductOp.AssetDid, deductOp.CreditType, appName, referenceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to settle debt: %w", err)
 	}
 
+	if err := r.recordOperationBalance(ctx, tx, operation); err != nil {
+		return nil, err
+	}
+
+	if err := r.updateBalanceSummary(ctx, tx, deductOp.LicenseID, deductOp.AssetDid); err != nil {
+		return nil, err
+	}
+
 	// Commit the transaction
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	r.logOperation(ctx, operation, "refunded credits")
+	r.observeSettledDebt(ctx, deductOp.LicenseID)
 
 	return operation, nil
 }
@@ -254,14 +477,24 @@ func (r *Repository) refundCreditsInternal(ctx context.Context, appName, referen
 // 1. Create a new grant record
 // 2. Create a new operation record
 // 3. Settle any debt if any
-func (r *Repository) CreateGrant(ctx context.Context, licenseID, assetDID string, creditAmount uint64, mintTime time.Time) (*models.CreditGrant, error) {
-	return RetryWithDeadlockHandling(ctx, "CreateGrant", func() (*models.CreditGrant, error) {
-		return r.createGrantInternal(ctx, licenseID, assetDID, creditAmount, mintTime)
+func (r *Repository) CreateGrant(ctx context.Context, licenseID, assetDID string, creditAmount uint64, mintTime time.Time, opts ...GrantOption) (*models.CreditGrant, error) {
+	options, err := NewGrantOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	logger := operationLogger(ctx, licenseID, assetDID, "", "", creditAmount)
+	logger.Debug().Msg("creating grant")
+	grant, err := retryTx(ctx, r, "CreateGrant", func(ctx context.Context) (*models.CreditGrant, error) {
+		return r.createGrantInternal(ctx, licenseID, assetDID, creditAmount, mintTime, options)
 	})
+	if err != nil {
+		logger.Debug().Err(err).Msg("failed to create grant")
+	}
+	return grant, err
 }
 
 // createGrantInternal is the internal implementation of CreateGrant
-func (r *Repository) createGrantInternal(ctx context.Context, licenseID, assetDID string, creditAmount uint64, mintTime time.Time) (*models.CreditGrant, error) {
+func (r *Repository) createGrantInternal(ctx context.Context, licenseID, assetDID string, creditAmount uint64, mintTime time.Time, options GrantOptions) (*models.CreditGrant, error) {
 	if creditAmount == 0 {
 		return nil, fmt.Errorf("invalid amount: %d. Amount must be positive", creditAmount)
 	}
@@ -270,15 +503,14 @@ func (r *Repository) createGrantInternal(ctx context.Context, licenseID, assetDI
 	}
 	amount := int64(creditAmount)
 
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelReadCommitted,
-	})
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer observeTransaction("CreateGrant")()
 	defer rollbackTx(ctx, tx)
 
-	grants, err := r.getActiveGrants(ctx, tx, licenseID, assetDID)
+	grants, err := r.getActiveGrants(ctx, tx, licenseID, assetDID, options.creditType())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get active grants: %w", err)
 	}
@@ -293,7 +525,9 @@ func (r *Repository) createGrantInternal(ctx context.Context, licenseID, assetDI
 		InitialAmount:   amount,
 		RemainingAmount: amount,
 		Status:          GrantStatusPending,
-		ExpiresAt:       getExpirationDate(mintTime),
+		ExpiresAt:       null.TimeFrom(getExpirationDate(mintTime)),
+		Metadata:        options.metadata(),
+		CreditType:      options.creditType(),
 	}
 
 	if err := grant.Insert(ctx, tx, boil.Infer()); err != nil {
@@ -308,6 +542,7 @@ func (r *Repository) createGrantInternal(ctx context.Context, licenseID, assetDI
 		AppName:       "credit_tracker",
 		ReferenceID:   grant.ID,
 		CreatedAt:     null.TimeFrom(time.Now()),
+		CreditType:    grant.CreditType,
 	}
 
 	if err := operation.Insert(ctx, tx, boil.Infer()); err != nil {
@@ -325,21 +560,31 @@ func (r *Repository) createGrantInternal(ctx context.Context, licenseID, assetDI
 		return nil, fmt.Errorf("failed to record operation grant: %w", err)
 	}
 
-	err = r.settleDebt(ctx, tx, licenseID, assetDID, "credit_tracker", grant.ID)
+	_, err = r.settleDebt(ctx, tx, licenseID, assetDID, grant.CreditType, "credit_tracker", grant.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to settle debt: %w", err)
 	}
 
+	if err := r.recordOperationBalance(ctx, tx, operation); err != nil {
+		return nil, err
+	}
+
+	if err := r.updateBalanceSummary(ctx, tx, licenseID, assetDID); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	r.logOperation(ctx, operation, "created grant")
+	r.observeSettledDebt(ctx, licenseID)
 
 	return grant, nil
 }
 
 // UpdateGrantTxHash updates the tx hash for the given grant
 func (r *Repository) UpdateGrantTxHash(ctx context.Context, grant *models.CreditGrant, txHash string) (*models.CreditGrant, error) {
-	return RetryWithDeadlockHandling(ctx, "updateGrantTxHash", func() (*models.CreditGrant, error) {
+	return retryTx(ctx, r, "updateGrantTxHash", func(ctx context.Context) (*models.CreditGrant, error) {
 		return r.updateGrantTxHashInternal(ctx, grant, txHash)
 	})
 }
@@ -353,33 +598,155 @@ func (r *Repository) updateGrantTxHashInternal(ctx context.Context, grant *model
 	return grant, nil
 }
 
+// FailGrant marks a pending grant as failed, for a burn that did not happen on chain.
+// Credits already spent from the grant become outstanding debt of the license and asset.
+// It returns GrantNotPendingErr if the grant was confirmed or failed in the meantime.
+func (r *Repository) FailGrant(ctx context.Context, grant *models.CreditGrant) (*models.CreditGrant, error) {
+	return retryTx(ctx, r, "FailGrant", func(ctx context.Context) (*models.CreditGrant, error) {
+		return r.failGrantInternal(ctx, grant)
+	})
+}
+
+// failGrantInternal is the internal implementation of FailGrant
+func (r *Repository) failGrantInternal(ctx context.Context, grant *models.CreditGrant) (*models.CreditGrant, error) {
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("FailGrant")()
+	defer rollbackTx(ctx, tx)
+
+	locked, err := models.CreditGrants(
+		models.CreditGrantWhere.ID.EQ(grant.ID),
+		qm.For("UPDATE"),
+	).One(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grant: %w", err)
+	}
+	if locked.Status != GrantStatusPending {
+		return nil, fmt.Errorf("%w: grant %s is %s", GrantNotPendingErr, locked.ID, locked.Status)
+	}
+
+	operation, err := r.failGrantTx(ctx, tx, locked)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.updateBalanceSummary(ctx, tx, locked.LicenseID, locked.AssetDid); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.logOperation(ctx, operation, "failed grant")
+	r.observeDebt(ctx, locked.LicenseID)
+
+	return locked, nil
+}
+
+// failGrantTx marks a locked pending grant as failed and records a grant_failed operation of its credits, referencing the grant.
+// The caller refreshes the balance summary and logs the returned operation once the transaction commits.
+func (r *Repository) failGrantTx(ctx context.Context, tx *sql.Tx, grant *models.CreditGrant) (*models.CreditOperation, error) {
+	now := time.Now()
+	grant.Status = GrantStatusFailed
+	grant.UpdatedAt = null.TimeFrom(now)
+	if _, err := grant.Update(ctx, tx, boil.Whitelist(models.CreditGrantColumns.Status, models.CreditGrantColumns.UpdatedAt)); err != nil {
+		return nil, fmt.Errorf("failed to update grant: %w", err)
+	}
+
+	operation := &models.CreditOperation{
+		LicenseID:     grant.LicenseID,
+		AssetDid:      grant.AssetDid,
+		OperationType: OperationTypeGrantFailed,
+		TotalAmount:   grant.InitialAmount,
+		AppName:       "credit_tracker",
+		ReferenceID:   grant.ID,
+		CreatedAt:     null.TimeFrom(now),
+		CreditType:    grant.CreditType,
+	}
+	if err := operation.Insert(ctx, tx, boil.Infer()); err != nil {
+		return nil, fmt.Errorf("failed to create grant failure operation: %w", err)
+	}
+	if err := r.recordOperationBalance(ctx, tx, operation); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
 // confirmGrant confirms a grant for the given license and asset
 // 1. Update the grant record to set the log index
 // 2. Create a new operation record
 // 3. Settle any debt if any
-func (r *Repository) ConfirmGrant(ctx context.Context, licenseID, assetDID string, txHash string, logIndex int, creditAmount uint64, mintTime time.Time) (*models.CreditOperation, error) {
-	return RetryWithDeadlockHandling(ctx, "ConfirmGrant", func() (*models.CreditOperation, error) {
-		return r.confirmGrantInternal(ctx, licenseID, assetDID, txHash, logIndex, creditAmount, mintTime)
+func (r *Repository) ConfirmGrant(ctx context.Context, licenseID, assetDID string, txHash string, logIndex int, creditAmount uint64, mintTime time.Time, opts ...GrantOption) (*models.CreditOperation, error) {
+	options, err := NewGrantOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	logger := operationLogger(ctx, licenseID, assetDID, "", "", creditAmount).With().Str("txHash", txHash).Int("logIndex", logIndex).Logger()
+	logger.Debug().Msg("confirming grant")
+	operation, err := retryTx(ctx, r, "ConfirmGrant", func(ctx context.Context) (*models.CreditOperation, error) {
+		return r.confirmGrantInternal(ctx, licenseID, assetDID, txHash, logIndex, creditAmount, mintTime, options)
 	})
+	if err != nil {
+		logger.Debug().Err(err).Msg("failed to confirm grant")
+	}
+	return operation, err
 }
 
 // confirmGrantInternal is the internal implementation of ConfirmGrant
-func (r *Repository) confirmGrantInternal(ctx context.Context, licenseID, assetDID string, txHash string, logIndex int, creditAmount uint64, mintTime time.Time) (*models.CreditOperation, error) {
+func (r *Repository) confirmGrantInternal(ctx context.Context, licenseID, assetDID string, txHash string, logIndex int, creditAmount uint64, mintTime time.Time, options GrantOptions) (*models.CreditOperation, error) {
 	if creditAmount == 0 {
 		return nil, fmt.Errorf("invalid amount: %d. Amount must be positive", creditAmount)
 	}
 	if creditAmount > math.MaxInt64 {
 		return nil, fmt.Errorf("credit amount is too large must be less than %d", math.MaxInt64)
 	}
-	amount := int64(creditAmount)
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelReadCommitted,
-	})
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer observeTransaction("ConfirmGrant")()
 	defer rollbackTx(ctx, tx)
 
+	operation, err := r.confirmGrantTx(ctx, tx, licenseID, assetDID, txHash, logIndex, int64(creditAmount), mintTime, options)
+	if IsDuplicateKeyError(err) {
+		// a concurrent confirmation of the same chain event committed first, its grant holds the unique tx hash and log index
+		return nil, r.concurrentConfirmErr(ctx, licenseID, assetDID, txHash, logIndex, int64(creditAmount), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	confirmation, err := r.newGrantConfirmation(ctx, tx, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.logOperation(ctx, operation, "confirmed grant")
+	r.observeSettledDebt(ctx, licenseID)
+	r.notifyGrantConfirmed(ctx, confirmation)
+
+	return operation, nil
+}
+
+// confirmGrantTx confirms a grant within the given transaction
+func (r *Repository) confirmGrantTx(ctx context.Context, tx *sql.Tx, licenseID, assetDID string, txHash string, logIndex int, amount int64, mintTime time.Time, options GrantOptions) (*models.CreditOperation, error) {
+	// a chain event is only confirmed once, replays of the event are reported instead of failing on the insert
+	confirmed, err := models.CreditGrants(
+		models.CreditGrantWhere.TXHash.EQ(txHash),
+		models.CreditGrantWhere.LogIndex.EQ(null.IntFrom(logIndex)),
+		models.CreditGrantWhere.Status.IN([]string{GrantStatusConfirmed, GrantStatusExpired}),
+	).One(ctx, tx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to find confirmed grant: %w", err)
+	}
+	if confirmed != nil {
+		return nil, alreadyConfirmedErr(confirmed, licenseID, assetDID, amount)
+	}
+
 	// get the oldest pending grant that matches the given parameters
 	grant, err := models.CreditGrants(
 		models.CreditGrantWhere.TXHash.EQ(txHash),
@@ -393,6 +760,11 @@ func (r *Repository) confirmGrantInternal(ctx context.Context, licenseID, assetD
 		if !errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("failed to find grant: %w", err)
 		}
+		if !r.allowPendingGrantMismatch {
+			if err := checkPendingGrantMismatch(ctx, tx, licenseID, assetDID, txHash); err != nil {
+				return nil, err
+			}
+		}
 		// create a new grant if there is no matching grant
 		grant = &models.CreditGrant{
 			LicenseID:       licenseID,
@@ -402,7 +774,10 @@ func (r *Repository) confirmGrantInternal(ctx context.Context, licenseID, assetD
 			TXHash:          txHash,
 			Status:          GrantStatusConfirmed,
 			LogIndex:        null.IntFrom(logIndex),
-			ExpiresAt:       getExpirationDate(mintTime),
+			BlockNumber:     options.blockNumber(),
+			ExpiresAt:       null.TimeFrom(getExpirationDate(mintTime)),
+			Metadata:        options.metadata(),
+			CreditType:      options.creditType(),
 			CreatedAt:       null.TimeFrom(time.Now()),
 			UpdatedAt:       null.TimeFrom(time.Now()),
 		}
@@ -410,22 +785,68 @@ func (r *Repository) confirmGrantInternal(ctx context.Context, licenseID, assetD
 			return nil, fmt.Errorf("failed to create grant record: %w", err)
 		}
 	} else {
+		if grant.InitialAmount != amount {
+			// the chain burned a different amount than the pending grant was created for, confirming either amount would be wrong
+			return nil, fmt.Errorf("%w: pending grant %s is for %d credits, confirmation is for %d credits",
+				GrantAmountMismatchErr, grant.ID, grant.InitialAmount, amount)
+		}
 		grant.LogIndex = null.IntFrom(logIndex)
 		grant.Status = GrantStatusConfirmed
 		grant.UpdatedAt = null.TimeFrom(time.Now())
+		columns := []string{models.CreditGrantColumns.LogIndex, models.CreditGrantColumns.Status, models.CreditGrantColumns.UpdatedAt}
+		if options.Metadata != nil {
+			grant.Metadata = options.metadata()
+			columns = append(columns, models.CreditGrantColumns.Metadata)
+		}
+		if options.CreditType != "" {
+			grant.CreditType = options.CreditType
+			columns = append(columns, models.CreditGrantColumns.CreditType)
+		}
+		if options.BlockNumber != nil {
+			grant.BlockNumber = options.blockNumber()
+			columns = append(columns, models.CreditGrantColumns.BlockNumber)
+		}
 
-		if _, err := grant.Update(ctx, tx, boil.Whitelist(models.CreditGrantColumns.LogIndex, models.CreditGrantColumns.Status, models.CreditGrantColumns.UpdatedAt)); err != nil {
+		if _, err := grant.Update(ctx, tx, boil.Whitelist(columns...)); err != nil {
 			return nil, fmt.Errorf("failed to update grant: %w", err)
 		}
 	}
 
+	return r.recordGrantConfirmation(ctx, tx, grant, amount)
+}
+
+// alreadyConfirmedErr is the error of confirming a chain event that the grant was already confirmed with,
+// a conflict when the confirmation has different grant details.
+func alreadyConfirmedErr(confirmed *models.CreditGrant, licenseID, assetDID string, amount int64) error {
+	if confirmed.LicenseID != licenseID || confirmed.AssetDid != assetDID || confirmed.InitialAmount != amount {
+		return fmt.Errorf("%w: grant %s", ConfirmConflictErr, confirmed.ID)
+	}
+	return fmt.Errorf("%w: grant %s", GrantAlreadyConfirmedErr, confirmed.ID)
+}
+
+// concurrentConfirmErr reads the grant a concurrent confirmation of the chain event created after the confirmation failed
+// on the unique tx hash and log index, and returns the error of confirming it again. The failed transaction is aborted, so the read is outside it.
+func (r *Repository) concurrentConfirmErr(ctx context.Context, licenseID, assetDID, txHash string, logIndex int, amount int64, duplicateErr error) error {
+	confirmed, err := models.CreditGrants(
+		models.CreditGrantWhere.TXHash.EQ(txHash),
+		models.CreditGrantWhere.LogIndex.EQ(null.IntFrom(logIndex)),
+	).One(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to find concurrently confirmed grant: %w: %w", err, duplicateErr)
+	}
+	return alreadyConfirmedErr(confirmed, licenseID, assetDID, amount)
+}
+
+// recordGrantConfirmation records the grant_confirm operation of the confirmed amount of a grant, settles debt with it, and refreshes the balance summary.
+func (r *Repository) recordGrantConfirmation(ctx context.Context, tx *sql.Tx, grant *models.CreditGrant, amount int64) (*models.CreditOperation, error) {
 	operation := &models.CreditOperation{
-		LicenseID:     licenseID,
-		AssetDid:      assetDID,
+		LicenseID:     grant.LicenseID,
+		AssetDid:      grant.AssetDid,
 		OperationType: OperationTypeGrantConfirm,
 		TotalAmount:   amount,
 		AppName:       "credit_tracker",
 		ReferenceID:   grant.ID,
+		CreditType:    grant.CreditType,
 	}
 	if err := operation.Insert(ctx, tx, boil.Infer()); err != nil {
 		return nil, fmt.Errorf("failed to create operation record: %w", err)
@@ -442,70 +863,115 @@ func (r *Repository) confirmGrantInternal(ctx context.Context, licenseID, assetD
 		return nil, fmt.Errorf("failed to record grant operation: %w", err)
 	}
 
-	err = r.settleDebt(ctx, tx, licenseID, assetDID, "credit_tracker", grant.ID)
+	_, err := r.settleDebt(ctx, tx, grant.LicenseID, grant.AssetDid, grant.CreditType, "credit_tracker", grant.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to settle debt: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	if err := r.recordOperationBalance(ctx, tx, operation); err != nil {
+		return nil, err
+	}
+
+	if err := r.updateBalanceSummary(ctx, tx, grant.LicenseID, grant.AssetDid); err != nil {
+		return nil, err
 	}
 
 	return operation, nil
 }
 
+// checkPendingGrantMismatch returns PendingGrantMismatchErr if a pending grant with the tx hash exists for a different license or asset,
+// which means our pending record and the chain disagree.
+func checkPendingGrantMismatch(ctx context.Context, tx *sql.Tx, licenseID, assetDID string, txHash string) error {
+	pending, err := models.CreditGrants(
+		models.CreditGrantWhere.TXHash.EQ(txHash),
+		models.CreditGrantWhere.Status.EQ(GrantStatusPending),
+		qm.OrderBy(models.CreditGrantColumns.CreatedAt+" ASC, "+models.CreditGrantColumns.ID+" ASC"),
+	).One(ctx, tx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to find pending grant: %w", err)
+	}
+	return fmt.Errorf("%w: pending grant %s is for license %s and asset %s, confirmation is for license %s and asset %s",
+		PendingGrantMismatchErr, pending.ID, pending.LicenseID, pending.AssetDid, licenseID, assetDID)
+}
+
+// Balance is the spendable balance and outstanding debt of a license and asset.
+type Balance struct {
+	// Spendable credits from active grants
+	Balance int64 `json:"balance"`
+	// Outstanding debt from failed grants
+	Debt int64 `json:"debt"`
+}
+
 // GetBalance returns the balance for the given license and asset
 // 1. Get the outstanding debt
-// 2. If the debt is positive, return the debt
-// 3. If the debt is negative, return the balance
-// 4. If the debt is zero, return the balance
-func (r *Repository) GetBalance(ctx context.Context, licenseID, assetDID string) (int64, error) {
-	return RetryWithDeadlockHandling(ctx, "GetBalance", func() (int64, error) {
-		return r.getBalanceInternal(ctx, licenseID, assetDID)
+// 2. Get the spendable balance from active grants
+// The spendable balance is reported regardless of debt, debt only blocks spending.
+// The balance is of the grants of DefaultCreditType unless another credit type is set with WithCreditType, debt is of every credit type.
+func (r *Repository) GetBalance(ctx context.Context, licenseID, assetDID string, opts ...PoolOption) (*Balance, error) {
+	options, err := NewPoolOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return retryTx(ctx, r, "GetBalance", func(ctx context.Context) (*Balance, error) {
+		return r.getBalanceInternal(ctx, licenseID, assetDID, options.CreditType)
 	})
 }
 
 // getBalanceInternal is the internal implementation of GetBalance
-func (r *Repository) getBalanceInternal(ctx context.Context, licenseID, assetDID string) (int64, error) {
+func (r *Repository) getBalanceInternal(ctx context.Context, licenseID, assetDID, creditType string) (*Balance, error) {
 	debt, err := r.getOutstandingDebt(ctx, licenseID, assetDID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get outstanding debt: %w", err)
-	}
-	if debt < 0 {
-		return -debt, nil
+		return nil, fmt.Errorf("failed to get outstanding debt: %w", err)
 	}
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelReadCommitted,
-	})
+	tx, err := r.db.BeginTx(ctx, r.balanceTxOptions())
 	if err != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer observeTransaction("GetBalance")()
 	defer rollbackTx(ctx, tx)
 
-	balance, err := r.calculateBalance(ctx, tx, licenseID, assetDID)
+	expired, err := r.expireGrants(ctx, tx, licenseID, assetDID)
 	if err != nil {
-		return 0, fmt.Errorf("failed to calculate balance: %w", err)
+		return nil, err
+	}
+
+	balance, err := r.calculateBalance(ctx, tx, licenseID, assetDID, creditType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate balance: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	r.logExpirations(ctx, expired)
 
-	return balance, nil
+	return &Balance{Balance: balance, Debt: debt}, nil
 }
 
-// calculateBalance calculates the balance for the given license and asset
-func (r *Repository) calculateBalance(ctx context.Context, tx *sql.Tx, licenseID, assetDID string) (int64, error) {
+// GetDebt returns the outstanding debt of the given license and asset, the credits owed from failed grants.
+// Deductions are refused until the debt is settled by new credits.
+func (r *Repository) GetDebt(ctx context.Context, licenseID, assetDID string) (int64, error) {
+	return retryTx(ctx, r, "GetDebt", func(ctx context.Context) (int64, error) {
+		return r.getOutstandingDebt(ctx, licenseID, assetDID)
+	})
+}
+
+// calculateBalance calculates the balance for the given license, asset, and credit type, an empty credit type is the balance of every type
+func (r *Repository) calculateBalance(ctx context.Context, tx *sql.Tx, licenseID, assetDID, creditType string) (int64, error) {
 	// use sql to add up the remaining amount of all confirmed/pending grants that are not expired
 	var sum int64
-	err := models.CreditGrants(
+	mods := creditTypeMods([]qm.QueryMod{
 		qm.Select("COALESCE(SUM(remaining_amount), 0)"),
 		models.CreditGrantWhere.LicenseID.EQ(licenseID),
 		models.CreditGrantWhere.AssetDid.EQ(assetDID),
-		models.CreditGrantWhere.Status.IN([]string{GrantStatusConfirmed, GrantStatusPending}),
-		models.CreditGrantWhere.ExpiresAt.GT(time.Now()),
+		models.CreditGrantWhere.Status.IN(r.spendableStatuses()),
+		notExpired(time.Now()),
 		models.CreditGrantWhere.RemainingAmount.GT(0),
-	).QueryRowContext(ctx, tx).Scan(&sum)
+	}, creditType)
+	err := models.CreditGrants(mods...).QueryRowContext(ctx, tx).Scan(&sum)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate balance: %w", err)
 	}
@@ -513,17 +979,21 @@ func (r *Repository) calculateBalance(ctx context.Context, tx *sql.Tx, licenseID
 	return sum, nil
 }
 
-// getActiveGrants retrieves active credit grants for a license/asset, ordered by expiration (FIFO)
-func (r *Repository) getActiveGrants(ctx context.Context, tx *sql.Tx, licenseID, assetDID string) ([]*models.CreditGrant, error) {
-	grants, err := models.CreditGrants(
+// getActiveGrants retrieves active credit grants of the credit type for a license/asset, ordered by expiration (FIFO).
+// An empty credit type retrieves the grants of every type.
+func (r *Repository) getActiveGrants(ctx context.Context, tx *sql.Tx, licenseID, assetDID, creditType string) ([]*models.CreditGrant, error) {
+	lockStart := time.Now()
+	mods := creditTypeMods([]qm.QueryMod{
 		models.CreditGrantWhere.LicenseID.EQ(licenseID),
 		models.CreditGrantWhere.AssetDid.EQ(assetDID),
 		models.CreditGrantWhere.RemainingAmount.GT(0),
-		models.CreditGrantWhere.ExpiresAt.GT(time.Now()),
-		models.CreditGrantWhere.Status.IN([]string{GrantStatusConfirmed, GrantStatusPending}),
-		qm.OrderBy(models.CreditGrantColumns.ExpiresAt+" ASC, "+models.CreditGrantColumns.CreatedAt+" ASC, "+models.CreditGrantColumns.ID+" ASC"),
+		notExpired(time.Now()),
+		models.CreditGrantWhere.Status.IN(r.spendableStatuses()),
+		qm.OrderBy(models.CreditGrantColumns.ExpiresAt + " ASC, " + models.CreditGrantColumns.CreatedAt + " ASC, " + models.CreditGrantColumns.ID + " ASC"),
 		qm.For("UPDATE"),
-	).All(ctx, tx)
+	}, creditType)
+	grants, err := models.CreditGrants(mods...).All(ctx, tx)
+	GrantLockWaitDuration.Observe(time.Since(lockStart).Seconds())
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to query active grants: %w", err)
@@ -532,16 +1002,31 @@ func (r *Repository) getActiveGrants(ctx context.Context, tx *sql.Tx, licenseID,
 	return grants, nil
 }
 
-// getFailedGrants retrieves all failed grants with outstanding debt
-func (r *Repository) getFailedGrants(ctx context.Context, tx *sql.Tx, licenseID, assetDID string) ([]*models.CreditGrant, error) {
-	grants, err := models.CreditGrants(
+// grantAmountColumns returns the columns to update after changing a grant's remaining amount.
+// When depleted grant marking is enabled it also sets depleted_at on grants that reached zero and clears it on grants that were refilled.
+func (r *Repository) grantAmountColumns(grant *models.CreditGrant) []string {
+	columns := []string{models.CreditGrantColumns.RemainingAmount, models.CreditGrantColumns.UpdatedAt}
+	switch {
+	case grant.RemainingAmount == 0 && r.markDepletedGrants && !grant.DepletedAt.Valid:
+		grant.DepletedAt = null.TimeFrom(time.Now())
+		columns = append(columns, models.CreditGrantColumns.DepletedAt)
+	case grant.RemainingAmount > 0 && grant.DepletedAt.Valid:
+		grant.DepletedAt = null.Time{}
+		columns = append(columns, models.CreditGrantColumns.DepletedAt)
+	}
+	return columns
+}
+
+// getFailedGrants retrieves all failed grants of the credit type with outstanding debt
+func (r *Repository) getFailedGrants(ctx context.Context, tx *sql.Tx, licenseID, assetDID, creditType string) ([]*models.CreditGrant, error) {
+	grants, err := models.CreditGrants(creditTypeMods([]qm.QueryMod{
 		models.CreditGrantWhere.LicenseID.EQ(licenseID),
 		models.CreditGrantWhere.AssetDid.EQ(assetDID),
 		models.CreditGrantWhere.Status.EQ(GrantStatusFailed),
-		qm.Where(models.CreditGrantColumns.RemainingAmount+" < "+models.CreditGrantColumns.InitialAmount),
-		qm.OrderBy(models.CreditGrantColumns.CreatedAt+" ASC, "+models.CreditGrantColumns.ID+" ASC"),
+		qm.Where(models.CreditGrantColumns.RemainingAmount + " < " + models.CreditGrantColumns.InitialAmount),
+		qm.OrderBy(models.CreditGrantColumns.CreatedAt + " ASC, " + models.CreditGrantColumns.ID + " ASC"),
 		qm.For("UPDATE"),
-	).All(ctx, tx)
+	}, creditType)...).All(ctx, tx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query failed grants: %w", err)
 	}
@@ -551,14 +1036,19 @@ func (r *Repository) getFailedGrants(ctx context.Context, tx *sql.Tx, licenseID,
 
 // getOutstandingDebt calculates debt from failed grants (initial_amount - remaining_amount)
 func (r *Repository) getOutstandingDebt(ctx context.Context, licenseID, assetDID string) (int64, error) {
+	return outstandingDebt(ctx, r.db, licenseID, assetDID, "")
+}
+
+// outstandingDebt calculates debt from failed grants of the credit type using the given executor, an empty credit type adds up every type
+func outstandingDebt(ctx context.Context, exec boil.ContextExecutor, licenseID, assetDID, creditType string) (int64, error) {
 	var totalDebt int64
-	err := models.CreditGrants(
+	err := models.CreditGrants(creditTypeMods([]qm.QueryMod{
 		qm.Select("COALESCE(SUM(initial_amount - remaining_amount), 0) as total_debt"),
 		models.CreditGrantWhere.LicenseID.EQ(licenseID),
 		models.CreditGrantWhere.AssetDid.EQ(assetDID),
 		models.CreditGrantWhere.Status.EQ(GrantStatusFailed),
-		qm.Where(models.CreditGrantColumns.RemainingAmount+" < "+models.CreditGrantColumns.InitialAmount),
-	).QueryRowContext(ctx, r.db).Scan(&totalDebt)
+		qm.Where(models.CreditGrantColumns.RemainingAmount + " < " + models.CreditGrantColumns.InitialAmount),
+	}, creditType)...).QueryRowContext(ctx, exec).Scan(&totalDebt)
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate outstanding debt: %w", err)
@@ -567,30 +1057,32 @@ func (r *Repository) getOutstandingDebt(ctx context.Context, licenseID, assetDID
 	return totalDebt, nil
 }
 
-// settleDebt settles any debt for the given license and asset
-// Gets all grants and moves remaining balance from all active grants to any failed grants that do not have inital == remaining
-// 1. Get all failed grants that have debt oldest first
-// 2. Get all active grants
+// settleDebt settles any debt of the credit type for the given license and asset
+// Gets all grants of the credit type and moves remaining balance from the active grants to any failed grants that do not have inital == remaining,
+// so debt is only ever settled with credits of the pool it was run up in
+// 1. Get all failed grants of the credit type that have debt oldest first
+// 2. Get all active grants of the credit type
 // 3. For each failed grant, try to settle from active grants
 // 4. If we were able to settle any amount, update the failed grant
-// 5. Update the operation with the final balance
-func (r *Repository) settleDebt(ctx context.Context, tx *sql.Tx, licenseID, assetDID, appName, referenceID string) error {
-	debt, err := r.getOutstandingDebt(ctx, licenseID, assetDID)
+// 5. Stop once the active grants are exhausted, then update the operation with the amount settled and the final balance
+// It returns the debt settlement operation, or nil when there was no debt or no balance to settle it with.
+func (r *Repository) settleDebt(ctx context.Context, tx *sql.Tx, licenseID, assetDID, creditType, appName, referenceID string) (*models.CreditOperation, error) {
+	debt, err := outstandingDebt(ctx, tx, licenseID, assetDID, creditType)
 	if err != nil {
-		return fmt.Errorf("failed to get outstanding debt: %w", err)
+		return nil, fmt.Errorf("failed to get outstanding debt: %w", err)
 	}
 	if debt == 0 {
 		// no debt to settle
-		return nil
+		return nil, nil
 	}
 
-	balance, err := r.calculateBalance(ctx, tx, licenseID, assetDID)
+	balance, err := r.calculateBalance(ctx, tx, licenseID, assetDID, creditType)
 	if err != nil {
-		return fmt.Errorf("failed to calculate balance: %w", err)
+		return nil, fmt.Errorf("failed to calculate balance: %w", err)
 	}
 	if balance == 0 {
 		// no available balance to settle debt
-		return nil
+		return nil, nil
 	}
 
 	operation := &models.CreditOperation{
@@ -601,25 +1093,36 @@ func (r *Repository) settleDebt(ctx context.Context, tx *sql.Tx, licenseID, asse
 		AppName:       appName,
 		ReferenceID:   referenceID,
 		CreatedAt:     null.TimeFrom(time.Now()),
+		CreditType:    creditType,
 	}
 	if err := operation.Insert(ctx, tx, boil.Infer()); err != nil {
-		return fmt.Errorf("failed to create operation record: %w", err)
+		return nil, fmt.Errorf("failed to create operation record: %w", err)
 	}
 
 	// Get All failed grants
-	failedGrants, err := r.getFailedGrants(ctx, tx, licenseID, assetDID)
+	failedGrants, err := r.getFailedGrants(ctx, tx, licenseID, assetDID, creditType)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get All active grants
-	activeGrants, err := r.getActiveGrants(ctx, tx, licenseID, assetDID)
+	activeGrants, err := r.getActiveGrants(ctx, tx, licenseID, assetDID, creditType)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var available int64
+	for _, activeGrant := range activeGrants {
+		available += activeGrant.RemainingAmount
 	}
 
 	// For each failed grant, try to settle from active grants
+	var totalSettled int64
 	for _, failedGrant := range failedGrants {
+		if available <= 0 {
+			zerolog.Ctx(ctx).Debug().Msgf("No more active grants to settle from for failed grant %s", failedGrant.TXHash)
+			break
+		}
 		grantDebt := failedGrant.InitialAmount - failedGrant.RemainingAmount
 		if grantDebt <= 0 {
 			continue
@@ -637,12 +1140,15 @@ func (r *Repository) settleDebt(ctx context.Context, tx *sql.Tx, licenseID, asse
 			if availableAmount <= 0 {
 				continue
 			}
+			if err := guardGrantDebit(ctx, activeGrant, availableAmount); err != nil {
+				return nil, err
+			}
 
 			activeGrant.RemainingAmount -= availableAmount
 			activeGrant.UpdatedAt = null.TimeFrom(time.Now())
-			_, err := activeGrant.Update(ctx, tx, boil.Whitelist(models.CreditGrantColumns.RemainingAmount, models.CreditGrantColumns.UpdatedAt))
+			_, err := activeGrant.Update(ctx, tx, boil.Whitelist(r.grantAmountColumns(activeGrant)...))
 			if err != nil {
-				return fmt.Errorf("failed to update active grant %s: %w", activeGrant.TXHash, err)
+				return nil, grantUpdateError(activeGrant, err)
 			}
 
 			// Record the transfer in operation grants
@@ -657,29 +1163,28 @@ func (r *Repository) settleDebt(ctx context.Context, tx *sql.Tx, licenseID, asse
 			}
 
 			if err := grantDetail.Insert(ctx, tx, boil.Infer()); err != nil {
-				return fmt.Errorf("failed to record transfer detail: %w", err)
+				return nil, fmt.Errorf("failed to record transfer detail: %w", err)
 			}
 
 			remainingToSettle -= availableAmount
 		}
 
-		// If we did not settle any amount then there are no more active grants to settle from
-		if remainingToSettle >= grantDebt {
-			zerolog.Ctx(ctx).Debug().Msgf("No more active grants to settle from for failed grant %s", failedGrant.TXHash)
-			break
-		}
-
 		// If we were able to settle any amount, update the failed grant
 		amountSettled := grantDebt - remainingToSettle
+		if amountSettled <= 0 {
+			continue
+		}
+		available -= amountSettled
+		totalSettled += amountSettled
 		newAmount := failedGrant.RemainingAmount + amountSettled
 		if newAmount < failedGrant.RemainingAmount {
 			// integer overflow unexpected but can't hurt to check
-			return fmt.Errorf("debt settlement would cause integer overflow for grant %s", failedGrant.ID)
+			return nil, fmt.Errorf("debt settlement would cause integer overflow for grant %s", failedGrant.ID)
 		}
 		failedGrant.RemainingAmount = newAmount
 		failedGrant.UpdatedAt = null.TimeFrom(time.Now())
 		if _, err := failedGrant.Update(ctx, tx, boil.Whitelist(models.CreditGrantColumns.RemainingAmount, models.CreditGrantColumns.UpdatedAt)); err != nil {
-			return fmt.Errorf("failed to update failed grant %s: %w", failedGrant.TXHash, err)
+			return nil, fmt.Errorf("failed to update failed grant %s: %w", failedGrant.TXHash, err)
 		}
 
 		// Record the settlement in operation grants
@@ -694,11 +1199,22 @@ func (r *Repository) settleDebt(ctx context.Context, tx *sql.Tx, licenseID, asse
 		}
 
 		if err := grantDetail.Insert(ctx, tx, boil.Infer()); err != nil {
-			return fmt.Errorf("failed to record settlement detail: %w", err)
+			return nil, fmt.Errorf("failed to record settlement detail: %w", err)
 		}
 	}
 
-	return nil
+	// the debt and balance were read before the grants were locked, so record what was actually moved
+	if totalSettled != operation.TotalAmount {
+		operation.TotalAmount = totalSettled
+		if _, err := operation.Update(ctx, tx, boil.Whitelist(models.CreditOperationColumns.TotalAmount)); err != nil {
+			return nil, fmt.Errorf("failed to update settled amount: %w", err)
+		}
+	}
+
+	if err := r.recordOperationBalance(ctx, tx, operation); err != nil {
+		return nil, err
+	}
+	return operation, nil
 }
 
 func (r *Repository) getGrantsFromOperation(ctx context.Context, tx *sql.Tx, referenceID, appName string) ([]*models.CreditOperationGrant, *models.CreditOperation, error) {
@@ -710,7 +1226,7 @@ func (r *Repository) getGrantsFromOperation(ctx context.Context, tx *sql.Tx, ref
 	).One(ctx, tx)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil, fmt.Errorf("referenced deduction operation not found: %w", err)
+			return nil, nil, fmt.Errorf("referenced deduction %w: %w", OperationNotFoundErr, err)
 		}
 		return nil, nil, fmt.Errorf("failed to check if operation exists: %w", err)
 	}
@@ -718,6 +1234,7 @@ func (r *Repository) getGrantsFromOperation(ctx context.Context, tx *sql.Tx, ref
 	operationGrants, err := models.CreditOperationGrants(
 		models.CreditOperationGrantWhere.AppName.EQ(appName),
 		models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+		models.CreditOperationGrantWhere.OperationType.EQ(OperationTypeDeduction),
 		qm.Load(models.CreditOperationGrantRels.Grant),
 	).All(ctx, tx)
 	if err != nil {
@@ -727,14 +1244,118 @@ func (r *Repository) getGrantsFromOperation(ctx context.Context, tx *sql.Tx, ref
 		return nil, nil, fmt.Errorf("failed to get operation grants: %w", err)
 	}
 
+	for _, opGrant := range operationGrants {
+		if opGrant.GetGrant() == nil {
+			return nil, nil, fmt.Errorf("grant not found for operation grant %s", opGrant.ID)
+		}
+	}
+	// order the grants the way the deduction used them
+	slices.SortFunc(operationGrants, func(a, b *models.CreditOperationGrant) int {
+		return CompareGrantFIFO(a.GetGrant(), b.GetGrant())
+	})
+
+	// summary-only operations have no grant rows, their refund is redistributed instead
+	if !operation.SummaryOnly {
+		grantTotal := int64(0)
+		for _, opGrant := range operationGrants {
+			grantTotal -= opGrant.AmountUsed
+		}
+		if grantTotal < operation.TotalAmount {
+			// the grant rows of a deleted grant are deleted with it, so the credits have nowhere to go back to
+			return nil, nil, fmt.Errorf("%w: %w: operation total %d, grant total %d", RefundUnavailableErr, OperationGrantMismatchErr, operation.TotalAmount, grantTotal)
+		}
+		if grantTotal != operation.TotalAmount {
+			return nil, nil, fmt.Errorf("%w: operation total %d, grant total %d", OperationGrantMismatchErr, operation.TotalAmount, grantTotal)
+		}
+	}
+
 	return operationGrants, operation, nil
 }
 
+// CompareGrantFIFO orders grants the way deductions use them, by expiration then creation.
+// Perpetual grants are used last, like the NULLS LAST ordering of the expiration in the grant queries.
+func CompareGrantFIFO(a, b *models.CreditGrant) int {
+	return cmp.Or(
+		compareExpiration(a.ExpiresAt, b.ExpiresAt),
+		a.CreatedAt.Time.Compare(b.CreatedAt.Time),
+		strings.Compare(a.ID, b.ID),
+	)
+}
+
+// compareExpiration compares expiration times where a null expiration never expires.
+func compareExpiration(a, b null.Time) int {
+	switch {
+	case !a.Valid && !b.Valid:
+		return 0
+	case !a.Valid:
+		return 1
+	case !b.Valid:
+		return -1
+	}
+	return a.Time.Compare(b.Time)
+}
+
+// notExpired matches the grants that expire after the given time and the perpetual grants that never expire.
+func notExpired(at time.Time) qm.QueryMod {
+	return qm.Expr(
+		models.CreditGrantWhere.ExpiresAt.IsNull(),
+		qm.Or2(models.CreditGrantWhere.ExpiresAt.GT(null.TimeFrom(at))),
+	)
+}
+
 // expire on the same date in the next month
 func getExpirationDate(mintTime time.Time) time.Time {
 	return mintTime.UTC().AddDate(0, 1, 0)
 }
 
+// traceIDFrom returns the trace ID of the request to store on its operations.
+func traceIDFrom(ctx context.Context) null.String {
+	if traceID := tracing.TraceIDFromContext(ctx); traceID != "" {
+		return null.StringFrom(traceID)
+	}
+	return null.String{}
+}
+
+// logOperation logs a committed operation, adds its fields to the span of the context, and publishes it to the subscribers of its license.
+// The context logger carries the trace ID of the request, and the balance after the operation is logged when it is recorded.
+func (r *Repository) logOperation(ctx context.Context, operation *models.CreditOperation, msg string) {
+	setOperationAttributes(ctx, operation)
+	r.operationHub.Publish(operation)
+	event := zerolog.Ctx(ctx).Debug().
+		Str("licenseId", operation.LicenseID).
+		Str("assetDid", operation.AssetDid).
+		Str("operationType", operation.OperationType).
+		Str("appName", operation.AppName).
+		Str("referenceId", operation.ReferenceID).
+		Int64("amount", operation.TotalAmount)
+	if operation.BalanceAfter.Valid {
+		event = event.Int64("balanceAfter", operation.BalanceAfter.Int64)
+	}
+	event.Msg(msg)
+}
+
+// operationLogger returns the logger of the context with the fields of an operation that is about to run,
+// leaving out the fields the operation does not know up front.
+func operationLogger(ctx context.Context, licenseID, assetDID, appName, referenceID string, amount uint64) zerolog.Logger {
+	logCtx := zerolog.Ctx(ctx).With()
+	if licenseID != "" {
+		logCtx = logCtx.Str("licenseId", licenseID)
+	}
+	if assetDID != "" {
+		logCtx = logCtx.Str("assetDid", assetDID)
+	}
+	if appName != "" {
+		logCtx = logCtx.Str("appName", appName)
+	}
+	if referenceID != "" {
+		logCtx = logCtx.Str("referenceId", referenceID)
+	}
+	if amount != 0 {
+		logCtx = logCtx.Uint64("amount", amount)
+	}
+	return logCtx.Logger()
+}
+
 // rollbackTx is a helper function to handle transaction rollback with error checking
 func rollbackTx(ctx context.Context, tx *sql.Tx) {
 	if tx == nil {
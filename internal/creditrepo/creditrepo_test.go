@@ -14,6 +14,7 @@ import (
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
@@ -46,7 +47,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -85,7 +86,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: 5,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant1.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -97,7 +98,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusPending,
-			ExpiresAt:       time.Now().Add(48 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(48 * time.Hour)),
 		}
 		err = grant2.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -141,7 +142,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: 0,
 			Status:          "confirmed",
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -153,6 +154,87 @@ func TestDeductCredits(t *testing.T) {
 		assert.Contains(t, err.Error(), "insufficient credits")
 	})
 
+	t.Run("insufficient credits shortfall", func(t *testing.T) {
+		t.Parallel()
+		testCases := []struct {
+			name      string
+			remaining []int64
+			required  int64
+		}{
+			{name: "empty", remaining: []int64{0}, required: 10},
+			{name: "partial", remaining: []int64{40}, required: 100},
+			{name: "multiple grants", remaining: []int64{30, 45}, required: 100},
+			{name: "one short", remaining: []int64{99}, required: 100},
+		}
+		for _, tc := range testCases {
+			licenseID := "test-license-deduct-shortfall-" + tc.name
+			available := int64(0)
+			for _, remaining := range tc.remaining {
+				grant := &models.CreditGrant{
+					LicenseID:       licenseID,
+					AssetDid:        testAssetID,
+					InitialAmount:   defaultGrantAmount,
+					RemainingAmount: remaining,
+					Status:          GrantStatusConfirmed,
+					ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+				}
+				require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+				available += remaining
+			}
+
+			_, err := repo.DeductCredits(ctx, licenseID, testAssetID, uint64(tc.required), testAPIEndpoint, uuid.NewString())
+			require.ErrorIs(t, err, InsufficientCreditsErr, tc.name)
+			var insufficientErr *InsufficientCreditsError
+			require.ErrorAs(t, err, &insufficientErr, tc.name)
+			assert.Equal(t, available, insufficientErr.Available, tc.name)
+			assert.Equal(t, tc.required, insufficientErr.Required, tc.name)
+			assert.Equal(t, tc.required-available, insufficientErr.Shortfall, tc.name)
+		}
+	})
+
+	t.Run("only pending grant", func(t *testing.T) {
+		t.Parallel()
+		testCases := []struct {
+			name          string
+			allowed       bool
+			expectBalance int64
+		}{
+			{name: "allowed", allowed: true, expectBalance: defaultGrantAmount},
+			{name: "confirmed-only", allowed: false, expectBalance: 0},
+		}
+		for _, tc := range testCases {
+			licenseID := "test-license-deduct-pending-" + tc.name
+			grant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        testAssetID,
+				InitialAmount:   defaultGrantAmount,
+				RemainingAmount: defaultGrantAmount,
+				Status:          GrantStatusPending,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			}
+			require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+			pendingRepo := New(db, WithPendingGrantSpending(tc.allowed))
+
+			balance, err := pendingRepo.GetBalance(ctx, licenseID, testAssetID)
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectBalance, balance.Balance, tc.name)
+
+			_, err = pendingRepo.DeductCredits(ctx, licenseID, testAssetID, 1, testAPIEndpoint, uuid.NewString())
+			if tc.allowed {
+				require.NoError(t, err, tc.name)
+			} else {
+				require.ErrorIs(t, err, InsufficientCreditsErr, tc.name)
+			}
+
+			require.NoError(t, grant.Reload(ctx, db))
+			if tc.allowed {
+				assert.Equal(t, defaultGrantAmount-1, grant.RemainingAmount, tc.name)
+			} else {
+				assert.Equal(t, defaultGrantAmount, grant.RemainingAmount, tc.name)
+			}
+		}
+	})
+
 	t.Run("with outstanding debt", func(t *testing.T) {
 		t.Parallel()
 		licenseID := "test-license-deduct-debt"
@@ -163,7 +245,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount - 500, // 500 debt
 			Status:          GrantStatusFailed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -185,7 +267,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(-24 * time.Hour), // Expired
+			ExpiresAt:       null.TimeFrom(time.Now().Add(-24 * time.Hour)), // Expired
 		}
 		err := expiredGrant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -196,7 +278,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err = activeGrant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -232,7 +314,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusPending,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := pendingGrant1.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -243,7 +325,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusPending,
-			ExpiresAt:       time.Now().Add(48 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(48 * time.Hour)),
 		}
 		err = pendingGrant2.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -279,7 +361,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -315,7 +397,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -344,7 +426,7 @@ func TestDeductCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -363,7 +445,7 @@ func TestDeductCredits(t *testing.T) {
 		assert.Equal(t, defaultGrantAmount-apiCost, grant.RemainingAmount)
 
 		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, uint64(apiCost), testAPIEndpoint, referenceID)
-		require.Error(t, err)
+		require.ErrorIs(t, err, DuplicateOperationErr)
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) {
 			fmt.Printf("%s: As PostgreSQL error: %#v\n", licenseID, pqErr)
@@ -378,6 +460,62 @@ func TestDeductCredits(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, defaultGrantAmount-apiCost, grant.RemainingAmount)
 	})
+
+	t.Run("deduction that exhausts a grant marks it depleted", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-deduct-depleted"
+		depletingRepo := New(db, WithDepletedGrantMarking(true))
+		// Setup: Create a small grant that will be fully used and a larger one that will not
+		grant1 := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: 5,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant1.Insert(ctx, db, boil.Infer()))
+		grant2 := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(48 * time.Hour)),
+		}
+		require.NoError(t, grant2.Insert(ctx, db, boil.Infer()))
+
+		// Test: Deduct credits exhausting the first grant
+		referenceID := uuid.NewString()
+		_, err := depletingRepo.DeductCredits(ctx, licenseID, testAssetID, 10, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Verify: Only the exhausted grant is marked depleted
+		grant1, err = models.FindCreditGrant(ctx, db, grant1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), grant1.RemainingAmount)
+		assert.True(t, grant1.DepletedAt.Valid)
+		grant2, err = models.FindCreditGrant(ctx, db, grant2.ID)
+		require.NoError(t, err)
+		assert.False(t, grant2.DepletedAt.Valid)
+
+		// Verify: The depleted grant is excluded from the active set
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		activeGrants, err := depletingRepo.getActiveGrants(ctx, tx, licenseID, testAssetID, DefaultCreditType)
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+		require.Len(t, activeGrants, 1)
+		assert.Equal(t, grant2.ID, activeGrants[0].ID)
+
+		// Verify: Refunding the deduction clears the depleted marker
+		_, err = depletingRepo.RefundCredits(ctx, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+		grant1, err = models.FindCreditGrant(ctx, db, grant1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), grant1.RemainingAmount)
+		assert.False(t, grant1.DepletedAt.Valid)
+	})
 }
 
 func TestRefundCredits(t *testing.T) {
@@ -399,7 +537,7 @@ func TestRefundCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -459,7 +597,7 @@ func TestRefundCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: 5,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant1.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -470,7 +608,7 @@ func TestRefundCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(48 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(48 * time.Hour)),
 		}
 		err = grant2.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -543,7 +681,7 @@ func TestRefundCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(1 * time.Second), // Expired
+			ExpiresAt:       null.TimeFrom(time.Now().Add(1 * time.Second)), // Expired
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -561,14 +699,14 @@ func TestRefundCredits(t *testing.T) {
 		time.Sleep(1 * time.Second)
 		tx, err := db.BeginTx(ctx, nil)
 		require.NoError(t, err)
-		preRefundBalance, err := repo.calculateBalance(ctx, tx, licenseID, testAssetID)
+		preRefundBalance, err := repo.calculateBalance(ctx, tx, licenseID, testAssetID, DefaultCreditType)
 		require.NoError(t, err)
 
 		// Test: Refund credits
 		_, err = repo.RefundCredits(ctx, testAPIEndpoint, referenceID)
 		require.NoError(t, err)
 
-		postRefundBalance, err := repo.calculateBalance(ctx, tx, licenseID, testAssetID)
+		postRefundBalance, err := repo.calculateBalance(ctx, tx, licenseID, testAssetID, DefaultCreditType)
 		require.NoError(t, err)
 		require.Equal(t, preRefundBalance, postRefundBalance)
 		err = tx.Commit()
@@ -601,7 +739,7 @@ func TestRefundCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -649,7 +787,7 @@ func TestRefundCredits(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount,
 			Status:          GrantStatusConfirmed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := grant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -674,7 +812,7 @@ func TestRefundCredits(t *testing.T) {
 		assert.Equal(t, defaultGrantAmount, grant.RemainingAmount)
 
 		_, err = repo.RefundCredits(ctx, testAPIEndpoint, referenceID)
-		require.Error(t, err)
+		require.ErrorIs(t, err, DuplicateOperationErr)
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) {
 			fmt.Printf("%s: As PostgreSQL error: %#v\n", licenseID, pqErr)
@@ -688,6 +826,253 @@ func TestRefundCredits(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, defaultGrantAmount, grant.RemainingAmount)
 	})
+
+	t.Run("refund with missing grant rows", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-refund-grant-mismatch"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		err := grant.Insert(ctx, db, boil.Infer())
+		require.NoError(t, err)
+
+		referenceID := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, uint64(100), testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Setup: Remove the grant rows recorded for the deduction
+		_, err = models.CreditOperationGrants(
+			models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+		).DeleteAll(ctx, db)
+		require.NoError(t, err)
+
+		// Test: Refund should fail instead of silently refunding nothing
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, referenceID)
+		require.ErrorIs(t, err, OperationGrantMismatchErr)
+
+		// Verify: No refund operation was recorded
+		exists, err := models.CreditOperations(
+			models.CreditOperationWhere.ReferenceID.EQ(referenceID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeRefund),
+		).Exists(ctx, db)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("partial refund refills the last drained grant first", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-partial-refund"
+		// Setup: The deduction drains the first grant then uses the second
+		firstGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   60,
+			RemainingAmount: 60,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		err := firstGrant.Insert(ctx, db, boil.Infer())
+		require.NoError(t, err)
+		secondGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(48 * time.Hour)),
+		}
+		err = secondGrant.Insert(ctx, db, boil.Infer())
+		require.NoError(t, err)
+
+		referenceID := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, uint64(100), testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Test: Refund half of the deduction
+		refundOp, err := repo.RefundPartialCredits(ctx, testAPIEndpoint, referenceID, 50)
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), refundOp.TotalAmount)
+
+		// Verify: The second grant is refilled fully before the first
+		err = secondGrant.Reload(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, defaultGrantAmount, secondGrant.RemainingAmount)
+		err = firstGrant.Reload(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), firstGrant.RemainingAmount)
+
+		// Verify: A refund row is recorded per grant touched
+		refundGrants, err := models.CreditOperationGrants(
+			models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+			models.CreditOperationGrantWhere.OperationType.EQ(OperationTypeRefund),
+		).All(ctx, db)
+		require.NoError(t, err)
+		refunded := map[string]int64{}
+		for _, opGrant := range refundGrants {
+			refunded[opGrant.GrantID] += opGrant.AmountUsed
+		}
+		assert.Equal(t, map[string]int64{firstGrant.ID: 10, secondGrant.ID: 40}, refunded)
+
+		// Test: The deduction can not be refunded again
+		_, err = repo.RefundPartialCredits(ctx, testAPIEndpoint, referenceID, 10)
+		require.Error(t, err)
+	})
+
+	t.Run("partial refund exceeding the deduction", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-partial-refund-exceeds"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		err := grant.Insert(ctx, db, boil.Infer())
+		require.NoError(t, err)
+
+		referenceID := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, uint64(100), testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Test: Refund more than was deducted
+		_, err = repo.RefundPartialCredits(ctx, testAPIEndpoint, referenceID, 101)
+		require.ErrorIs(t, err, RefundExceedsDeductionErr)
+
+		// Verify: Grant was not changed
+		err = grant.Reload(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, defaultGrantAmount-100, grant.RemainingAmount)
+	})
+
+	t.Run("partial refund after full refund", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-partial-refund-after-full"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		err := grant.Insert(ctx, db, boil.Infer())
+		require.NoError(t, err)
+
+		referenceID := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, uint64(100), testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Test: A partial refund of an already refunded deduction is rejected
+		_, err = repo.RefundPartialCredits(ctx, testAPIEndpoint, referenceID, 50)
+		require.Error(t, err)
+
+		// Verify: Grant was only refunded once
+		err = grant.Reload(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, defaultGrantAmount, grant.RemainingAmount)
+	})
+
+	t.Run("refund exceeding grant capacity after chargeback", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-refund-chargeback-error"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		err := grant.Insert(ctx, db, boil.Infer())
+		require.NoError(t, err)
+
+		referenceID := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, uint64(100), testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Setup: A chargeback reduces the grant so only 40 of the 100 used credits can be returned
+		grant.InitialAmount = defaultGrantAmount - 60
+		grant.RemainingAmount = defaultGrantAmount - 100
+		_, err = grant.Update(ctx, db, boil.Whitelist(models.CreditGrantColumns.InitialAmount, models.CreditGrantColumns.RemainingAmount))
+		require.NoError(t, err)
+
+		// Test: Refund should fail with the default policy
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, referenceID)
+		require.ErrorIs(t, err, RefundExceedsCapacityErr)
+
+		// Verify: Grant was not changed
+		err = grant.Reload(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, defaultGrantAmount-100, grant.RemainingAmount)
+	})
+
+	t.Run("refund exceeding grant capacity redirected after chargeback", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-refund-chargeback-redirect"
+		redirectRepo := New(db, WithRefundOverflowPolicy(RefundOverflowRedirect))
+		firstGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		err := firstGrant.Insert(ctx, db, boil.Infer())
+		require.NoError(t, err)
+		secondGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount - 100,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(48 * time.Hour)),
+		}
+		err = secondGrant.Insert(ctx, db, boil.Infer())
+		require.NoError(t, err)
+
+		referenceID := uuid.NewString()
+		_, err = redirectRepo.DeductCredits(ctx, licenseID, testAssetID, uint64(100), testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Setup: A chargeback reduces the first grant so only 40 of the 100 used credits can be returned
+		firstGrant.InitialAmount = defaultGrantAmount - 60
+		firstGrant.RemainingAmount = defaultGrantAmount - 100
+		_, err = firstGrant.Update(ctx, db, boil.Whitelist(models.CreditGrantColumns.InitialAmount, models.CreditGrantColumns.RemainingAmount))
+		require.NoError(t, err)
+
+		// Test: Refund is capped and the excess goes to the second grant
+		_, err = redirectRepo.RefundCredits(ctx, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Verify: First grant is full and the second grant took the excess
+		err = firstGrant.Reload(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, firstGrant.InitialAmount, firstGrant.RemainingAmount)
+		err = secondGrant.Reload(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, defaultGrantAmount-40, secondGrant.RemainingAmount)
+
+		refundGrants, err := models.CreditOperationGrants(
+			models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+			models.CreditOperationGrantWhere.OperationType.EQ(OperationTypeRefund),
+		).All(ctx, db)
+		require.NoError(t, err)
+		refunded := map[string]int64{}
+		for _, opGrant := range refundGrants {
+			refunded[opGrant.GrantID] += opGrant.AmountUsed
+		}
+		assert.Equal(t, map[string]int64{firstGrant.ID: 40, secondGrant.ID: 60}, refunded)
+	})
 }
 
 func TestCreateGrant(t *testing.T) {
@@ -750,7 +1135,7 @@ func TestCreateGrant(t *testing.T) {
 			InitialAmount:   defaultGrantAmount,
 			RemainingAmount: defaultGrantAmount - 100, // 100 debt
 			Status:          GrantStatusFailed,
-			ExpiresAt:       time.Now().Add(24 * time.Hour),
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
 		}
 		err := failedGrant.Insert(ctx, db, boil.Infer())
 		require.NoError(t, err)
@@ -778,6 +1163,60 @@ func TestCreateGrant(t *testing.T) {
 		assert.Equal(t, defaultGrantAmount-100, newGrant.RemainingAmount)
 	})
 
+	t.Run("create grant settles several failed grants with limited balance", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-multi-debt"
+		// Setup: Two failed grants with 100 debt each and a confirmed grant with 50 credits left
+		var failedGrants []*models.CreditGrant
+		for range 2 {
+			failedGrant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        testAssetID,
+				InitialAmount:   defaultGrantAmount,
+				RemainingAmount: defaultGrantAmount - 100,
+				Status:          GrantStatusFailed,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			}
+			require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+			failedGrants = append(failedGrants, failedGrant)
+		}
+		activeGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   50,
+			RemainingAmount: 50,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		}
+		require.NoError(t, activeGrant.Insert(ctx, db, boil.Infer()))
+
+		// Test: A grant of 100 brings the balance to 150, less than the 200 of debt
+		grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, 100, time.Now())
+		require.NoError(t, err)
+
+		// Verify: The first failed grant is settled and the second is half settled
+		for i, expected := range []int64{defaultGrantAmount, defaultGrantAmount - 50} {
+			require.NoError(t, failedGrants[i].Reload(ctx, db))
+			assert.Equal(t, expected, failedGrants[i].RemainingAmount)
+		}
+		require.NoError(t, activeGrant.Reload(ctx, db))
+		assert.Equal(t, int64(0), activeGrant.RemainingAmount)
+		require.NoError(t, grant.Reload(ctx, db))
+		assert.Equal(t, int64(0), grant.RemainingAmount)
+
+		// Verify: The settlement records the amount moved
+		settlement, err := models.CreditOperations(
+			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeDebtSettlement),
+		).One(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(150), settlement.TotalAmount)
+
+		debt, err := repo.getOutstandingDebt(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), debt)
+	})
+
 	t.Run("create grant with zero amount", func(t *testing.T) {
 		t.Parallel()
 		licenseID := "test-license-grant-zero"
@@ -895,7 +1334,7 @@ func TestConfirmGrant(t *testing.T) {
 		assert.Equal(t, 1, grant.LogIndex.Int)
 		assert.Equal(t, defaultGrantAmount, grant.InitialAmount)
 		assert.Equal(t, defaultGrantAmount, grant.RemainingAmount)
-		assert.Equal(t, getExpirationDate(mintTime).Truncate(time.Millisecond), grant.ExpiresAt.UTC().Truncate(time.Millisecond))
+		assert.Equal(t, getExpirationDate(mintTime).Truncate(time.Millisecond), grant.ExpiresAt.Time.UTC().Truncate(time.Millisecond))
 
 		// Verify: Check operation record
 		operation, err := models.CreditOperations(
@@ -917,6 +1356,79 @@ func TestConfirmGrant(t *testing.T) {
 		assert.Equal(t, OperationTypeGrantConfirm, operationGrants[0].OperationType)
 	})
 
+	t.Run("confirm grant with pending grant of different license", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-confirm-mismatch"
+		otherLicenseID := "test-license-grant-confirm-mismatch-other"
+		localTextTXHash := common.BytesToAddress([]byte(licenseID))
+		// Setup: Create a pending grant for the tx hash under one license
+		grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, uint64(defaultGrantAmount), time.Now())
+		require.NoError(t, err)
+		_, err = repo.UpdateGrantTxHash(ctx, grant, localTextTXHash.Hex())
+		require.NoError(t, err)
+
+		// Test: Confirm the tx hash under a different license
+		_, err = repo.ConfirmGrant(ctx, otherLicenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), time.Now())
+		require.ErrorIs(t, err, PendingGrantMismatchErr)
+
+		// Verify: No new grant was created and the pending grant is untouched
+		grants, err := models.CreditGrants(
+			models.CreditGrantWhere.TXHash.EQ(localTextTXHash.Hex()),
+		).All(ctx, db)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(grants))
+		assert.Equal(t, licenseID, grants[0].LicenseID)
+		assert.Equal(t, GrantStatusPending, grants[0].Status)
+
+		// Test: Confirming creates a new grant when mismatches are allowed
+		mismatchRepo := New(db, WithPendingGrantMismatchConfirmation(true))
+		_, err = mismatchRepo.ConfirmGrant(ctx, otherLicenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), time.Now())
+		require.NoError(t, err)
+
+		grants, err = models.CreditGrants(
+			models.CreditGrantWhere.TXHash.EQ(localTextTXHash.Hex()),
+			models.CreditGrantWhere.LicenseID.EQ(otherLicenseID),
+		).All(ctx, db)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(grants))
+		assert.Equal(t, GrantStatusConfirmed, grants[0].Status)
+	})
+
+	t.Run("confirm grant with pending grant of different amount", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-confirm-amount-mismatch"
+		localTextTXHash := common.BytesToAddress([]byte(licenseID))
+		// Setup: Create a pending grant for the tx hash
+		grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, uint64(defaultGrantAmount), time.Now())
+		require.NoError(t, err)
+		_, err = repo.UpdateGrantTxHash(ctx, grant, localTextTXHash.Hex())
+		require.NoError(t, err)
+
+		// Test: Confirm the tx hash with a different amount
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount/2), time.Now())
+		require.ErrorIs(t, err, GrantAmountMismatchErr)
+
+		// Verify: The pending grant is untouched and no credits were granted
+		grants, err := models.CreditGrants(
+			models.CreditGrantWhere.TXHash.EQ(localTextTXHash.Hex()),
+		).All(ctx, db)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(grants))
+		assert.Equal(t, GrantStatusPending, grants[0].Status)
+		assert.Equal(t, defaultGrantAmount, grants[0].InitialAmount)
+
+		operations, err := models.CreditOperations(
+			models.CreditOperationWhere.ReferenceID.EQ(grant.ID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeGrantConfirm),
+		).Count(ctx, db)
+		require.NoError(t, err)
+		assert.Zero(t, operations)
+
+		// Test: Confirming with the pending amount still succeeds
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), time.Now())
+		require.NoError(t, err)
+	})
+
 	t.Run("confirm already confirmed grant", func(t *testing.T) {
 		t.Parallel()
 		licenseID := "test-license-grant-confirm-twice"
@@ -937,11 +1449,11 @@ func TestConfirmGrant(t *testing.T) {
 		assert.Equal(t, 1, grant.LogIndex.Int)
 		assert.Equal(t, defaultGrantAmount, grant.InitialAmount)
 		assert.Equal(t, defaultGrantAmount, grant.RemainingAmount)
-		assert.Equal(t, getExpirationDate(mintTime).Truncate(time.Millisecond), grant.ExpiresAt.UTC().Truncate(time.Millisecond))
+		assert.Equal(t, getExpirationDate(mintTime).Truncate(time.Millisecond), grant.ExpiresAt.Time.UTC().Truncate(time.Millisecond))
 
 		// Test: Try to confirm again
 		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), mintTime)
-		require.Error(t, err)
+		require.ErrorIs(t, err, GrantAlreadyConfirmedErr)
 		// Verify: Grant should be updated with new log index
 		grants, err := models.CreditGrants(
 			models.CreditGrantWhere.TXHash.EQ(localTextTXHash.Hex()),
@@ -951,6 +1463,19 @@ func TestConfirmGrant(t *testing.T) {
 		assert.Equal(t, GrantStatusConfirmed, grants[0].Status)
 	})
 
+	t.Run("confirm already confirmed grant with different amount", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-confirm-conflict"
+		localTextTXHash := common.BytesToAddress([]byte(licenseID))
+		mintTime := time.Now()
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), mintTime)
+		require.NoError(t, err)
+
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount+1), mintTime)
+		require.ErrorIs(t, err, ConfirmConflictErr)
+		require.NotErrorIs(t, err, GrantAlreadyConfirmedErr)
+	})
+
 }
 
 func TestFIFOOrdering(t *testing.T) {
@@ -1161,4 +1686,158 @@ func TestConcurrentOperations(t *testing.T) {
 		assert.Equal(t, GrantStatusConfirmed, grants[1].Status)
 		assert.Equal(t, 1, grants[1].LogIndex.Int)
 	})
+
+	t.Run("concurrent confirmations of the same chain event", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-concurrent-confirm-same-event"
+		localTextTXHash := common.BytesToAddress([]byte(licenseID))
+		// Setup: Both confirmations race for the same pending grant
+		pending, err := repo.CreateGrant(ctx, licenseID, testAssetID, uint64(defaultGrantAmount), time.Now())
+		require.NoError(t, err)
+		_, err = repo.UpdateGrantTxHash(ctx, pending, localTextTXHash.Hex())
+		require.NoError(t, err)
+
+		// Test: Confirm the same tx hash and log index twice at once
+		done := make(chan error, 2)
+		for range 2 {
+			go func() {
+				_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), time.Now())
+				done <- err
+			}()
+		}
+		errs := []error{<-done, <-done}
+
+		// Verify: One confirmation succeeds and the other reports the event as already confirmed
+		var succeeded int
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+				continue
+			}
+			require.ErrorIs(t, err, GrantAlreadyConfirmedErr)
+		}
+		assert.Equal(t, 1, succeeded)
+
+		// Verify: The chain event has exactly one confirmed grant
+		grants, err := models.CreditGrants(
+			models.CreditGrantWhere.TXHash.EQ(localTextTXHash.Hex()),
+			models.CreditGrantWhere.Status.EQ(GrantStatusConfirmed),
+		).All(ctx, db)
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		assert.Equal(t, pending.ID, grants[0].ID)
+		assert.Equal(t, 1, grants[0].LogIndex.Int)
+	})
+
+	t.Run("stuck lock times out", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-concurrent-timeout"
+		localTextTXHash := common.BytesToAddress([]byte(licenseID))
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), time.Now())
+		require.NoError(t, err)
+
+		// hold the grant row lock until the deduction gives up
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback() //nolint:errcheck
+		_, err = models.CreditGrants(
+			models.CreditGrantWhere.TXHash.EQ(localTextTXHash.Hex()),
+			qm.For("UPDATE"),
+		).All(ctx, tx)
+		require.NoError(t, err)
+
+		timeoutRepo := New(db, WithOpTimeout(200*time.Millisecond))
+		start := time.Now()
+		_, err = timeoutRepo.DeductCredits(ctx, licenseID, testAssetID, 100, testAPIEndpoint, uuid.NewString())
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestGetBalance(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("balance and debt are reported separately", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-balance-with-debt"
+		// Setup: Create an active grant and a failed grant with debt
+		activeGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: 300,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, activeGrant.Insert(ctx, db, boil.Infer()))
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount - 500,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+
+		// Test: Balance reports both the spendable balance and the debt
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), balance.Balance)
+		assert.Equal(t, int64(500), balance.Debt)
+
+		// Verify: Debt still blocks spending
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 1, testAPIEndpoint, uuid.NewString())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "outstanding debt")
+	})
+
+	t.Run("balance without debt", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-balance-no-debt"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, defaultGrantAmount, balance.Balance)
+		assert.Equal(t, int64(0), balance.Debt)
+	})
+
+	t.Run("only failed grants", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-balance-only-debt"
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount - 700,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+
+		// Test: Nothing is spendable but the debt is still reported
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), balance.Balance)
+		assert.Equal(t, int64(700), balance.Debt)
+
+		balances, err := repo.GetBalances(ctx, licenseID, []string{testAssetID})
+		require.NoError(t, err)
+		assert.Equal(t, &Balance{Balance: 0, Debt: 700}, balances[testAssetID])
+	})
 }
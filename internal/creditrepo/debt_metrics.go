@@ -0,0 +1,91 @@
+package creditrepo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/rs/zerolog"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const (
+	// debtSeriesTTL is how long a debt series is kept without being refreshed. Another instance may have settled the debt
+	// in the meantime, so the series is dropped rather than reporting debt this instance can no longer vouch for.
+	debtSeriesTTL = time.Hour
+	// debtRejectionRefreshInterval is how often deductions refused for debt refresh the series of their license,
+	// they can be as frequent as the deductions themselves.
+	debtRejectionRefreshInterval = time.Minute
+)
+
+// debtGauge keeps the CreditDebt series of the licenses in debt and when each was last refreshed,
+// so that series of settled debt are removed and stale series do not pile up.
+type debtGauge struct {
+	mu        sync.Mutex
+	refreshed map[string]time.Time
+}
+
+func newDebtGauge() *debtGauge {
+	return &debtGauge{refreshed: map[string]time.Time{}}
+}
+
+// set reports the debt of a license, removing its series when there is no debt, and removes the series not refreshed within debtSeriesTTL.
+func (g *debtGauge) set(licenseID string, debt int64, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if debt > 0 {
+		CreditDebt.WithLabelValues(licenseID).Set(float64(debt))
+		g.refreshed[licenseID] = now
+	} else {
+		CreditDebt.DeleteLabelValues(licenseID)
+		delete(g.refreshed, licenseID)
+	}
+	for tracked, refreshed := range g.refreshed {
+		if now.Sub(refreshed) > debtSeriesTTL {
+			CreditDebt.DeleteLabelValues(tracked)
+			delete(g.refreshed, tracked)
+		}
+	}
+}
+
+// refreshedSince reports whether the license has a series refreshed at or after the given time.
+func (g *debtGauge) refreshedSince(licenseID string, since time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	refreshed, ok := g.refreshed[licenseID]
+	return ok && !refreshed.Before(since)
+}
+
+// observeDebt updates the debt gauge of a license after an operation that may have created debt committed.
+// The debt is read from the balance summaries, which are committed with the operation. Failing to read it only skips the update.
+func (r *Repository) observeDebt(ctx context.Context, licenseID string) {
+	var debt int64
+	err := models.CreditBalanceSummaries(
+		qm.Select("COALESCE(SUM("+models.CreditBalanceSummaryColumns.Debt+"), 0)"),
+		models.CreditBalanceSummaryWhere.LicenseID.EQ(licenseID),
+	).QueryRowContext(ctx, r.db).Scan(&debt)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("licenseId", licenseID).Msg("failed to update the debt gauge")
+		return
+	}
+	r.debtGauge.set(licenseID, debt, time.Now())
+}
+
+// observeSettledDebt updates the debt gauge of a license after an operation that settles debt committed.
+// Debt only goes down by settlement, so licenses without a series are skipped and operations of licenses without debt do not pay for the query.
+func (r *Repository) observeSettledDebt(ctx context.Context, licenseID string) {
+	if r.debtGauge.refreshedSince(licenseID, time.Time{}) {
+		r.observeDebt(ctx, licenseID)
+	}
+}
+
+// observeDebtRejection updates the debt gauge of a license when credits could not be spent because of its debt,
+// which also reports debt created before this instance started or by another instance.
+func (r *Repository) observeDebtRejection(ctx context.Context, licenseID string, err error) {
+	if !errors.Is(err, OutstandingDebtErr) || r.debtGauge.refreshedSince(licenseID, time.Now().Add(-debtRejectionRefreshInterval)) {
+		return
+	}
+	r.observeDebt(ctx, licenseID)
+}
@@ -0,0 +1,104 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebtGauge(t *testing.T) {
+	t.Parallel()
+	gauge := newDebtGauge()
+	now := time.Now()
+
+	// Test: Debt creates the series of the license
+	gauge.set("test-debt-gauge-1", 30, now)
+	value, ok := debtSeries(t, "test-debt-gauge-1")
+	require.True(t, ok)
+	assert.Equal(t, float64(30), value)
+	assert.True(t, gauge.refreshedSince("test-debt-gauge-1", now))
+
+	// Test: Settled debt removes the series
+	gauge.set("test-debt-gauge-1", 0, now)
+	_, ok = debtSeries(t, "test-debt-gauge-1")
+	assert.False(t, ok)
+	assert.False(t, gauge.refreshedSince("test-debt-gauge-1", time.Time{}))
+
+	// Test: Series not refreshed within the TTL are removed by the next update
+	gauge.set("test-debt-gauge-2", 10, now)
+	gauge.set("test-debt-gauge-3", 20, now.Add(debtSeriesTTL+time.Second))
+	_, ok = debtSeries(t, "test-debt-gauge-2")
+	assert.False(t, ok)
+	value, ok = debtSeries(t, "test-debt-gauge-3")
+	require.True(t, ok)
+	assert.Equal(t, float64(20), value)
+}
+
+func TestDebtGaugeOperations(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+
+	repo := New(dbContainer.DB)
+	ctx := context.Background()
+	licenseID := "test-license-debt-gauge"
+
+	// Setup: Spend from a pending grant, then fail it
+	grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, 100, time.Now())
+	require.NoError(t, err)
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 30, testAPIEndpoint, "debt-gauge-1")
+	require.NoError(t, err)
+	_, ok := debtSeries(t, licenseID)
+	assert.False(t, ok)
+
+	_, err = repo.FailGrant(ctx, grant)
+	require.NoError(t, err)
+
+	// Verify: The gauge reports the debt of the failed grant
+	value, ok := debtSeries(t, licenseID)
+	require.True(t, ok)
+	assert.Equal(t, float64(30), value)
+
+	// Verify: A deduction refused for the debt keeps the series
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 1, testAPIEndpoint, "debt-gauge-2")
+	require.ErrorIs(t, err, OutstandingDebtErr)
+	value, ok = debtSeries(t, licenseID)
+	require.True(t, ok)
+	assert.Equal(t, float64(30), value)
+
+	// Test: A confirmed grant settles the debt
+	_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xdebt-gauge", 0, 100, time.Now())
+	require.NoError(t, err)
+
+	// Verify: The series of the settled debt is removed
+	_, ok = debtSeries(t, licenseID)
+	assert.False(t, ok)
+}
+
+// debtSeries returns the CreditDebt value of a license and whether the license has a series.
+func debtSeries(t *testing.T, licenseID string) (float64, bool) {
+	t.Helper()
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		CreditDebt.Collect(metrics)
+		close(metrics)
+	}()
+	var value float64
+	var found bool
+	for metric := range metrics {
+		out := &dto.Metric{}
+		require.NoError(t, metric.Write(out))
+		for _, label := range out.GetLabel() {
+			if label.GetName() == "developer_license" && label.GetValue() == licenseID {
+				value, found = out.GetGauge().GetValue(), true
+			}
+		}
+	}
+	return value, found
+}
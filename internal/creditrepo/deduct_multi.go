@@ -0,0 +1,145 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+)
+
+const deductCreditsSavepoint = "deduct_credits"
+
+// DeductMultiMode selects how DeductCreditsMulti handles a failed deduction.
+type DeductMultiMode string
+
+const (
+	// DeductMultiAtomic rolls back every deduction when one of them fails.
+	DeductMultiAtomic DeductMultiMode = "atomic"
+	// DeductMultiBestEffort commits the deductions that succeed and reports the failed ones.
+	DeductMultiBestEffort DeductMultiMode = "best_effort"
+)
+
+// DeductInput is a single asset deduction of a multi-asset deduction.
+type DeductInput struct {
+	AssetDID    string
+	Amount      uint64
+	ReferenceID string
+}
+
+// DeductOutcome is the result of a single asset deduction.
+type DeductOutcome struct {
+	Input     DeductInput
+	Operation *models.CreditOperation
+	Err       error
+}
+
+// DeductCreditsMulti deducts credits from several assets of a license in a single transaction.
+// In atomic mode, the default, a failed deduction rolls back all of them and the error of the failed deduction is returned,
+// the outcomes of the other deductions are then MultiDeductRolledBackErr.
+// In best-effort mode each deduction runs in its own savepoint, so only the failed deductions are rolled back.
+// The returned outcomes are in the same order as the deductions.
+func (r *Repository) DeductCreditsMulti(ctx context.Context, licenseID, appName string, deductions []DeductInput, mode DeductMultiMode) ([]DeductOutcome, error) {
+	switch mode {
+	case "":
+		mode = DeductMultiAtomic
+	case DeductMultiAtomic, DeductMultiBestEffort:
+	default:
+		return nil, fmt.Errorf("invalid deduct mode: %s", mode)
+	}
+	outcomes, err := limitedTx(ctx, r, "DeductCreditsMulti", func(ctx context.Context) ([]DeductOutcome, error) {
+		return r.deductCreditsMultiInternal(ctx, licenseID, appName, deductions, mode)
+	})
+	for _, outcome := range outcomes {
+		r.observeDebtRejection(ctx, licenseID, outcome.Err)
+	}
+	return outcomes, err
+}
+
+// DeductCreditsBatch deducts credits from several assets of a license in a single transaction, in best-effort mode.
+// A failed deduction does not affect the others, see the outcome errors.
+func (r *Repository) DeductCreditsBatch(ctx context.Context, licenseID, appName string, deductions []DeductInput) ([]DeductOutcome, error) {
+	return r.DeductCreditsMulti(ctx, licenseID, appName, deductions, DeductMultiBestEffort)
+}
+
+// deductCreditsMultiInternal is the internal implementation of DeductCreditsMulti
+func (r *Repository) deductCreditsMultiInternal(ctx context.Context, licenseID, appName string, deductions []DeductInput, mode DeductMultiMode) ([]DeductOutcome, error) {
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("DeductCreditsMulti")()
+	defer rollbackTx(ctx, tx)
+
+	outcomes := make([]DeductOutcome, len(deductions))
+	var expired []*models.CreditOperation
+	for i, input := range deductions {
+		outcomes[i].Input = input
+		// grants are expired outside the savepoint, so a failed deduction does not roll back the expiration
+		assetExpired, err := r.expireGrants(ctx, tx, licenseID, input.AssetDID)
+		if err != nil {
+			return nil, err
+		}
+		expired = append(expired, assetExpired...)
+		if mode == DeductMultiAtomic {
+			outcomes[i].Operation, outcomes[i].Err = r.deductInputTx(ctx, tx, licenseID, appName, input)
+			if outcomes[i].Err != nil {
+				if IsRetryableError(outcomes[i].Err) {
+					// the whole transaction is retried on deadlock or serialization failure
+					return nil, outcomes[i].Err
+				}
+				return rolledBackOutcomes(outcomes, deductions, i), fmt.Errorf("failed to deduct credits for asset %s: %w", input.AssetDID, outcomes[i].Err)
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT "+deductCreditsSavepoint); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		outcomes[i].Operation, outcomes[i].Err = r.deductInputTx(ctx, tx, licenseID, appName, input)
+		if IsRetryableError(outcomes[i].Err) {
+			// the whole transaction is retried on deadlock or serialization failure
+			return nil, outcomes[i].Err
+		}
+		if outcomes[i].Err != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+deductCreditsSavepoint); err != nil {
+				return nil, fmt.Errorf("failed to rollback to savepoint: %w", err)
+			}
+		} else if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+deductCreditsSavepoint); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.logExpirations(ctx, expired)
+	for _, outcome := range outcomes {
+		if outcome.Operation != nil {
+			r.logOperation(ctx, outcome.Operation, "deducted credits")
+		}
+	}
+
+	return outcomes, nil
+}
+
+// deductInputTx deducts a single asset deduction within the transaction.
+func (r *Repository) deductInputTx(ctx context.Context, tx *sql.Tx, licenseID, appName string, input DeductInput) (*models.CreditOperation, error) {
+	if input.Amount > math.MaxInt64 {
+		return nil, fmt.Errorf("deduction amount is too large must be less than %d", math.MaxInt64)
+	}
+	return r.deductCreditsTx(ctx, tx, licenseID, input.AssetDID, int64(input.Amount), appName, input.ReferenceID, DefaultCreditType)
+}
+
+// rolledBackOutcomes marks every outcome except the failed one as rolled back.
+func rolledBackOutcomes(outcomes []DeductOutcome, deductions []DeductInput, failed int) []DeductOutcome {
+	for i := range outcomes {
+		outcomes[i].Input = deductions[i]
+		if i != failed {
+			outcomes[i].Operation = nil
+			outcomes[i].Err = MultiDeductRolledBackErr
+		}
+	}
+	return outcomes
+}
@@ -0,0 +1,120 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestDeductCreditsMulti(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	const (
+		affordableAsset   = "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:1"
+		unaffordableAsset = "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:2"
+	)
+	// setup gives the affordable asset 100 credits and the unaffordable asset 10 credits
+	setup := func(t *testing.T, licenseID string) {
+		t.Helper()
+		for assetDID, amount := range map[string]int64{affordableAsset: 100, unaffordableAsset: 10} {
+			grant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        assetDID,
+				InitialAmount:   amount,
+				RemainingAmount: amount,
+				Status:          GrantStatusConfirmed,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			}
+			require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		}
+	}
+	deductions := []DeductInput{
+		{AssetDID: affordableAsset, Amount: 40, ReferenceID: "multi-1"},
+		{AssetDID: unaffordableAsset, Amount: 50, ReferenceID: "multi-2"},
+	}
+
+	t.Run("atomic rolls back every deduction", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-deduct-multi-atomic"
+		setup(t, licenseID)
+
+		outcomes, err := repo.DeductCreditsMulti(ctx, licenseID, "app", deductions, DeductMultiAtomic)
+		require.ErrorIs(t, err, InsufficientCreditsErr)
+		require.Len(t, outcomes, 2)
+		assert.ErrorIs(t, outcomes[0].Err, MultiDeductRolledBackErr)
+		assert.Nil(t, outcomes[0].Operation)
+		assert.ErrorIs(t, outcomes[1].Err, InsufficientCreditsErr)
+
+		balance, err := repo.GetBalance(ctx, licenseID, affordableAsset)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance.Balance)
+		exists, err := models.CreditOperationExists(ctx, db, "app", "multi-1", OperationTypeDeduction)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("best effort commits the affordable deductions", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-deduct-multi-best-effort"
+		setup(t, licenseID)
+		bestEffort := []DeductInput{
+			{AssetDID: affordableAsset, Amount: 40, ReferenceID: "multi-best-effort-1"},
+			{AssetDID: unaffordableAsset, Amount: 50, ReferenceID: "multi-best-effort-2"},
+			{AssetDID: unaffordableAsset, Amount: 10, ReferenceID: "multi-best-effort-3"},
+		}
+
+		outcomes, err := repo.DeductCreditsMulti(ctx, licenseID, "app", bestEffort, DeductMultiBestEffort)
+		require.NoError(t, err)
+		require.Len(t, outcomes, 3)
+		require.NoError(t, outcomes[0].Err)
+		assert.Equal(t, int64(40), outcomes[0].Operation.TotalAmount)
+		assert.ErrorIs(t, outcomes[1].Err, InsufficientCreditsErr)
+		assert.Nil(t, outcomes[1].Operation)
+		require.NoError(t, outcomes[2].Err)
+
+		balance, err := repo.GetBalance(ctx, licenseID, affordableAsset)
+		require.NoError(t, err)
+		assert.Equal(t, int64(60), balance.Balance)
+		balance, err = repo.GetBalance(ctx, licenseID, unaffordableAsset)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), balance.Balance)
+	})
+
+	t.Run("batch keeps idempotency per reference", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-deduct-batch"
+		setup(t, licenseID)
+		batch := []DeductInput{{AssetDID: affordableAsset, Amount: 10, ReferenceID: "batch-1"}}
+		_, err := repo.DeductCreditsBatch(ctx, licenseID, "app", batch)
+		require.NoError(t, err)
+
+		outcomes, err := repo.DeductCreditsBatch(ctx, licenseID, "app", append(batch, DeductInput{AssetDID: affordableAsset, Amount: 10, ReferenceID: "batch-2"}))
+		require.NoError(t, err)
+		require.Len(t, outcomes, 2)
+		assert.True(t, IsDuplicateKeyError(outcomes[0].Err))
+		require.NoError(t, outcomes[1].Err)
+
+		balance, err := repo.GetBalance(ctx, licenseID, affordableAsset)
+		require.NoError(t, err)
+		assert.Equal(t, int64(80), balance.Balance)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.DeductCreditsMulti(ctx, "test-license-deduct-multi-invalid", "app", deductions, "some")
+		require.Error(t, err)
+	})
+}
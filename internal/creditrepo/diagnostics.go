@@ -0,0 +1,87 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+)
+
+// longRunningTransactionsQuery lists the open transactions of the current database and user that are older than $1,
+// along with the backends blocking them and the number of locks they are waiting for.
+const longRunningTransactionsQuery = `
+	SELECT
+		a.pid,
+		COALESCE(a.state, '') AS state,
+		a.xact_start,
+		EXTRACT(EPOCH FROM (NOW() - a.xact_start)) AS duration_seconds,
+		a.wait_event_type,
+		a.wait_event,
+		COALESCE(a.query, '') AS query,
+		COALESCE(pg_blocking_pids(a.pid), '{}') AS blocked_by,
+		(SELECT COUNT(*) FROM pg_locks l WHERE l.pid = a.pid AND NOT l.granted) AS waiting_locks
+	FROM pg_stat_activity a
+	WHERE a.datname = current_database()
+		AND a.usename = current_user
+		AND a.pid <> pg_backend_pid()
+		AND a.xact_start IS NOT NULL
+		AND NOW() - a.xact_start >= $1 * INTERVAL '1 second'
+	ORDER BY a.xact_start ASC
+`
+
+// TransactionDiagnostic describes an open database transaction.
+type TransactionDiagnostic struct {
+	// Backend process ID
+	PID int `json:"pid"`
+	// Backend state (active, idle in transaction, ...)
+	State string `json:"state"`
+	// When the transaction started
+	StartedAt time.Time `json:"startedAt"`
+	// How long the transaction has been open in seconds
+	DurationSeconds float64 `json:"durationSeconds"`
+	// Type of event the backend is waiting for, if any
+	WaitEventType *string `json:"waitEventType"`
+	// Event the backend is waiting for, if any
+	WaitEvent *string `json:"waitEvent"`
+	// Most recent query of the backend
+	Query string `json:"query"`
+	// Backends holding locks this transaction is waiting for
+	BlockedBy []int64 `json:"blockedBy"`
+	// Number of locks this transaction is waiting for
+	WaitingLocks int `json:"waitingLocks"`
+}
+
+// GetLongRunningTransactions returns the credit tracker transactions that have been open for at least minDuration,
+// including which backends are blocking them, for incident response.
+func (r *Repository) GetLongRunningTransactions(ctx context.Context, minDuration time.Duration) ([]*TransactionDiagnostic, error) {
+	rows, err := queries.Raw(longRunningTransactionsQuery, minDuration.Seconds()).QueryContext(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var diagnostics []*TransactionDiagnostic
+	for rows.Next() {
+		diagnostic := &TransactionDiagnostic{}
+		if err := rows.Scan(
+			&diagnostic.PID,
+			&diagnostic.State,
+			&diagnostic.StartedAt,
+			&diagnostic.DurationSeconds,
+			&diagnostic.WaitEventType,
+			&diagnostic.WaitEvent,
+			&diagnostic.Query,
+			(*pq.Int64Array)(&diagnostic.BlockedBy),
+			&diagnostic.WaitingLocks,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transactions: %w", err)
+	}
+	return diagnostics, nil
+}
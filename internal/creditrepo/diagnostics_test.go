@@ -0,0 +1,62 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestTransactionDiagnostics(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("deduction records transaction duration", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-diagnostics-duration"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+
+		before := histogramSampleCount(t, TransactionDuration.WithLabelValues("DeductCredits"))
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 1, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		after := histogramSampleCount(t, TransactionDuration.WithLabelValues("DeductCredits"))
+		assert.Greater(t, after, before)
+	})
+
+	t.Run("long running transaction is reported", func(t *testing.T) {
+		t.Parallel()
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback() //nolint:errcheck
+		_, err = tx.ExecContext(ctx, "SELECT 1")
+		require.NoError(t, err)
+		time.Sleep(200 * time.Millisecond)
+
+		diagnostics, err := repo.GetLongRunningTransactions(ctx, 100*time.Millisecond)
+		require.NoError(t, err)
+		require.NotEmpty(t, diagnostics)
+		for _, diagnostic := range diagnostics {
+			assert.GreaterOrEqual(t, diagnostic.DurationSeconds, 0.1)
+		}
+	})
+}
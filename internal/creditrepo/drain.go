@@ -0,0 +1,63 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// drainState tracks the operations running on the repository so that shutdown can wait for them before closing the database.
+type drainState struct {
+	mu       sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+	// abort is cancelled when the drain times out, cancelling the operations still running
+	abort       context.Context
+	cancelAbort context.CancelFunc
+}
+
+func newDrainState() *drainState {
+	abort, cancel := context.WithCancel(context.Background())
+	return &drainState{abort: abort, cancelAbort: cancel}
+}
+
+// begin registers an operation and returns its context, cancelled if the drain times out, and the function ending it.
+// It fails with ShuttingDownErr once the repository is draining.
+func (d *drainState) begin(ctx context.Context) (context.Context, func(), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return nil, nil, ShuttingDownErr
+	}
+	d.inFlight.Add(1)
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(d.abort, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		d.inFlight.Done()
+	}, nil
+}
+
+// Drain stops the repository from starting new operations, which fail with ShuttingDownErr, and waits for the running ones.
+// When the context is done first, the running operations are cancelled, so that their transactions roll back instead of
+// being cut off when the database is closed, and Drain returns once they did with an error wrapping the context error.
+func (r *Repository) Drain(ctx context.Context) error {
+	r.drain.mu.Lock()
+	r.drain.draining = true
+	r.drain.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.drain.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.drain.cancelAbort()
+		<-done
+		return fmt.Errorf("cancelled the operations still running: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,160 @@
+package creditrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	t.Run("waits for running operations", func(t *testing.T) {
+		t.Parallel()
+		repo := New(nil)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		result := make(chan error, 1)
+		go func() {
+			_, err := retryTx(context.Background(), repo, "TestDrain", func(ctx context.Context) (int, error) {
+				close(started)
+				<-release
+				return 1, ctx.Err()
+			})
+			result <- err
+		}()
+		<-started
+
+		drained := make(chan error, 1)
+		go func() {
+			drained <- repo.Drain(context.Background())
+		}()
+
+		// Verify: New operations are rejected while the running one finishes
+		require.Eventually(t, func() bool {
+			_, err := retryTx(context.Background(), repo, "TestDrain", func(context.Context) (int, error) {
+				return 0, nil
+			})
+			return errors.Is(err, ShuttingDownErr)
+		}, time.Second, time.Millisecond)
+		assert.Empty(t, drained)
+
+		close(release)
+		require.NoError(t, <-result)
+		require.NoError(t, <-drained)
+	})
+
+	t.Run("cancels operations still running after the timeout", func(t *testing.T) {
+		t.Parallel()
+		repo := New(nil)
+		started := make(chan struct{})
+		result := make(chan error, 1)
+		go func() {
+			_, err := retryTx(context.Background(), repo, "TestDrain", func(ctx context.Context) (int, error) {
+				close(started)
+				<-ctx.Done()
+				return 0, ctx.Err()
+			})
+			result <- err
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := repo.Drain(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorIs(t, <-result, context.Canceled)
+	})
+
+	t.Run("without running operations", func(t *testing.T) {
+		t.Parallel()
+		repo := New(nil)
+		require.NoError(t, repo.Drain(context.Background()))
+		_, err := repo.DeductCredits(context.Background(), "license", "asset", 10, "app", "ref-1")
+		require.ErrorIs(t, err, ShuttingDownErr)
+	})
+}
+
+func TestDrainDeduction(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+	ctx := context.Background()
+
+	// slowDeduction starts a deduction that waits on a row lock of the asset's grant and returns the transaction holding the lock.
+	slowDeduction := func(t *testing.T, repo *Repository, licenseID, referenceID string) (chan error, func()) {
+		t.Helper()
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0x"+licenseID, 0, 100, time.Now())
+		require.NoError(t, err)
+		tx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		_, err = models.CreditGrants(
+			models.CreditGrantWhere.LicenseID.EQ(licenseID),
+			qm.For("UPDATE"),
+		).All(ctx, tx)
+		require.NoError(t, err)
+
+		result := make(chan error, 1)
+		go func() {
+			_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 40, testAPIEndpoint, referenceID)
+			result <- err
+		}()
+		// give the deduction time to block on the lock
+		time.Sleep(100 * time.Millisecond)
+		return result, func() { _ = tx.Rollback() }
+	}
+
+	t.Run("deduction finishing within the timeout commits", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db)
+		licenseID := "test-license-drain-commit"
+		result, release := slowDeduction(t, repo, licenseID, "drain-commit")
+
+		drained := make(chan error, 1)
+		go func() {
+			drained <- repo.Drain(context.Background())
+		}()
+		release()
+		require.NoError(t, <-result)
+		require.NoError(t, <-drained)
+
+		exists, err := models.CreditOperationExists(ctx, db, testAPIEndpoint, "drain-commit", OperationTypeDeduction)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		grants, err := models.CreditGrants(models.CreditGrantWhere.LicenseID.EQ(licenseID)).All(ctx, db)
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		assert.Equal(t, int64(60), grants[0].RemainingAmount)
+	})
+
+	t.Run("deduction still running after the timeout rolls back", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db)
+		licenseID := "test-license-drain-rollback"
+		result, release := slowDeduction(t, repo, licenseID, "drain-rollback")
+		defer release()
+
+		drainCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, repo.Drain(drainCtx), context.DeadlineExceeded)
+		require.Error(t, <-result)
+
+		// Verify: Neither the operation nor the debit of the grant was written
+		release()
+		exists, err := models.CreditOperationExists(ctx, db, testAPIEndpoint, "drain-rollback", OperationTypeDeduction)
+		require.NoError(t, err)
+		assert.False(t, exists)
+		grants, err := models.CreditGrants(models.CreditGrantWhere.LicenseID.EQ(licenseID)).All(ctx, db)
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		assert.Equal(t, int64(100), grants[0].RemainingAmount)
+	})
+}
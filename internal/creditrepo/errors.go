@@ -2,6 +2,7 @@ package creditrepo
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/lib/pq"
 )
@@ -11,6 +12,13 @@ const (
 	DuplicateKeyError = pq.ErrorCode("23505")
 	// DeadlockError is returned when a deadlock error occurs.
 	DeadlockError = pq.ErrorCode("40P01")
+	// SerializationFailureError is returned when a serializable transaction conflicts with a concurrent one.
+	SerializationFailureError = pq.ErrorCode("40001")
+	// CheckViolationError is returned when a row violates a check constraint.
+	CheckViolationError = pq.ErrorCode("23514")
+
+	// grantRemainingAmountCheck is the check constraint that keeps the remaining amount of a grant non-negative.
+	grantRemainingAmountCheck = "credit_grants_remaining_amount_check"
 )
 const (
 	// InsufficientCreditsErr is returned when the credit balance is insufficient to perform the operation.
@@ -18,8 +26,98 @@ const (
 
 	// GrantAlreadyExistsErr is returned when a grant already exists for the given license and asset.
 	GrantAlreadyExistsErr = constError("active grant already exists for the given license and asset")
+
+	// ConfirmConflictErr is returned when a chain event was already confirmed with a different license, asset, or amount.
+	ConfirmConflictErr = constError("chain event already confirmed with different grant details")
+
+	// GrantAlreadyConfirmedErr is returned when a chain event is confirmed again with the same grant details.
+	GrantAlreadyConfirmedErr = constError("chain event already confirmed")
+
+	// MultiDeductRolledBackErr is the outcome of a deduction that was rolled back because another deduction of the same atomic batch failed.
+	MultiDeductRolledBackErr = constError("deduction rolled back because another deduction failed")
+
+	// OperationGrantMismatchErr is returned when the grant rows of an operation do not add up to its total amount.
+	OperationGrantMismatchErr = constError("operation grant amounts do not match the operation total")
+
+	// RefundExceedsCapacityErr is returned when a refund would raise a grant's remaining amount above its initial amount.
+	RefundExceedsCapacityErr = constError("refund exceeds grant capacity")
+
+	// RefundExceedsDeductionErr is returned when a partial refund is larger than the deduction it refunds.
+	RefundExceedsDeductionErr = constError("refund exceeds the deducted amount")
+
+	// RefundUnavailableErr is returned when refunding a deduction whose grants were deleted by the expired grant cleanup,
+	// so there is nothing left to return the credits to.
+	RefundUnavailableErr = constError("deduction can no longer be refunded")
+
+	// PendingGrantMismatchErr is returned when a confirmation's tx hash matches a pending grant of a different license or asset.
+	PendingGrantMismatchErr = constError("confirmation does not match the license and asset of the pending grant")
+
+	// GrantAmountMismatchErr is returned when a confirmation's amount differs from the amount of the pending grant with its tx hash.
+	GrantAmountMismatchErr = constError("confirmation does not match the amount of the pending grant")
+
+	// GrantNotPendingErr is returned when failing a grant that is no longer pending.
+	GrantNotPendingErr = constError("grant is not pending")
+
+	// DuplicateOperationErr is returned when a deduction or refund reuses the app name and reference ID of an earlier one.
+	// Nothing is charged or refunded, so the caller can treat it as the earlier operation having succeeded.
+	DuplicateOperationErr = constError("operation already exists")
+
+	// AmbiguousReferenceErr is returned when refunding by reference ID alone and deductions of several apps share the reference ID.
+	AmbiguousReferenceErr = constError("reference ID matches deductions of several apps")
+
+	// GrantNotFoundErr is returned when no grant has the tx hash being looked up.
+	GrantNotFoundErr = constError("grant not found")
+
+	// OperationNotFoundErr is returned when no operation has the reference ID being looked up, or a refund has no deduction to refund.
+	OperationNotFoundErr = constError("operation not found")
+
+	// InvalidCursorErr is returned when a page cursor was not returned by the query it is passed to.
+	InvalidCursorErr = constError("invalid cursor")
+
+	// AmbiguousGrantErr is returned when looking up a grant by tx hash and several confirmed grants share the tx hash,
+	// as when one transaction emitted several burn events.
+	AmbiguousGrantErr = constError("tx hash matches several confirmed grants")
+
+	// ConcurrencyLimitErr is returned when a deduction or refund is shed because too many are already running.
+	ConcurrencyLimitErr = constError("too many concurrent deductions and refunds")
+
+	// NegativeGrantAmountErr is returned when a debit would leave a grant with a negative remaining amount.
+	// Debits are capped at the remaining amount of each grant, so it always points to a bug and the operation is rolled back.
+	NegativeGrantAmountErr = constError("grant remaining amount would be negative")
+
+	// OutstandingDebtErr is returned when credits are spent from an asset with outstanding debt from failed grants.
+	OutstandingDebtErr = constError("cannot use credits, while there is outstanding debt")
+
+	// ShuttingDownErr is returned when an operation is started after the repository began draining for shutdown.
+	ShuttingDownErr = constError("repository is shutting down")
 )
 
+// InsufficientCreditsError is returned when a deduction requires more credits than are available.
+// It matches InsufficientCreditsErr with errors.Is.
+type InsufficientCreditsError struct {
+	Available int64
+	Required  int64
+	Shortfall int64
+}
+
+// NewInsufficientCreditsError creates an InsufficientCreditsError with the shortfall between the required and available credits.
+func NewInsufficientCreditsError(available, required int64) *InsufficientCreditsError {
+	return &InsufficientCreditsError{
+		Available: available,
+		Required:  required,
+		Shortfall: required - available,
+	}
+}
+
+func (e *InsufficientCreditsError) Error() string {
+	return fmt.Sprintf("%s. Current: %d, Required: %d", InsufficientCreditsErr, e.Available, e.Required)
+}
+
+// Is reports whether the target is InsufficientCreditsErr.
+func (e *InsufficientCreditsError) Is(target error) bool {
+	return target == InsufficientCreditsErr
+}
+
 type constError string
 
 func (e constError) Error() string {
@@ -32,8 +130,26 @@ func IsDuplicateKeyError(err error) bool {
 	return errors.As(err, &pqErr) && pqErr.Code == DuplicateKeyError
 }
 
+// IsNegativeGrantAmountError checks if the error is a violation of the check constraint on the remaining amount of a grant.
+func IsNegativeGrantAmountError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == CheckViolationError && pqErr.Constraint == grantRemainingAmountCheck
+}
+
 // IsDeadlockError checks if the error is a deadlock error.
 func IsDeadlockError(err error) bool {
 	var pqErr *pq.Error
 	return errors.As(err, &pqErr) && pqErr.Code == DeadlockError
 }
+
+// IsSerializationFailure checks if the error is a serialization failure of a serializable transaction.
+func IsSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == SerializationFailureError
+}
+
+// IsRetryableError checks if the error is a deadlock or a serialization failure,
+// which roll back the transaction and succeed when the whole transaction is attempted again.
+func IsRetryableError(err error) bool {
+	return IsDeadlockError(err) || IsSerializationFailure(err)
+}
@@ -0,0 +1,22 @@
+package creditrepo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsufficientCreditsError(t *testing.T) {
+	err := fmt.Errorf("failed to deduct: %w", NewInsufficientCreditsError(25, 100))
+	require.ErrorIs(t, err, InsufficientCreditsErr)
+
+	var insufficientErr *InsufficientCreditsError
+	require.True(t, errors.As(err, &insufficientErr))
+	assert.Equal(t, int64(25), insufficientErr.Available)
+	assert.Equal(t, int64(100), insufficientErr.Required)
+	assert.Equal(t, int64(75), insufficientErr.Shortfall)
+	assert.Contains(t, err.Error(), "insufficient credits")
+}
@@ -0,0 +1,103 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/google/uuid"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// WithLazyExpiration sets whether balance and deduction transactions first expire the grants of the license and asset
+// whose expiration passed. Expired grants get the expired status and their unused credits are recorded as an expiration operation.
+func WithLazyExpiration(enabled bool) Option {
+	return func(r *Repository) {
+		r.lazyExpiration = enabled
+	}
+}
+
+// expireGrants transitions the confirmed and pending grants of the license and asset whose expiration passed to expired.
+// Each expired grant gets an expiration operation, referencing the grant, for its unused credits.
+// It returns the expiration operations, which the caller logs once the transaction commits.
+func (r *Repository) expireGrants(ctx context.Context, tx *sql.Tx, licenseID, assetDID string) ([]*models.CreditOperation, error) {
+	if !r.lazyExpiration {
+		return nil, nil
+	}
+	now := time.Now()
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.LicenseID.EQ(licenseID),
+		models.CreditGrantWhere.AssetDid.EQ(assetDID),
+		models.CreditGrantWhere.Status.IN([]string{GrantStatusConfirmed, GrantStatusPending}),
+		models.CreditGrantWhere.ExpiresAt.LTE(null.TimeFrom(now)),
+		qm.OrderBy(models.CreditGrantColumns.ExpiresAt+" ASC, "+models.CreditGrantColumns.ID+" ASC"),
+		qm.For("UPDATE"),
+	).All(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired grants: %w", err)
+	}
+	if len(grants) == 0 {
+		return nil, nil
+	}
+
+	var operations []*models.CreditOperation
+	for _, grant := range grants {
+		lost := grant.RemainingAmount
+		grant.Status = GrantStatusExpired
+		grant.RemainingAmount = 0
+		grant.UpdatedAt = null.TimeFrom(now)
+		columns := append(r.grantAmountColumns(grant), models.CreditGrantColumns.Status)
+		if _, err := grant.Update(ctx, tx, boil.Whitelist(columns...)); err != nil {
+			return nil, fmt.Errorf("failed to expire grant %s: %w", grant.ID, err)
+		}
+		if lost == 0 {
+			continue
+		}
+
+		operation := &models.CreditOperation{
+			LicenseID:     licenseID,
+			AssetDid:      assetDID,
+			OperationType: OperationTypeExpiration,
+			TotalAmount:   lost,
+			AppName:       "credit_tracker",
+			ReferenceID:   grant.ID,
+			CreatedAt:     null.TimeFrom(now),
+			CreditType:    grant.CreditType,
+		}
+		if err := operation.Insert(ctx, tx, boil.Infer()); err != nil {
+			return nil, fmt.Errorf("failed to create expiration operation: %w", err)
+		}
+		opGrant := &models.CreditOperationGrant{
+			ID:            uuid.New().String(),
+			AppName:       operation.AppName,
+			ReferenceID:   operation.ReferenceID,
+			OperationType: operation.OperationType,
+			GrantID:       grant.ID,
+			AmountUsed:    -lost,
+			CreatedAt:     null.TimeFrom(now),
+		}
+		if err := opGrant.Insert(ctx, tx, boil.Infer()); err != nil {
+			return nil, fmt.Errorf("failed to record expired grant: %w", err)
+		}
+		if err := r.recordOperationBalance(ctx, tx, operation); err != nil {
+			return nil, err
+		}
+		operations = append(operations, operation)
+	}
+
+	if err := r.updateBalanceSummary(ctx, tx, licenseID, assetDID); err != nil {
+		return nil, err
+	}
+	return operations, nil
+}
+
+// logExpirations logs the committed expiration operations returned by expireGrants.
+func (r *Repository) logExpirations(ctx context.Context, operations []*models.CreditOperation) {
+	for _, operation := range operations {
+		r.logOperation(ctx, operation, "expired grant")
+	}
+}
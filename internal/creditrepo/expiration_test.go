@@ -0,0 +1,135 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestLazyExpiration(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	ctx := context.Background()
+
+	// setup creates a grant that expired since the last operation and an active grant
+	setup := func(t *testing.T, licenseID string) (*models.CreditGrant, *models.CreditGrant) {
+		t.Helper()
+		expired := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   100,
+			RemainingAmount: 70,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(-time.Minute)),
+		}
+		require.NoError(t, expired.Insert(ctx, db, boil.Infer()))
+		active := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   100,
+			RemainingAmount: 100,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, active.Insert(ctx, db, boil.Infer()))
+		return expired, active
+	}
+
+	t.Run("balance read expires the grant", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db, WithLazyExpiration(true))
+		licenseID := "test-license-lazy-expiration-balance"
+		expired, _ := setup(t, licenseID)
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance.Balance)
+
+		require.NoError(t, expired.Reload(ctx, db))
+		assert.Equal(t, GrantStatusExpired, expired.Status)
+		assert.Zero(t, expired.RemainingAmount)
+		operation, err := models.FindCreditOperation(ctx, db, "credit_tracker", expired.ID, OperationTypeExpiration)
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), operation.TotalAmount)
+
+		// a second read has nothing left to expire
+		balance, err = repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance.Balance)
+		count, err := models.CreditOperations(models.CreditOperationWhere.LicenseID.EQ(licenseID)).Count(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("deduction expires the grant", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db, WithLazyExpiration(true))
+		licenseID := "test-license-lazy-expiration-deduct"
+		expired, active := setup(t, licenseID)
+
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 30, "app", "lazy-expiration-ref")
+		require.NoError(t, err)
+
+		require.NoError(t, expired.Reload(ctx, db))
+		assert.Equal(t, GrantStatusExpired, expired.Status)
+		require.NoError(t, active.Reload(ctx, db))
+		assert.Equal(t, int64(70), active.RemainingAmount)
+	})
+
+	t.Run("rolled back expiration is not published", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db, WithLazyExpiration(true))
+		licenseID := "test-license-lazy-expiration-rollback"
+		expired, _ := setup(t, licenseID)
+		operations, unsubscribe := repo.SubscribeOperations(licenseID)
+		defer unsubscribe()
+
+		// Test: A deduction past the balance fails and rolls back the expiration it made
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 500, "app", "lazy-expiration-rollback-ref")
+		require.ErrorIs(t, err, InsufficientCreditsErr)
+
+		// Verify: The grant is not expired and the subscriber got nothing
+		require.NoError(t, expired.Reload(ctx, db))
+		assert.Equal(t, GrantStatusConfirmed, expired.Status)
+		select {
+		case operation := <-operations:
+			t.Fatalf("unexpected %s operation of a rolled back transaction", operation.OperationType)
+		default:
+		}
+
+		// Verify: The committed expiration of a balance read is published
+		_, err = repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		select {
+		case operation := <-operations:
+			assert.Equal(t, OperationTypeExpiration, operation.OperationType)
+			assert.Equal(t, expired.ID, operation.ReferenceID)
+		default:
+			t.Fatal("expected the expiration operation")
+		}
+	})
+
+	t.Run("disabled leaves the grant", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db)
+		licenseID := "test-license-lazy-expiration-disabled"
+		expired, _ := setup(t, licenseID)
+
+		_, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+
+		require.NoError(t, expired.Reload(ctx, db))
+		assert.Equal(t, GrantStatusConfirmed, expired.Status)
+		assert.Equal(t, int64(70), expired.RemainingAmount)
+	})
+}
@@ -0,0 +1,182 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// FIFOViolation is a deduction that drew from a grant while a grant that expires earlier still had capacity.
+type FIFOViolation struct {
+	// App name of the deduction
+	AppName string `json:"appName"`
+	// Reference ID of the deduction
+	ReferenceID string `json:"referenceId"`
+	// When the deduction was made
+	DeductedAt time.Time `json:"deductedAt"`
+	// Grant the deduction drew from out of order
+	GrantID string `json:"grantId"`
+	// Earlier expiring grant that should have been used first
+	SkippedGrantID string `json:"skippedGrantId"`
+	// Credits left on the skipped grant after the deduction
+	SkippedCapacity int64 `json:"skippedCapacity"`
+}
+
+// AuditFIFOCompliance replays the grant allocations of a license and asset and returns the deductions made during the time period
+// that drew from a grant while a grant that expires earlier still had capacity after the deduction.
+// Summary-only deductions have no allocations to replay so they are not audited, and the capacity they used is not accounted for.
+func (r *Repository) AuditFIFOCompliance(ctx context.Context, licenseID, assetDID string, fromDate time.Time, toDate time.Time) ([]*FIFOViolation, error) {
+	if fromDate.IsZero() || licenseID == "" || assetDID == "" {
+		return nil, fmt.Errorf("fromDate, licenseID, and assetDID are required")
+	}
+	if !toDate.IsZero() && fromDate.After(toDate) {
+		return nil, fmt.Errorf("fromDate must be before toDate")
+	}
+
+	opMods := []qm.QueryMod{
+		models.CreditOperationWhere.LicenseID.EQ(licenseID),
+		models.CreditOperationWhere.AssetDid.EQ(assetDID),
+		models.CreditOperationWhere.OperationType.EQ(OperationTypeDeduction),
+		models.CreditOperationWhere.SummaryOnly.EQ(false),
+		models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(fromDate)),
+		qm.OrderBy(models.CreditOperationColumns.CreatedAt + " ASC"),
+	}
+	if !toDate.IsZero() {
+		opMods = append(opMods, models.CreditOperationWhere.CreatedAt.LTE(null.TimeFrom(toDate)))
+	}
+	deductions, err := models.CreditOperations(opMods...).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deductions: %w", err)
+	}
+	if len(deductions) == 0 {
+		return nil, nil
+	}
+
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.LicenseID.EQ(licenseID),
+		models.CreditGrantWhere.AssetDid.EQ(assetDID),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grants: %w", err)
+	}
+	grantIDs := make([]string, len(grants))
+	for i, grant := range grants {
+		grantIDs[i] = grant.ID
+	}
+
+	// allocations after the last deduction can not affect the replay
+	allocations, err := models.CreditOperationGrants(
+		models.CreditOperationGrantWhere.GrantID.IN(grantIDs),
+		models.CreditOperationGrantWhere.CreatedAt.LTE(null.TimeFrom(deductions[len(deductions)-1].CreatedAt.Time)),
+		qm.OrderBy(models.CreditOperationGrantColumns.CreatedAt+" ASC"),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grant allocations: %w", err)
+	}
+
+	return auditFIFO(deductions, grants, allocations), nil
+}
+
+// auditFIFO replays the allocations in time order and checks every deduction against the grant balances just before it.
+// Allocations must be ordered by creation time.
+func auditFIFO(deductions []*models.CreditOperation, grants []*models.CreditGrant, allocations []*models.CreditOperationGrant) []*FIFOViolation {
+	grantsByID := make(map[string]*models.CreditGrant, len(grants))
+	for _, grant := range grants {
+		grantsByID[grant.ID] = grant
+	}
+	fifoGrants := slices.Clone(grants)
+	slices.SortFunc(fifoGrants, CompareGrantFIFO)
+
+	// remaining is the replayed balance of each grant, a grant starts with its initial amount
+	remaining := make(map[string]int64, len(grants))
+	for _, grant := range grants {
+		remaining[grant.ID] = grant.InitialAmount
+	}
+
+	// the deduction rows are recorded just after their operation so they are looked up by operation instead of time
+	deductionAllocations := make(map[string][]*models.CreditOperationGrant)
+	for _, allocation := range allocations {
+		if allocation.OperationType == OperationTypeDeduction {
+			key := allocation.AppName + "/" + allocation.ReferenceID
+			deductionAllocations[key] = append(deductionAllocations[key], allocation)
+		}
+	}
+	applied := make(map[string]bool, len(allocations))
+
+	var violations []*FIFOViolation
+	next := 0
+	for _, deduction := range deductions {
+		// apply the allocations of every operation made before the deduction
+		for ; next < len(allocations) && allocations[next].CreatedAt.Time.Before(deduction.CreatedAt.Time); next++ {
+			allocation := allocations[next]
+			if !applied[allocation.ID] {
+				remaining[allocation.GrantID] += allocationDelta(allocation, grantsByID[allocation.GrantID])
+				applied[allocation.ID] = true
+			}
+		}
+
+		own := deductionAllocations[deduction.AppName+"/"+deduction.ReferenceID]
+		for _, allocation := range own {
+			remaining[allocation.GrantID] += allocation.AmountUsed
+			applied[allocation.ID] = true
+		}
+
+		for _, allocation := range own {
+			grant := grantsByID[allocation.GrantID]
+			if grant == nil {
+				continue
+			}
+			for _, earlier := range fifoGrants {
+				if CompareGrantFIFO(earlier, grant) >= 0 {
+					break
+				}
+				if !wasSpendable(earlier, deduction.CreatedAt.Time) || remaining[earlier.ID] <= 0 {
+					continue
+				}
+				violations = append(violations, &FIFOViolation{
+					AppName:         deduction.AppName,
+					ReferenceID:     deduction.ReferenceID,
+					DeductedAt:      deduction.CreatedAt.Time,
+					GrantID:         grant.ID,
+					SkippedGrantID:  earlier.ID,
+					SkippedCapacity: remaining[earlier.ID],
+				})
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// allocationDelta is the change an allocation made to the remaining amount of its grant.
+// Purchase and confirmation rows record the initial amount, which the replay starts from.
+func allocationDelta(allocation *models.CreditOperationGrant, grant *models.CreditGrant) int64 {
+	switch allocation.OperationType {
+	case OperationTypeDeduction, OperationTypeRefund, OperationTypeExpiration:
+		return allocation.AmountUsed
+	case OperationTypeTransfer:
+		// the source rows take credits off, the destination row records the initial amount of the new grant
+		return min(allocation.AmountUsed, 0)
+	case OperationTypeDebtSettlement:
+		// settlements move credits from active grants to the failed grants in debt
+		if grant != nil && grant.Status == GrantStatusFailed {
+			return allocation.AmountUsed
+		}
+		return -allocation.AmountUsed
+	default:
+		return 0
+	}
+}
+
+// wasSpendable returns whether deductions at the given time could use the grant.
+func wasSpendable(grant *models.CreditGrant, at time.Time) bool {
+	if grant.Status == GrantStatusFailed || (grant.ExpiresAt.Valid && !grant.ExpiresAt.Time.After(at)) {
+		return false
+	}
+	return !grant.CreatedAt.Valid || !grant.CreatedAt.Time.After(at)
+}
@@ -0,0 +1,191 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestAuditFIFO(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newGrant := func(id string, amount int64, expiresIn time.Duration) *models.CreditGrant {
+		return &models.CreditGrant{
+			ID:            id,
+			InitialAmount: amount,
+			Status:        GrantStatusConfirmed,
+			ExpiresAt:     null.TimeFrom(start.Add(expiresIn)),
+			CreatedAt:     null.TimeFrom(start),
+		}
+	}
+	newDeduction := func(referenceID string, at time.Duration) *models.CreditOperation {
+		return &models.CreditOperation{
+			AppName:       testAPIEndpoint,
+			ReferenceID:   referenceID,
+			OperationType: OperationTypeDeduction,
+			CreatedAt:     null.TimeFrom(start.Add(at)),
+		}
+	}
+	newAllocation := func(operationType, referenceID, grantID string, amount int64, at time.Duration) *models.CreditOperationGrant {
+		return &models.CreditOperationGrant{
+			ID:            uuid.NewString(),
+			AppName:       testAPIEndpoint,
+			ReferenceID:   referenceID,
+			OperationType: operationType,
+			GrantID:       grantID,
+			AmountUsed:    amount,
+			// rows are recorded just after their operation
+			CreatedAt: null.TimeFrom(start.Add(at + time.Millisecond)),
+		}
+	}
+	grants := []*models.CreditGrant{
+		newGrant("later", 100, 48*time.Hour),
+		newGrant("earlier", 100, 24*time.Hour),
+	}
+
+	t.Run("compliant ledger", func(t *testing.T) {
+		deductions := []*models.CreditOperation{
+			newDeduction("first", time.Minute),
+			newDeduction("second", 2*time.Minute),
+			newDeduction("third", 4*time.Minute),
+		}
+		allocations := []*models.CreditOperationGrant{
+			newAllocation(OperationTypeDeduction, "first", "earlier", -60, time.Minute),
+			newAllocation(OperationTypeDeduction, "second", "earlier", -40, 2*time.Minute),
+			newAllocation(OperationTypeDeduction, "second", "later", -10, 2*time.Minute),
+			// the earlier grant is fully refunded then used again before the later grant
+			newAllocation(OperationTypeRefund, "first", "earlier", 60, 3*time.Minute),
+			newAllocation(OperationTypeDeduction, "third", "earlier", -60, 4*time.Minute),
+		}
+		assert.Empty(t, auditFIFO(deductions, grants, allocations))
+	})
+
+	t.Run("later grant used while earlier grant had capacity", func(t *testing.T) {
+		deductions := []*models.CreditOperation{
+			newDeduction("first", time.Minute),
+			newDeduction("second", 2*time.Minute),
+		}
+		allocations := []*models.CreditOperationGrant{
+			newAllocation(OperationTypeDeduction, "first", "earlier", -60, time.Minute),
+			newAllocation(OperationTypeDeduction, "second", "later", -10, 2*time.Minute),
+		}
+		violations := auditFIFO(deductions, grants, allocations)
+		require.Len(t, violations, 1)
+		assert.Equal(t, "second", violations[0].ReferenceID)
+		assert.Equal(t, "later", violations[0].GrantID)
+		assert.Equal(t, "earlier", violations[0].SkippedGrantID)
+		assert.Equal(t, int64(40), violations[0].SkippedCapacity)
+	})
+
+	t.Run("earlier grant expired", func(t *testing.T) {
+		deductions := []*models.CreditOperation{
+			newDeduction("first", 25*time.Hour),
+		}
+		allocations := []*models.CreditOperationGrant{
+			newAllocation(OperationTypeDeduction, "first", "later", -10, 25*time.Hour),
+		}
+		assert.Empty(t, auditFIFO(deductions, grants, allocations))
+	})
+}
+
+func TestAuditFIFOCompliance(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	insertGrants := func(t *testing.T, licenseID string) (*models.CreditGrant, *models.CreditGrant) {
+		t.Helper()
+		earlier := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   100,
+			RemainingAmount: 100,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, earlier.Insert(ctx, db, boil.Infer()))
+		later := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(48 * time.Hour)),
+		}
+		require.NoError(t, later.Insert(ctx, db, boil.Infer()))
+		return earlier, later
+	}
+
+	t.Run("compliant ledger", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-fifo-audit"
+		fromDate := time.Now().Add(-time.Hour)
+		insertGrants(t, licenseID)
+
+		referenceID := uuid.NewString()
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 60, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 80, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 30, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+
+		violations, err := repo.AuditFIFOCompliance(ctx, licenseID, testAssetID, fromDate, time.Time{})
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("non-compliant ledger", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-fifo-audit-violation"
+		fromDate := time.Now().Add(-time.Hour)
+		earlier, later := insertGrants(t, licenseID)
+
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 60, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+
+		// Setup: Record a deduction that skipped the earlier grant
+		referenceID := uuid.NewString()
+		operation := &models.CreditOperation{
+			LicenseID:     licenseID,
+			AssetDid:      testAssetID,
+			OperationType: OperationTypeDeduction,
+			TotalAmount:   10,
+			AppName:       testAPIEndpoint,
+			ReferenceID:   referenceID,
+			CreatedAt:     null.TimeFrom(time.Now()),
+		}
+		require.NoError(t, operation.Insert(ctx, db, boil.Infer()))
+		opGrant := &models.CreditOperationGrant{
+			ID:            uuid.NewString(),
+			AppName:       testAPIEndpoint,
+			ReferenceID:   referenceID,
+			OperationType: OperationTypeDeduction,
+			GrantID:       later.ID,
+			AmountUsed:    -10,
+			CreatedAt:     null.TimeFrom(time.Now()),
+		}
+		require.NoError(t, opGrant.Insert(ctx, db, boil.Infer()))
+
+		violations, err := repo.AuditFIFOCompliance(ctx, licenseID, testAssetID, fromDate, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, violations, 1)
+		assert.Equal(t, referenceID, violations[0].ReferenceID)
+		assert.Equal(t, later.ID, violations[0].GrantID)
+		assert.Equal(t, earlier.ID, violations[0].SkippedGrantID)
+		assert.Equal(t, int64(40), violations[0].SkippedCapacity)
+	})
+}
@@ -0,0 +1,29 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/rs/zerolog"
+)
+
+// guardGrantDebit fails a debit that would leave the grant with a negative remaining amount.
+// The grant is not changed, and the failure is logged at error level since it can only be caused by a bug.
+func guardGrantDebit(ctx context.Context, grant *models.CreditGrant, debit int64) error {
+	if debit >= 0 && grant.RemainingAmount-debit >= 0 {
+		return nil
+	}
+	zerolog.Ctx(ctx).Error().Str("grantId", grant.ID).Str("licenseId", grant.LicenseID).Str("assetDid", grant.AssetDid).
+		Int64("remainingAmount", grant.RemainingAmount).Int64("debit", debit).
+		Msg("refusing debit that would make the grant remaining amount negative")
+	return fmt.Errorf("%w: grant %s has %d remaining, debit of %d", NegativeGrantAmountErr, grant.ID, grant.RemainingAmount, debit)
+}
+
+// grantUpdateError wraps the error of updating a grant, matching NegativeGrantAmountErr if the database refused a negative remaining amount.
+func grantUpdateError(grant *models.CreditGrant, err error) error {
+	if IsNegativeGrantAmountError(err) {
+		return fmt.Errorf("%w: grant %s: %w", NegativeGrantAmountErr, grant.TXHash, err)
+	}
+	return fmt.Errorf("failed to update grant %s: %w", grant.TXHash, err)
+}
@@ -0,0 +1,69 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGuardGrantDebit(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	grant := &models.CreditGrant{ID: "grant", RemainingAmount: 100}
+	require.NoError(t, guardGrantDebit(ctx, grant, 0))
+	require.NoError(t, guardGrantDebit(ctx, grant, 100))
+
+	// Test: Debits larger than the remaining amount are refused and leave the grant as it was
+	err := guardGrantDebit(ctx, grant, 101)
+	require.ErrorIs(t, err, NegativeGrantAmountErr)
+	assert.Equal(t, int64(100), grant.RemainingAmount)
+
+	// Test: A negative debit taken from an already negative grant is refused too
+	err = guardGrantDebit(ctx, &models.CreditGrant{ID: "negative", RemainingAmount: -10}, -10)
+	require.ErrorIs(t, err, NegativeGrantAmountErr)
+}
+
+func TestGrantRemainingAmountConstraint(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+	licenseID := "test-license-negative-grant"
+
+	_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xnegative-grant", 0, 100, time.Now())
+	require.NoError(t, err)
+	grant, err := repo.GetGrantByTxHash(ctx, "0xnegative-grant")
+	require.NoError(t, err)
+
+	// Test: Over-deduct the grant directly, bypassing the guard
+	grant.RemainingAmount -= 150
+	grant.UpdatedAt = null.TimeFrom(time.Now())
+	_, err = grant.Update(ctx, db, boil.Whitelist(models.CreditGrantColumns.RemainingAmount, models.CreditGrantColumns.UpdatedAt))
+
+	// Verify: The database refuses the negative amount and the error is recognized
+	require.Error(t, err)
+	assert.True(t, IsNegativeGrantAmountError(err), err)
+	require.ErrorIs(t, grantUpdateError(grant, err), NegativeGrantAmountErr)
+
+	stored, err := models.FindCreditGrant(ctx, db, grant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), stored.RemainingAmount)
+
+	// Verify: Deductions still stop at the balance
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 150, testAPIEndpoint, "negative-grant-1")
+	require.ErrorIs(t, err, InsufficientCreditsErr)
+	balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), balance.Balance)
+}
@@ -0,0 +1,73 @@
+package creditrepo
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// GrantOption sets optional details of a grant.
+type GrantOption func(*GrantOptions)
+
+// GrantOptions are the optional details of a grant, set with GrantOption.
+type GrantOptions struct {
+	// JSON document stored with the grant, nil for none
+	Metadata []byte
+	// Credit type of the grant, see WithGrantCreditType
+	CreditType string
+	// Block of the burn that confirmed the grant, nil when unknown
+	BlockNumber *int64
+}
+
+// WithGrantMetadata stores a JSON document with the grant, such as the order or campaign the credits were bought for.
+// Confirming a grant with metadata replaces the metadata of the pending grant.
+func WithGrantMetadata(metadata []byte) GrantOption {
+	return func(o *GrantOptions) {
+		o.Metadata = metadata
+	}
+}
+
+// WithGrantBlockNumber records the number of the block with the burn that confirms the grant, for reconciling grants against the chain.
+// It is only stored by ConfirmGrant, and a nil block number leaves the column empty.
+func WithGrantBlockNumber(blockNumber *int64) GrantOption {
+	return func(o *GrantOptions) {
+		o.BlockNumber = blockNumber
+	}
+}
+
+// NewGrantOptions applies the grant options and validates the result.
+func NewGrantOptions(opts ...GrantOption) (GrantOptions, error) {
+	var options GrantOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.Metadata != nil && !json.Valid(options.Metadata) {
+		return options, errors.New("grant metadata must be valid JSON")
+	}
+	if err := validateCreditType(options.CreditType); err != nil {
+		return options, err
+	}
+	if options.BlockNumber != nil && *options.BlockNumber < 0 {
+		return options, errors.New("grant block number must not be negative")
+	}
+	return options, nil
+}
+
+// metadata is the column value of the metadata, null when there is none.
+func (o GrantOptions) metadata() null.JSON {
+	return null.NewJSON(o.Metadata, o.Metadata != nil)
+}
+
+// blockNumber is the column value of the block number, null when it is unknown.
+func (o GrantOptions) blockNumber() null.Int64 {
+	return null.Int64FromPtr(o.BlockNumber)
+}
+
+// creditType is the credit type of a new grant.
+func (o GrantOptions) creditType() string {
+	if o.CreditType == "" {
+		return DefaultCreditType
+	}
+	return o.CreditType
+}
@@ -0,0 +1,142 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestGrantMetadata(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("create grant stores metadata", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-metadata-create"
+		metadata := []byte(`{"order_id":"order-1","campaign":"launch"}`)
+
+		grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now(), WithGrantMetadata(metadata))
+		require.NoError(t, err)
+
+		// Verify: The metadata is read back from the database
+		stored, err := models.FindCreditGrant(ctx, db, grant.ID)
+		require.NoError(t, err)
+		require.True(t, stored.Metadata.Valid)
+		assert.JSONEq(t, string(metadata), string(stored.Metadata.JSON))
+	})
+
+	t.Run("create grant without metadata", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-metadata-none"
+
+		grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now())
+		require.NoError(t, err)
+
+		stored, err := models.FindCreditGrant(ctx, db, grant.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.Metadata.Valid)
+	})
+
+	t.Run("confirm grant stores metadata", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-metadata-confirm"
+		metadata := []byte(`{"source":"chain"}`)
+
+		operation, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xmetadata-confirm", 0, 1000, time.Now(), WithGrantMetadata(metadata))
+		require.NoError(t, err)
+
+		stored, err := models.FindCreditGrant(ctx, db, operation.ReferenceID)
+		require.NoError(t, err)
+		require.True(t, stored.Metadata.Valid)
+		assert.JSONEq(t, string(metadata), string(stored.Metadata.JSON))
+	})
+
+	t.Run("confirm keeps metadata of pending grant", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-metadata-pending"
+		metadata := []byte(`{"order_id":"order-2"}`)
+		txHash := "0xmetadata-pending"
+
+		grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now(), WithGrantMetadata(metadata))
+		require.NoError(t, err)
+		_, err = repo.UpdateGrantTxHash(ctx, grant, txHash)
+		require.NoError(t, err)
+
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, txHash, 0, 1000, time.Now())
+		require.NoError(t, err)
+
+		stored, err := models.FindCreditGrant(ctx, db, grant.ID)
+		require.NoError(t, err)
+		assert.Equal(t, GrantStatusConfirmed, stored.Status)
+		assert.JSONEq(t, string(metadata), string(stored.Metadata.JSON))
+	})
+
+	t.Run("confirm grant stores the block number", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-block-number"
+		txHash := "0xblock-number-pending"
+		blockNumber := int64(18_000_000)
+
+		// Setup: A pending grant confirmed with its block number, and a new grant confirmed without one
+		grant, err := repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now())
+		require.NoError(t, err)
+		_, err = repo.UpdateGrantTxHash(ctx, grant, txHash)
+		require.NoError(t, err)
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, txHash, 0, 1000, time.Now(), WithGrantBlockNumber(&blockNumber))
+		require.NoError(t, err)
+		withoutBlock, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xblock-number-none", 0, 1000, time.Now(), WithGrantBlockNumber(nil))
+		require.NoError(t, err)
+
+		stored, err := models.FindCreditGrant(ctx, db, grant.ID)
+		require.NoError(t, err)
+		assert.Equal(t, GrantStatusConfirmed, stored.Status)
+		assert.Equal(t, null.Int64From(blockNumber), stored.BlockNumber)
+
+		stored, err = models.FindCreditGrant(ctx, db, withoutBlock.ReferenceID)
+		require.NoError(t, err)
+		assert.Equal(t, GrantStatusConfirmed, stored.Status)
+		assert.False(t, stored.BlockNumber.Valid)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-metadata-invalid"
+
+		_, err := repo.CreateGrant(ctx, licenseID, testAssetID, 1000, time.Now(), WithGrantMetadata([]byte(`{"order_id":`)))
+		require.Error(t, err)
+
+		grants, err := models.CreditGrants(models.CreditGrantWhere.LicenseID.EQ(licenseID)).All(ctx, db)
+		require.NoError(t, err)
+		assert.Empty(t, grants)
+	})
+}
+
+func TestNewGrantOptions(t *testing.T) {
+	t.Parallel()
+
+	options, err := NewGrantOptions()
+	require.NoError(t, err)
+	assert.False(t, options.metadata().Valid)
+
+	options, err = NewGrantOptions(WithGrantMetadata([]byte(`{"a":1}`)))
+	require.NoError(t, err)
+	assert.True(t, options.metadata().Valid)
+
+	_, err = NewGrantOptions(WithGrantMetadata([]byte(`not json`)))
+	require.Error(t, err)
+
+	blockNumber := int64(-1)
+	_, err = NewGrantOptions(WithGrantBlockNumber(&blockNumber))
+	require.Error(t, err)
+}
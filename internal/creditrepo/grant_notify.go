@@ -0,0 +1,59 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/rs/zerolog"
+)
+
+// GrantConfirmedNotifier is notified of the grants confirmed by ConfirmGrant and ConfirmGrants.
+type GrantConfirmedNotifier interface {
+	PublishGrantConfirmed(ctx context.Context, licenseID, assetDID, grantID string, amount, balance int64) error
+}
+
+// WithGrantConfirmedNotifier notifies of every confirmed grant with its credits and the balance after the confirmation.
+// Notifications are sent after the confirmation is committed, so they never announce a grant that was rolled back.
+func WithGrantConfirmedNotifier(notifier GrantConfirmedNotifier) Option {
+	return func(r *Repository) {
+		r.grantConfirmedNotifier = notifier
+	}
+}
+
+// grantConfirmation is a committed grant confirmation waiting to be notified.
+type grantConfirmation struct {
+	operation *models.CreditOperation
+	balance   int64
+}
+
+// newGrantConfirmation reads the balance after a grant confirmation within its transaction, for the notification sent after commit.
+// It returns nil without a notifier.
+func (r *Repository) newGrantConfirmation(ctx context.Context, tx *sql.Tx, operation *models.CreditOperation) (*grantConfirmation, error) {
+	if r.grantConfirmedNotifier == nil {
+		return nil, nil
+	}
+	if operation.BalanceAfter.Valid {
+		return &grantConfirmation{operation: operation, balance: operation.BalanceAfter.Int64}, nil
+	}
+	balance, err := r.calculateBalance(ctx, tx, operation.LicenseID, operation.AssetDid, operation.CreditType)
+	if err != nil {
+		return nil, err
+	}
+	return &grantConfirmation{operation: operation, balance: balance}, nil
+}
+
+// notifyGrantConfirmed notifies of a committed grant confirmation.
+// Failures are logged, the grant has already been confirmed.
+func (r *Repository) notifyGrantConfirmed(ctx context.Context, confirmation *grantConfirmation) {
+	if confirmation == nil {
+		return
+	}
+	operation := confirmation.operation
+	// the reference ID of a grant_confirm operation is the grant ID
+	err := r.grantConfirmedNotifier.PublishGrantConfirmed(ctx, operation.LicenseID, operation.AssetDid, operation.ReferenceID, operation.TotalAmount, confirmation.balance)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("licenseId", operation.LicenseID).Str("assetDid", operation.AssetDid).
+			Str("grantId", operation.ReferenceID).Msg("failed to publish grant confirmed event")
+	}
+}
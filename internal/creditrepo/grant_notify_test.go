@@ -0,0 +1,103 @@
+package creditrepo
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// grantConfirmedEvent is a notification recorded by recordingGrantNotifier.
+type grantConfirmedEvent struct {
+	LicenseID string
+	AssetDID  string
+	GrantID   string
+	Amount    int64
+	Balance   int64
+}
+
+// recordingGrantNotifier records the grant confirmed notifications it receives.
+type recordingGrantNotifier struct {
+	mu     sync.Mutex
+	events []grantConfirmedEvent
+}
+
+func (n *recordingGrantNotifier) PublishGrantConfirmed(_ context.Context, licenseID, assetDID, grantID string, amount, balance int64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, grantConfirmedEvent{LicenseID: licenseID, AssetDID: assetDID, GrantID: grantID, Amount: amount, Balance: balance})
+	return nil
+}
+
+func (n *recordingGrantNotifier) eventsOf(licenseID string) []grantConfirmedEvent {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var events []grantConfirmedEvent
+	for _, event := range n.events {
+		if event.LicenseID == licenseID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestGrantConfirmedNotifier(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	notifier := &recordingGrantNotifier{}
+	repo := New(db, WithGrantConfirmedNotifier(notifier))
+	ctx := context.Background()
+
+	t.Run("confirmation is notified after commit", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-notify"
+		txHash := "0xnotify"
+
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, txHash, 1, 100, time.Now())
+		require.NoError(t, err)
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, txHash+"2", 1, 50, time.Now())
+		require.NoError(t, err)
+
+		events := notifier.eventsOf(licenseID)
+		require.Len(t, events, 2)
+		grant, err := models.CreditGrants(models.CreditGrantWhere.TXHash.EQ(txHash)).One(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, grantConfirmedEvent{LicenseID: licenseID, AssetDID: testAssetID, GrantID: grant.ID, Amount: 100, Balance: 100}, events[0])
+		assert.Equal(t, int64(50), events[1].Amount)
+		assert.Equal(t, int64(150), events[1].Balance)
+
+		// a replayed chain event is rolled back and not notified again
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, txHash, 1, 100, time.Now())
+		require.ErrorIs(t, err, GrantAlreadyConfirmedErr)
+		assert.Len(t, notifier.eventsOf(licenseID), 2)
+	})
+
+	t.Run("batch notifies confirmed grants only", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-grant-notify-batch"
+
+		outcomes, err := repo.ConfirmGrants(ctx, []ConfirmInput{
+			{LicenseID: licenseID, AssetDID: testAssetID, TxHash: "0xnotify-batch", LogIndex: 1, Amount: 30, MintTime: time.Now()},
+			{LicenseID: licenseID, AssetDID: testAssetID, TxHash: "0xnotify-batch", LogIndex: 2, Amount: 0, MintTime: time.Now()},
+			{LicenseID: licenseID, AssetDID: testAssetID, TxHash: "0xnotify-batch", LogIndex: 3, Amount: 20, MintTime: time.Now()},
+		})
+		require.NoError(t, err)
+		require.Len(t, outcomes, 3)
+		assert.Equal(t, ConfirmStatusFailed, outcomes[1].Status)
+
+		events := notifier.eventsOf(licenseID)
+		require.Len(t, events, 2)
+		assert.Equal(t, int64(30), events[0].Amount)
+		assert.Equal(t, int64(30), events[0].Balance)
+		assert.Equal(t, int64(20), events[1].Amount)
+		assert.Equal(t, int64(50), events[1].Balance)
+	})
+}
@@ -0,0 +1,105 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const (
+	defaultListGrantsLimit = 100
+	maxListGrantsLimit     = 1000
+)
+
+// ListOptions filters and pages the grants returned by ListGrants.
+type ListOptions struct {
+	// Maximum number of grants to return, zero uses the default
+	Limit int
+	// Number of grants to skip
+	Offset int
+	// Only return grants with this status, empty returns every status
+	Status string
+	// Include grants that have expired
+	IncludeExpired bool
+}
+
+// ListGrants returns the grants of a license and asset in FIFO order, the order deductions use them.
+func (r *Repository) ListGrants(ctx context.Context, licenseID, assetDID string, opts ListOptions) ([]*models.CreditGrant, error) {
+	if licenseID == "" || assetDID == "" {
+		return nil, fmt.Errorf("licenseID and assetDID are required")
+	}
+	if opts.Offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+	switch opts.Status {
+	case "", GrantStatusPending, GrantStatusConfirmed, GrantStatusFailed, GrantStatusExpired:
+	default:
+		return nil, fmt.Errorf("invalid grant status: %s", opts.Status)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultListGrantsLimit
+	}
+	limit = min(limit, maxListGrantsLimit)
+
+	mods := []qm.QueryMod{
+		models.CreditGrantWhere.LicenseID.EQ(licenseID),
+		models.CreditGrantWhere.AssetDid.EQ(assetDID),
+		qm.OrderBy(models.CreditGrantColumns.ExpiresAt + " ASC, " + models.CreditGrantColumns.CreatedAt + " ASC, " + models.CreditGrantColumns.ID + " ASC"),
+		qm.Limit(limit),
+		qm.Offset(opts.Offset),
+	}
+	if opts.Status != "" {
+		mods = append(mods, models.CreditGrantWhere.Status.EQ(opts.Status))
+	}
+	if !opts.IncludeExpired {
+		mods = append(mods, notExpired(time.Now()))
+	}
+
+	grants, err := models.CreditGrants(mods...).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grants: %w", err)
+	}
+	return grants, nil
+}
+
+// GetGrantByTxHash returns the grant of a burn transaction. A tx hash can be shared by a pending grant that later failed and
+// the grant created when its burn confirmed, so the confirmed or expired grant is returned when there is one, otherwise the newest grant.
+// It returns GrantNotFoundErr when no grant has the tx hash and AmbiguousGrantErr when several confirmed grants do.
+func (r *Repository) GetGrantByTxHash(ctx context.Context, txHash string) (*models.CreditGrant, error) {
+	if txHash == "" {
+		return nil, fmt.Errorf("txHash is required")
+	}
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.TXHash.EQ(txHash),
+		qm.OrderBy(models.CreditGrantColumns.CreatedAt+" DESC, "+models.CreditGrantColumns.ID+" DESC"),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grants by tx hash: %w", err)
+	}
+	return PickGrantByTxHash(txHash, grants)
+}
+
+// PickGrantByTxHash picks the grant GetGrantByTxHash returns out of the grants sharing a tx hash, ordered newest first.
+func PickGrantByTxHash(txHash string, grants []*models.CreditGrant) (*models.CreditGrant, error) {
+	if len(grants) == 0 {
+		return nil, fmt.Errorf("%w: tx hash %s", GrantNotFoundErr, txHash)
+	}
+	var confirmed *models.CreditGrant
+	for _, grant := range grants {
+		if grant.Status != GrantStatusConfirmed && grant.Status != GrantStatusExpired {
+			continue
+		}
+		if confirmed != nil {
+			return nil, fmt.Errorf("%w: tx hash %s", AmbiguousGrantErr, txHash)
+		}
+		confirmed = grant
+	}
+	if confirmed != nil {
+		return confirmed, nil
+	}
+	return grants[0], nil
+}
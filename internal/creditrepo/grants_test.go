@@ -0,0 +1,171 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestListGrants(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	licenseID := "test-license-list-grants"
+	// Setup: Grants inserted out of FIFO order, one of them expired
+	grantSpecs := []struct {
+		status    string
+		expiresIn time.Duration
+	}{
+		{status: GrantStatusConfirmed, expiresIn: 72 * time.Hour},
+		{status: GrantStatusPending, expiresIn: 24 * time.Hour},
+		{status: GrantStatusConfirmed, expiresIn: -time.Hour},
+		{status: GrantStatusFailed, expiresIn: 48 * time.Hour},
+	}
+	grantIDs := make([]string, len(grantSpecs))
+	for i, spec := range grantSpecs {
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          spec.status,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(spec.expiresIn)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		grantIDs[i] = grant.ID
+	}
+
+	ids := func(grants []*models.CreditGrant) []string {
+		result := make([]string, 0, len(grants))
+		for _, grant := range grants {
+			result = append(result, grant.ID)
+		}
+		return result
+	}
+
+	t.Run("active grants in FIFO order", func(t *testing.T) {
+		t.Parallel()
+		grants, err := repo.ListGrants(ctx, licenseID, testAssetID, ListOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{grantIDs[1], grantIDs[3], grantIDs[0]}, ids(grants))
+	})
+
+	t.Run("include expired grants", func(t *testing.T) {
+		t.Parallel()
+		grants, err := repo.ListGrants(ctx, licenseID, testAssetID, ListOptions{IncludeExpired: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{grantIDs[2], grantIDs[1], grantIDs[3], grantIDs[0]}, ids(grants))
+	})
+
+	t.Run("status filter", func(t *testing.T) {
+		t.Parallel()
+		grants, err := repo.ListGrants(ctx, licenseID, testAssetID, ListOptions{Status: GrantStatusConfirmed, IncludeExpired: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{grantIDs[2], grantIDs[0]}, ids(grants))
+	})
+
+	t.Run("limit and offset", func(t *testing.T) {
+		t.Parallel()
+		grants, err := repo.ListGrants(ctx, licenseID, testAssetID, ListOptions{Limit: 2, Offset: 1, IncludeExpired: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{grantIDs[1], grantIDs[3]}, ids(grants))
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.ListGrants(ctx, licenseID, testAssetID, ListOptions{Status: "unknown"})
+		require.Error(t, err)
+	})
+}
+
+func TestGetGrantByTxHash(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	// confirmed grants get a log index, unique per tx hash
+	insertGrant := func(t *testing.T, licenseID, txHash, status string, logIndex int, createdAt time.Time) *models.CreditGrant {
+		t.Helper()
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   1000,
+			RemainingAmount: 1000,
+			TXHash:          txHash,
+			Status:          status,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			CreatedAt:       null.TimeFrom(createdAt),
+		}
+		if status == GrantStatusConfirmed {
+			grant.LogIndex = null.IntFrom(logIndex)
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		return grant
+	}
+
+	t.Run("found", func(t *testing.T) {
+		t.Parallel()
+		grant := insertGrant(t, "test-license-grant-by-tx-hash", "0xgrant-by-tx-hash-found", GrantStatusPending, 0, time.Now())
+
+		found, err := repo.GetGrantByTxHash(ctx, grant.TXHash)
+		require.NoError(t, err)
+		assert.Equal(t, grant.ID, found.ID)
+		assert.Equal(t, GrantStatusPending, found.Status)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.GetGrantByTxHash(ctx, "0xgrant-by-tx-hash-missing")
+		require.ErrorIs(t, err, GrantNotFoundErr)
+	})
+
+	t.Run("confirmed grant preferred over a failed pending grant", func(t *testing.T) {
+		t.Parallel()
+		txHash := "0xgrant-by-tx-hash-shared"
+		licenseID := "test-license-grant-by-tx-hash-shared"
+		confirmed := insertGrant(t, licenseID, txHash, GrantStatusConfirmed, 0, time.Now().Add(-time.Hour))
+		insertGrant(t, licenseID, txHash, GrantStatusFailed, 0, time.Now())
+
+		found, err := repo.GetGrantByTxHash(ctx, txHash)
+		require.NoError(t, err)
+		assert.Equal(t, confirmed.ID, found.ID)
+	})
+
+	t.Run("newest grant without a confirmed grant", func(t *testing.T) {
+		t.Parallel()
+		txHash := "0xgrant-by-tx-hash-unconfirmed"
+		licenseID := "test-license-grant-by-tx-hash-unconfirmed"
+		insertGrant(t, licenseID, txHash, GrantStatusFailed, 0, time.Now().Add(-time.Hour))
+		newest := insertGrant(t, licenseID, txHash, GrantStatusPending, 0, time.Now())
+
+		found, err := repo.GetGrantByTxHash(ctx, txHash)
+		require.NoError(t, err)
+		assert.Equal(t, newest.ID, found.ID)
+	})
+
+	t.Run("several confirmed grants", func(t *testing.T) {
+		t.Parallel()
+		txHash := "0xgrant-by-tx-hash-ambiguous"
+		insertGrant(t, "test-license-grant-by-tx-hash-ambiguous-1", txHash, GrantStatusConfirmed, 0, time.Now())
+		insertGrant(t, "test-license-grant-by-tx-hash-ambiguous-2", txHash, GrantStatusConfirmed, 1, time.Now())
+
+		_, err := repo.GetGrantByTxHash(ctx, txHash)
+		require.ErrorIs(t, err, AmbiguousGrantErr)
+	})
+}
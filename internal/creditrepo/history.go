@@ -0,0 +1,284 @@
+package creditrepo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const (
+	defaultOperationHistoryLimit = 100
+	maxOperationHistoryLimit     = 1000
+)
+
+// OperationRecord is a single ledger entry of a license and the grants it touched.
+type OperationRecord struct {
+	// Asset DID
+	AssetDID string `json:"assetDid"`
+	// Type of the operation, one of deduction, refund, grant_purchase, grant_confirm, debt_settlement or expiration
+	OperationType string `json:"operationType"`
+	// Total number of credits of the operation
+	TotalAmount int64 `json:"totalAmount"`
+	// Name of the app that made the operation
+	AppName string `json:"appName"`
+	// Reference ID of the operation, refunds share the reference ID of the deduction they refund
+	ReferenceID string `json:"referenceId"`
+	// When the operation was made
+	CreatedAt time.Time `json:"createdAt"`
+	// Credits taken from or returned to each grant
+	Grants []OperationGrantRecord `json:"grants"`
+}
+
+// OperationGrantRecord is the part of an operation applied to a single grant.
+type OperationGrantRecord struct {
+	// Grant ID
+	GrantID string `json:"grantId"`
+	// Number of credits applied to the grant
+	Amount int64 `json:"amount"`
+}
+
+// OperationHistoryOptions filters and pages the operations returned by GetOperationHistory.
+type OperationHistoryOptions struct {
+	// Only return operations of this asset, empty returns every asset
+	AssetDID string
+	// Only return operations made at or after this time, zero leaves the period open
+	FromDate time.Time
+	// Only return operations made at or before this time, zero leaves the period open
+	ToDate time.Time
+	// Only return operations of these types, empty returns every type
+	OperationTypes []string
+	// Maximum number of operations to return, zero uses the default
+	Limit int
+	// Number of operations to skip, after the cursor when there is one
+	Offset int
+	// NextCursor of the previous page, empty for the first page
+	Cursor string
+}
+
+// OperationHistoryPage is a page of the operation history of a license.
+type OperationHistoryPage struct {
+	// Operations newest first
+	Operations []OperationRecord `json:"operations"`
+	// Cursor for the next page, empty when there are no more operations
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// OperationPosition is the position of an operation in the history. The created time alone does not order operations made
+// in the same transaction, so the operation key breaks ties.
+type OperationPosition struct {
+	CreatedAt     time.Time `json:"c"`
+	AppName       string    `json:"a"`
+	ReferenceID   string    `json:"r"`
+	OperationType string    `json:"t"`
+}
+
+// PositionOf returns the position of an operation in the history.
+func PositionOf(record OperationRecord) OperationPosition {
+	return OperationPosition{
+		CreatedAt:     record.CreatedAt,
+		AppName:       record.AppName,
+		ReferenceID:   record.ReferenceID,
+		OperationType: record.OperationType,
+	}
+}
+
+// Compare orders positions the way GetOperationHistory does: newest first, then by app name, reference ID, and operation type.
+func (p OperationPosition) Compare(other OperationPosition) int {
+	if c := other.CreatedAt.Compare(p.CreatedAt); c != 0 {
+		return c
+	}
+	return cmp.Or(
+		strings.Compare(p.AppName, other.AppName),
+		strings.Compare(p.ReferenceID, other.ReferenceID),
+		strings.Compare(p.OperationType, other.OperationType),
+	)
+}
+
+// EncodeOperationCursor returns the cursor of the page ending with the operation.
+func EncodeOperationCursor(record OperationRecord) (string, error) {
+	return encodeCursor(PositionOf(record))
+}
+
+// DecodeOperationCursor returns the position of the last operation of the page of a cursor.
+// It fails with InvalidCursorErr when the cursor was not returned by GetOperationHistory.
+func DecodeOperationCursor(cursor string) (OperationPosition, error) {
+	var position OperationPosition
+	if err := decodeCursor(cursor, &position); err != nil {
+		return OperationPosition{}, err
+	}
+	return position, nil
+}
+
+// IsOperationType reports whether the operation type is one the ledger records.
+func IsOperationType(operationType string) bool {
+	switch operationType {
+	case OperationTypeDeduction, OperationTypeRefund, OperationTypeGrantPurchase, OperationTypeGrantConfirm, OperationTypeDebtSettlement,
+		OperationTypeExpiration, OperationTypeGrantFailed, OperationTypeTransfer:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetOperationHistory returns a page of the operations of a license, newest first, with the grants each operation touched.
+// Pages are keyset paginated: the cursor is the NextCursor of the previous page, which keeps pages stable as new operations
+// are made and, unlike the offset, does not scan the skipped operations.
+func (r *Repository) GetOperationHistory(ctx context.Context, licenseID string, opts OperationHistoryOptions) (*OperationHistoryPage, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	if !opts.FromDate.IsZero() && !opts.ToDate.IsZero() && opts.FromDate.After(opts.ToDate) {
+		return nil, fmt.Errorf("fromDate must be before toDate")
+	}
+	if opts.Offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+	for _, operationType := range opts.OperationTypes {
+		if !IsOperationType(operationType) {
+			return nil, fmt.Errorf("invalid operation type: %q", operationType)
+		}
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultOperationHistoryLimit
+	}
+	limit = min(limit, maxOperationHistoryLimit)
+
+	mods := []qm.QueryMod{
+		models.CreditOperationWhere.LicenseID.EQ(licenseID),
+		qm.OrderBy(models.CreditOperationColumns.CreatedAt + " DESC, " + models.CreditOperationColumns.AppName + " ASC, " +
+			models.CreditOperationColumns.ReferenceID + " ASC, " + models.CreditOperationColumns.OperationType + " ASC"),
+		// fetch one extra operation to know if there is a next page
+		qm.Limit(limit + 1),
+		qm.Offset(opts.Offset),
+	}
+	if opts.AssetDID != "" {
+		mods = append(mods, models.CreditOperationWhere.AssetDid.EQ(opts.AssetDID))
+	}
+	if !opts.FromDate.IsZero() {
+		mods = append(mods, models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(opts.FromDate)))
+	}
+	if !opts.ToDate.IsZero() {
+		mods = append(mods, models.CreditOperationWhere.CreatedAt.LTE(null.TimeFrom(opts.ToDate)))
+	}
+	if len(opts.OperationTypes) > 0 {
+		mods = append(mods, models.CreditOperationWhere.OperationType.IN(opts.OperationTypes))
+	}
+	if opts.Cursor != "" {
+		after, err := DecodeOperationCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// older operations, or operations made at the same time that sort after the cursor's operation key
+		mods = append(mods, qm.Where("("+models.CreditOperationColumns.CreatedAt+" < ? OR ("+models.CreditOperationColumns.CreatedAt+" = ? AND ("+
+			models.CreditOperationColumns.AppName+", "+models.CreditOperationColumns.ReferenceID+", "+models.CreditOperationColumns.OperationType+") > (?, ?, ?)))",
+			after.CreatedAt, after.CreatedAt, after.AppName, after.ReferenceID, after.OperationType))
+	}
+
+	operations, err := models.CreditOperations(mods...).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operations: %w", err)
+	}
+	page := &OperationHistoryPage{Operations: []OperationRecord{}}
+	if len(operations) == 0 {
+		return page, nil
+	}
+	hasNext := len(operations) > limit
+	if hasNext {
+		operations = operations[:limit]
+	}
+
+	keys := make([]any, 0, len(operations)*3)
+	for _, operation := range operations {
+		keys = append(keys, operation.AppName, operation.ReferenceID, operation.OperationType)
+	}
+	opGrants, err := models.CreditOperationGrants(
+		qm.WhereIn("("+models.CreditOperationGrantColumns.AppName+", "+models.CreditOperationGrantColumns.ReferenceID+", "+
+			models.CreditOperationGrantColumns.OperationType+") IN ?", keys...),
+		qm.OrderBy(models.CreditOperationGrantColumns.CreatedAt+" ASC, "+models.CreditOperationGrantColumns.ID+" ASC"),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation grants: %w", err)
+	}
+
+	page.Operations = operationRecords(operations, opGrants)
+	if hasNext {
+		page.NextCursor, err = EncodeOperationCursor(page.Operations[len(page.Operations)-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// GetOperationByReference returns the operations of a license with the reference ID, oldest first, with the grants each operation touched,
+// such as a deduction and its refunds. Operations of every app sharing the reference ID are returned.
+// It fails with OperationNotFoundErr when the license has no operation with the reference ID.
+func (r *Repository) GetOperationByReference(ctx context.Context, licenseID, referenceID string) ([]OperationRecord, error) {
+	if licenseID == "" || referenceID == "" {
+		return nil, fmt.Errorf("licenseID and referenceID are required")
+	}
+
+	operations, err := models.CreditOperations(
+		models.CreditOperationWhere.LicenseID.EQ(licenseID),
+		models.CreditOperationWhere.ReferenceID.EQ(referenceID),
+		qm.OrderBy(models.CreditOperationColumns.CreatedAt+" ASC, "+models.CreditOperationColumns.AppName+" ASC, "+
+			models.CreditOperationColumns.OperationType+" ASC"),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operations: %w", err)
+	}
+	if len(operations) == 0 {
+		return nil, OperationNotFoundErr
+	}
+
+	opGrants, err := models.CreditOperationGrants(
+		models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+		qm.OrderBy(models.CreditOperationGrantColumns.CreatedAt+" ASC, "+models.CreditOperationGrantColumns.ID+" ASC"),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation grants: %w", err)
+	}
+
+	return operationRecords(operations, opGrants), nil
+}
+
+// operationKey identifies an operation, its grant rows share the key of the operation.
+type operationKey struct {
+	appName       string
+	referenceID   string
+	operationType string
+}
+
+// operationRecords pairs each operation with the grant rows of the same operation key.
+func operationRecords(operations models.CreditOperationSlice, opGrants models.CreditOperationGrantSlice) []OperationRecord {
+	grantsByKey := make(map[operationKey][]OperationGrantRecord, len(operations))
+	for _, opGrant := range opGrants {
+		key := operationKey{appName: opGrant.AppName, referenceID: opGrant.ReferenceID, operationType: opGrant.OperationType}
+		grantsByKey[key] = append(grantsByKey[key], OperationGrantRecord{GrantID: opGrant.GrantID, Amount: opGrant.AmountUsed})
+	}
+
+	records := make([]OperationRecord, 0, len(operations))
+	for _, operation := range operations {
+		grants := grantsByKey[operationKey{appName: operation.AppName, referenceID: operation.ReferenceID, operationType: operation.OperationType}]
+		if grants == nil {
+			grants = []OperationGrantRecord{}
+		}
+		records = append(records, OperationRecord{
+			AssetDID:      operation.AssetDid,
+			OperationType: operation.OperationType,
+			TotalAmount:   operation.TotalAmount,
+			AppName:       operation.AppName,
+			ReferenceID:   operation.ReferenceID,
+			CreatedAt:     operation.CreatedAt.Time,
+			Grants:        grants,
+		})
+	}
+	return records
+}
@@ -0,0 +1,222 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGetOperationHistory(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	licenseID := "test-license-operation-history"
+	// Setup: A failed grant with debt, settled by the next confirmed grant, then a deduction and its refund
+	failedGrant := &models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        testAssetID,
+		InitialAmount:   50,
+		RemainingAmount: 0,
+		Status:          GrantStatusFailed,
+		ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+	}
+	require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+	confirmOp, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xhistory", 1, uint64(defaultGrantAmount), time.Now())
+	require.NoError(t, err)
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 10, "app", "history-ref")
+	require.NoError(t, err)
+	_, err = repo.RefundCredits(ctx, "app", "history-ref")
+	require.NoError(t, err)
+
+	types := func(records []OperationRecord) []string {
+		result := make([]string, 0, len(records))
+		for _, record := range records {
+			result = append(result, record.OperationType)
+		}
+		return result
+	}
+
+	t.Run("every operation type newest first", func(t *testing.T) {
+		t.Parallel()
+		page, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{AssetDID: testAssetID})
+		require.NoError(t, err)
+		records := page.Operations
+		assert.Equal(t, []string{OperationTypeRefund, OperationTypeDeduction, OperationTypeDebtSettlement, OperationTypeGrantConfirm}, types(records))
+		assert.Empty(t, page.NextCursor)
+
+		refund, deduction, settlement, confirm := records[0], records[1], records[2], records[3]
+		assert.Equal(t, deduction.ReferenceID, refund.ReferenceID)
+		assert.Equal(t, int64(10), refund.TotalAmount)
+		require.Len(t, refund.Grants, 1)
+		assert.Equal(t, int64(10), refund.Grants[0].Amount)
+		assert.Equal(t, int64(50), settlement.TotalAmount)
+		require.Len(t, settlement.Grants, 1)
+		assert.Equal(t, confirmOp.ReferenceID, settlement.ReferenceID)
+		require.Len(t, confirm.Grants, 1)
+		assert.Equal(t, confirm.Grants[0].GrantID, settlement.Grants[0].GrantID)
+	})
+
+	t.Run("limit and offset", func(t *testing.T) {
+		t.Parallel()
+		page, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{OperationTypeDeduction, OperationTypeDebtSettlement}, types(page.Operations))
+	})
+
+	t.Run("refunds only", func(t *testing.T) {
+		t.Parallel()
+		page, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{OperationTypes: []string{OperationTypeRefund}})
+		require.NoError(t, err)
+		require.Len(t, page.Operations, 1)
+		assert.Equal(t, OperationTypeRefund, page.Operations[0].OperationType)
+		assert.Equal(t, "history-ref", page.Operations[0].ReferenceID)
+
+		page, err = repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{OperationTypes: []string{OperationTypeDeduction, OperationTypeRefund}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{OperationTypeRefund, OperationTypeDeduction}, types(page.Operations))
+
+		_, err = repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{OperationTypes: []string{"withdrawal"}})
+		require.Error(t, err)
+	})
+
+	t.Run("time period", func(t *testing.T) {
+		t.Parallel()
+		page, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{AssetDID: testAssetID, FromDate: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		assert.Empty(t, page.Operations)
+
+		_, err = repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{AssetDID: testAssetID, FromDate: time.Now(), ToDate: time.Now().Add(-time.Hour)})
+		require.Error(t, err)
+	})
+}
+
+func TestGetOperationHistoryCursor(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+
+	repo := New(dbContainer.DB)
+	ctx := context.Background()
+	licenseID := "test-license-operation-history-cursor"
+
+	_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xhistory-cursor", 1, uint64(defaultGrantAmount), time.Now())
+	require.NoError(t, err)
+	for i := range 7 {
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 1, "app", fmt.Sprintf("history-cursor-%d", i))
+		require.NoError(t, err)
+	}
+	all, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{})
+	require.NoError(t, err)
+	require.Len(t, all.Operations, 8)
+
+	// Test: Page through the history three operations at a time
+	var paged []OperationRecord
+	var pages int
+	cursor := ""
+	for {
+		page, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{Limit: 3, Cursor: cursor})
+		require.NoError(t, err)
+		paged = append(paged, page.Operations...)
+		pages++
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	// Verify: The pages hold every operation once, in the order of the whole history
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, all.Operations, paged)
+
+	// Verify: A new operation does not shift the pages after the first
+	page, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{Limit: 3})
+	require.NoError(t, err)
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 1, "app", "history-cursor-new")
+	require.NoError(t, err)
+	next, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{Limit: 3, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, all.Operations[3:6], next.Operations)
+
+	// Verify: The cursor applies with the operation type filter
+	deductions, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{
+		OperationTypes: []string{OperationTypeDeduction},
+		Limit:          5,
+	})
+	require.NoError(t, err)
+	require.Len(t, deductions.Operations, 5)
+	rest, err := repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{
+		OperationTypes: []string{OperationTypeDeduction},
+		Cursor:         deductions.NextCursor,
+	})
+	require.NoError(t, err)
+	require.Len(t, rest.Operations, 3)
+	assert.Empty(t, rest.NextCursor)
+	for _, record := range rest.Operations {
+		assert.Equal(t, OperationTypeDeduction, record.OperationType)
+	}
+
+	_, err = repo.GetOperationHistory(ctx, licenseID, OperationHistoryOptions{Cursor: "not a cursor"})
+	require.ErrorIs(t, err, InvalidCursorErr)
+}
+
+func TestGetOperationByReference(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+
+	repo := New(dbContainer.DB)
+	ctx := context.Background()
+	licenseID := "test-license-operation-reference"
+
+	confirmOp, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xoperation-reference", 1, uint64(defaultGrantAmount), time.Now())
+	require.NoError(t, err)
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 30, "app", "operation-reference-1")
+	require.NoError(t, err)
+	_, err = repo.RefundCredits(ctx, "app", "operation-reference-1")
+	require.NoError(t, err)
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 5, "app", "operation-reference-2")
+	require.NoError(t, err)
+
+	t.Run("deduction and its refund", func(t *testing.T) {
+		t.Parallel()
+		records, err := repo.GetOperationByReference(ctx, licenseID, "operation-reference-1")
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+
+		deduction, refund := records[0], records[1]
+		assert.Equal(t, OperationTypeDeduction, deduction.OperationType)
+		assert.Equal(t, OperationTypeRefund, refund.OperationType)
+		for _, record := range records {
+			assert.Equal(t, int64(30), record.TotalAmount)
+			assert.Equal(t, "app", record.AppName)
+			require.Len(t, record.Grants, 1)
+			assert.Equal(t, confirmOp.ReferenceID, record.Grants[0].GrantID)
+		}
+		// Verify: The deduction took from the grant and the refund returned to it
+		assert.Equal(t, int64(-30), deduction.Grants[0].Amount)
+		assert.Equal(t, int64(30), refund.Grants[0].Amount)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.GetOperationByReference(ctx, licenseID, "operation-reference-missing")
+		require.ErrorIs(t, err, OperationNotFoundErr)
+
+		// Verify: The reference of another license is not found either
+		_, err = repo.GetOperationByReference(ctx, "test-license-operation-reference-other", "operation-reference-1")
+		require.ErrorIs(t, err, OperationNotFoundErr)
+	})
+}
@@ -0,0 +1,46 @@
+package creditrepo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ParseIsolationLevel parses the isolation level of the transactions that move credits, ReadCommitted or Serializable.
+// The names are case insensitive and an empty name is ReadCommitted.
+func ParseIsolationLevel(level string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "", "readcommitted", "read_committed":
+		return sql.LevelReadCommitted, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("invalid isolation level %q: must be ReadCommitted or Serializable", level)
+	}
+}
+
+// WithIsolationLevel sets the isolation level of the transactions that move credits: deductions, refunds, transfers, and the grant
+// lifecycle. Serializable closes races between concurrent transactions on the same grants that row locks alone do not, at the cost of
+// serialization failures, which are retried like deadlocks. Read-only queries keep their own isolation level.
+func WithIsolationLevel(level sql.IsolationLevel) Option {
+	return func(r *Repository) {
+		r.isolationLevel = level
+	}
+}
+
+// writeTxOptions returns the options of a transaction that moves credits.
+func (r *Repository) writeTxOptions() *sql.TxOptions {
+	return &sql.TxOptions{
+		Isolation: r.isolationLevel,
+	}
+}
+
+// balanceTxOptions returns the options of a balance read, which writes like the other write paths when lazy expiration is enabled.
+func (r *Repository) balanceTxOptions() *sql.TxOptions {
+	if r.lazyExpiration {
+		return r.writeTxOptions()
+	}
+	return &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	}
+}
@@ -0,0 +1,91 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIsolationLevel(t *testing.T) {
+	t.Parallel()
+
+	for name, expected := range map[string]sql.IsolationLevel{
+		"":               sql.LevelReadCommitted,
+		"ReadCommitted":  sql.LevelReadCommitted,
+		"read_committed": sql.LevelReadCommitted,
+		"Serializable":   sql.LevelSerializable,
+		" serializable ": sql.LevelSerializable,
+	} {
+		level, err := ParseIsolationLevel(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, level, name)
+	}
+
+	_, err := ParseIsolationLevel("RepeatableRead")
+	require.Error(t, err)
+}
+
+func TestWithIsolationLevel(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, sql.LevelReadCommitted, New(nil).writeTxOptions().Isolation)
+	assert.Equal(t, sql.LevelSerializable, New(nil, WithIsolationLevel(sql.LevelSerializable)).writeTxOptions().Isolation)
+
+	// balance reads only take the write isolation level when lazy expiration makes them write
+	assert.Equal(t, sql.LevelReadCommitted, New(nil, WithIsolationLevel(sql.LevelSerializable)).balanceTxOptions().Isolation)
+	assert.Equal(t, sql.LevelSerializable, New(nil, WithIsolationLevel(sql.LevelSerializable), WithLazyExpiration(true)).balanceTxOptions().Isolation)
+}
+
+func TestRetryTx_SerializationFailureRetried(t *testing.T) {
+	attempts := 0
+	result, err := retryTx(context.Background(), New(nil, WithIsolationLevel(sql.LevelSerializable)), "TestFunction", func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts <= 2 {
+			return "", fmt.Errorf("failed to update grant: %w", &pq.Error{Code: SerializationFailureError})
+		}
+		return "success", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "success", result)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestSerializableDeductions(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+
+	repo := New(dbContainer.DB, WithIsolationLevel(sql.LevelSerializable), WithDeadlockMaxAttempts(0))
+	ctx := context.Background()
+	licenseID := "test-license-serializable"
+
+	_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xserializable-1", 0, 500, time.Now())
+	require.NoError(t, err)
+	_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xserializable-2", 0, 500, time.Now())
+	require.NoError(t, err)
+
+	// Test: Concurrent deductions of the same asset conflict and are retried until each commits
+	const deductions = 10
+	done := make(chan error, deductions)
+	for i := range deductions {
+		go func() {
+			_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 60, testAPIEndpoint, fmt.Sprintf("serializable-%d", i))
+			done <- err
+		}()
+	}
+	for range deductions {
+		require.NoError(t, <-done)
+	}
+
+	// Verify: Every deduction was applied exactly once
+	balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000-deductions*60), balance.Balance)
+}
@@ -0,0 +1,105 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+)
+
+// licenseSummaryQuery computes the spendable balance of the grants with a status in the array $3 at $2 and the outstanding debt
+// of every asset of a license ($1), then adds them up and counts the assets with a balance and the assets in debt.
+var licenseSummaryQuery = fmt.Sprintf(`
+	SELECT COALESCE(SUM(balance), 0) AS balance,
+		COALESCE(SUM(debt), 0) AS debt,
+		COUNT(*) AS assets,
+		COUNT(*) FILTER (WHERE balance > 0) AS active_assets,
+		COUNT(*) FILTER (WHERE debt > 0) AS assets_in_debt
+	FROM (
+		SELECT %[1]s,
+			COALESCE(SUM(%[2]s) FILTER (WHERE %[3]s = ANY($3) AND (%[4]s IS NULL OR %[4]s > $2) AND %[2]s > 0), 0) AS balance,
+			COALESCE(SUM(%[5]s - %[2]s) FILTER (WHERE %[3]s = '%[6]s' AND %[2]s < %[5]s), 0) AS debt
+		FROM %[7]s
+		WHERE %[8]s = $1
+		GROUP BY %[1]s
+	) AS asset_totals
+`,
+	models.CreditGrantColumns.AssetDid,
+	models.CreditGrantColumns.RemainingAmount,
+	models.CreditGrantColumns.Status,
+	models.CreditGrantColumns.ExpiresAt,
+	models.CreditGrantColumns.InitialAmount,
+	GrantStatusFailed,
+	models.TableNames.CreditGrants,
+	models.CreditGrantColumns.LicenseID,
+)
+
+// LicenseSummary is the total balance and debt of a license across all of its assets.
+type LicenseSummary struct {
+	// License ID
+	LicenseID string `json:"licenseId" boil:"-"`
+	// Spendable credits of every asset
+	Balance int64 `json:"balance" boil:"balance"`
+	// Outstanding debt of every asset
+	Debt int64 `json:"debt" boil:"debt"`
+	// Assets with grants of any status
+	Assets int64 `json:"assets" boil:"assets"`
+	// Assets with spendable credits
+	ActiveAssets int64 `json:"activeAssets" boil:"active_assets"`
+	// Assets with outstanding debt, an asset in debt can still have spendable credits
+	AssetsInDebt int64 `json:"assetsInDebt" boil:"assets_in_debt"`
+}
+
+// AddAsset adds the balance and debt of an asset to the summary.
+func (s *LicenseSummary) AddAsset(balance, debt int64) {
+	s.Balance += balance
+	s.Debt += debt
+	s.Assets++
+	if balance > 0 {
+		s.ActiveAssets++
+	}
+	if debt > 0 {
+		s.AssetsInDebt++
+	}
+}
+
+// GetLicenseSummary returns the total spendable balance and outstanding debt of a license and how many of its assets
+// have credits or debt, computed in a single aggregate query over the grants.
+// Like GetAccountSnapshot it does not expire grants when lazy expiration is enabled; expired grants are never counted in the balance either way.
+func (r *Repository) GetLicenseSummary(ctx context.Context, licenseID string) (*LicenseSummary, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	return retryTx(ctx, r, "GetLicenseSummary", func(ctx context.Context) (*LicenseSummary, error) {
+		return r.getLicenseSummaryInternal(ctx, licenseID)
+	})
+}
+
+// getLicenseSummaryInternal is the internal implementation of GetLicenseSummary
+func (r *Repository) getLicenseSummaryInternal(ctx context.Context, licenseID string) (*LicenseSummary, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("GetLicenseSummary")()
+	defer rollbackTx(ctx, tx)
+
+	summary := &LicenseSummary{}
+	err = queries.Raw(licenseSummaryQuery, licenseID, time.Now(), pq.StringArray(r.spendableStatuses())).Bind(ctx, tx, summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize license: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	summary.LicenseID = licenseID
+
+	return summary, nil
+}
@@ -0,0 +1,98 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGetLicenseSummary(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	insertGrant := func(t *testing.T, licenseID, assetDID, status string, remaining int64, expiresAt null.Time) {
+		t.Helper()
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        assetDID,
+			InitialAmount:   100,
+			RemainingAmount: remaining,
+			Status:          status,
+			ExpiresAt:       expiresAt,
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+	}
+
+	t.Run("mix of assets", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-summary"
+		later := null.TimeFrom(time.Now().Add(24 * time.Hour))
+
+		// Setup: two assets with a balance, one in debt, one with its credits used up, and one with only an expired grant
+		insertGrant(t, licenseID, "test-asset-summary-a", GrantStatusConfirmed, 100, later)
+		insertGrant(t, licenseID, "test-asset-summary-a", GrantStatusConfirmed, 50, null.Time{})
+		insertGrant(t, licenseID, "test-asset-summary-b", GrantStatusConfirmed, 30, later)
+		insertGrant(t, licenseID, "test-asset-summary-c", GrantStatusFailed, 40, later)
+		insertGrant(t, licenseID, "test-asset-summary-d", GrantStatusConfirmed, 0, later)
+		insertGrant(t, licenseID, "test-asset-summary-e", GrantStatusConfirmed, 100, null.TimeFrom(time.Now().Add(-time.Hour)))
+		insertGrant(t, "test-license-summary-other", "test-asset-summary-a", GrantStatusConfirmed, 100, later)
+
+		// Test: Summarize the license
+		summary, err := repo.GetLicenseSummary(ctx, licenseID)
+		require.NoError(t, err)
+
+		// Verify: Balances and debt are added up and the assets are counted by their state
+		assert.Equal(t, licenseID, summary.LicenseID)
+		assert.Equal(t, int64(180), summary.Balance)
+		assert.Equal(t, int64(60), summary.Debt)
+		assert.Equal(t, int64(5), summary.Assets)
+		assert.Equal(t, int64(2), summary.ActiveAssets)
+		assert.Equal(t, int64(1), summary.AssetsInDebt)
+	})
+
+	t.Run("asset in debt with a balance", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-summary-debt-and-balance"
+		later := null.TimeFrom(time.Now().Add(24 * time.Hour))
+
+		insertGrant(t, licenseID, testAssetID, GrantStatusConfirmed, 70, later)
+		insertGrant(t, licenseID, testAssetID, GrantStatusFailed, 90, later)
+
+		summary, err := repo.GetLicenseSummary(ctx, licenseID)
+		require.NoError(t, err)
+
+		// Verify: The asset counts as both active and in debt
+		assert.Equal(t, int64(70), summary.Balance)
+		assert.Equal(t, int64(10), summary.Debt)
+		assert.Equal(t, int64(1), summary.Assets)
+		assert.Equal(t, int64(1), summary.ActiveAssets)
+		assert.Equal(t, int64(1), summary.AssetsInDebt)
+	})
+
+	t.Run("license without grants", func(t *testing.T) {
+		t.Parallel()
+
+		summary, err := repo.GetLicenseSummary(ctx, "test-license-summary-empty")
+		require.NoError(t, err)
+		assert.Equal(t, &LicenseSummary{LicenseID: "test-license-summary-empty"}, summary)
+	})
+
+	t.Run("license is required", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := repo.GetLicenseSummary(ctx, "")
+		require.Error(t, err)
+	})
+}
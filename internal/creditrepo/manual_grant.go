@@ -0,0 +1,91 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/google/uuid"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// manualGrantPrefix starts the synthetic reference that manual grants store in place of a burn transaction hash.
+const manualGrantPrefix = "manual-"
+
+// CreateManualGrant creates a confirmed grant that is not bought with a burn, such as goodwill credits issued by support.
+// The grant gets a synthetic reference in place of a burn transaction hash and expires like a grant minted now.
+// The reason is recorded as the reason code of the grant_confirm operation, and the credits settle any outstanding debt first.
+func (r *Repository) CreateManualGrant(ctx context.Context, licenseID, assetDID string, amount uint64, reason string) (*models.CreditGrant, error) {
+	if amount == 0 {
+		return nil, fmt.Errorf("invalid amount: %d. Amount must be positive", amount)
+	}
+	if amount > math.MaxInt64 {
+		return nil, fmt.Errorf("credit amount is too large must be less than %d", math.MaxInt64)
+	}
+	if licenseID == "" || assetDID == "" || reason == "" {
+		return nil, fmt.Errorf("licenseID, assetDID, and reason are required")
+	}
+	if len(reason) > MaxReasonCodeLength {
+		return nil, fmt.Errorf("reason must be at most %d characters", MaxReasonCodeLength)
+	}
+	logger := operationLogger(ctx, licenseID, assetDID, "", "", amount).With().Str("reason", reason).Logger()
+	logger.Debug().Msg("creating manual grant")
+	referenceID := manualGrantPrefix + uuid.NewString()
+	grant, err := retryTx(ctx, r, "CreateManualGrant", func(ctx context.Context) (*models.CreditGrant, error) {
+		return r.createManualGrantInternal(ctx, licenseID, assetDID, int64(amount), referenceID, reason)
+	})
+	if err != nil {
+		logger.Debug().Err(err).Msg("failed to create manual grant")
+	}
+	return grant, err
+}
+
+// createManualGrantInternal is the internal implementation of CreateManualGrant
+func (r *Repository) createManualGrantInternal(ctx context.Context, licenseID, assetDID string, amount int64, referenceID, reason string) (*models.CreditGrant, error) {
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("CreateManualGrant")()
+	defer rollbackTx(ctx, tx)
+
+	now := time.Now()
+	grant := &models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDID,
+		InitialAmount:   amount,
+		RemainingAmount: amount,
+		TXHash:          referenceID,
+		Status:          GrantStatusConfirmed,
+		ExpiresAt:       null.TimeFrom(getExpirationDate(now)),
+		CreatedAt:       null.TimeFrom(now),
+		UpdatedAt:       null.TimeFrom(now),
+	}
+	if err := grant.Insert(ctx, tx, boil.Infer()); err != nil {
+		return nil, fmt.Errorf("failed to create grant record: %w", err)
+	}
+
+	operation, err := r.recordGrantConfirmation(ctx, tx, grant, amount)
+	if err != nil {
+		return nil, err
+	}
+	operation.ReasonCode = null.StringFrom(reason)
+	if _, err := operation.Update(ctx, tx, boil.Whitelist(models.CreditOperationColumns.ReasonCode)); err != nil {
+		return nil, fmt.Errorf("failed to record grant reason: %w", err)
+	}
+	// debt settlement takes from the grant through its own copy of the row
+	if err := grant.Reload(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to reload grant: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.logOperation(ctx, operation, "created manual grant")
+	r.observeSettledDebt(ctx, licenseID)
+
+	return grant, nil
+}
@@ -0,0 +1,98 @@
+package creditrepo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestCreateManualGrant(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("confirmed with reason", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-manual-grant"
+
+		grant, err := repo.CreateManualGrant(ctx, licenseID, testAssetID, 500, "goodwill")
+		require.NoError(t, err)
+		assert.Equal(t, GrantStatusConfirmed, grant.Status)
+		assert.True(t, strings.HasPrefix(grant.TXHash, manualGrantPrefix))
+		assert.True(t, grant.ExpiresAt.Valid)
+
+		operation, err := models.CreditOperations(
+			models.CreditOperationWhere.ReferenceID.EQ(grant.ID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeGrantConfirm),
+		).One(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, null.StringFrom("goodwill"), operation.ReasonCode)
+		assert.Equal(t, int64(500), operation.TotalAmount)
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(500), balance.Balance)
+	})
+
+	t.Run("clears existing debt", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-manual-grant-debt"
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   1000,
+			RemainingAmount: 700,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+
+		grant, err := repo.CreateManualGrant(ctx, licenseID, testAssetID, 500, "incident_compensation")
+		require.NoError(t, err)
+
+		// Verify: The debt of 300 was settled from the manual grant
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Zero(t, balance.Debt)
+		assert.Equal(t, int64(200), balance.Balance)
+		assert.Equal(t, int64(200), grant.RemainingAmount)
+
+		require.NoError(t, failedGrant.Reload(ctx, db))
+		assert.Equal(t, int64(1000), failedGrant.RemainingAmount)
+
+		settlement, err := models.CreditOperations(
+			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeDebtSettlement),
+		).One(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), settlement.TotalAmount)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-manual-grant-invalid"
+
+		_, err := repo.CreateManualGrant(ctx, licenseID, testAssetID, 0, "goodwill")
+		require.Error(t, err)
+		_, err = repo.CreateManualGrant(ctx, licenseID, testAssetID, 100, "")
+		require.Error(t, err)
+		_, err = repo.CreateManualGrant(ctx, licenseID, testAssetID, 100, strings.Repeat("a", MaxReasonCodeLength+1))
+		require.Error(t, err)
+
+		grants, err := models.CreditGrants(models.CreditGrantWhere.LicenseID.EQ(licenseID)).All(ctx, db)
+		require.NoError(t, err)
+		assert.Empty(t, grants)
+	})
+}
@@ -0,0 +1,969 @@
+// Package memstore is an in-memory creditrepo.CreditStore for unit tests of code that depends on the store.
+// It follows the FIFO and debt rules of the Postgres repository but has no transactions or persistence.
+// The storetest contract suite runs against both, so the rules the tests rely on do not drift apart.
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/google/uuid"
+	"github.com/volatiletech/null/v8"
+)
+
+const storeAppName = "credit_tracker"
+
+var _ creditrepo.CreditStore = (*Store)(nil)
+
+// Store is an in-memory credit store, safe for concurrent use.
+type Store struct {
+	mu         sync.Mutex
+	grants     []*models.CreditGrant
+	operations []*models.CreditOperation
+	opGrants   []*models.CreditOperationGrant
+	hub        *creditrepo.OperationHub
+
+	// SelfTestSteps is returned by SelfTest.
+	SelfTestSteps []creditrepo.SelfTestStep
+	// Transactions is returned by GetLongRunningTransactions.
+	Transactions []*creditrepo.TransactionDiagnostic
+}
+
+// New creates an empty store.
+func New() *Store {
+	return &Store{hub: creditrepo.NewOperationHub()}
+}
+
+// AddGrant adds a grant as is, for setting up test state that the store operations can not create.
+func (s *Store) AddGrant(grant *models.CreditGrant) *models.CreditGrant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if grant.ID == "" {
+		grant.ID = uuid.NewString()
+	}
+	if !grant.CreatedAt.Valid {
+		grant.CreatedAt = null.TimeFrom(time.Now())
+	}
+	if grant.CreditType == "" {
+		grant.CreditType = creditrepo.DefaultCreditType
+	}
+	s.grants = append(s.grants, grant)
+	return grant
+}
+
+// DeductCredits deducts credits from the active grants of the credit type in FIFO order.
+func (s *Store) DeductCredits(_ context.Context, licenseID string, assetDID string, amount uint64, appName string, referenceID string, opts ...creditrepo.PoolOption) (*models.CreditOperation, error) {
+	if amount > math.MaxInt64 {
+		return nil, fmt.Errorf("deduction amount is too large must be less than %d", math.MaxInt64)
+	}
+	options, err := creditrepo.NewPoolOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if debt := s.debt(licenseID, assetDID, options.CreditType); debt > 0 {
+		return nil, fmt.Errorf("%w: %d. Please add credits to clear debt first", creditrepo.OutstandingDebtErr, debt)
+	}
+	grants := s.activeGrants(licenseID, assetDID, options.CreditType, time.Now())
+	balance := int64(0)
+	for _, grant := range grants {
+		balance += grant.RemainingAmount
+	}
+	if balance < int64(amount) {
+		return nil, creditrepo.NewInsufficientCreditsError(balance, int64(amount))
+	}
+	operation, err := s.addOperation(licenseID, assetDID, creditrepo.OperationTypeDeduction, int64(amount), appName, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	operation.CreditType = options.CreditType
+
+	remaining := int64(amount)
+	for _, grant := range grants {
+		if remaining == 0 {
+			break
+		}
+		used := min(remaining, grant.RemainingAmount)
+		grant.RemainingAmount -= used
+		remaining -= used
+		s.addOperationGrant(operation, grant, -used)
+	}
+	return operation, nil
+}
+
+// DeductCreditsBatch deducts each deduction on its own, a failed deduction does not affect the others.
+func (s *Store) DeductCreditsBatch(ctx context.Context, licenseID, appName string, deductions []creditrepo.DeductInput) ([]creditrepo.DeductOutcome, error) {
+	outcomes := make([]creditrepo.DeductOutcome, 0, len(deductions))
+	for _, input := range deductions {
+		operation, err := s.DeductCredits(ctx, licenseID, input.AssetDID, input.Amount, appName, input.ReferenceID)
+		outcomes = append(outcomes, creditrepo.DeductOutcome{Input: input, Operation: operation, Err: err})
+	}
+	return outcomes, nil
+}
+
+// RefundCredits returns the credits of a deduction to the grants it used and settles the debt of its credit type.
+// Like the repository's default refund overflow policy, a refund that does not fit a grant's spent credits is refused.
+func (s *Store) RefundCredits(_ context.Context, appName string, referenceID string, opts ...creditrepo.RefundOption) (*models.CreditOperation, error) {
+	options, err := creditrepo.NewRefundOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deduction := s.findOperation(appName, referenceID, creditrepo.OperationTypeDeduction)
+	if deduction == nil {
+		return nil, fmt.Errorf("%w: referenced deduction %s of %s", creditrepo.OperationNotFoundErr, referenceID, appName)
+	}
+	if s.findOperation(appName, referenceID, creditrepo.OperationTypeRefund) != nil {
+		return nil, fmt.Errorf("%w: %s %s %s", creditrepo.DuplicateOperationErr, appName, referenceID, creditrepo.OperationTypeRefund)
+	}
+	var opGrants []*models.CreditOperationGrant
+	for _, opGrant := range s.opGrants {
+		if opGrant.AppName != appName || opGrant.ReferenceID != referenceID || opGrant.OperationType != creditrepo.OperationTypeDeduction {
+			continue
+		}
+		grant := s.findGrant(opGrant.GrantID)
+		if capacity := max(grant.InitialAmount-grant.RemainingAmount, 0); -opGrant.AmountUsed > capacity {
+			return nil, fmt.Errorf("%w: grant %s can take %d of the %d refunded credits", creditrepo.RefundExceedsCapacityErr, grant.ID, capacity, -opGrant.AmountUsed)
+		}
+		opGrants = append(opGrants, opGrant)
+	}
+	operation, err := s.addOperation(deduction.LicenseID, deduction.AssetDid, creditrepo.OperationTypeRefund, deduction.TotalAmount, appName, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	operation.ReasonCode = null.NewString(options.ReasonCode, options.ReasonCode != "")
+	operation.CreditType = deduction.CreditType
+	// refill the grants in reverse of the order they were deducted from
+	for _, opGrant := range slices.Backward(opGrants) {
+		grant := s.findGrant(opGrant.GrantID)
+		grant.RemainingAmount -= opGrant.AmountUsed
+		s.addOperationGrant(operation, grant, -opGrant.AmountUsed)
+	}
+	if _, err := s.settleDebt(deduction.LicenseID, deduction.AssetDid, deduction.CreditType, appName, referenceID); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+// CreateGrant creates a pending grant unless the license and asset still have an active grant, and settles the debt of its credit type.
+func (s *Store) CreateGrant(_ context.Context, licenseID string, assetDID string, creditAmount uint64, mintTime time.Time, opts ...creditrepo.GrantOption) (*models.CreditGrant, error) {
+	if creditAmount == 0 || creditAmount > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid amount: %d", creditAmount)
+	}
+	options, err := creditrepo.NewGrantOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	creditType := cmp.Or(options.CreditType, creditrepo.DefaultCreditType)
+	if len(s.activeGrants(licenseID, assetDID, creditType, time.Now())) > 0 {
+		return nil, creditrepo.GrantAlreadyExistsErr
+	}
+	grant := s.newGrant(licenseID, assetDID, int64(creditAmount), creditrepo.GrantStatusPending, mintTime)
+	grant.Metadata = null.NewJSON(options.Metadata, options.Metadata != nil)
+	grant.CreditType = creditType
+	operation, err := s.addOperation(licenseID, assetDID, creditrepo.OperationTypeGrantPurchase, grant.InitialAmount, storeAppName, grant.ID)
+	if err != nil {
+		return nil, err
+	}
+	operation.CreditType = grant.CreditType
+	s.addOperationGrant(operation, grant, grant.InitialAmount)
+	if _, err := s.settleDebt(licenseID, assetDID, grant.CreditType, storeAppName, grant.ID); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// CreateManualGrant creates a confirmed grant without a burn and records the reason on its grant_confirm operation.
+func (s *Store) CreateManualGrant(_ context.Context, licenseID, assetDID string, amount uint64, reason string) (*models.CreditGrant, error) {
+	if amount == 0 || amount > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid amount: %d", amount)
+	}
+	if licenseID == "" || assetDID == "" || reason == "" {
+		return nil, fmt.Errorf("licenseID, assetDID, and reason are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	grant := s.newGrant(licenseID, assetDID, int64(amount), creditrepo.GrantStatusConfirmed, time.Now())
+	grant.TXHash = "manual-" + grant.ID
+	operation, err := s.addOperation(licenseID, assetDID, creditrepo.OperationTypeGrantConfirm, grant.InitialAmount, storeAppName, grant.ID)
+	if err != nil {
+		return nil, err
+	}
+	operation.ReasonCode = null.StringFrom(reason)
+	s.addOperationGrant(operation, grant, grant.InitialAmount)
+	if _, err := s.settleDebt(licenseID, assetDID, grant.CreditType, storeAppName, grant.ID); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// UpdateGrantTxHash sets the tx hash of a grant.
+func (s *Store) UpdateGrantTxHash(_ context.Context, grant *models.CreditGrant, txHash string) (*models.CreditGrant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.findGrant(grant.ID)
+	if stored == nil {
+		return nil, fmt.Errorf("grant %s not found", grant.ID)
+	}
+	stored.TXHash = txHash
+	grant.TXHash = txHash
+	return grant, nil
+}
+
+// FailGrant marks a pending grant as failed and records a grant_failed operation.
+func (s *Store) FailGrant(_ context.Context, grant *models.CreditGrant) (*models.CreditGrant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.findGrant(grant.ID)
+	if stored == nil {
+		return nil, fmt.Errorf("grant %s not found", grant.ID)
+	}
+	if stored.Status != creditrepo.GrantStatusPending {
+		return nil, fmt.Errorf("%w: grant %s is %s", creditrepo.GrantNotPendingErr, stored.ID, stored.Status)
+	}
+	operation, err := s.addOperation(stored.LicenseID, stored.AssetDid, creditrepo.OperationTypeGrantFailed, stored.InitialAmount, storeAppName, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	operation.CreditType = stored.CreditType
+	stored.Status = creditrepo.GrantStatusFailed
+	grant.Status = stored.Status
+	return grant, nil
+}
+
+// ConfirmGrant confirms the pending grant with the tx hash, or creates a confirmed grant if there is none, and settles the debt of its credit type.
+func (s *Store) ConfirmGrant(_ context.Context, licenseID string, assetDID string, txHash string, logIndex int, creditAmount uint64, mintTime time.Time, opts ...creditrepo.GrantOption) (*models.CreditOperation, error) {
+	if creditAmount == 0 || creditAmount > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid amount: %d", creditAmount)
+	}
+	options, err := creditrepo.NewGrantOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, confirmed := range s.grants {
+		if confirmed.TXHash != txHash || confirmed.LogIndex != null.IntFrom(logIndex) || confirmed.Status != creditrepo.GrantStatusConfirmed {
+			continue
+		}
+		if confirmed.LicenseID != licenseID || confirmed.AssetDid != assetDID || confirmed.InitialAmount != int64(creditAmount) {
+			return nil, fmt.Errorf("%w: grant %s", creditrepo.ConfirmConflictErr, confirmed.ID)
+		}
+		return nil, fmt.Errorf("%w: grant %s", creditrepo.GrantAlreadyConfirmedErr, confirmed.ID)
+	}
+
+	var grant *models.CreditGrant
+	for _, candidate := range s.grants {
+		if candidate.TXHash == txHash && candidate.LicenseID == licenseID && candidate.AssetDid == assetDID && candidate.Status == creditrepo.GrantStatusPending {
+			grant = candidate
+			break
+		}
+	}
+	if grant == nil {
+		grant = s.newGrant(licenseID, assetDID, int64(creditAmount), creditrepo.GrantStatusConfirmed, mintTime)
+		grant.TXHash = txHash
+	} else if grant.InitialAmount != int64(creditAmount) {
+		return nil, fmt.Errorf("%w: pending grant %s is for %d credits, confirmation is for %d credits",
+			creditrepo.GrantAmountMismatchErr, grant.ID, grant.InitialAmount, creditAmount)
+	}
+	grant.Status = creditrepo.GrantStatusConfirmed
+	grant.LogIndex = null.IntFrom(logIndex)
+	if options.Metadata != nil {
+		grant.Metadata = null.JSONFrom(options.Metadata)
+	}
+	if options.CreditType != "" {
+		grant.CreditType = options.CreditType
+	}
+	if options.BlockNumber != nil {
+		grant.BlockNumber = null.Int64FromPtr(options.BlockNumber)
+	}
+
+	operation, err := s.addOperation(licenseID, assetDID, creditrepo.OperationTypeGrantConfirm, int64(creditAmount), storeAppName, grant.ID)
+	if err != nil {
+		return nil, err
+	}
+	operation.CreditType = grant.CreditType
+	s.addOperationGrant(operation, grant, int64(creditAmount))
+	if _, err := s.settleDebt(licenseID, assetDID, grant.CreditType, storeAppName, grant.ID); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+// GetBalance returns the spendable balance of the credit type and outstanding debt of a license and asset.
+func (s *Store) GetBalance(_ context.Context, licenseID string, assetDID string, opts ...creditrepo.PoolOption) (*creditrepo.Balance, error) {
+	options, err := creditrepo.NewPoolOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &creditrepo.Balance{Balance: s.balance(licenseID, assetDID, options.CreditType), Debt: s.debt(licenseID, assetDID, "")}, nil
+}
+
+// GetBalances returns the balance of each of the given assets of a license.
+func (s *Store) GetBalances(_ context.Context, licenseID string, assetDIDs []string) (map[string]*creditrepo.Balance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	balances := make(map[string]*creditrepo.Balance, len(assetDIDs))
+	for _, assetDID := range assetDIDs {
+		balances[assetDID] = &creditrepo.Balance{Balance: s.balance(licenseID, assetDID, ""), Debt: s.debt(licenseID, assetDID, "")}
+	}
+	return balances, nil
+}
+
+// GetDebt returns the outstanding debt of a license and asset.
+func (s *Store) GetDebt(_ context.Context, licenseID string, assetDID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.debt(licenseID, assetDID, ""), nil
+}
+
+// GetAssetsWithDebt returns every asset of a license with outstanding debt, ordered by asset DID.
+func (s *Store) GetAssetsWithDebt(_ context.Context, licenseID string) ([]creditrepo.AssetDebt, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	debts := []creditrepo.AssetDebt{}
+	for _, assetDID := range s.assets(licenseID) {
+		if debt := s.debt(licenseID, assetDID, ""); debt > 0 {
+			debts = append(debts, creditrepo.AssetDebt{AssetDID: assetDID, Debt: debt})
+		}
+	}
+	return debts, nil
+}
+
+// SettleOutstandingDebt settles the debt of the failed grants, oldest first, with the active grants of their credit type in FIFO order.
+// The debt of each credit type is recorded as its own settlement.
+func (s *Store) SettleOutstandingDebt(_ context.Context, licenseID, assetDID string) (int64, error) {
+	if licenseID == "" || assetDID == "" {
+		return 0, fmt.Errorf("licenseID and assetDID are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var creditTypes []string
+	for _, grant := range s.grants {
+		if grant.LicenseID == licenseID && grant.AssetDid == assetDID && grant.Status == creditrepo.GrantStatusFailed &&
+			grant.RemainingAmount < grant.InitialAmount && !slices.Contains(creditTypes, grant.CreditType) {
+			creditTypes = append(creditTypes, grant.CreditType)
+		}
+	}
+	slices.Sort(creditTypes)
+	var settled int64
+	for _, creditType := range creditTypes {
+		amount, err := s.settleDebt(licenseID, assetDID, creditType, storeAppName, "settlement-"+uuid.NewString())
+		if err != nil {
+			return 0, err
+		}
+		settled += amount
+	}
+	return settled, nil
+}
+
+// CanDeduct returns whether a deduction of the amount would succeed and the spendable balance, without deducting.
+func (s *Store) CanDeduct(_ context.Context, licenseID string, assetDID string, amount uint64) (bool, int64, error) {
+	if amount > math.MaxInt64 {
+		return false, 0, fmt.Errorf("deduction amount is too large must be less than %d", math.MaxInt64)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	balance := s.balance(licenseID, assetDID, creditrepo.DefaultCreditType)
+	return s.debt(licenseID, assetDID, creditrepo.DefaultCreditType) == 0 && balance >= int64(amount), balance, nil
+}
+
+// GetBalanceSummaries returns the live balance and debt of every asset of a license, the store has no cache to go stale.
+func (s *Store) GetBalanceSummaries(_ context.Context, licenseID string) ([]*creditrepo.BalanceSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	summaries := []*creditrepo.BalanceSummary{}
+	for _, assetDID := range s.assets(licenseID) {
+		summaries = append(summaries, &creditrepo.BalanceSummary{
+			LicenseID:   licenseID,
+			AssetDID:    assetDID,
+			Balance:     s.balance(licenseID, assetDID, ""),
+			Debt:        s.debt(licenseID, assetDID, ""),
+			RefreshedAt: now,
+		})
+	}
+	return summaries, nil
+}
+
+// RefreshLicenseBalanceSummaries returns the number of assets of the license.
+func (s *Store) RefreshLicenseBalanceSummaries(_ context.Context, licenseID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.assets(licenseID))), nil
+}
+
+// ListGrants returns the grants of a license and asset in FIFO order.
+func (s *Store) ListGrants(_ context.Context, licenseID string, assetDID string, opts creditrepo.ListOptions) ([]*models.CreditGrant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var grants []*models.CreditGrant
+	for _, grant := range s.grants {
+		if grant.LicenseID != licenseID || grant.AssetDid != assetDID {
+			continue
+		}
+		if opts.Status != "" && grant.Status != opts.Status {
+			continue
+		}
+		if !opts.IncludeExpired && expired(grant, now) {
+			continue
+		}
+		grants = append(grants, grant)
+	}
+	slices.SortFunc(grants, creditrepo.CompareGrantFIFO)
+
+	start := min(opts.Offset, len(grants))
+	end := len(grants)
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, end)
+	}
+	return grants[start:end], nil
+}
+
+// GetGrantByTxHash returns the confirmed or expired grant of a tx hash, otherwise the newest grant with it.
+func (s *Store) GetGrantByTxHash(_ context.Context, txHash string) (*models.CreditGrant, error) {
+	if txHash == "" {
+		return nil, fmt.Errorf("txHash is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var grants []*models.CreditGrant
+	for _, grant := range slices.Backward(s.grants) {
+		if grant.TXHash == txHash {
+			grants = append(grants, grant)
+		}
+	}
+	return creditrepo.PickGrantByTxHash(txHash, grants)
+}
+
+// GetOperationHistory returns a page of the operations of a license, newest first, with the grants each operation touched.
+// A limit of zero returns every operation.
+func (s *Store) GetOperationHistory(_ context.Context, licenseID string, opts creditrepo.OperationHistoryOptions) (*creditrepo.OperationHistoryPage, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	if opts.Offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+	for _, operationType := range opts.OperationTypes {
+		if !creditrepo.IsOperationType(operationType) {
+			return nil, fmt.Errorf("invalid operation type: %q", operationType)
+		}
+	}
+	var after *creditrepo.OperationPosition
+	if opts.Cursor != "" {
+		position, err := creditrepo.DecodeOperationCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &position
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := []creditrepo.OperationRecord{}
+	for _, operation := range s.operationsInPeriod(licenseID, opts.AssetDID, opts.FromDate, opts.ToDate) {
+		if len(opts.OperationTypes) > 0 && !slices.Contains(opts.OperationTypes, operation.OperationType) {
+			continue
+		}
+		record := s.operationRecord(operation)
+		if after != nil && creditrepo.PositionOf(record).Compare(*after) <= 0 {
+			continue
+		}
+		records = append(records, record)
+	}
+	slices.SortFunc(records, func(a, b creditrepo.OperationRecord) int {
+		return creditrepo.PositionOf(a).Compare(creditrepo.PositionOf(b))
+	})
+	records = records[min(opts.Offset, len(records)):]
+
+	page := &creditrepo.OperationHistoryPage{Operations: records}
+	if opts.Limit > 0 && len(records) > opts.Limit {
+		page.Operations = records[:opts.Limit]
+		var err error
+		page.NextCursor, err = creditrepo.EncodeOperationCursor(page.Operations[opts.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// GetOperationByReference returns the operations of a license with the reference ID, oldest first, with the grants each operation touched.
+func (s *Store) GetOperationByReference(_ context.Context, licenseID, referenceID string) ([]creditrepo.OperationRecord, error) {
+	if licenseID == "" || referenceID == "" {
+		return nil, fmt.Errorf("licenseID and referenceID are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []creditrepo.OperationRecord
+	for _, operation := range s.operations {
+		if operation.LicenseID == licenseID && operation.ReferenceID == referenceID {
+			records = append(records, s.operationRecord(operation))
+		}
+	}
+	if len(records) == 0 {
+		return nil, creditrepo.OperationNotFoundErr
+	}
+	return records, nil
+}
+
+// operationRecord pairs the operation with the grants it touched.
+func (s *Store) operationRecord(operation *models.CreditOperation) creditrepo.OperationRecord {
+	grants := []creditrepo.OperationGrantRecord{}
+	for _, opGrant := range s.opGrants {
+		if opGrant.AppName == operation.AppName && opGrant.ReferenceID == operation.ReferenceID && opGrant.OperationType == operation.OperationType {
+			grants = append(grants, creditrepo.OperationGrantRecord{GrantID: opGrant.GrantID, Amount: opGrant.AmountUsed})
+		}
+	}
+	return creditrepo.OperationRecord{
+		AssetDID:      operation.AssetDid,
+		OperationType: operation.OperationType,
+		TotalAmount:   operation.TotalAmount,
+		AppName:       operation.AppName,
+		ReferenceID:   operation.ReferenceID,
+		CreatedAt:     operation.CreatedAt.Time,
+		Grants:        grants,
+	}
+}
+
+// GetRecentOperations returns the most recent operations of a license across all assets, newest first.
+// A limit of zero returns every operation.
+func (s *Store) GetRecentOperations(_ context.Context, licenseID string, limit int) ([]creditrepo.RecentOperation, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recentOperations(licenseID, limit), nil
+}
+
+// recentOperations returns up to limit of the most recent operations of a license, all of them when limit is zero.
+func (s *Store) recentOperations(licenseID string, limit int) []creditrepo.RecentOperation {
+	operations := s.operationsInPeriod(licenseID, "", time.Time{}, time.Time{})
+	slices.SortStableFunc(operations, func(a, b *models.CreditOperation) int {
+		return b.CreatedAt.Time.Compare(a.CreatedAt.Time)
+	})
+	if limit > 0 {
+		operations = operations[:min(limit, len(operations))]
+	}
+
+	recent := make([]creditrepo.RecentOperation, 0, len(operations))
+	for _, operation := range operations {
+		recent = append(recent, creditrepo.NewRecentOperation(operation))
+	}
+	return recent
+}
+
+// SubscribeOperations returns a channel of the operations of the license added from now on, and a function that unsubscribes.
+func (s *Store) SubscribeOperations(licenseID string) (<-chan creditrepo.RecentOperation, func()) {
+	return s.hub.Subscribe(licenseID)
+}
+
+// GetAccountSnapshot returns the state of every asset of a license with grants and the license's recent operations.
+func (s *Store) GetAccountSnapshot(_ context.Context, licenseID string) (*creditrepo.AccountSnapshot, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// the snapshot has as many recent operations as GetRecentOperations returns by default
+	snapshot := &creditrepo.AccountSnapshot{LicenseID: licenseID, TakenAt: now, RecentOperations: s.recentOperations(licenseID, 10), Assets: []creditrepo.AssetSnapshot{}}
+	for _, assetDID := range s.assets(licenseID) {
+		asset := creditrepo.AssetSnapshot{AssetDID: assetDID, Balance: s.balance(licenseID, assetDID, ""), Debt: s.debt(licenseID, assetDID, "")}
+		for _, grant := range s.activeGrants(licenseID, assetDID, "", now) {
+			if grant.ExpiresAt.Valid && (asset.NextExpiration == nil || grant.ExpiresAt.Time.Before(*asset.NextExpiration)) {
+				asset.NextExpiration = &grant.ExpiresAt.Time
+			}
+		}
+		for _, grant := range s.grants {
+			if grant.LicenseID != licenseID || grant.AssetDid != assetDID || grant.Status != creditrepo.GrantStatusPending {
+				continue
+			}
+			asset.PendingGrants.Count++
+			asset.PendingGrants.TotalAmount += grant.InitialAmount
+			if asset.PendingGrants.OldestCreatedAt == nil || grant.CreatedAt.Time.Before(*asset.PendingGrants.OldestCreatedAt) {
+				asset.PendingGrants.OldestCreatedAt = &grant.CreatedAt.Time
+			}
+		}
+		snapshot.AddAsset(asset)
+	}
+	return snapshot, nil
+}
+
+// GetLicenseSummary returns the total balance and debt of a license and how many of its assets have credits or debt.
+func (s *Store) GetLicenseSummary(_ context.Context, licenseID string) (*creditrepo.LicenseSummary, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := &creditrepo.LicenseSummary{LicenseID: licenseID}
+	for _, assetDID := range s.assets(licenseID) {
+		summary.AddAsset(s.balance(licenseID, assetDID, ""), s.debt(licenseID, assetDID, ""))
+	}
+	return summary, nil
+}
+
+// GetLicenseUsageReport returns the usage of a license across all assets.
+func (s *Store) GetLicenseUsageReport(_ context.Context, licenseID string, fromDate time.Time, toDate time.Time, includePerAsset bool) (*creditrepo.LicenseUsageReport, error) {
+	if fromDate.IsZero() || licenseID == "" {
+		return nil, fmt.Errorf("fromDate and licenseID are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &creditrepo.LicenseUsageReport{LicenseID: licenseID, FromDate: fromDate, ToDate: toDate, RefundsByReason: []creditrepo.RefundReasonTotal{}}
+	assets := map[string]*creditrepo.AssetUsage{}
+	refunds := map[string]*creditrepo.RefundReasonTotal{}
+	// assets that only received grants were not accessed
+	accessed := map[string]bool{}
+	for _, operation := range s.operationsInPeriod(licenseID, "", fromDate, toDate) {
+		asset := assets[operation.AssetDid]
+		if asset == nil {
+			asset = &creditrepo.AssetUsage{AssetDID: operation.AssetDid}
+			assets[operation.AssetDid] = asset
+		}
+		asset.NumOfCreditsUsed += usage(operation)
+		report.NumOfCreditsUsed += usage(operation)
+		switch operation.OperationType {
+		case creditrepo.OperationTypeDeduction:
+			accessed[operation.AssetDid] = true
+		case creditrepo.OperationTypeGrantConfirm:
+			asset.NumOfCreditsGrantsPurchased++
+			report.NumOfCreditsGrantsPurchased++
+		case creditrepo.OperationTypeRefund:
+			total := refunds[operation.ReasonCode.String]
+			if total == nil {
+				total = &creditrepo.RefundReasonTotal{ReasonCode: operation.ReasonCode.String}
+				refunds[total.ReasonCode] = total
+			}
+			total.NumOfRefunds++
+			total.NumOfCreditsRefunded += operation.TotalAmount
+			accessed[operation.AssetDid] = true
+		}
+	}
+	for _, asset := range assets {
+		if accessed[asset.AssetDID] {
+			report.NumOfAssets++
+		}
+	}
+	for _, reasonCode := range slices.Sorted(maps.Keys(refunds)) {
+		report.RefundsByReason = append(report.RefundsByReason, *refunds[reasonCode])
+	}
+	if includePerAsset {
+		report.PerAsset = []creditrepo.AssetUsage{}
+		for _, assetDID := range slices.Sorted(maps.Keys(assets)) {
+			report.PerAsset = append(report.PerAsset, *assets[assetDID])
+		}
+	}
+	return report, nil
+}
+
+// GetLicenseAssetUsageReport returns the usage of a single asset of a license, without projections.
+func (s *Store) GetLicenseAssetUsageReport(_ context.Context, licenseID string, assetDID string, fromDate time.Time, toDate time.Time, includeGrantTxHashes bool) (*creditrepo.LicenseAssetUsageReport, error) {
+	if fromDate.IsZero() || licenseID == "" || assetDID == "" {
+		return nil, fmt.Errorf("fromDate, licenseID, and assetDID are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &creditrepo.LicenseAssetUsageReport{
+		LicenseID:               licenseID,
+		AssetDID:                assetDID,
+		FromDate:                fromDate,
+		ToDate:                  toDate,
+		CurrentCreditsRemaining: s.balance(licenseID, assetDID, ""),
+	}
+	for _, operation := range s.operationsInPeriod(licenseID, assetDID, fromDate, toDate) {
+		report.NumOfCreditsUsed += usage(operation)
+		if operation.OperationType != creditrepo.OperationTypeGrantConfirm {
+			continue
+		}
+		report.NumOfCreditsGrantsPurchased++
+		report.NumOfCreditsGranted += operation.TotalAmount
+		if includeGrantTxHashes {
+			report.ConfirmedGrants = append(report.ConfirmedGrants, creditrepo.ConfirmedGrant{
+				TxHash:      s.findGrant(operation.ReferenceID).TXHash,
+				Amount:      operation.TotalAmount,
+				ConfirmedAt: operation.CreatedAt.Time,
+			})
+		}
+	}
+	return report, nil
+}
+
+// GetPendingGrantStats aggregates the pending grants of a license, across every asset when assetDID is empty.
+func (s *Store) GetPendingGrantStats(_ context.Context, licenseID, assetDID string) (*creditrepo.PendingGrantStats, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &creditrepo.PendingGrantStats{}
+	for _, grant := range s.grants {
+		if grant.LicenseID != licenseID || (assetDID != "" && grant.AssetDid != assetDID) || grant.Status != creditrepo.GrantStatusPending {
+			continue
+		}
+		stats.Count++
+		stats.TotalAmount += grant.InitialAmount
+		if stats.OldestCreatedAt == nil || grant.CreatedAt.Time.Before(*stats.OldestCreatedAt) {
+			stats.OldestCreatedAt = &grant.CreatedAt.Time
+		}
+	}
+	return stats, nil
+}
+
+// ReconcileBalance returns the balance of a license and asset replayed from the grant allocations and summed from the grants.
+func (s *Store) ReconcileBalance(_ context.Context, licenseID, assetDID string) (int64, int64, error) {
+	if licenseID == "" || assetDID == "" {
+		return 0, 0, fmt.Errorf("licenseID and assetDID are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var grants []*models.CreditGrant
+	var grantBalance int64
+	for _, grant := range s.grants {
+		if grant.LicenseID == licenseID && grant.AssetDid == assetDID {
+			grants = append(grants, grant)
+			grantBalance += grant.RemainingAmount
+		}
+	}
+	return creditrepo.LedgerBalance(grants, s.opGrants), grantBalance, nil
+}
+
+// GetLongRunningTransactions returns the configured Transactions, the store has no transactions of its own.
+func (s *Store) GetLongRunningTransactions(_ context.Context, _ time.Duration) ([]*creditrepo.TransactionDiagnostic, error) {
+	return s.Transactions, nil
+}
+
+// SelfTest returns the configured SelfTestSteps.
+func (s *Store) SelfTest(_ context.Context) []creditrepo.SelfTestStep {
+	return s.SelfTestSteps
+}
+
+func (s *Store) newGrant(licenseID, assetDID string, amount int64, status string, mintTime time.Time) *models.CreditGrant {
+	grant := &models.CreditGrant{
+		ID:              uuid.NewString(),
+		LicenseID:       licenseID,
+		AssetDid:        assetDID,
+		InitialAmount:   amount,
+		RemainingAmount: amount,
+		Status:          status,
+		ExpiresAt:       null.TimeFrom(mintTime.UTC().AddDate(0, 1, 0)),
+		CreatedAt:       null.TimeFrom(time.Now()),
+		CreditType:      creditrepo.DefaultCreditType,
+	}
+	s.grants = append(s.grants, grant)
+	return grant
+}
+
+// settleDebt moves the credits of the active grants of the credit type to its failed grants, oldest failed grant first,
+// and records them as a debt_settlement operation. It returns the credits settled, zero without debt or balance.
+func (s *Store) settleDebt(licenseID, assetDID, creditType, appName, referenceID string) (int64, error) {
+	type move struct {
+		grant  *models.CreditGrant
+		amount int64
+	}
+	var moves []move
+	var settled int64
+	active := s.activeGrants(licenseID, assetDID, creditType, time.Now())
+	for _, failed := range s.grants {
+		if failed.LicenseID != licenseID || failed.AssetDid != assetDID || failed.CreditType != creditType || failed.Status != creditrepo.GrantStatusFailed {
+			continue
+		}
+		for _, grant := range active {
+			amount := min(failed.InitialAmount-failed.RemainingAmount, grant.RemainingAmount)
+			if amount <= 0 {
+				continue
+			}
+			grant.RemainingAmount -= amount
+			failed.RemainingAmount += amount
+			settled += amount
+			moves = append(moves, move{grant, amount}, move{failed, amount})
+		}
+	}
+	if settled == 0 {
+		return 0, nil
+	}
+
+	operation, err := s.addOperation(licenseID, assetDID, creditrepo.OperationTypeDebtSettlement, settled, appName, referenceID)
+	if err != nil {
+		return 0, err
+	}
+	operation.CreditType = creditType
+	for _, m := range moves {
+		s.addOperationGrant(operation, m.grant, m.amount)
+	}
+	return settled, nil
+}
+
+func (s *Store) addOperation(licenseID, assetDID, operationType string, amount int64, appName, referenceID string) (*models.CreditOperation, error) {
+	if s.findOperation(appName, referenceID, operationType) != nil {
+		return nil, fmt.Errorf("%w: %s %s %s", creditrepo.DuplicateOperationErr, appName, referenceID, operationType)
+	}
+	operation := &models.CreditOperation{
+		LicenseID:     licenseID,
+		AssetDid:      assetDID,
+		OperationType: operationType,
+		TotalAmount:   amount,
+		AppName:       appName,
+		ReferenceID:   referenceID,
+		CreatedAt:     null.TimeFrom(time.Now()),
+		CreditType:    creditrepo.DefaultCreditType,
+	}
+	s.operations = append(s.operations, operation)
+	s.hub.Publish(operation)
+	return operation, nil
+}
+
+func (s *Store) addOperationGrant(operation *models.CreditOperation, grant *models.CreditGrant, amount int64) {
+	s.opGrants = append(s.opGrants, &models.CreditOperationGrant{
+		ID:            uuid.NewString(),
+		AppName:       operation.AppName,
+		ReferenceID:   operation.ReferenceID,
+		OperationType: operation.OperationType,
+		GrantID:       grant.ID,
+		AmountUsed:    amount,
+		CreatedAt:     null.TimeFrom(time.Now()),
+	})
+}
+
+func (s *Store) findOperation(appName, referenceID, operationType string) *models.CreditOperation {
+	for _, operation := range s.operations {
+		if operation.AppName == appName && operation.ReferenceID == referenceID && operation.OperationType == operationType {
+			return operation
+		}
+	}
+	return nil
+}
+
+func (s *Store) findGrant(id string) *models.CreditGrant {
+	for _, grant := range s.grants {
+		if grant.ID == id {
+			return grant
+		}
+	}
+	return nil
+}
+
+// expired returns whether the grant expired at the given time, perpetual grants never expire.
+func expired(grant *models.CreditGrant, now time.Time) bool {
+	return grant.ExpiresAt.Valid && !grant.ExpiresAt.Time.After(now)
+}
+
+// activeGrants returns the spendable grants of a license, asset, and credit type in FIFO order, an empty credit type returns the grants of every type.
+func (s *Store) activeGrants(licenseID, assetDID, creditType string, now time.Time) []*models.CreditGrant {
+	var grants []*models.CreditGrant
+	for _, grant := range s.grants {
+		if creditType != "" && grant.CreditType != creditType {
+			continue
+		}
+		if grant.LicenseID == licenseID && grant.AssetDid == assetDID && grant.RemainingAmount > 0 && !expired(grant, now) &&
+			(grant.Status == creditrepo.GrantStatusConfirmed || grant.Status == creditrepo.GrantStatusPending) {
+			grants = append(grants, grant)
+		}
+	}
+	slices.SortFunc(grants, creditrepo.CompareGrantFIFO)
+	return grants
+}
+
+func (s *Store) balance(licenseID, assetDID, creditType string) int64 {
+	balance := int64(0)
+	for _, grant := range s.activeGrants(licenseID, assetDID, creditType, time.Now()) {
+		balance += grant.RemainingAmount
+	}
+	return balance
+}
+
+func (s *Store) debt(licenseID, assetDID, creditType string) int64 {
+	debt := int64(0)
+	for _, grant := range s.grants {
+		if creditType != "" && grant.CreditType != creditType {
+			continue
+		}
+		if grant.LicenseID == licenseID && grant.AssetDid == assetDID && grant.Status == creditrepo.GrantStatusFailed {
+			debt += max(grant.InitialAmount-grant.RemainingAmount, 0)
+		}
+	}
+	return debt
+}
+
+// assets returns the sorted assets of a license that have grants.
+func (s *Store) assets(licenseID string) []string {
+	var assets []string
+	for _, grant := range s.grants {
+		if grant.LicenseID == licenseID && !slices.Contains(assets, grant.AssetDid) {
+			assets = append(assets, grant.AssetDid)
+		}
+	}
+	slices.Sort(assets)
+	return assets
+}
+
+// operationsInPeriod returns the operations of a license, and asset if set, created during the time period.
+func (s *Store) operationsInPeriod(licenseID, assetDID string, fromDate, toDate time.Time) []*models.CreditOperation {
+	var operations []*models.CreditOperation
+	for _, operation := range s.operations {
+		if operation.LicenseID != licenseID || (assetDID != "" && operation.AssetDid != assetDID) {
+			continue
+		}
+		if operation.CreatedAt.Time.Before(fromDate) || (!toDate.IsZero() && operation.CreatedAt.Time.After(toDate)) {
+			continue
+		}
+		operations = append(operations, operation)
+	}
+	return operations
+}
+
+// usage is the net credits an operation used, deductions count up and refunds count down.
+func usage(operation *models.CreditOperation) int64 {
+	switch operation.OperationType {
+	case creditrepo.OperationTypeDeduction:
+		return operation.TotalAmount
+	case creditrepo.OperationTypeRefund:
+		return -operation.TotalAmount
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,11 @@
+package memstore
+
+import (
+	"testing"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo/storetest"
+)
+
+func TestStoreContract(t *testing.T) {
+	storetest.Run(t, New())
+}
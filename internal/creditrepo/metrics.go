@@ -0,0 +1,65 @@
+package creditrepo
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// TransactionDuration tracks how long repository transactions are held open
+	TransactionDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "credit_tracker_transaction_duration_seconds",
+			Help:    "Duration of credit tracker database transactions from begin to commit or rollback",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"operation"},
+	)
+
+	// OperationDuration tracks the wall-clock duration of repository operations, including deadlock retries
+	OperationDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "credit_tracker_operation_duration_seconds",
+			Help:    "Duration of credit tracker repository operations from the first attempt until they return, including deadlock retries",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		[]string{"operation"},
+	)
+
+	// DeadlockRetries counts the attempts of repository operations retried after a deadlock or serialization failure
+	DeadlockRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "credit_tracker_deadlock_retries_total",
+			Help: "Total number of repository operation attempts retried after a deadlock or serialization failure",
+		},
+		[]string{"operation"},
+	)
+
+	// CreditDebt tracks the outstanding debt of each developer license across its assets, licenses without debt have no series
+	CreditDebt = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "credit_tracker_debt",
+			Help: "Outstanding debt from failed grants of a developer license, updated when its debt is created, settled, or blocks a deduction",
+		},
+		[]string{"developer_license"},
+	)
+
+	// GrantLockWaitDuration tracks how long it takes to acquire the row locks on active grants
+	GrantLockWaitDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "credit_tracker_grant_lock_wait_seconds",
+			Help:    "Duration of the locking active grant query, including the time spent waiting for row locks",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+	)
+)
+
+// observeTransaction starts timing a transaction and returns a function that records its duration.
+func observeTransaction(operation string) func() {
+	start := time.Now()
+	return func() {
+		TransactionDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,50 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveTransaction(t *testing.T) {
+	const operation = "TestObserveTransaction"
+
+	observeTransaction(operation)()
+	observeTransaction(operation)()
+
+	assert.Equal(t, uint64(2), histogramSampleCount(t, TransactionDuration.WithLabelValues(operation)))
+}
+
+func TestOperationDuration(t *testing.T) {
+	const operation = "TestOperationDuration"
+	attempts := 0
+
+	// Test: An operation that deadlocks once is retried and observed once
+	result, err := retryTx(context.Background(), New(nil), operation, func(context.Context) (int, error) {
+		attempts++
+		if attempts == 1 {
+			return 0, &pq.Error{Code: DeadlockError}
+		}
+		return attempts, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result)
+
+	assert.Equal(t, uint64(1), histogramSampleCount(t, OperationDuration.WithLabelValues(operation)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(DeadlockRetries.WithLabelValues(operation)))
+}
+
+func histogramSampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+	metric, ok := observer.(prometheus.Metric)
+	require.True(t, ok)
+	out := &dto.Metric{}
+	require.NoError(t, metric.Write(out))
+	return out.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,79 @@
+package creditrepo
+
+import (
+	"sync"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+)
+
+// operationSubscriberBuffer is how many operations a subscriber can fall behind before it is dropped.
+const operationSubscriberBuffer = 100
+
+// OperationHub broadcasts committed operations to the subscribers of their license, within a single instance of the service.
+type OperationHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan RecentOperation]struct{}
+}
+
+// NewOperationHub creates a hub without subscribers.
+func NewOperationHub() *OperationHub {
+	return &OperationHub{subscribers: make(map[string]map[chan RecentOperation]struct{})}
+}
+
+// Subscribe returns a channel of the operations of the license published from now on, and a function that unsubscribes.
+// The channel is closed when unsubscribing, or when the subscriber falls behind by more than the channel buffer,
+// in which case it should subscribe again and catch up from the operations table.
+func (h *OperationHub) Subscribe(licenseID string) (<-chan RecentOperation, func()) {
+	ch := make(chan RecentOperation, operationSubscriberBuffer)
+	h.mu.Lock()
+	if h.subscribers[licenseID] == nil {
+		h.subscribers[licenseID] = make(map[chan RecentOperation]struct{})
+	}
+	h.subscribers[licenseID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.unsubscribe(licenseID, ch)
+	}
+}
+
+// Publish sends a committed operation to the subscribers of its license without waiting on any of them.
+func (h *OperationHub) Publish(operation *models.CreditOperation) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[operation.LicenseID] {
+		select {
+		case ch <- NewRecentOperation(operation):
+		default:
+			// a slow subscriber must not hold up the operations
+			h.unsubscribe(operation.LicenseID, ch)
+		}
+	}
+}
+
+// unsubscribe removes and closes the channel if it is still subscribed, the caller holds the lock.
+func (h *OperationHub) unsubscribe(licenseID string, ch chan RecentOperation) {
+	if _, ok := h.subscribers[licenseID][ch]; !ok {
+		return
+	}
+	delete(h.subscribers[licenseID], ch)
+	if len(h.subscribers[licenseID]) == 0 {
+		delete(h.subscribers, licenseID)
+	}
+	close(ch)
+}
+
+// WithOperationHub publishes the committed operations of the repository to the hub instead of a hub of its own.
+func WithOperationHub(hub *OperationHub) Option {
+	return func(r *Repository) {
+		r.operationHub = hub
+	}
+}
+
+// SubscribeOperations returns a channel of the operations of the license committed by this repository from now on,
+// and a function that unsubscribes. See OperationHub.Subscribe.
+func (r *Repository) SubscribeOperations(licenseID string) (<-chan RecentOperation, func()) {
+	return r.operationHub.Subscribe(licenseID)
+}
@@ -0,0 +1,97 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestOperationHub(t *testing.T) {
+	t.Parallel()
+
+	t.Run("delivers operations of the license", func(t *testing.T) {
+		t.Parallel()
+		hub := NewOperationHub()
+		operations, unsubscribe := hub.Subscribe("license-a")
+		defer unsubscribe()
+
+		hub.Publish(&models.CreditOperation{LicenseID: "license-b", ReferenceID: "other"})
+		hub.Publish(&models.CreditOperation{LicenseID: "license-a", ReferenceID: "ref-1", TotalAmount: 10, CreatedAt: null.TimeFrom(time.Now())})
+
+		operation := <-operations
+		assert.Equal(t, "ref-1", operation.ReferenceID)
+		assert.Equal(t, int64(10), operation.TotalAmount)
+		assert.Empty(t, operations)
+	})
+
+	t.Run("unsubscribing closes the channel", func(t *testing.T) {
+		t.Parallel()
+		hub := NewOperationHub()
+		operations, unsubscribe := hub.Subscribe("license-a")
+		unsubscribe()
+		unsubscribe()
+
+		_, ok := <-operations
+		assert.False(t, ok)
+		hub.Publish(&models.CreditOperation{LicenseID: "license-a"})
+	})
+
+	t.Run("drops subscribers that fall behind", func(t *testing.T) {
+		t.Parallel()
+		hub := NewOperationHub()
+		slow, unsubscribeSlow := hub.Subscribe("license-a")
+		defer unsubscribeSlow()
+		fast, unsubscribeFast := hub.Subscribe("license-a")
+		defer unsubscribeFast()
+
+		received := 0
+		for range operationSubscriberBuffer + 1 {
+			hub.Publish(&models.CreditOperation{LicenseID: "license-a"})
+			<-fast
+			received++
+		}
+
+		// Verify: The slow subscriber gets the buffered operations then a closed channel, the fast one keeps receiving
+		for range operationSubscriberBuffer {
+			_, ok := <-slow
+			require.True(t, ok)
+		}
+		_, ok := <-slow
+		assert.False(t, ok)
+		assert.Equal(t, operationSubscriberBuffer+1, received)
+	})
+}
+
+func TestSubscribeOperations(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+
+	repo := New(dbContainer.DB)
+	ctx := context.Background()
+	licenseID := "test-license-subscribe"
+
+	operations, unsubscribe := repo.SubscribeOperations(licenseID)
+	defer unsubscribe()
+
+	_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xsubscribe", 0, 100, time.Now())
+	require.NoError(t, err)
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 40, testAPIEndpoint, "subscribe-1")
+	require.NoError(t, err)
+	_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 500, testAPIEndpoint, "subscribe-2")
+	require.ErrorIs(t, err, InsufficientCreditsErr)
+
+	// Verify: Committed operations are published in order, the rolled back deduction is not
+	assert.Equal(t, OperationTypeGrantConfirm, (<-operations).OperationType)
+	deduction := <-operations
+	assert.Equal(t, OperationTypeDeduction, deduction.OperationType)
+	assert.Equal(t, "subscribe-1", deduction.ReferenceID)
+	assert.Equal(t, int64(40), deduction.TotalAmount)
+	assert.Empty(t, operations)
+}
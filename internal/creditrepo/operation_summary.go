@@ -0,0 +1,119 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/google/uuid"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// WithSummaryOnlyApps sets the app names whose deductions are recorded as an operation summary only.
+//
+// Skipping the per-grant credit_operation_grants rows reduces write volume for high-volume, low-value deductions
+// at the cost of refund granularity: since it is unknown which grants a summary-only deduction used, its refund
+// is redistributed across the used credits of the license and asset grants in proportion to how much of each grant was used.
+func WithSummaryOnlyApps(appNames ...string) Option {
+	return func(r *Repository) {
+		r.summaryOnlyApps = make(map[string]struct{}, len(appNames))
+		for _, appName := range appNames {
+			r.summaryOnlyApps[appName] = struct{}{}
+		}
+	}
+}
+
+// isSummaryOnly returns true if deductions for the app are recorded without per-grant detail rows.
+func (r *Repository) isSummaryOnly(appName string) bool {
+	_, ok := r.summaryOnlyApps[appName]
+	return ok
+}
+
+// refundSummaryOnly refunds a summary-only deduction by redistributing the refund amount across the used credits
+// of the license and asset grants of its credit type in proportion to how much of each grant was used.
+func (r *Repository) refundSummaryOnly(ctx context.Context, tx *sql.Tx, operation *models.CreditOperation) error {
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.LicenseID.EQ(operation.LicenseID),
+		models.CreditGrantWhere.AssetDid.EQ(operation.AssetDid),
+		models.CreditGrantWhere.CreditType.EQ(operation.CreditType),
+		models.CreditGrantWhere.Status.NEQ(GrantStatusFailed),
+		qm.Where(models.CreditGrantColumns.RemainingAmount+" < "+models.CreditGrantColumns.InitialAmount),
+		qm.OrderBy(models.CreditGrantColumns.ExpiresAt+" ASC"),
+		qm.For("UPDATE"),
+	).All(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to get used grants: %w", err)
+	}
+
+	shares, err := proportionalShares(operation.TotalAmount, grants)
+	if err != nil {
+		return err
+	}
+
+	for i, grant := range grants {
+		if shares[i] == 0 {
+			continue
+		}
+		grant.RemainingAmount += shares[i]
+		grant.UpdatedAt = null.TimeFrom(time.Now())
+		if _, err := grant.Update(ctx, tx, boil.Whitelist(r.grantAmountColumns(grant)...)); err != nil {
+			return fmt.Errorf("failed to update grant %s: %w", grant.TXHash, err)
+		}
+
+		grantDetail := &models.CreditOperationGrant{
+			ID:            uuid.New().String(),
+			AppName:       operation.AppName,
+			ReferenceID:   operation.ReferenceID,
+			OperationType: operation.OperationType,
+			GrantID:       grant.ID,
+			AmountUsed:    shares[i],
+			CreatedAt:     null.TimeFrom(time.Now()),
+		}
+		if err := grantDetail.Insert(ctx, tx, boil.Infer()); err != nil {
+			return fmt.Errorf("failed to record transaction detail: %w", err)
+		}
+	}
+	return nil
+}
+
+// proportionalShares splits the amount across the grants in proportion to their used credits.
+// The remainder left by rounding down is assigned one credit at a time in grant order, never exceeding a grant's used credits.
+func proportionalShares(amount int64, grants []*models.CreditGrant) ([]int64, error) {
+	totalUsed := int64(0)
+	for _, grant := range grants {
+		totalUsed += grant.InitialAmount - grant.RemainingAmount
+	}
+	if amount > totalUsed {
+		return nil, fmt.Errorf("refund amount %d exceeds the %d used credits available to redistribute", amount, totalUsed)
+	}
+
+	shares := make([]int64, len(grants))
+	if amount <= 0 {
+		return shares, nil
+	}
+	leftover := amount
+	for i, grant := range grants {
+		used := uint64(grant.InitialAmount - grant.RemainingAmount)
+		// amount * used / totalUsed without overflowing, the quotient is at most used
+		hi, lo := bits.Mul64(uint64(amount), used)
+		share, _ := bits.Div64(hi, lo, uint64(totalUsed))
+		shares[i] = int64(share)
+		leftover -= shares[i]
+	}
+	// rounding down loses less than one credit per grant, so the leftover is smaller than the number of grants
+	for i, grant := range grants {
+		if leftover == 0 {
+			break
+		}
+		if shares[i] < grant.InitialAmount-grant.RemainingAmount {
+			shares[i]++
+			leftover--
+		}
+	}
+	return shares, nil
+}
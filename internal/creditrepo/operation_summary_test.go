@@ -0,0 +1,149 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+const testSummaryOnlyApp = "test-summary-only-app"
+
+func TestOperationRecordingModes(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db, WithSummaryOnlyApps(testSummaryOnlyApp))
+	ctx := context.Background()
+
+	insertGrants := func(t *testing.T, licenseID string, amounts ...int64) []*models.CreditGrant {
+		t.Helper()
+		grants := make([]*models.CreditGrant, 0, len(amounts))
+		for i, amount := range amounts {
+			grant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        testAssetID,
+				InitialAmount:   amount,
+				RemainingAmount: amount,
+				Status:          GrantStatusConfirmed,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(time.Duration(i+1) * time.Hour)),
+			}
+			require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+			grants = append(grants, grant)
+		}
+		return grants
+	}
+
+	t.Run("verbose mode records per-grant rows", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-recording-verbose"
+		insertGrants(t, licenseID, 100, 100)
+
+		referenceID := uuid.NewString()
+		operation, err := repo.DeductCredits(ctx, licenseID, testAssetID, 150, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+		assert.False(t, operation.SummaryOnly)
+
+		opGrants, err := models.CreditOperationGrants(
+			models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+			models.CreditOperationGrantWhere.OperationType.EQ(OperationTypeDeduction),
+		).All(ctx, db)
+		require.NoError(t, err)
+		assert.Len(t, opGrants, 2)
+	})
+
+	t.Run("summary mode skips per-grant rows", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-recording-summary"
+		insertGrants(t, licenseID, 100, 100)
+
+		referenceID := uuid.NewString()
+		operation, err := repo.DeductCredits(ctx, licenseID, testAssetID, 150, testSummaryOnlyApp, referenceID)
+		require.NoError(t, err)
+		assert.True(t, operation.SummaryOnly)
+
+		opGrants, err := models.CreditOperationGrants(
+			models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+		).All(ctx, db)
+		require.NoError(t, err)
+		assert.Empty(t, opGrants)
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), balance.Balance)
+	})
+
+	t.Run("refund in summary mode is redistributed proportionally", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-recording-summary-refund"
+		grants := insertGrants(t, licenseID, 100, 100)
+
+		// FIFO uses all of the first grant and 50 of the second
+		referenceID := uuid.NewString()
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 150, testSummaryOnlyApp, referenceID)
+		require.NoError(t, err)
+
+		refundOp, err := repo.RefundCredits(ctx, testSummaryOnlyApp, referenceID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(150), refundOp.TotalAmount)
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(200), balance.Balance)
+
+		for _, grant := range grants {
+			require.NoError(t, grant.Reload(ctx, db))
+			assert.Equal(t, grant.InitialAmount, grant.RemainingAmount)
+		}
+
+		refundGrants, err := models.CreditOperationGrants(
+			models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+			models.CreditOperationGrantWhere.OperationType.EQ(OperationTypeRefund),
+		).All(ctx, db)
+		require.NoError(t, err)
+		total := int64(0)
+		for _, refundGrant := range refundGrants {
+			total += refundGrant.AmountUsed
+		}
+		assert.Equal(t, int64(150), total)
+	})
+}
+
+func TestProportionalShares(t *testing.T) {
+	grant := func(initial, remaining int64) *models.CreditGrant {
+		return &models.CreditGrant{InitialAmount: initial, RemainingAmount: remaining}
+	}
+
+	t.Run("shares follow used credits", func(t *testing.T) {
+		shares, err := proportionalShares(30, []*models.CreditGrant{grant(100, 0), grant(100, 50)})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{20, 10}, shares)
+	})
+
+	t.Run("rounding remainder goes to the first grants", func(t *testing.T) {
+		shares, err := proportionalShares(5, []*models.CreditGrant{grant(3, 0), grant(3, 0), grant(3, 0)})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2, 2, 1}, shares)
+	})
+
+	t.Run("large amounts do not overflow", func(t *testing.T) {
+		large := int64(1) << 61
+		shares, err := proportionalShares(large, []*models.CreditGrant{grant(large, 0), grant(large, 0)})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{large / 2, large / 2}, shares)
+	})
+
+	t.Run("amount above used credits", func(t *testing.T) {
+		_, err := proportionalShares(101, []*models.CreditGrant{grant(100, 0)})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,197 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const (
+	// pendingGrantScrapeTimeout bounds the query of a pending grant metrics scrape.
+	pendingGrantScrapeTimeout = 5 * time.Second
+	// stalePendingGrantBatchSize is the number of stale pending grants failed per transaction.
+	stalePendingGrantBatchSize = 100
+)
+
+// PendingGrantStats is the backlog of grants waiting for the confirmation of their burn.
+type PendingGrantStats struct {
+	// Number of pending grants
+	Count int64 `json:"count"`
+	// Credits of the pending grants
+	TotalAmount int64 `json:"totalAmount"`
+	// Creation time of the oldest pending grant, nil without pending grants
+	OldestCreatedAt *time.Time `json:"oldestCreatedAt"`
+}
+
+// pendingGrantStatsRow is a row of the pending grant stats query.
+type pendingGrantStatsRow struct {
+	Count       int64     `boil:"count"`
+	TotalAmount int64     `boil:"total_amount"`
+	Oldest      null.Time `boil:"oldest"`
+}
+
+// GetPendingGrantStats returns the number, credits, and oldest creation time of the pending grants of a license,
+// across every asset when assetDID is empty.
+func (r *Repository) GetPendingGrantStats(ctx context.Context, licenseID, assetDID string) (*PendingGrantStats, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	mods := []qm.QueryMod{models.CreditGrantWhere.LicenseID.EQ(licenseID)}
+	if assetDID != "" {
+		mods = append(mods, models.CreditGrantWhere.AssetDid.EQ(assetDID))
+	}
+	return r.pendingGrantStats(ctx, mods...)
+}
+
+// pendingGrantStats aggregates the pending grants matching the mods.
+func (r *Repository) pendingGrantStats(ctx context.Context, mods ...qm.QueryMod) (*PendingGrantStats, error) {
+	mods = append(mods,
+		qm.Select(fmt.Sprintf("COUNT(*) AS count, COALESCE(SUM(%s), 0) AS total_amount, MIN(%s) AS oldest",
+			models.CreditGrantColumns.InitialAmount, models.CreditGrantColumns.CreatedAt)),
+		models.CreditGrantWhere.Status.EQ(GrantStatusPending),
+	)
+	var row pendingGrantStatsRow
+	if err := models.CreditGrants(mods...).Bind(ctx, r.db, &row); err != nil {
+		return nil, fmt.Errorf("failed to get pending grant stats: %w", err)
+	}
+	return &PendingGrantStats{
+		Count:           row.Count,
+		TotalAmount:     row.TotalAmount,
+		OldestCreatedAt: row.Oldest.Ptr(),
+	}, nil
+}
+
+// ExpireStalePendingGrants fails the pending grants created more than olderThan ago, whose burn never confirmed,
+// so they stop counting toward the spendable balance. Each failed grant gets a grant_failed operation.
+// It returns the number of grants it failed.
+func (r *Repository) ExpireStalePendingGrants(ctx context.Context, olderThan time.Duration) (int, error) {
+	if olderThan <= 0 {
+		return 0, fmt.Errorf("olderThan must be positive")
+	}
+	total := 0
+	for {
+		failed, err := retryTx(ctx, r, "ExpireStalePendingGrants", func(ctx context.Context) (int, error) {
+			return r.failStalePendingGrantsBatch(ctx, time.Now().Add(-olderThan))
+		})
+		if err != nil {
+			return total, err
+		}
+		total += failed
+		if failed < stalePendingGrantBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// failStalePendingGrantsBatch fails a batch of the pending grants created before the cutoff in one transaction.
+// Grants locked by a concurrent confirmation are skipped and left for the next run.
+func (r *Repository) failStalePendingGrantsBatch(ctx context.Context, cutoff time.Time) (int, error) {
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("ExpireStalePendingGrants")()
+	defer rollbackTx(ctx, tx)
+
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.Status.EQ(GrantStatusPending),
+		models.CreditGrantWhere.CreatedAt.LT(null.TimeFrom(cutoff)),
+		qm.OrderBy(models.CreditGrantColumns.CreatedAt+" ASC, "+models.CreditGrantColumns.ID+" ASC"),
+		qm.Limit(stalePendingGrantBatchSize),
+		qm.For("UPDATE SKIP LOCKED"),
+	).All(ctx, tx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stale pending grants: %w", err)
+	}
+
+	type licenseAsset struct{ licenseID, assetDID string }
+	touched := map[licenseAsset]struct{}{}
+	operations := make([]*models.CreditOperation, 0, len(grants))
+	for _, grant := range grants {
+		operation, err := r.failGrantTx(ctx, tx, grant)
+		if err != nil {
+			return 0, err
+		}
+		operations = append(operations, operation)
+		touched[licenseAsset{grant.LicenseID, grant.AssetDid}] = struct{}{}
+	}
+	for key := range touched {
+		if err := r.updateBalanceSummary(ctx, tx, key.licenseID, key.assetDID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, operation := range operations {
+		r.logOperation(ctx, operation, "failed grant")
+	}
+	licenses := map[string]struct{}{}
+	for key := range touched {
+		licenses[key.licenseID] = struct{}{}
+	}
+	for licenseID := range licenses {
+		r.observeDebt(ctx, licenseID)
+	}
+	return len(grants), nil
+}
+
+var (
+	pendingGrantsDesc = prometheus.NewDesc(
+		"credit_tracker_pending_grants",
+		"Number of grants waiting for the confirmation of their burn",
+		nil, nil,
+	)
+	pendingGrantCreditsDesc = prometheus.NewDesc(
+		"credit_tracker_pending_grant_credits",
+		"Credits of the grants waiting for the confirmation of their burn",
+		nil, nil,
+	)
+	oldestPendingGrantAgeDesc = prometheus.NewDesc(
+		"credit_tracker_oldest_pending_grant_age_seconds",
+		"Age of the oldest grant waiting for the confirmation of its burn, zero without pending grants",
+		nil, nil,
+	)
+)
+
+// PendingGrantCollector is a prometheus collector of the pending grant backlog across every license,
+// queried on each scrape.
+type PendingGrantCollector struct {
+	repo *Repository
+}
+
+// NewPendingGrantCollector creates a collector of the pending grants of the repository.
+func NewPendingGrantCollector(repo *Repository) *PendingGrantCollector {
+	return &PendingGrantCollector{repo: repo}
+}
+
+// Describe implements prometheus.Collector.
+func (c *PendingGrantCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pendingGrantsDesc
+	ch <- pendingGrantCreditsDesc
+	ch <- oldestPendingGrantAgeDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *PendingGrantCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), pendingGrantScrapeTimeout)
+	defer cancel()
+	stats, err := c.repo.pendingGrantStats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(pendingGrantsDesc, err)
+		return
+	}
+	var oldestAge float64
+	if stats.OldestCreatedAt != nil {
+		oldestAge = time.Since(*stats.OldestCreatedAt).Seconds()
+	}
+	ch <- prometheus.MustNewConstMetric(pendingGrantsDesc, prometheus.GaugeValue, float64(stats.Count))
+	ch <- prometheus.MustNewConstMetric(pendingGrantCreditsDesc, prometheus.GaugeValue, float64(stats.TotalAmount))
+	ch <- prometheus.MustNewConstMetric(oldestPendingGrantAgeDesc, prometheus.GaugeValue, oldestAge)
+}
@@ -0,0 +1,139 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGetPendingGrantStats(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	licenseID := "test-license-pending-stats"
+	otherAssetID := testAssetID + "1"
+	oldest := time.Now().Add(-3 * time.Hour).Truncate(time.Microsecond)
+	grantSpecs := []struct {
+		assetDID  string
+		status    string
+		amount    int64
+		createdAt time.Time
+	}{
+		{assetDID: testAssetID, status: GrantStatusPending, amount: 100, createdAt: oldest},
+		{assetDID: testAssetID, status: GrantStatusPending, amount: 200, createdAt: time.Now().Add(-time.Hour)},
+		{assetDID: otherAssetID, status: GrantStatusPending, amount: 400, createdAt: time.Now()},
+		{assetDID: testAssetID, status: GrantStatusConfirmed, amount: 800, createdAt: oldest.Add(-time.Hour)},
+		{assetDID: testAssetID, status: GrantStatusFailed, amount: 1600, createdAt: oldest.Add(-time.Hour)},
+	}
+	for _, spec := range grantSpecs {
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        spec.assetDID,
+			InitialAmount:   spec.amount,
+			RemainingAmount: spec.amount,
+			Status:          spec.status,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			CreatedAt:       null.TimeFrom(spec.createdAt),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+	}
+
+	t.Run("single asset", func(t *testing.T) {
+		t.Parallel()
+		stats, err := repo.GetPendingGrantStats(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stats.Count)
+		assert.Equal(t, int64(300), stats.TotalAmount)
+		require.NotNil(t, stats.OldestCreatedAt)
+		assert.True(t, oldest.Equal(*stats.OldestCreatedAt))
+	})
+
+	t.Run("every asset", func(t *testing.T) {
+		t.Parallel()
+		stats, err := repo.GetPendingGrantStats(ctx, licenseID, "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stats.Count)
+		assert.Equal(t, int64(700), stats.TotalAmount)
+	})
+
+	t.Run("no pending grants", func(t *testing.T) {
+		t.Parallel()
+		stats, err := repo.GetPendingGrantStats(ctx, "test-license-pending-stats-none", "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), stats.Count)
+		assert.Equal(t, int64(0), stats.TotalAmount)
+		assert.Nil(t, stats.OldestCreatedAt)
+	})
+
+	t.Run("collector", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, 3, testutil.CollectAndCount(NewPendingGrantCollector(repo)))
+	})
+}
+
+func TestExpireStalePendingGrants(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	licenseID := "test-license-stale-pending"
+	insertGrant := func(status string, age time.Duration) *models.CreditGrant {
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          status,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			CreatedAt:       null.TimeFrom(time.Now().Add(-age)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		return grant
+	}
+	stale := insertGrant(GrantStatusPending, 2*time.Hour)
+	recent := insertGrant(GrantStatusPending, time.Minute)
+	confirmed := insertGrant(GrantStatusConfirmed, 2*time.Hour)
+
+	failed, err := repo.ExpireStalePendingGrants(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, failed, 1)
+
+	for grant, status := range map[*models.CreditGrant]string{
+		stale:     GrantStatusFailed,
+		recent:    GrantStatusPending,
+		confirmed: GrantStatusConfirmed,
+	} {
+		require.NoError(t, grant.Reload(ctx, db))
+		assert.Equal(t, status, grant.Status, grant.ID)
+	}
+
+	operation, err := models.FindCreditOperation(ctx, db, "credit_tracker", stale.ID, OperationTypeGrantFailed)
+	require.NoError(t, err)
+	assert.Equal(t, licenseID, operation.LicenseID)
+	assert.Equal(t, defaultGrantAmount, operation.TotalAmount)
+
+	// the failed grant no longer counts toward the balance
+	balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+	require.NoError(t, err)
+	assert.Equal(t, 2*defaultGrantAmount, balance.Balance)
+
+	_, err = repo.ExpireStalePendingGrants(ctx, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// CreatePerpetualGrant creates a confirmed grant that never expires, for promotional or enterprise credits that are not bought with a burn.
+// The reference ID identifies the grant in place of a burn transaction hash.
+// Perpetual grants are spent after every dated grant of the license and asset.
+func (r *Repository) CreatePerpetualGrant(ctx context.Context, licenseID, assetDID string, creditAmount uint64, referenceID string) (*models.CreditOperation, error) {
+	if creditAmount == 0 {
+		return nil, fmt.Errorf("invalid amount: %d. Amount must be positive", creditAmount)
+	}
+	if creditAmount > math.MaxInt64 {
+		return nil, fmt.Errorf("credit amount is too large must be less than %d", math.MaxInt64)
+	}
+	if licenseID == "" || assetDID == "" || referenceID == "" {
+		return nil, fmt.Errorf("licenseID, assetDID, and referenceID are required")
+	}
+	return retryTx(ctx, r, "CreatePerpetualGrant", func(ctx context.Context) (*models.CreditOperation, error) {
+		return r.createPerpetualGrantInternal(ctx, licenseID, assetDID, int64(creditAmount), referenceID)
+	})
+}
+
+// createPerpetualGrantInternal is the internal implementation of CreatePerpetualGrant
+func (r *Repository) createPerpetualGrantInternal(ctx context.Context, licenseID, assetDID string, amount int64, referenceID string) (*models.CreditOperation, error) {
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("CreatePerpetualGrant")()
+	defer rollbackTx(ctx, tx)
+
+	grant := &models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        assetDID,
+		InitialAmount:   amount,
+		RemainingAmount: amount,
+		TXHash:          referenceID,
+		Status:          GrantStatusConfirmed,
+		CreatedAt:       null.TimeFrom(time.Now()),
+		UpdatedAt:       null.TimeFrom(time.Now()),
+	}
+	if err := grant.Insert(ctx, tx, boil.Infer()); err != nil {
+		return nil, fmt.Errorf("failed to create grant record: %w", err)
+	}
+
+	operation, err := r.recordGrantConfirmation(ctx, tx, grant, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.logOperation(ctx, operation, "created perpetual grant")
+
+	return operation, nil
+}
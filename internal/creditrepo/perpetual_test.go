@@ -0,0 +1,126 @@
+package creditrepo
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestCompareGrantFIFOPerpetual(t *testing.T) {
+	now := time.Now()
+	perpetual := &models.CreditGrant{ID: "perpetual", CreatedAt: null.TimeFrom(now.Add(-time.Hour))}
+	later := &models.CreditGrant{ID: "later", ExpiresAt: null.TimeFrom(now.Add(48 * time.Hour)), CreatedAt: null.TimeFrom(now)}
+	sooner := &models.CreditGrant{ID: "sooner", ExpiresAt: null.TimeFrom(now.Add(24 * time.Hour)), CreatedAt: null.TimeFrom(now)}
+
+	grants := []*models.CreditGrant{perpetual, later, sooner}
+	slices.SortFunc(grants, CompareGrantFIFO)
+	assert.Equal(t, []*models.CreditGrant{sooner, later, perpetual}, grants)
+
+	assert.True(t, wasSpendable(perpetual, now.AddDate(100, 0, 0)))
+	assert.False(t, wasSpendable(sooner, now.AddDate(0, 0, 2)))
+}
+
+func TestCreatePerpetualGrant(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	ctx := context.Background()
+
+	t.Run("spent after dated grants", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db)
+		licenseID := "test-license-perpetual-fifo"
+		_, err := repo.CreatePerpetualGrant(ctx, licenseID, testAssetID, 500, "promo-fifo")
+		require.NoError(t, err)
+		dated := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   100,
+			RemainingAmount: 100,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, dated.Insert(ctx, db, boil.Infer()))
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(600), balance.Balance)
+
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 150, testAPIEndpoint, "perpetual-fifo-1")
+		require.NoError(t, err)
+
+		require.NoError(t, dated.Reload(ctx, db))
+		assert.Zero(t, dated.RemainingAmount)
+		perpetual, err := models.CreditGrants(
+			models.CreditGrantWhere.LicenseID.EQ(licenseID),
+			models.CreditGrantWhere.ExpiresAt.IsNull(),
+		).One(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(450), perpetual.RemainingAmount)
+		assert.Equal(t, GrantStatusConfirmed, perpetual.Status)
+		assert.Equal(t, "promo-fifo", perpetual.TXHash)
+	})
+
+	t.Run("remains spendable while dated grants expire", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db, WithLazyExpiration(true))
+		licenseID := "test-license-perpetual-expiry"
+		_, err := repo.CreatePerpetualGrant(ctx, licenseID, testAssetID, 200, "promo-expiry")
+		require.NoError(t, err)
+		// a perpetual grant created long ago is still active, unlike a dated grant of the same age
+		old := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   300,
+			RemainingAmount: 300,
+			Status:          GrantStatusConfirmed,
+			CreatedAt:       null.TimeFrom(time.Now().AddDate(-5, 0, 0)),
+		}
+		require.NoError(t, old.Insert(ctx, db, boil.Infer()))
+		expired := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   100,
+			RemainingAmount: 100,
+			Status:          GrantStatusConfirmed,
+			CreatedAt:       null.TimeFrom(time.Now().AddDate(-5, 0, 0)),
+			ExpiresAt:       null.TimeFrom(time.Now().AddDate(-5, 1, 0)),
+		}
+		require.NoError(t, expired.Insert(ctx, db, boil.Infer()))
+
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(500), balance.Balance)
+
+		require.NoError(t, expired.Reload(ctx, db))
+		assert.Equal(t, GrantStatusExpired, expired.Status)
+		require.NoError(t, old.Reload(ctx, db))
+		assert.Equal(t, GrantStatusConfirmed, old.Status)
+
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 500, testAPIEndpoint, "perpetual-expiry-1")
+		require.NoError(t, err)
+
+		grants, err := repo.ListGrants(ctx, licenseID, testAssetID, ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, grants, 2)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+		repo := New(db)
+		_, err := repo.CreatePerpetualGrant(ctx, "test-license-perpetual-invalid", testAssetID, 0, "promo-invalid")
+		require.Error(t, err)
+		_, err = repo.CreatePerpetualGrant(ctx, "test-license-perpetual-invalid", testAssetID, 10, "")
+		require.Error(t, err)
+	})
+}
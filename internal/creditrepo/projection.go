@@ -0,0 +1,69 @@
+package creditrepo
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	defaultExhaustionRounding   = 24 * time.Hour
+	defaultUtilizationPrecision = 4
+)
+
+// ProjectionOptions controls the rounding of the projected exhaustion and utilization computations.
+type ProjectionOptions struct {
+	// ExhaustionRounding is the unit the projected exhaustion time is rounded up to. Zero disables rounding.
+	ExhaustionRounding time.Duration
+	// UtilizationPrecision is the number of decimal places the utilization rate is rounded to.
+	UtilizationPrecision int
+}
+
+// DefaultProjectionOptions rounds exhaustion to whole days and utilization to 4 decimal places.
+func DefaultProjectionOptions() ProjectionOptions {
+	return ProjectionOptions{
+		ExhaustionRounding:   defaultExhaustionRounding,
+		UtilizationPrecision: defaultUtilizationPrecision,
+	}
+}
+
+// UtilizationRate returns the fraction of granted credits that were used, clamped to [0, 1].
+// Returns nil when nothing was granted since the rate is undefined.
+func (o ProjectionOptions) UtilizationRate(used, granted int64) *float64 {
+	if granted <= 0 {
+		return nil
+	}
+	rate := float64(used) / float64(granted)
+	rate = min(max(rate, 0), 1)
+	precision := max(o.UtilizationPrecision, 0)
+	scale := math.Pow(10, float64(precision))
+	rate = math.Round(rate*scale) / scale
+	return &rate
+}
+
+// ProjectedExhaustion returns when the remaining credits will run out if usage continues at the
+// rate observed over the given period. Returns nil when there was no usage or the period is empty,
+// since the credits are not projected to run out.
+func (o ProjectionOptions) ProjectedExhaustion(remaining, used int64, period time.Duration, now time.Time) *time.Time {
+	if used <= 0 || period <= 0 {
+		return nil
+	}
+	if remaining <= 0 {
+		return &now
+	}
+	// remaining / (used / period) computed in float to avoid overflowing the duration multiplication
+	untilExhausted := float64(period) * (float64(remaining) / float64(used))
+	if untilExhausted >= float64(math.MaxInt64-max(o.ExhaustionRounding, 0)) || math.IsNaN(untilExhausted) {
+		return nil
+	}
+	duration := time.Duration(untilExhausted)
+	if o.ExhaustionRounding > 0 {
+		// round up so we never project exhaustion earlier than the raw estimate
+		rounded := duration.Truncate(o.ExhaustionRounding)
+		if rounded < duration {
+			rounded += o.ExhaustionRounding
+		}
+		duration = rounded
+	}
+	exhaustion := now.Add(duration)
+	return &exhaustion
+}
@@ -0,0 +1,89 @@
+package creditrepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUtilizationRate(t *testing.T) {
+	opts := DefaultProjectionOptions()
+
+	t.Run("zero grants is undefined", func(t *testing.T) {
+		assert.Nil(t, opts.UtilizationRate(10, 0))
+	})
+
+	t.Run("zero usage", func(t *testing.T) {
+		rate := opts.UtilizationRate(0, 100)
+		require.NotNil(t, rate)
+		assert.Equal(t, 0.0, *rate)
+	})
+
+	t.Run("normal usage is rounded", func(t *testing.T) {
+		rate := opts.UtilizationRate(1, 3)
+		require.NotNil(t, rate)
+		assert.Equal(t, 0.3333, *rate)
+	})
+
+	t.Run("usage above grants is clamped", func(t *testing.T) {
+		rate := opts.UtilizationRate(150, 100)
+		require.NotNil(t, rate)
+		assert.Equal(t, 1.0, *rate)
+	})
+
+	t.Run("negative usage is clamped", func(t *testing.T) {
+		rate := opts.UtilizationRate(-5, 100)
+		require.NotNil(t, rate)
+		assert.Equal(t, 0.0, *rate)
+	})
+
+	t.Run("custom precision", func(t *testing.T) {
+		rate := ProjectionOptions{UtilizationPrecision: 1}.UtilizationRate(1, 3)
+		require.NotNil(t, rate)
+		assert.Equal(t, 0.3, *rate)
+	})
+}
+
+func TestProjectedExhaustion(t *testing.T) {
+	opts := DefaultProjectionOptions()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	t.Run("zero usage never exhausts", func(t *testing.T) {
+		assert.Nil(t, opts.ProjectedExhaustion(100, 0, week, now))
+	})
+
+	t.Run("zero period is undefined", func(t *testing.T) {
+		assert.Nil(t, opts.ProjectedExhaustion(100, 10, 0, now))
+	})
+
+	t.Run("zero remaining is exhausted now", func(t *testing.T) {
+		exhaustion := opts.ProjectedExhaustion(0, 10, week, now)
+		require.NotNil(t, exhaustion)
+		assert.Equal(t, now, *exhaustion)
+	})
+
+	t.Run("normal usage is rounded up to whole days", func(t *testing.T) {
+		// 7 credits per week is 1 per day, 10 remaining lasts 10 days
+		exhaustion := opts.ProjectedExhaustion(10, 7, week, now)
+		require.NotNil(t, exhaustion)
+		assert.Equal(t, now.AddDate(0, 0, 10), *exhaustion)
+
+		// 2 per day, 5 remaining lasts 2.5 days which rounds up to 3
+		exhaustion = opts.ProjectedExhaustion(5, 14, week, now)
+		require.NotNil(t, exhaustion)
+		assert.Equal(t, now.AddDate(0, 0, 3), *exhaustion)
+	})
+
+	t.Run("no rounding", func(t *testing.T) {
+		exhaustion := ProjectionOptions{}.ProjectedExhaustion(5, 14, week, now)
+		require.NotNil(t, exhaustion)
+		assert.Equal(t, now.Add(60*time.Hour), *exhaustion)
+	})
+
+	t.Run("overflowing projection is undefined", func(t *testing.T) {
+		assert.Nil(t, opts.ProjectedExhaustion(1<<62, 1, week, now))
+	})
+}
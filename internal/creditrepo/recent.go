@@ -0,0 +1,79 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const (
+	defaultRecentOperationsLimit = 10
+	maxRecentOperationsLimit     = 100
+)
+
+// RecentOperation is an operation of a license without the grants it touched.
+type RecentOperation struct {
+	// Asset DID
+	AssetDID string `json:"assetDid"`
+	// Type of the operation
+	OperationType string `json:"operationType"`
+	// Total number of credits of the operation
+	TotalAmount int64 `json:"totalAmount"`
+	// Name of the app that made the operation
+	AppName string `json:"appName"`
+	// Reference ID of the operation
+	ReferenceID string `json:"referenceId"`
+	// When the operation was made
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// GetRecentOperations returns the most recent operations of a license across all assets, newest first.
+// Unlike GetOperationHistory it takes no filters or offset and does not load the grants of each operation,
+// so it is a single scan of the license and creation time index. A limit of zero uses the default.
+func (r *Repository) GetRecentOperations(ctx context.Context, licenseID string, limit int) ([]RecentOperation, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+	if limit == 0 {
+		limit = defaultRecentOperationsLimit
+	}
+	limit = min(limit, maxRecentOperationsLimit)
+	return recentOperations(ctx, r.db, licenseID, limit)
+}
+
+// recentOperations returns up to limit of the most recent operations of a license using the given executor, newest first.
+func recentOperations(ctx context.Context, exec boil.ContextExecutor, licenseID string, limit int) ([]RecentOperation, error) {
+	operations, err := models.CreditOperations(
+		models.CreditOperationWhere.LicenseID.EQ(licenseID),
+		qm.OrderBy(models.CreditOperationColumns.CreatedAt+" DESC"),
+		qm.Limit(limit),
+	).All(ctx, exec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent operations: %w", err)
+	}
+
+	recent := make([]RecentOperation, 0, len(operations))
+	for _, operation := range operations {
+		recent = append(recent, NewRecentOperation(operation))
+	}
+	return recent, nil
+}
+
+// NewRecentOperation returns the operation without the grants it touched.
+func NewRecentOperation(operation *models.CreditOperation) RecentOperation {
+	return RecentOperation{
+		AssetDID:      operation.AssetDid,
+		OperationType: operation.OperationType,
+		TotalAmount:   operation.TotalAmount,
+		AppName:       operation.AppName,
+		ReferenceID:   operation.ReferenceID,
+		CreatedAt:     operation.CreatedAt.Time,
+	}
+}
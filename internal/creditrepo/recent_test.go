@@ -0,0 +1,90 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGetRecentOperations(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	licenseID := "test-license-recent-operations"
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	// Setup: 15 deductions a minute apart across two assets, and one of another license
+	for i := range 15 {
+		assetDID := testAssetID
+		if i%2 == 1 {
+			assetDID = testAssetID + "-other"
+		}
+		operation := &models.CreditOperation{
+			AppName:       testAPIEndpoint,
+			ReferenceID:   fmt.Sprintf("recent-%02d", i),
+			OperationType: OperationTypeDeduction,
+			LicenseID:     licenseID,
+			AssetDid:      assetDID,
+			TotalAmount:   int64(i + 1),
+			CreatedAt:     null.TimeFrom(start.Add(time.Duration(i) * time.Minute)),
+		}
+		require.NoError(t, operation.Insert(ctx, db, boil.Infer()))
+	}
+	otherLicense := &models.CreditOperation{
+		AppName:       testAPIEndpoint,
+		ReferenceID:   "recent-other-license",
+		OperationType: OperationTypeDeduction,
+		LicenseID:     licenseID + "-other",
+		AssetDid:      testAssetID,
+		TotalAmount:   1,
+		CreatedAt:     null.TimeFrom(start.Add(time.Hour)),
+	}
+	require.NoError(t, otherLicense.Insert(ctx, db, boil.Infer()))
+
+	t.Run("newest first up to the limit", func(t *testing.T) {
+		t.Parallel()
+		recent, err := repo.GetRecentOperations(ctx, licenseID, 3)
+		require.NoError(t, err)
+		require.Len(t, recent, 3)
+		assert.Equal(t, "recent-14", recent[0].ReferenceID)
+		assert.Equal(t, "recent-13", recent[1].ReferenceID)
+		assert.Equal(t, testAssetID+"-other", recent[1].AssetDID)
+		assert.Equal(t, "recent-12", recent[2].ReferenceID)
+		assert.Equal(t, int64(13), recent[2].TotalAmount)
+		assert.True(t, start.Add(14*time.Minute).Equal(recent[0].CreatedAt))
+	})
+
+	t.Run("default and maximum limit", func(t *testing.T) {
+		t.Parallel()
+		recent, err := repo.GetRecentOperations(ctx, licenseID, 0)
+		require.NoError(t, err)
+		assert.Len(t, recent, defaultRecentOperationsLimit)
+
+		recent, err = repo.GetRecentOperations(ctx, licenseID, maxRecentOperationsLimit+1)
+		require.NoError(t, err)
+		require.Len(t, recent, 15)
+		for i := 1; i < len(recent); i++ {
+			assert.False(t, recent[i].CreatedAt.After(recent[i-1].CreatedAt))
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.GetRecentOperations(ctx, "", 5)
+		require.Error(t, err)
+		_, err = repo.GetRecentOperations(ctx, licenseID, -1)
+		require.Error(t, err)
+	})
+}
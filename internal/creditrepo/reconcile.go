@@ -0,0 +1,110 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/rs/zerolog"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// ReconcileBalance computes the balance of a license and asset two ways, by summing the remaining amounts of its grants
+// and by replaying the credit_operation_grants allocations against the initial amounts, and returns both.
+// Both cover every grant regardless of status or expiration, so they are not the spendable balance.
+// Summary-only operations record no allocations, their total amounts are taken off the ledger balance instead.
+// A discrepancy is logged as a warning.
+func (r *Repository) ReconcileBalance(ctx context.Context, licenseID, assetDID string) (ledgerBalance, grantBalance int64, err error) {
+	if licenseID == "" || assetDID == "" {
+		return 0, 0, fmt.Errorf("licenseID and assetDID are required")
+	}
+	type balances struct{ ledger, grant int64 }
+	result, err := retryTx(ctx, r, "ReconcileBalance", func(ctx context.Context) (balances, error) {
+		ledger, grant, err := r.reconcileBalanceInternal(ctx, licenseID, assetDID)
+		return balances{ledger: ledger, grant: grant}, err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if result.ledger != result.grant {
+		zerolog.Ctx(ctx).Warn().
+			Str("licenseId", licenseID).
+			Str("assetDid", assetDID).
+			Int64("ledgerBalance", result.ledger).
+			Int64("grantBalance", result.grant).
+			Msg("grant balance does not match the operation ledger")
+	}
+	return result.ledger, result.grant, nil
+}
+
+// reconcileBalanceInternal is the internal implementation of ReconcileBalance
+func (r *Repository) reconcileBalanceInternal(ctx context.Context, licenseID, assetDID string) (int64, int64, error) {
+	// repeatable read makes the grants and allocations come from the same snapshot of the database
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("ReconcileBalance")()
+	defer rollbackTx(ctx, tx)
+
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.LicenseID.EQ(licenseID),
+		models.CreditGrantWhere.AssetDid.EQ(assetDID),
+	).All(ctx, tx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get grants: %w", err)
+	}
+	grantIDs := make([]string, len(grants))
+	for i, grant := range grants {
+		grantIDs[i] = grant.ID
+	}
+	allocations, err := models.CreditOperationGrants(
+		models.CreditOperationGrantWhere.GrantID.IN(grantIDs),
+	).All(ctx, tx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get grant allocations: %w", err)
+	}
+
+	var summaryOnlyTotal int64
+	err = models.CreditOperations(
+		qm.Select("COALESCE(SUM("+models.CreditOperationColumns.TotalAmount+"), 0)"),
+		models.CreditOperationWhere.LicenseID.EQ(licenseID),
+		models.CreditOperationWhere.AssetDid.EQ(assetDID),
+		models.CreditOperationWhere.SummaryOnly.EQ(true),
+	).QueryRowContext(ctx, tx).Scan(&summaryOnlyTotal)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get summary-only operation total: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	var grantBalance int64
+	for _, grant := range grants {
+		grantBalance += grant.RemainingAmount
+	}
+	return LedgerBalance(grants, allocations) - summaryOnlyTotal, grantBalance, nil
+}
+
+// LedgerBalance replays the allocations of the grants against their initial amounts and returns the resulting total.
+func LedgerBalance(grants []*models.CreditGrant, allocations []*models.CreditOperationGrant) int64 {
+	grantsByID := make(map[string]*models.CreditGrant, len(grants))
+	var balance int64
+	for _, grant := range grants {
+		grantsByID[grant.ID] = grant
+		balance += grant.InitialAmount
+	}
+	for _, allocation := range allocations {
+		grant, ok := grantsByID[allocation.GrantID]
+		if !ok {
+			continue
+		}
+		balance += allocationDelta(allocation, grant)
+	}
+	return balance
+}
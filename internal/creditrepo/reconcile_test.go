@@ -0,0 +1,80 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestReconcileBalance(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	summaryOnlyApp := "summary-only-reconcile"
+	repo := New(db, WithSummaryOnlyApps(summaryOnlyApp))
+	ctx := context.Background()
+
+	// confirmGrant creates a confirmed grant of the license and asset and returns it.
+	confirmGrant := func(t *testing.T, licenseID string, amount uint64) *models.CreditGrant {
+		t.Helper()
+		operation, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0x"+uuid.NewString(), 0, amount, time.Now())
+		require.NoError(t, err)
+		grant, err := models.FindCreditGrant(ctx, db, operation.ReferenceID)
+		require.NoError(t, err)
+		return grant
+	}
+
+	t.Run("consistent account", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-reconcile-consistent"
+		confirmGrant(t, licenseID, 1000)
+		confirmGrant(t, licenseID, 500)
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 1200, testAPIEndpoint, "reconcile-1")
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 100, testAPIEndpoint, "reconcile-2")
+		require.NoError(t, err)
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, "reconcile-2")
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 50, summaryOnlyApp, "reconcile-3")
+		require.NoError(t, err)
+
+		ledgerBalance, grantBalance, err := repo.ReconcileBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(250), grantBalance)
+		assert.Equal(t, grantBalance, ledgerBalance)
+	})
+
+	t.Run("corrupted grant", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-reconcile-corrupted"
+		grant := confirmGrant(t, licenseID, 1000)
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 300, testAPIEndpoint, "reconcile-corrupted")
+		require.NoError(t, err)
+
+		// Setup: Change the remaining amount without recording an allocation
+		require.NoError(t, grant.Reload(ctx, db))
+		grant.RemainingAmount += 25
+		_, err = grant.Update(ctx, db, boil.Whitelist(models.CreditGrantColumns.RemainingAmount))
+		require.NoError(t, err)
+
+		ledgerBalance, grantBalance, err := repo.ReconcileBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(700), ledgerBalance)
+		assert.Equal(t, int64(725), grantBalance)
+	})
+
+	t.Run("license and asset are required", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := repo.ReconcileBalance(ctx, "", testAssetID)
+		require.Error(t, err)
+	})
+}
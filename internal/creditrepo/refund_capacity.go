@@ -0,0 +1,92 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/google/uuid"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// RefundOverflowPolicy decides what happens when refunding a grant would raise its remaining amount above its initial amount,
+// e.g. when the grant was reduced by a chargeback between the deduction and its refund.
+type RefundOverflowPolicy string
+
+const (
+	// RefundOverflowError fails the refund with RefundExceedsCapacityErr.
+	RefundOverflowError RefundOverflowPolicy = "error"
+	// RefundOverflowRedirect caps the refund at the grant's capacity and refunds the excess to other active grants with spare capacity.
+	RefundOverflowRedirect RefundOverflowPolicy = "redirect"
+)
+
+// WithRefundOverflowPolicy sets how refunds that exceed a grant's capacity are handled.
+func WithRefundOverflowPolicy(policy RefundOverflowPolicy) Option {
+	return func(r *Repository) {
+		r.refundOverflow = policy
+	}
+}
+
+// capRefund returns the part of the refund amount that fits in the grant and the excess that does not.
+// The excess is only allowed with the redirect policy.
+func (r *Repository) capRefund(grant *models.CreditGrant, amount int64) (int64, int64, error) {
+	capacity := max(grant.InitialAmount-grant.RemainingAmount, 0)
+	if amount <= capacity {
+		return amount, 0, nil
+	}
+	if r.refundOverflow != RefundOverflowRedirect {
+		return 0, 0, fmt.Errorf("%w: grant %s can take %d of the %d refunded credits", RefundExceedsCapacityErr, grant.ID, capacity, amount)
+	}
+	return capacity, amount - capacity, nil
+}
+
+// redirectRefundExcess refunds the excess to the active grants of the license, asset, and credit type with spare capacity, soonest expiring first.
+func (r *Repository) redirectRefundExcess(ctx context.Context, tx *sql.Tx, operation *models.CreditOperation, excess int64) error {
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.LicenseID.EQ(operation.LicenseID),
+		models.CreditGrantWhere.AssetDid.EQ(operation.AssetDid),
+		models.CreditGrantWhere.CreditType.EQ(operation.CreditType),
+		notExpired(time.Now()),
+		models.CreditGrantWhere.Status.IN([]string{GrantStatusConfirmed, GrantStatusPending}),
+		qm.Where(models.CreditGrantColumns.RemainingAmount+" < "+models.CreditGrantColumns.InitialAmount),
+		qm.OrderBy(models.CreditGrantColumns.ExpiresAt+" ASC, "+models.CreditGrantColumns.CreatedAt+" ASC, "+models.CreditGrantColumns.ID+" ASC"),
+		qm.For("UPDATE"),
+	).All(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to get grants with spare capacity: %w", err)
+	}
+
+	for _, grant := range grants {
+		if excess == 0 {
+			break
+		}
+		amount := min(excess, grant.InitialAmount-grant.RemainingAmount)
+		grant.RemainingAmount += amount
+		grant.UpdatedAt = null.TimeFrom(time.Now())
+		if _, err := grant.Update(ctx, tx, boil.Whitelist(r.grantAmountColumns(grant)...)); err != nil {
+			return fmt.Errorf("failed to update grant %s: %w", grant.TXHash, err)
+		}
+
+		grantDetail := &models.CreditOperationGrant{
+			ID:            uuid.New().String(),
+			AppName:       operation.AppName,
+			ReferenceID:   operation.ReferenceID,
+			OperationType: operation.OperationType,
+			GrantID:       grant.ID,
+			AmountUsed:    amount,
+			CreatedAt:     null.TimeFrom(time.Now()),
+		}
+		if err := grantDetail.Insert(ctx, tx, boil.Infer()); err != nil {
+			return fmt.Errorf("failed to record transaction detail: %w", err)
+		}
+		excess -= amount
+	}
+	if excess > 0 {
+		return fmt.Errorf("%w: no active grant can take the remaining %d refunded credits", RefundExceedsCapacityErr, excess)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package creditrepo
+
+import (
+	"fmt"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// MaxReasonCodeLength is the longest refund reason code that can be stored.
+const MaxReasonCodeLength = 64
+
+// RefundOption sets optional details of a refund.
+type RefundOption func(*RefundOptions)
+
+// RefundOptions are the optional details of a refund, set with RefundOption.
+type RefundOptions struct {
+	// Why the credits were refunded, empty for no reason
+	ReasonCode string
+}
+
+// WithRefundReason records why the credits were refunded on the refund operation, such as "client_error" or "upstream_failure".
+// Usage reports break refunds down by reason code.
+func WithRefundReason(reasonCode string) RefundOption {
+	return func(o *RefundOptions) {
+		o.ReasonCode = reasonCode
+	}
+}
+
+// NewRefundOptions applies the refund options and validates the result.
+func NewRefundOptions(opts ...RefundOption) (RefundOptions, error) {
+	var options RefundOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if len(options.ReasonCode) > MaxReasonCodeLength {
+		return options, fmt.Errorf("reason code must be at most %d characters", MaxReasonCodeLength)
+	}
+	return options, nil
+}
+
+// reasonCode is the column value of the reason code, null when there is none.
+func (o RefundOptions) reasonCode() null.String {
+	return null.NewString(o.ReasonCode, o.ReasonCode != "")
+}
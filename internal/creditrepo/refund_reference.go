@@ -0,0 +1,48 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// RefundByReference refunds the deduction with the reference ID like RefundCredits, whatever app made it.
+// This lets a service other than the one that deducted issue the refund. The refund is recorded under the app name
+// of the deduction, and fails with AmbiguousReferenceErr when deductions of several apps share the reference ID.
+func (r *Repository) RefundByReference(ctx context.Context, referenceID string, opts ...RefundOption) (*models.CreditOperation, error) {
+	options, err := NewRefundOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return limitedTx(ctx, r, "RefundByReference", func(ctx context.Context) (*models.CreditOperation, error) {
+		appName, err := deductionAppName(ctx, r.db, referenceID)
+		if err != nil {
+			return nil, err
+		}
+		return r.refundCreditsInternal(ctx, appName, referenceID, 0, options)
+	})
+}
+
+// deductionAppName returns the app name of the only deduction with the reference ID.
+func deductionAppName(ctx context.Context, exec boil.ContextExecutor, referenceID string) (string, error) {
+	deductions, err := models.CreditOperations(
+		qm.Select(models.CreditOperationColumns.AppName),
+		models.CreditOperationWhere.ReferenceID.EQ(referenceID),
+		models.CreditOperationWhere.OperationType.EQ(OperationTypeDeduction),
+		qm.Limit(2),
+	).All(ctx, exec)
+	if err != nil {
+		return "", fmt.Errorf("failed to find deduction: %w", err)
+	}
+	switch len(deductions) {
+	case 0:
+		return "", fmt.Errorf("referenced deduction operation not found: %s", referenceID)
+	case 1:
+		return deductions[0].AppName, nil
+	default:
+		return "", fmt.Errorf("%w: %s", AmbiguousReferenceErr, referenceID)
+	}
+}
@@ -0,0 +1,91 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestRefundByReference(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	insertGrant := func(t *testing.T, licenseID string) *models.CreditGrant {
+		t.Helper()
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   1000,
+			RemainingAmount: 1000,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		return grant
+	}
+
+	t.Run("unique reference", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-refund-reference"
+		grant := insertGrant(t, licenseID)
+		referenceID := uuid.NewString()
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 100, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Test: The refund finds the deduction without its app name
+		operation, err := repo.RefundByReference(ctx, referenceID, WithRefundReason("upstream_failure"))
+		require.NoError(t, err)
+		assert.Equal(t, OperationTypeRefund, operation.OperationType)
+		assert.Equal(t, testAPIEndpoint, operation.AppName)
+		assert.Equal(t, int64(100), operation.TotalAmount)
+
+		require.NoError(t, grant.Reload(ctx, db))
+		assert.Equal(t, int64(1000), grant.RemainingAmount)
+
+		// Verify: The deduction can not be refunded again
+		_, err = repo.RefundByReference(ctx, referenceID)
+		require.Error(t, err)
+	})
+
+	t.Run("ambiguous reference", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-refund-reference-ambiguous"
+		grant := insertGrant(t, licenseID)
+		referenceID := uuid.NewString()
+		for _, appName := range []string{testAPIEndpoint, testAPIEndpoint + "-other"} {
+			_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 100, appName, referenceID)
+			require.NoError(t, err)
+		}
+
+		// Test: The refund does not pick one of the deductions
+		_, err := repo.RefundByReference(ctx, referenceID)
+		require.ErrorIs(t, err, AmbiguousReferenceErr)
+
+		require.NoError(t, grant.Reload(ctx, db))
+		assert.Equal(t, int64(800), grant.RemainingAmount)
+
+		// Verify: The strict refund still works
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown reference", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.RefundByReference(ctx, uuid.NewString())
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, AmbiguousReferenceErr)
+	})
+}
@@ -11,13 +11,6 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-var (
-	creditSelect = fmt.Sprintf(`
-		COALESCE(SUM(CASE WHEN %s = '%s' THEN total_amount ELSE 0 END), 0) - 
-		COALESCE(SUM(CASE WHEN %s = '%s' THEN total_amount ELSE 0 END), 0) as usage_count
-	`, models.CreditOperationTableColumns.OperationType, OperationTypeDeduction, models.CreditOperationTableColumns.OperationType, OperationTypeRefund)
-)
-
 // UsageReport is a report of the usage of a license
 type LicenseUsageReport struct {
 	// License ID
@@ -32,6 +25,30 @@ type LicenseUsageReport struct {
 	NumOfCreditsGrantsPurchased int64 `json:"numOfCreditsGrantsPurchased"`
 	// Number of credits used during the time period
 	NumOfCreditsUsed int64 `json:"numOfCreditsUsed"`
+	// Refunds during the time period grouped by reason code
+	RefundsByReason []RefundReasonTotal `json:"refundsByReason"`
+	// Usage of each asset accessed during the time period, only included when requested
+	PerAsset []AssetUsage `json:"perAsset,omitempty"`
+}
+
+// AssetUsage is the usage of a single asset during the time period of a license usage report.
+type AssetUsage struct {
+	// Asset DID
+	AssetDID string `json:"assetDid" boil:"asset_did"`
+	// Number of credits used during the time period
+	NumOfCreditsUsed int64 `json:"numOfCreditsUsed" boil:"usage_count"`
+	// Number of credit grants purchased during the time period
+	NumOfCreditsGrantsPurchased int64 `json:"numOfCreditsGrantsPurchased" boil:"num_of_credits_grants_purchased"`
+}
+
+// RefundReasonTotal is the number and credits of the refunds with the same reason code.
+type RefundReasonTotal struct {
+	// Reason code of the refunds, empty for refunds without a reason
+	ReasonCode string `json:"reasonCode" boil:"reason_code"`
+	// Number of refunds
+	NumOfRefunds int64 `json:"numOfRefunds" boil:"num_of_refunds"`
+	// Number of credits refunded
+	NumOfCreditsRefunded int64 `json:"numOfCreditsRefunded" boil:"num_of_credits_refunded"`
 }
 
 type LicenseAssetUsageReport struct {
@@ -49,9 +66,29 @@ type LicenseAssetUsageReport struct {
 	NumOfCreditsGrantsPurchased int64 `json:"numOfCreditsGrantsPurchased"`
 	// Number of credits remaining at the current time, this is not affected by the time period
 	CurrentCreditsRemaining int64 `json:"currentCreditsRemaining"`
+	// Number of credits granted by grants confirmed during the time period
+	NumOfCreditsGranted int64 `json:"numOfCreditsGranted"`
+	// Fraction of the credits granted during the time period that were used, null if nothing was granted
+	UtilizationRate *float64 `json:"utilizationRate"`
+	// When the remaining credits run out at the usage rate of the time period, null if there was no usage
+	ProjectedExhaustion *time.Time `json:"projectedExhaustion"`
+	// Grants confirmed during the time period, only included when requested
+	ConfirmedGrants []ConfirmedGrant `json:"confirmedGrants,omitempty"`
 }
 
-func (r *Repository) GetLicenseUsageReport(ctx context.Context, licenseID string, fromDate time.Time, toDate time.Time) (*LicenseUsageReport, error) {
+// ConfirmedGrant is a grant that was confirmed on chain.
+type ConfirmedGrant struct {
+	// Transaction hash of the burn that created the grant
+	TxHash string `json:"txHash"`
+	// Number of credits granted
+	Amount int64 `json:"amount"`
+	// When the grant was confirmed
+	ConfirmedAt time.Time `json:"confirmedAt"`
+}
+
+// GetLicenseUsageReport returns the usage report of a license across all assets.
+// If includePerAsset is set the report also breaks the usage down by asset, ordered by asset DID.
+func (r *Repository) GetLicenseUsageReport(ctx context.Context, licenseID string, fromDate time.Time, toDate time.Time, includePerAsset bool) (*LicenseUsageReport, error) {
 	if fromDate.IsZero() || licenseID == "" {
 		return nil, fmt.Errorf("fromDate and licenseID are required")
 	}
@@ -73,12 +110,15 @@ func (r *Repository) GetLicenseUsageReport(ctx context.Context, licenseID string
 	var assetCount int64
 	var creditUsed int64
 	var grantCount int64
+	refundsByReason := []RefundReasonTotal{}
+	var perAsset []AssetUsage
 
-	// Query 1: Count unique assets accessed during the time period
+	// Query 1: Count unique assets accessed during the time period, assets that only received grants were not accessed
 	g.Go(func() error {
 		mods := []qm.QueryMod{
 			qm.Select("COUNT(DISTINCT asset_did)"),
 			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.IN(r.usage.types()),
 			models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(fromDate)),
 		}
 		if !toDate.IsZero() {
@@ -97,8 +137,9 @@ func (r *Repository) GetLicenseUsageReport(ctx context.Context, licenseID string
 	// Query 2: Calculate credits used during the time period
 	g.Go(func() error {
 		mods := []qm.QueryMod{
-			qm.Select(creditSelect),
+			qm.Select(r.usage.creditSelect()),
 			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.IN(r.usage.types()),
 			models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(fromDate)),
 		}
 		if !toDate.IsZero() {
@@ -135,6 +176,50 @@ func (r *Repository) GetLicenseUsageReport(ctx context.Context, licenseID string
 		return nil
 	})
 
+	// Query 4: Break the refunds during the time period down by reason code
+	g.Go(func() error {
+		mods := []qm.QueryMod{
+			qm.Select(fmt.Sprintf("COALESCE(%[1]s, '') AS reason_code, COUNT(*) AS num_of_refunds, COALESCE(SUM(%[2]s), 0) AS num_of_credits_refunded",
+				models.CreditOperationColumns.ReasonCode, models.CreditOperationColumns.TotalAmount)),
+			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeRefund),
+			models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(fromDate)),
+			qm.GroupBy("1"),
+			qm.OrderBy("1"),
+		}
+		if !toDate.IsZero() {
+			mods = append(mods, models.CreditOperationWhere.CreatedAt.LTE(null.TimeFrom(toDate)))
+		}
+
+		if err := models.CreditOperations(mods...).Bind(ctx, r.db, &refundsByReason); err != nil {
+			return fmt.Errorf("failed to break down refunds by reason: %w", err)
+		}
+		return nil
+	})
+
+	// Query 5: Break the usage during the time period down by asset
+	if includePerAsset {
+		g.Go(func() error {
+			mods := []qm.QueryMod{
+				qm.Select(models.CreditOperationColumns.AssetDid, r.usage.creditSelect(),
+					fmt.Sprintf("COUNT(*) FILTER (WHERE %s = '%s') AS num_of_credits_grants_purchased", models.CreditOperationColumns.OperationType, OperationTypeGrantConfirm)),
+				models.CreditOperationWhere.LicenseID.EQ(licenseID),
+				models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(fromDate)),
+				qm.GroupBy(models.CreditOperationColumns.AssetDid),
+				qm.OrderBy(models.CreditOperationColumns.AssetDid),
+			}
+			if !toDate.IsZero() {
+				mods = append(mods, models.CreditOperationWhere.CreatedAt.LTE(null.TimeFrom(toDate)))
+			}
+
+			perAsset = []AssetUsage{}
+			if err := models.CreditOperations(mods...).Bind(ctx, r.db, &perAsset); err != nil {
+				return fmt.Errorf("failed to break down usage by asset: %w", err)
+			}
+			return nil
+		})
+	}
+
 	// Wait for all queries to complete
 	if err := g.Wait(); err != nil {
 		return nil, err
@@ -147,12 +232,16 @@ func (r *Repository) GetLicenseUsageReport(ctx context.Context, licenseID string
 		NumOfAssets:                 assetCount,
 		NumOfCreditsGrantsPurchased: grantCount,
 		NumOfCreditsUsed:            creditUsed,
+		RefundsByReason:             refundsByReason,
+		PerAsset:                    perAsset,
 	}
 
 	return report, nil
 }
 
-func (r *Repository) GetLicenseAssetUsageReport(ctx context.Context, licenseID string, assetDID string, fromDate time.Time, toDate time.Time) (*LicenseAssetUsageReport, error) {
+// GetLicenseAssetUsageReport returns the usage report for a single asset of a license.
+// If includeGrantTxHashes is set the report lists the tx hashes and amounts of the grants confirmed during the time period.
+func (r *Repository) GetLicenseAssetUsageReport(ctx context.Context, licenseID string, assetDID string, fromDate time.Time, toDate time.Time, includeGrantTxHashes bool) (*LicenseAssetUsageReport, error) {
 	if fromDate.IsZero() || licenseID == "" || assetDID == "" {
 		return nil, fmt.Errorf("fromDate, licenseID, and assetDID are required")
 	}
@@ -173,13 +262,16 @@ func (r *Repository) GetLicenseAssetUsageReport(ctx context.Context, licenseID s
 	// Variables to store results
 	var creditsUsed int64
 	var grantsPurchased int64
+	var creditsGranted int64
 	var remainingCredits int64
+	var confirmedGrants []ConfirmedGrant
 
 	// Query 1: Calculate credits used during the time period for this specific asset
 	g.Go(func() error {
 		mods := []qm.QueryMod{
-			qm.Select(creditSelect),
+			qm.Select(r.usage.creditSelect()),
 			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.IN(r.usage.types()),
 			models.CreditOperationWhere.AssetDid.EQ(assetDID),
 			models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(fromDate)),
 		}
@@ -196,9 +288,10 @@ func (r *Repository) GetLicenseAssetUsageReport(ctx context.Context, licenseID s
 		return nil
 	})
 
-	// Query 2: Count credit grants purchased during the time period for this specific asset
+	// Query 2: Count credit grants purchased and the credits they granted during the time period for this specific asset
 	g.Go(func() error {
 		grantMods := []qm.QueryMod{
+			qm.Select("COUNT(*), COALESCE(SUM(total_amount), 0)"),
 			models.CreditOperationWhere.LicenseID.EQ(licenseID),
 			models.CreditOperationWhere.AssetDid.EQ(assetDID),
 			models.CreditOperationWhere.OperationType.EQ(OperationTypeGrantConfirm),
@@ -208,31 +301,48 @@ func (r *Repository) GetLicenseAssetUsageReport(ctx context.Context, licenseID s
 			grantMods = append(grantMods, models.CreditOperationWhere.CreatedAt.LTE(null.TimeFrom(toDate)))
 		}
 
-		count, err := models.CreditOperations(
+		err := models.CreditOperations(
 			grantMods...,
-		).Count(ctx, r.db)
+		).QueryRowContext(ctx, r.db).Scan(&grantsPurchased, &creditsGranted)
 		if err != nil {
 			return fmt.Errorf("failed to count credit grants: %w", err)
 		}
-		grantsPurchased = count
 		return nil
 	})
 
 	// Query 3: Calculate remaining credits for this license and asset
 	g.Go(func() error {
-		credits, err := r.GetBalance(ctx, licenseID, assetDID)
+		balance, err := r.GetBalance(ctx, licenseID, assetDID)
 		if err != nil {
 			return fmt.Errorf("failed to get remaining credits: %w", err)
 		}
-		remainingCredits = credits
+		remainingCredits = balance.Balance
 		return nil
 	})
 
+	// Query 4: List the grants confirmed during the time period for this specific asset
+	if includeGrantTxHashes {
+		g.Go(func() error {
+			grants, err := r.getConfirmedGrants(ctx, licenseID, assetDID, fromDate, toDate)
+			if err != nil {
+				return fmt.Errorf("failed to get confirmed grants: %w", err)
+			}
+			confirmedGrants = grants
+			return nil
+		})
+	}
+
 	// Wait for all queries to complete
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
+	periodEnd := toDate
+	if periodEnd.IsZero() || periodEnd.After(now) {
+		periodEnd = now
+	}
+
 	report := &LicenseAssetUsageReport{
 		LicenseID:                   licenseID,
 		AssetDID:                    assetDID,
@@ -241,7 +351,130 @@ func (r *Repository) GetLicenseAssetUsageReport(ctx context.Context, licenseID s
 		NumOfCreditsUsed:            creditsUsed,
 		NumOfCreditsGrantsPurchased: grantsPurchased,
 		CurrentCreditsRemaining:     remainingCredits,
+		NumOfCreditsGranted:         creditsGranted,
+		UtilizationRate:             r.projection.UtilizationRate(creditsUsed, creditsGranted),
+		ProjectedExhaustion:         r.projection.ProjectedExhaustion(remainingCredits, creditsUsed, periodEnd.Sub(fromDate), now),
+		ConfirmedGrants:             confirmedGrants,
 	}
 
 	return report, nil
 }
+
+// getConfirmedGrants returns the grants confirmed during the time period along with the tx hash that created them.
+func (r *Repository) getConfirmedGrants(ctx context.Context, licenseID string, assetDID string, fromDate time.Time, toDate time.Time) ([]ConfirmedGrant, error) {
+	mods := []qm.QueryMod{
+		models.CreditOperationWhere.LicenseID.EQ(licenseID),
+		models.CreditOperationWhere.AssetDid.EQ(assetDID),
+		models.CreditOperationWhere.OperationType.EQ(OperationTypeGrantConfirm),
+		models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(fromDate)),
+		qm.OrderBy(models.CreditOperationColumns.CreatedAt + " ASC"),
+	}
+	if !toDate.IsZero() {
+		mods = append(mods, models.CreditOperationWhere.CreatedAt.LTE(null.TimeFrom(toDate)))
+	}
+	operations, err := models.CreditOperations(mods...).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grant confirmation operations: %w", err)
+	}
+	if len(operations) == 0 {
+		return nil, nil
+	}
+
+	// grant confirmations reference the confirmed grant by ID
+	grantIDs := make([]string, 0, len(operations))
+	for _, operation := range operations {
+		grantIDs = append(grantIDs, operation.ReferenceID)
+	}
+	grants, err := models.CreditGrants(
+		models.CreditGrantWhere.ID.IN(grantIDs),
+	).All(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get confirmed grants: %w", err)
+	}
+	txHashes := make(map[string]string, len(grants))
+	for _, grant := range grants {
+		txHashes[grant.ID] = grant.TXHash
+	}
+
+	confirmedGrants := make([]ConfirmedGrant, 0, len(operations))
+	for _, operation := range operations {
+		confirmedGrants = append(confirmedGrants, ConfirmedGrant{
+			TxHash:      txHashes[operation.ReferenceID],
+			Amount:      operation.TotalAmount,
+			ConfirmedAt: operation.CreatedAt.Time,
+		})
+	}
+	return confirmedGrants, nil
+}
+
+// GetNetSpendByAsset returns the credits deducted minus the credits refunded during the time period for every asset of a license
+// that had a deduction or refund in the period, or the operation types set by the usage options.
+// A fully refunded asset is included with a net spend of zero.
+func (r *Repository) GetNetSpendByAsset(ctx context.Context, licenseID string, fromDate time.Time, toDate time.Time) (map[string]int64, error) {
+	if fromDate.IsZero() || licenseID == "" {
+		return nil, fmt.Errorf("fromDate and licenseID are required")
+	}
+
+	// Validate date ranges
+	if !toDate.IsZero() && fromDate.After(toDate) {
+		return nil, fmt.Errorf("fromDate must be before toDate")
+	}
+
+	mods := []qm.QueryMod{
+		qm.Select(models.CreditOperationTableColumns.AssetDid+" as asset_did", r.usage.creditSelect()),
+		models.CreditOperationWhere.LicenseID.EQ(licenseID),
+		models.CreditOperationWhere.OperationType.IN(r.usage.types()),
+		models.CreditOperationWhere.CreatedAt.GTE(null.TimeFrom(fromDate)),
+		qm.GroupBy(models.CreditOperationTableColumns.AssetDid),
+	}
+	if !toDate.IsZero() {
+		mods = append(mods, models.CreditOperationWhere.CreatedAt.LTE(null.TimeFrom(toDate)))
+	}
+
+	var rows []struct {
+		AssetDID string `boil:"asset_did"`
+		NetSpend int64  `boil:"usage_count"`
+	}
+	if err := models.CreditOperations(mods...).Bind(ctx, r.db, &rows); err != nil {
+		return nil, fmt.Errorf("failed to calculate net spend by asset: %w", err)
+	}
+
+	netSpend := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		netSpend[row.AssetDID] = row.NetSpend
+	}
+	return netSpend, nil
+}
+
+// GetConfirmedGrantTotal returns the summed initial amount of the confirmed grants of a license created during the time period,
+// for reconciling against the DCX burned on chain. Pending and failed grants are excluded.
+// An empty assetDID sums the grants of every asset, and zero dates leave the time period open.
+func (r *Repository) GetConfirmedGrantTotal(ctx context.Context, licenseID string, assetDID string, fromDate time.Time, toDate time.Time) (int64, error) {
+	if licenseID == "" {
+		return 0, fmt.Errorf("licenseID is required")
+	}
+	if !fromDate.IsZero() && !toDate.IsZero() && fromDate.After(toDate) {
+		return 0, fmt.Errorf("fromDate must be before toDate")
+	}
+
+	mods := []qm.QueryMod{
+		qm.Select("COALESCE(SUM(" + models.CreditGrantColumns.InitialAmount + "), 0)"),
+		models.CreditGrantWhere.LicenseID.EQ(licenseID),
+		models.CreditGrantWhere.Status.EQ(GrantStatusConfirmed),
+	}
+	if assetDID != "" {
+		mods = append(mods, models.CreditGrantWhere.AssetDid.EQ(assetDID))
+	}
+	if !fromDate.IsZero() {
+		mods = append(mods, models.CreditGrantWhere.CreatedAt.GTE(null.TimeFrom(fromDate)))
+	}
+	if !toDate.IsZero() {
+		mods = append(mods, models.CreditGrantWhere.CreatedAt.LTE(null.TimeFrom(toDate)))
+	}
+
+	var total int64
+	if err := models.CreditGrants(mods...).QueryRowContext(ctx, r.db).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to calculate confirmed grant total: %w", err)
+	}
+	return total, nil
+}
@@ -2,14 +2,18 @@ package creditrepo
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DIMO-Network/credit-tracker/models"
 	"github.com/DIMO-Network/credit-tracker/tests"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
 func TestGetLicenseUsageReport(t *testing.T) {
@@ -45,7 +49,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get usage report
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate)
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show correct data
@@ -83,7 +87,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get usage report
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Now())
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Now(), false)
 		require.NoError(t, err)
 
 		// Verify: Report should show correct data
@@ -93,6 +97,104 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		assert.Equal(t, int64(250), report.NumOfCreditsUsed, "Incorrect number of credits used")              // 100 + 150 deductions
 	})
 
+	t.Run("usage report with per asset breakdown", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-report-per-asset"
+		assetDIDs := []string{"test-asset-a", "test-asset-b", "test-asset-c"}
+		fromDate := time.Now().Add(-24 * time.Hour)
+
+		// Setup: A grant for every asset and deductions of different sizes, one of them refunded
+		for i, assetDID := range assetDIDs {
+			txHash := common.BytesToAddress([]byte(licenseID + assetDID))
+			_, err := repo.ConfirmGrant(ctx, licenseID, assetDID, txHash.Hex(), i, uint64(defaultGrantAmount), time.Now().Add(-12*time.Hour))
+			require.NoError(t, err)
+		}
+		_, err := repo.DeductCredits(ctx, licenseID, assetDIDs[0], 100, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, assetDIDs[0], 50, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		refundedRef := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, assetDIDs[1], 70, testAPIEndpoint, refundedRef)
+		require.NoError(t, err)
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, refundedRef)
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, assetDIDs[1], 30, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+
+		// Test: The breakdown is only included when requested
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Now(), false)
+		require.NoError(t, err)
+		assert.Nil(t, report.PerAsset)
+
+		report, err = repo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Now(), true)
+		require.NoError(t, err)
+
+		// Verify: One entry per asset ordered by asset DID, summing to the aggregates
+		require.Len(t, report.PerAsset, len(assetDIDs))
+		assert.Equal(t, AssetUsage{AssetDID: assetDIDs[0], NumOfCreditsUsed: 150, NumOfCreditsGrantsPurchased: 1}, report.PerAsset[0])
+		assert.Equal(t, AssetUsage{AssetDID: assetDIDs[1], NumOfCreditsUsed: 30, NumOfCreditsGrantsPurchased: 1}, report.PerAsset[1])
+		assert.Equal(t, AssetUsage{AssetDID: assetDIDs[2], NumOfCreditsUsed: 0, NumOfCreditsGrantsPurchased: 1}, report.PerAsset[2])
+		var creditsUsed, grantsPurchased int64
+		for _, asset := range report.PerAsset {
+			creditsUsed += asset.NumOfCreditsUsed
+			grantsPurchased += asset.NumOfCreditsGrantsPurchased
+		}
+		assert.Equal(t, report.NumOfCreditsUsed, creditsUsed)
+		assert.Equal(t, report.NumOfCreditsGrantsPurchased, grantsPurchased)
+		assert.Equal(t, int64(2), report.NumOfAssets) // the third asset only received a grant
+	})
+
+	t.Run("usage report with configured usage operation types", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-report-usage-types"
+		fromDate := time.Now().Add(-24 * time.Hour)
+
+		// Setup: 50 credits of debt settled by a grant, then a deduction of 100 with 40 refunded
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   50,
+			RemainingAmount: 0,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+		txHash := common.BytesToAddress([]byte(licenseID))
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, txHash.Hex(), 1, uint64(defaultGrantAmount), time.Now().Add(-12*time.Hour))
+		require.NoError(t, err)
+		referenceID := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, testAssetID, 100, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+		_, err = repo.RefundPartialCredits(ctx, testAPIEndpoint, referenceID, 40)
+		require.NoError(t, err)
+
+		testCases := []struct {
+			name          string
+			usedTypes     []string
+			returnedTypes []string
+			expectUsed    int64
+		}{
+			{name: "default", expectUsed: 60},
+			{name: "settlements count as used", usedTypes: []string{OperationTypeDeduction, OperationTypeDebtSettlement}, expectUsed: 110},
+			{name: "refunds ignored", returnedTypes: []string{OperationTypeExpiration}, expectUsed: 100},
+		}
+		for _, tc := range testCases {
+			usageOpts, err := NewUsageOptions(tc.usedTypes, tc.returnedTypes)
+			require.NoError(t, err, tc.name)
+			usageRepo := New(db, WithUsageOptions(usageOpts))
+
+			report, err := usageRepo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Now(), true)
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectUsed, report.NumOfCreditsUsed, tc.name)
+			require.Len(t, report.PerAsset, 1, tc.name)
+			assert.Equal(t, tc.expectUsed, report.PerAsset[0].NumOfCreditsUsed, tc.name)
+
+			assetReport, err := usageRepo.GetLicenseAssetUsageReport(ctx, licenseID, testAssetID, fromDate, time.Now(), false)
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, tc.expectUsed, assetReport.NumOfCreditsUsed, tc.name)
+		}
+	})
+
 	t.Run("usage report with time period filtering", func(t *testing.T) {
 		t.Parallel()
 		licenseID := "test-license-report-time-filter"
@@ -122,7 +224,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get usage report for specific time period
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate)
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should only include data within the time period
@@ -154,7 +256,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get usage report with zero toDate and refund
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate)
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show net usage (deduction - refund)
@@ -164,6 +266,55 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		assert.Equal(t, int64(0), report.NumOfCreditsUsed, "Incorrect number of credits used")
 	})
 
+	t.Run("usage report with refunds by reason", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-report-refund-reasons"
+		assetDID := "test-asset-refund-reasons"
+		fromDate := time.Now().Add(-24 * time.Hour)
+
+		localTextTXHash := common.BytesToAddress([]byte(licenseID))
+		_, err := repo.ConfirmGrant(ctx, licenseID, assetDID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), time.Now().Add(-12*time.Hour))
+		require.NoError(t, err)
+
+		refunds := []struct {
+			amount     uint64
+			reasonCode string
+		}{
+			{amount: 100, reasonCode: "upstream_failure"},
+			{amount: 200, reasonCode: "client_error"},
+			{amount: 300, reasonCode: "upstream_failure"},
+			{amount: 400},
+		}
+		for _, refund := range refunds {
+			referenceID := uuid.NewString()
+			_, err := repo.DeductCredits(ctx, licenseID, assetDID, refund.amount, testAPIEndpoint, referenceID)
+			require.NoError(t, err)
+			operation, err := repo.RefundCredits(ctx, testAPIEndpoint, referenceID, WithRefundReason(refund.reasonCode))
+			require.NoError(t, err)
+
+			// Verify: The reason code is persisted on the refund operation
+			stored, err := models.FindCreditOperation(ctx, db, testAPIEndpoint, referenceID, OperationTypeRefund)
+			require.NoError(t, err)
+			assert.Equal(t, operation.ReasonCode, stored.ReasonCode)
+			assert.Equal(t, refund.reasonCode != "", stored.ReasonCode.Valid)
+			assert.Equal(t, refund.reasonCode, stored.ReasonCode.String)
+		}
+
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Time{}, false)
+		require.NoError(t, err)
+		assert.Equal(t, []RefundReasonTotal{
+			{ReasonCode: "", NumOfRefunds: 1, NumOfCreditsRefunded: 400},
+			{ReasonCode: "client_error", NumOfRefunds: 1, NumOfCreditsRefunded: 200},
+			{ReasonCode: "upstream_failure", NumOfRefunds: 2, NumOfCreditsRefunded: 400},
+		}, report.RefundsByReason)
+	})
+
+	t.Run("refund reason code too long", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.RefundCredits(ctx, testAPIEndpoint, uuid.NewString(), WithRefundReason(strings.Repeat("x", MaxReasonCodeLength+1)))
+		require.Error(t, err)
+	})
+
 	t.Run("usage report with multiple grants in period", func(t *testing.T) {
 		t.Parallel()
 		licenseID := "test-license-report-multiple-grants"
@@ -185,7 +336,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get usage report
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Now())
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Now(), false)
 		require.NoError(t, err)
 
 		// Verify: Report should show correct data
@@ -195,6 +346,27 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		assert.Equal(t, int64(100), report.NumOfCreditsUsed, "Incorrect number of credits used")              // One deduction
 	})
 
+	t.Run("usage report with grant confirmation but no deductions", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-report-grant-only"
+		assetDID := "test-asset-grant-only"
+		fromDate := time.Now().Add(-24 * time.Hour)
+
+		// Setup: Confirm a grant without using any of its credits
+		localTextTXHash := common.BytesToAddress([]byte(licenseID))
+		_, err := repo.ConfirmGrant(ctx, licenseID, assetDID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), time.Now().Add(-12*time.Hour))
+		require.NoError(t, err)
+
+		// Test: Get usage report
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, time.Now(), false)
+		require.NoError(t, err)
+
+		// Verify: The grant is reported but the asset was not accessed
+		assert.Equal(t, int64(0), report.NumOfAssets, "Incorrect number of assets")                           // Asset only received a grant
+		assert.Equal(t, int64(1), report.NumOfCreditsGrantsPurchased, "Incorrect number of grants purchased") // One grant confirmed in period
+		assert.Equal(t, int64(0), report.NumOfCreditsUsed, "Incorrect number of credits used")                // No deductions
+	})
+
 	t.Run("usage report with no activity in period", func(t *testing.T) {
 		t.Parallel()
 		licenseID := "test-license-report-no-activity"
@@ -213,7 +385,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get usage report for period with no activity
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate)
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show zero activity
@@ -230,7 +402,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		toDate := time.Now()
 
 		// Test: Get usage report with missing fromDate
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate)
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate, false)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "fromDate and licenseID are required")
 		assert.Nil(t, report)
@@ -243,7 +415,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		toDate := time.Now()
 
 		// Test: Get usage report with missing licenseID
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate)
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate, false)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "fromDate and licenseID are required")
 		assert.Nil(t, report)
@@ -267,7 +439,7 @@ func TestGetLicenseUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get usage report with zero toDate (should work, no upper bound)
-		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate)
+		report, err := repo.GetLicenseUsageReport(ctx, licenseID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show correct data
@@ -310,7 +482,7 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get asset usage report
-		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate)
+		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show correct data
@@ -323,6 +495,34 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		assert.Equal(t, int64(defaultGrantAmount-300), report.CurrentCreditsRemaining, "Incorrect remaining credits") // Remaining after deductions
 	})
 
+	t.Run("asset usage report with debt", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-asset-report-debt"
+		assetDID := "test-asset-debt"
+		fromDate := time.Now().Add(-24 * time.Hour)
+
+		// Setup: A failed grant whose credits were partly spent
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        assetDID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount - 400,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+
+		// Test: Get asset usage report
+		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, time.Now(), false)
+		require.NoError(t, err)
+
+		// Verify: The remaining credits are the spendable credits, the debt is not reported as remaining
+		assert.Equal(t, int64(0), report.CurrentCreditsRemaining, "Incorrect remaining credits")
+		balance, err := repo.GetBalance(ctx, licenseID, assetDID)
+		require.NoError(t, err)
+		assert.Equal(t, &Balance{Balance: 0, Debt: 400}, balance)
+	})
+
 	t.Run("asset usage report with time period filtering", func(t *testing.T) {
 		t.Parallel()
 		licenseID := "test-license-asset-report-time-filter"
@@ -353,7 +553,7 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get asset usage report for specific time period
-		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate)
+		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should only include data within the time period
@@ -386,7 +586,7 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get asset usage report
-		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate)
+		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show net usage (deduction - refund)
@@ -403,19 +603,19 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		toDate := time.Now()
 
 		// Test: Missing fromDate
-		report, err := repo.GetLicenseAssetUsageReport(ctx, "test-license", "test-asset", time.Time{}, toDate)
+		report, err := repo.GetLicenseAssetUsageReport(ctx, "test-license", "test-asset", time.Time{}, toDate, false)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "fromDate, licenseID, and assetDID are required")
 		assert.Nil(t, report)
 
 		// Test: Missing licenseID
-		report, err = repo.GetLicenseAssetUsageReport(ctx, "", "test-asset", fromDate, toDate)
+		report, err = repo.GetLicenseAssetUsageReport(ctx, "", "test-asset", fromDate, toDate, false)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "fromDate, licenseID, and assetDID are required")
 		assert.Nil(t, report)
 
 		// Test: Missing assetDID
-		report, err = repo.GetLicenseAssetUsageReport(ctx, "test-license", "", fromDate, toDate)
+		report, err = repo.GetLicenseAssetUsageReport(ctx, "test-license", "", fromDate, toDate, false)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "fromDate, licenseID, and assetDID are required")
 		assert.Nil(t, report)
@@ -439,7 +639,7 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get asset usage report for period with no activity
-		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate)
+		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show zero activity but current remaining credits
@@ -468,7 +668,7 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get asset usage report with zero toDate (should work, no upper bound)
-		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate)
+		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show correct data
@@ -479,6 +679,30 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		assert.Equal(t, int64(defaultGrantAmount-100), report.CurrentCreditsRemaining, "Incorrect remaining credits") // Remaining after deduction
 	})
 
+	t.Run("asset usage report with grant tx hashes", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-asset-report-tx-hashes"
+		assetDID := "test-asset-tx-hashes"
+		fromDate := time.Now().Add(-24 * time.Hour)
+
+		// Setup: Create a confirmed grant
+		localTextTXHash := common.BytesToAddress([]byte(licenseID))
+		_, err := repo.ConfirmGrant(ctx, licenseID, assetDID, localTextTXHash.Hex(), 1, uint64(defaultGrantAmount), time.Now().Add(-12*time.Hour))
+		require.NoError(t, err)
+
+		// Test: tx hashes are not included by default
+		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, time.Time{}, false)
+		require.NoError(t, err)
+		assert.Empty(t, report.ConfirmedGrants, "Grant tx hashes should only be included when requested")
+
+		// Test: tx hashes are included when requested
+		report, err = repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, time.Time{}, true)
+		require.NoError(t, err)
+		require.Len(t, report.ConfirmedGrants, 1)
+		assert.Equal(t, localTextTXHash.Hex(), report.ConfirmedGrants[0].TxHash, "Incorrect grant tx hash")
+		assert.Equal(t, defaultGrantAmount, report.ConfirmedGrants[0].Amount, "Incorrect grant amount")
+	})
+
 	t.Run("asset usage report with zero toDate and refund", func(t *testing.T) {
 		t.Parallel()
 		licenseID := "test-license-asset-report-zero-todate-refund"
@@ -501,7 +725,7 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test: Get asset usage report with zero toDate and refund
-		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate)
+		report, err := repo.GetLicenseAssetUsageReport(ctx, licenseID, assetDID, fromDate, toDate, false)
 		require.NoError(t, err)
 
 		// Verify: Report should show net usage (deduction - refund)
@@ -512,3 +736,138 @@ func TestGetLicenseAssetUsageReport(t *testing.T) {
 		assert.Equal(t, int64(defaultGrantAmount), report.CurrentCreditsRemaining, "Incorrect remaining credits") // Full amount remaining after refund
 	})
 }
+
+func TestGetNetSpendByAsset(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("net spend with multiple assets", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-net-spend"
+		assetDIDs := []string{"test-asset-1", "test-asset-2", "test-asset-3"}
+		fromDate := time.Now().Add(-24 * time.Hour)
+		toDate := time.Now().Add(1 * time.Hour)
+
+		// Setup: Create a grant for each asset
+		for i, assetDID := range assetDIDs {
+			localTextTXHash := common.BytesToAddress([]byte(licenseID + assetDID))
+			_, err := repo.ConfirmGrant(ctx, licenseID, assetDID, localTextTXHash.Hex(), i, uint64(defaultGrantAmount), time.Now().Add(-12*time.Hour))
+			require.NoError(t, err)
+		}
+
+		// Setup: Asset 1 has two deductions
+		_, err := repo.DeductCredits(ctx, licenseID, assetDIDs[0], 100, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		_, err = repo.DeductCredits(ctx, licenseID, assetDIDs[0], 200, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+
+		// Setup: Asset 2 has one of two deductions refunded
+		_, err = repo.DeductCredits(ctx, licenseID, assetDIDs[1], 150, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		refundedReferenceID := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, assetDIDs[1], 50, testAPIEndpoint, refundedReferenceID)
+		require.NoError(t, err)
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, refundedReferenceID)
+		require.NoError(t, err)
+
+		// Setup: Asset 3 is fully refunded
+		fullyRefundedReferenceID := uuid.NewString()
+		_, err = repo.DeductCredits(ctx, licenseID, assetDIDs[2], 300, testAPIEndpoint, fullyRefundedReferenceID)
+		require.NoError(t, err)
+		_, err = repo.RefundCredits(ctx, testAPIEndpoint, fullyRefundedReferenceID)
+		require.NoError(t, err)
+
+		// Test: Get net spend by asset
+		netSpend, err := repo.GetNetSpendByAsset(ctx, licenseID, fromDate, toDate)
+		require.NoError(t, err)
+
+		// Verify: Deductions are netted against refunds per asset
+		expected := map[string]int64{
+			assetDIDs[0]: 300,
+			assetDIDs[1]: 150,
+			assetDIDs[2]: 0,
+		}
+		assert.Equal(t, expected, netSpend)
+	})
+
+	t.Run("no operations in period", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-net-spend-empty"
+
+		// Test: Get net spend for a license without operations
+		netSpend, err := repo.GetNetSpendByAsset(ctx, licenseID, time.Now().Add(-24*time.Hour), time.Time{})
+		require.NoError(t, err)
+
+		// Verify: No assets are returned
+		assert.Empty(t, netSpend)
+	})
+
+	t.Run("invalid date range", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.GetNetSpendByAsset(ctx, "test-license-net-spend-invalid", time.Now(), time.Now().Add(-time.Hour))
+		require.Error(t, err)
+	})
+}
+
+func TestGetConfirmedGrantTotal(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("sums confirmed grants only", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-confirmed-total"
+		otherAssetID := "test-asset-confirmed-total"
+
+		// Setup: Two confirmed grants for the asset, one for another asset, and a pending and a failed grant
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, common.BytesToAddress([]byte(licenseID+"-1")).Hex(), 1, 100, time.Now())
+		require.NoError(t, err)
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, common.BytesToAddress([]byte(licenseID+"-2")).Hex(), 1, 250, time.Now())
+		require.NoError(t, err)
+		_, err = repo.ConfirmGrant(ctx, licenseID, otherAssetID, common.BytesToAddress([]byte(licenseID+"-3")).Hex(), 1, 40, time.Now())
+		require.NoError(t, err)
+		for _, status := range []string{GrantStatusPending, GrantStatusFailed} {
+			grant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        testAssetID,
+				InitialAmount:   1000,
+				RemainingAmount: 1000,
+				Status:          status,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			}
+			require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		}
+
+		// Test & Verify: Pending and failed grants are excluded
+		total, err := repo.GetConfirmedGrantTotal(ctx, licenseID, testAssetID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(350), total)
+
+		// Test & Verify: An empty asset sums every asset of the license
+		total, err = repo.GetConfirmedGrantTotal(ctx, licenseID, "", time.Time{}, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(390), total)
+	})
+
+	t.Run("no grants in period", func(t *testing.T) {
+		t.Parallel()
+		total, err := repo.GetConfirmedGrantTotal(ctx, "test-license-confirmed-total-empty", testAssetID, time.Now().Add(-time.Hour), time.Time{})
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+
+	t.Run("invalid date range", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.GetConfirmedGrantTotal(ctx, "test-license-confirmed-total-invalid", testAssetID, time.Now(), time.Now().Add(-time.Hour))
+		require.Error(t, err)
+	})
+}
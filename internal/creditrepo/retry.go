@@ -2,21 +2,81 @@ package creditrepo
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"time"
 
 	"github.com/rs/zerolog"
 )
 
 const (
-	defaultWait = time.Millisecond
+	defaultBaseWait            = time.Millisecond
+	defaultMaxWait             = 100 * time.Millisecond
+	defaultDeadlockMaxAttempts = 10
 )
 
-// RetryWithDeadlockHandling is a generic retry function that handles deadlock errors
-// It retries until context is cancelled or a non-deadlock error occurs
+// DeadlockRetryPolicy sets how operations that deadlocked are retried.
+// The wait before the nth retry is BaseWait doubled n-1 times, capped at MaxWait, with up to half of it taken off at random
+// so that transactions that deadlocked together do not retry together.
+type DeadlockRetryPolicy struct {
+	// Wait before the first retry
+	BaseWait time.Duration
+	// Longest wait between two attempts
+	MaxWait time.Duration
+	// Attempts before the deadlock or serialization failure is returned, zero retries until the context is done
+	MaxAttempts int
+}
+
+// DefaultDeadlockRetryPolicy waits 1ms before the first retry, at most 100ms between attempts, and gives up after 10 attempts.
+func DefaultDeadlockRetryPolicy() DeadlockRetryPolicy {
+	return DeadlockRetryPolicy{
+		BaseWait:    defaultBaseWait,
+		MaxWait:     defaultMaxWait,
+		MaxAttempts: defaultDeadlockMaxAttempts,
+	}
+}
+
+// WithDeadlockMaxAttempts sets how many times an operation is attempted before a deadlock error is returned.
+// Zero retries deadlocks until the context is done.
+func WithDeadlockMaxAttempts(maxAttempts int) Option {
+	return func(r *Repository) {
+		r.deadlockRetry.MaxAttempts = max(maxAttempts, 0)
+	}
+}
+
+// backoff returns the wait before retrying the given attempt, between half and all of the exponential wait.
+func (p DeadlockRetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.BaseWait
+	for i := 1; i < attempt && wait < p.MaxWait; i++ {
+		wait *= 2
+	}
+	wait = min(wait, p.MaxWait)
+	if wait <= 1 {
+		return wait
+	}
+	half := wait / 2
+	return wait - rand.N(half+1)
+}
+
+// RetryWithDeadlockHandling is a generic retry function that handles deadlock errors and serialization failures
+// It retries with DefaultDeadlockRetryPolicy until context is cancelled, a non-retryable error occurs, or the attempts run out
 func RetryWithDeadlockHandling[T any](
 	ctx context.Context,
 	funcName string,
 	operation func() (T, error),
+) (T, error) {
+	return RetryWithDeadlockPolicy(ctx, funcName, DefaultDeadlockRetryPolicy(), operation)
+}
+
+// RetryWithDeadlockPolicy retries the operation on deadlock or serialization failure, waiting between attempts as set by the policy.
+// It stops when the context is cancelled or an error other than those matched by IsRetryableError occurs, and returns the last
+// retryable error, still matched by IsRetryableError, once the policy's attempts run out.
+func RetryWithDeadlockPolicy[T any](
+	ctx context.Context,
+	funcName string,
+	policy DeadlockRetryPolicy,
+	operation func() (T, error),
 ) (T, error) {
 	var lastErr error
 	var result T
@@ -38,26 +98,69 @@ func RetryWithDeadlockHandling[T any](
 			return result, nil
 		}
 
-		// If it's not a deadlock error, return immediately
-		if !IsDeadlockError(lastErr) {
+		// If it's not a deadlock or serialization failure, return immediately
+		if !IsRetryableError(lastErr) {
 			return result, lastErr
 		}
 
-		// Log the deadlock error
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return result, fmt.Errorf("%s conflicted after %d attempts: %w", funcName, attempt, lastErr)
+		}
+
+		// Log the conflict
+		wait := policy.backoff(attempt)
 		logger := zerolog.Ctx(ctx)
 		logger.Warn().
 			Err(lastErr).
 			Str("function", funcName).
 			Int("attempt", attempt).
-			Msg("Deadlock detected, retrying operation")
+			Dur("wait", wait).
+			Msg("Transaction conflict detected, retrying operation")
+		DeadlockRetries.WithLabelValues(funcName).Inc()
+		addDeadlockEvent(ctx, attempt, wait)
 
 		// Wait with context cancellation support
 		select {
 		case <-ctx.Done():
 			var zero T
 			return zero, ctx.Err()
-		case <-time.After(defaultWait):
+		case <-time.After(wait):
 			// Continue to next attempt
 		}
 	}
 }
+
+// retryTx retries the operation on deadlock with the repository's retry policy, running each attempt with its own context
+// bounded by the repository's operation timeout when it is positive.
+// The database driver does not always report a cancelled statement as a context error, so an attempt failing after its timeout
+// returns an error wrapping context.DeadlineExceeded and is not retried.
+// The duration of the whole operation, every attempt included, is recorded in OperationDuration and traced as a span.
+// Operations started once the repository is draining fail with ShuttingDownErr, see Drain.
+func retryTx[T any](ctx context.Context, r *Repository, funcName string, operation func(ctx context.Context) (T, error)) (result T, err error) {
+	ctx, end, err := r.drain.begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer end()
+	timeout := r.opTimeout
+	start := time.Now()
+	ctx, span := r.startSpan(ctx, funcName)
+	defer func() {
+		OperationDuration.WithLabelValues(funcName).Observe(time.Since(start).Seconds())
+		endSpan(span, err)
+	}()
+	return RetryWithDeadlockPolicy(ctx, funcName, r.deadlockRetry, func() (T, error) {
+		if timeout <= 0 {
+			return operation(ctx)
+		}
+		opCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result, err := operation(opCtx)
+		if err != nil && ctx.Err() == nil && errors.Is(opCtx.Err(), context.DeadlineExceeded) && !errors.Is(err, context.DeadlineExceeded) {
+			var zero T
+			// the driver error is not wrapped so a deadlock reported by the cancelled attempt is not retried
+			return zero, fmt.Errorf("%s timed out after %s: %w: %v", funcName, timeout, context.DeadlineExceeded, err)
+		}
+		return result, err
+	})
+}
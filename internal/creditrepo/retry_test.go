@@ -3,7 +3,9 @@ package creditrepo
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
@@ -47,6 +49,22 @@ func TestRetryWithDeadlockHandling_DeadlockError(t *testing.T) {
 	assert.Equal(t, 3, attempts)
 }
 
+func TestRetryWithDeadlockHandling_SerializationFailure(t *testing.T) {
+	ctx := context.Background()
+	serializationErr := &pq.Error{Code: "40001"}
+	attempts := 0
+	result, err := RetryWithDeadlockHandling(ctx, "TestFunction", func() (string, error) {
+		attempts++
+		if attempts <= 2 {
+			return "", serializationErr
+		}
+		return "success", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "success", result)
+	assert.Equal(t, 3, attempts)
+}
+
 func TestRetryWithDeadlockHandling_SuccessOnFirstTry(t *testing.T) {
 	ctx := context.Background()
 	attempts := 0
@@ -59,6 +77,58 @@ func TestRetryWithDeadlockHandling_SuccessOnFirstTry(t *testing.T) {
 	assert.Equal(t, 1, attempts)
 }
 
+func TestRetryWithDeadlockPolicy_MaxAttempts(t *testing.T) {
+	deadlockErr := &pq.Error{Code: DeadlockError}
+	policy := DeadlockRetryPolicy{BaseWait: time.Microsecond, MaxWait: time.Millisecond, MaxAttempts: 3}
+	attempts := 0
+	_, err := RetryWithDeadlockPolicy(context.Background(), "TestFunction", policy, func() (string, error) {
+		attempts++
+		return "", deadlockErr
+	})
+	assert.True(t, IsDeadlockError(err))
+	assert.ErrorIs(t, err, deadlockErr)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryWithDeadlockPolicy_UnlimitedAttempts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := DeadlockRetryPolicy{BaseWait: time.Microsecond, MaxWait: time.Microsecond}
+	attempts := 0
+	_, err := RetryWithDeadlockPolicy(ctx, "TestFunction", policy, func() (string, error) {
+		attempts++
+		if attempts == 20 {
+			cancel()
+		}
+		return "", &pq.Error{Code: DeadlockError}
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 20, attempts)
+}
+
+func TestDeadlockRetryPolicy_Backoff(t *testing.T) {
+	policy := DeadlockRetryPolicy{BaseWait: time.Millisecond, MaxWait: 16 * time.Millisecond}
+	waits := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 8 * time.Millisecond, 16 * time.Millisecond, 16 * time.Millisecond}
+	for range 100 {
+		var previous time.Duration
+		for i, wait := range waits {
+			backoff := policy.backoff(i + 1)
+			// the jitter takes at most half of the wait off, so a doubled wait is never shorter than the one before
+			assert.GreaterOrEqual(t, backoff, wait/2)
+			assert.LessOrEqual(t, backoff, wait)
+			if i > 0 && waits[i-1] < wait {
+				assert.GreaterOrEqual(t, backoff, previous)
+			}
+			previous = backoff
+		}
+	}
+}
+
+func TestWithDeadlockMaxAttempts(t *testing.T) {
+	assert.Equal(t, DefaultDeadlockRetryPolicy(), New(nil).deadlockRetry)
+	assert.Equal(t, 5, New(nil, WithDeadlockMaxAttempts(5)).deadlockRetry.MaxAttempts)
+	assert.Equal(t, 0, New(nil, WithDeadlockMaxAttempts(-1)).deadlockRetry.MaxAttempts)
+}
+
 func TestIsDeadlockError(t *testing.T) {
 	deadlockErr := &pq.Error{Code: DeadlockError}
 	assert.True(t, IsDeadlockError(deadlockErr))
@@ -68,3 +138,65 @@ func TestIsDeadlockError(t *testing.T) {
 	assert.False(t, IsDeadlockError(regularErr))
 	assert.False(t, IsDeadlockError(nil))
 }
+
+func TestRetryWithDeadlockPolicy_SerializationFailureMaxAttempts(t *testing.T) {
+	serializationErr := &pq.Error{Code: "40001"}
+	policy := DeadlockRetryPolicy{BaseWait: time.Microsecond, MaxWait: time.Millisecond, MaxAttempts: 3}
+	attempts := 0
+	_, err := RetryWithDeadlockPolicy(context.Background(), "TestFunction", policy, func() (string, error) {
+		attempts++
+		return "", serializationErr
+	})
+	assert.True(t, IsSerializationFailure(err))
+	assert.ErrorIs(t, err, serializationErr)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	assert.True(t, IsSerializationFailure(&pq.Error{Code: "40001"}))
+	assert.False(t, IsSerializationFailure(&pq.Error{Code: DeadlockError}))
+	assert.True(t, IsRetryableError(&pq.Error{Code: DeadlockError}))
+	assert.True(t, IsRetryableError(fmt.Errorf("failed to lock grants: %w", &pq.Error{Code: "40001"})))
+	assert.False(t, IsRetryableError(&pq.Error{Code: "23505"}))
+	assert.False(t, IsRetryableError(errors.New("regular error")))
+	assert.False(t, IsRetryableError(nil))
+}
+
+func TestRetryTx_Timeout(t *testing.T) {
+	attempts := 0
+	_, err := retryTx(context.Background(), New(nil, WithOpTimeout(10*time.Millisecond)), "TestFunction", func(ctx context.Context) (string, error) {
+		attempts++
+		<-ctx.Done()
+		// the driver reports the cancelled statement as a deadlock, which must not be retried
+		return "", &pq.Error{Code: DeadlockError}
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, IsDeadlockError(err))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryTx_NoTimeout(t *testing.T) {
+	result, err := retryTx(context.Background(), New(nil), "TestFunction", func(ctx context.Context) (string, error) {
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		return "success", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "success", result)
+}
+
+func TestRetryTx_DeadlockRetriedWithinTimeout(t *testing.T) {
+	attempts := 0
+	result, err := retryTx(context.Background(), New(nil, WithOpTimeout(time.Second)), "TestFunction", func(ctx context.Context) (string, error) {
+		attempts++
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		if attempts == 1 {
+			return "", &pq.Error{Code: DeadlockError}
+		}
+		return "success", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "success", result)
+	assert.Equal(t, 2, attempts)
+}
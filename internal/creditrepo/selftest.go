@@ -0,0 +1,121 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// SelfTestLicensePrefix namespaces the diagnostic licenses used by SelfTest.
+	SelfTestLicensePrefix = "credit-tracker-self-test-"
+	// SelfTestAssetDID is the reserved diagnostic asset used by SelfTest.
+	SelfTestAssetDID = "did:erc721:0:0x0000000000000000000000000000000000000000:0"
+	selfTestAppName  = "credit_tracker_self_test"
+	selfTestAmount   = 100
+	selfTestDeduct   = 10
+)
+
+// SelfTestStep is the result of a single self-test step.
+type SelfTestStep struct {
+	Name   string
+	Passed bool
+	Error  string
+}
+
+// SelfTest exercises grant creation, deduction, and refund against a diagnostic license and asset,
+// then deletes every record it created. Steps after the first failure are not run, the cleanup step always is.
+func (r *Repository) SelfTest(ctx context.Context) []SelfTestStep {
+	licenseID := SelfTestLicensePrefix + uuid.NewString()
+	referenceID := uuid.NewString()
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "create_grant", run: func() error {
+			_, err := r.CreateGrant(ctx, licenseID, SelfTestAssetDID, selfTestAmount, time.Now())
+			if err != nil {
+				return err
+			}
+			return r.expectSelfTestBalance(ctx, licenseID, selfTestAmount)
+		}},
+		{name: "deduct", run: func() error {
+			_, err := r.DeductCredits(ctx, licenseID, SelfTestAssetDID, selfTestDeduct, selfTestAppName, referenceID)
+			if err != nil {
+				return err
+			}
+			return r.expectSelfTestBalance(ctx, licenseID, selfTestAmount-selfTestDeduct)
+		}},
+		{name: "refund", run: func() error {
+			_, err := r.RefundCredits(ctx, selfTestAppName, referenceID)
+			if err != nil {
+				return err
+			}
+			return r.expectSelfTestBalance(ctx, licenseID, selfTestAmount)
+		}},
+	}
+
+	results := make([]SelfTestStep, 0, len(steps)+1)
+	for _, step := range steps {
+		result := SelfTestStep{Name: step.name, Passed: true}
+		if err := step.run(); err != nil {
+			result.Passed = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+		if !result.Passed {
+			break
+		}
+	}
+
+	cleanup := SelfTestStep{Name: "cleanup", Passed: true}
+	if err := r.cleanupSelfTest(ctx, licenseID); err != nil {
+		cleanup.Passed = false
+		cleanup.Error = err.Error()
+	}
+	return append(results, cleanup)
+}
+
+// expectSelfTestBalance reads back the diagnostic balance and compares it with the expected amount.
+func (r *Repository) expectSelfTestBalance(ctx context.Context, licenseID string, expected int64) error {
+	balance, err := r.GetBalance(ctx, licenseID, SelfTestAssetDID)
+	if err != nil {
+		return err
+	}
+	if balance.Balance != expected {
+		return fmt.Errorf("unexpected balance: %d, expected: %d", balance.Balance, expected)
+	}
+	return nil
+}
+
+// cleanupSelfTest deletes the records created for the diagnostic license, operation grants are deleted by cascade.
+func (r *Repository) cleanupSelfTest(ctx context.Context, licenseID string) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("SelfTest")()
+	defer rollbackTx(ctx, tx)
+
+	if _, err := models.CreditOperations(models.CreditOperationWhere.LicenseID.EQ(licenseID)).DeleteAll(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete self-test operations: %w", err)
+	}
+	if _, err := models.CreditGrants(models.CreditGrantWhere.LicenseID.EQ(licenseID)).DeleteAll(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete self-test grants: %w", err)
+	}
+	if _, err := models.CreditBalanceSummaries(models.CreditBalanceSummaryWhere.LicenseID.EQ(licenseID)).DeleteAll(ctx, tx); err != nil {
+		return fmt.Errorf("failed to delete self-test balance summaries: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+func TestSelfTest(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	steps := repo.SelfTest(ctx)
+	require.Len(t, steps, 4)
+	for _, step := range steps {
+		assert.True(t, step.Passed, "%s: %s", step.Name, step.Error)
+	}
+	assert.Equal(t, "cleanup", steps[len(steps)-1].Name)
+
+	// Verify: nothing is left behind for the diagnostic licenses
+	grantCount, err := models.CreditGrants(
+		qm.Where(models.CreditGrantColumns.LicenseID+" LIKE ?", SelfTestLicensePrefix+"%"),
+	).Count(ctx, db)
+	require.NoError(t, err)
+	assert.Zero(t, grantCount)
+	operationCount, err := models.CreditOperations(
+		models.CreditOperationWhere.AssetDid.EQ(SelfTestAssetDID),
+	).Count(ctx, db)
+	require.NoError(t, err)
+	assert.Zero(t, operationCount)
+}
@@ -0,0 +1,102 @@
+package creditrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/google/uuid"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// debtSettlementPrefix starts the reference ID of the debt settlements requested with SettleOutstandingDebt.
+const debtSettlementPrefix = "settlement-"
+
+// SettleOutstandingDebt settles the outstanding debt of a license and asset with its spendable credits, like confirming a grant does,
+// for credits that were added without settling the debt. The debt of each credit type is only settled with credits of that type,
+// and recorded as its own settlement. It returns the credits settled, which is zero without debt or spendable credits.
+// The failed and active grants are locked for the settlement, so concurrent settlements never move the same credits twice.
+func (r *Repository) SettleOutstandingDebt(ctx context.Context, licenseID, assetDID string) (int64, error) {
+	if licenseID == "" || assetDID == "" {
+		return 0, fmt.Errorf("licenseID and assetDID are required")
+	}
+	logger := operationLogger(ctx, licenseID, assetDID, "", "", 0)
+	logger.Debug().Msg("settling outstanding debt")
+	settled, err := retryTx(ctx, r, "SettleOutstandingDebt", func(ctx context.Context) (int64, error) {
+		return r.settleOutstandingDebtInternal(ctx, licenseID, assetDID)
+	})
+	if err != nil {
+		logger.Debug().Err(err).Msg("failed to settle outstanding debt")
+	}
+	return settled, err
+}
+
+// settleOutstandingDebtInternal is the internal implementation of SettleOutstandingDebt
+func (r *Repository) settleOutstandingDebtInternal(ctx context.Context, licenseID, assetDID string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("SettleOutstandingDebt")()
+	defer rollbackTx(ctx, tx)
+
+	creditTypes, err := debtCreditTypes(ctx, tx, licenseID, assetDID)
+	if err != nil {
+		return 0, err
+	}
+	var operations []*models.CreditOperation
+	var settled int64
+	for _, creditType := range creditTypes {
+		operation, err := r.settleDebt(ctx, tx, licenseID, assetDID, creditType, "credit_tracker", debtSettlementPrefix+uuid.NewString())
+		if err != nil {
+			return 0, fmt.Errorf("failed to settle debt: %w", err)
+		}
+		if operation == nil || operation.TotalAmount == 0 {
+			continue
+		}
+		operations = append(operations, operation)
+		settled += operation.TotalAmount
+	}
+	if settled == 0 {
+		// nothing was settled, roll back rather than record an empty settlement
+		return 0, nil
+	}
+
+	if err := r.updateBalanceSummary(ctx, tx, licenseID, assetDID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, operation := range operations {
+		r.logOperation(ctx, operation, "settled outstanding debt")
+	}
+	r.observeSettledDebt(ctx, licenseID)
+
+	return settled, nil
+}
+
+// debtCreditTypes returns the credit types of the failed grants of a license and asset with outstanding debt, in name order.
+func debtCreditTypes(ctx context.Context, tx *sql.Tx, licenseID, assetDID string) ([]string, error) {
+	var rows []struct {
+		CreditType string `boil:"credit_type"`
+	}
+	err := models.CreditGrants(
+		qm.Distinct(models.CreditGrantColumns.CreditType),
+		models.CreditGrantWhere.LicenseID.EQ(licenseID),
+		models.CreditGrantWhere.AssetDid.EQ(assetDID),
+		models.CreditGrantWhere.Status.EQ(GrantStatusFailed),
+		qm.Where(models.CreditGrantColumns.RemainingAmount+" < "+models.CreditGrantColumns.InitialAmount),
+		qm.OrderBy(models.CreditGrantColumns.CreditType),
+	).Bind(ctx, tx, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit types with debt: %w", err)
+	}
+	creditTypes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		creditTypes = append(creditTypes, row.CreditType)
+	}
+	return creditTypes, nil
+}
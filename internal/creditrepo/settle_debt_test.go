@@ -0,0 +1,187 @@
+package creditrepo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestSettleOutstandingDebt(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+
+	t.Run("settles as much debt as the balance covers", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-settle-debt"
+		// Setup: Two failed grants with 100 debt each and a confirmed grant with 150 credits left, added without settling the debt
+		var failedGrants []*models.CreditGrant
+		for range 2 {
+			failedGrant := &models.CreditGrant{
+				LicenseID:       licenseID,
+				AssetDid:        testAssetID,
+				InitialAmount:   defaultGrantAmount,
+				RemainingAmount: defaultGrantAmount - 100,
+				Status:          GrantStatusFailed,
+				ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+			}
+			require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+			failedGrants = append(failedGrants, failedGrant)
+		}
+		activeGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   150,
+			RemainingAmount: 150,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		}
+		require.NoError(t, activeGrant.Insert(ctx, db, boil.Infer()))
+
+		// Test: Settling the debt uses the whole balance
+		settled, err := repo.SettleOutstandingDebt(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(150), settled)
+
+		// Verify: The first failed grant is settled and the second is half settled
+		for i, expected := range []int64{defaultGrantAmount, defaultGrantAmount - 50} {
+			require.NoError(t, failedGrants[i].Reload(ctx, db))
+			assert.Equal(t, expected, failedGrants[i].RemainingAmount)
+		}
+		require.NoError(t, activeGrant.Reload(ctx, db))
+		assert.Equal(t, int64(0), activeGrant.RemainingAmount)
+		debt, err := repo.GetDebt(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), debt)
+
+		// Verify: The settlement is recorded under its own reference
+		settlement, err := models.CreditOperations(
+			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeDebtSettlement),
+		).One(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(150), settlement.TotalAmount)
+		assert.True(t, strings.HasPrefix(settlement.ReferenceID, debtSettlementPrefix))
+
+		// Test: With the balance used up, settling again moves nothing and records nothing
+		settled, err = repo.SettleOutstandingDebt(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), settled)
+		count, err := models.CreditOperations(
+			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeDebtSettlement),
+		).Count(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("debt is only settled with credits of its type", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-settle-debt-types"
+		// Setup: A failed attestation grant with 100 debt, and confirmed telemetry and attestation grants
+		failedGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount - 100,
+			Status:          GrantStatusFailed,
+			CreditType:      "attestation",
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failedGrant.Insert(ctx, db, boil.Infer()))
+		telemetryGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   500,
+			RemainingAmount: 500,
+			Status:          GrantStatusConfirmed,
+			CreditType:      "telemetry",
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		}
+		require.NoError(t, telemetryGrant.Insert(ctx, db, boil.Infer()))
+		attestationGrant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   40,
+			RemainingAmount: 40,
+			Status:          GrantStatusConfirmed,
+			CreditType:      "attestation",
+			ExpiresAt:       null.TimeFrom(time.Now().Add(time.Hour)),
+		}
+		require.NoError(t, attestationGrant.Insert(ctx, db, boil.Infer()))
+
+		// Test: Only the attestation credits settle the attestation debt
+		settled, err := repo.SettleOutstandingDebt(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(40), settled)
+
+		// Verify: The telemetry grant is untouched
+		require.NoError(t, telemetryGrant.Reload(ctx, db))
+		assert.Equal(t, int64(500), telemetryGrant.RemainingAmount)
+		require.NoError(t, attestationGrant.Reload(ctx, db))
+		assert.Equal(t, int64(0), attestationGrant.RemainingAmount)
+		require.NoError(t, failedGrant.Reload(ctx, db))
+		assert.Equal(t, defaultGrantAmount-60, failedGrant.RemainingAmount)
+
+		// Verify: The settlement is recorded with the credit type of the debt
+		settlement, err := models.CreditOperations(
+			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeDebtSettlement),
+		).One(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(40), settlement.TotalAmount)
+		assert.Equal(t, "attestation", settlement.CreditType)
+
+		// Test: Confirming a telemetry grant leaves the remaining attestation debt alone
+		_, err = repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xsettle-debt-types", 0, 100, time.Now(), WithGrantCreditType("telemetry"))
+		require.NoError(t, err)
+		debt, err := repo.GetDebt(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(60), debt)
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID, WithCreditType("telemetry"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(600), balance.Balance)
+	})
+
+	t.Run("no debt is a no-op", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-settle-no-debt"
+		_, err := repo.ConfirmGrant(ctx, licenseID, testAssetID, "0xsettle-no-debt", 0, 100, time.Now())
+		require.NoError(t, err)
+
+		settled, err := repo.SettleOutstandingDebt(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), settled)
+
+		// Verify: The balance is untouched and no settlement is recorded
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance.Balance)
+		count, err := models.CreditOperations(
+			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeDebtSettlement),
+		).Count(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("missing license or asset", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.SettleOutstandingDebt(ctx, "", testAssetID)
+		require.Error(t, err)
+		_, err = repo.SettleOutstandingDebt(ctx, "test-license-settle-missing", "")
+		require.Error(t, err)
+	})
+}
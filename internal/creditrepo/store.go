@@ -0,0 +1,45 @@
+package creditrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+)
+
+// CreditStore is the set of credit operations the gRPC and HTTP controllers depend on.
+// Repository is the Postgres implementation, memstore provides an in-memory implementation for unit tests.
+type CreditStore interface {
+	DeductCredits(ctx context.Context, licenseID string, assetDID string, amount uint64, appName string, referenceID string, opts ...PoolOption) (*models.CreditOperation, error)
+	DeductCreditsBatch(ctx context.Context, licenseID, appName string, deductions []DeductInput) ([]DeductOutcome, error)
+	RefundCredits(ctx context.Context, appName string, referenceID string, opts ...RefundOption) (*models.CreditOperation, error)
+	CreateGrant(ctx context.Context, licenseID string, assetDID string, creditAmount uint64, mintTime time.Time, opts ...GrantOption) (*models.CreditGrant, error)
+	CreateManualGrant(ctx context.Context, licenseID, assetDID string, amount uint64, reason string) (*models.CreditGrant, error)
+	UpdateGrantTxHash(ctx context.Context, grant *models.CreditGrant, txHash string) (*models.CreditGrant, error)
+	FailGrant(ctx context.Context, grant *models.CreditGrant) (*models.CreditGrant, error)
+	ConfirmGrant(ctx context.Context, licenseID string, assetDID string, txHash string, logIndex int, creditAmount uint64, mintTime time.Time, opts ...GrantOption) (*models.CreditOperation, error)
+	GetBalance(ctx context.Context, licenseID string, assetDID string, opts ...PoolOption) (*Balance, error)
+	GetBalances(ctx context.Context, licenseID string, assetDIDs []string) (map[string]*Balance, error)
+	GetDebt(ctx context.Context, licenseID string, assetDID string) (int64, error)
+	GetAssetsWithDebt(ctx context.Context, licenseID string) ([]AssetDebt, error)
+	SettleOutstandingDebt(ctx context.Context, licenseID, assetDID string) (int64, error)
+	CanDeduct(ctx context.Context, licenseID string, assetDID string, amount uint64) (bool, int64, error)
+	GetBalanceSummaries(ctx context.Context, licenseID string) ([]*BalanceSummary, error)
+	RefreshLicenseBalanceSummaries(ctx context.Context, licenseID string) (int64, error)
+	GetOperationHistory(ctx context.Context, licenseID string, opts OperationHistoryOptions) (*OperationHistoryPage, error)
+	GetOperationByReference(ctx context.Context, licenseID, referenceID string) ([]OperationRecord, error)
+	GetRecentOperations(ctx context.Context, licenseID string, limit int) ([]RecentOperation, error)
+	SubscribeOperations(licenseID string) (<-chan RecentOperation, func())
+	GetAccountSnapshot(ctx context.Context, licenseID string) (*AccountSnapshot, error)
+	GetLicenseSummary(ctx context.Context, licenseID string) (*LicenseSummary, error)
+	ListGrants(ctx context.Context, licenseID string, assetDID string, opts ListOptions) ([]*models.CreditGrant, error)
+	GetGrantByTxHash(ctx context.Context, txHash string) (*models.CreditGrant, error)
+	GetLicenseUsageReport(ctx context.Context, licenseID string, fromDate time.Time, toDate time.Time, includePerAsset bool) (*LicenseUsageReport, error)
+	GetLicenseAssetUsageReport(ctx context.Context, licenseID string, assetDID string, fromDate time.Time, toDate time.Time, includeGrantTxHashes bool) (*LicenseAssetUsageReport, error)
+	GetPendingGrantStats(ctx context.Context, licenseID, assetDID string) (*PendingGrantStats, error)
+	ReconcileBalance(ctx context.Context, licenseID, assetDID string) (ledgerBalance, grantBalance int64, err error)
+	GetLongRunningTransactions(ctx context.Context, minDuration time.Duration) ([]*TransactionDiagnostic, error)
+	SelfTest(ctx context.Context) []SelfTestStep
+}
+
+var _ CreditStore = (*Repository)(nil)
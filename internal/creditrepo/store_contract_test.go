@@ -0,0 +1,17 @@
+package creditrepo_test
+
+import (
+	"testing"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo/storetest"
+	"github.com/DIMO-Network/credit-tracker/tests"
+)
+
+func TestRepositoryStoreContract(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+
+	storetest.Run(t, creditrepo.New(dbContainer.DB))
+}
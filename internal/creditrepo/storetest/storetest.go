@@ -0,0 +1,137 @@
+// Package storetest is a contract test suite of creditrepo.CreditStore, run against the Postgres repository and the memstore
+// so the in-memory store used by the controller tests behaves like the repository.
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const assetDID = "storetest-asset"
+
+// Run runs the contract tests against the store. Every test uses its own license, so the store may be shared with other tests.
+func Run(t *testing.T, store creditrepo.CreditStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	requireBalance := func(t *testing.T, licenseID string, balance, debt int64) {
+		t.Helper()
+		got, err := store.GetBalance(ctx, licenseID, assetDID)
+		require.NoError(t, err)
+		assert.Equal(t, balance, got.Balance, "balance")
+		assert.Equal(t, debt, got.Debt, "debt")
+	}
+
+	t.Run("deduction draws from the balance", func(t *testing.T) {
+		licenseID := "storetest-license-deduct"
+		_, err := store.ConfirmGrant(ctx, licenseID, assetDID, "0xstoretest-deduct-1", 0, 100, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		_, err = store.ConfirmGrant(ctx, licenseID, assetDID, "0xstoretest-deduct-2", 0, 100, time.Now())
+		require.NoError(t, err)
+
+		operation, err := store.DeductCredits(ctx, licenseID, assetDID, 150, "app", "storetest-deduct-1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(150), operation.TotalAmount)
+		assert.Equal(t, creditrepo.DefaultCreditType, operation.CreditType)
+		requireBalance(t, licenseID, 50, 0)
+
+		// Verify: A deduction past the balance and a reused reference ID are refused with the repository's sentinels
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 51, "app", "storetest-deduct-2")
+		require.ErrorIs(t, err, creditrepo.InsufficientCreditsErr)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 1, "app", "storetest-deduct-1")
+		require.ErrorIs(t, err, creditrepo.DuplicateOperationErr)
+		requireBalance(t, licenseID, 50, 0)
+	})
+
+	t.Run("debt blocks deductions until a grant settles it", func(t *testing.T) {
+		licenseID := "storetest-license-debt"
+		pending, err := store.CreateGrant(ctx, licenseID, assetDID, 100, time.Now())
+		require.NoError(t, err)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 60, "app", "storetest-debt-1")
+		require.NoError(t, err)
+		_, err = store.FailGrant(ctx, pending)
+		require.NoError(t, err)
+		requireBalance(t, licenseID, 0, 60)
+
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 1, "app", "storetest-debt-2")
+		require.ErrorIs(t, err, creditrepo.OutstandingDebtErr)
+
+		// Test: Confirming a grant settles the debt first
+		_, err = store.ConfirmGrant(ctx, licenseID, assetDID, "0xstoretest-debt", 0, 100, time.Now())
+		require.NoError(t, err)
+		requireBalance(t, licenseID, 40, 0)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 40, "app", "storetest-debt-3")
+		require.NoError(t, err)
+	})
+
+	t.Run("refund returns the credits once", func(t *testing.T) {
+		licenseID := "storetest-license-refund"
+		_, err := store.ConfirmGrant(ctx, licenseID, assetDID, "0xstoretest-refund", 0, 100, time.Now())
+		require.NoError(t, err)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 30, "app", "storetest-refund-1")
+		require.NoError(t, err)
+
+		operation, err := store.RefundCredits(ctx, "app", "storetest-refund-1", creditrepo.WithRefundReason("upstream_failure"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), operation.TotalAmount)
+		assert.Equal(t, "upstream_failure", operation.ReasonCode.String)
+		requireBalance(t, licenseID, 100, 0)
+
+		_, err = store.RefundCredits(ctx, "app", "storetest-refund-1")
+		require.ErrorIs(t, err, creditrepo.DuplicateOperationErr)
+		_, err = store.RefundCredits(ctx, "app", "storetest-refund-missing")
+		require.ErrorIs(t, err, creditrepo.OperationNotFoundErr)
+		requireBalance(t, licenseID, 100, 0)
+	})
+
+	t.Run("refund settles debt", func(t *testing.T) {
+		licenseID := "storetest-license-refund-debt"
+		// Setup: A pending grant spent in full, then a confirmed grant partly spent
+		pending, err := store.CreateGrant(ctx, licenseID, assetDID, 100, time.Now())
+		require.NoError(t, err)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 100, "app", "storetest-refund-debt-1")
+		require.NoError(t, err)
+		_, err = store.ConfirmGrant(ctx, licenseID, assetDID, "0xstoretest-refund-debt", 0, 100, time.Now())
+		require.NoError(t, err)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 40, "app", "storetest-refund-debt-2")
+		require.NoError(t, err)
+		_, err = store.FailGrant(ctx, pending)
+		require.NoError(t, err)
+		requireBalance(t, licenseID, 60, 100)
+
+		// Test: The refunded credits go back to their grant and then settle the debt
+		_, err = store.RefundCredits(ctx, "app", "storetest-refund-debt-2")
+		require.NoError(t, err)
+		requireBalance(t, licenseID, 0, 0)
+	})
+
+	t.Run("debt of a credit type only blocks that type", func(t *testing.T) {
+		licenseID := "storetest-license-credit-type"
+		pending, err := store.CreateGrant(ctx, licenseID, assetDID, 100, time.Now(), creditrepo.WithGrantCreditType("attestation"))
+		require.NoError(t, err)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 50, "app", "storetest-credit-type-1", creditrepo.WithCreditType("attestation"))
+		require.NoError(t, err)
+		_, err = store.FailGrant(ctx, pending)
+		require.NoError(t, err)
+		_, err = store.ConfirmGrant(ctx, licenseID, assetDID, "0xstoretest-credit-type", 0, 100, time.Now(), creditrepo.WithGrantCreditType("telemetry"))
+		require.NoError(t, err)
+
+		// Verify: The telemetry grant neither settles nor is blocked by the attestation debt
+		debt, err := store.GetDebt(ctx, licenseID, assetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), debt)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 100, "app", "storetest-credit-type-2", creditrepo.WithCreditType("telemetry"))
+		require.NoError(t, err)
+		_, err = store.DeductCredits(ctx, licenseID, assetDID, 1, "app", "storetest-credit-type-3", creditrepo.WithCreditType("attestation"))
+		require.ErrorIs(t, err, creditrepo.OutstandingDebtErr)
+
+		settled, err := store.SettleOutstandingDebt(ctx, licenseID, assetDID)
+		require.NoError(t, err)
+		assert.Zero(t, settled)
+	})
+}
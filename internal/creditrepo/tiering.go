@@ -0,0 +1,185 @@
+package creditrepo
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/null/v8"
+)
+
+// DiscountTier discounts the credits a license uses in a calendar month beyond a threshold.
+type DiscountTier struct {
+	// Credits used in the month from which the discount applies
+	Threshold int64
+	// Discount in percent, from 0 to 100
+	Percent int64
+}
+
+// NewDiscountTiers validates a tier table and returns it sorted by threshold.
+// Thresholds must be positive and unique, and percents between 0 and 100.
+func NewDiscountTiers(tiers []DiscountTier) ([]DiscountTier, error) {
+	tiers = slices.Clone(tiers)
+	slices.SortFunc(tiers, func(a, b DiscountTier) int {
+		return cmp.Compare(a.Threshold, b.Threshold)
+	})
+	for i, tier := range tiers {
+		if tier.Threshold <= 0 {
+			return nil, fmt.Errorf("invalid discount tier threshold %d: must be positive", tier.Threshold)
+		}
+		if tier.Percent < 0 || tier.Percent > 100 {
+			return nil, fmt.Errorf("invalid discount tier percent %d: must be between 0 and 100", tier.Percent)
+		}
+		if i > 0 && tiers[i-1].Threshold == tier.Threshold {
+			return nil, fmt.Errorf("duplicate discount tier threshold %d", tier.Threshold)
+		}
+	}
+	return tiers, nil
+}
+
+// ParseDiscountTiers parses a tier table of comma-separated threshold:percent pairs, e.g. 10000:10,50000:25.
+func ParseDiscountTiers(table string) ([]DiscountTier, error) {
+	if strings.TrimSpace(table) == "" {
+		return nil, nil
+	}
+	var tiers []DiscountTier
+	for _, entry := range strings.Split(table, ",") {
+		threshold, percent, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid discount tier %q: must be threshold:percent", entry)
+		}
+		var tier DiscountTier
+		var err error
+		if tier.Threshold, err = strconv.ParseInt(threshold, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid discount tier threshold %q: %w", threshold, err)
+		}
+		if tier.Percent, err = strconv.ParseInt(percent, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid discount tier percent %q: %w", percent, err)
+		}
+		tiers = append(tiers, tier)
+	}
+	return NewDiscountTiers(tiers)
+}
+
+// WithDiscountTiers sets the tier table of DeductWithTiering, the tiers should come from NewDiscountTiers or ParseDiscountTiers.
+func WithDiscountTiers(tiers []DiscountTier) Option {
+	return func(r *Repository) {
+		r.discountTiers = tiers
+	}
+}
+
+// TieredAmount returns the credits charged for a deduction of the nominal amount when the license already used
+// the given credits this month. Each part of the deduction is discounted by the tier its cumulative usage falls in,
+// so a deduction crossing a threshold is only discounted beyond it. Discounts are rounded down.
+func TieredAmount(tiers []DiscountTier, usedThisMonth, nominalAmount int64) int64 {
+	charged := int64(0)
+	start, end := usedThisMonth, usedThisMonth+nominalAmount
+	percent := int64(0)
+	for _, tier := range tiers {
+		if tier.Threshold > start {
+			// the part of the deduction below this tier is charged at the previous tier
+			upTo := min(tier.Threshold, end)
+			charged += discounted(upTo-start, percent)
+			start = upTo
+		}
+		if start >= end {
+			return charged
+		}
+		percent = tier.Percent
+	}
+	return charged + discounted(end-start, percent)
+}
+
+// discounted returns the amount less the percent discount, with the discount rounded down.
+func discounted(amount, percent int64) int64 {
+	discount := amount/100*percent + amount%100*percent/100
+	return amount - discount
+}
+
+// DeductWithTiering deducts credits like DeductCredits, charging the amount discounted by the tier table
+// for the license's usage of the current calendar month (UTC).
+// The month's usage is the nominal amount of the license's deductions since the start of the month, refunds do not lower it.
+// The operation records the discounted credits as its total amount and the requested credits as its nominal amount.
+// Without a tier table the whole amount is charged.
+func (r *Repository) DeductWithTiering(ctx context.Context, licenseID, assetDID string, deductionAmount uint64, appName, referenceID string) (*models.CreditOperation, error) {
+	operation, err := limitedTx(ctx, r, "DeductWithTiering", func(ctx context.Context) (*models.CreditOperation, error) {
+		return r.deductWithTieringInternal(ctx, licenseID, assetDID, deductionAmount, appName, referenceID)
+	})
+	if err != nil {
+		r.observeDebtRejection(ctx, licenseID, err)
+	}
+	return operation, err
+}
+
+// deductWithTieringInternal is the internal implementation of DeductWithTiering
+func (r *Repository) deductWithTieringInternal(ctx context.Context, licenseID, assetDID string, deductionAmount uint64, appName, referenceID string) (*models.CreditOperation, error) {
+	if deductionAmount > math.MaxInt64 {
+		return nil, fmt.Errorf("deduction amount is too large must be less than %d", math.MaxInt64)
+	}
+
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("DeductWithTiering")()
+	defer rollbackTx(ctx, tx)
+
+	// concurrent tiered deductions of a license are serialized so each one sees the usage of the others
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", licenseID); err != nil {
+		return nil, fmt.Errorf("failed to lock license usage: %w", err)
+	}
+	usedThisMonth, err := monthlyNominalUsage(ctx, tx, licenseID, startOfMonth(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	nominalAmount := int64(deductionAmount)
+	amount := TieredAmount(r.discountTiers, usedThisMonth, nominalAmount)
+	expired, err := r.expireGrants(ctx, tx, licenseID, assetDID)
+	if err != nil {
+		return nil, err
+	}
+	operation, _, err := r.debitGrantsTx(ctx, tx, licenseID, assetDID, amount, null.Int64From(nominalAmount), OperationTypeDeduction, appName, referenceID, DefaultCreditType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.logExpirations(ctx, expired)
+	r.logOperation(ctx, operation, "deducted credits with tiering")
+
+	return operation, nil
+}
+
+// monthlyNominalUsage returns the nominal credits of the license's deductions since the start of the month.
+// Deductions without a nominal amount count their total amount.
+func monthlyNominalUsage(ctx context.Context, tx *sql.Tx, licenseID string, monthStart time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+		SELECT COALESCE(SUM(COALESCE(%[1]s, %[2]s)), 0)
+		FROM %[3]s
+		WHERE %[4]s = $1 AND %[5]s = $2 AND %[6]s >= $3
+	`, models.CreditOperationTableColumns.NominalAmount, models.CreditOperationTableColumns.TotalAmount,
+		models.TableNames.CreditOperations, models.CreditOperationTableColumns.LicenseID,
+		models.CreditOperationTableColumns.OperationType, models.CreditOperationTableColumns.CreatedAt)
+
+	var used int64
+	if err := tx.QueryRowContext(ctx, query, licenseID, OperationTypeDeduction, monthStart).Scan(&used); err != nil {
+		return 0, fmt.Errorf("failed to get monthly usage: %w", err)
+	}
+	return used, nil
+}
+
+// startOfMonth returns the start of the calendar month of t in UTC.
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,155 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestTieredAmount(t *testing.T) {
+	t.Parallel()
+	tiers := []DiscountTier{{Threshold: 100, Percent: 10}, {Threshold: 200, Percent: 50}}
+
+	tests := []struct {
+		name          string
+		tiers         []DiscountTier
+		usedThisMonth int64
+		nominalAmount int64
+		expected      int64
+	}{
+		{name: "no-tiers", usedThisMonth: 500, nominalAmount: 40, expected: 40},
+		{name: "below-first-tier", tiers: tiers, usedThisMonth: 20, nominalAmount: 60, expected: 60},
+		{name: "ends-at-threshold", tiers: tiers, usedThisMonth: 60, nominalAmount: 40, expected: 40},
+		{name: "crosses-first-tier", tiers: tiers, usedThisMonth: 80, nominalAmount: 40, expected: 20 + 18},
+		{name: "within-first-tier", tiers: tiers, usedThisMonth: 100, nominalAmount: 50, expected: 45},
+		{name: "crosses-every-tier", tiers: tiers, usedThisMonth: 50, nominalAmount: 200, expected: 50 + 90 + 25},
+		{name: "beyond-last-tier", tiers: tiers, usedThisMonth: 1000, nominalAmount: 10, expected: 5},
+		{name: "discount-rounded-down", tiers: tiers, usedThisMonth: 100, nominalAmount: 5, expected: 5},
+		{name: "full-discount", tiers: []DiscountTier{{Threshold: 10, Percent: 100}}, usedThisMonth: 10, nominalAmount: 7, expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, TieredAmount(tt.tiers, tt.usedThisMonth, tt.nominalAmount))
+		})
+	}
+}
+
+func TestParseDiscountTiers(t *testing.T) {
+	t.Parallel()
+
+	tiers, err := ParseDiscountTiers("50000:25, 10000:10")
+	require.NoError(t, err)
+	assert.Equal(t, []DiscountTier{{Threshold: 10000, Percent: 10}, {Threshold: 50000, Percent: 25}}, tiers)
+
+	tiers, err = ParseDiscountTiers("")
+	require.NoError(t, err)
+	assert.Empty(t, tiers)
+
+	for _, table := range []string{"10000", "abc:10", "10000:x", "0:10", "10000:101", "10000:-1", "10000:10,10000:20"} {
+		_, err := ParseDiscountTiers(table)
+		assert.Error(t, err, table)
+	}
+}
+
+func TestDeductWithTiering(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	ctx := context.Background()
+	tiers, err := NewDiscountTiers([]DiscountTier{{Threshold: 100, Percent: 10}, {Threshold: 200, Percent: 50}})
+	require.NoError(t, err)
+	repo := New(db, WithDiscountTiers(tiers))
+
+	insertGrant := func(t *testing.T, licenseID string, remaining int64) *models.CreditGrant {
+		t.Helper()
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: remaining,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		return grant
+	}
+
+	t.Run("crossing tier boundaries within a month", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-tiering"
+		grant := insertGrant(t, licenseID, 1000)
+
+		// Deductions before the first threshold are charged in full
+		operation, err := repo.DeductWithTiering(ctx, licenseID, testAssetID, 80, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		assert.Equal(t, int64(80), operation.TotalAmount)
+		assert.Equal(t, null.Int64From(80), operation.NominalAmount)
+
+		// 20 credits below the threshold at full price and 20 beyond it at 10% off
+		operation, err = repo.DeductWithTiering(ctx, licenseID, testAssetID, 40, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		assert.Equal(t, int64(38), operation.TotalAmount)
+		assert.Equal(t, null.Int64From(40), operation.NominalAmount)
+
+		// Plain deductions count toward the month's usage without a discount
+		operation, err = repo.DeductCredits(ctx, licenseID, testAssetID, 30, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), operation.TotalAmount)
+		assert.False(t, operation.NominalAmount.Valid)
+
+		// 50 credits at 10% off and 50 beyond the second threshold at 50% off
+		operation, err = repo.DeductWithTiering(ctx, licenseID, testAssetID, 100, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), operation.TotalAmount)
+		assert.Equal(t, null.Int64From(100), operation.NominalAmount)
+
+		// Verify: The grant is debited the discounted credits
+		require.NoError(t, grant.Reload(ctx, db))
+		assert.Equal(t, int64(1000-80-38-30-70), grant.RemainingAmount)
+		stored, err := models.FindCreditOperation(ctx, db, operation.AppName, operation.ReferenceID, operation.OperationType)
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), stored.TotalAmount)
+		assert.Equal(t, null.Int64From(100), stored.NominalAmount)
+	})
+
+	t.Run("previous months do not count", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-tiering-previous-month"
+		insertGrant(t, licenseID, 1000)
+		previous := &models.CreditOperation{
+			LicenseID:     licenseID,
+			AssetDid:      testAssetID,
+			OperationType: OperationTypeDeduction,
+			TotalAmount:   500,
+			AppName:       testAPIEndpoint,
+			ReferenceID:   uuid.NewString(),
+			CreatedAt:     null.TimeFrom(startOfMonth(time.Now()).Add(-time.Hour)),
+		}
+		require.NoError(t, previous.Insert(ctx, db, boil.Infer()))
+
+		operation, err := repo.DeductWithTiering(ctx, licenseID, testAssetID, 50, testAPIEndpoint, uuid.NewString())
+		require.NoError(t, err)
+		assert.Equal(t, int64(50), operation.TotalAmount)
+	})
+
+	t.Run("insufficient credits for the discounted amount", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-tiering-insufficient"
+		insertGrant(t, licenseID, 20)
+
+		_, err := repo.DeductWithTiering(ctx, licenseID, testAssetID, 50, testAPIEndpoint, uuid.NewString())
+		var insufficientErr *InsufficientCreditsError
+		require.ErrorAs(t, err, &insufficientErr)
+	})
+}
@@ -0,0 +1,48 @@
+package creditrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/tracing"
+	"github.com/DIMO-Network/credit-tracker/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracerProvider sets the tracer provider of the spans around repository operations, the global provider by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(r *Repository) {
+		r.tracer = provider.Tracer(tracing.TracerName)
+	}
+}
+
+// startSpan starts the span of a repository operation, every attempt of the operation runs within it.
+func (r *Repository) startSpan(ctx context.Context, funcName string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "creditrepo."+funcName, trace.WithSpanKind(trace.SpanKindInternal))
+}
+
+// endSpan records the error of the operation, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// addDeadlockEvent records an attempt that deadlocked or failed to serialize on the span of the context.
+func addDeadlockEvent(ctx context.Context, attempt int, wait time.Duration) {
+	trace.SpanFromContext(ctx).AddEvent("deadlock retry", trace.WithAttributes(
+		attribute.Int("attempt", attempt),
+		attribute.Int64("wait_ms", wait.Milliseconds()),
+	))
+}
+
+// setOperationAttributes adds the license, asset, amount, and type of a recorded operation to the span of the context.
+func setOperationAttributes(ctx context.Context, operation *models.CreditOperation) {
+	trace.SpanFromContext(ctx).SetAttributes(
+		tracing.OperationAttributes(operation.LicenseID, operation.AssetDid, operation.TotalAmount, operation.OperationType)...,
+	)
+}
@@ -0,0 +1,200 @@
+package creditrepo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/tracing"
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestOperationTraceID(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+
+	t.Run("deduction trace ID is logged and stored", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-trace-id"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(context.Background(), db, boil.Infer()))
+
+		var logs bytes.Buffer
+		logger := zerolog.New(&logs).Level(zerolog.DebugLevel)
+		traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+		ctx := metadata.NewIncomingContext(logger.WithContext(context.Background()), metadata.Pairs(tracing.TraceIDMetadataKey, traceID))
+
+		// Test: Deduct through the gRPC trace interceptor
+		referenceID := uuid.NewString()
+		_, err := tracing.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			return repo.DeductCredits(ctx, licenseID, testAssetID, 1, testAPIEndpoint, referenceID)
+		})
+		require.NoError(t, err)
+
+		// Verify: The stored operation has the trace ID
+		operation, err := models.FindCreditOperation(context.Background(), db, testAPIEndpoint, referenceID, OperationTypeDeduction)
+		require.NoError(t, err)
+		assert.Equal(t, traceID, operation.TraceID.String)
+
+		// Verify: The deduction log has the same trace ID
+		logEntry := findLogEntry(t, &logs, "deducted credits")
+		assert.Equal(t, traceID, logEntry[tracing.LogField])
+		assert.Equal(t, referenceID, logEntry["referenceId"])
+	})
+}
+
+func TestOperationLogs(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db, WithBalanceSnapshots(true))
+
+	t.Run("deduction logs license and amount", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-operation-logs"
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: defaultGrantAmount,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, grant.Insert(context.Background(), db, boil.Infer()))
+
+		var logs bytes.Buffer
+		ctx := zerolog.New(&logs).Level(zerolog.DebugLevel).WithContext(context.Background())
+		referenceID := uuid.NewString()
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 25, testAPIEndpoint, referenceID)
+		require.NoError(t, err)
+
+		// Verify: The start and end of the deduction are logged with the license and amount
+		start := findLogEntry(t, &logs, "deducting credits")
+		assert.Equal(t, licenseID, start["licenseId"])
+		assert.Equal(t, testAssetID, start["assetDid"])
+		assert.Equal(t, referenceID, start["referenceId"])
+		assert.EqualValues(t, 25, start["amount"])
+		end := findLogEntry(t, &logs, "deducted credits")
+		assert.Equal(t, licenseID, end["licenseId"])
+		assert.EqualValues(t, 25, end["amount"])
+		assert.EqualValues(t, defaultGrantAmount-25, end["balanceAfter"])
+	})
+
+	t.Run("failed deduction is logged", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-operation-logs-failed"
+
+		var logs bytes.Buffer
+		ctx := zerolog.New(&logs).Level(zerolog.DebugLevel).WithContext(context.Background())
+		_, err := repo.DeductCredits(ctx, licenseID, testAssetID, 25, testAPIEndpoint, uuid.NewString())
+		require.Error(t, err)
+
+		failure := findLogEntry(t, &logs, "failed to deduct credits")
+		assert.Equal(t, licenseID, failure["licenseId"])
+		assert.NotEmpty(t, failure["error"])
+	})
+
+	t.Run("nothing is logged above debug level", func(t *testing.T) {
+		t.Parallel()
+		var logs bytes.Buffer
+		ctx := zerolog.New(&logs).Level(zerolog.InfoLevel).WithContext(context.Background())
+		_, err := repo.CreateGrant(ctx, "test-license-operation-logs-info", testAssetID, 100, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, logs.String())
+	})
+}
+
+// findLogEntry returns the first JSON log entry with the message.
+func findLogEntry(t *testing.T, logs *bytes.Buffer, msg string) map[string]any {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(logs.Bytes()))
+	for decoder.More() {
+		var entry map[string]any
+		require.NoError(t, decoder.Decode(&entry))
+		if entry[zerolog.MessageFieldName] == msg {
+			return entry
+		}
+	}
+	require.Failf(t, "log entry not found", "no log entry with message %q in %s", msg, logs.String())
+	return nil
+}
+
+func TestRetryTxSpan(t *testing.T) {
+	t.Parallel()
+	exporter := tracetest.NewInMemoryExporter()
+	repo := New(nil, WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))))
+
+	t.Run("records the operation and deadlock retries", func(t *testing.T) {
+		exporter.Reset()
+		attempts := 0
+		_, err := retryTx(context.Background(), repo, "DeductCredits", func(ctx context.Context) (*models.CreditOperation, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, &pq.Error{Code: DeadlockError}
+			}
+			operation := &models.CreditOperation{LicenseID: "license", AssetDid: "asset", OperationType: OperationTypeDeduction, TotalAmount: 25}
+			repo.logOperation(ctx, operation, "deducted credits")
+			return operation, nil
+		})
+		require.NoError(t, err)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "creditrepo.DeductCredits", span.Name)
+		assert.Equal(t, codes.Unset, span.Status.Code)
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			tracing.LicenseIDKey.String("license"),
+			tracing.AssetDIDKey.String("asset"),
+			tracing.AmountKey.Int64(25),
+			tracing.OperationTypeKey.String(OperationTypeDeduction),
+		}, span.Attributes)
+		require.Len(t, span.Events, 1)
+		assert.Equal(t, "deadlock retry", span.Events[0].Name)
+		assert.Contains(t, span.Events[0].Attributes, attribute.Int("attempt", 1))
+	})
+
+	t.Run("records the error of a failed operation", func(t *testing.T) {
+		exporter.Reset()
+		_, err := retryTx(context.Background(), repo, "RefundCredits", func(context.Context) (int, error) {
+			return 0, errors.New("refund failed")
+		})
+		require.Error(t, err)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "creditrepo.RefundCredits", spans[0].Name)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.Equal(t, "refund failed", spans[0].Status.Description)
+	})
+}
@@ -0,0 +1,132 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// TransferCredits moves credits between two assets of the same license, e.g. for a vehicle re-registered under a new asset DID.
+// The credits are deducted from the active grants of the source asset in FIFO order and granted to the destination asset
+// as a confirmed grant, whose tx hash is the reference ID. The grant expires with the latest expiring grant the credits came from,
+// and never expires if any of them was perpetual.
+// Like DeductCredits the transfer is refused while the source asset has outstanding debt or an insufficient balance.
+// The source operation is keyed by the reference ID and the destination operation by the new grant ID,
+// so repeating a transfer with the same reference ID is rejected as a duplicate.
+func (r *Repository) TransferCredits(ctx context.Context, licenseID, fromAssetDID, toAssetDID string, amount uint64, referenceID string) (*models.CreditOperation, error) {
+	if amount == 0 {
+		return nil, fmt.Errorf("invalid amount: %d. Amount must be positive", amount)
+	}
+	if amount > math.MaxInt64 {
+		return nil, fmt.Errorf("transfer amount is too large must be less than %d", math.MaxInt64)
+	}
+	if licenseID == "" || fromAssetDID == "" || toAssetDID == "" || referenceID == "" {
+		return nil, fmt.Errorf("licenseID, fromAssetDID, toAssetDID, and referenceID are required")
+	}
+	if fromAssetDID == toAssetDID {
+		return nil, fmt.Errorf("cannot transfer credits to the same asset")
+	}
+	operation, err := retryTx(ctx, r, "TransferCredits", func(ctx context.Context) (*models.CreditOperation, error) {
+		return r.transferCreditsInternal(ctx, licenseID, fromAssetDID, toAssetDID, int64(amount), referenceID)
+	})
+	if err != nil {
+		r.observeDebtRejection(ctx, licenseID, err)
+	}
+	return operation, err
+}
+
+// transferCreditsInternal is the internal implementation of TransferCredits, it returns the source operation.
+func (r *Repository) transferCreditsInternal(ctx context.Context, licenseID, fromAssetDID, toAssetDID string, amount int64, referenceID string) (*models.CreditOperation, error) {
+	tx, err := r.db.BeginTx(ctx, r.writeTxOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer observeTransaction("TransferCredits")()
+	defer rollbackTx(ctx, tx)
+
+	expired, err := r.expireGrants(ctx, tx, licenseID, fromAssetDID)
+	if err != nil {
+		return nil, err
+	}
+	operation, debited, err := r.debitGrantsTx(ctx, tx, licenseID, fromAssetDID, amount, null.Int64{}, OperationTypeTransfer, "credit_tracker", referenceID, DefaultCreditType)
+	if err != nil {
+		return nil, err
+	}
+
+	grant := &models.CreditGrant{
+		LicenseID:       licenseID,
+		AssetDid:        toAssetDID,
+		InitialAmount:   amount,
+		RemainingAmount: amount,
+		TXHash:          referenceID,
+		Status:          GrantStatusConfirmed,
+		ExpiresAt:       transferExpiration(debited),
+		CreatedAt:       null.TimeFrom(time.Now()),
+		UpdatedAt:       null.TimeFrom(time.Now()),
+	}
+	if err := grant.Insert(ctx, tx, boil.Infer()); err != nil {
+		return nil, fmt.Errorf("failed to create grant record: %w", err)
+	}
+
+	received := &models.CreditOperation{
+		LicenseID:     licenseID,
+		AssetDid:      toAssetDID,
+		OperationType: OperationTypeTransfer,
+		TotalAmount:   amount,
+		AppName:       "credit_tracker",
+		ReferenceID:   grant.ID,
+		CreatedAt:     null.TimeFrom(time.Now()),
+		TraceID:       traceIDFrom(ctx),
+	}
+	if err := received.Insert(ctx, tx, boil.Infer()); err != nil {
+		return nil, fmt.Errorf("failed to create operation record: %w", err)
+	}
+	opGrant := &models.CreditOperationGrant{
+		AppName:       received.AppName,
+		ReferenceID:   received.ReferenceID,
+		OperationType: received.OperationType,
+		GrantID:       grant.ID,
+		AmountUsed:    amount,
+	}
+	if err := opGrant.Insert(ctx, tx, boil.Infer()); err != nil {
+		return nil, fmt.Errorf("failed to record operation grant: %w", err)
+	}
+
+	if _, err := r.settleDebt(ctx, tx, licenseID, toAssetDID, DefaultCreditType, "credit_tracker", grant.ID); err != nil {
+		return nil, fmt.Errorf("failed to settle debt: %w", err)
+	}
+	if err := r.recordOperationBalance(ctx, tx, received); err != nil {
+		return nil, err
+	}
+	if err := r.updateBalanceSummary(ctx, tx, licenseID, toAssetDID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	r.logExpirations(ctx, expired)
+	r.logOperation(ctx, operation, "transferred credits")
+	r.observeSettledDebt(ctx, licenseID)
+
+	return operation, nil
+}
+
+// transferExpiration returns the latest expiration of the given grants, or null if any of them never expires.
+func transferExpiration(grants []*models.CreditGrant) null.Time {
+	var expiresAt null.Time
+	for _, grant := range grants {
+		if !grant.ExpiresAt.Valid {
+			return null.Time{}
+		}
+		if !expiresAt.Valid || grant.ExpiresAt.Time.After(expiresAt.Time) {
+			expiresAt = grant.ExpiresAt
+		}
+	}
+	return expiresAt
+}
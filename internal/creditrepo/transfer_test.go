@@ -0,0 +1,168 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestTransferCredits(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+	toAssetID := testAssetID + "-new"
+
+	insertGrant := func(t *testing.T, licenseID, assetDID string, remaining int64, expiresAt time.Time) *models.CreditGrant {
+		t.Helper()
+		grant := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        assetDID,
+			InitialAmount:   defaultGrantAmount,
+			RemainingAmount: remaining,
+			Status:          GrantStatusConfirmed,
+			ExpiresAt:       null.TimeFrom(expiresAt),
+		}
+		require.NoError(t, grant.Insert(ctx, db, boil.Infer()))
+		return grant
+	}
+
+	t.Run("full transfer", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-transfer-full"
+		referenceID := uuid.NewString()
+		earlier := insertGrant(t, licenseID, testAssetID, 30, time.Now().Add(24*time.Hour))
+		later := insertGrant(t, licenseID, testAssetID, 70, time.Now().Add(48*time.Hour))
+
+		operation, err := repo.TransferCredits(ctx, licenseID, testAssetID, toAssetID, 100, referenceID)
+		require.NoError(t, err)
+		assert.Equal(t, OperationTypeTransfer, operation.OperationType)
+		assert.Equal(t, testAssetID, operation.AssetDid)
+		assert.Equal(t, int64(100), operation.TotalAmount)
+
+		// Verify: The source grants are drained in FIFO order
+		require.NoError(t, earlier.Reload(ctx, db))
+		require.NoError(t, later.Reload(ctx, db))
+		assert.Equal(t, int64(0), earlier.RemainingAmount)
+		assert.Equal(t, int64(0), later.RemainingAmount)
+		sourceBalance, err := repo.GetBalance(ctx, licenseID, testAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), sourceBalance.Balance)
+
+		// Verify: The destination has a confirmed grant expiring with the latest source grant
+		destGrant, err := models.CreditGrants(
+			models.CreditGrantWhere.LicenseID.EQ(licenseID),
+			models.CreditGrantWhere.AssetDid.EQ(toAssetID),
+		).One(ctx, db)
+		require.NoError(t, err)
+		assert.Equal(t, GrantStatusConfirmed, destGrant.Status)
+		assert.Equal(t, int64(100), destGrant.InitialAmount)
+		assert.Equal(t, int64(100), destGrant.RemainingAmount)
+		assert.Equal(t, referenceID, destGrant.TXHash)
+		assert.True(t, later.ExpiresAt.Time.Equal(destGrant.ExpiresAt.Time))
+		destBalance, err := repo.GetBalance(ctx, licenseID, toAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), destBalance.Balance)
+
+		// Verify: Paired transfer operations on both assets
+		operations, err := models.CreditOperations(
+			models.CreditOperationWhere.LicenseID.EQ(licenseID),
+			models.CreditOperationWhere.OperationType.EQ(OperationTypeTransfer),
+		).All(ctx, db)
+		require.NoError(t, err)
+		require.Len(t, operations, 2)
+		byAsset := map[string]*models.CreditOperation{}
+		for _, op := range operations {
+			byAsset[op.AssetDid] = op
+		}
+		assert.Equal(t, referenceID, byAsset[testAssetID].ReferenceID)
+		assert.Equal(t, destGrant.ID, byAsset[toAssetID].ReferenceID)
+		assert.Equal(t, int64(100), byAsset[toAssetID].TotalAmount)
+
+		opGrants, err := models.CreditOperationGrants(
+			models.CreditOperationGrantWhere.ReferenceID.EQ(referenceID),
+			models.CreditOperationGrantWhere.OperationType.EQ(OperationTypeTransfer),
+		).All(ctx, db)
+		require.NoError(t, err)
+		require.Len(t, opGrants, 2)
+		assert.Equal(t, int64(-100), opGrants[0].AmountUsed+opGrants[1].AmountUsed)
+	})
+
+	t.Run("over balance transfer is rejected", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-transfer-over-balance"
+		grant := insertGrant(t, licenseID, testAssetID, 40, time.Now().Add(24*time.Hour))
+
+		_, err := repo.TransferCredits(ctx, licenseID, testAssetID, toAssetID, 50, uuid.NewString())
+		require.ErrorIs(t, err, InsufficientCreditsErr)
+
+		require.NoError(t, grant.Reload(ctx, db))
+		assert.Equal(t, int64(40), grant.RemainingAmount)
+		count, err := models.CreditGrants(
+			models.CreditGrantWhere.LicenseID.EQ(licenseID),
+			models.CreditGrantWhere.AssetDid.EQ(toAssetID),
+		).Count(ctx, db)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("transfer with outstanding debt is rejected", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-transfer-debt"
+		insertGrant(t, licenseID, testAssetID, 100, time.Now().Add(24*time.Hour))
+		failed := &models.CreditGrant{
+			LicenseID:       licenseID,
+			AssetDid:        testAssetID,
+			InitialAmount:   20,
+			RemainingAmount: 0,
+			Status:          GrantStatusFailed,
+			ExpiresAt:       null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		require.NoError(t, failed.Insert(ctx, db, boil.Infer()))
+
+		_, err := repo.TransferCredits(ctx, licenseID, testAssetID, toAssetID, 10, uuid.NewString())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "outstanding debt")
+	})
+
+	t.Run("repeated reference ID is rejected", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-transfer-idempotent"
+		referenceID := uuid.NewString()
+		grant := insertGrant(t, licenseID, testAssetID, 100, time.Now().Add(24*time.Hour))
+
+		_, err := repo.TransferCredits(ctx, licenseID, testAssetID, toAssetID, 30, referenceID)
+		require.NoError(t, err)
+		_, err = repo.TransferCredits(ctx, licenseID, testAssetID, toAssetID, 30, referenceID)
+		require.Error(t, err)
+		assert.True(t, IsDuplicateKeyError(err))
+
+		// Verify: Only the first transfer moved credits
+		require.NoError(t, grant.Reload(ctx, db))
+		assert.Equal(t, int64(70), grant.RemainingAmount)
+		destBalance, err := repo.GetBalance(ctx, licenseID, toAssetID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), destBalance.Balance)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.TransferCredits(ctx, "test-license-transfer-invalid", testAssetID, testAssetID, 10, uuid.NewString())
+		require.Error(t, err)
+		_, err = repo.TransferCredits(ctx, "test-license-transfer-invalid", testAssetID, toAssetID, 0, uuid.NewString())
+		require.Error(t, err)
+		_, err = repo.TransferCredits(ctx, "test-license-transfer-invalid", testAssetID, toAssetID, 10, "")
+		require.Error(t, err)
+	})
+}
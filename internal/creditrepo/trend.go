@@ -0,0 +1,92 @@
+package creditrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+)
+
+const maxTrendWindows = 366
+
+// grantVsUsageTrendQuery sums the confirmed grant and net used credits of a license ($1) per window of $3 seconds,
+// counted from $2 up to $4. The used credits are those of the types in the array $5 less those of the types in the array $6.
+// Windows without operations are not returned.
+var grantVsUsageTrendQuery = fmt.Sprintf(`
+	SELECT FLOOR(EXTRACT(EPOCH FROM (%[1]s - $2)) / $3)::int AS window_index,
+		COALESCE(SUM(%[2]s) FILTER (WHERE %[3]s = '%[4]s'), 0) AS granted,
+		COALESCE(SUM(%[2]s) FILTER (WHERE %[3]s = ANY($5)), 0) - COALESCE(SUM(%[2]s) FILTER (WHERE %[3]s = ANY($6)), 0) AS used
+	FROM %[5]s
+	WHERE %[6]s = $1 AND %[1]s >= $2 AND %[1]s < $4
+	GROUP BY window_index
+`,
+	models.CreditOperationColumns.CreatedAt,
+	models.CreditOperationColumns.TotalAmount,
+	models.CreditOperationColumns.OperationType,
+	OperationTypeGrantConfirm,
+	models.TableNames.CreditOperations,
+	models.CreditOperationColumns.LicenseID,
+)
+
+// TrendWindow is the credits granted and used by a license during a single window.
+type TrendWindow struct {
+	// Start of the window, inclusive
+	Start time.Time `json:"start"`
+	// End of the window, exclusive
+	End time.Time `json:"end"`
+	// Credits of the grants confirmed during the window
+	Granted int64 `json:"granted"`
+	// Credits deducted during the window less the credits refunded, or as set by the usage options
+	Used int64 `json:"used"`
+}
+
+// trendBucket is a row of the grant vs usage trend query.
+type trendBucket struct {
+	WindowIndex int   `boil:"window_index"`
+	Granted     int64 `boil:"granted"`
+	Used        int64 `boil:"used"`
+}
+
+// GetGrantVsUsageTrend returns the credits granted and used by a license over count consecutive windows of the given length,
+// oldest first, with the last window ending now.
+func (r *Repository) GetGrantVsUsageTrend(ctx context.Context, licenseID string, window time.Duration, count int) ([]TrendWindow, error) {
+	return r.getGrantVsUsageTrend(ctx, licenseID, window, count, time.Now())
+}
+
+// getGrantVsUsageTrend is the implementation of GetGrantVsUsageTrend with the last window ending at end.
+func (r *Repository) getGrantVsUsageTrend(ctx context.Context, licenseID string, window time.Duration, count int, end time.Time) ([]TrendWindow, error) {
+	if licenseID == "" {
+		return nil, fmt.Errorf("licenseID is required")
+	}
+	if window < time.Second {
+		return nil, fmt.Errorf("window must be at least one second")
+	}
+	if count <= 0 || count > maxTrendWindows {
+		return nil, fmt.Errorf("count must be between 1 and %d", maxTrendWindows)
+	}
+
+	start := end.Add(-window * time.Duration(count))
+	windows := make([]TrendWindow, count)
+	for i := range windows {
+		windows[i].Start = start.Add(window * time.Duration(i))
+		windows[i].End = windows[i].Start.Add(window)
+	}
+
+	var buckets []trendBucket
+	err := queries.Raw(grantVsUsageTrendQuery, licenseID, start, window.Seconds(), end,
+		pq.StringArray(r.usage.UsedTypes), pq.StringArray(r.usage.ReturnedTypes)).Bind(ctx, r.db, &buckets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grant vs usage trend: %w", err)
+	}
+	for _, bucket := range buckets {
+		if bucket.WindowIndex < 0 || bucket.WindowIndex >= count {
+			continue
+		}
+		windows[bucket.WindowIndex].Granted = bucket.Granted
+		windows[bucket.WindowIndex].Used = bucket.Used
+	}
+	return windows, nil
+}
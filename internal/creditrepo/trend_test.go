@@ -0,0 +1,77 @@
+package creditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/DIMO-Network/credit-tracker/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestGetGrantVsUsageTrend(t *testing.T) {
+	t.Parallel()
+	dbContainer := tests.SetupTestContainer(t)
+	dbContainer.TeardownIfLastTest(t)
+	db := dbContainer.DB
+
+	repo := New(db)
+	ctx := context.Background()
+	week := 7 * 24 * time.Hour
+
+	t.Run("per window totals", func(t *testing.T) {
+		t.Parallel()
+		licenseID := "test-license-trend"
+		end := time.Now().Truncate(time.Second)
+		start := end.Add(-3 * week)
+		operations := []*models.CreditOperation{
+			// before the first window
+			{OperationType: OperationTypeGrantConfirm, TotalAmount: 5000, ReferenceID: "old-grant", CreatedAt: null.TimeFrom(start.Add(-time.Hour))},
+			// first window
+			{OperationType: OperationTypeGrantConfirm, TotalAmount: 1000, ReferenceID: "grant-1", CreatedAt: null.TimeFrom(start)},
+			{OperationType: OperationTypeDeduction, TotalAmount: 300, ReferenceID: "deduct-1", CreatedAt: null.TimeFrom(start.Add(time.Hour))},
+			{OperationType: OperationTypeRefund, TotalAmount: 100, ReferenceID: "deduct-1", CreatedAt: null.TimeFrom(start.Add(2 * time.Hour))},
+			// second window has no operations
+			// third window, pending purchases and settlements are neither granted nor used
+			{OperationType: OperationTypeGrantPurchase, TotalAmount: 2000, ReferenceID: "grant-2", CreatedAt: null.TimeFrom(start.Add(2 * week))},
+			{OperationType: OperationTypeGrantConfirm, TotalAmount: 2000, ReferenceID: "grant-2", CreatedAt: null.TimeFrom(start.Add(2*week + time.Hour))},
+			{OperationType: OperationTypeDeduction, TotalAmount: 700, ReferenceID: "deduct-2", CreatedAt: null.TimeFrom(start.Add(3*week - time.Second))},
+			// at the end of the last window
+			{OperationType: OperationTypeDeduction, TotalAmount: 900, ReferenceID: "deduct-3", CreatedAt: null.TimeFrom(end)},
+		}
+		for _, operation := range operations {
+			operation.LicenseID = licenseID
+			operation.AssetDid = testAssetID
+			operation.AppName = testAPIEndpoint
+			require.NoError(t, operation.Insert(ctx, db, boil.Infer()))
+		}
+
+		windows, err := repo.getGrantVsUsageTrend(ctx, licenseID, week, 3, end)
+		require.NoError(t, err)
+		require.Len(t, windows, 3)
+		for i, window := range windows {
+			assert.True(t, start.Add(time.Duration(i)*week).Equal(window.Start))
+			assert.True(t, window.Start.Add(week).Equal(window.End))
+		}
+		assert.Equal(t, int64(1000), windows[0].Granted)
+		assert.Equal(t, int64(200), windows[0].Used)
+		assert.Equal(t, int64(0), windows[1].Granted)
+		assert.Equal(t, int64(0), windows[1].Used)
+		assert.Equal(t, int64(2000), windows[2].Granted)
+		assert.Equal(t, int64(700), windows[2].Used)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+		_, err := repo.GetGrantVsUsageTrend(ctx, "", week, 6)
+		require.Error(t, err)
+		_, err = repo.GetGrantVsUsageTrend(ctx, "test-license-trend-invalid", 0, 6)
+		require.Error(t, err)
+		_, err = repo.GetGrantVsUsageTrend(ctx, "test-license-trend-invalid", week, 0)
+		require.Error(t, err)
+	})
+}
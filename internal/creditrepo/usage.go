@@ -0,0 +1,83 @@
+package creditrepo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/DIMO-Network/credit-tracker/models"
+)
+
+// UsageOptions sets which operation types count toward the credits used in reports and trends.
+// The credits used are the credits of the used types less the credits of the returned types.
+type UsageOptions struct {
+	// Operation types whose credits count as used
+	UsedTypes []string
+	// Operation types whose credits are taken off the credits used
+	ReturnedTypes []string
+}
+
+// DefaultUsageOptions counts deductions as used and takes refunds off, so settlements,
+// expirations, and failed grants do not affect usage.
+func DefaultUsageOptions() UsageOptions {
+	return UsageOptions{
+		UsedTypes:     []string{OperationTypeDeduction},
+		ReturnedTypes: []string{OperationTypeRefund},
+	}
+}
+
+// NewUsageOptions validates the operation types counted as used and returned.
+// Empty used types count deductions, and empty returned types take refunds off.
+// Grant operations cannot be counted, and a type cannot be both used and returned.
+func NewUsageOptions(usedTypes, returnedTypes []string) (UsageOptions, error) {
+	opts := DefaultUsageOptions()
+	if len(usedTypes) > 0 {
+		opts.UsedTypes = usedTypes
+	}
+	if len(returnedTypes) > 0 {
+		opts.ReturnedTypes = returnedTypes
+	}
+	for _, operationType := range slices.Concat(opts.UsedTypes, opts.ReturnedTypes) {
+		switch operationType {
+		case OperationTypeDeduction, OperationTypeRefund, OperationTypeDebtSettlement, OperationTypeExpiration, OperationTypeGrantFailed:
+		default:
+			return UsageOptions{}, fmt.Errorf("invalid usage operation type: %q", operationType)
+		}
+	}
+	for _, operationType := range opts.UsedTypes {
+		if slices.Contains(opts.ReturnedTypes, operationType) {
+			return UsageOptions{}, fmt.Errorf("operation type %s cannot be both used and returned", operationType)
+		}
+	}
+	return opts, nil
+}
+
+// WithUsageOptions sets the operation types counted as credits used, the options should come from NewUsageOptions.
+func WithUsageOptions(opts UsageOptions) Option {
+	return func(r *Repository) {
+		r.usage = opts
+	}
+}
+
+// types returns every operation type that affects the credits used.
+func (o UsageOptions) types() []string {
+	return slices.Concat(o.UsedTypes, o.ReturnedTypes)
+}
+
+// creditSelect returns the select expression of the credits used, aliased usage_count.
+// The types are validated by NewUsageOptions so they are safe to quote into the query.
+func (o UsageOptions) creditSelect() string {
+	return fmt.Sprintf(`
+		COALESCE(SUM(CASE WHEN %[1]s IN (%[2]s) THEN %[3]s ELSE 0 END), 0) -
+		COALESCE(SUM(CASE WHEN %[1]s IN (%[4]s) THEN %[3]s ELSE 0 END), 0) as usage_count
+	`, models.CreditOperationTableColumns.OperationType, quoteTypes(o.UsedTypes),
+		models.CreditOperationTableColumns.TotalAmount, quoteTypes(o.ReturnedTypes))
+}
+
+// quoteTypes returns the operation types as a list of SQL string literals, NULL for an empty list.
+func quoteTypes(operationTypes []string) string {
+	if len(operationTypes) == 0 {
+		return "NULL"
+	}
+	return "'" + strings.Join(operationTypes, "', '") + "'"
+}
@@ -0,0 +1,40 @@
+package creditrepo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUsageOptions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty types keep the defaults", func(t *testing.T) {
+		t.Parallel()
+		opts, err := NewUsageOptions(nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultUsageOptions(), opts)
+	})
+
+	t.Run("configured types", func(t *testing.T) {
+		t.Parallel()
+		opts, err := NewUsageOptions([]string{OperationTypeDeduction, OperationTypeDebtSettlement}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{OperationTypeDeduction, OperationTypeDebtSettlement}, opts.UsedTypes)
+		assert.Equal(t, []string{OperationTypeRefund}, opts.ReturnedTypes)
+		assert.Equal(t, []string{OperationTypeDeduction, OperationTypeDebtSettlement, OperationTypeRefund}, opts.types())
+		assert.Contains(t, opts.creditSelect(), "IN ('deduction', 'debt_settlement')")
+		assert.Contains(t, opts.creditSelect(), "IN ('refund')")
+	})
+
+	t.Run("invalid types", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewUsageOptions([]string{OperationTypeGrantConfirm}, nil)
+		require.Error(t, err)
+		_, err = NewUsageOptions([]string{"deduction' OR '1'='1"}, nil)
+		require.Error(t, err)
+		_, err = NewUsageOptions(nil, []string{OperationTypeDeduction})
+		require.Error(t, err)
+	})
+}
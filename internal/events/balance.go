@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DIMO-Network/cloudevent"
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+)
+
+const (
+	// LowBalanceEventType is the cloud event type published when a balance drops below the low balance threshold.
+	LowBalanceEventType = "credit.balance.low"
+	// GrantConfirmedEventType is the cloud event type published when a grant is confirmed and its credits can be spent.
+	GrantConfirmedEventType = "credit.grant_confirmed"
+	creditTrackerSource     = "dimo/credit-tracker"
+)
+
+// LowBalanceData is the payload of a low balance event.
+type LowBalanceData struct {
+	LicenseID string `json:"licenseId"`
+	AssetDid  string `json:"assetDid"`
+	Balance   int64  `json:"balance"`
+	Threshold int64  `json:"threshold"`
+}
+
+// GrantConfirmedData is the payload of a grant confirmed event.
+type GrantConfirmedData struct {
+	LicenseID string `json:"licenseId"`
+	AssetDid  string `json:"assetDid"`
+	GrantID   string `json:"grantId"`
+	Amount    int64  `json:"amount"`
+	Balance   int64  `json:"balance"`
+}
+
+// BalancePublisher publishes balance cloud events to a Kafka topic.
+type BalancePublisher struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+// NewBalancePublisher creates a publisher sending to the given topic.
+func NewBalancePublisher(producer sarama.SyncProducer, topic string) *BalancePublisher {
+	return &BalancePublisher{producer: producer, topic: topic}
+}
+
+// PublishLowBalance publishes a low balance event for the license and asset, keyed by license so a license's events stay in order.
+func (p *BalancePublisher) PublishLowBalance(_ context.Context, licenseID, assetDID string, balance, threshold int64) error {
+	data := LowBalanceData{
+		LicenseID: licenseID,
+		AssetDid:  assetDID,
+		Balance:   balance,
+		Threshold: threshold,
+	}
+	if err := publish(p, LowBalanceEventType, licenseID, assetDID, data); err != nil {
+		return fmt.Errorf("failed to publish low balance event: %w", err)
+	}
+	return nil
+}
+
+// PublishGrantConfirmed publishes a grant confirmed event with the confirmed credits and the balance after the confirmation,
+// keyed by license so a license's events stay in order.
+func (p *BalancePublisher) PublishGrantConfirmed(_ context.Context, licenseID, assetDID, grantID string, amount, balance int64) error {
+	data := GrantConfirmedData{
+		LicenseID: licenseID,
+		AssetDid:  assetDID,
+		GrantID:   grantID,
+		Amount:    amount,
+		Balance:   balance,
+	}
+	if err := publish(p, GrantConfirmedEventType, licenseID, assetDID, data); err != nil {
+		return fmt.Errorf("failed to publish grant confirmed event: %w", err)
+	}
+	return nil
+}
+
+// publish sends a cloud event of the asset with the data to the publisher's topic, keyed by license.
+func publish[T any](p *BalancePublisher, eventType, licenseID, assetDID string, data T) error {
+	event := cloudevent.CloudEvent[T]{
+		CloudEventHeader: cloudevent.CloudEventHeader{
+			ID:              uuid.NewString(),
+			Source:          creditTrackerSource,
+			Producer:        creditTrackerSource,
+			SpecVersion:     cloudevent.SpecVersion,
+			Subject:         assetDID,
+			Time:            time.Now().UTC(),
+			Type:            eventType,
+			DataContentType: "application/json",
+		},
+		Data: data,
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	_, _, err = p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(licenseID),
+		Value: sarama.ByteEncoder(value),
+	})
+	return err
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// burnContractABI is the part of the DCX burn contract ABI used to burn DCX for the credits of a license and asset.
+	burnContractABI = `[{"type":"function","name":"burnForCredits","stateMutability":"nonpayable","inputs":[` +
+		`{"name":"licenseId","type":"string"},{"name":"assetDid","type":"string"},{"name":"amount","type":"uint256"}],"outputs":[]}]`
+	burnMethod = "burnForCredits"
+)
+
+// ChainBurner sends credit burn transactions to the DCX burn contract.
+type ChainBurner struct {
+	contract *bind.BoundContract
+	signer   *bind.TransactOpts
+	// mu serializes sends so concurrent burns do not pick the same pending nonce
+	mu sync.Mutex
+}
+
+// NewChainBurner creates a burner sending from the account of the private key to the burn contract through the backend,
+// usually an *ethclient.Client.
+func NewChainBurner(backend bind.ContractBackend, contractAddress common.Address, privateKey *ecdsa.PrivateKey, chainID *big.Int) (*ChainBurner, error) {
+	parsed, err := abi.JSON(strings.NewReader(burnContractABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse burn contract ABI: %w", err)
+	}
+	signer, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transaction signer: %w", err)
+	}
+	return &ChainBurner{
+		contract: bind.NewBoundContract(contractAddress, parsed, backend, backend, backend),
+		signer:   signer,
+	}, nil
+}
+
+// BurnCredits sends the burn transaction for the credits of a license and asset and returns it without waiting for it to be mined.
+func (b *ChainBurner) BurnCredits(ctx context.Context, licenseID, assetDID string, amount uint64) (*types.Transaction, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	opts := *b.signer
+	opts.Context = ctx
+	tx, err := b.contract.Transact(&opts, burnMethod, licenseID, assetDID, new(big.Int).SetUint64(amount))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send burn transaction: %w", err)
+	}
+	return tx, nil
+}
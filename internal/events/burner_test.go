@@ -0,0 +1,92 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockBackend is a contract backend that records the transactions sent to it.
+// Methods a burn does not use are left to the embedded nil interface.
+type mockBackend struct {
+	bind.ContractBackend
+	sendErr error
+	sent    []*types.Transaction
+}
+
+func (m *mockBackend) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(1_000_000_000)}, nil
+}
+
+func (m *mockBackend) PendingCodeAt(context.Context, common.Address) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (m *mockBackend) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return uint64(len(m.sent)), nil
+}
+
+func (m *mockBackend) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return big.NewInt(1_000_000_000), nil
+}
+
+func (m *mockBackend) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return big.NewInt(1_000_000_000), nil
+}
+
+func (m *mockBackend) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 100_000, nil
+}
+
+func (m *mockBackend) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	if m.sendErr != nil {
+		return m.sendErr
+	}
+	m.sent = append(m.sent, tx)
+	return nil
+}
+
+func TestChainBurner(t *testing.T) {
+	ctx := context.Background()
+	contractAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	t.Run("sends burn to the contract", func(t *testing.T) {
+		backend := &mockBackend{}
+		burner, err := NewChainBurner(backend, contractAddress, privateKey, big.NewInt(80002))
+		require.NoError(t, err)
+
+		tx, err := burner.BurnCredits(ctx, "license-burn", testAssetDID, 100)
+		require.NoError(t, err)
+		require.Len(t, backend.sent, 1)
+		assert.Equal(t, backend.sent[0].Hash(), tx.Hash())
+		assert.Equal(t, contractAddress, *tx.To())
+
+		parsed, err := abi.JSON(strings.NewReader(burnContractABI))
+		require.NoError(t, err)
+		args, err := parsed.Methods[burnMethod].Inputs.Unpack(tx.Data()[4:])
+		require.NoError(t, err)
+		assert.Equal(t, []any{"license-burn", testAssetDID, big.NewInt(100)}, args)
+	})
+
+	t.Run("send failure", func(t *testing.T) {
+		backend := &mockBackend{sendErr: errors.New("connection refused")}
+		burner, err := NewChainBurner(backend, contractAddress, privateKey, big.NewInt(80002))
+		require.NoError(t, err)
+
+		_, err = burner.BurnCredits(ctx, "license-burn", testAssetDID, 100)
+		require.Error(t, err)
+	})
+}
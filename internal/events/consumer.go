@@ -0,0 +1,38 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog"
+)
+
+// Consume processes the contract events of the topic as a member of the consumer group until the context is done.
+// A rebalance ends the group session, so the group is joined again after every session, and the group is closed on return.
+// Messages left unmarked when the context is done are redelivered to the next member of the group.
+func (p *ContractProcessor) Consume(ctx context.Context, group sarama.ConsumerGroup, topic string) error {
+	defer func() {
+		if err := group.Close(); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("failed to close kafka consumer group")
+		}
+	}()
+	go func() {
+		for err := range group.Errors() {
+			zerolog.Ctx(ctx).Error().Err(err).Str("topic", topic).Msg("kafka consumer group error")
+		}
+	}()
+
+	for {
+		if err := group.Consume(ctx, []string{topic}, p); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return nil
+			}
+			return fmt.Errorf("failed to consume %s: %w", topic, err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo/memstore"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsumerGroup runs a session over each batch of messages sent to sessions, as the group does after every rebalance.
+type fakeConsumerGroup struct {
+	sarama.ConsumerGroup
+	sessions   chan []*sarama.ConsumerMessage
+	errors     chan error
+	consumeErr error
+	topics     []string
+	marked     []*sarama.ConsumerMessage
+	closed     bool
+}
+
+func newFakeConsumerGroup() *fakeConsumerGroup {
+	return &fakeConsumerGroup{sessions: make(chan []*sarama.ConsumerMessage, 10), errors: make(chan error)}
+}
+
+func (g *fakeConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	if g.consumeErr != nil {
+		return g.consumeErr
+	}
+	g.topics = topics
+	var msgs []*sarama.ConsumerMessage
+	select {
+	case <-ctx.Done():
+		return nil
+	case msgs = <-g.sessions:
+	}
+	session := &fakeSession{ctx: ctx}
+	if err := handler.Setup(session); err != nil {
+		return err
+	}
+	if err := handler.ConsumeClaim(session, newFakeClaim(msgs...)); err != nil {
+		return err
+	}
+	g.marked = append(g.marked, session.marked...)
+	return handler.Cleanup(session)
+}
+
+func (g *fakeConsumerGroup) Errors() <-chan error { return g.errors }
+
+func (g *fakeConsumerGroup) Close() error {
+	g.closed = true
+	close(g.errors)
+	return nil
+}
+
+func TestContractProcessorConsume(t *testing.T) {
+	burnMessage := func(t *testing.T, licenseID string) *sarama.ConsumerMessage {
+		args, err := json.Marshal(DCXBurnedData{LicenseID: licenseID, AssetDid: testAssetDID, Amount: 100})
+		require.NoError(t, err)
+		return dcxBurnedMessage(t, licenseID, args)
+	}
+
+	t.Run("consumed events confirm grants across rebalances", func(t *testing.T) {
+		store := memstore.New()
+		processor := NewContractProcessor(store, nil)
+		processor.dcxBurnedEventID = testDCXBurnedEventID
+		group := newFakeConsumerGroup()
+		first, second := burnMessage(t, "license-consume-1"), burnMessage(t, "license-consume-2")
+		group.sessions <- []*sarama.ConsumerMessage{first}
+		group.sessions <- []*sarama.ConsumerMessage{second}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error, 1)
+		go func() {
+			done <- processor.Consume(ctx, group, "topic.contract.event")
+		}()
+
+		// Verify: The events of both sessions confirmed their grants
+		for _, licenseID := range []string{"license-consume-1", "license-consume-2"} {
+			require.Eventually(t, func() bool {
+				grant, err := store.GetGrantByTxHash(ctx, "0xburn-"+licenseID)
+				return err == nil && grant.Status == creditrepo.GrantStatusConfirmed
+			}, 5*time.Second, time.Millisecond, licenseID)
+		}
+
+		// Verify: Cancelling the context stops consuming and closes the group
+		cancel()
+		require.NoError(t, <-done)
+		assert.True(t, group.closed)
+		assert.Equal(t, []string{"topic.contract.event"}, group.topics)
+		assert.Equal(t, []*sarama.ConsumerMessage{first, second}, group.marked)
+	})
+
+	t.Run("closed group stops consuming", func(t *testing.T) {
+		group := newFakeConsumerGroup()
+		group.consumeErr = sarama.ErrClosedConsumerGroup
+		processor := NewContractProcessor(memstore.New(), nil)
+		require.NoError(t, processor.Consume(context.Background(), group, "topic.contract.event"))
+		assert.True(t, group.closed)
+	})
+
+	t.Run("consume failure is returned", func(t *testing.T) {
+		group := newFakeConsumerGroup()
+		group.consumeErr = errors.New("brokers unreachable")
+		processor := NewContractProcessor(memstore.New(), nil)
+		err := processor.Consume(context.Background(), group, "topic.contract.event")
+		require.ErrorIs(t, err, group.consumeErr)
+		assert.True(t, group.closed)
+	})
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultMaxAttempts  = 5
+	defaultRetryBackoff = 200 * time.Millisecond
+	maxRetryBackoff     = 10 * time.Second
+
+	// DeadLetterErrorHeader is the header of a dead-lettered message holding the reason it failed.
+	DeadLetterErrorHeader = "dlq-error"
+	// DeadLetterTopicHeader is the header of a dead-lettered message holding the topic it was consumed from.
+	DeadLetterTopicHeader = "dlq-source-topic"
+	// DeadLetterPartitionHeader is the header of a dead-lettered message holding the partition it was consumed from.
+	DeadLetterPartitionHeader = "dlq-source-partition"
+	// DeadLetterOffsetHeader is the header of a dead-lettered message holding its offset in the source partition.
+	DeadLetterOffsetHeader = "dlq-source-offset"
+)
+
+// ProcessorOption configures a ContractProcessor.
+type ProcessorOption func(*ContractProcessor)
+
+// WithDeadLetterQueue publishes messages that fail permanently, or still fail after every attempt, to the topic.
+// Without a dead-letter queue such messages are logged and skipped.
+func WithDeadLetterQueue(producer sarama.SyncProducer, topic string) ProcessorOption {
+	return func(p *ContractProcessor) {
+		p.deadLetterProducer = producer
+		p.deadLetterTopic = topic
+	}
+}
+
+// WithDCXBurnedEventID sets the event signature hash that identifies DCX burned events, matched case insensitively.
+// An empty ID keeps DefaultDCXBurnedEventID.
+func WithDCXBurnedEventID(eventID string) ProcessorOption {
+	return func(p *ContractProcessor) {
+		if eventID != "" {
+			p.dcxBurnedEventID = eventID
+		}
+	}
+}
+
+// WithRetry sets the number of attempts made to process a message and the backoff before the first retry,
+// which doubles for every retry after it up to 10 seconds.
+func WithRetry(maxAttempts int, backoff time.Duration) ProcessorOption {
+	return func(p *ContractProcessor) {
+		p.maxAttempts = maxAttempts
+		p.retryBackoff = backoff
+	}
+}
+
+// permanentError is a processing failure that retrying cannot fix, such as a malformed payload.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// isPermanent reports whether retrying the processing that failed with err cannot succeed.
+func isPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent) ||
+		errors.Is(err, creditrepo.ConfirmConflictErr) ||
+		errors.Is(err, creditrepo.PendingGrantMismatchErr) ||
+		errors.Is(err, creditrepo.GrantAmountMismatchErr)
+}
+
+// retry runs handle until it succeeds, fails permanently, or runs out of attempts, backing off between attempts.
+// It returns the context error if the context is done while backing off.
+func (p ContractProcessor) retry(ctx context.Context, handle func() error) error {
+	backoff := p.retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := handle()
+		if err == nil || isPermanent(err) || attempt >= p.maxAttempts {
+			return err
+		}
+		zerolog.Ctx(ctx).Warn().Err(err).Int("attempt", attempt).Dur("backoff", backoff).Msg("failed to process message, retrying")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// deadLetter publishes the original message with the reason it failed to the dead-letter topic.
+func (p ContractProcessor) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, reason error) error {
+	logger := zerolog.Ctx(ctx).With().Str("topic", msg.Topic).Int32("partition", msg.Partition).Int64("offset", msg.Offset).Logger()
+	if p.deadLetterProducer == nil {
+		logger.Error().Err(reason).Bytes("payload", msg.Value).Msg("failed to process message, skipping it")
+		return nil
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+4)
+	for _, header := range msg.Headers {
+		headers = append(headers, *header)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(DeadLetterErrorHeader), Value: []byte(reason.Error())},
+		sarama.RecordHeader{Key: []byte(DeadLetterTopicHeader), Value: []byte(msg.Topic)},
+		sarama.RecordHeader{Key: []byte(DeadLetterPartitionHeader), Value: []byte(strconv.Itoa(int(msg.Partition)))},
+		sarama.RecordHeader{Key: []byte(DeadLetterOffsetHeader), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+	_, _, err := p.deadLetterProducer.SendMessage(&sarama.ProducerMessage{
+		Topic:   p.deadLetterTopic,
+		Key:     sarama.ByteEncoder(msg.Key),
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message to dead-letter topic: %w", err)
+	}
+	logger.Warn().Err(reason).Msg("failed to process message, sent it to the dead-letter topic")
+	return nil
+}
@@ -0,0 +1,146 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/cloudevent"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo/memstore"
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDCXBurnedEventID = "0xdcxburned"
+
+// flakyGrantRepo fails the first failures confirmations before passing them to the store.
+type flakyGrantRepo struct {
+	*memstore.Store
+	failures int
+}
+
+func (f *flakyGrantRepo) ConfirmGrant(ctx context.Context, licenseID string, assetDID string, txHash string, logIndex int, amount uint64, mintTime time.Time, opts ...creditrepo.GrantOption) (*models.CreditOperation, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("connection refused")
+	}
+	return f.Store.ConfirmGrant(ctx, licenseID, assetDID, txHash, logIndex, amount, mintTime, opts...)
+}
+
+// fakeSession records the messages marked during a consumer group session.
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []*sarama.ConsumerMessage
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg)
+}
+
+// fakeClaim delivers a fixed set of messages and then closes.
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func newFakeClaim(msgs ...*sarama.ConsumerMessage) *fakeClaim {
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(msgs))}
+	for _, msg := range msgs {
+		claim.messages <- msg
+	}
+	close(claim.messages)
+	return claim
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+func dcxBurnedMessage(t *testing.T, licenseID string, arguments json.RawMessage) *sarama.ConsumerMessage {
+	t.Helper()
+	value, err := json.Marshal(cloudevent.CloudEvent[contractEventData]{
+		CloudEventHeader: cloudevent.CloudEventHeader{Type: contractEventType},
+		Data: contractEventData{
+			EventSignature: testDCXBurnedEventID,
+			Arguments:      arguments,
+			TxHash:         "0xburn-" + licenseID,
+			LogIndex:       1,
+		},
+	})
+	require.NoError(t, err)
+	return &sarama.ConsumerMessage{Topic: "topic.contract.event", Partition: 2, Offset: 42, Key: []byte(licenseID), Value: value}
+}
+
+func TestConsumeClaimRetry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("transient failure recovers", func(t *testing.T) {
+		repo := &flakyGrantRepo{Store: memstore.New(), failures: 2}
+		producer := mocks.NewSyncProducer(t, nil)
+		processor := NewContractProcessor(repo, nil, WithRetry(3, time.Millisecond), WithDeadLetterQueue(producer, "topic.contract.event.dlq"))
+		processor.dcxBurnedEventID = testDCXBurnedEventID
+		licenseID := "license-transient"
+		args, err := json.Marshal(DCXBurnedData{LicenseID: licenseID, AssetDid: testAssetDID, Amount: 100})
+		require.NoError(t, err)
+		msg := dcxBurnedMessage(t, licenseID, args)
+
+		session := &fakeSession{ctx: ctx}
+		require.NoError(t, processor.ConsumeClaim(session, newFakeClaim(msg)))
+
+		assert.Equal(t, []*sarama.ConsumerMessage{msg}, session.marked)
+		balance, err := repo.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance.Balance)
+		require.NoError(t, producer.Close())
+	})
+
+	t.Run("poison message goes to the dead-letter topic", func(t *testing.T) {
+		producer := mocks.NewSyncProducer(t, nil)
+		processor := NewContractProcessor(memstore.New(), nil, WithRetry(3, time.Millisecond), WithDeadLetterQueue(producer, "topic.contract.event.dlq"))
+		processor.dcxBurnedEventID = testDCXBurnedEventID
+		msg := dcxBurnedMessage(t, "license-poison", json.RawMessage(`{"amount":"not a number"}`))
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(dlqMsg *sarama.ProducerMessage) error {
+			value, err := dlqMsg.Value.Encode()
+			if err != nil {
+				return err
+			}
+			if dlqMsg.Topic != "topic.contract.event.dlq" || string(value) != string(msg.Value) {
+				return errors.New("dead-lettered message does not match the original")
+			}
+			headers := map[string]string{}
+			for _, header := range dlqMsg.Headers {
+				headers[string(header.Key)] = string(header.Value)
+			}
+			if headers[DeadLetterErrorHeader] == "" || headers[DeadLetterTopicHeader] != msg.Topic ||
+				headers[DeadLetterPartitionHeader] != "2" || headers[DeadLetterOffsetHeader] != "42" {
+				return errors.New("unexpected dead-letter headers")
+			}
+			return nil
+		})
+
+		session := &fakeSession{ctx: ctx}
+		require.NoError(t, processor.ConsumeClaim(session, newFakeClaim(msg)))
+
+		assert.Equal(t, []*sarama.ConsumerMessage{msg}, session.marked)
+		require.NoError(t, producer.Close())
+	})
+
+	t.Run("failed dead-letter publish leaves the message unmarked", func(t *testing.T) {
+		producer := mocks.NewSyncProducer(t, nil)
+		processor := NewContractProcessor(memstore.New(), nil, WithRetry(3, time.Millisecond), WithDeadLetterQueue(producer, "topic.contract.event.dlq"))
+		producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+		session := &fakeSession{ctx: ctx}
+		err := processor.ConsumeClaim(session, newFakeClaim(&sarama.ConsumerMessage{Value: []byte("not json")}))
+		require.ErrorIs(t, err, sarama.ErrOutOfBrokers)
+		assert.Empty(t, session.marked)
+		require.NoError(t, producer.Close())
+	})
+}
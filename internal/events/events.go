@@ -3,108 +3,158 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/big"
-	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/DIMO-Network/cloudevent"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
 	"github.com/DIMO-Network/credit-tracker/models"
 	"github.com/IBM/sarama"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/rs/zerolog"
 )
 
 type GrantRepository interface {
-	CreateGrant(ctx context.Context, licenseID string, assetDID string, amount uint64, mintTime time.Time) (*models.CreditGrant, error)
+	CreateGrant(ctx context.Context, licenseID string, assetDID string, amount uint64, mintTime time.Time, opts ...creditrepo.GrantOption) (*models.CreditGrant, error)
 	UpdateGrantTxHash(ctx context.Context, grant *models.CreditGrant, txHash string) (*models.CreditGrant, error)
-	ConfirmGrant(ctx context.Context, licenseID string, assetDID string, txHash string, logIndex int, amount uint64, mintTime time.Time) (*models.CreditOperation, error)
+	FailGrant(ctx context.Context, grant *models.CreditGrant) (*models.CreditGrant, error)
+	ConfirmGrant(ctx context.Context, licenseID string, assetDID string, txHash string, logIndex int, amount uint64, mintTime time.Time, opts ...creditrepo.GrantOption) (*models.CreditOperation, error)
+}
+
+// BurnNotConfiguredErr is returned by CreateGrant when the processor has no burner to send the burn transaction.
+var BurnNotConfiguredErr = errors.New("credit burn is not configured")
+
+// CreditBurner sends the on-chain burn that backs a grant.
+type CreditBurner interface {
+	BurnCredits(ctx context.Context, licenseID, assetDID string, amount uint64) (*types.Transaction, error)
 }
 
 type ContractProcessor struct {
-	grantRepo        GrantRepository
-	dcxBurnedEventID string
+	grantRepo          GrantRepository
+	burner             CreditBurner
+	dcxBurnedEventID   string
+	maxAttempts        int
+	retryBackoff       time.Duration
+	deadLetterProducer sarama.SyncProducer
+	deadLetterTopic    string
 }
 
-func NewContractProcessor(grantRepo GrantRepository) *ContractProcessor {
-	return &ContractProcessor{grantRepo: grantRepo}
+// NewContractProcessor creates a processor that burns credits with the burner and confirms grants from contract events.
+// A nil burner fails every CreateGrant with BurnNotConfiguredErr.
+func NewContractProcessor(grantRepo GrantRepository, burner CreditBurner, opts ...ProcessorOption) *ContractProcessor {
+	p := &ContractProcessor{
+		grantRepo:        grantRepo,
+		burner:           burner,
+		dcxBurnedEventID: DefaultDCXBurnedEventID,
+		maxAttempts:      defaultMaxAttempts,
+		retryBackoff:     defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
+// CreateGrant creates a pending grant and sends its burn transaction.
+// The grant is confirmed once the DCX burned event of the transaction is consumed, a grant whose transaction could not be sent is failed.
 func (c *ContractProcessor) CreateGrant(ctx context.Context, licenseID string, assetDID string, amount uint64) (*types.Transaction, error) {
+	if c.burner == nil {
+		return nil, BurnNotConfiguredErr
+	}
 	grant, err := c.grantRepo.CreateGrant(ctx, licenseID, assetDID, amount, time.Now())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create grant: %w", err)
 	}
-	tx := tmpFakeTx()
-	_, err = c.grantRepo.UpdateGrantTxHash(ctx, grant, tx.Hash().String())
+	tx, err := c.burner.BurnCredits(ctx, licenseID, assetDID, amount)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update grant tx hash: %w", err)
+		// the pending grant is spendable, so it must not outlive a burn that never happened
+		if _, failErr := c.grantRepo.FailGrant(context.WithoutCancel(ctx), grant); failErr != nil {
+			zerolog.Ctx(ctx).Error().Err(failErr).Str("grantId", grant.ID).Msg("failed to fail grant after burn failure")
+		}
+		return nil, fmt.Errorf("failed to burn credits: %w", err)
 	}
-
-	// TODO: remove this once we have a real event handler is implemented
-	err = c.handleDCXBurned(ctx, contractEventData{
-		TxHash:    tx.Hash().String(),
-		LogIndex:  1,
-		Arguments: json.RawMessage(fmt.Sprintf(`{"licenseId": "%s", "assetDid": "%s", "amount": %d}`, licenseID, assetDID, amount)),
-	})
+	_, err = c.grantRepo.UpdateGrantTxHash(ctx, grant, tx.Hash().String())
 	if err != nil {
-		return nil, fmt.Errorf("failed to handle dcx burned: %w", err)
+		return nil, fmt.Errorf("failed to update grant tx hash: %w", err)
 	}
 	return tx, nil
 }
 
 const (
 	contractEventType = "zone.dimo.contract.event"
+
+	// DCXBurnedEventSignature is the signature of the event the DCX burn contract emits for every burn.
+	DCXBurnedEventSignature = "DCXBurned(string,string,uint256)"
 )
 
+// DefaultDCXBurnedEventID is the keccak256 hash of DCXBurnedEventSignature, the topic identifying DCX burned events in contract events.
+var DefaultDCXBurnedEventID = crypto.Keccak256Hash([]byte(DCXBurnedEventSignature)).Hex()
+
 type contractEventData struct {
 	EventSignature string          `json:"eventSignature"`
 	Arguments      json.RawMessage `json:"arguments"`
 	TxHash         string          `json:"txHash"`
 	LogIndex       int             `json:"logIndex"`
+	BlockNumber    *int64          `json:"blockNumber"`
 }
 
 func (p ContractProcessor) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
 func (p ContractProcessor) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim processes contract events, retrying transient failures with backoff.
+// A message that fails permanently or runs out of attempts is sent to the dead-letter topic, and every message is marked once it is handled.
 func (p ContractProcessor) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
 	for {
 		select {
-		case <-session.Context().Done():
+		case <-ctx.Done():
 			return nil
 		case msg, ok := <-claim.Messages():
 			if !ok {
-				zerolog.Ctx(session.Context()).Info().Msg("message channel closed")
+				zerolog.Ctx(ctx).Info().Msg("message channel closed")
 				return nil
 			}
 
-			var event cloudevent.CloudEvent[contractEventData]
-			if err := json.Unmarshal(msg.Value, &event); err != nil {
-				zerolog.Ctx(session.Context()).Err(err).Msg("failed to parse contract event")
-				continue
-			}
-
-			if event.Type != contractEventType {
-				session.MarkMessage(msg, "")
-				continue
-			}
-
-			switch event.Data.EventSignature {
-			case p.dcxBurnedEventID:
-				if err := p.handleDCXBurned(session.Context(), event.Data); err != nil {
-					zerolog.Ctx(session.Context()).Err(err).Msg("failed to process dcx burned")
-					continue
+			if err := p.processMessage(ctx, msg); err != nil {
+				if ctx.Err() != nil {
+					// the session ended while retrying, the unmarked message is redelivered to the next session
+					return nil
+				}
+				if err := p.deadLetter(ctx, msg, err); err != nil {
+					// leave the message unmarked so it is redelivered instead of lost
+					return err
 				}
-			default:
-				// do nothing
 			}
 
 			session.MarkMessage(msg, "")
-
 		}
 	}
 }
 
+// processMessage handles a single contract event message.
+func (p ContractProcessor) processMessage(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var event cloudevent.CloudEvent[contractEventData]
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		return permanentError{fmt.Errorf("failed to parse contract event: %w", err)}
+	}
+
+	if event.Type != contractEventType {
+		return nil
+	}
+
+	if !strings.EqualFold(event.Data.EventSignature, p.dcxBurnedEventID) {
+		// other events of the watched contracts are not for this service
+		zerolog.Ctx(ctx).Debug().Str("eventSignature", event.Data.EventSignature).Msg("skipping unrelated contract event")
+		return nil
+	}
+	return p.retry(ctx, func() error {
+		return p.handleDCXBurned(ctx, event.Data)
+	})
+}
+
 type DCXBurnedData struct {
 	LicenseID string `json:"licenseId"`
 	AssetDid  string `json:"assetDid"`
@@ -114,25 +164,23 @@ type DCXBurnedData struct {
 func (p ContractProcessor) handleDCXBurned(ctx context.Context, data contractEventData) error {
 	var burn DCXBurnedData
 	if err := json.Unmarshal(data.Arguments, &burn); err != nil {
-		return fmt.Errorf("failed to parse dcx burned event: %w", err)
+		return permanentError{fmt.Errorf("failed to parse dcx burned event: %w", err)}
 	}
 
-	_, err := p.grantRepo.ConfirmGrant(ctx, burn.LicenseID, burn.AssetDid, data.TxHash, data.LogIndex, burn.Amount, time.Now())
+	_, err := p.grantRepo.ConfirmGrant(ctx, burn.LicenseID, burn.AssetDid, data.TxHash, data.LogIndex, burn.Amount, time.Now(), creditrepo.WithGrantBlockNumber(data.BlockNumber))
+	if errors.Is(err, creditrepo.GrantAlreadyConfirmedErr) {
+		// replayed messages are expected from the consumer group, the grant was confirmed by an earlier delivery
+		zerolog.Ctx(ctx).Info().Str("txHash", data.TxHash).Int("logIndex", data.LogIndex).Msg("dcx burned event already confirmed")
+		return nil
+	}
+	if errors.Is(err, creditrepo.GrantAmountMismatchErr) {
+		// the burn and our pending grant disagree, which needs a person to look at it before any credits are granted
+		zerolog.Ctx(ctx).Error().Err(err).Str("txHash", data.TxHash).Int("logIndex", data.LogIndex).Uint64("amount", burn.Amount).
+			Msg("dcx burned event amount does not match the pending grant")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create grant: %w", err)
 	}
 
 	return nil
 }
-
-func tmpFakeTx() *types.Transaction {
-	randNonce := rand.Uint64()
-	return types.NewTx(&types.LegacyTx{
-		Nonce:    randNonce,
-		To:       &common.Address{},
-		Value:    big.NewInt(0),
-		Gas:      0,
-		GasPrice: big.NewInt(0),
-		Data:     []byte{},
-	})
-}
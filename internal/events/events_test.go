@@ -0,0 +1,263 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/cloudevent"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo"
+	"github.com/DIMO-Network/credit-tracker/internal/creditrepo/memstore"
+	"github.com/DIMO-Network/credit-tracker/models"
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+const testAssetDID = "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:123"
+
+func TestHandleDCXBurned(t *testing.T) {
+	ctx := context.Background()
+	burnEvent := func(licenseID string, amount uint64) contractEventData {
+		args, err := json.Marshal(DCXBurnedData{LicenseID: licenseID, AssetDid: testAssetDID, Amount: amount})
+		require.NoError(t, err)
+		return contractEventData{TxHash: "0xburn", LogIndex: 3, Arguments: args}
+	}
+
+	t.Run("redelivered event is a no-op", func(t *testing.T) {
+		store := memstore.New()
+		processor := NewContractProcessor(store, nil)
+		licenseID := "license-redelivered"
+
+		require.NoError(t, processor.handleDCXBurned(ctx, burnEvent(licenseID, 100)))
+		require.NoError(t, processor.handleDCXBurned(ctx, burnEvent(licenseID, 100)))
+
+		balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance.Balance)
+	})
+
+	t.Run("block number of the event is stored with the grant", func(t *testing.T) {
+		store := memstore.New()
+		processor := NewContractProcessor(store, nil)
+		licenseID := "license-block-number"
+
+		event := burnEvent(licenseID, 100)
+		blockNumber := int64(1234)
+		event.BlockNumber = &blockNumber
+		require.NoError(t, processor.handleDCXBurned(ctx, event))
+
+		grant, err := store.GetGrantByTxHash(ctx, "0xburn")
+		require.NoError(t, err)
+		assert.Equal(t, null.Int64From(blockNumber), grant.BlockNumber)
+
+		// Verify: Events without a block number are still confirmed
+		event = burnEvent("license-no-block-number", 100)
+		event.TxHash = "0xburn-no-block"
+		require.NoError(t, processor.handleDCXBurned(ctx, event))
+		grant, err = store.GetGrantByTxHash(ctx, "0xburn-no-block")
+		require.NoError(t, err)
+		assert.False(t, grant.BlockNumber.Valid)
+	})
+
+	t.Run("redelivered event with different details fails", func(t *testing.T) {
+		store := memstore.New()
+		processor := NewContractProcessor(store, nil)
+		licenseID := "license-conflict"
+
+		require.NoError(t, processor.handleDCXBurned(ctx, burnEvent(licenseID, 100)))
+		require.Error(t, processor.handleDCXBurned(ctx, burnEvent(licenseID, 50)))
+
+		balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance.Balance)
+	})
+
+	t.Run("burn amount different from the pending grant is permanent", func(t *testing.T) {
+		store := memstore.New()
+		processor := NewContractProcessor(store, nil)
+		licenseID := "license-amount-mismatch"
+		grant, err := store.CreateGrant(ctx, licenseID, testAssetDID, 100, time.Now())
+		require.NoError(t, err)
+		_, err = store.UpdateGrantTxHash(ctx, grant, "0xburn")
+		require.NoError(t, err)
+
+		err = processor.handleDCXBurned(ctx, burnEvent(licenseID, 50))
+		require.ErrorIs(t, err, creditrepo.GrantAmountMismatchErr)
+		assert.True(t, isPermanent(err))
+
+		// Verify: The pending grant was not confirmed
+		grant, err = store.GetGrantByTxHash(ctx, "0xburn")
+		require.NoError(t, err)
+		assert.Equal(t, creditrepo.GrantStatusPending, grant.Status)
+	})
+}
+
+func TestProcessMessageEventSignature(t *testing.T) {
+	ctx := context.Background()
+	burnMessage := func(t *testing.T, licenseID, eventSignature string) *sarama.ConsumerMessage {
+		args, err := json.Marshal(DCXBurnedData{LicenseID: licenseID, AssetDid: testAssetDID, Amount: 100})
+		require.NoError(t, err)
+		value, err := json.Marshal(cloudevent.CloudEvent[contractEventData]{
+			CloudEventHeader: cloudevent.CloudEventHeader{Type: contractEventType},
+			Data:             contractEventData{EventSignature: eventSignature, Arguments: args, TxHash: "0xburn-" + licenseID, LogIndex: 1},
+		})
+		require.NoError(t, err)
+		return &sarama.ConsumerMessage{Topic: "topic.contract.event", Value: value}
+	}
+	confirmed := func(t *testing.T, store *memstore.Store, licenseID string) bool {
+		balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		return balance.Balance == 100
+	}
+
+	t.Run("configured event ID", func(t *testing.T) {
+		store := memstore.New()
+		processor := NewContractProcessor(store, nil, WithDCXBurnedEventID(testDCXBurnedEventID))
+
+		require.NoError(t, processor.processMessage(ctx, burnMessage(t, "license-signed", testDCXBurnedEventID)))
+		require.NoError(t, processor.processMessage(ctx, burnMessage(t, "license-signed-upper", "0xDCXBURNED")))
+		require.NoError(t, processor.processMessage(ctx, burnMessage(t, "license-unrelated", "0xtransfer")))
+		require.NoError(t, processor.processMessage(ctx, burnMessage(t, "license-unsigned", "")))
+
+		// Verify: Only the DCX burned events confirmed a grant
+		assert.True(t, confirmed(t, store, "license-signed"))
+		assert.True(t, confirmed(t, store, "license-signed-upper"))
+		assert.False(t, confirmed(t, store, "license-unrelated"))
+		assert.False(t, confirmed(t, store, "license-unsigned"))
+	})
+
+	t.Run("default event ID", func(t *testing.T) {
+		store := memstore.New()
+		processor := NewContractProcessor(store, nil, WithDCXBurnedEventID(""))
+
+		assert.Equal(t, crypto.Keccak256Hash([]byte("DCXBurned(string,string,uint256)")).Hex(), DefaultDCXBurnedEventID)
+		require.NoError(t, processor.processMessage(ctx, burnMessage(t, "license-default", DefaultDCXBurnedEventID)))
+		require.NoError(t, processor.processMessage(ctx, burnMessage(t, "license-default-unrelated", testDCXBurnedEventID)))
+
+		assert.True(t, confirmed(t, store, "license-default"))
+		assert.False(t, confirmed(t, store, "license-default-unrelated"))
+	})
+}
+
+// stubBurner returns tx, or err when set, for every burn.
+type stubBurner struct {
+	tx  *types.Transaction
+	err error
+}
+
+func (s stubBurner) BurnCredits(context.Context, string, string, uint64) (*types.Transaction, error) {
+	return s.tx, s.err
+}
+
+func TestContractProcessorCreateGrant(t *testing.T) {
+	ctx := context.Background()
+	listGrants := func(t *testing.T, store *memstore.Store, licenseID string) []*models.CreditGrant {
+		t.Helper()
+		grants, err := store.ListGrants(ctx, licenseID, testAssetDID, creditrepo.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		return grants
+	}
+
+	t.Run("pending grant has the burn tx hash", func(t *testing.T) {
+		store := memstore.New()
+		burnTx := types.NewTx(&types.LegacyTx{Nonce: 1})
+		processor := NewContractProcessor(store, stubBurner{tx: burnTx})
+		licenseID := "license-burn-sent"
+
+		tx, err := processor.CreateGrant(ctx, licenseID, testAssetDID, 100)
+		require.NoError(t, err)
+		assert.Equal(t, burnTx.Hash(), tx.Hash())
+
+		grant := listGrants(t, store, licenseID)[0]
+		assert.Equal(t, creditrepo.GrantStatusPending, grant.Status)
+		assert.Equal(t, burnTx.Hash().String(), grant.TXHash)
+	})
+
+	t.Run("failed burn fails the grant", func(t *testing.T) {
+		store := memstore.New()
+		processor := NewContractProcessor(store, stubBurner{err: errors.New("connection refused")})
+		licenseID := "license-burn-failed"
+
+		_, err := processor.CreateGrant(ctx, licenseID, testAssetDID, 100)
+		require.Error(t, err)
+
+		grant := listGrants(t, store, licenseID)[0]
+		assert.Equal(t, creditrepo.GrantStatusFailed, grant.Status)
+		balance, err := store.GetBalance(ctx, licenseID, testAssetDID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), balance.Balance)
+	})
+
+	t.Run("no burner", func(t *testing.T) {
+		processor := NewContractProcessor(memstore.New(), nil)
+		_, err := processor.CreateGrant(ctx, "license-no-burner", testAssetDID, 100)
+		require.ErrorIs(t, err, BurnNotConfiguredErr)
+	})
+}
+
+func TestBalancePublisher(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithCheckerFunctionAndSucceed(func(value []byte) error {
+		var event cloudevent.CloudEvent[LowBalanceData]
+		if err := json.Unmarshal(value, &event); err != nil {
+			return err
+		}
+		if event.Type != LowBalanceEventType {
+			return fmt.Errorf("unexpected event type %s", event.Type)
+		}
+		expected := LowBalanceData{LicenseID: "license-low-balance", AssetDid: testAssetDID, Balance: 40, Threshold: 50}
+		if event.Data != expected {
+			return fmt.Errorf("unexpected event data %+v", event.Data)
+		}
+		return nil
+	})
+	publisher := NewBalancePublisher(producer, "topic.credit.balance")
+
+	require.NoError(t, publisher.PublishLowBalance(context.Background(), "license-low-balance", testAssetDID, 40, 50))
+	require.NoError(t, producer.Close())
+}
+
+func TestBalancePublisherGrantConfirmed(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		if msg.Topic != "topic.credit.grant" {
+			return fmt.Errorf("unexpected topic %s", msg.Topic)
+		}
+		key, err := msg.Key.Encode()
+		if err != nil {
+			return err
+		}
+		if string(key) != "license-grant" {
+			return fmt.Errorf("unexpected key %s", key)
+		}
+		value, err := msg.Value.Encode()
+		if err != nil {
+			return err
+		}
+		var event cloudevent.CloudEvent[GrantConfirmedData]
+		if err := json.Unmarshal(value, &event); err != nil {
+			return err
+		}
+		if event.Type != GrantConfirmedEventType || event.Subject != testAssetDID {
+			return fmt.Errorf("unexpected event type %s or subject %s", event.Type, event.Subject)
+		}
+		expected := GrantConfirmedData{LicenseID: "license-grant", AssetDid: testAssetDID, GrantID: "grant-1", Amount: 100, Balance: 130}
+		if event.Data != expected {
+			return fmt.Errorf("unexpected event data %+v", event.Data)
+		}
+		return nil
+	})
+	publisher := NewBalancePublisher(producer, "topic.credit.grant")
+
+	require.NoError(t, publisher.PublishGrantConfirmed(context.Background(), "license-grant", testAssetDID, "grant-1", 100, 130))
+	require.NoError(t, producer.Close())
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// TracerName is the name of the tracer of the spans created by the service.
+const TracerName = "github.com/DIMO-Network/credit-tracker"
+
+// Span attributes of credit operations.
+const (
+	LicenseIDKey     = attribute.Key("credit.license_id")
+	AssetDIDKey      = attribute.Key("credit.asset_did")
+	AmountKey        = attribute.Key("credit.amount")
+	OperationTypeKey = attribute.Key("credit.operation_type")
+)
+
+// OperationAttributes returns the span attributes of a credit operation, leaving out the fields that are not set.
+func OperationAttributes(licenseID, assetDID string, amount int64, operationType string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if licenseID != "" {
+		attrs = append(attrs, LicenseIDKey.String(licenseID))
+	}
+	if assetDID != "" {
+		attrs = append(attrs, AssetDIDKey.String(assetDID))
+	}
+	if amount != 0 {
+		attrs = append(attrs, AmountKey.Int64(amount))
+	}
+	if operationType != "" {
+		attrs = append(attrs, OperationTypeKey.String(operationType))
+	}
+	return attrs
+}
+
+// NewTracerProvider creates a tracer provider that batches spans to an OTLP HTTP collector.
+// The exporter is configured with the standard OTEL_EXPORTER_OTLP_* environment variables, such as OTEL_EXPORTER_OTLP_ENDPOINT.
+func NewTracerProvider(ctx context.Context, serviceName string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
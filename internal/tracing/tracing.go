@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TraceIDMetadataKey is the gRPC metadata key used to pass a trace ID in and out of the service.
+	TraceIDMetadataKey = "x-trace-id"
+	// TraceParentMetadataKey is the W3C trace context metadata key, the trace ID is used when no x-trace-id is given.
+	TraceParentMetadataKey = "traceparent"
+	// LogField is the structured log field the trace ID is logged under.
+	LogField = "traceId"
+)
+
+type traceIDKey struct{}
+
+// WithTraceID returns a copy of the context carrying the trace ID.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID of the context, or an empty string if there is none.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// UnaryServerInterceptor captures the trace ID of the incoming request, generating one if the caller did not send any.
+// When the request has an OpenTelemetry span and the caller sent no trace ID, the trace ID of the span is used instead.
+// The trace ID is stored in the context, added to the context logger, and returned to the caller in the response header.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		traceID := traceIDFromMetadata(ctx)
+		if spanContext := trace.SpanContextFromContext(ctx); traceID == "" && spanContext.HasTraceID() {
+			traceID = spanContext.TraceID().String()
+		}
+		if traceID == "" {
+			traceID = NewTraceID()
+		}
+		ctx = WithTraceID(ctx, traceID)
+		ctx = zerolog.Ctx(ctx).With().Str(LogField, traceID).Logger().WithContext(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(TraceIDMetadataKey, traceID))
+		return handler(ctx, req)
+	}
+}
+
+// NewTraceID generates a random W3C compatible trace ID.
+func NewTraceID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// traceIDFromMetadata returns the trace ID sent by the caller, either directly or as part of a W3C traceparent.
+func traceIDFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(TraceIDMetadataKey); len(values) > 0 && isValidTraceID(values[0]) {
+		return values[0]
+	}
+	if values := md.Get(TraceParentMetadataKey); len(values) > 0 {
+		// version-traceid-parentid-flags
+		parts := strings.Split(values[0], "-")
+		if len(parts) == 4 && len(parts[1]) == 32 && isValidTraceID(parts[1]) {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
+// isValidTraceID limits caller provided trace IDs to short printable identifiers so they can be stored and logged safely.
+func isValidTraceID(traceID string) bool {
+	if traceID == "" || len(traceID) > 64 {
+		return false
+	}
+	for _, r := range traceID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	captureTraceID := func(t *testing.T, md metadata.MD) string {
+		t.Helper()
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		var traceID string
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			traceID = TraceIDFromContext(ctx)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		return traceID
+	}
+
+	t.Run("uses the trace ID from metadata", func(t *testing.T) {
+		assert.Equal(t, "abc-123", captureTraceID(t, metadata.Pairs(TraceIDMetadataKey, "abc-123")))
+	})
+
+	t.Run("uses the trace ID from traceparent", func(t *testing.T) {
+		md := metadata.Pairs(TraceParentMetadataKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", captureTraceID(t, md))
+	})
+
+	t.Run("generates a trace ID when none is given", func(t *testing.T) {
+		traceID := captureTraceID(t, metadata.MD{})
+		assert.Len(t, traceID, 32)
+		assert.NotEqual(t, traceID, captureTraceID(t, metadata.MD{}))
+	})
+
+	t.Run("uses the trace ID of the request span", func(t *testing.T) {
+		spanTraceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		require.NoError(t, err)
+		spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+		require.NoError(t, err)
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: spanTraceID, SpanID: spanID}))
+		var traceID string
+		_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+			traceID = TraceIDFromContext(ctx)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, spanTraceID.String(), traceID)
+	})
+
+	t.Run("ignores invalid trace IDs", func(t *testing.T) {
+		traceID := captureTraceID(t, metadata.Pairs(TraceIDMetadataKey, "bad trace\nid"))
+		assert.Len(t, traceID, 32)
+	})
+}
@@ -4,11 +4,13 @@
 package models
 
 var TableNames = struct {
-	CreditGrants          string
-	CreditOperationGrants string
-	CreditOperations      string
+	CreditBalanceSummaries string
+	CreditGrants           string
+	CreditOperationGrants  string
+	CreditOperations       string
 }{
-	CreditGrants:          "credit_grants",
-	CreditOperationGrants: "credit_operation_grants",
-	CreditOperations:      "credit_operations",
+	CreditBalanceSummaries: "credit_balance_summaries",
+	CreditGrants:           "credit_grants",
+	CreditOperationGrants:  "credit_operation_grants",
+	CreditOperations:       "credit_operations",
 }
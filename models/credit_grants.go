@@ -37,16 +37,22 @@ type CreditGrant struct {
 	InitialAmount int64 `boil:"initial_amount" json:"initial_amount" toml:"initial_amount" yaml:"initial_amount"`
 	// Current unused credits (changes based on usage)
 	RemainingAmount int64 `boil:"remaining_amount" json:"remaining_amount" toml:"remaining_amount" yaml:"remaining_amount"`
-	// When these credits become unusable
-	ExpiresAt time.Time `boil:"expires_at" json:"expires_at" toml:"expires_at" yaml:"expires_at"`
+	// When these credits become unusable (null for perpetual grants that never expire)
+	ExpiresAt null.Time `boil:"expires_at" json:"expires_at,omitempty" toml:"expires_at" yaml:"expires_at,omitempty"`
 	// Blockchain block number (for verification and ordering)
 	BlockNumber null.Int64 `boil:"block_number" json:"block_number,omitempty" toml:"block_number" yaml:"block_number,omitempty"`
-	// Transaction state: pending, confirmed, or failed
+	// Transaction state: pending, confirmed, failed, or expired
 	Status string `boil:"status" json:"status" toml:"status" yaml:"status"`
 	// When this record was created in our system
 	CreatedAt null.Time `boil:"created_at" json:"created_at,omitempty" toml:"created_at" yaml:"created_at,omitempty"`
 	// Last modification (status changes, remaining_amount updates)
 	UpdatedAt null.Time `boil:"updated_at" json:"updated_at,omitempty" toml:"updated_at" yaml:"updated_at,omitempty"`
+	// When the remaining amount reached zero (null while credits remain)
+	DepletedAt null.Time `boil:"depleted_at" json:"depleted_at,omitempty" toml:"depleted_at" yaml:"depleted_at,omitempty"`
+	// Structured context of the grant such as the purchase source or promo code (null when none was given)
+	Metadata null.JSON `boil:"metadata" json:"metadata,omitempty" toml:"metadata" yaml:"metadata,omitempty"`
+	// Product the credits are for (e.g. telemetry, attestation); deductions only draw from grants of their type
+	CreditType string `boil:"credit_type" json:"credit_type" toml:"credit_type" yaml:"credit_type"`
 
 	R *creditGrantR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L creditGrantL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -65,6 +71,9 @@ var CreditGrantColumns = struct {
 	Status          string
 	CreatedAt       string
 	UpdatedAt       string
+	DepletedAt      string
+	Metadata        string
+	CreditType      string
 }{
 	ID:              "id",
 	TXHash:          "tx_hash",
@@ -78,6 +87,9 @@ var CreditGrantColumns = struct {
 	Status:          "status",
 	CreatedAt:       "created_at",
 	UpdatedAt:       "updated_at",
+	DepletedAt:      "depleted_at",
+	Metadata:        "metadata",
+	CreditType:      "credit_type",
 }
 
 var CreditGrantTableColumns = struct {
@@ -93,6 +105,9 @@ var CreditGrantTableColumns = struct {
 	Status          string
 	CreatedAt       string
 	UpdatedAt       string
+	DepletedAt      string
+	Metadata        string
+	CreditType      string
 }{
 	ID:              "credit_grants.id",
 	TXHash:          "credit_grants.tx_hash",
@@ -106,41 +121,13 @@ var CreditGrantTableColumns = struct {
 	Status:          "credit_grants.status",
 	CreatedAt:       "credit_grants.created_at",
 	UpdatedAt:       "credit_grants.updated_at",
+	DepletedAt:      "credit_grants.depleted_at",
+	Metadata:        "credit_grants.metadata",
+	CreditType:      "credit_grants.credit_type",
 }
 
 // Generated where
 
-type whereHelperstring struct{ field string }
-
-func (w whereHelperstring) EQ(x string) qm.QueryMod      { return qmhelper.Where(w.field, qmhelper.EQ, x) }
-func (w whereHelperstring) NEQ(x string) qm.QueryMod     { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
-func (w whereHelperstring) LT(x string) qm.QueryMod      { return qmhelper.Where(w.field, qmhelper.LT, x) }
-func (w whereHelperstring) LTE(x string) qm.QueryMod     { return qmhelper.Where(w.field, qmhelper.LTE, x) }
-func (w whereHelperstring) GT(x string) qm.QueryMod      { return qmhelper.Where(w.field, qmhelper.GT, x) }
-func (w whereHelperstring) GTE(x string) qm.QueryMod     { return qmhelper.Where(w.field, qmhelper.GTE, x) }
-func (w whereHelperstring) LIKE(x string) qm.QueryMod    { return qm.Where(w.field+" LIKE ?", x) }
-func (w whereHelperstring) NLIKE(x string) qm.QueryMod   { return qm.Where(w.field+" NOT LIKE ?", x) }
-func (w whereHelperstring) ILIKE(x string) qm.QueryMod   { return qm.Where(w.field+" ILIKE ?", x) }
-func (w whereHelperstring) NILIKE(x string) qm.QueryMod  { return qm.Where(w.field+" NOT ILIKE ?", x) }
-func (w whereHelperstring) SIMILAR(x string) qm.QueryMod { return qm.Where(w.field+" SIMILAR TO ?", x) }
-func (w whereHelperstring) NSIMILAR(x string) qm.QueryMod {
-	return qm.Where(w.field+" NOT SIMILAR TO ?", x)
-}
-func (w whereHelperstring) IN(slice []string) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
-}
-func (w whereHelperstring) NIN(slice []string) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
-}
-
 type whereHelpernull_Int struct{ field string }
 
 func (w whereHelpernull_Int) EQ(x null.Int) qm.QueryMod {
@@ -179,50 +166,30 @@ func (w whereHelpernull_Int) NIN(slice []int) qm.QueryMod {
 func (w whereHelpernull_Int) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
 func (w whereHelpernull_Int) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
 
-type whereHelperint64 struct{ field string }
-
-func (w whereHelperint64) EQ(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
-func (w whereHelperint64) NEQ(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
-func (w whereHelperint64) LT(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
-func (w whereHelperint64) LTE(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
-func (w whereHelperint64) GT(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
-func (w whereHelperint64) GTE(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
-func (w whereHelperint64) IN(slice []int64) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
-}
-func (w whereHelperint64) NIN(slice []int64) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
-}
-
-type whereHelpertime_Time struct{ field string }
+type whereHelpernull_Time struct{ field string }
 
-func (w whereHelpertime_Time) EQ(x time.Time) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.EQ, x)
+func (w whereHelpernull_Time) EQ(x null.Time) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
 }
-func (w whereHelpertime_Time) NEQ(x time.Time) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.NEQ, x)
+func (w whereHelpernull_Time) NEQ(x null.Time) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
 }
-func (w whereHelpertime_Time) LT(x time.Time) qm.QueryMod {
+func (w whereHelpernull_Time) LT(x null.Time) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.LT, x)
 }
-func (w whereHelpertime_Time) LTE(x time.Time) qm.QueryMod {
+func (w whereHelpernull_Time) LTE(x null.Time) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.LTE, x)
 }
-func (w whereHelpertime_Time) GT(x time.Time) qm.QueryMod {
+func (w whereHelpernull_Time) GT(x null.Time) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.GT, x)
 }
-func (w whereHelpertime_Time) GTE(x time.Time) qm.QueryMod {
+func (w whereHelpernull_Time) GTE(x null.Time) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.GTE, x)
 }
 
+func (w whereHelpernull_Time) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_Time) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+
 type whereHelpernull_Int64 struct{ field string }
 
 func (w whereHelpernull_Int64) EQ(x null.Int64) qm.QueryMod {
@@ -261,29 +228,29 @@ func (w whereHelpernull_Int64) NIN(slice []int64) qm.QueryMod {
 func (w whereHelpernull_Int64) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
 func (w whereHelpernull_Int64) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
 
-type whereHelpernull_Time struct{ field string }
+type whereHelpernull_JSON struct{ field string }
 
-func (w whereHelpernull_Time) EQ(x null.Time) qm.QueryMod {
+func (w whereHelpernull_JSON) EQ(x null.JSON) qm.QueryMod {
 	return qmhelper.WhereNullEQ(w.field, false, x)
 }
-func (w whereHelpernull_Time) NEQ(x null.Time) qm.QueryMod {
+func (w whereHelpernull_JSON) NEQ(x null.JSON) qm.QueryMod {
 	return qmhelper.WhereNullEQ(w.field, true, x)
 }
-func (w whereHelpernull_Time) LT(x null.Time) qm.QueryMod {
+func (w whereHelpernull_JSON) LT(x null.JSON) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.LT, x)
 }
-func (w whereHelpernull_Time) LTE(x null.Time) qm.QueryMod {
+func (w whereHelpernull_JSON) LTE(x null.JSON) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.LTE, x)
 }
-func (w whereHelpernull_Time) GT(x null.Time) qm.QueryMod {
+func (w whereHelpernull_JSON) GT(x null.JSON) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.GT, x)
 }
-func (w whereHelpernull_Time) GTE(x null.Time) qm.QueryMod {
+func (w whereHelpernull_JSON) GTE(x null.JSON) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.GTE, x)
 }
 
-func (w whereHelpernull_Time) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
-func (w whereHelpernull_Time) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+func (w whereHelpernull_JSON) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_JSON) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
 
 var CreditGrantWhere = struct {
 	ID              whereHelperstring
@@ -293,11 +260,14 @@ var CreditGrantWhere = struct {
 	AssetDid        whereHelperstring
 	InitialAmount   whereHelperint64
 	RemainingAmount whereHelperint64
-	ExpiresAt       whereHelpertime_Time
+	ExpiresAt       whereHelpernull_Time
 	BlockNumber     whereHelpernull_Int64
 	Status          whereHelperstring
 	CreatedAt       whereHelpernull_Time
 	UpdatedAt       whereHelpernull_Time
+	DepletedAt      whereHelpernull_Time
+	Metadata        whereHelpernull_JSON
+	CreditType      whereHelperstring
 }{
 	ID:              whereHelperstring{field: "\"credit_tracker\".\"credit_grants\".\"id\""},
 	TXHash:          whereHelperstring{field: "\"credit_tracker\".\"credit_grants\".\"tx_hash\""},
@@ -306,11 +276,14 @@ var CreditGrantWhere = struct {
 	AssetDid:        whereHelperstring{field: "\"credit_tracker\".\"credit_grants\".\"asset_did\""},
 	InitialAmount:   whereHelperint64{field: "\"credit_tracker\".\"credit_grants\".\"initial_amount\""},
 	RemainingAmount: whereHelperint64{field: "\"credit_tracker\".\"credit_grants\".\"remaining_amount\""},
-	ExpiresAt:       whereHelpertime_Time{field: "\"credit_tracker\".\"credit_grants\".\"expires_at\""},
+	ExpiresAt:       whereHelpernull_Time{field: "\"credit_tracker\".\"credit_grants\".\"expires_at\""},
 	BlockNumber:     whereHelpernull_Int64{field: "\"credit_tracker\".\"credit_grants\".\"block_number\""},
 	Status:          whereHelperstring{field: "\"credit_tracker\".\"credit_grants\".\"status\""},
 	CreatedAt:       whereHelpernull_Time{field: "\"credit_tracker\".\"credit_grants\".\"created_at\""},
 	UpdatedAt:       whereHelpernull_Time{field: "\"credit_tracker\".\"credit_grants\".\"updated_at\""},
+	DepletedAt:      whereHelpernull_Time{field: "\"credit_tracker\".\"credit_grants\".\"depleted_at\""},
+	Metadata:        whereHelpernull_JSON{field: "\"credit_tracker\".\"credit_grants\".\"metadata\""},
+	CreditType:      whereHelperstring{field: "\"credit_tracker\".\"credit_grants\".\"credit_type\""},
 }
 
 // CreditGrantRels is where relationship names are stored.
@@ -350,9 +323,9 @@ func (r *creditGrantR) GetGrantCreditOperationGrants() CreditOperationGrantSlice
 type creditGrantL struct{}
 
 var (
-	creditGrantAllColumns            = []string{"id", "tx_hash", "log_index", "license_id", "asset_did", "initial_amount", "remaining_amount", "expires_at", "block_number", "status", "created_at", "updated_at"}
-	creditGrantColumnsWithoutDefault = []string{"tx_hash", "license_id", "asset_did", "initial_amount", "remaining_amount", "expires_at"}
-	creditGrantColumnsWithDefault    = []string{"id", "log_index", "block_number", "status", "created_at", "updated_at"}
+	creditGrantAllColumns            = []string{"id", "tx_hash", "log_index", "license_id", "asset_did", "initial_amount", "remaining_amount", "expires_at", "block_number", "status", "created_at", "updated_at", "depleted_at", "metadata", "credit_type"}
+	creditGrantColumnsWithoutDefault = []string{"tx_hash", "license_id", "asset_did", "initial_amount", "remaining_amount"}
+	creditGrantColumnsWithDefault    = []string{"id", "log_index", "expires_at", "block_number", "status", "created_at", "updated_at", "depleted_at", "metadata", "credit_type"}
 	creditGrantPrimaryKeyColumns     = []string{"id"}
 	creditGrantGeneratedColumns      = []string{}
 )
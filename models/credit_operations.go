@@ -28,7 +28,7 @@ type CreditOperation struct {
 	AppName string `boil:"app_name" json:"app_name" toml:"app_name" yaml:"app_name"`
 	// External reference (API request ID, order ID, etc.)
 	ReferenceID string `boil:"reference_id" json:"reference_id" toml:"reference_id" yaml:"reference_id"`
-	// Type: deduction (deducts credits), refund (returns credits), grant_purchase (new grant), debt_settlement (settles previous debt)
+	// Type: deduction (deducts credits), refund (returns credits), grant_purchase (new grant), debt_settlement (settles previous debt), expiration (unused credits of an expired grant), grant_failed (credits of a pending grant that failed), transfer (credits moved between assets of a license)
 	OperationType string `boil:"operation_type" json:"operation_type" toml:"operation_type" yaml:"operation_type"`
 	// License that used the credits
 	LicenseID string `boil:"license_id" json:"license_id" toml:"license_id" yaml:"license_id"`
@@ -38,6 +38,18 @@ type CreditOperation struct {
 	TotalAmount int64 `boil:"total_amount" json:"total_amount" toml:"total_amount" yaml:"total_amount"`
 	// When this operation occurred
 	CreatedAt null.Time `boil:"created_at" json:"created_at,omitempty" toml:"created_at" yaml:"created_at,omitempty"`
+	// Whether per-grant detail rows were skipped (refunds are redistributed proportionally)
+	SummaryOnly bool `boil:"summary_only" json:"summary_only" toml:"summary_only" yaml:"summary_only"`
+	// Trace ID of the originating request (null for internal operations)
+	TraceID null.String `boil:"trace_id" json:"trace_id,omitempty" toml:"trace_id" yaml:"trace_id,omitempty"`
+	// Spendable balance after the operation (null when snapshots are disabled)
+	BalanceAfter null.Int64 `boil:"balance_after" json:"balance_after,omitempty" toml:"balance_after" yaml:"balance_after,omitempty"`
+	// Why the credits were refunded or manually granted (null for other operations and refunds without a reason)
+	ReasonCode null.String `boil:"reason_code" json:"reason_code,omitempty" toml:"reason_code" yaml:"reason_code,omitempty"`
+	// Credits before the volume discount (null for operations without tiering)
+	NominalAmount null.Int64 `boil:"nominal_amount" json:"nominal_amount,omitempty" toml:"nominal_amount" yaml:"nominal_amount,omitempty"`
+	// Product the credits of the operation are for
+	CreditType string `boil:"credit_type" json:"credit_type" toml:"credit_type" yaml:"credit_type"`
 
 	R *creditOperationR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L creditOperationL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -51,6 +63,12 @@ var CreditOperationColumns = struct {
 	AssetDid      string
 	TotalAmount   string
 	CreatedAt     string
+	SummaryOnly   string
+	TraceID       string
+	BalanceAfter  string
+	ReasonCode    string
+	NominalAmount string
+	CreditType    string
 }{
 	AppName:       "app_name",
 	ReferenceID:   "reference_id",
@@ -59,6 +77,12 @@ var CreditOperationColumns = struct {
 	AssetDid:      "asset_did",
 	TotalAmount:   "total_amount",
 	CreatedAt:     "created_at",
+	SummaryOnly:   "summary_only",
+	TraceID:       "trace_id",
+	BalanceAfter:  "balance_after",
+	ReasonCode:    "reason_code",
+	NominalAmount: "nominal_amount",
+	CreditType:    "credit_type",
 }
 
 var CreditOperationTableColumns = struct {
@@ -69,6 +93,12 @@ var CreditOperationTableColumns = struct {
 	AssetDid      string
 	TotalAmount   string
 	CreatedAt     string
+	SummaryOnly   string
+	TraceID       string
+	BalanceAfter  string
+	ReasonCode    string
+	NominalAmount string
+	CreditType    string
 }{
 	AppName:       "credit_operations.app_name",
 	ReferenceID:   "credit_operations.reference_id",
@@ -77,10 +107,81 @@ var CreditOperationTableColumns = struct {
 	AssetDid:      "credit_operations.asset_did",
 	TotalAmount:   "credit_operations.total_amount",
 	CreatedAt:     "credit_operations.created_at",
+	SummaryOnly:   "credit_operations.summary_only",
+	TraceID:       "credit_operations.trace_id",
+	BalanceAfter:  "credit_operations.balance_after",
+	ReasonCode:    "credit_operations.reason_code",
+	NominalAmount: "credit_operations.nominal_amount",
+	CreditType:    "credit_operations.credit_type",
 }
 
 // Generated where
 
+type whereHelperbool struct{ field string }
+
+func (w whereHelperbool) EQ(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperbool) NEQ(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperbool) LT(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperbool) LTE(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperbool) GT(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperbool) GTE(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+
+type whereHelpernull_String struct{ field string }
+
+func (w whereHelpernull_String) EQ(x null.String) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_String) NEQ(x null.String) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_String) LT(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_String) LTE(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_String) GT(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_String) GTE(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+func (w whereHelpernull_String) LIKE(x null.String) qm.QueryMod {
+	return qm.Where(w.field+" LIKE ?", x)
+}
+func (w whereHelpernull_String) NLIKE(x null.String) qm.QueryMod {
+	return qm.Where(w.field+" NOT LIKE ?", x)
+}
+func (w whereHelpernull_String) ILIKE(x null.String) qm.QueryMod {
+	return qm.Where(w.field+" ILIKE ?", x)
+}
+func (w whereHelpernull_String) NILIKE(x null.String) qm.QueryMod {
+	return qm.Where(w.field+" NOT ILIKE ?", x)
+}
+func (w whereHelpernull_String) SIMILAR(x null.String) qm.QueryMod {
+	return qm.Where(w.field+" SIMILAR TO ?", x)
+}
+func (w whereHelpernull_String) NSIMILAR(x null.String) qm.QueryMod {
+	return qm.Where(w.field+" NOT SIMILAR TO ?", x)
+}
+func (w whereHelpernull_String) IN(slice []string) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
+}
+func (w whereHelpernull_String) NIN(slice []string) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
+}
+
+func (w whereHelpernull_String) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_String) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+
 var CreditOperationWhere = struct {
 	AppName       whereHelperstring
 	ReferenceID   whereHelperstring
@@ -89,6 +190,12 @@ var CreditOperationWhere = struct {
 	AssetDid      whereHelperstring
 	TotalAmount   whereHelperint64
 	CreatedAt     whereHelpernull_Time
+	SummaryOnly   whereHelperbool
+	TraceID       whereHelpernull_String
+	BalanceAfter  whereHelpernull_Int64
+	ReasonCode    whereHelpernull_String
+	NominalAmount whereHelpernull_Int64
+	CreditType    whereHelperstring
 }{
 	AppName:       whereHelperstring{field: "\"credit_tracker\".\"credit_operations\".\"app_name\""},
 	ReferenceID:   whereHelperstring{field: "\"credit_tracker\".\"credit_operations\".\"reference_id\""},
@@ -97,6 +204,12 @@ var CreditOperationWhere = struct {
 	AssetDid:      whereHelperstring{field: "\"credit_tracker\".\"credit_operations\".\"asset_did\""},
 	TotalAmount:   whereHelperint64{field: "\"credit_tracker\".\"credit_operations\".\"total_amount\""},
 	CreatedAt:     whereHelpernull_Time{field: "\"credit_tracker\".\"credit_operations\".\"created_at\""},
+	SummaryOnly:   whereHelperbool{field: "\"credit_tracker\".\"credit_operations\".\"summary_only\""},
+	TraceID:       whereHelpernull_String{field: "\"credit_tracker\".\"credit_operations\".\"trace_id\""},
+	BalanceAfter:  whereHelpernull_Int64{field: "\"credit_tracker\".\"credit_operations\".\"balance_after\""},
+	ReasonCode:    whereHelpernull_String{field: "\"credit_tracker\".\"credit_operations\".\"reason_code\""},
+	NominalAmount: whereHelpernull_Int64{field: "\"credit_tracker\".\"credit_operations\".\"nominal_amount\""},
+	CreditType:    whereHelperstring{field: "\"credit_tracker\".\"credit_operations\".\"credit_type\""},
 }
 
 // CreditOperationRels is where relationship names are stored.
@@ -116,9 +229,9 @@ func (*creditOperationR) NewStruct() *creditOperationR {
 type creditOperationL struct{}
 
 var (
-	creditOperationAllColumns            = []string{"app_name", "reference_id", "operation_type", "license_id", "asset_did", "total_amount", "created_at"}
+	creditOperationAllColumns            = []string{"app_name", "reference_id", "operation_type", "license_id", "asset_did", "total_amount", "created_at", "summary_only", "trace_id", "balance_after", "reason_code", "nominal_amount", "credit_type"}
 	creditOperationColumnsWithoutDefault = []string{"app_name", "reference_id", "operation_type", "license_id", "asset_did", "total_amount"}
-	creditOperationColumnsWithDefault    = []string{"created_at"}
+	creditOperationColumnsWithDefault    = []string{"created_at", "summary_only", "trace_id", "balance_after", "reason_code", "nominal_amount", "credit_type"}
 	creditOperationPrimaryKeyColumns     = []string{"app_name", "reference_id", "operation_type"}
 	creditOperationGeneratedColumns      = []string{}
 )
@@ -9,6 +9,7 @@ package grpc
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -29,6 +30,11 @@ const (
 	MetadataKey_METADATA_KEY_ASSET_DID         MetadataKey = 1
 	MetadataKey_METADATA_KEY_TRANSACTION_HASH  MetadataKey = 2
 	MetadataKey_METADATA_KEY_DEVELOPER_LICENSE MetadataKey = 3
+	MetadataKey_METADATA_KEY_AVAILABLE_CREDITS MetadataKey = 4
+	MetadataKey_METADATA_KEY_REQUIRED_CREDITS  MetadataKey = 5
+	MetadataKey_METADATA_KEY_CREDIT_SHORTFALL  MetadataKey = 6
+	MetadataKey_METADATA_KEY_APP_NAME          MetadataKey = 7
+	MetadataKey_METADATA_KEY_REFERENCE_ID      MetadataKey = 8
 )
 
 // Enum value maps for MetadataKey.
@@ -38,12 +44,22 @@ var (
 		1: "METADATA_KEY_ASSET_DID",
 		2: "METADATA_KEY_TRANSACTION_HASH",
 		3: "METADATA_KEY_DEVELOPER_LICENSE",
+		4: "METADATA_KEY_AVAILABLE_CREDITS",
+		5: "METADATA_KEY_REQUIRED_CREDITS",
+		6: "METADATA_KEY_CREDIT_SHORTFALL",
+		7: "METADATA_KEY_APP_NAME",
+		8: "METADATA_KEY_REFERENCE_ID",
 	}
 	MetadataKey_value = map[string]int32{
 		"METADATA_KEY_UNSPECIFIED":       0,
 		"METADATA_KEY_ASSET_DID":         1,
 		"METADATA_KEY_TRANSACTION_HASH":  2,
 		"METADATA_KEY_DEVELOPER_LICENSE": 3,
+		"METADATA_KEY_AVAILABLE_CREDITS": 4,
+		"METADATA_KEY_REQUIRED_CREDITS":  5,
+		"METADATA_KEY_CREDIT_SHORTFALL":  6,
+		"METADATA_KEY_APP_NAME":          7,
+		"METADATA_KEY_REFERENCE_ID":      8,
 	}
 )
 
@@ -82,6 +98,8 @@ const (
 	ErrorReason_ERROR_REASON_INSUFFICIENT_CREDITS      ErrorReason = 1
 	ErrorReason_ERROR_REASON_INVALID_ASSET_DID         ErrorReason = 2
 	ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE ErrorReason = 3
+	ErrorReason_ERROR_REASON_INVALID_DATE_RANGE        ErrorReason = 4
+	ErrorReason_ERROR_REASON_DUPLICATE_OPERATION       ErrorReason = 5
 )
 
 // Enum value maps for ErrorReason.
@@ -91,12 +109,16 @@ var (
 		1: "ERROR_REASON_INSUFFICIENT_CREDITS",
 		2: "ERROR_REASON_INVALID_ASSET_DID",
 		3: "ERROR_REASON_INVALID_DEVELOPER_LICENSE",
+		4: "ERROR_REASON_INVALID_DATE_RANGE",
+		5: "ERROR_REASON_DUPLICATE_OPERATION",
 	}
 	ErrorReason_value = map[string]int32{
 		"ERROR_REASON_UNSPECIFIED":               0,
 		"ERROR_REASON_INSUFFICIENT_CREDITS":      1,
 		"ERROR_REASON_INVALID_ASSET_DID":         2,
 		"ERROR_REASON_INVALID_DEVELOPER_LICENSE": 3,
+		"ERROR_REASON_INVALID_DATE_RANGE":        4,
+		"ERROR_REASON_DUPLICATE_OPERATION":       5,
 	}
 )
 
@@ -253,26 +275,1774 @@ func (x *CreditDeductRequest) GetAppName() string {
 
 // Response message for credit deduction
 type CreditDeductResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Spendable balance of the license and asset after the deduction
+	RemainingBalance int64 `protobuf:"varint,1,opt,name=remaining_balance,json=remainingBalance,proto3" json:"remaining_balance,omitempty"`
+	// Reference ID of the deduction operation, refund the deduction with it and the app name
+	OperationId   string `protobuf:"bytes,2,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreditDeductResponse) Reset() {
+	*x = CreditDeductResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreditDeductResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreditDeductResponse) ProtoMessage() {}
+
+func (x *CreditDeductResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreditDeductResponse.ProtoReflect.Descriptor instead.
+func (*CreditDeductResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreditDeductResponse) GetRemainingBalance() int64 {
+	if x != nil {
+		return x.RemainingBalance
+	}
+	return 0
+}
+
+func (x *CreditDeductResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+// A single asset deduction of a batch
+type BatchDeductItem struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	AssetDid string                 `protobuf:"bytes,1,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	Amount   uint64                 `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	// Identifies the deduction like the reference ID of DeductCredits, a reference ID is only deducted once
+	ReferenceId   string `protobuf:"bytes,3,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeductItem) Reset() {
+	*x = BatchDeductItem{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeductItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeductItem) ProtoMessage() {}
+
+func (x *BatchDeductItem) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeductItem.ProtoReflect.Descriptor instead.
+func (*BatchDeductItem) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BatchDeductItem) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *BatchDeductItem) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *BatchDeductItem) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+// Request message for deducting credits from several assets
+type BatchDeductCreditsRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	AppName          string                 `protobuf:"bytes,2,opt,name=app_name,json=appName,proto3" json:"app_name,omitempty"`
+	Items            []*BatchDeductItem     `protobuf:"bytes,3,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *BatchDeductCreditsRequest) Reset() {
+	*x = BatchDeductCreditsRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeductCreditsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeductCreditsRequest) ProtoMessage() {}
+
+func (x *BatchDeductCreditsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeductCreditsRequest.ProtoReflect.Descriptor instead.
+func (*BatchDeductCreditsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchDeductCreditsRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *BatchDeductCreditsRequest) GetAppName() string {
+	if x != nil {
+		return x.AppName
+	}
+	return ""
+}
+
+func (x *BatchDeductCreditsRequest) GetItems() []*BatchDeductItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// The result of a single asset deduction of a batch
+type BatchDeductResult struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	AssetDid    string                 `protobuf:"bytes,1,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	ReferenceId string                 `protobuf:"bytes,2,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	Success     bool                   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	// Set when the deduction failed, ERROR_REASON_DUPLICATE_OPERATION means the reference ID was already deducted and nothing was charged
+	ErrorReason   ErrorReason `protobuf:"varint,4,opt,name=error_reason,json=errorReason,proto3,enum=grpc.ErrorReason" json:"error_reason,omitempty"`
+	Error         string      `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeductResult) Reset() {
+	*x = BatchDeductResult{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeductResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeductResult) ProtoMessage() {}
+
+func (x *BatchDeductResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeductResult.ProtoReflect.Descriptor instead.
+func (*BatchDeductResult) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchDeductResult) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *BatchDeductResult) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+func (x *BatchDeductResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *BatchDeductResult) GetErrorReason() ErrorReason {
+	if x != nil {
+		return x.ErrorReason
+	}
+	return ErrorReason_ERROR_REASON_UNSPECIFIED
+}
+
+func (x *BatchDeductResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Response message for a batch deduction, the results are in the same order as the request items
+type BatchDeductCreditsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BatchDeductResult   `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeductCreditsResponse) Reset() {
+	*x = BatchDeductCreditsResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeductCreditsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeductCreditsResponse) ProtoMessage() {}
+
+func (x *BatchDeductCreditsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeductCreditsResponse.ProtoReflect.Descriptor instead.
+func (*BatchDeductCreditsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchDeductCreditsResponse) GetResults() []*BatchDeductResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// Request message for the balances of several assets
+type GetBalancesRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	AssetDids        []string               `protobuf:"bytes,2,rep,name=asset_dids,json=assetDids,proto3" json:"asset_dids,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetBalancesRequest) Reset() {
+	*x = GetBalancesRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBalancesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBalancesRequest) ProtoMessage() {}
+
+func (x *GetBalancesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBalancesRequest.ProtoReflect.Descriptor instead.
+func (*GetBalancesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetBalancesRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *GetBalancesRequest) GetAssetDids() []string {
+	if x != nil {
+		return x.AssetDids
+	}
+	return nil
+}
+
+// The balance of a single asset
+type AssetBalance struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Spendable credits from active grants
+	Balance int64 `protobuf:"varint,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	// Outstanding debt from failed grants
+	Debt          int64 `protobuf:"varint,2,opt,name=debt,proto3" json:"debt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssetBalance) Reset() {
+	*x = AssetBalance{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetBalance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetBalance) ProtoMessage() {}
+
+func (x *AssetBalance) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetBalance.ProtoReflect.Descriptor instead.
+func (*AssetBalance) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AssetBalance) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *AssetBalance) GetDebt() int64 {
+	if x != nil {
+		return x.Debt
+	}
+	return 0
+}
+
+// Response message for the balances of several assets, every requested asset is included
+type GetBalancesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Keyed by asset DID
+	Balances      map[string]*AssetBalance `protobuf:"bytes,1,rep,name=balances,proto3" json:"balances,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBalancesResponse) Reset() {
+	*x = GetBalancesResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBalancesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBalancesResponse) ProtoMessage() {}
+
+func (x *GetBalancesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBalancesResponse.ProtoReflect.Descriptor instead.
+func (*GetBalancesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetBalancesResponse) GetBalances() map[string]*AssetBalance {
+	if x != nil {
+		return x.Balances
+	}
+	return nil
+}
+
+// Request message for the outstanding debt of an asset
+type GetDebtRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	AssetDid         string                 `protobuf:"bytes,2,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetDebtRequest) Reset() {
+	*x = GetDebtRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDebtRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDebtRequest) ProtoMessage() {}
+
+func (x *GetDebtRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDebtRequest.ProtoReflect.Descriptor instead.
+func (*GetDebtRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetDebtRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *GetDebtRequest) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+// Response message for the outstanding debt of an asset
+type GetDebtResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Credits owed from failed grants
+	Debt          int64 `protobuf:"varint,1,opt,name=debt,proto3" json:"debt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDebtResponse) Reset() {
+	*x = GetDebtResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDebtResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDebtResponse) ProtoMessage() {}
+
+func (x *GetDebtResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDebtResponse.ProtoReflect.Descriptor instead.
+func (*GetDebtResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetDebtResponse) GetDebt() int64 {
+	if x != nil {
+		return x.Debt
+	}
+	return 0
+}
+
+// Request message for settling the outstanding debt of an asset
+type SettleDebtRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	AssetDid         string                 `protobuf:"bytes,2,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SettleDebtRequest) Reset() {
+	*x = SettleDebtRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SettleDebtRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SettleDebtRequest) ProtoMessage() {}
+
+func (x *SettleDebtRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SettleDebtRequest.ProtoReflect.Descriptor instead.
+func (*SettleDebtRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SettleDebtRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *SettleDebtRequest) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+// Response message for settling the outstanding debt of an asset
+type SettleDebtResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Credits moved from the active grants to settle the debt, zero when there was no debt or no credits
+	SettledAmount int64 `protobuf:"varint,1,opt,name=settled_amount,json=settledAmount,proto3" json:"settled_amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SettleDebtResponse) Reset() {
+	*x = SettleDebtResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SettleDebtResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SettleDebtResponse) ProtoMessage() {}
+
+func (x *SettleDebtResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SettleDebtResponse.ProtoReflect.Descriptor instead.
+func (*SettleDebtResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SettleDebtResponse) GetSettledAmount() int64 {
+	if x != nil {
+		return x.SettledAmount
+	}
+	return 0
+}
+
+// Request message for a dry run of a deduction
+type CheckCreditsRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	AssetDid         string                 `protobuf:"bytes,2,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	Amount           uint64                 `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CheckCreditsRequest) Reset() {
+	*x = CheckCreditsRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckCreditsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckCreditsRequest) ProtoMessage() {}
+
+func (x *CheckCreditsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckCreditsRequest.ProtoReflect.Descriptor instead.
+func (*CheckCreditsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CheckCreditsRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *CheckCreditsRequest) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *CheckCreditsRequest) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+// Response message for a dry run of a deduction
+type CheckCreditsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether the deduction would succeed, false when there is outstanding debt or the balance does not cover the amount
+	CanDeduct bool `protobuf:"varint,1,opt,name=can_deduct,json=canDeduct,proto3" json:"can_deduct,omitempty"`
+	// Spendable credits from active grants
+	Balance       int64 `protobuf:"varint,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckCreditsResponse) Reset() {
+	*x = CheckCreditsResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckCreditsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckCreditsResponse) ProtoMessage() {}
+
+func (x *CheckCreditsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckCreditsResponse.ProtoReflect.Descriptor instead.
+func (*CheckCreditsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CheckCreditsResponse) GetCanDeduct() bool {
+	if x != nil {
+		return x.CanDeduct
+	}
+	return false
+}
+
+func (x *CheckCreditsResponse) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+// Request message for the account snapshot of a license
+type GetAccountSnapshotRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetAccountSnapshotRequest) Reset() {
+	*x = GetAccountSnapshotRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountSnapshotRequest) ProtoMessage() {}
+
+func (x *GetAccountSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetAccountSnapshotRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+// Number, credits, and oldest creation time of pending grants
+type PendingGrantStats struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Count       int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	TotalAmount int64                  `protobuf:"varint,2,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
+	// Unset without pending grants
+	OldestCreatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=oldest_created_at,json=oldestCreatedAt,proto3" json:"oldest_created_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PendingGrantStats) Reset() {
+	*x = PendingGrantStats{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PendingGrantStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PendingGrantStats) ProtoMessage() {}
+
+func (x *PendingGrantStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PendingGrantStats.ProtoReflect.Descriptor instead.
+func (*PendingGrantStats) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PendingGrantStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *PendingGrantStats) GetTotalAmount() int64 {
+	if x != nil {
+		return x.TotalAmount
+	}
+	return 0
+}
+
+func (x *PendingGrantStats) GetOldestCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OldestCreatedAt
+	}
+	return nil
+}
+
+// An operation of a license without the grants it touched
+type RecentOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AssetDid      string                 `protobuf:"bytes,1,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	OperationType string                 `protobuf:"bytes,2,opt,name=operation_type,json=operationType,proto3" json:"operation_type,omitempty"`
+	TotalAmount   int64                  `protobuf:"varint,3,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
+	AppName       string                 `protobuf:"bytes,4,opt,name=app_name,json=appName,proto3" json:"app_name,omitempty"`
+	ReferenceId   string                 `protobuf:"bytes,5,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecentOperation) Reset() {
+	*x = RecentOperation{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecentOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecentOperation) ProtoMessage() {}
+
+func (x *RecentOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecentOperation.ProtoReflect.Descriptor instead.
+func (*RecentOperation) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RecentOperation) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *RecentOperation) GetOperationType() string {
+	if x != nil {
+		return x.OperationType
+	}
+	return ""
+}
+
+func (x *RecentOperation) GetTotalAmount() int64 {
+	if x != nil {
+		return x.TotalAmount
+	}
+	return 0
+}
+
+func (x *RecentOperation) GetAppName() string {
+	if x != nil {
+		return x.AppName
+	}
+	return ""
+}
+
+func (x *RecentOperation) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+func (x *RecentOperation) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// Request message for watching the operations of a license
+type WatchOperationsRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	// Recent operations sent before the new ones, 10 when unset and at most 100
+	BackfillLimit int32 `protobuf:"varint,2,opt,name=backfill_limit,json=backfillLimit,proto3" json:"backfill_limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchOperationsRequest) Reset() {
+	*x = WatchOperationsRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchOperationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchOperationsRequest) ProtoMessage() {}
+
+func (x *WatchOperationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchOperationsRequest.ProtoReflect.Descriptor instead.
+func (*WatchOperationsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WatchOperationsRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *WatchOperationsRequest) GetBackfillLimit() int32 {
+	if x != nil {
+		return x.BackfillLimit
+	}
+	return 0
+}
+
+// State of a single asset of an account snapshot
+type AssetSnapshot struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	AssetDid string                 `protobuf:"bytes,1,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	// Spendable credits from active grants
+	Balance int64 `protobuf:"varint,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	// Outstanding debt from failed grants
+	Debt          int64              `protobuf:"varint,3,opt,name=debt,proto3" json:"debt,omitempty"`
+	PendingGrants *PendingGrantStats `protobuf:"bytes,4,opt,name=pending_grants,json=pendingGrants,proto3" json:"pending_grants,omitempty"`
+	// Soonest expiration of a grant with spendable credits, unset when no such grant expires
+	NextExpiration *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=next_expiration,json=nextExpiration,proto3" json:"next_expiration,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AssetSnapshot) Reset() {
+	*x = AssetSnapshot{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetSnapshot) ProtoMessage() {}
+
+func (x *AssetSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetSnapshot.ProtoReflect.Descriptor instead.
+func (*AssetSnapshot) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AssetSnapshot) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *AssetSnapshot) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *AssetSnapshot) GetDebt() int64 {
+	if x != nil {
+		return x.Debt
+	}
+	return 0
+}
+
+func (x *AssetSnapshot) GetPendingGrants() *PendingGrantStats {
+	if x != nil {
+		return x.PendingGrants
+	}
+	return nil
+}
+
+func (x *AssetSnapshot) GetNextExpiration() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextExpiration
+	}
+	return nil
+}
+
+// Response message for the account snapshot of a license, the totals are the sums of the assets
+type GetAccountSnapshotResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	TakenAt          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=taken_at,json=takenAt,proto3" json:"taken_at,omitempty"`
+	Balance          int64                  `protobuf:"varint,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	Debt             int64                  `protobuf:"varint,4,opt,name=debt,proto3" json:"debt,omitempty"`
+	PendingGrants    *PendingGrantStats     `protobuf:"bytes,5,opt,name=pending_grants,json=pendingGrants,proto3" json:"pending_grants,omitempty"`
+	NextExpiration   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=next_expiration,json=nextExpiration,proto3" json:"next_expiration,omitempty"`
+	// Newest first
+	RecentOperations []*RecentOperation `protobuf:"bytes,7,rep,name=recent_operations,json=recentOperations,proto3" json:"recent_operations,omitempty"`
+	// Every asset with grants, ordered by asset DID
+	Assets        []*AssetSnapshot `protobuf:"bytes,8,rep,name=assets,proto3" json:"assets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountSnapshotResponse) Reset() {
+	*x = GetAccountSnapshotResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountSnapshotResponse) ProtoMessage() {}
+
+func (x *GetAccountSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*GetAccountSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetAccountSnapshotResponse) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *GetAccountSnapshotResponse) GetTakenAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TakenAt
+	}
+	return nil
+}
+
+func (x *GetAccountSnapshotResponse) GetBalance() int64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *GetAccountSnapshotResponse) GetDebt() int64 {
+	if x != nil {
+		return x.Debt
+	}
+	return 0
+}
+
+func (x *GetAccountSnapshotResponse) GetPendingGrants() *PendingGrantStats {
+	if x != nil {
+		return x.PendingGrants
+	}
+	return nil
+}
+
+func (x *GetAccountSnapshotResponse) GetNextExpiration() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextExpiration
+	}
+	return nil
+}
+
+func (x *GetAccountSnapshotResponse) GetRecentOperations() []*RecentOperation {
+	if x != nil {
+		return x.RecentOperations
+	}
+	return nil
+}
+
+func (x *GetAccountSnapshotResponse) GetAssets() []*AssetSnapshot {
+	if x != nil {
+		return x.Assets
+	}
+	return nil
+}
+
+// Request message for the grant of a burn transaction
+type GetGrantRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TxHash        string                 `protobuf:"bytes,1,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGrantRequest) Reset() {
+	*x = GetGrantRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGrantRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGrantRequest) ProtoMessage() {}
+
+func (x *GetGrantRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGrantRequest.ProtoReflect.Descriptor instead.
+func (*GetGrantRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetGrantRequest) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+// Response message for the grant of a burn transaction
+type GetGrantResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	GrantId          string                 `protobuf:"bytes,1,opt,name=grant_id,json=grantId,proto3" json:"grant_id,omitempty"`
+	DeveloperLicense string                 `protobuf:"bytes,2,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	AssetDid         string                 `protobuf:"bytes,3,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	// One of pending, confirmed, failed, or expired
+	Status        string `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	InitialAmount int64  `protobuf:"varint,5,opt,name=initial_amount,json=initialAmount,proto3" json:"initial_amount,omitempty"`
+	// Credits left to spend, below the initial amount of a failed grant while it is in debt
+	RemainingAmount int64 `protobuf:"varint,6,opt,name=remaining_amount,json=remainingAmount,proto3" json:"remaining_amount,omitempty"`
+	// Unset for perpetual grants
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Log index of the burn event, unset until the grant is confirmed
+	LogIndex      *int64                 `protobuf:"varint,8,opt,name=log_index,json=logIndex,proto3,oneof" json:"log_index,omitempty"`
+	TxHash        string                 `protobuf:"bytes,9,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGrantResponse) Reset() {
+	*x = GetGrantResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGrantResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGrantResponse) ProtoMessage() {}
+
+func (x *GetGrantResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGrantResponse.ProtoReflect.Descriptor instead.
+func (*GetGrantResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetGrantResponse) GetGrantId() string {
+	if x != nil {
+		return x.GrantId
+	}
+	return ""
+}
+
+func (x *GetGrantResponse) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *GetGrantResponse) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *GetGrantResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *GetGrantResponse) GetInitialAmount() int64 {
+	if x != nil {
+		return x.InitialAmount
+	}
+	return 0
+}
+
+func (x *GetGrantResponse) GetRemainingAmount() int64 {
+	if x != nil {
+		return x.RemainingAmount
+	}
+	return 0
+}
+
+func (x *GetGrantResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *GetGrantResponse) GetLogIndex() int64 {
+	if x != nil && x.LogIndex != nil {
+		return *x.LogIndex
+	}
+	return 0
+}
+
+func (x *GetGrantResponse) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+func (x *GetGrantResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+// Request message for a manual grant
+type AdminAddCreditsRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	AssetDid         string                 `protobuf:"bytes,2,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	Amount           uint64                 `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	// Why the credits were granted, such as goodwill or incident_compensation, at most 64 characters
+	Reason        string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdminAddCreditsRequest) Reset() {
+	*x = AdminAddCreditsRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdminAddCreditsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminAddCreditsRequest) ProtoMessage() {}
+
+func (x *AdminAddCreditsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminAddCreditsRequest.ProtoReflect.Descriptor instead.
+func (*AdminAddCreditsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AdminAddCreditsRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *AdminAddCreditsRequest) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *AdminAddCreditsRequest) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *AdminAddCreditsRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Response message for a manual grant
+type AdminAddCreditsResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	GrantId string                 `protobuf:"bytes,1,opt,name=grant_id,json=grantId,proto3" json:"grant_id,omitempty"`
+	// Synthetic reference the grant stores in place of a burn tx hash
+	TxHash        string `protobuf:"bytes,2,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdminAddCreditsResponse) Reset() {
+	*x = AdminAddCreditsResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdminAddCreditsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminAddCreditsResponse) ProtoMessage() {}
+
+func (x *AdminAddCreditsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminAddCreditsResponse.ProtoReflect.Descriptor instead.
+func (*AdminAddCreditsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *AdminAddCreditsResponse) GetGrantId() string {
+	if x != nil {
+		return x.GrantId
+	}
+	return ""
+}
+
+func (x *AdminAddCreditsResponse) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+// Request message for refunding credits
+type RefundCreditsRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ReferenceId string                 `protobuf:"bytes,1,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
+	AppName     string                 `protobuf:"bytes,2,opt,name=app_name,json=appName,proto3" json:"app_name,omitempty"`
+	// Optional reason for the refund, such as client_error or upstream_failure, at most 64 characters
+	ReasonCode    string `protobuf:"bytes,3,opt,name=reason_code,json=reasonCode,proto3" json:"reason_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundCreditsRequest) Reset() {
+	*x = RefundCreditsRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundCreditsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundCreditsRequest) ProtoMessage() {}
+
+func (x *RefundCreditsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundCreditsRequest.ProtoReflect.Descriptor instead.
+func (*RefundCreditsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *RefundCreditsRequest) GetReferenceId() string {
+	if x != nil {
+		return x.ReferenceId
+	}
+	return ""
+}
+
+func (x *RefundCreditsRequest) GetAppName() string {
+	if x != nil {
+		return x.AppName
+	}
+	return ""
+}
+
+func (x *RefundCreditsRequest) GetReasonCode() string {
+	if x != nil {
+		return x.ReasonCode
+	}
+	return ""
+}
+
+// Response message for credit refund
+type RefundCreditsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefundCreditsResponse) Reset() {
+	*x = RefundCreditsResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundCreditsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundCreditsResponse) ProtoMessage() {}
+
+func (x *RefundCreditsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundCreditsResponse.ProtoReflect.Descriptor instead.
+func (*RefundCreditsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{26}
+}
+
+// Request message for the self-test
+type SelfTestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SelfTestRequest) Reset() {
+	*x = SelfTestRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelfTestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfTestRequest) ProtoMessage() {}
+
+func (x *SelfTestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfTestRequest.ProtoReflect.Descriptor instead.
+func (*SelfTestRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{27}
+}
+
+// Result of a single self-test step
+type SelfTestStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Passed        bool                   `protobuf:"varint,2,opt,name=passed,proto3" json:"passed,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SelfTestStep) Reset() {
+	*x = SelfTestStep{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelfTestStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfTestStep) ProtoMessage() {}
+
+func (x *SelfTestStep) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfTestStep.ProtoReflect.Descriptor instead.
+func (*SelfTestStep) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *SelfTestStep) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SelfTestStep) GetPassed() bool {
+	if x != nil {
+		return x.Passed
+	}
+	return false
+}
+
+func (x *SelfTestStep) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Response message for the self-test
+type SelfTestResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Passed        bool                   `protobuf:"varint,1,opt,name=passed,proto3" json:"passed,omitempty"`
+	Steps         []*SelfTestStep        `protobuf:"bytes,2,rep,name=steps,proto3" json:"steps,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreditDeductResponse) Reset() {
-	*x = CreditDeductResponse{}
-	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[1]
+func (x *SelfTestResponse) Reset() {
+	*x = SelfTestResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelfTestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfTestResponse) ProtoMessage() {}
+
+func (x *SelfTestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfTestResponse.ProtoReflect.Descriptor instead.
+func (*SelfTestResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SelfTestResponse) GetPassed() bool {
+	if x != nil {
+		return x.Passed
+	}
+	return false
+}
+
+func (x *SelfTestResponse) GetSteps() []*SelfTestStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+// Request message for a usage report
+type GetUsageReportRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DeveloperLicense string                 `protobuf:"bytes,1,opt,name=developer_license,json=developerLicense,proto3" json:"developer_license,omitempty"`
+	// Optional, reports on a single asset of the license when set
+	AssetDid string                 `protobuf:"bytes,2,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	FromDate *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from_date,json=fromDate,proto3" json:"from_date,omitempty"`
+	// Optional, the report runs until now when unset
+	ToDate *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to_date,json=toDate,proto3" json:"to_date,omitempty"`
+	// Only used for asset reports, includes the grants confirmed during the time period
+	IncludeConfirmedGrants bool `protobuf:"varint,5,opt,name=include_confirmed_grants,json=includeConfirmedGrants,proto3" json:"include_confirmed_grants,omitempty"`
+	// Only used for license reports, breaks the usage down by asset
+	IncludePerAsset bool `protobuf:"varint,6,opt,name=include_per_asset,json=includePerAsset,proto3" json:"include_per_asset,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetUsageReportRequest) Reset() {
+	*x = GetUsageReportRequest{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreditDeductResponse) String() string {
+func (x *GetUsageReportRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreditDeductResponse) ProtoMessage() {}
+func (*GetUsageReportRequest) ProtoMessage() {}
 
-func (x *CreditDeductResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[1]
+func (x *GetUsageReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -283,35 +2053,78 @@ func (x *CreditDeductResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreditDeductResponse.ProtoReflect.Descriptor instead.
-func (*CreditDeductResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use GetUsageReportRequest.ProtoReflect.Descriptor instead.
+func (*GetUsageReportRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{30}
 }
 
-// Request message for refunding credits
-type RefundCreditsRequest struct {
+func (x *GetUsageReportRequest) GetDeveloperLicense() string {
+	if x != nil {
+		return x.DeveloperLicense
+	}
+	return ""
+}
+
+func (x *GetUsageReportRequest) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *GetUsageReportRequest) GetFromDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FromDate
+	}
+	return nil
+}
+
+func (x *GetUsageReportRequest) GetToDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ToDate
+	}
+	return nil
+}
+
+func (x *GetUsageReportRequest) GetIncludeConfirmedGrants() bool {
+	if x != nil {
+		return x.IncludeConfirmedGrants
+	}
+	return false
+}
+
+func (x *GetUsageReportRequest) GetIncludePerAsset() bool {
+	if x != nil {
+		return x.IncludePerAsset
+	}
+	return false
+}
+
+// A grant that was confirmed on chain
+type ConfirmedGrant struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ReferenceId   string                 `protobuf:"bytes,1,opt,name=reference_id,json=referenceId,proto3" json:"reference_id,omitempty"`
-	AppName       string                 `protobuf:"bytes,2,opt,name=app_name,json=appName,proto3" json:"app_name,omitempty"`
+	TxHash        string                 `protobuf:"bytes,1,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	Amount        int64                  `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	ConfirmedAt   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=confirmed_at,json=confirmedAt,proto3" json:"confirmed_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RefundCreditsRequest) Reset() {
-	*x = RefundCreditsRequest{}
-	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[2]
+func (x *ConfirmedGrant) Reset() {
+	*x = ConfirmedGrant{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RefundCreditsRequest) String() string {
+func (x *ConfirmedGrant) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RefundCreditsRequest) ProtoMessage() {}
+func (*ConfirmedGrant) ProtoMessage() {}
 
-func (x *RefundCreditsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[2]
+func (x *ConfirmedGrant) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -322,47 +2135,193 @@ func (x *RefundCreditsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RefundCreditsRequest.ProtoReflect.Descriptor instead.
-func (*RefundCreditsRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use ConfirmedGrant.ProtoReflect.Descriptor instead.
+func (*ConfirmedGrant) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *RefundCreditsRequest) GetReferenceId() string {
+func (x *ConfirmedGrant) GetTxHash() string {
 	if x != nil {
-		return x.ReferenceId
+		return x.TxHash
 	}
 	return ""
 }
 
-func (x *RefundCreditsRequest) GetAppName() string {
+func (x *ConfirmedGrant) GetAmount() int64 {
 	if x != nil {
-		return x.AppName
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ConfirmedGrant) GetConfirmedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ConfirmedAt
+	}
+	return nil
+}
+
+// Number and credits of the refunds with the same reason code
+type RefundReasonTotal struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty for refunds without a reason
+	ReasonCode           string `protobuf:"bytes,1,opt,name=reason_code,json=reasonCode,proto3" json:"reason_code,omitempty"`
+	NumOfRefunds         int64  `protobuf:"varint,2,opt,name=num_of_refunds,json=numOfRefunds,proto3" json:"num_of_refunds,omitempty"`
+	NumOfCreditsRefunded int64  `protobuf:"varint,3,opt,name=num_of_credits_refunded,json=numOfCreditsRefunded,proto3" json:"num_of_credits_refunded,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *RefundReasonTotal) Reset() {
+	*x = RefundReasonTotal{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundReasonTotal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundReasonTotal) ProtoMessage() {}
+
+func (x *RefundReasonTotal) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundReasonTotal.ProtoReflect.Descriptor instead.
+func (*RefundReasonTotal) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *RefundReasonTotal) GetReasonCode() string {
+	if x != nil {
+		return x.ReasonCode
 	}
 	return ""
 }
 
-// Response message for credit refund
-type RefundCreditsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *RefundReasonTotal) GetNumOfRefunds() int64 {
+	if x != nil {
+		return x.NumOfRefunds
+	}
+	return 0
+}
+
+func (x *RefundReasonTotal) GetNumOfCreditsRefunded() int64 {
+	if x != nil {
+		return x.NumOfCreditsRefunded
+	}
+	return 0
+}
+
+// Usage of a single asset during the time period of a license report
+type AssetUsage struct {
+	state                       protoimpl.MessageState `protogen:"open.v1"`
+	AssetDid                    string                 `protobuf:"bytes,1,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	NumOfCreditsUsed            int64                  `protobuf:"varint,2,opt,name=num_of_credits_used,json=numOfCreditsUsed,proto3" json:"num_of_credits_used,omitempty"`
+	NumOfCreditsGrantsPurchased int64                  `protobuf:"varint,3,opt,name=num_of_credits_grants_purchased,json=numOfCreditsGrantsPurchased,proto3" json:"num_of_credits_grants_purchased,omitempty"`
+	unknownFields               protoimpl.UnknownFields
+	sizeCache                   protoimpl.SizeCache
+}
+
+func (x *AssetUsage) Reset() {
+	*x = AssetUsage{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetUsage) ProtoMessage() {}
+
+func (x *AssetUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetUsage.ProtoReflect.Descriptor instead.
+func (*AssetUsage) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *AssetUsage) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *AssetUsage) GetNumOfCreditsUsed() int64 {
+	if x != nil {
+		return x.NumOfCreditsUsed
+	}
+	return 0
+}
+
+func (x *AssetUsage) GetNumOfCreditsGrantsPurchased() int64 {
+	if x != nil {
+		return x.NumOfCreditsGrantsPurchased
+	}
+	return 0
+}
+
+// Response message for a usage report, the asset fields are only set for asset reports
+type GetUsageReportResponse struct {
+	state                       protoimpl.MessageState `protogen:"open.v1"`
+	LicenseId                   string                 `protobuf:"bytes,1,opt,name=license_id,json=licenseId,proto3" json:"license_id,omitempty"`
+	AssetDid                    string                 `protobuf:"bytes,2,opt,name=asset_did,json=assetDid,proto3" json:"asset_did,omitempty"`
+	FromDate                    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from_date,json=fromDate,proto3" json:"from_date,omitempty"`
+	ToDate                      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to_date,json=toDate,proto3" json:"to_date,omitempty"`
+	NumOfAssets                 int64                  `protobuf:"varint,5,opt,name=num_of_assets,json=numOfAssets,proto3" json:"num_of_assets,omitempty"`
+	NumOfCreditsGrantsPurchased int64                  `protobuf:"varint,6,opt,name=num_of_credits_grants_purchased,json=numOfCreditsGrantsPurchased,proto3" json:"num_of_credits_grants_purchased,omitempty"`
+	NumOfCreditsUsed            int64                  `protobuf:"varint,7,opt,name=num_of_credits_used,json=numOfCreditsUsed,proto3" json:"num_of_credits_used,omitempty"`
+	CurrentCreditsRemaining     int64                  `protobuf:"varint,8,opt,name=current_credits_remaining,json=currentCreditsRemaining,proto3" json:"current_credits_remaining,omitempty"`
+	NumOfCreditsGranted         int64                  `protobuf:"varint,9,opt,name=num_of_credits_granted,json=numOfCreditsGranted,proto3" json:"num_of_credits_granted,omitempty"`
+	UtilizationRate             *float64               `protobuf:"fixed64,10,opt,name=utilization_rate,json=utilizationRate,proto3,oneof" json:"utilization_rate,omitempty"`
+	ProjectedExhaustion         *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=projected_exhaustion,json=projectedExhaustion,proto3" json:"projected_exhaustion,omitempty"`
+	ConfirmedGrants             []*ConfirmedGrant      `protobuf:"bytes,12,rep,name=confirmed_grants,json=confirmedGrants,proto3" json:"confirmed_grants,omitempty"`
+	// Only set for license reports
+	RefundsByReason []*RefundReasonTotal `protobuf:"bytes,13,rep,name=refunds_by_reason,json=refundsByReason,proto3" json:"refunds_by_reason,omitempty"`
+	// Only set for license reports that include the per-asset breakdown, ordered by asset DID
+	PerAsset      []*AssetUsage `protobuf:"bytes,14,rep,name=per_asset,json=perAsset,proto3" json:"per_asset,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RefundCreditsResponse) Reset() {
-	*x = RefundCreditsResponse{}
-	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[3]
+func (x *GetUsageReportResponse) Reset() {
+	*x = GetUsageReportResponse{}
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RefundCreditsResponse) String() string {
+func (x *GetUsageReportResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RefundCreditsResponse) ProtoMessage() {}
+func (*GetUsageReportResponse) ProtoMessage() {}
 
-func (x *RefundCreditsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[3]
+func (x *GetUsageReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_credit_tracker_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -373,43 +2332,315 @@ func (x *RefundCreditsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RefundCreditsResponse.ProtoReflect.Descriptor instead.
-func (*RefundCreditsResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use GetUsageReportResponse.ProtoReflect.Descriptor instead.
+func (*GetUsageReportResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_credit_tracker_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *GetUsageReportResponse) GetLicenseId() string {
+	if x != nil {
+		return x.LicenseId
+	}
+	return ""
+}
+
+func (x *GetUsageReportResponse) GetAssetDid() string {
+	if x != nil {
+		return x.AssetDid
+	}
+	return ""
+}
+
+func (x *GetUsageReportResponse) GetFromDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FromDate
+	}
+	return nil
+}
+
+func (x *GetUsageReportResponse) GetToDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ToDate
+	}
+	return nil
+}
+
+func (x *GetUsageReportResponse) GetNumOfAssets() int64 {
+	if x != nil {
+		return x.NumOfAssets
+	}
+	return 0
+}
+
+func (x *GetUsageReportResponse) GetNumOfCreditsGrantsPurchased() int64 {
+	if x != nil {
+		return x.NumOfCreditsGrantsPurchased
+	}
+	return 0
+}
+
+func (x *GetUsageReportResponse) GetNumOfCreditsUsed() int64 {
+	if x != nil {
+		return x.NumOfCreditsUsed
+	}
+	return 0
+}
+
+func (x *GetUsageReportResponse) GetCurrentCreditsRemaining() int64 {
+	if x != nil {
+		return x.CurrentCreditsRemaining
+	}
+	return 0
+}
+
+func (x *GetUsageReportResponse) GetNumOfCreditsGranted() int64 {
+	if x != nil {
+		return x.NumOfCreditsGranted
+	}
+	return 0
+}
+
+func (x *GetUsageReportResponse) GetUtilizationRate() float64 {
+	if x != nil && x.UtilizationRate != nil {
+		return *x.UtilizationRate
+	}
+	return 0
+}
+
+func (x *GetUsageReportResponse) GetProjectedExhaustion() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ProjectedExhaustion
+	}
+	return nil
+}
+
+func (x *GetUsageReportResponse) GetConfirmedGrants() []*ConfirmedGrant {
+	if x != nil {
+		return x.ConfirmedGrants
+	}
+	return nil
+}
+
+func (x *GetUsageReportResponse) GetRefundsByReason() []*RefundReasonTotal {
+	if x != nil {
+		return x.RefundsByReason
+	}
+	return nil
+}
+
+func (x *GetUsageReportResponse) GetPerAsset() []*AssetUsage {
+	if x != nil {
+		return x.PerAsset
+	}
+	return nil
 }
 
 var File_pkg_grpc_credit_tracker_proto protoreflect.FileDescriptor
 
 const file_pkg_grpc_credit_tracker_proto_rawDesc = "" +
 	"\n" +
-	"\x1dpkg/grpc/credit-tracker.proto\x12\x04grpc\"\xb5\x01\n" +
+	"\x1dpkg/grpc/credit-tracker.proto\x12\x04grpc\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb5\x01\n" +
 	"\x13CreditDeductRequest\x12+\n" +
 	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12\x1b\n" +
 	"\tasset_did\x18\x02 \x01(\tR\bassetDid\x12\x16\n" +
 	"\x06amount\x18\x03 \x01(\x04R\x06amount\x12!\n" +
 	"\freference_id\x18\x04 \x01(\tR\vreferenceId\x12\x19\n" +
-	"\bapp_name\x18\x05 \x01(\tR\aappName\"\x16\n" +
-	"\x14CreditDeductResponse\"T\n" +
+	"\bapp_name\x18\x05 \x01(\tR\aappName\"f\n" +
+	"\x14CreditDeductResponse\x12+\n" +
+	"\x11remaining_balance\x18\x01 \x01(\x03R\x10remainingBalance\x12!\n" +
+	"\foperation_id\x18\x02 \x01(\tR\voperationId\"i\n" +
+	"\x0fBatchDeductItem\x12\x1b\n" +
+	"\tasset_did\x18\x01 \x01(\tR\bassetDid\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x04R\x06amount\x12!\n" +
+	"\freference_id\x18\x03 \x01(\tR\vreferenceId\"\x90\x01\n" +
+	"\x19BatchDeductCreditsRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12\x19\n" +
+	"\bapp_name\x18\x02 \x01(\tR\aappName\x12+\n" +
+	"\x05items\x18\x03 \x03(\v2\x15.grpc.BatchDeductItemR\x05items\"\xb9\x01\n" +
+	"\x11BatchDeductResult\x12\x1b\n" +
+	"\tasset_did\x18\x01 \x01(\tR\bassetDid\x12!\n" +
+	"\freference_id\x18\x02 \x01(\tR\vreferenceId\x12\x18\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\x124\n" +
+	"\ferror_reason\x18\x04 \x01(\x0e2\x11.grpc.ErrorReasonR\verrorReason\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"O\n" +
+	"\x1aBatchDeductCreditsResponse\x121\n" +
+	"\aresults\x18\x01 \x03(\v2\x17.grpc.BatchDeductResultR\aresults\"`\n" +
+	"\x12GetBalancesRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12\x1d\n" +
+	"\n" +
+	"asset_dids\x18\x02 \x03(\tR\tassetDids\"<\n" +
+	"\fAssetBalance\x12\x18\n" +
+	"\abalance\x18\x01 \x01(\x03R\abalance\x12\x12\n" +
+	"\x04debt\x18\x02 \x01(\x03R\x04debt\"\xab\x01\n" +
+	"\x13GetBalancesResponse\x12C\n" +
+	"\bbalances\x18\x01 \x03(\v2'.grpc.GetBalancesResponse.BalancesEntryR\bbalances\x1aO\n" +
+	"\rBalancesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12(\n" +
+	"\x05value\x18\x02 \x01(\v2\x12.grpc.AssetBalanceR\x05value:\x028\x01\"Z\n" +
+	"\x0eGetDebtRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12\x1b\n" +
+	"\tasset_did\x18\x02 \x01(\tR\bassetDid\"%\n" +
+	"\x0fGetDebtResponse\x12\x12\n" +
+	"\x04debt\x18\x01 \x01(\x03R\x04debt\"]\n" +
+	"\x11SettleDebtRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12\x1b\n" +
+	"\tasset_did\x18\x02 \x01(\tR\bassetDid\";\n" +
+	"\x12SettleDebtResponse\x12%\n" +
+	"\x0esettled_amount\x18\x01 \x01(\x03R\rsettledAmount\"w\n" +
+	"\x13CheckCreditsRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12\x1b\n" +
+	"\tasset_did\x18\x02 \x01(\tR\bassetDid\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x04R\x06amount\"O\n" +
+	"\x14CheckCreditsResponse\x12\x1d\n" +
+	"\n" +
+	"can_deduct\x18\x01 \x01(\bR\tcanDeduct\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x03R\abalance\"H\n" +
+	"\x19GetAccountSnapshotRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\"\x94\x01\n" +
+	"\x11PendingGrantStats\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12!\n" +
+	"\ftotal_amount\x18\x02 \x01(\x03R\vtotalAmount\x12F\n" +
+	"\x11oldest_created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x0foldestCreatedAt\"\xf1\x01\n" +
+	"\x0fRecentOperation\x12\x1b\n" +
+	"\tasset_did\x18\x01 \x01(\tR\bassetDid\x12%\n" +
+	"\x0eoperation_type\x18\x02 \x01(\tR\roperationType\x12!\n" +
+	"\ftotal_amount\x18\x03 \x01(\x03R\vtotalAmount\x12\x19\n" +
+	"\bapp_name\x18\x04 \x01(\tR\aappName\x12!\n" +
+	"\freference_id\x18\x05 \x01(\tR\vreferenceId\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"l\n" +
+	"\x16WatchOperationsRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12%\n" +
+	"\x0ebackfill_limit\x18\x02 \x01(\x05R\rbackfillLimit\"\xdf\x01\n" +
+	"\rAssetSnapshot\x12\x1b\n" +
+	"\tasset_did\x18\x01 \x01(\tR\bassetDid\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x03R\abalance\x12\x12\n" +
+	"\x04debt\x18\x03 \x01(\x03R\x04debt\x12>\n" +
+	"\x0epending_grants\x18\x04 \x01(\v2\x17.grpc.PendingGrantStatsR\rpendingGrants\x12C\n" +
+	"\x0fnext_expiration\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x0enextExpiration\"\xa4\x03\n" +
+	"\x1aGetAccountSnapshotResponse\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x125\n" +
+	"\btaken_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\atakenAt\x12\x18\n" +
+	"\abalance\x18\x03 \x01(\x03R\abalance\x12\x12\n" +
+	"\x04debt\x18\x04 \x01(\x03R\x04debt\x12>\n" +
+	"\x0epending_grants\x18\x05 \x01(\v2\x17.grpc.PendingGrantStatsR\rpendingGrants\x12C\n" +
+	"\x0fnext_expiration\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x0enextExpiration\x12B\n" +
+	"\x11recent_operations\x18\a \x03(\v2\x15.grpc.RecentOperationR\x10recentOperations\x12+\n" +
+	"\x06assets\x18\b \x03(\v2\x13.grpc.AssetSnapshotR\x06assets\"*\n" +
+	"\x0fGetGrantRequest\x12\x17\n" +
+	"\atx_hash\x18\x01 \x01(\tR\x06txHash\"\xa0\x03\n" +
+	"\x10GetGrantResponse\x12\x19\n" +
+	"\bgrant_id\x18\x01 \x01(\tR\agrantId\x12+\n" +
+	"\x11developer_license\x18\x02 \x01(\tR\x10developerLicense\x12\x1b\n" +
+	"\tasset_did\x18\x03 \x01(\tR\bassetDid\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12%\n" +
+	"\x0einitial_amount\x18\x05 \x01(\x03R\rinitialAmount\x12)\n" +
+	"\x10remaining_amount\x18\x06 \x01(\x03R\x0fremainingAmount\x129\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12 \n" +
+	"\tlog_index\x18\b \x01(\x03H\x00R\blogIndex\x88\x01\x01\x12\x17\n" +
+	"\atx_hash\x18\t \x01(\tR\x06txHash\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAtB\f\n" +
+	"\n" +
+	"_log_index\"\x92\x01\n" +
+	"\x16AdminAddCreditsRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12\x1b\n" +
+	"\tasset_did\x18\x02 \x01(\tR\bassetDid\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x04R\x06amount\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"M\n" +
+	"\x17AdminAddCreditsResponse\x12\x19\n" +
+	"\bgrant_id\x18\x01 \x01(\tR\agrantId\x12\x17\n" +
+	"\atx_hash\x18\x02 \x01(\tR\x06txHash\"u\n" +
 	"\x14RefundCreditsRequest\x12!\n" +
 	"\freference_id\x18\x01 \x01(\tR\vreferenceId\x12\x19\n" +
-	"\bapp_name\x18\x02 \x01(\tR\aappName\"\x17\n" +
-	"\x15RefundCreditsResponse*\x8e\x01\n" +
+	"\bapp_name\x18\x02 \x01(\tR\aappName\x12\x1f\n" +
+	"\vreason_code\x18\x03 \x01(\tR\n" +
+	"reasonCode\"\x17\n" +
+	"\x15RefundCreditsResponse\"\x11\n" +
+	"\x0fSelfTestRequest\"P\n" +
+	"\fSelfTestStep\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06passed\x18\x02 \x01(\bR\x06passed\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"T\n" +
+	"\x10SelfTestResponse\x12\x16\n" +
+	"\x06passed\x18\x01 \x01(\bR\x06passed\x12(\n" +
+	"\x05steps\x18\x02 \x03(\v2\x12.grpc.SelfTestStepR\x05steps\"\xb5\x02\n" +
+	"\x15GetUsageReportRequest\x12+\n" +
+	"\x11developer_license\x18\x01 \x01(\tR\x10developerLicense\x12\x1b\n" +
+	"\tasset_did\x18\x02 \x01(\tR\bassetDid\x127\n" +
+	"\tfrom_date\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bfromDate\x123\n" +
+	"\ato_date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06toDate\x128\n" +
+	"\x18include_confirmed_grants\x18\x05 \x01(\bR\x16includeConfirmedGrants\x12*\n" +
+	"\x11include_per_asset\x18\x06 \x01(\bR\x0fincludePerAsset\"\x80\x01\n" +
+	"\x0eConfirmedGrant\x12\x17\n" +
+	"\atx_hash\x18\x01 \x01(\tR\x06txHash\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x03R\x06amount\x12=\n" +
+	"\fconfirmed_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vconfirmedAt\"\x91\x01\n" +
+	"\x11RefundReasonTotal\x12\x1f\n" +
+	"\vreason_code\x18\x01 \x01(\tR\n" +
+	"reasonCode\x12$\n" +
+	"\x0enum_of_refunds\x18\x02 \x01(\x03R\fnumOfRefunds\x125\n" +
+	"\x17num_of_credits_refunded\x18\x03 \x01(\x03R\x14numOfCreditsRefunded\"\x9e\x01\n" +
+	"\n" +
+	"AssetUsage\x12\x1b\n" +
+	"\tasset_did\x18\x01 \x01(\tR\bassetDid\x12-\n" +
+	"\x13num_of_credits_used\x18\x02 \x01(\x03R\x10numOfCreditsUsed\x12D\n" +
+	"\x1fnum_of_credits_grants_purchased\x18\x03 \x01(\x03R\x1bnumOfCreditsGrantsPurchased\"\x95\x06\n" +
+	"\x16GetUsageReportResponse\x12\x1d\n" +
+	"\n" +
+	"license_id\x18\x01 \x01(\tR\tlicenseId\x12\x1b\n" +
+	"\tasset_did\x18\x02 \x01(\tR\bassetDid\x127\n" +
+	"\tfrom_date\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bfromDate\x123\n" +
+	"\ato_date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06toDate\x12\"\n" +
+	"\rnum_of_assets\x18\x05 \x01(\x03R\vnumOfAssets\x12D\n" +
+	"\x1fnum_of_credits_grants_purchased\x18\x06 \x01(\x03R\x1bnumOfCreditsGrantsPurchased\x12-\n" +
+	"\x13num_of_credits_used\x18\a \x01(\x03R\x10numOfCreditsUsed\x12:\n" +
+	"\x19current_credits_remaining\x18\b \x01(\x03R\x17currentCreditsRemaining\x123\n" +
+	"\x16num_of_credits_granted\x18\t \x01(\x03R\x13numOfCreditsGranted\x12.\n" +
+	"\x10utilization_rate\x18\n" +
+	" \x01(\x01H\x00R\x0futilizationRate\x88\x01\x01\x12M\n" +
+	"\x14projected_exhaustion\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\x13projectedExhaustion\x12?\n" +
+	"\x10confirmed_grants\x18\f \x03(\v2\x14.grpc.ConfirmedGrantR\x0fconfirmedGrants\x12C\n" +
+	"\x11refunds_by_reason\x18\r \x03(\v2\x17.grpc.RefundReasonTotalR\x0frefundsByReason\x12-\n" +
+	"\tper_asset\x18\x0e \x03(\v2\x10.grpc.AssetUsageR\bperAssetB\x13\n" +
+	"\x11_utilization_rate*\xb2\x02\n" +
 	"\vMetadataKey\x12\x1c\n" +
 	"\x18METADATA_KEY_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16METADATA_KEY_ASSET_DID\x10\x01\x12!\n" +
 	"\x1dMETADATA_KEY_TRANSACTION_HASH\x10\x02\x12\"\n" +
-	"\x1eMETADATA_KEY_DEVELOPER_LICENSE\x10\x03*\xa2\x01\n" +
+	"\x1eMETADATA_KEY_DEVELOPER_LICENSE\x10\x03\x12\"\n" +
+	"\x1eMETADATA_KEY_AVAILABLE_CREDITS\x10\x04\x12!\n" +
+	"\x1dMETADATA_KEY_REQUIRED_CREDITS\x10\x05\x12!\n" +
+	"\x1dMETADATA_KEY_CREDIT_SHORTFALL\x10\x06\x12\x19\n" +
+	"\x15METADATA_KEY_APP_NAME\x10\a\x12\x1d\n" +
+	"\x19METADATA_KEY_REFERENCE_ID\x10\b*\xed\x01\n" +
 	"\vErrorReason\x12\x1c\n" +
 	"\x18ERROR_REASON_UNSPECIFIED\x10\x00\x12%\n" +
 	"!ERROR_REASON_INSUFFICIENT_CREDITS\x10\x01\x12\"\n" +
 	"\x1eERROR_REASON_INVALID_ASSET_DID\x10\x02\x12*\n" +
-	"&ERROR_REASON_INVALID_DEVELOPER_LICENSE\x10\x03*L\n" +
+	"&ERROR_REASON_INVALID_DEVELOPER_LICENSE\x10\x03\x12#\n" +
+	"\x1fERROR_REASON_INVALID_DATE_RANGE\x10\x04\x12$\n" +
+	" ERROR_REASON_DUPLICATE_OPERATION\x10\x05*L\n" +
 	"\vErrorDomain\x12\x1c\n" +
 	"\x18ERROR_DOMAIN_UNSPECIFIED\x10\x00\x12\x1f\n" +
-	"\x1bERROR_DOMAIN_CREDIT_TRACKER\x10\x012\xa5\x01\n" +
+	"\x1bERROR_DOMAIN_CREDIT_TRACKER\x10\x012\xce\a\n" +
 	"\rCreditTracker\x12H\n" +
 	"\rDeductCredits\x12\x19.grpc.CreditDeductRequest\x1a\x1a.grpc.CreditDeductResponse\"\x00\x12J\n" +
-	"\rRefundCredits\x12\x1a.grpc.RefundCreditsRequest\x1a\x1b.grpc.RefundCreditsResponse\"\x00B1Z/github.com/DIMO-Network/credit-tracker/pkg/grpcb\x06proto3"
+	"\rRefundCredits\x12\x1a.grpc.RefundCreditsRequest\x1a\x1b.grpc.RefundCreditsResponse\"\x00\x12;\n" +
+	"\bSelfTest\x12\x15.grpc.SelfTestRequest\x1a\x16.grpc.SelfTestResponse\"\x00\x12M\n" +
+	"\x0eGetUsageReport\x12\x1b.grpc.GetUsageReportRequest\x1a\x1c.grpc.GetUsageReportResponse\"\x00\x12Y\n" +
+	"\x12BatchDeductCredits\x12\x1f.grpc.BatchDeductCreditsRequest\x1a .grpc.BatchDeductCreditsResponse\"\x00\x12D\n" +
+	"\vGetBalances\x12\x18.grpc.GetBalancesRequest\x1a\x19.grpc.GetBalancesResponse\"\x00\x128\n" +
+	"\aGetDebt\x12\x14.grpc.GetDebtRequest\x1a\x15.grpc.GetDebtResponse\"\x00\x12A\n" +
+	"\n" +
+	"SettleDebt\x12\x17.grpc.SettleDebtRequest\x1a\x18.grpc.SettleDebtResponse\"\x00\x12G\n" +
+	"\fCheckCredits\x12\x19.grpc.CheckCreditsRequest\x1a\x1a.grpc.CheckCreditsResponse\"\x00\x12Y\n" +
+	"\x12GetAccountSnapshot\x12\x1f.grpc.GetAccountSnapshotRequest\x1a .grpc.GetAccountSnapshotResponse\"\x00\x12;\n" +
+	"\bGetGrant\x12\x15.grpc.GetGrantRequest\x1a\x16.grpc.GetGrantResponse\"\x00\x12P\n" +
+	"\x0fAdminAddCredits\x12\x1c.grpc.AdminAddCreditsRequest\x1a\x1d.grpc.AdminAddCreditsResponse\"\x00\x12J\n" +
+	"\x0fWatchOperations\x12\x1c.grpc.WatchOperationsRequest\x1a\x15.grpc.RecentOperation\"\x000\x01B1Z/github.com/DIMO-Network/credit-tracker/pkg/grpcb\x06proto3"
 
 var (
 	file_pkg_grpc_credit_tracker_proto_rawDescOnce sync.Once
@@ -424,26 +2655,107 @@ func file_pkg_grpc_credit_tracker_proto_rawDescGZIP() []byte {
 }
 
 var file_pkg_grpc_credit_tracker_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_pkg_grpc_credit_tracker_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pkg_grpc_credit_tracker_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_pkg_grpc_credit_tracker_proto_goTypes = []any{
-	(MetadataKey)(0),              // 0: grpc.MetadataKey
-	(ErrorReason)(0),              // 1: grpc.ErrorReason
-	(ErrorDomain)(0),              // 2: grpc.ErrorDomain
-	(*CreditDeductRequest)(nil),   // 3: grpc.CreditDeductRequest
-	(*CreditDeductResponse)(nil),  // 4: grpc.CreditDeductResponse
-	(*RefundCreditsRequest)(nil),  // 5: grpc.RefundCreditsRequest
-	(*RefundCreditsResponse)(nil), // 6: grpc.RefundCreditsResponse
+	(MetadataKey)(0),                   // 0: grpc.MetadataKey
+	(ErrorReason)(0),                   // 1: grpc.ErrorReason
+	(ErrorDomain)(0),                   // 2: grpc.ErrorDomain
+	(*CreditDeductRequest)(nil),        // 3: grpc.CreditDeductRequest
+	(*CreditDeductResponse)(nil),       // 4: grpc.CreditDeductResponse
+	(*BatchDeductItem)(nil),            // 5: grpc.BatchDeductItem
+	(*BatchDeductCreditsRequest)(nil),  // 6: grpc.BatchDeductCreditsRequest
+	(*BatchDeductResult)(nil),          // 7: grpc.BatchDeductResult
+	(*BatchDeductCreditsResponse)(nil), // 8: grpc.BatchDeductCreditsResponse
+	(*GetBalancesRequest)(nil),         // 9: grpc.GetBalancesRequest
+	(*AssetBalance)(nil),               // 10: grpc.AssetBalance
+	(*GetBalancesResponse)(nil),        // 11: grpc.GetBalancesResponse
+	(*GetDebtRequest)(nil),             // 12: grpc.GetDebtRequest
+	(*GetDebtResponse)(nil),            // 13: grpc.GetDebtResponse
+	(*SettleDebtRequest)(nil),          // 14: grpc.SettleDebtRequest
+	(*SettleDebtResponse)(nil),         // 15: grpc.SettleDebtResponse
+	(*CheckCreditsRequest)(nil),        // 16: grpc.CheckCreditsRequest
+	(*CheckCreditsResponse)(nil),       // 17: grpc.CheckCreditsResponse
+	(*GetAccountSnapshotRequest)(nil),  // 18: grpc.GetAccountSnapshotRequest
+	(*PendingGrantStats)(nil),          // 19: grpc.PendingGrantStats
+	(*RecentOperation)(nil),            // 20: grpc.RecentOperation
+	(*WatchOperationsRequest)(nil),     // 21: grpc.WatchOperationsRequest
+	(*AssetSnapshot)(nil),              // 22: grpc.AssetSnapshot
+	(*GetAccountSnapshotResponse)(nil), // 23: grpc.GetAccountSnapshotResponse
+	(*GetGrantRequest)(nil),            // 24: grpc.GetGrantRequest
+	(*GetGrantResponse)(nil),           // 25: grpc.GetGrantResponse
+	(*AdminAddCreditsRequest)(nil),     // 26: grpc.AdminAddCreditsRequest
+	(*AdminAddCreditsResponse)(nil),    // 27: grpc.AdminAddCreditsResponse
+	(*RefundCreditsRequest)(nil),       // 28: grpc.RefundCreditsRequest
+	(*RefundCreditsResponse)(nil),      // 29: grpc.RefundCreditsResponse
+	(*SelfTestRequest)(nil),            // 30: grpc.SelfTestRequest
+	(*SelfTestStep)(nil),               // 31: grpc.SelfTestStep
+	(*SelfTestResponse)(nil),           // 32: grpc.SelfTestResponse
+	(*GetUsageReportRequest)(nil),      // 33: grpc.GetUsageReportRequest
+	(*ConfirmedGrant)(nil),             // 34: grpc.ConfirmedGrant
+	(*RefundReasonTotal)(nil),          // 35: grpc.RefundReasonTotal
+	(*AssetUsage)(nil),                 // 36: grpc.AssetUsage
+	(*GetUsageReportResponse)(nil),     // 37: grpc.GetUsageReportResponse
+	nil,                                // 38: grpc.GetBalancesResponse.BalancesEntry
+	(*timestamppb.Timestamp)(nil),      // 39: google.protobuf.Timestamp
 }
 var file_pkg_grpc_credit_tracker_proto_depIdxs = []int32{
-	3, // 0: grpc.CreditTracker.DeductCredits:input_type -> grpc.CreditDeductRequest
-	5, // 1: grpc.CreditTracker.RefundCredits:input_type -> grpc.RefundCreditsRequest
-	4, // 2: grpc.CreditTracker.DeductCredits:output_type -> grpc.CreditDeductResponse
-	6, // 3: grpc.CreditTracker.RefundCredits:output_type -> grpc.RefundCreditsResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	5,  // 0: grpc.BatchDeductCreditsRequest.items:type_name -> grpc.BatchDeductItem
+	1,  // 1: grpc.BatchDeductResult.error_reason:type_name -> grpc.ErrorReason
+	7,  // 2: grpc.BatchDeductCreditsResponse.results:type_name -> grpc.BatchDeductResult
+	38, // 3: grpc.GetBalancesResponse.balances:type_name -> grpc.GetBalancesResponse.BalancesEntry
+	39, // 4: grpc.PendingGrantStats.oldest_created_at:type_name -> google.protobuf.Timestamp
+	39, // 5: grpc.RecentOperation.created_at:type_name -> google.protobuf.Timestamp
+	19, // 6: grpc.AssetSnapshot.pending_grants:type_name -> grpc.PendingGrantStats
+	39, // 7: grpc.AssetSnapshot.next_expiration:type_name -> google.protobuf.Timestamp
+	39, // 8: grpc.GetAccountSnapshotResponse.taken_at:type_name -> google.protobuf.Timestamp
+	19, // 9: grpc.GetAccountSnapshotResponse.pending_grants:type_name -> grpc.PendingGrantStats
+	39, // 10: grpc.GetAccountSnapshotResponse.next_expiration:type_name -> google.protobuf.Timestamp
+	20, // 11: grpc.GetAccountSnapshotResponse.recent_operations:type_name -> grpc.RecentOperation
+	22, // 12: grpc.GetAccountSnapshotResponse.assets:type_name -> grpc.AssetSnapshot
+	39, // 13: grpc.GetGrantResponse.expires_at:type_name -> google.protobuf.Timestamp
+	39, // 14: grpc.GetGrantResponse.created_at:type_name -> google.protobuf.Timestamp
+	31, // 15: grpc.SelfTestResponse.steps:type_name -> grpc.SelfTestStep
+	39, // 16: grpc.GetUsageReportRequest.from_date:type_name -> google.protobuf.Timestamp
+	39, // 17: grpc.GetUsageReportRequest.to_date:type_name -> google.protobuf.Timestamp
+	39, // 18: grpc.ConfirmedGrant.confirmed_at:type_name -> google.protobuf.Timestamp
+	39, // 19: grpc.GetUsageReportResponse.from_date:type_name -> google.protobuf.Timestamp
+	39, // 20: grpc.GetUsageReportResponse.to_date:type_name -> google.protobuf.Timestamp
+	39, // 21: grpc.GetUsageReportResponse.projected_exhaustion:type_name -> google.protobuf.Timestamp
+	34, // 22: grpc.GetUsageReportResponse.confirmed_grants:type_name -> grpc.ConfirmedGrant
+	35, // 23: grpc.GetUsageReportResponse.refunds_by_reason:type_name -> grpc.RefundReasonTotal
+	36, // 24: grpc.GetUsageReportResponse.per_asset:type_name -> grpc.AssetUsage
+	10, // 25: grpc.GetBalancesResponse.BalancesEntry.value:type_name -> grpc.AssetBalance
+	3,  // 26: grpc.CreditTracker.DeductCredits:input_type -> grpc.CreditDeductRequest
+	28, // 27: grpc.CreditTracker.RefundCredits:input_type -> grpc.RefundCreditsRequest
+	30, // 28: grpc.CreditTracker.SelfTest:input_type -> grpc.SelfTestRequest
+	33, // 29: grpc.CreditTracker.GetUsageReport:input_type -> grpc.GetUsageReportRequest
+	6,  // 30: grpc.CreditTracker.BatchDeductCredits:input_type -> grpc.BatchDeductCreditsRequest
+	9,  // 31: grpc.CreditTracker.GetBalances:input_type -> grpc.GetBalancesRequest
+	12, // 32: grpc.CreditTracker.GetDebt:input_type -> grpc.GetDebtRequest
+	14, // 33: grpc.CreditTracker.SettleDebt:input_type -> grpc.SettleDebtRequest
+	16, // 34: grpc.CreditTracker.CheckCredits:input_type -> grpc.CheckCreditsRequest
+	18, // 35: grpc.CreditTracker.GetAccountSnapshot:input_type -> grpc.GetAccountSnapshotRequest
+	24, // 36: grpc.CreditTracker.GetGrant:input_type -> grpc.GetGrantRequest
+	26, // 37: grpc.CreditTracker.AdminAddCredits:input_type -> grpc.AdminAddCreditsRequest
+	21, // 38: grpc.CreditTracker.WatchOperations:input_type -> grpc.WatchOperationsRequest
+	4,  // 39: grpc.CreditTracker.DeductCredits:output_type -> grpc.CreditDeductResponse
+	29, // 40: grpc.CreditTracker.RefundCredits:output_type -> grpc.RefundCreditsResponse
+	32, // 41: grpc.CreditTracker.SelfTest:output_type -> grpc.SelfTestResponse
+	37, // 42: grpc.CreditTracker.GetUsageReport:output_type -> grpc.GetUsageReportResponse
+	8,  // 43: grpc.CreditTracker.BatchDeductCredits:output_type -> grpc.BatchDeductCreditsResponse
+	11, // 44: grpc.CreditTracker.GetBalances:output_type -> grpc.GetBalancesResponse
+	13, // 45: grpc.CreditTracker.GetDebt:output_type -> grpc.GetDebtResponse
+	15, // 46: grpc.CreditTracker.SettleDebt:output_type -> grpc.SettleDebtResponse
+	17, // 47: grpc.CreditTracker.CheckCredits:output_type -> grpc.CheckCreditsResponse
+	23, // 48: grpc.CreditTracker.GetAccountSnapshot:output_type -> grpc.GetAccountSnapshotResponse
+	25, // 49: grpc.CreditTracker.GetGrant:output_type -> grpc.GetGrantResponse
+	27, // 50: grpc.CreditTracker.AdminAddCredits:output_type -> grpc.AdminAddCreditsResponse
+	20, // 51: grpc.CreditTracker.WatchOperations:output_type -> grpc.RecentOperation
+	39, // [39:52] is the sub-list for method output_type
+	26, // [26:39] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_pkg_grpc_credit_tracker_proto_init() }
@@ -451,13 +2763,15 @@ func file_pkg_grpc_credit_tracker_proto_init() {
 	if File_pkg_grpc_credit_tracker_proto != nil {
 		return
 	}
+	file_pkg_grpc_credit_tracker_proto_msgTypes[22].OneofWrappers = []any{}
+	file_pkg_grpc_credit_tracker_proto_msgTypes[34].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_grpc_credit_tracker_proto_rawDesc), len(file_pkg_grpc_credit_tracker_proto_rawDesc)),
 			NumEnums:      3,
-			NumMessages:   4,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,18 +19,58 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	CreditTracker_DeductCredits_FullMethodName = "/grpc.CreditTracker/DeductCredits"
-	CreditTracker_RefundCredits_FullMethodName = "/grpc.CreditTracker/RefundCredits"
+	CreditTracker_DeductCredits_FullMethodName      = "/grpc.CreditTracker/DeductCredits"
+	CreditTracker_RefundCredits_FullMethodName      = "/grpc.CreditTracker/RefundCredits"
+	CreditTracker_SelfTest_FullMethodName           = "/grpc.CreditTracker/SelfTest"
+	CreditTracker_GetUsageReport_FullMethodName     = "/grpc.CreditTracker/GetUsageReport"
+	CreditTracker_BatchDeductCredits_FullMethodName = "/grpc.CreditTracker/BatchDeductCredits"
+	CreditTracker_GetBalances_FullMethodName        = "/grpc.CreditTracker/GetBalances"
+	CreditTracker_GetDebt_FullMethodName            = "/grpc.CreditTracker/GetDebt"
+	CreditTracker_SettleDebt_FullMethodName         = "/grpc.CreditTracker/SettleDebt"
+	CreditTracker_CheckCredits_FullMethodName       = "/grpc.CreditTracker/CheckCredits"
+	CreditTracker_GetAccountSnapshot_FullMethodName = "/grpc.CreditTracker/GetAccountSnapshot"
+	CreditTracker_GetGrant_FullMethodName           = "/grpc.CreditTracker/GetGrant"
+	CreditTracker_AdminAddCredits_FullMethodName    = "/grpc.CreditTracker/AdminAddCredits"
+	CreditTracker_WatchOperations_FullMethodName    = "/grpc.CreditTracker/WatchOperations"
 )
 
 // CreditTrackerClient is the client API for CreditTracker service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CreditTrackerClient interface {
-	// DeductCredits attempts to deduct credits from a given license and token
+	// DeductCredits attempts to deduct credits from a given license and token.
+	// Reusing the app name and reference ID of an earlier deduction fails with ALREADY_EXISTS and charges nothing
 	DeductCredits(ctx context.Context, in *CreditDeductRequest, opts ...grpc.CallOption) (*CreditDeductResponse, error)
-	// RefundCredits refunds credits to a given license and token
+	// RefundCredits refunds credits to a given license and token, refunding a deduction again fails with ALREADY_EXISTS
 	RefundCredits(ctx context.Context, in *RefundCreditsRequest, opts ...grpc.CallOption) (*RefundCreditsResponse, error)
+	// SelfTest is an admin method that creates a grant, deducts, and refunds against a diagnostic license and asset, then cleans up
+	SelfTest(ctx context.Context, in *SelfTestRequest, opts ...grpc.CallOption) (*SelfTestResponse, error)
+	// GetUsageReport returns the usage report of a license, or of a single asset of the license when an asset DID is given
+	GetUsageReport(ctx context.Context, in *GetUsageReportRequest, opts ...grpc.CallOption) (*GetUsageReportResponse, error)
+	// BatchDeductCredits deducts credits from several assets of a license, each item succeeds or fails on its own
+	BatchDeductCredits(ctx context.Context, in *BatchDeductCreditsRequest, opts ...grpc.CallOption) (*BatchDeductCreditsResponse, error)
+	// GetBalances returns the balance of several assets of a license at once
+	GetBalances(ctx context.Context, in *GetBalancesRequest, opts ...grpc.CallOption) (*GetBalancesResponse, error)
+	// GetDebt returns the outstanding debt of a license and asset, deductions are refused until it is settled
+	GetDebt(ctx context.Context, in *GetDebtRequest, opts ...grpc.CallOption) (*GetDebtResponse, error)
+	// SettleDebt settles the outstanding debt of a license and asset with its available credits, as much as they cover
+	SettleDebt(ctx context.Context, in *SettleDebtRequest, opts ...grpc.CallOption) (*SettleDebtResponse, error)
+	// CheckCredits returns whether a deduction of the amount would succeed right now, without deducting anything
+	CheckCredits(ctx context.Context, in *CheckCreditsRequest, opts ...grpc.CallOption) (*CheckCreditsResponse, error)
+	// GetAccountSnapshot returns the balance, debt, pending grants, soonest expiration, and recent operations of a license,
+	// in total and per asset, read at a single point in time
+	GetAccountSnapshot(ctx context.Context, in *GetAccountSnapshotRequest, opts ...grpc.CallOption) (*GetAccountSnapshotResponse, error)
+	// GetGrant returns the grant of a burn transaction, the confirmed grant when a failed pending grant shares its tx hash.
+	// Fails with NOT_FOUND when no grant has the tx hash and FAILED_PRECONDITION when several confirmed grants do
+	GetGrant(ctx context.Context, in *GetGrantRequest, opts ...grpc.CallOption) (*GetGrantResponse, error)
+	// AdminAddCredits grants credits that are not bought with a burn, such as goodwill credits issued by support.
+	// The grant is confirmed right away and settles any outstanding debt. Requires the bearer token of an admin
+	// in the authorization metadata, fails with UNAUTHENTICATED without a valid token and PERMISSION_DENIED for other callers
+	AdminAddCredits(ctx context.Context, in *AdminAddCreditsRequest, opts ...grpc.CallOption) (*AdminAddCreditsResponse, error)
+	// WatchOperations streams the operations of a license: first its most recent operations, oldest first, then each
+	// operation committed by the serving instance while the stream is open. The stream ends with UNAVAILABLE when the
+	// caller falls too far behind, watching again sends the recent operations again to catch up
+	WatchOperations(ctx context.Context, in *WatchOperationsRequest, opts ...grpc.CallOption) (CreditTracker_WatchOperationsClient, error)
 }
 
 type creditTrackerClient struct {
@@ -59,14 +99,165 @@ func (c *creditTrackerClient) RefundCredits(ctx context.Context, in *RefundCredi
 	return out, nil
 }
 
+func (c *creditTrackerClient) SelfTest(ctx context.Context, in *SelfTestRequest, opts ...grpc.CallOption) (*SelfTestResponse, error) {
+	out := new(SelfTestResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_SelfTest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) GetUsageReport(ctx context.Context, in *GetUsageReportRequest, opts ...grpc.CallOption) (*GetUsageReportResponse, error) {
+	out := new(GetUsageReportResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_GetUsageReport_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) BatchDeductCredits(ctx context.Context, in *BatchDeductCreditsRequest, opts ...grpc.CallOption) (*BatchDeductCreditsResponse, error) {
+	out := new(BatchDeductCreditsResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_BatchDeductCredits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) GetBalances(ctx context.Context, in *GetBalancesRequest, opts ...grpc.CallOption) (*GetBalancesResponse, error) {
+	out := new(GetBalancesResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_GetBalances_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) GetDebt(ctx context.Context, in *GetDebtRequest, opts ...grpc.CallOption) (*GetDebtResponse, error) {
+	out := new(GetDebtResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_GetDebt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) SettleDebt(ctx context.Context, in *SettleDebtRequest, opts ...grpc.CallOption) (*SettleDebtResponse, error) {
+	out := new(SettleDebtResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_SettleDebt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) CheckCredits(ctx context.Context, in *CheckCreditsRequest, opts ...grpc.CallOption) (*CheckCreditsResponse, error) {
+	out := new(CheckCreditsResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_CheckCredits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) GetAccountSnapshot(ctx context.Context, in *GetAccountSnapshotRequest, opts ...grpc.CallOption) (*GetAccountSnapshotResponse, error) {
+	out := new(GetAccountSnapshotResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_GetAccountSnapshot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) GetGrant(ctx context.Context, in *GetGrantRequest, opts ...grpc.CallOption) (*GetGrantResponse, error) {
+	out := new(GetGrantResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_GetGrant_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) AdminAddCredits(ctx context.Context, in *AdminAddCreditsRequest, opts ...grpc.CallOption) (*AdminAddCreditsResponse, error) {
+	out := new(AdminAddCreditsResponse)
+	err := c.cc.Invoke(ctx, CreditTracker_AdminAddCredits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditTrackerClient) WatchOperations(ctx context.Context, in *WatchOperationsRequest, opts ...grpc.CallOption) (CreditTracker_WatchOperationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CreditTracker_ServiceDesc.Streams[0], CreditTracker_WatchOperations_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &creditTrackerWatchOperationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CreditTracker_WatchOperationsClient interface {
+	Recv() (*RecentOperation, error)
+	grpc.ClientStream
+}
+
+type creditTrackerWatchOperationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *creditTrackerWatchOperationsClient) Recv() (*RecentOperation, error) {
+	m := new(RecentOperation)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CreditTrackerServer is the server API for CreditTracker service.
 // All implementations must embed UnimplementedCreditTrackerServer
 // for forward compatibility
 type CreditTrackerServer interface {
-	// DeductCredits attempts to deduct credits from a given license and token
+	// DeductCredits attempts to deduct credits from a given license and token.
+	// Reusing the app name and reference ID of an earlier deduction fails with ALREADY_EXISTS and charges nothing
 	DeductCredits(context.Context, *CreditDeductRequest) (*CreditDeductResponse, error)
-	// RefundCredits refunds credits to a given license and token
+	// RefundCredits refunds credits to a given license and token, refunding a deduction again fails with ALREADY_EXISTS
 	RefundCredits(context.Context, *RefundCreditsRequest) (*RefundCreditsResponse, error)
+	// SelfTest is an admin method that creates a grant, deducts, and refunds against a diagnostic license and asset, then cleans up
+	SelfTest(context.Context, *SelfTestRequest) (*SelfTestResponse, error)
+	// GetUsageReport returns the usage report of a license, or of a single asset of the license when an asset DID is given
+	GetUsageReport(context.Context, *GetUsageReportRequest) (*GetUsageReportResponse, error)
+	// BatchDeductCredits deducts credits from several assets of a license, each item succeeds or fails on its own
+	BatchDeductCredits(context.Context, *BatchDeductCreditsRequest) (*BatchDeductCreditsResponse, error)
+	// GetBalances returns the balance of several assets of a license at once
+	GetBalances(context.Context, *GetBalancesRequest) (*GetBalancesResponse, error)
+	// GetDebt returns the outstanding debt of a license and asset, deductions are refused until it is settled
+	GetDebt(context.Context, *GetDebtRequest) (*GetDebtResponse, error)
+	// SettleDebt settles the outstanding debt of a license and asset with its available credits, as much as they cover
+	SettleDebt(context.Context, *SettleDebtRequest) (*SettleDebtResponse, error)
+	// CheckCredits returns whether a deduction of the amount would succeed right now, without deducting anything
+	CheckCredits(context.Context, *CheckCreditsRequest) (*CheckCreditsResponse, error)
+	// GetAccountSnapshot returns the balance, debt, pending grants, soonest expiration, and recent operations of a license,
+	// in total and per asset, read at a single point in time
+	GetAccountSnapshot(context.Context, *GetAccountSnapshotRequest) (*GetAccountSnapshotResponse, error)
+	// GetGrant returns the grant of a burn transaction, the confirmed grant when a failed pending grant shares its tx hash.
+	// Fails with NOT_FOUND when no grant has the tx hash and FAILED_PRECONDITION when several confirmed grants do
+	GetGrant(context.Context, *GetGrantRequest) (*GetGrantResponse, error)
+	// AdminAddCredits grants credits that are not bought with a burn, such as goodwill credits issued by support.
+	// The grant is confirmed right away and settles any outstanding debt. Requires the bearer token of an admin
+	// in the authorization metadata, fails with UNAUTHENTICATED without a valid token and PERMISSION_DENIED for other callers
+	AdminAddCredits(context.Context, *AdminAddCreditsRequest) (*AdminAddCreditsResponse, error)
+	// WatchOperations streams the operations of a license: first its most recent operations, oldest first, then each
+	// operation committed by the serving instance while the stream is open. The stream ends with UNAVAILABLE when the
+	// caller falls too far behind, watching again sends the recent operations again to catch up
+	WatchOperations(*WatchOperationsRequest, CreditTracker_WatchOperationsServer) error
 	mustEmbedUnimplementedCreditTrackerServer()
 }
 
@@ -80,6 +271,39 @@ func (UnimplementedCreditTrackerServer) DeductCredits(context.Context, *CreditDe
 func (UnimplementedCreditTrackerServer) RefundCredits(context.Context, *RefundCreditsRequest) (*RefundCreditsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RefundCredits not implemented")
 }
+func (UnimplementedCreditTrackerServer) SelfTest(context.Context, *SelfTestRequest) (*SelfTestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SelfTest not implemented")
+}
+func (UnimplementedCreditTrackerServer) GetUsageReport(context.Context, *GetUsageReportRequest) (*GetUsageReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsageReport not implemented")
+}
+func (UnimplementedCreditTrackerServer) BatchDeductCredits(context.Context, *BatchDeductCreditsRequest) (*BatchDeductCreditsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeductCredits not implemented")
+}
+func (UnimplementedCreditTrackerServer) GetBalances(context.Context, *GetBalancesRequest) (*GetBalancesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalances not implemented")
+}
+func (UnimplementedCreditTrackerServer) GetDebt(context.Context, *GetDebtRequest) (*GetDebtResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDebt not implemented")
+}
+func (UnimplementedCreditTrackerServer) SettleDebt(context.Context, *SettleDebtRequest) (*SettleDebtResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SettleDebt not implemented")
+}
+func (UnimplementedCreditTrackerServer) CheckCredits(context.Context, *CheckCreditsRequest) (*CheckCreditsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckCredits not implemented")
+}
+func (UnimplementedCreditTrackerServer) GetAccountSnapshot(context.Context, *GetAccountSnapshotRequest) (*GetAccountSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountSnapshot not implemented")
+}
+func (UnimplementedCreditTrackerServer) GetGrant(context.Context, *GetGrantRequest) (*GetGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGrant not implemented")
+}
+func (UnimplementedCreditTrackerServer) AdminAddCredits(context.Context, *AdminAddCreditsRequest) (*AdminAddCreditsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminAddCredits not implemented")
+}
+func (UnimplementedCreditTrackerServer) WatchOperations(*WatchOperationsRequest, CreditTracker_WatchOperationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOperations not implemented")
+}
 func (UnimplementedCreditTrackerServer) mustEmbedUnimplementedCreditTrackerServer() {}
 
 // UnsafeCreditTrackerServer may be embedded to opt out of forward compatibility for this service.
@@ -129,6 +353,207 @@ func _CreditTracker_RefundCredits_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CreditTracker_SelfTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SelfTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).SelfTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_SelfTest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).SelfTest(ctx, req.(*SelfTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_GetUsageReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).GetUsageReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_GetUsageReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).GetUsageReport(ctx, req.(*GetUsageReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_BatchDeductCredits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeductCreditsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).BatchDeductCredits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_BatchDeductCredits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).BatchDeductCredits(ctx, req.(*BatchDeductCreditsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_GetBalances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).GetBalances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_GetBalances_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).GetBalances(ctx, req.(*GetBalancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_GetDebt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDebtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).GetDebt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_GetDebt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).GetDebt(ctx, req.(*GetDebtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_SettleDebt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SettleDebtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).SettleDebt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_SettleDebt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).SettleDebt(ctx, req.(*SettleDebtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_CheckCredits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckCreditsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).CheckCredits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_CheckCredits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).CheckCredits(ctx, req.(*CheckCreditsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_GetAccountSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).GetAccountSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_GetAccountSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).GetAccountSnapshot(ctx, req.(*GetAccountSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_GetGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).GetGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_GetGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).GetGrant(ctx, req.(*GetGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_AdminAddCredits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminAddCreditsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditTrackerServer).AdminAddCredits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditTracker_AdminAddCredits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditTrackerServer).AdminAddCredits(ctx, req.(*AdminAddCreditsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditTracker_WatchOperations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOperationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CreditTrackerServer).WatchOperations(m, &creditTrackerWatchOperationsServer{stream})
+}
+
+type CreditTracker_WatchOperationsServer interface {
+	Send(*RecentOperation) error
+	grpc.ServerStream
+}
+
+type creditTrackerWatchOperationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *creditTrackerWatchOperationsServer) Send(m *RecentOperation) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // CreditTracker_ServiceDesc is the grpc.ServiceDesc for CreditTracker service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -144,7 +569,53 @@ var CreditTracker_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RefundCredits",
 			Handler:    _CreditTracker_RefundCredits_Handler,
 		},
+		{
+			MethodName: "SelfTest",
+			Handler:    _CreditTracker_SelfTest_Handler,
+		},
+		{
+			MethodName: "GetUsageReport",
+			Handler:    _CreditTracker_GetUsageReport_Handler,
+		},
+		{
+			MethodName: "BatchDeductCredits",
+			Handler:    _CreditTracker_BatchDeductCredits_Handler,
+		},
+		{
+			MethodName: "GetBalances",
+			Handler:    _CreditTracker_GetBalances_Handler,
+		},
+		{
+			MethodName: "GetDebt",
+			Handler:    _CreditTracker_GetDebt_Handler,
+		},
+		{
+			MethodName: "SettleDebt",
+			Handler:    _CreditTracker_SettleDebt_Handler,
+		},
+		{
+			MethodName: "CheckCredits",
+			Handler:    _CreditTracker_CheckCredits_Handler,
+		},
+		{
+			MethodName: "GetAccountSnapshot",
+			Handler:    _CreditTracker_GetAccountSnapshot_Handler,
+		},
+		{
+			MethodName: "GetGrant",
+			Handler:    _CreditTracker_GetGrant_Handler,
+		},
+		{
+			MethodName: "AdminAddCredits",
+			Handler:    _CreditTracker_AdminAddCredits_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOperations",
+			Handler:       _CreditTracker_WatchOperations_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/grpc/credit-tracker.proto",
 }
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Cached per-(license, asset) balances so bulk balance lookups do not have to aggregate credit_grants on demand.
+-- Rows are refreshed incrementally by every credit operation and fully by RefreshBalanceSummaries.
+CREATE TABLE credit_balance_summaries (
+    license_id VARCHAR(255) NOT NULL,             -- License identifier: Ethereum address or string ID
+    asset_did VARCHAR(500) NOT NULL,              -- DID string identifying the physical asset/device
+    PRIMARY KEY (license_id, asset_did),
+
+    balance BIGINT NOT NULL DEFAULT 0,            -- Spendable credits from active (confirmed/pending, unexpired) grants
+    debt BIGINT NOT NULL DEFAULT 0,               -- Outstanding debt from failed grants
+    refreshed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP -- When this summary was last recomputed
+);
+
+COMMENT ON TABLE credit_balance_summaries IS 'Cached per license and asset balances, refreshed on every operation and by the balance summary refresh job.';
+COMMENT ON COLUMN credit_balance_summaries.license_id IS 'License identifier: Ethereum address or string ID';
+COMMENT ON COLUMN credit_balance_summaries.asset_did IS 'DID string identifying the physical asset/device';
+COMMENT ON COLUMN credit_balance_summaries.balance IS 'Spendable credits from active (confirmed/pending, unexpired) grants';
+COMMENT ON COLUMN credit_balance_summaries.debt IS 'Outstanding debt from failed grants';
+COMMENT ON COLUMN credit_balance_summaries.refreshed_at IS 'When this summary was last recomputed';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE credit_balance_summaries;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Marks grants whose remaining amount was fully used so they can be filtered and archived efficiently
+ALTER TABLE credit_grants
+    ADD COLUMN depleted_at TIMESTAMPTZ;           -- When the remaining amount reached zero (null while credits remain)
+
+COMMENT ON COLUMN credit_grants.depleted_at IS 'When the remaining amount reached zero (null while credits remain)';
+
+CREATE INDEX idx_credit_grants_depleted
+    ON credit_grants(depleted_at)
+    WHERE depleted_at IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_credit_grants_depleted;
+ALTER TABLE credit_grants DROP COLUMN depleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Operations recorded without per-grant detail rows to reduce write volume for high-volume, low-value deductions
+ALTER TABLE credit_operations
+    ADD COLUMN summary_only BOOLEAN NOT NULL DEFAULT FALSE; -- Whether per-grant detail rows were skipped (refunds are redistributed proportionally)
+
+COMMENT ON COLUMN credit_operations.summary_only IS 'Whether per-grant detail rows were skipped (refunds are redistributed proportionally)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE credit_operations DROP COLUMN summary_only;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Trace ID of the request that created the operation so ledger rows can be joined with logs
+ALTER TABLE credit_operations
+    ADD COLUMN trace_id VARCHAR(64);              -- Trace ID of the originating request (null for internal operations)
+
+COMMENT ON COLUMN credit_operations.trace_id IS 'Trace ID of the originating request (null for internal operations)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE credit_operations DROP COLUMN trace_id;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Spendable balance after the operation so historical balances can be read without replaying the ledger
+ALTER TABLE credit_operations
+    ADD COLUMN balance_after BIGINT;              -- Spendable balance after the operation (null when snapshots are disabled)
+
+COMMENT ON COLUMN credit_operations.balance_after IS 'Spendable balance after the operation (null when snapshots are disabled)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE credit_operations DROP COLUMN balance_after;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Grants that expired with unused credits, the unused credits are recorded as an expiration operation
+ALTER TABLE credit_grants DROP CONSTRAINT credit_grants_status_check;
+ALTER TABLE credit_grants ADD CONSTRAINT credit_grants_status_check
+    CHECK (status IN ('pending', 'confirmed', 'failed', 'expired'));
+ALTER TABLE credit_operations DROP CONSTRAINT credit_operations_operation_type_check;
+ALTER TABLE credit_operations ADD CONSTRAINT credit_operations_operation_type_check
+    CHECK (operation_type IN ('deduction', 'refund', 'grant_purchase', 'grant_confirm', 'debt_settlement', 'expiration'));
+
+COMMENT ON COLUMN credit_grants.status IS 'Transaction state: pending, confirmed, failed, or expired';
+COMMENT ON COLUMN credit_operations.operation_type IS 'Type: deduction (deducts credits), refund (returns credits), grant_purchase (new grant), debt_settlement (settles previous debt), expiration (unused credits of an expired grant)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DELETE FROM credit_operations WHERE operation_type = 'expiration';
+UPDATE credit_grants SET status = 'confirmed' WHERE status = 'expired';
+ALTER TABLE credit_operations DROP CONSTRAINT credit_operations_operation_type_check;
+ALTER TABLE credit_operations ADD CONSTRAINT credit_operations_operation_type_check
+    CHECK (operation_type IN ('deduction', 'refund', 'grant_purchase', 'grant_confirm', 'debt_settlement'));
+ALTER TABLE credit_grants DROP CONSTRAINT credit_grants_status_check;
+ALTER TABLE credit_grants ADD CONSTRAINT credit_grants_status_check
+    CHECK (status IN ('pending', 'confirmed', 'failed'));
+COMMENT ON COLUMN credit_grants.status IS 'Transaction state: pending, confirmed, or failed';
+COMMENT ON COLUMN credit_operations.operation_type IS 'Type: deduction (deducts credits), refund (returns credits), grant_purchase (new grant), debt_settlement (settles previous debt)';
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Perpetual grants never expire, they have no expiration time
+ALTER TABLE credit_grants ALTER COLUMN expires_at DROP NOT NULL;
+
+COMMENT ON COLUMN credit_grants.expires_at IS 'When these credits become unusable (null for perpetual grants that never expire)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+UPDATE credit_grants SET expires_at = '9999-12-31 00:00:00+00' WHERE expires_at IS NULL;
+ALTER TABLE credit_grants ALTER COLUMN expires_at SET NOT NULL;
+COMMENT ON COLUMN credit_grants.expires_at IS 'When these credits become unusable';
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Pending grants that failed, because their burn was not sent or not confirmed in time, are recorded as a grant_failed operation
+ALTER TABLE credit_operations DROP CONSTRAINT credit_operations_operation_type_check;
+ALTER TABLE credit_operations ADD CONSTRAINT credit_operations_operation_type_check
+    CHECK (operation_type IN ('deduction', 'refund', 'grant_purchase', 'grant_confirm', 'debt_settlement', 'expiration', 'grant_failed'));
+
+COMMENT ON COLUMN credit_operations.operation_type IS 'Type: deduction (deducts credits), refund (returns credits), grant_purchase (new grant), debt_settlement (settles previous debt), expiration (unused credits of an expired grant), grant_failed (credits of a pending grant that failed)';
+
+-- Stale pending grant lookup by age
+CREATE INDEX IF NOT EXISTS idx_credit_grants_pending_created_at
+    ON credit_grants(created_at)
+    WHERE status = 'pending';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS idx_credit_grants_pending_created_at;
+DELETE FROM credit_operations WHERE operation_type = 'grant_failed';
+ALTER TABLE credit_operations DROP CONSTRAINT credit_operations_operation_type_check;
+ALTER TABLE credit_operations ADD CONSTRAINT credit_operations_operation_type_check
+    CHECK (operation_type IN ('deduction', 'refund', 'grant_purchase', 'grant_confirm', 'debt_settlement', 'expiration'));
+COMMENT ON COLUMN credit_operations.operation_type IS 'Type: deduction (deducts credits), refund (returns credits), grant_purchase (new grant), debt_settlement (settles previous debt), expiration (unused credits of an expired grant)';
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Reason given for a refund, so refunds can be broken down by why they happened
+ALTER TABLE credit_operations
+    ADD COLUMN reason_code VARCHAR(64); -- Why the credits were refunded (null for other operations and refunds without a reason)
+
+COMMENT ON COLUMN credit_operations.reason_code IS 'Why the credits were refunded (null for other operations and refunds without a reason)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE credit_operations DROP COLUMN reason_code;
+-- +goose StatementEnd
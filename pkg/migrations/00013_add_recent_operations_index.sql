@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Recent operations of a license across all assets (most recent first)
+CREATE INDEX idx_credit_operations_license_created_at
+    ON credit_operations(license_id, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_credit_operations_license_created_at;
+-- +goose StatementEnd
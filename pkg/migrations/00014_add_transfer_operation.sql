@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Credits moved between two assets of a license are recorded as a transfer operation on each asset
+ALTER TABLE credit_operations DROP CONSTRAINT credit_operations_operation_type_check;
+ALTER TABLE credit_operations ADD CONSTRAINT credit_operations_operation_type_check
+    CHECK (operation_type IN ('deduction', 'refund', 'grant_purchase', 'grant_confirm', 'debt_settlement', 'expiration', 'grant_failed', 'transfer'));
+
+COMMENT ON COLUMN credit_operations.operation_type IS 'Type: deduction (deducts credits), refund (returns credits), grant_purchase (new grant), debt_settlement (settles previous debt), expiration (unused credits of an expired grant), grant_failed (credits of a pending grant that failed), transfer (credits moved between assets of a license)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DELETE FROM credit_operation_grants WHERE operation_type = 'transfer';
+DELETE FROM credit_operations WHERE operation_type = 'transfer';
+ALTER TABLE credit_operations DROP CONSTRAINT credit_operations_operation_type_check;
+ALTER TABLE credit_operations ADD CONSTRAINT credit_operations_operation_type_check
+    CHECK (operation_type IN ('deduction', 'refund', 'grant_purchase', 'grant_confirm', 'debt_settlement', 'expiration', 'grant_failed'));
+COMMENT ON COLUMN credit_operations.operation_type IS 'Type: deduction (deducts credits), refund (returns credits), grant_purchase (new grant), debt_settlement (settles previous debt), expiration (unused credits of an expired grant), grant_failed (credits of a pending grant that failed)';
+-- +goose StatementEnd
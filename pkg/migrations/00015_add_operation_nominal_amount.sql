@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Credits of a deduction before its volume discount, total_amount holds the discounted credits
+ALTER TABLE credit_operations
+    ADD COLUMN nominal_amount BIGINT; -- Credits before the volume discount (null for operations without tiering)
+
+COMMENT ON COLUMN credit_operations.nominal_amount IS 'Credits before the volume discount (null for operations without tiering)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE credit_operations DROP COLUMN nominal_amount;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Structured context attached to a grant by its creator
+ALTER TABLE credit_grants
+    ADD COLUMN metadata JSONB; -- Structured context of the grant such as the purchase source or promo code (null when none was given)
+
+COMMENT ON COLUMN credit_grants.metadata IS 'Structured context of the grant such as the purchase source or promo code (null when none was given)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE credit_grants DROP COLUMN metadata;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Credits are bought for a product, deductions only draw from grants of the same product
+ALTER TABLE credit_grants
+    ADD COLUMN credit_type VARCHAR(50) NOT NULL DEFAULT 'default'; -- Product the credits are for (e.g. telemetry, attestation); deductions only draw from grants of their type
+
+ALTER TABLE credit_operations
+    ADD COLUMN credit_type VARCHAR(50) NOT NULL DEFAULT 'default'; -- Product the credits of the operation are for
+
+COMMENT ON COLUMN credit_grants.credit_type IS 'Product the credits are for (e.g. telemetry, attestation); deductions only draw from grants of their type';
+COMMENT ON COLUMN credit_operations.credit_type IS 'Product the credits of the operation are for';
+
+-- FIFO queries filter active grants by credit type
+DROP INDEX idx_credit_grants_active;
+CREATE INDEX idx_credit_grants_active
+    ON credit_grants(license_id, asset_did, credit_type, status, expires_at, remaining_amount)
+    WHERE remaining_amount > 0 AND status IN ('confirmed', 'pending');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_credit_grants_active;
+CREATE INDEX idx_credit_grants_active
+    ON credit_grants(license_id, asset_did, status, expires_at, remaining_amount)
+    WHERE remaining_amount > 0 AND status IN ('confirmed', 'pending');
+ALTER TABLE credit_operations DROP COLUMN credit_type;
+ALTER TABLE credit_grants DROP COLUMN credit_type;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+
+-- Manual grants record why the credits were granted in the reason code of their grant_confirm operation
+COMMENT ON COLUMN credit_operations.reason_code IS 'Why the credits were refunded or manually granted (null for other operations and refunds without a reason)';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+COMMENT ON COLUMN credit_operations.reason_code IS 'Why the credits were refunded (null for other operations and refunds without a reason)';
+-- +goose StatementEnd
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"strconv"
@@ -17,9 +18,14 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type TestServer struct {
@@ -32,9 +38,12 @@ type TestServer struct {
 
 func setupTestServer(t *testing.T) *TestServer {
 	// Create test settings
-	settings := &config.Settings{
+	return setupTestServerWithSettings(t, &config.Settings{
 		GRPCPort: 0, // Let the OS choose an available port
-	}
+	})
+}
+
+func setupTestServerWithSettings(t *testing.T, settings *config.Settings) *TestServer {
 	authServer := setupAuthServer(t)
 	authServer.TeardownIfLastTest(t)
 	settings.JWKKeySetURL = authServer.URL() + "/keys"
@@ -44,7 +53,7 @@ func setupTestServer(t *testing.T) *TestServer {
 	settings.DB = db.Settings
 
 	// Create servers
-	app, rpcServer, err := app.CreateServers(t.Context(), settings)
+	app, rpcServer, _, _, err := app.CreateServers(t.Context(), settings)
 	require.NoError(t, err)
 
 	// Start server on random port
@@ -101,8 +110,9 @@ func TestCreditTrackerEndToEnd(t *testing.T) {
 			Amount:           10,
 		}
 
-		_, err := client.DeductCredits(ctx, req)
+		resp, err := client.DeductCredits(ctx, req)
 		require.NoError(t, err)
+		assert.GreaterOrEqual(t, resp.GetRemainingBalance(), int64(0))
 	})
 }
 
@@ -125,3 +135,115 @@ func TestCreditTrackerBasicAuth(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, fiber.StatusOK, resp.StatusCode, string(body))
 }
+
+func TestReportRateLimit(t *testing.T) {
+	t.Parallel()
+	// Set up test server with a report limit of 2 requests per license
+	server := setupTestServerWithSettings(t, &config.Settings{
+		ReportRateLimit:       2,
+		ReportRateLimitWindow: time.Minute,
+	})
+
+	devAddress := common.HexToAddress("0x1234567890123456789012345678901234567891")
+	token, err := server.authServer.CreateToken(t, devAddress)
+	require.NoError(t, err)
+
+	sendReportRequest := func() *http.Response {
+		req := httptest.NewRequestWithContext(t.Context(), "GET", "/v1/credits/"+devAddress.String()+"/usage?fromDate=2025-01-01T00:00:00Z", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := server.app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	for range 2 {
+		resp := sendReportRequest()
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+
+	resp := sendReportRequest()
+	require.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	retryAfter, err := strconv.Atoi(resp.Header.Get(fiber.HeaderRetryAfter))
+	require.NoError(t, err)
+	require.Positive(t, retryAfter)
+	require.LessOrEqual(t, retryAfter, 60)
+}
+
+func TestGetUsageReport(t *testing.T) {
+	t.Parallel()
+	// Set up test server
+	server := setupTestServer(t)
+
+	// Connect to the server
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.NewClient("localhost:"+server.rpcPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+
+	client := ctgrpc.NewCreditTrackerClient(conn)
+	licenseID := "test-license-usage-report"
+	assetDID := "did:erc721:80002:0x45fbCD3ef7361d156e8b16F5538AE36DEdf61Da8:124"
+	fromDate := timestamppb.New(time.Now().Add(-time.Hour))
+
+	_, err = client.DeductCredits(ctx, &ctgrpc.CreditDeductRequest{
+		AssetDid:         assetDID,
+		DeveloperLicense: licenseID,
+		Amount:           10,
+		ReferenceId:      "usage-report-deduction",
+		AppName:          "e2e",
+	})
+	require.NoError(t, err)
+
+	t.Run("license report", func(t *testing.T) {
+		resp, err := client.GetUsageReport(ctx, &ctgrpc.GetUsageReportRequest{
+			DeveloperLicense: licenseID,
+			FromDate:         fromDate,
+		})
+		require.NoError(t, err)
+		require.Equal(t, licenseID, resp.LicenseId)
+		require.Empty(t, resp.AssetDid)
+		require.Equal(t, int64(1), resp.NumOfAssets)
+		require.Equal(t, int64(10), resp.NumOfCreditsUsed)
+	})
+
+	t.Run("asset report", func(t *testing.T) {
+		resp, err := client.GetUsageReport(ctx, &ctgrpc.GetUsageReportRequest{
+			DeveloperLicense:       licenseID,
+			AssetDid:               assetDID,
+			FromDate:               fromDate,
+			ToDate:                 timestamppb.Now(),
+			IncludeConfirmedGrants: true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, assetDID, resp.AssetDid)
+		require.Equal(t, int64(10), resp.NumOfCreditsUsed)
+		require.Equal(t, resp.NumOfCreditsGranted-10, resp.CurrentCreditsRemaining)
+	})
+
+	t.Run("missing developer license", func(t *testing.T) {
+		_, err := client.GetUsageReport(ctx, &ctgrpc.GetUsageReportRequest{
+			FromDate: fromDate,
+		})
+		requireInvalidArgument(t, err, ctgrpc.ErrorReason_ERROR_REASON_INVALID_DEVELOPER_LICENSE)
+	})
+
+	t.Run("missing fromDate", func(t *testing.T) {
+		_, err := client.GetUsageReport(ctx, &ctgrpc.GetUsageReportRequest{
+			DeveloperLicense: licenseID,
+		})
+		requireInvalidArgument(t, err, ctgrpc.ErrorReason_ERROR_REASON_INVALID_DATE_RANGE)
+	})
+}
+
+// requireInvalidArgument asserts the error is an InvalidArgument status with the given reason in its error info.
+func requireInvalidArgument(t *testing.T, err error, reason ctgrpc.ErrorReason) {
+	t.Helper()
+	grpcStatus, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, grpcStatus.Code())
+	require.Len(t, grpcStatus.Details(), 1)
+	errorInfo, ok := grpcStatus.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	require.Equal(t, reason.String(), errorInfo.Reason)
+}